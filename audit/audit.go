@@ -0,0 +1,71 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the changes made through the admin API, and writes them to a Sink
+// such as a file or syslog, from where they can be shipped to a durable store.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Record describes an admin RPC that changes trees. Each change is recorded twice: once
+// before it's made, with an empty Status, and once after, with its outcome.
+type Record struct {
+	// Time is when the record was created.
+	Time time.Time `json:"time"`
+	// Method is the full name of the RPC.
+	Method string `json:"method"`
+	// Peer is the address of the client, if known.
+	Peer string `json:"peer,omitempty"`
+	// Request is the RPC request, as JSON, with any private keys removed.
+	Request json.RawMessage `json:"request,omitempty"`
+	// Status is the code the RPC returned, empty before it returns.
+	Status string `json:"status,omitempty"`
+	// Error is the error the RPC returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Sink stores Records. Write must only return once the record is stored, so that a change is
+// never made without a record of it.
+type Sink interface {
+	// Write stores r.
+	Write(r *Record) error
+	// Close releases the resources held by the sink.
+	Close() error
+}
+
+// NewSink creates a Sink from a spec of the form "<kind>:<arg>":
+//
+//   - "file:<path>" appends to the file at path, see NewFileSink. maxFileBytes is passed on
+//     as the size at which the file is rotated.
+//   - "syslog:<tag>" writes to the local syslog daemon, with the given tag, see
+//     NewSyslogSink.
+func NewSink(spec string, maxFileBytes int64) (Sink, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("audit sink %q, want <kind>:<arg>", spec)
+	}
+	switch kind, arg := parts[0], parts[1]; kind {
+	case "file":
+		return NewFileSink(arg, maxFileBytes)
+	case "syslog":
+		return NewSyslogSink(arg)
+	default:
+		return nil, fmt.Errorf("unknown audit sink kind %q, want file or syslog", kind)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// rotatedSuffixFormat is the time format of the suffix added to the names of rotated files.
+const rotatedSuffixFormat = "20060102T150405.000000000"
+
+// FileSink appends Records to a file as JSON, one per line. Each record is synced to disk
+// before Write returns.
+type FileSink struct {
+	path     string
+	maxBytes int64
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewFileSink creates a FileSink appending to the file at path. Once the file reaches
+// maxBytes it's renamed, with the time of the rotation appended to its name, and a new file
+// is started. Rotated files are never deleted. Zero maxBytes means the file isn't rotated.
+func NewFileSink(path string, maxBytes int64) (*FileSink, error) {
+	s := &FileSink{path: path, maxBytes: maxBytes}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, fi.Size()
+	return nil
+}
+
+// rotate closes the current file, renames it and opens a new one.
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	s.f = nil
+	rotated := fmt.Sprintf("%s.%s", s.path, time.Now().UTC().Format(rotatedSuffixFormat))
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		// A previous rotation failed after closing the file.
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate audit file %v: %v", s.path, err)
+		}
+	}
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+// Close implements Sink.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
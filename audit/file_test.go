@@ -0,0 +1,104 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %v: %v", path, err)
+	}
+	defer f.Close()
+	var records []Record
+	for s := bufio.NewScanner(f); s.Scan(); {
+		var r Record
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatalf("Failed to unmarshal record %q: %v", s.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	r := &Record{Time: time.Unix(1500000000, 0).UTC(), Method: "/trillian.TrillianAdmin/DeleteTree", Request: json.RawMessage(`{"tree_id":"1"}`)}
+	line, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Failed to marshal record: %v", err)
+	}
+	// Room for two records per file.
+	maxBytes := int64(2*(len(line)+1) + 1)
+
+	s, err := NewFileSink(path, maxBytes)
+	if err != nil {
+		t.Fatalf("NewFileSink() returned err = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Write(r); err != nil {
+			t.Fatalf("Write() returned err = %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() returned err = %v", err)
+	}
+
+	// Reopening appends, until the file is full.
+	if s, err = NewFileSink(path, maxBytes); err != nil {
+		t.Fatalf("NewFileSink() returned err = %v", err)
+	}
+	defer s.Close()
+	if err := s.Write(r); err != nil {
+		t.Fatalf("Write() returned err = %v", err)
+	}
+
+	if got, want := len(readRecords(t, path)), 1; got != want {
+		t.Errorf("%v holds %v records, want %v", path, got, want)
+	}
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil || len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, %v, want 1 file", rotated, err)
+	}
+	records := readRecords(t, rotated[0])
+	if got, want := len(records), 2; got != want {
+		t.Fatalf("%v holds %v records, want %v", rotated[0], got, want)
+	}
+	if got := records[0]; got.Method != r.Method || !got.Time.Equal(r.Time) || string(got.Request) != string(r.Request) {
+		t.Errorf("record = %+v, want %+v", got, r)
+	}
+}
+
+func TestNewSinkErrors(t *testing.T) {
+	for _, spec := range []string{"", "file", "file:", "kafka:audit", "/var/log/audit.log"} {
+		if _, err := NewSink(spec, 0); err == nil {
+			t.Errorf("NewSink(%q) returned err = nil, want err", spec)
+		}
+	}
+}
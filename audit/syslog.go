@@ -0,0 +1,49 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink writes Records to the local syslog daemon as JSON, with the AUTHPRIV facility
+// and INFO severity.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink creates a SyslogSink writing messages with the given tag.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	w, err := syslog.New(syslog.LOG_AUTHPRIV|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{w: w}, nil
+}
+
+// Write implements Sink.
+func (s *SyslogSink) Write(r *Record) error {
+	msg, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(msg))
+}
+
+// Close implements Sink.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
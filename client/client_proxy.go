@@ -51,6 +51,11 @@ func (c *MockLogClient) GetInclusionProof(ctx context.Context, in *trillian.GetI
 	return resp, err
 }
 
+// GetInclusionProofWithRoot forwards requests.
+func (c *MockLogClient) GetInclusionProofWithRoot(ctx context.Context, in *trillian.GetInclusionProofWithRootRequest, opts ...grpc.CallOption) (*trillian.GetInclusionProofWithRootResponse, error) {
+	return c.c.GetInclusionProofWithRoot(ctx, in)
+}
+
 // GetInclusionProofByHash forwards requests and optionaly corrupts responses.
 func (c *MockLogClient) GetInclusionProofByHash(ctx context.Context, in *trillian.GetInclusionProofByHashRequest, opts ...grpc.CallOption) (*trillian.GetInclusionProofByHashResponse, error) {
 	resp, err := c.c.GetInclusionProofByHash(ctx, in)
@@ -88,11 +93,66 @@ func (c *MockLogClient) GetConsistencyProof(ctx context.Context, in *trillian.Ge
 	return resp, nil
 }
 
+// GetProofByMerkleHash forwards requests.
+func (c *MockLogClient) GetProofByMerkleHash(ctx context.Context, in *trillian.GetProofByMerkleHashRequest, opts ...grpc.CallOption) (*trillian.GetProofByMerkleHashResponse, error) {
+	return c.c.GetProofByMerkleHash(ctx, in)
+}
+
 // GetLatestSignedLogRoot forwards requests.
 func (c *MockLogClient) GetLatestSignedLogRoot(ctx context.Context, in *trillian.GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*trillian.GetLatestSignedLogRootResponse, error) {
 	return c.c.GetLatestSignedLogRoot(ctx, in)
 }
 
+// GetLatestCheckpoint forwards requests.
+func (c *MockLogClient) GetLatestCheckpoint(ctx context.Context, in *trillian.GetLatestCheckpointRequest, opts ...grpc.CallOption) (*trillian.GetLatestCheckpointResponse, error) {
+	return c.c.GetLatestCheckpoint(ctx, in)
+}
+
+// HasLeaves forwards requests.
+func (c *MockLogClient) HasLeaves(ctx context.Context, in *trillian.HasLeavesRequest, opts ...grpc.CallOption) (*trillian.HasLeavesResponse, error) {
+	return c.c.HasLeaves(ctx, in)
+}
+
+// GetLatestLeafByIdentityHashPrefix forwards requests.
+func (c *MockLogClient) GetLatestLeafByIdentityHashPrefix(ctx context.Context, in *trillian.GetLatestLeafByIdentityHashPrefixRequest, opts ...grpc.CallOption) (*trillian.GetLatestLeafByIdentityHashPrefixResponse, error) {
+	return c.c.GetLatestLeafByIdentityHashPrefix(ctx, in)
+}
+
+// CheckConsistencyProof forwards requests.
+func (c *MockLogClient) CheckConsistencyProof(ctx context.Context, in *trillian.CheckConsistencyProofRequest, opts ...grpc.CallOption) (*trillian.CheckConsistencyProofResponse, error) {
+	return c.c.CheckConsistencyProof(ctx, in)
+}
+
+// CountLeaves forwards requests.
+func (c *MockLogClient) CountLeaves(ctx context.Context, in *trillian.CountLeavesRequest, opts ...grpc.CallOption) (*trillian.CountLeavesResponse, error) {
+	return c.c.CountLeaves(ctx, in)
+}
+
+// GetEntries forwards requests.
+func (c *MockLogClient) GetEntries(ctx context.Context, in *trillian.GetEntriesRequest, opts ...grpc.CallOption) (trillian.TrillianLog_GetEntriesClient, error) {
+	return c.c.GetEntries(ctx, in)
+}
+
+// GetSignedLogRootHistory forwards requests.
+func (c *MockLogClient) GetSignedLogRootHistory(ctx context.Context, in *trillian.GetSignedLogRootHistoryRequest, opts ...grpc.CallOption) (trillian.TrillianLog_GetSignedLogRootHistoryClient, error) {
+	return c.c.GetSignedLogRootHistory(ctx, in)
+}
+
+// AddCosignature forwards requests.
+func (c *MockLogClient) AddCosignature(ctx context.Context, in *trillian.AddCosignatureRequest, opts ...grpc.CallOption) (*trillian.AddCosignatureResponse, error) {
+	return c.c.AddCosignature(ctx, in)
+}
+
+// GetLatestCosignedLogRoot forwards requests.
+func (c *MockLogClient) GetLatestCosignedLogRoot(ctx context.Context, in *trillian.GetLatestCosignedLogRootRequest, opts ...grpc.CallOption) (*trillian.GetLatestCosignedLogRootResponse, error) {
+	return c.c.GetLatestCosignedLogRoot(ctx, in)
+}
+
+// GetSignedLogRootAtTime forwards requests.
+func (c *MockLogClient) GetSignedLogRootAtTime(ctx context.Context, in *trillian.GetSignedLogRootAtTimeRequest, opts ...grpc.CallOption) (*trillian.GetSignedLogRootAtTimeResponse, error) {
+	return c.c.GetSignedLogRootAtTime(ctx, in)
+}
+
 // GetSequencedLeafCount forwards requests.
 func (c *MockLogClient) GetSequencedLeafCount(ctx context.Context, in *trillian.GetSequencedLeafCountRequest, opts ...grpc.CallOption) (*trillian.GetSequencedLeafCountResponse, error) {
 	return c.c.GetSequencedLeafCount(ctx, in)
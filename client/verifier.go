@@ -46,8 +46,7 @@ func (c *logVerifier) VerifyRoot(trusted, newRoot *trillian.SignedLogRoot,
 	consistency [][]byte) error {
 
 	// Verify SignedLogRoot signature.
-	hash := tcrypto.HashLogRoot(*newRoot)
-	if err := tcrypto.Verify(c.pubKey, hash, newRoot.Signature); err != nil {
+	if err := tcrypto.VerifyLogRoot(c.pubKey, *newRoot); err != nil {
 		return err
 	}
 
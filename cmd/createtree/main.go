@@ -64,6 +64,13 @@ var (
 	displayName        = flag.String("display_name", "", "Display name of the new tree")
 	description        = flag.String("description", "", "Description of the new tree")
 	maxRootDuration    = flag.Duration("max_root_duration", 0, "Interval after which a new signed root is produced despite no submissions; zero means never")
+	maxClientTSSkew    = flag.Duration("max_client_timestamp_skew", 0, "Maximum skew allowed for client-supplied leaf queue timestamps; zero means they're not accepted")
+	drainGracePeriod   = flag.Duration("drain_grace_period", 0, "Period during which the new tree keeps accepting writes once it's DRAINING; zero means writes are rejected as soon as it's DRAINING")
+	mapLeafHashing     = flag.String("map_leaf_hashing", trillian.MapLeafHashing_SERVER_HASHED_LEAVES.String(), "Whether leaf hashes of the new map are computed by the server or by clients")
+	checkpointOrigin   = flag.String("checkpoint_origin", "", "Origin of the new log, unique among the server's trees and identifying it in checkpoints and metrics; empty means the tree ID. Can't be changed later")
+	rootMetadataHook   = flag.String("root_metadata_hook", "", "Name of the hook, registered with the log signer, supplying metadata for each signed root of the new log; empty means no metadata")
+	deadLetterAttempts = flag.Int("dead_letter_attempts", 0, "Number of sequencing passes a queued leaf of the new log may fail before it's dead-lettered; zero means leaves are never dead-lettered")
+	revisionLookback   = flag.Int64("max_revision_lookback", 0, "Number of revisions before the latest one that remain readable in the new map; zero means all revisions are readable")
 
 	privateKeyFormat = flag.String("private_key_format", "PrivateKey", "Type of private key to be used (PrivateKey, PEMKeyFile, or PKCS11ConfigFile)")
 	pemKeyPath       = flag.String("pem_key_path", "", "Path to the private key PEM file")
@@ -78,7 +85,10 @@ var (
 type createOpts struct {
 	addr                                                                                     string
 	treeState, treeType, hashStrategy, hashAlgorithm, sigAlgorithm, displayName, description string
-	maxRootDuration                                                                          time.Duration
+	mapLeafHashing, rootMetadataHook, checkpointOrigin                                       string
+	maxRootDuration, maxClientTimestampSkew, drainGracePeriod                                time.Duration
+	deadLetterAttempts                                                                       int
+	maxRevisionLookback                                                                      int64
 	privateKeyType, pemKeyPath, pemKeyPass, pkcs11ConfigPath                                 string
 }
 
@@ -131,6 +141,11 @@ func newRequest(opts *createOpts) (*trillian.CreateTreeRequest, error) {
 		return nil, fmt.Errorf("unknown SignatureAlgorithm: %v", opts.sigAlgorithm)
 	}
 
+	mlh, ok := trillian.MapLeafHashing_value[opts.mapLeafHashing]
+	if !ok {
+		return nil, fmt.Errorf("unknown MapLeafHashing: %v", opts.mapLeafHashing)
+	}
+
 	pk, err := newPK(opts)
 	if err != nil {
 		return nil, err
@@ -146,7 +161,20 @@ func newRequest(opts *createOpts) (*trillian.CreateTreeRequest, error) {
 		Description:        opts.description,
 		PrivateKey:         pk,
 		MaxRootDuration:    ptypes.DurationProto(opts.maxRootDuration),
+		MapLeafHashing:     trillian.MapLeafHashing(mlh),
+		RootMetadataHook:   opts.rootMetadataHook,
+		CheckpointOrigin:   opts.checkpointOrigin,
 	}}
+	if opts.maxClientTimestampSkew != 0 {
+		ctr.Tree.MaxClientTimestampSkew = ptypes.DurationProto(opts.maxClientTimestampSkew)
+	}
+	if opts.drainGracePeriod != 0 {
+		ctr.Tree.DrainGracePeriod = ptypes.DurationProto(opts.drainGracePeriod)
+	}
+	if opts.deadLetterAttempts != 0 {
+		ctr.Tree.DeadLetterPolicy = &trillian.DeadLetterPolicy{MaxAttempts: int32(opts.deadLetterAttempts)}
+	}
+	ctr.Tree.MaxRevisionLookback = opts.maxRevisionLookback
 	return ctr, nil
 }
 
@@ -198,6 +226,7 @@ func newPK(opts *createOpts) (*any.Any, error) {
 			TokenLabel: config.TokenLabel,
 			Pin:        config.PIN,
 			PublicKey:  string(pubKeyBytes),
+			ModulePath: config.Module,
 		})
 	default:
 		return nil, fmt.Errorf("unknown private key type: %v", opts.privateKeyType)
@@ -206,19 +235,26 @@ func newPK(opts *createOpts) (*any.Any, error) {
 
 func newOptsFromFlags() *createOpts {
 	return &createOpts{
-		addr:             *adminServerAddr,
-		treeState:        *treeState,
-		treeType:         *treeType,
-		hashStrategy:     *hashStrategy,
-		hashAlgorithm:    *hashAlgorithm,
-		sigAlgorithm:     *signatureAlgorithm,
-		displayName:      *displayName,
-		description:      *description,
-		maxRootDuration:  *maxRootDuration,
-		privateKeyType:   *privateKeyFormat,
-		pemKeyPath:       *pemKeyPath,
-		pemKeyPass:       *pemKeyPassword,
-		pkcs11ConfigPath: *pkcs11ConfigPath,
+		addr:                   *adminServerAddr,
+		treeState:              *treeState,
+		treeType:               *treeType,
+		hashStrategy:           *hashStrategy,
+		hashAlgorithm:          *hashAlgorithm,
+		sigAlgorithm:           *signatureAlgorithm,
+		displayName:            *displayName,
+		description:            *description,
+		mapLeafHashing:         *mapLeafHashing,
+		rootMetadataHook:       *rootMetadataHook,
+		checkpointOrigin:       *checkpointOrigin,
+		maxRootDuration:        *maxRootDuration,
+		maxClientTimestampSkew: *maxClientTSSkew,
+		drainGracePeriod:       *drainGracePeriod,
+		deadLetterAttempts:     *deadLetterAttempts,
+		maxRevisionLookback:    *revisionLookback,
+		privateKeyType:         *privateKeyFormat,
+		pemKeyPath:             *pemKeyPath,
+		pemKeyPass:             *pemKeyPassword,
+		pkcs11ConfigPath:       *pkcs11ConfigPath,
 	}
 }
 
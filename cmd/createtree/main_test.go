@@ -89,12 +89,14 @@ func TestRun(t *testing.T) {
 	nonDefaultTree.SignatureAlgorithm = sigpb.DigitallySigned_ECDSA
 	nonDefaultTree.DisplayName = "Llamas Map"
 	nonDefaultTree.Description = "For all your digital llama needs!"
+	nonDefaultTree.MapLeafHashing = trillian.MapLeafHashing_CLIENT_HASHED_LEAVES
 
 	nonDefaultOpts := *validOpts
 	nonDefaultOpts.treeType = nonDefaultTree.TreeType.String()
 	nonDefaultOpts.sigAlgorithm = nonDefaultTree.SignatureAlgorithm.String()
 	nonDefaultOpts.displayName = nonDefaultTree.DisplayName
 	nonDefaultOpts.description = nonDefaultTree.Description
+	nonDefaultOpts.mapLeafHashing = nonDefaultTree.MapLeafHashing.String()
 
 	emptyAddr := *validOpts
 	emptyAddr.addr = ""
@@ -235,6 +237,62 @@ func (s *fakeAdminServer) UpdateTree(context.Context, *trillian.UpdateTreeReques
 	return nil, errUnimplemented
 }
 
+func (s *fakeAdminServer) BatchUpdateTrees(context.Context, *trillian.BatchUpdateTreesRequest) (*trillian.BatchUpdateTreesResponse, error) {
+	return nil, errUnimplemented
+}
+
 func (s *fakeAdminServer) DeleteTree(context.Context, *trillian.DeleteTreeRequest) (*empty.Empty, error) {
 	return nil, errUnimplemented
 }
+
+func (s *fakeAdminServer) RepairTreeRoot(context.Context, *trillian.RepairTreeRootRequest) (*trillian.RepairTreeRootResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) GetTreeFootprint(context.Context, *trillian.GetTreeFootprintRequest) (*trillian.GetTreeFootprintResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) ListDeadLetteredLeaves(context.Context, *trillian.ListDeadLetteredLeavesRequest) (*trillian.ListDeadLetteredLeavesResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) RequeueDeadLetteredLeaves(context.Context, *trillian.RequeueDeadLetteredLeavesRequest) (*trillian.RequeueDeadLetteredLeavesResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) GetQuotaTokens(context.Context, *trillian.GetQuotaTokensRequest) (*trillian.GetQuotaTokensResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) ListPendingTrees(context.Context, *trillian.ListPendingTreesRequest) (*trillian.ListPendingTreesResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) CreateQuotaConfig(context.Context, *trillian.CreateQuotaConfigRequest) (*trillian.QuotaConfig, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) UpdateQuotaConfig(context.Context, *trillian.UpdateQuotaConfigRequest) (*trillian.QuotaConfig, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) DeleteQuotaConfig(context.Context, *trillian.DeleteQuotaConfigRequest) (*empty.Empty, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) ListQuotaConfigs(context.Context, *trillian.ListQuotaConfigsRequest) (*trillian.ListQuotaConfigsResponse, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) UndeleteTree(context.Context, *trillian.UndeleteTreeRequest) (*trillian.Tree, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) RotateTreeKey(context.Context, *trillian.RotateTreeKeyRequest) (*trillian.Tree, error) {
+	return nil, errUnimplemented
+}
+
+func (s *fakeAdminServer) RetireTreeKey(context.Context, *trillian.RetireTreeKeyRequest) (*trillian.Tree, error) {
+	return nil, errUnimplemented
+}
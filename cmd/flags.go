@@ -15,23 +15,38 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"bitbucket.org/creachadair/shell"
+	"github.com/golang/glog"
 )
 
-func parseFlags(file string) error {
+// splitFlags splits the contents of a flag file into arguments.
+func splitFlags(file string) ([]string, error) {
 	args, valid := shell.Split(file)
 	if !valid {
-		return errors.New("flag file contains unclosed quotations")
+		return nil, errors.New("flag file contains unclosed quotations")
 	}
 	// Expand any environment variables in the args
 	for i := range args {
 		args[i] = os.ExpandEnv(args[i])
 	}
+	return args, nil
+}
+
+func parseFlags(file string) error {
+	args, err := splitFlags(file)
+	if err != nil {
+		return err
+	}
 
 	if err := flag.CommandLine.Parse(args); err != nil {
 		return err
@@ -54,3 +69,212 @@ func ParseFlagFile(path string) error {
 	}
 	return parseFlags(string(file))
 }
+
+// Reloadable is a flag that FlagFileReloader changes when the flag file is reloaded.
+type Reloadable struct {
+	// Name is the name of the flag.
+	Name string
+	// Check, if set, validates a new value of the flag, beyond it being parsed fine.
+	Check func(value string) error
+	// Apply, if set, is called once the flag has been set to a new value, to put it
+	// into effect.
+	Apply func()
+}
+
+// FlagFileReloader reloads a designated set of flags from a flag file, leaving
+// the others as they were at startup. Flags given on the command line, or
+// otherwise set over the file's values at startup, keep taking precedence over
+// the file.
+type FlagFileReloader struct {
+	path  string
+	flags *flag.FlagSet
+	// fixed holds the reloadable flags that are set by something taking
+	// precedence over the file, so aren't reloaded.
+	fixed      map[string]bool
+	reloadable []Reloadable
+	mu         sync.Mutex
+}
+
+// NewFlagFileReloader returns a FlagFileReloader of the flag file at path, for
+// flags given on the command line and in the file to flag.Parse and
+// ParseFlagFile at startup.
+func NewFlagFileReloader(path string, reloadable ...Reloadable) (*FlagFileReloader, error) {
+	return newFlagFileReloader(flag.CommandLine, os.Args[1:], path, reloadable...)
+}
+
+func newFlagFileReloader(flags *flag.FlagSet, args []string, path string, reloadable ...Reloadable) (*FlagFileReloader, error) {
+	for _, rl := range reloadable {
+		if flags.Lookup(rl.Name) == nil {
+			return nil, fmt.Errorf("flag provided but not defined: -%v", rl.Name)
+		}
+	}
+	r := &FlagFileReloader{path: path, flags: flags, fixed: make(map[string]bool), reloadable: reloadable}
+	cmdLine, err := readFlags(flags, args)
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.readFile()
+	if err != nil {
+		return nil, err
+	}
+	for _, rl := range reloadable {
+		f := flags.Lookup(rl.Name)
+		if _, ok := cmdLine[rl.Name]; ok {
+			r.fixed[rl.Name] = true
+			continue
+		}
+		// A flag whose value comes from neither the file nor its default was
+		// set by something else, e.g. a ServerConfig applied after the file.
+		value, ok := values[rl.Name]
+		if !ok {
+			value = f.DefValue
+		}
+		if v, err := canonical(f, value); err != nil || v != f.Value.String() {
+			r.fixed[rl.Name] = true
+		}
+	}
+	return r, nil
+}
+
+// readFile returns the unparsed values of the flags set in the file.
+func (r *FlagFileReloader) readFile() (map[string]string, error) {
+	file, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	args, err := splitFlags(string(file))
+	if err != nil {
+		return nil, err
+	}
+	return readFlags(r.flags, args)
+}
+
+// Reload reads the flag file again and sets the reloadable flags to their new
+// values, or to their defaults if they're no longer in the file. Changes are
+// all-or-nothing: if the file can't be parsed, or any new value is invalid,
+// none of them are applied.
+func (r *FlagFileReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values, err := r.readFile()
+	if err != nil {
+		return err
+	}
+
+	// Work out and validate the changes before setting any flag.
+	var changed []Reloadable
+	newValues := make(map[string]string)
+	for _, rl := range r.reloadable {
+		if r.fixed[rl.Name] {
+			continue
+		}
+		f := r.flags.Lookup(rl.Name)
+		value, ok := values[rl.Name]
+		if !ok {
+			value = f.DefValue
+		}
+		if value == f.Value.String() {
+			continue
+		}
+		if rl.Check != nil {
+			if err := rl.Check(value); err != nil {
+				return fmt.Errorf("invalid value %q for flag -%v: %v", value, rl.Name, err)
+			}
+		}
+		changed = append(changed, rl)
+		newValues[rl.Name] = value
+	}
+
+	oldValues := make(map[string]string)
+	for _, rl := range changed {
+		f := r.flags.Lookup(rl.Name)
+		oldValues[rl.Name] = f.Value.String()
+		if err := f.Value.Set(newValues[rl.Name]); err != nil {
+			// Put back the flags already set, including f as a failed Set may
+			// still have changed it.
+			for name, value := range oldValues {
+				r.flags.Lookup(name).Value.Set(value)
+			}
+			return fmt.Errorf("invalid value %q for flag -%v: %v", newValues[rl.Name], rl.Name, err)
+		}
+	}
+	for _, rl := range changed {
+		glog.Infof("Reloaded flag -%v=%v", rl.Name, newValues[rl.Name])
+		if rl.Apply != nil {
+			rl.Apply()
+		}
+	}
+	return nil
+}
+
+// Run reloads the flag file on each SIGHUP, until ctx is done.
+func (r *FlagFileReloader) Run(ctx context.Context) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+			if err := r.Reload(); err != nil {
+				glog.Errorf("Failed to reload flags from config file %q, keeping the current ones: %v", r.path, err)
+			}
+		}
+	}
+}
+
+// canonical returns value as formatted by f once it's set to it, leaving f as
+// it was.
+func canonical(f *flag.Flag, value string) (string, error) {
+	old := f.Value.String()
+	defer f.Value.Set(old)
+	if err := f.Value.Set(value); err != nil {
+		return "", err
+	}
+	return f.Value.String(), nil
+}
+
+// rawValue is a flag.Value keeping the unparsed value of a flag.
+type rawValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *rawValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *rawValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *rawValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// readFlags returns the unparsed values of the flags of flags that are set in
+// args, without changing any of them.
+func readFlags(flags *flag.FlagSet, args []string) (map[string]string, error) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	flags.VisitAll(func(f *flag.Flag) {
+		bf, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		})
+		fs.Var(&rawValue{isBool: ok && bf.IsBoolFlag()}, f.Name, f.Usage)
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values, nil
+}
@@ -15,9 +15,13 @@
 package cmd
 
 import (
+	"errors"
 	"flag"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseFlags(t *testing.T) {
@@ -107,3 +111,156 @@ func TestParseFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestFlagFileReloader(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := fs.String("a", "default", "")
+	b := fs.String("b", "", "")
+	n := fs.Int("n", 1, "")
+	verbose := fs.Bool("verbose", false, "")
+
+	file, err := ioutil.TempFile("", "flags")
+	if err != nil {
+		t.Fatalf("TempFile()=_,%v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+
+	var applied []string
+	apply := func(name string) func() {
+		return func() { applied = append(applied, name) }
+	}
+	positive := func(value string) error {
+		if strings.HasPrefix(value, "-") {
+			return errors.New("must be positive")
+		}
+		return nil
+	}
+	*b = "cmdline"
+	r, err := newFlagFileReloader(fs, []string{"-b", "cmdline"}, file.Name(),
+		Reloadable{Name: "a", Apply: apply("a")},
+		Reloadable{Name: "b", Apply: apply("b")},
+		Reloadable{Name: "n", Check: positive, Apply: apply("n")},
+		Reloadable{Name: "verbose"})
+	if err != nil {
+		t.Fatalf("newFlagFileReloader()=_,%v", err)
+	}
+
+	for _, test := range []struct {
+		desc        string
+		contents    string
+		wantErr     bool
+		wantA       string
+		wantB       string
+		wantN       int
+		wantVerbose bool
+		wantApplied []string
+	}{
+		{
+			desc:        "changes",
+			contents:    "-a one -n 2 -verbose",
+			wantA:       "one",
+			wantB:       "cmdline",
+			wantN:       2,
+			wantVerbose: true,
+			wantApplied: []string{"a", "n"},
+		},
+		{
+			desc:        "command-line takes precedence",
+			contents:    "-a one -b two -n 2 -verbose",
+			wantA:       "one",
+			wantB:       "cmdline",
+			wantN:       2,
+			wantVerbose: true,
+		},
+		{
+			desc:        "unparsable value",
+			contents:    "-a three -n three",
+			wantErr:     true,
+			wantA:       "one",
+			wantB:       "cmdline",
+			wantN:       2,
+			wantVerbose: true,
+		},
+		{
+			desc:        "failed check",
+			contents:    "-a three -n -3",
+			wantErr:     true,
+			wantA:       "one",
+			wantB:       "cmdline",
+			wantN:       2,
+			wantVerbose: true,
+		},
+		{
+			desc:        "undefined flag",
+			contents:    "-a three -c three",
+			wantErr:     true,
+			wantA:       "one",
+			wantB:       "cmdline",
+			wantN:       2,
+			wantVerbose: true,
+		},
+		{
+			desc:        "removed flags revert to defaults",
+			contents:    "-n 3",
+			wantA:       "default",
+			wantB:       "cmdline",
+			wantN:       3,
+			wantApplied: []string{"a", "n"},
+		},
+	} {
+		applied = nil
+		if err := ioutil.WriteFile(file.Name(), []byte(test.contents), 0600); err != nil {
+			t.Fatalf("WriteFile()=%v", err)
+		}
+		if err := r.Reload(); (err != nil) != test.wantErr {
+			t.Errorf("%v: Reload()=%v, wantErr: %v", test.desc, err, test.wantErr)
+		}
+		if *a != test.wantA || *b != test.wantB || *n != test.wantN || *verbose != test.wantVerbose {
+			t.Errorf("%v: got flags a=%q, b=%q, n=%v, verbose=%v, want a=%q, b=%q, n=%v, verbose=%v", test.desc, *a, *b, *n, *verbose, test.wantA, test.wantB, test.wantN, test.wantVerbose)
+		}
+		if strings.Join(applied, ",") != strings.Join(test.wantApplied, ",") {
+			t.Errorf("%v: applied %v, want %v", test.desc, applied, test.wantApplied)
+		}
+	}
+}
+
+func TestFlagFileReloaderSetOverFile(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	d := fs.Duration("d", time.Second, "")
+	n := fs.Int("n", 1, "")
+
+	file, err := ioutil.TempFile("", "flags")
+	if err != nil {
+		t.Fatalf("TempFile()=_,%v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Close()
+	if err := ioutil.WriteFile(file.Name(), []byte("-d 60s -n 2"), 0600); err != nil {
+		t.Fatalf("WriteFile()=%v", err)
+	}
+
+	// d has the file's value, n was set over it after the file was parsed.
+	*d = time.Minute
+	*n = 5
+	r, err := newFlagFileReloader(fs, nil, file.Name(), Reloadable{Name: "d"}, Reloadable{Name: "n"})
+	if err != nil {
+		t.Fatalf("newFlagFileReloader()=_,%v", err)
+	}
+	if err := ioutil.WriteFile(file.Name(), []byte("-d 2m -n 3"), 0600); err != nil {
+		t.Fatalf("WriteFile()=%v", err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload()=%v, want nil", err)
+	}
+	if *d != 2*time.Minute || *n != 5 {
+		t.Errorf("got flags d=%v, n=%v, want d=%v, n=%v", *d, *n, 2*time.Minute, 5)
+	}
+}
+
+func TestNewFlagFileReloaderUndefinedFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := newFlagFileReloader(fs, nil, "flags", Reloadable{Name: "a"}); err == nil {
+		t.Error("newFlagFileReloader() with an undefined reloadable flag succeeded, want err")
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/crypto/sigpb"
+	"google.golang.org/genproto/protobuf/field_mask"
+)
+
+// adminOpts contains all user-supplied options required to run a command.
+// It's meant to facilitate tests and focus flag reads to a single point.
+type adminOpts struct {
+	showDeleted                                                                              bool
+	treeState, treeType, hashStrategy, hashAlgorithm, sigAlgorithm, displayName, description string
+	mapLeafHashing, rootMetadataHook, checkpointOrigin                                       string
+	maxRootDuration, maxClientTimestampSkew, drainGracePeriod                                time.Duration
+	deadLetterAttempts                                                                       int
+	maxRevisionLookback                                                                      int64
+	privateKeyType, keyCurve, pemKeyPath, pemKeyPass, pkcs11ConfigPath                       string
+	keySize                                                                                  int
+
+	// setFlags holds the names of the flags set on the command line, which
+	// determine the fields changed by update.
+	setFlags map[string]bool
+}
+
+// updatableFields maps the flags accepted by update to the tree fields they
+// change, in the order they're added to the update mask.
+var updatableFields = []struct{ flag, path string }{
+	{"tree_state", "tree_state"},
+	{"display_name", "display_name"},
+	{"description", "description"},
+	{"max_root_duration", "max_root_duration"},
+	{"max_client_timestamp_skew", "max_client_timestamp_skew"},
+	{"drain_grace_period", "drain_grace_period"},
+	{"root_metadata_hook", "root_metadata_hook"},
+	{"dead_letter_attempts", "dead_letter_policy"},
+	{"max_revision_lookback", "max_revision_lookback"},
+}
+
+type command func(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"create":   createTree,
+	"list":     listTrees,
+	"get":      getTree,
+	"update":   updateTree,
+	"freeze":   freezeTree,
+	"delete":   deleteTree,
+	"undelete": undeleteTree,
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func createTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("create takes no arguments, got %v", args)
+	}
+	tree, err := newTree(opts)
+	if err != nil {
+		return err
+	}
+	req := &trillian.CreateTreeRequest{Tree: tree}
+	if err := setKey(req, opts); err != nil {
+		return err
+	}
+	tree, err = client.CreateTree(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, tree.TreeId)
+	return nil
+}
+
+func listTrees(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return fmt.Errorf("list takes no arguments, got %v", args)
+	}
+	resp, err := client.ListTrees(ctx, &trillian.ListTreesRequest{ShowDeleted: opts.showDeleted})
+	if err != nil {
+		return err
+	}
+	for _, tree := range resp.Tree {
+		fmt.Fprintf(out, "%v\t%s\t%s\t%v\n", tree.TreeId, tree.TreeType, tree.TreeState, tree.DisplayName)
+	}
+	return nil
+}
+
+func getTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	treeID, err := parseTreeID("get", args)
+	if err != nil {
+		return err
+	}
+	tree, err := client.GetTree(ctx, &trillian.GetTreeRequest{TreeId: treeID})
+	if err != nil {
+		return err
+	}
+	return proto.MarshalText(out, tree)
+}
+
+func updateTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	treeID, err := parseTreeID("update", args)
+	if err != nil {
+		return err
+	}
+	tree, err := newTree(opts)
+	if err != nil {
+		return err
+	}
+	tree.TreeId = treeID
+	mask := &field_mask.FieldMask{}
+	for _, f := range updatableFields {
+		if opts.setFlags[f.flag] {
+			mask.Paths = append(mask.Paths, f.path)
+		}
+	}
+	if len(mask.Paths) == 0 {
+		return errors.New("update requires at least one tree field flag to be set")
+	}
+	return update(ctx, client, tree, mask, out)
+}
+
+func freezeTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	treeID, err := parseTreeID("freeze", args)
+	if err != nil {
+		return err
+	}
+	tree := &trillian.Tree{TreeId: treeID, TreeState: trillian.TreeState_FROZEN}
+	return update(ctx, client, tree, &field_mask.FieldMask{Paths: []string{"tree_state"}}, out)
+}
+
+func update(ctx context.Context, client trillian.TrillianAdminClient, tree *trillian.Tree, mask *field_mask.FieldMask, out io.Writer) error {
+	tree, err := client.UpdateTree(ctx, &trillian.UpdateTreeRequest{Tree: tree, UpdateMask: mask})
+	if err != nil {
+		return err
+	}
+	return proto.MarshalText(out, tree)
+}
+
+func deleteTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	treeID, err := parseTreeID("delete", args)
+	if err != nil {
+		return err
+	}
+	_, err = client.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: treeID})
+	return err
+}
+
+func undeleteTree(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	treeID, err := parseTreeID("undelete", args)
+	if err != nil {
+		return err
+	}
+	tree, err := client.UndeleteTree(ctx, &trillian.UndeleteTreeRequest{TreeId: treeID})
+	if err != nil {
+		return err
+	}
+	return proto.MarshalText(out, tree)
+}
+
+func parseTreeID(cmd string, args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%v takes a single tree ID argument, got %v", cmd, args)
+	}
+	treeID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tree ID %q: %v", args[0], err)
+	}
+	return treeID, nil
+}
+
+// newTree returns a tree with the fields set by opts, except for its keys.
+func newTree(opts *adminOpts) (*trillian.Tree, error) {
+	ts, ok := trillian.TreeState_value[opts.treeState]
+	if !ok {
+		return nil, fmt.Errorf("unknown TreeState: %v", opts.treeState)
+	}
+
+	tt, ok := trillian.TreeType_value[opts.treeType]
+	if !ok {
+		return nil, fmt.Errorf("unknown TreeType: %v", opts.treeType)
+	}
+
+	hs, ok := trillian.HashStrategy_value[opts.hashStrategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown HashStrategy: %v", opts.hashStrategy)
+	}
+
+	ha, ok := sigpb.DigitallySigned_HashAlgorithm_value[opts.hashAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown HashAlgorithm: %v", opts.hashAlgorithm)
+	}
+
+	sa, ok := sigpb.DigitallySigned_SignatureAlgorithm_value[opts.sigAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown SignatureAlgorithm: %v", opts.sigAlgorithm)
+	}
+
+	mlh, ok := trillian.MapLeafHashing_value[opts.mapLeafHashing]
+	if !ok {
+		return nil, fmt.Errorf("unknown MapLeafHashing: %v", opts.mapLeafHashing)
+	}
+
+	tree := &trillian.Tree{
+		TreeState:           trillian.TreeState(ts),
+		TreeType:            trillian.TreeType(tt),
+		HashStrategy:        trillian.HashStrategy(hs),
+		HashAlgorithm:       sigpb.DigitallySigned_HashAlgorithm(ha),
+		SignatureAlgorithm:  sigpb.DigitallySigned_SignatureAlgorithm(sa),
+		DisplayName:         opts.displayName,
+		Description:         opts.description,
+		MaxRootDuration:     ptypes.DurationProto(opts.maxRootDuration),
+		MapLeafHashing:      trillian.MapLeafHashing(mlh),
+		RootMetadataHook:    opts.rootMetadataHook,
+		CheckpointOrigin:    opts.checkpointOrigin,
+		MaxRevisionLookback: opts.maxRevisionLookback,
+	}
+	if opts.maxClientTimestampSkew != 0 {
+		tree.MaxClientTimestampSkew = ptypes.DurationProto(opts.maxClientTimestampSkew)
+	}
+	if opts.drainGracePeriod != 0 {
+		tree.DrainGracePeriod = ptypes.DurationProto(opts.drainGracePeriod)
+	}
+	if opts.deadLetterAttempts != 0 {
+		tree.DeadLetterPolicy = &trillian.DeadLetterPolicy{MaxAttempts: int32(opts.deadLetterAttempts)}
+	}
+	return tree, nil
+}
+
+// setKey sets the private key of the tree in req, or asks the server to
+// generate one if opts.privateKeyType is "Generate".
+func setKey(req *trillian.CreateTreeRequest, opts *adminOpts) error {
+	if opts.privateKeyType != "Generate" {
+		pk, err := newPK(opts)
+		if err != nil {
+			return err
+		}
+		req.Tree.PrivateKey = pk
+		return nil
+	}
+
+	switch req.Tree.SignatureAlgorithm {
+	case sigpb.DigitallySigned_ECDSA:
+		curve, ok := keyspb.Specification_ECDSA_Curve_value[opts.keyCurve]
+		if !ok {
+			return fmt.Errorf("unknown ECDSA curve: %v", opts.keyCurve)
+		}
+		req.KeySpec = &keyspb.Specification{Params: &keyspb.Specification_EcdsaParams{
+			EcdsaParams: &keyspb.Specification_ECDSA{Curve: keyspb.Specification_ECDSA_Curve(curve)},
+		}}
+	case sigpb.DigitallySigned_RSA:
+		req.KeySpec = &keyspb.Specification{Params: &keyspb.Specification_RsaParams{
+			RsaParams: &keyspb.Specification_RSA{Bits: int32(opts.keySize)},
+		}}
+	default:
+		return fmt.Errorf("can't generate keys for SignatureAlgorithm %s", req.Tree.SignatureAlgorithm)
+	}
+	return nil
+}
+
+// tokenCredentials sends a bearer token with every RPC.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+// newTokenCredentials reads the bearer token from path. If secure is false,
+// the token may be sent over unencrypted connections.
+func newTokenCredentials(path string, secure bool) (*tokenCredentials, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return nil, fmt.Errorf("empty token in %v", path)
+	}
+	return &tokenCredentials{token: token, secure: secure}, nil
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/letsencrypt/pkcs11key"
+)
+
+// newPK returns the private key to import into a new tree, as configured by
+// opts.privateKeyType.
+func newPK(opts *adminOpts) (*any.Any, error) {
+	switch opts.privateKeyType {
+	case "PEMKeyFile":
+		if opts.pemKeyPath == "" {
+			return nil, errors.New("empty pem_key_path")
+		}
+		if opts.pemKeyPass == "" {
+			return nil, fmt.Errorf("empty password for PEM key file %q", opts.pemKeyPath)
+		}
+		pemKey := &keyspb.PEMKeyFile{
+			Path:     opts.pemKeyPath,
+			Password: opts.pemKeyPass,
+		}
+		return ptypes.MarshalAny(pemKey)
+	case "PrivateKey":
+		if opts.pemKeyPath == "" {
+			return nil, errors.New("empty pem_key_path")
+		}
+		pemSigner, err := keys.NewFromPrivatePEMFile(
+			opts.pemKeyPath, opts.pemKeyPass)
+		if err != nil {
+			return nil, err
+		}
+		der, err := keys.MarshalPrivateKey(pemSigner)
+		if err != nil {
+			return nil, err
+		}
+		return ptypes.MarshalAny(&keyspb.PrivateKey{Der: der})
+	case "PKCS11ConfigFile":
+		if opts.pkcs11ConfigPath == "" {
+			return nil, errors.New("empty PKCS11 config file path")
+		}
+		configBytes, err := ioutil.ReadFile(opts.pkcs11ConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		var config pkcs11key.Config
+		if err = json.Unmarshal(configBytes, &config); err != nil {
+			return nil, err
+		}
+		pubKeyBytes, err := ioutil.ReadFile(config.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		return ptypes.MarshalAny(&keyspb.PKCS11Config{
+			TokenLabel: config.TokenLabel,
+			Pin:        config.PIN,
+			PublicKey:  string(pubKeyBytes),
+			ModulePath: config.Module,
+		})
+	default:
+		return nil, fmt.Errorf("unknown private key type: %v", opts.privateKeyType)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package main contains the implementation and entry point for the
+// trillian_admin command, which manages the lifecycle of trees through the
+// Trillian Admin API.
+//
+// Usage:
+// $ ./trillian_admin --admin_server=host:port [flags] <command> [<tree_id>]
+//
+// Commands:
+//   create              creates a tree and outputs its ID
+//   list                lists trees, one per line, as tab-separated ID, type, state and display name
+//   get <tree_id>       outputs a tree
+//   update <tree_id>    updates the tree fields whose flags are explicitly set
+//   freeze <tree_id>    sets the state of a tree to FROZEN
+//   delete <tree_id>    soft-deletes a tree
+//   undelete <tree_id>  restores a soft-deleted tree, which comes back FROZEN
+//
+// Example usage:
+// $ ./trillian_admin --admin_server=host:port \
+//     --tree_type=LOG \
+//     --signature_algorithm=ECDSA \
+//     --private_key_format=Generate \
+//     create
+// $ ./trillian_admin --admin_server=host:port --display_name="My log" update 12345
+//
+// The signing key of a new tree is either generated by the server
+// (--private_key_format=Generate) or imported from a PEM file or a PKCS #11
+// configuration, as in the createtree command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/cmd"
+	"github.com/google/trillian/crypto/sigpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	adminServerAddr = flag.String("admin_server", "", "Address of the gRPC Trillian Admin Server (host:port)")
+	tlsCAFile       = flag.String("tls_ca_file", "", "PEM bundle of CAs used to verify the Admin Server's TLS certificate; empty means RPCs aren't encrypted")
+	authTokenFile   = flag.String("auth_token_file", "", "File containing the bearer token sent with each RPC; empty means RPCs are unauthenticated")
+	showDeleted     = flag.Bool("show_deleted", false, "If true, list also outputs deleted trees")
+
+	treeState          = flag.String("tree_state", trillian.TreeState_ACTIVE.String(), "State of the tree")
+	treeType           = flag.String("tree_type", trillian.TreeType_LOG.String(), "Type of the new tree")
+	hashStrategy       = flag.String("hash_strategy", trillian.HashStrategy_RFC6962_SHA256.String(), "Hash strategy (aka preimage protection) of the new tree")
+	hashAlgorithm      = flag.String("hash_algorithm", sigpb.DigitallySigned_SHA256.String(), "Hash algorithm of the new tree")
+	signatureAlgorithm = flag.String("signature_algorithm", sigpb.DigitallySigned_RSA.String(), "Signature algorithm of the new tree")
+	displayName        = flag.String("display_name", "", "Display name of the tree")
+	description        = flag.String("description", "", "Description of the tree")
+	maxRootDuration    = flag.Duration("max_root_duration", 0, "Interval after which a new signed root is produced despite no submissions; zero means never")
+	maxClientTSSkew    = flag.Duration("max_client_timestamp_skew", 0, "Maximum skew allowed for client-supplied leaf queue timestamps; zero means they're not accepted")
+	drainGracePeriod   = flag.Duration("drain_grace_period", 0, "Period during which the tree keeps accepting writes once it's DRAINING; zero means writes are rejected as soon as it's DRAINING")
+	mapLeafHashing     = flag.String("map_leaf_hashing", trillian.MapLeafHashing_SERVER_HASHED_LEAVES.String(), "Whether leaf hashes of the new map are computed by the server or by clients")
+	checkpointOrigin   = flag.String("checkpoint_origin", "", "Origin of the new log, unique among the server's trees and identifying it in checkpoints and metrics; empty means the tree ID. Can't be changed later")
+	rootMetadataHook   = flag.String("root_metadata_hook", "", "Name of the hook, registered with the log signer, supplying metadata for each signed root of the log; empty means no metadata")
+	deadLetterAttempts = flag.Int("dead_letter_attempts", 0, "Number of sequencing passes a queued leaf of the log may fail before it's dead-lettered; zero means leaves are never dead-lettered")
+	revisionLookback   = flag.Int64("max_revision_lookback", 0, "Number of revisions before the latest one that remain readable in the map; zero means all revisions are readable")
+
+	privateKeyFormat = flag.String("private_key_format", "Generate", "Source of the new tree's private key (Generate, PrivateKey, PEMKeyFile, or PKCS11ConfigFile)")
+	keySize          = flag.Int("key_size", 0, "Size in bits of a generated RSA key; zero means the server's default")
+	keyCurve         = flag.String("key_curve", "DEFAULT_CURVE", "Curve of a generated ECDSA key (DEFAULT_CURVE, P256, P384 or P521)")
+	pemKeyPath       = flag.String("pem_key_path", "", "Path to the private key PEM file")
+	pemKeyPassword   = flag.String("pem_key_password", "", "Password of the private key PEM file")
+	pkcs11ConfigPath = flag.String("pkcs11_config_path", "", "Path to the PKCS #11 key configuration file")
+
+	configFile = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
+)
+
+func main() {
+	flag.Parse()
+
+	if *configFile != "" {
+		if err := cmd.ParseFlagFile(*configFile); err != nil {
+			glog.Exitf("Failed to load flags from config file %q: %s", *configFile, err)
+		}
+	}
+
+	if *adminServerAddr == "" {
+		fmt.Fprintln(os.Stderr, "Empty --admin_server, please provide the Admin server host:port")
+		os.Exit(1)
+	}
+	conn, err := dial(*adminServerAddr, *tlsCAFile, *authTokenFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %v: %v\n", *adminServerAddr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	if err := run(ctx, trillian.NewTrillianAdminClient(conn), newOptsFromFlags(), flag.Args(), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dial connects to the Admin Server at addr. If caFile is set the connection
+// uses TLS, and if tokenFile is set its contents are sent as a bearer token
+// with every RPC.
+func dial(addr, caFile, tokenFile string) (*grpc.ClientConn, error) {
+	var opts []grpc.DialOption
+	if caFile != "" {
+		creds, err := credentials.NewClientTLSFromFile(caFile, "")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if tokenFile != "" {
+		creds, err := newTokenCredentials(tokenFile, caFile != "")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(creds))
+	}
+	return grpc.Dial(addr, opts...)
+}
+
+// run executes the command named by args[0] using client, writing its output
+// to out.
+func run(ctx context.Context, client trillian.TrillianAdminClient, opts *adminOpts, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command, want one of: %v", commandNames())
+	}
+	c, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, want one of: %v", args[0], commandNames())
+	}
+	return c(ctx, client, opts, args[1:], out)
+}
+
+func newOptsFromFlags() *adminOpts {
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	return &adminOpts{
+		showDeleted:            *showDeleted,
+		treeState:              *treeState,
+		treeType:               *treeType,
+		hashStrategy:           *hashStrategy,
+		hashAlgorithm:          *hashAlgorithm,
+		sigAlgorithm:           *signatureAlgorithm,
+		displayName:            *displayName,
+		description:            *description,
+		mapLeafHashing:         *mapLeafHashing,
+		rootMetadataHook:       *rootMetadataHook,
+		checkpointOrigin:       *checkpointOrigin,
+		maxRootDuration:        *maxRootDuration,
+		maxClientTimestampSkew: *maxClientTSSkew,
+		drainGracePeriod:       *drainGracePeriod,
+		deadLetterAttempts:     *deadLetterAttempts,
+		maxRevisionLookback:    *revisionLookback,
+		privateKeyType:         *privateKeyFormat,
+		keyCurve:               *keyCurve,
+		keySize:                *keySize,
+		pemKeyPath:             *pemKeyPath,
+		pemKeyPass:             *pemKeyPassword,
+		pkcs11ConfigPath:       *pkcs11ConfigPath,
+		setFlags:               setFlags,
+	}
+}
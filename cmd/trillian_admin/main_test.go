@@ -0,0 +1,239 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc"
+)
+
+// fakeAdminClient records the last request it received and responds with
+// tree. Methods not used by the commands aren't implemented.
+type fakeAdminClient struct {
+	trillian.TrillianAdminClient
+	tree *trillian.Tree
+	req  proto.Message
+}
+
+func (c *fakeAdminClient) CreateTree(ctx context.Context, req *trillian.CreateTreeRequest, opts ...grpc.CallOption) (*trillian.Tree, error) {
+	c.req = req
+	return c.tree, nil
+}
+
+func (c *fakeAdminClient) ListTrees(ctx context.Context, req *trillian.ListTreesRequest, opts ...grpc.CallOption) (*trillian.ListTreesResponse, error) {
+	c.req = req
+	return &trillian.ListTreesResponse{Tree: []*trillian.Tree{c.tree}}, nil
+}
+
+func (c *fakeAdminClient) GetTree(ctx context.Context, req *trillian.GetTreeRequest, opts ...grpc.CallOption) (*trillian.Tree, error) {
+	c.req = req
+	return c.tree, nil
+}
+
+func (c *fakeAdminClient) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest, opts ...grpc.CallOption) (*trillian.Tree, error) {
+	c.req = req
+	return c.tree, nil
+}
+
+func (c *fakeAdminClient) DeleteTree(ctx context.Context, req *trillian.DeleteTreeRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	c.req = req
+	return &empty.Empty{}, nil
+}
+
+func (c *fakeAdminClient) UndeleteTree(ctx context.Context, req *trillian.UndeleteTreeRequest, opts ...grpc.CallOption) (*trillian.Tree, error) {
+	c.req = req
+	return c.tree, nil
+}
+
+func TestRun(t *testing.T) {
+	respTree := &trillian.Tree{
+		TreeId:      12345,
+		TreeState:   trillian.TreeState_ACTIVE,
+		TreeType:    trillian.TreeType_LOG,
+		DisplayName: "Llamas Log",
+	}
+	respText := proto.MarshalTextString(respTree)
+
+	defaultOpts := newOptsFromFlags()
+	defaultTree, err := newTree(defaultOpts)
+	if err != nil {
+		t.Fatalf("newTree() returned err = %v", err)
+	}
+
+	ecdsaOpts := *defaultOpts
+	ecdsaOpts.sigAlgorithm = sigpb.DigitallySigned_ECDSA.String()
+	ecdsaOpts.keyCurve = keyspb.Specification_ECDSA_P384.String()
+	ecdsaTree := *defaultTree
+	ecdsaTree.SignatureAlgorithm = sigpb.DigitallySigned_ECDSA
+
+	badCurveOpts := ecdsaOpts
+	badCurveOpts.keyCurve = "LLAMA!"
+
+	emptyPEMPath := *defaultOpts
+	emptyPEMPath.privateKeyType = "PrivateKey"
+
+	showDeletedOpts := *defaultOpts
+	showDeletedOpts.showDeleted = true
+
+	updateOpts := *defaultOpts
+	updateOpts.displayName = "New Name"
+	updateOpts.deadLetterAttempts = 3
+	updateOpts.setFlags = map[string]bool{"display_name": true, "dead_letter_attempts": true, "tree_type": true}
+	updateTree := *defaultTree
+	updateTree.TreeId = 12345
+	updateTree.DisplayName = "New Name"
+	updateTree.DeadLetterPolicy = &trillian.DeadLetterPolicy{MaxAttempts: 3}
+
+	tests := []struct {
+		desc    string
+		opts    *adminOpts
+		args    []string
+		wantErr bool
+		wantReq proto.Message
+		wantOut string
+	}{
+		{desc: "noCommand", opts: defaultOpts, wantErr: true},
+		{desc: "unknownCommand", opts: defaultOpts, args: []string{"llama"}, wantErr: true},
+		{
+			desc:    "createRSA",
+			opts:    defaultOpts,
+			args:    []string{"create"},
+			wantReq: &trillian.CreateTreeRequest{Tree: defaultTree, KeySpec: &keyspb.Specification{Params: &keyspb.Specification_RsaParams{RsaParams: &keyspb.Specification_RSA{}}}},
+			wantOut: "12345\n",
+		},
+		{
+			desc: "createECDSA",
+			opts: &ecdsaOpts,
+			args: []string{"create"},
+			wantReq: &trillian.CreateTreeRequest{Tree: &ecdsaTree, KeySpec: &keyspb.Specification{Params: &keyspb.Specification_EcdsaParams{
+				EcdsaParams: &keyspb.Specification_ECDSA{Curve: keyspb.Specification_ECDSA_P384},
+			}}},
+			wantOut: "12345\n",
+		},
+		{desc: "createBadCurve", opts: &badCurveOpts, args: []string{"create"}, wantErr: true},
+		{desc: "createEmptyPEMPath", opts: &emptyPEMPath, args: []string{"create"}, wantErr: true},
+		{desc: "createWithArgs", opts: defaultOpts, args: []string{"create", "12345"}, wantErr: true},
+		{
+			desc:    "list",
+			opts:    &showDeletedOpts,
+			args:    []string{"list"},
+			wantReq: &trillian.ListTreesRequest{ShowDeleted: true},
+			wantOut: "12345\tLOG\tACTIVE\tLlamas Log\n",
+		},
+		{
+			desc:    "get",
+			opts:    defaultOpts,
+			args:    []string{"get", "12345"},
+			wantReq: &trillian.GetTreeRequest{TreeId: 12345},
+			wantOut: respText,
+		},
+		{desc: "getNoTreeID", opts: defaultOpts, args: []string{"get"}, wantErr: true},
+		{desc: "getBadTreeID", opts: defaultOpts, args: []string{"get", "llama"}, wantErr: true},
+		{
+			desc: "update",
+			opts: &updateOpts,
+			args: []string{"update", "12345"},
+			wantReq: &trillian.UpdateTreeRequest{
+				Tree:       &updateTree,
+				UpdateMask: &field_mask.FieldMask{Paths: []string{"display_name", "dead_letter_policy"}},
+			},
+			wantOut: respText,
+		},
+		{desc: "updateNoFields", opts: defaultOpts, args: []string{"update", "12345"}, wantErr: true},
+		{
+			desc: "freeze",
+			opts: defaultOpts,
+			args: []string{"freeze", "12345"},
+			wantReq: &trillian.UpdateTreeRequest{
+				Tree:       &trillian.Tree{TreeId: 12345, TreeState: trillian.TreeState_FROZEN},
+				UpdateMask: &field_mask.FieldMask{Paths: []string{"tree_state"}},
+			},
+			wantOut: respText,
+		},
+		{
+			desc:    "delete",
+			opts:    defaultOpts,
+			args:    []string{"delete", "12345"},
+			wantReq: &trillian.DeleteTreeRequest{TreeId: 12345},
+		},
+		{
+			desc:    "undelete",
+			opts:    defaultOpts,
+			args:    []string{"undelete", "12345"},
+			wantReq: &trillian.UndeleteTreeRequest{TreeId: 12345},
+			wantOut: respText,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		client := &fakeAdminClient{tree: respTree}
+		var out bytes.Buffer
+
+		err := run(ctx, client, test.opts, test.args, &out)
+		switch hasErr := err != nil; {
+		case hasErr != test.wantErr:
+			t.Errorf("%v: run() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		case hasErr:
+			continue
+		}
+
+		if diff := pretty.Compare(client.req, test.wantReq); diff != "" {
+			t.Errorf("%v: post-run request diff:\n%v", test.desc, diff)
+		}
+		if got := out.String(); got != test.wantOut {
+			t.Errorf("%v: run() output = %q, want = %q", test.desc, got, test.wantOut)
+		}
+	}
+}
+
+func TestNewTokenCredentials(t *testing.T) {
+	f, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatalf("TempFile() returned err = %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("s3cr3t\n"); err != nil {
+		t.Fatalf("WriteString() returned err = %v", err)
+	}
+	f.Close()
+
+	creds, err := newTokenCredentials(f.Name(), true)
+	if err != nil {
+		t.Fatalf("newTokenCredentials() returned err = %v", err)
+	}
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata() returned err = %v", err)
+	}
+	if got, want := md["authorization"], "Bearer s3cr3t"; got != want {
+		t.Errorf("GetRequestMetadata()[authorization] = %q, want = %q", got, want)
+	}
+	if !creds.RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = false, want = true")
+	}
+}
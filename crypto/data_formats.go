@@ -31,11 +31,13 @@ const (
 	mapKeyRootHash       string = "RootHash"
 	mapKeyTimestampNanos string = "TimestampNanos"
 	mapKeyTreeSize       string = "TreeSize"
+	mapKeyMetadata       string = "Metadata"
 )
 
 // HashLogRoot hashes SignedLogRoot objects using ObjectHash with
 // "RootHash", "TimestampNanos", and "TreeSize", used as keys in
-// a map.
+// a map. "Metadata" is added only if the root has metadata, so that
+// the hashes of roots without it are unchanged.
 func HashLogRoot(root trillian.SignedLogRoot) []byte {
 	// Pull out the fields we want to hash.
 	// Caution: use string format for int64 values as they can overflow when
@@ -45,6 +47,9 @@ func HashLogRoot(root trillian.SignedLogRoot) []byte {
 		mapKeyRootHash:       base64.StdEncoding.EncodeToString(root.RootHash),
 		mapKeyTimestampNanos: strconv.FormatInt(root.TimestampNanos, 10),
 		mapKeyTreeSize:       strconv.FormatInt(root.TreeSize, 10)}
+	if len(root.Metadata) > 0 {
+		rootMap[mapKeyMetadata] = base64.StdEncoding.EncodeToString(root.Metadata)
+	}
 
 	hash := objecthash.ObjectHash(rootMap)
 	return hash[:]
@@ -15,6 +15,7 @@
 package crypto
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/google/trillian"
@@ -53,6 +54,22 @@ func TestHashLogRoot(t *testing.T) {
 				TreeSize:       3,
 			},
 		},
+		{
+			root: trillian.SignedLogRoot{
+				TimestampNanos: 2267709,
+				RootHash:       []byte("Islington"),
+				TreeSize:       2,
+				Metadata:       []byte("epoch 1"),
+			},
+		},
+		{
+			root: trillian.SignedLogRoot{
+				TimestampNanos: 2267709,
+				RootHash:       []byte("Islington"),
+				TreeSize:       2,
+				Metadata:       []byte("epoch 2"),
+			},
+		},
 	} {
 		hash := HashLogRoot(test.root)
 		var h [20]byte
@@ -64,3 +81,23 @@ func TestHashLogRoot(t *testing.T) {
 	}
 
 }
+
+func TestHashLogRootWithoutMetadata(t *testing.T) {
+	root := trillian.SignedLogRoot{
+		TimestampNanos: 2267709,
+		RootHash:       []byte("Islington"),
+		TreeSize:       2,
+	}
+	hash := HashLogRoot(root)
+
+	// Hashes of roots without metadata must not change, or existing signatures would no
+	// longer verify.
+	root.Metadata = []byte{}
+	if got := HashLogRoot(root); !bytes.Equal(got, hash) {
+		t.Errorf("HashLogRoot(empty metadata) = %x, want %x", got, hash)
+	}
+	root.Metadata = []byte("epoch 1")
+	if got := HashLogRoot(root); bytes.Equal(got, hash) {
+		t.Errorf("HashLogRoot(metadata) = %x, want a hash different from the root without metadata", got)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package breaker provides a circuit breaker around signers, so that a failing
+// key backend (e.g. a throttled KMS) isn't called again and again.
+package breaker
+
+import (
+	"crypto"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/errors"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed means signing requests are passed to the backend.
+	Closed State = iota
+	// Open means signing requests fail without calling the backend.
+	Open
+	// HalfOpen means a single signing request is passed to the backend, to
+	// probe whether it has recovered.
+	HalfOpen
+)
+
+const treeIDLabel = "treeid"
+
+var (
+	once         sync.Once
+	breakerState monitoring.Gauge
+)
+
+func createMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	breakerState = mf.NewGauge("signer_breaker_state", "State of the circuit breaker around the tree's signer: 0 closed, 1 open, 2 half-open", treeIDLabel)
+}
+
+// SignerFactory is a keys.SignerFactory whose signers are guarded by a circuit
+// breaker. After MaxFailures consecutive signing failures the breaker opens
+// and signing fails fast for Cooldown, after which one request is let through
+// to probe the backend.
+type SignerFactory struct {
+	keys.SignerFactory
+	// MaxFailures is the number of consecutive failures that open the breaker,
+	// values below 1 mean 1.
+	MaxFailures int
+	// Cooldown is how long the breaker stays open before probing the backend.
+	Cooldown time.Duration
+	// TimeSource is used to time the cooldown.
+	TimeSource util.TimeSource
+}
+
+// NewSignerFactory wraps sf in circuit breakers configured as described by
+// SignerFactory.
+func NewSignerFactory(sf keys.SignerFactory, maxFailures int, cooldown time.Duration, mf monitoring.MetricFactory) *SignerFactory {
+	once.Do(func() { createMetrics(mf) })
+	return &SignerFactory{
+		SignerFactory: sf,
+		MaxFailures:   maxFailures,
+		Cooldown:      cooldown,
+		TimeSource:    util.SystemTimeSource{},
+	}
+}
+
+// NewSigner returns the signer obtained from the wrapped SignerFactory,
+// guarded by a new circuit breaker. If ctx carries a tree (see
+// trees.NewContext), the state of the breaker is reported for it.
+func (f *SignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	once.Do(func() { createMetrics(nil) })
+	signer, err := f.SignerFactory.NewSigner(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{
+		Signer:      signer,
+		maxFailures: f.MaxFailures,
+		cooldown:    f.Cooldown,
+		timeSource:  f.TimeSource,
+	}
+	if tree, ok := trees.FromContext(ctx); ok {
+		s.label = strconv.FormatInt(tree.TreeId, 10)
+	}
+	breakerState.Set(float64(Closed), s.label)
+	return s, nil
+}
+
+// Signer is a crypto.Signer guarded by a circuit breaker.
+type Signer struct {
+	crypto.Signer
+	maxFailures int
+	cooldown    time.Duration
+	timeSource  util.TimeSource
+	label       string
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+}
+
+// State returns the current state of the breaker.
+func (s *Signer) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Sign signs digest with the wrapped signer, unless the breaker is open.
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := s.allow(); err != nil {
+		return nil, err
+	}
+	sig, err := s.Signer.Sign(rand, digest, opts)
+	s.record(err == nil)
+	return sig, err
+}
+
+// allow returns an error if a signing request mustn't be passed to the
+// backend.
+func (s *Signer) allow() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case Open:
+		if s.timeSource.Now().Before(s.openedAt.Add(s.cooldown)) {
+			return errors.Errorf(errors.Unavailable, "signer unavailable after %d consecutive failures", s.failures)
+		}
+		s.setState(HalfOpen)
+	case HalfOpen:
+		// A probe is already in flight.
+		return errors.Errorf(errors.Unavailable, "signer unavailable, probing after %d consecutive failures", s.failures)
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a signing request.
+func (s *Signer) record(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ok {
+		s.failures = 0
+		s.setState(Closed)
+		return
+	}
+	s.failures++
+	if s.state == HalfOpen || s.failures >= s.maxFailures {
+		s.openedAt = s.timeSource.Now()
+		s.setState(Open)
+	}
+}
+
+func (s *Signer) setState(state State) {
+	s.state = state
+	breakerState.Set(float64(state), s.label)
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breaker
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/errors"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+// fakeSigner fails while fail is set, counting the calls made to it.
+type fakeSigner struct {
+	crypto.Signer
+	fail  bool
+	calls int
+}
+
+func (s *fakeSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	if s.fail {
+		return nil, fmt.Errorf("throttled")
+	}
+	return []byte("signature"), nil
+}
+
+type fakeSignerFactory struct {
+	keys.SignerFactory
+	signer *fakeSigner
+}
+
+func (f fakeSignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	return f.signer, nil
+}
+
+func TestSignerBreaker(t *testing.T) {
+	ctx := trees.NewContext(context.Background(), &trillian.Tree{TreeId: 12345})
+	ts := util.NewFakeTimeSource(time.Unix(1000, 0))
+	backend := &fakeSigner{fail: true}
+	sf := NewSignerFactory(fakeSignerFactory{signer: backend}, 3, time.Minute, nil)
+	sf.TimeSource = ts
+
+	cs, err := sf.NewSigner(ctx, nil)
+	if err != nil {
+		t.Fatalf("NewSigner() = (_, %v), want (_, nil)", err)
+	}
+	s := cs.(*Signer)
+
+	sign := func(desc string, wantCalls int, wantState State, wantUnavailable bool) {
+		_, err := s.Sign(nil, []byte("digest"), crypto.SHA256)
+		if got, want := backend.calls, wantCalls; got != want {
+			t.Errorf("%v: backend called %d times, want %d", desc, got, want)
+		}
+		if got, want := s.State(), wantState; got != want {
+			t.Errorf("%v: State() = %v, want %v", desc, got, want)
+		}
+		if got := errors.ErrorCode(err) == errors.Unavailable; got != wantUnavailable {
+			t.Errorf("%v: Sign() = (_, %v), want Unavailable: %v", desc, err, wantUnavailable)
+		}
+	}
+
+	sign("failure 1", 1, Closed, false)
+	sign("failure 2", 2, Closed, false)
+	sign("failure 3", 3, Open, false)
+	sign("open", 3, Open, true)
+
+	ts.Set(ts.Now().Add(time.Minute))
+	sign("failed probe", 4, Open, false)
+	sign("reopened", 4, Open, true)
+
+	ts.Set(ts.Now().Add(time.Minute))
+	backend.fail = false
+	sign("successful probe", 5, Closed, false)
+	sign("closed", 6, Closed, false)
+
+	// A success resets the count of consecutive failures.
+	backend.fail = true
+	sign("failure 1 again", 7, Closed, false)
+	sign("failure 2 again", 8, Closed, false)
+}
+
+func TestSignerBreakerHalfOpen(t *testing.T) {
+	ts := util.NewFakeTimeSource(time.Unix(1000, 0))
+	backend := &fakeSigner{fail: true}
+	s := &Signer{Signer: backend, maxFailures: 1, cooldown: time.Minute, timeSource: ts}
+	once.Do(func() { createMetrics(nil) })
+
+	if _, err := s.Sign(nil, []byte("digest"), crypto.SHA256); err == nil {
+		t.Fatalf("Sign() = (_, nil), want error")
+	}
+	ts.Set(ts.Now().Add(time.Minute))
+
+	// While a probe is in flight, other requests fail fast.
+	if err := s.allow(); err != nil {
+		t.Fatalf("allow() = %v, want nil", err)
+	}
+	if got, want := s.State(), HalfOpen; got != want {
+		t.Errorf("State() = %v, want %v", got, want)
+	}
+	if err := s.allow(); errors.ErrorCode(err) != errors.Unavailable {
+		t.Errorf("allow() = %v, want Unavailable", err)
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/rsa"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/google/trillian/crypto/keyspb"
@@ -293,3 +295,43 @@ func TestGenerateKey(t *testing.T) {
 		}
 	}
 }
+
+func TestPKCS11PIN(t *testing.T) {
+	f, err := ioutil.TempFile("", "pin")
+	if err != nil {
+		t.Fatalf("TempFile() returned err = %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("1234\n"); err != nil {
+		t.Fatalf("WriteString() returned err = %v", err)
+	}
+	f.Close()
+
+	const pinEnv = "TRILLIAN_TEST_PKCS11_PIN"
+	os.Setenv(pinEnv, "5678")
+	defer os.Unsetenv(pinEnv)
+
+	for _, test := range []struct {
+		name    string
+		config  *keyspb.PKCS11Config
+		wantPIN string
+		wantErr bool
+	}{
+		{name: "noPIN", config: &keyspb.PKCS11Config{}},
+		{name: "pin", config: &keyspb.PKCS11Config{Pin: "4321"}, wantPIN: "4321"},
+		{name: "pinFile", config: &keyspb.PKCS11Config{PinFile: f.Name()}, wantPIN: "1234"},
+		{name: "missingPINFile", config: &keyspb.PKCS11Config{PinFile: f.Name() + ".missing"}, wantErr: true},
+		{name: "pinEnv", config: &keyspb.PKCS11Config{PinEnv: pinEnv}, wantPIN: "5678"},
+		{name: "unsetPINEnv", config: &keyspb.PKCS11Config{PinEnv: pinEnv + "_UNSET"}, wantErr: true},
+		{name: "pinAndPINFile", config: &keyspb.PKCS11Config{Pin: "4321", PinFile: f.Name()}, wantErr: true},
+	} {
+		pin, err := pkcs11PIN(test.config)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: pkcs11PIN() = (_, %v), want err? %v", test.name, err, test.wantErr)
+			continue
+		}
+		if pin != test.wantPIN {
+			t.Errorf("%v: pkcs11PIN() = %q, want %q", test.name, pin, test.wantPIN)
+		}
+	}
+}
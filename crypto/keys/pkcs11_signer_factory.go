@@ -0,0 +1,104 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package keys
+
+import (
+	"context"
+	"crypto"
+	"io"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/crypto/keyspb"
+)
+
+// PKCS11SignerFactory is a SignerFactory for keys held in PKCS#11 tokens,
+// such as HSMs, which are described by keyspb.PKCS11Config protos.
+//
+// Logging into a token is expensive, so signers are cached per key and reused
+// across NewSigner calls. A cached signer is dropped as soon as it fails to
+// sign, so the next NewSigner call logs into the token again.
+//
+// Other protos, as well as Generate, are handled by the wrapped SignerFactory.
+type PKCS11SignerFactory struct {
+	SignerFactory
+	modulePath string
+	// newSigner creates signers for PKCS#11 keys. It's NewFromPKCS11Config,
+	// except in tests.
+	newSigner func(modulePath string, config *keyspb.PKCS11Config) (crypto.Signer, error)
+
+	mu sync.Mutex
+	// signers holds the cached signers, keyed by the text form of their
+	// PKCS11Config.
+	signers map[string]*pkcs11Signer
+}
+
+// NewPKCS11SignerFactory returns a PKCS11SignerFactory that wraps sf.
+// modulePath is the PKCS#11 module used for keys that don't specify one.
+func NewPKCS11SignerFactory(sf SignerFactory, modulePath string) *PKCS11SignerFactory {
+	return &PKCS11SignerFactory{
+		SignerFactory: sf,
+		modulePath:    modulePath,
+		newSigner:     NewFromPKCS11Config,
+		signers:       make(map[string]*pkcs11Signer),
+	}
+}
+
+// NewSigner implements SignerFactory.NewSigner.
+func (f *PKCS11SignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	config, ok := pb.(*keyspb.PKCS11Config)
+	if !ok {
+		return f.SignerFactory.NewSigner(ctx, pb)
+	}
+
+	key := proto.CompactTextString(config)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if signer, ok := f.signers[key]; ok {
+		return signer, nil
+	}
+	s, err := f.newSigner(f.modulePath, config)
+	if err != nil {
+		return nil, err
+	}
+	signer := &pkcs11Signer{Signer: s, factory: f, key: key}
+	f.signers[key] = signer
+	return signer, nil
+}
+
+// evict removes signer from the cache, unless it has already been replaced.
+func (f *PKCS11SignerFactory) evict(signer *pkcs11Signer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.signers[signer.key] == signer {
+		delete(f.signers, signer.key)
+	}
+}
+
+// pkcs11Signer is a cached PKCS#11 signer, which evicts itself from its
+// factory's cache when signing fails.
+type pkcs11Signer struct {
+	crypto.Signer
+	factory *PKCS11SignerFactory
+	key     string
+}
+
+// Sign implements crypto.Signer.Sign.
+func (s *pkcs11Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := s.Signer.Sign(rand, digest, opts)
+	if err != nil {
+		s.factory.evict(s)
+	}
+	return sig, err
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package keys
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/trillian/crypto/keyspb"
+)
+
+// failingSigner is a crypto.Signer whose Sign method fails while fail is set.
+type failingSigner struct {
+	crypto.Signer
+	fail bool
+}
+
+func (s *failingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if s.fail {
+		return nil, errors.New("token removed")
+	}
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestPKCS11SignerFactory(t *testing.T) {
+	ctx := context.Background()
+	key, err := NewFromPrivatePEM(ecdsaPrivateKey, "")
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM() returned err = %v", err)
+	}
+	keyDER, err := MarshalPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() returned err = %v", err)
+	}
+
+	var created []*failingSigner
+	var newSignerErr error
+	f := NewPKCS11SignerFactory(&DefaultSignerFactory{}, "/default/module.so")
+	f.newSigner = func(modulePath string, config *keyspb.PKCS11Config) (crypto.Signer, error) {
+		if got, want := modulePath, "/default/module.so"; got != want {
+			t.Errorf("newSigner() called with modulePath = %q, want %q", got, want)
+		}
+		if newSignerErr != nil {
+			return nil, newSignerErr
+		}
+		s := &failingSigner{Signer: key}
+		created = append(created, s)
+		return s, nil
+	}
+
+	// Keys other than PKCS#11 ones are handled by the wrapped factory.
+	if _, err := f.NewSigner(ctx, &keyspb.PrivateKey{Der: keyDER}); err != nil {
+		t.Errorf("NewSigner(PrivateKey) returned err = %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("NewSigner(PrivateKey) created %v PKCS#11 signers, want 0", len(created))
+	}
+
+	config := &keyspb.PKCS11Config{TokenLabel: "log", PinEnv: "PIN", PublicKey: ecdsaPublicKey}
+	otherConfig := &keyspb.PKCS11Config{TokenLabel: "map", PinEnv: "PIN", PublicKey: ecdsaPublicKey}
+	digest := sha256.Sum256([]byte("root"))
+
+	signer1, err := f.NewSigner(ctx, config)
+	if err != nil {
+		t.Fatalf("NewSigner() returned err = %v", err)
+	}
+	signer2, err := f.NewSigner(ctx, config)
+	if err != nil {
+		t.Fatalf("NewSigner() returned err = %v", err)
+	}
+	if signer1 != signer2 {
+		t.Error("NewSigner() didn't reuse the cached signer")
+	}
+	if _, err := f.NewSigner(ctx, otherConfig); err != nil {
+		t.Fatalf("NewSigner(otherConfig) returned err = %v", err)
+	}
+	if got, want := len(created), 2; got != want {
+		t.Fatalf("NewSigner() created %v signers, want %v", got, want)
+	}
+
+	// A signer that fails to sign is evicted, so the token is logged into again.
+	created[0].fail = true
+	if _, err := signer1.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+		t.Fatal("Sign() returned err = nil, want non-nil")
+	}
+	signer3, err := f.NewSigner(ctx, config)
+	if err != nil {
+		t.Fatalf("NewSigner() returned err = %v", err)
+	}
+	if signer3 == signer1 {
+		t.Error("NewSigner() returned the signer that failed to sign")
+	}
+	if _, err := signer3.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Errorf("Sign() returned err = %v", err)
+	}
+	if got, want := len(created), 3; got != want {
+		t.Errorf("NewSigner() created %v signers, want %v", got, want)
+	}
+
+	// Errors creating signers are returned and aren't cached.
+	newSignerErr = errors.New("login failed")
+	if _, err := f.NewSigner(ctx, &keyspb.PKCS11Config{TokenLabel: "new"}); err != newSignerErr {
+		t.Errorf("NewSigner() returned err = %v, want %v", err, newSignerErr)
+	}
+	newSignerErr = nil
+	if _, err := f.NewSigner(ctx, &keyspb.PKCS11Config{TokenLabel: "new"}); err != nil {
+		t.Errorf("NewSigner() returned err = %v", err)
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian/crypto/keyspb"
@@ -174,13 +176,51 @@ func MarshalPrivateKey(key crypto.Signer) ([]byte, error) {
 }
 
 // NewFromPKCS11Config returns a crypto.Signer that uses a PKCS#11 interface.
+// The module in config.ModulePath, if set, is used instead of modulePath.
 func NewFromPKCS11Config(modulePath string, config *keyspb.PKCS11Config) (crypto.Signer, error) {
+	if config.GetModulePath() != "" {
+		modulePath = config.GetModulePath()
+	}
 	if modulePath == "" {
 		return nil, errors.New("No PKCS#11 module path set, cannot create signer")
 	}
+	pin, err := pkcs11PIN(config)
+	if err != nil {
+		return nil, err
+	}
 	pubKey, err := NewFromPublicPEM(config.GetPublicKey())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load public key from %q: %s", config.GetPublicKey(), err)
 	}
-	return pkcs11key.New(modulePath, config.GetTokenLabel(), config.GetPin(), pubKey)
+	return pkcs11key.New(modulePath, config.GetTokenLabel(), pin, pubKey)
+}
+
+// pkcs11PIN returns the PIN of the token config refers to, taken from
+// whichever of its pin, pin_file or pin_env fields is set.
+func pkcs11PIN(config *keyspb.PKCS11Config) (string, error) {
+	sources := 0
+	for _, source := range []string{config.GetPin(), config.GetPinFile(), config.GetPinEnv()} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", errors.New("at most one of pin, pin_file and pin_env may be set")
+	}
+
+	switch {
+	case config.GetPinFile() != "":
+		pin, err := ioutil.ReadFile(config.GetPinFile())
+		if err != nil {
+			return "", fmt.Errorf("failed to read PIN file: %v", err)
+		}
+		return strings.TrimSpace(string(pin)), nil
+	case config.GetPinEnv() != "":
+		pin, ok := os.LookupEnv(config.GetPinEnv())
+		if !ok {
+			return "", fmt.Errorf("PIN environment variable %q isn't set", config.GetPinEnv())
+		}
+		return pin, nil
+	}
+	return config.GetPin(), nil
 }
@@ -0,0 +1,176 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reconnect provides signers that re-establish their connection to a
+// network-backed key backend (e.g. Vault or a KMS) when it drops, instead of
+// failing the signing request.
+package reconnect
+
+import (
+	"crypto"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/client/backoff"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/trees"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const treeIDLabel = "treeid"
+
+var (
+	once               sync.Once
+	reconnectAttempts  monitoring.Counter
+	reconnectSuccesses monitoring.Counter
+)
+
+func createMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	reconnectAttempts = mf.NewCounter("signer_reconnect_attempts", "Number of attempts to reconnect the tree's signer to its key backend", treeIDLabel)
+	reconnectSuccesses = mf.NewCounter("signer_reconnect_successes", "Number of successful reconnections of the tree's signer to its key backend", treeIDLabel)
+}
+
+// IsConnectionError reports whether err means the connection to a key backend
+// was lost or couldn't be made, as opposed to e.g. the backend refusing to
+// sign because of missing permissions.
+func IsConnectionError(err error) bool {
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF, syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EPIPE:
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unavailable {
+		return true
+	}
+	return false
+}
+
+// SignerFactory is a keys.SignerFactory whose signers reconnect to their key
+// backend on connection errors. When signing fails with a connection error a
+// new signer is obtained from the wrapped SignerFactory, after waiting as
+// configured by Backoff, and signing is retried; this is done up to
+// MaxAttempts times before the error is returned.
+type SignerFactory struct {
+	keys.SignerFactory
+	// MaxAttempts is the number of reconnection attempts made for each
+	// signing request.
+	MaxAttempts int
+	// Backoff configures the wait before each reconnection attempt.
+	Backoff backoff.Backoff
+	// IsConnectionError classifies signing errors, errors for which it
+	// returns false are returned without reconnecting.
+	IsConnectionError func(error) bool
+}
+
+// NewSignerFactory wraps sf so that its signers make up to maxAttempts
+// reconnection attempts, waiting from minBackoff up to maxBackoff before each.
+func NewSignerFactory(sf keys.SignerFactory, maxAttempts int, minBackoff, maxBackoff time.Duration, mf monitoring.MetricFactory) *SignerFactory {
+	once.Do(func() { createMetrics(mf) })
+	return &SignerFactory{
+		SignerFactory: sf,
+		MaxAttempts:   maxAttempts,
+		Backoff: backoff.Backoff{
+			Min:    minBackoff,
+			Max:    maxBackoff,
+			Factor: 2,
+			// Jitter can't be added to a zero backoff.
+			Jitter: minBackoff > 0,
+		},
+		IsConnectionError: IsConnectionError,
+	}
+}
+
+// NewSigner returns the signer obtained from the wrapped SignerFactory, which
+// reconnects as described by SignerFactory. If ctx carries a tree (see
+// trees.NewContext), reconnections are metered for it.
+func (f *SignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	once.Do(func() { createMetrics(nil) })
+	signer, err := f.SignerFactory.NewSigner(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	// Signers outlive the request that created them, so reconnecting
+	// mustn't depend on its deadline.
+	reconnectCtx := context.Background()
+	s := &Signer{
+		signer:  signer,
+		factory: f,
+		pb:      pb,
+		backoff: f.Backoff,
+		sleep:   time.Sleep,
+	}
+	if tree, ok := trees.FromContext(ctx); ok {
+		reconnectCtx = trees.NewContext(reconnectCtx, tree)
+		s.label = strconv.FormatInt(tree.TreeId, 10)
+	}
+	s.ctx = reconnectCtx
+	return s, nil
+}
+
+// Signer is a crypto.Signer that reconnects to its key backend on connection
+// errors.
+type Signer struct {
+	factory *SignerFactory
+	ctx     context.Context
+	pb      proto.Message
+	label   string
+	sleep   func(time.Duration)
+
+	mu      sync.Mutex
+	signer  crypto.Signer
+	backoff backoff.Backoff
+}
+
+// Public returns the public key of the wrapped signer.
+func (s *Signer) Public() crypto.PublicKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signer.Public()
+}
+
+// Sign signs digest with the wrapped signer, reconnecting it to the key
+// backend if that fails with a connection error.
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.backoff.Reset()
+
+	sig, err := s.signer.Sign(rand, digest, opts)
+	for attempt := 0; err != nil && attempt < s.factory.MaxAttempts && s.factory.IsConnectionError(err); attempt++ {
+		s.sleep(s.backoff.Duration())
+		reconnectAttempts.Inc(s.label)
+		signer, serr := s.factory.SignerFactory.NewSigner(s.ctx, s.pb)
+		if serr != nil {
+			err = serr
+			continue
+		}
+		reconnectSuccesses.Inc(s.label)
+		s.signer = signer
+		sig, err = s.signer.Sign(rand, digest, opts)
+	}
+	return sig, err
+}
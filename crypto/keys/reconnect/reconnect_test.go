@@ -0,0 +1,187 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconnect
+
+import (
+	"crypto"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/trees"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errDropped = &net.OpError{Op: "write", Net: "tcp", Err: syscall.ECONNRESET}
+
+// fakeSigner fails with err, counting the calls made to it.
+type fakeSigner struct {
+	crypto.Signer
+	err   error
+	calls int
+}
+
+func (s *fakeSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte("signature"), nil
+}
+
+// fakeSignerFactory returns signers in turn, or fails with err.
+type fakeSignerFactory struct {
+	keys.SignerFactory
+	signers []*fakeSigner
+	errs    []error
+	calls   int
+	ctxs    []context.Context
+}
+
+func (f *fakeSignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	i := f.calls
+	f.calls++
+	f.ctxs = append(f.ctxs, ctx)
+	if i < len(f.errs) && f.errs[i] != nil {
+		return nil, f.errs[i]
+	}
+	return f.signers[i], nil
+}
+
+func TestSignerReconnects(t *testing.T) {
+	denied := status.Errorf(codes.PermissionDenied, "not allowed")
+	tests := []struct {
+		desc string
+		// signers are returned by successive NewSigner calls, or the
+		// corresponding factoryErrs.
+		signers     []*fakeSigner
+		factoryErrs []error
+		wantErr     error
+		// wantFactoryCalls includes the initial NewSigner call.
+		wantFactoryCalls int
+		wantSleeps       []time.Duration
+	}{
+		{
+			desc:             "ok",
+			signers:          []*fakeSigner{{}},
+			wantFactoryCalls: 1,
+		},
+		{
+			desc:             "notConnectionError",
+			signers:          []*fakeSigner{{err: denied}},
+			wantErr:          denied,
+			wantFactoryCalls: 1,
+		},
+		{
+			desc:             "reconnected",
+			signers:          []*fakeSigner{{err: errDropped}, {err: io.EOF}, {}},
+			wantFactoryCalls: 3,
+			wantSleeps:       []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			desc:             "reconnectFails",
+			signers:          []*fakeSigner{{err: errDropped}, nil, {}},
+			factoryErrs:      []error{nil, status.Errorf(codes.Unavailable, "connection refused")},
+			wantFactoryCalls: 3,
+			wantSleeps:       []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			desc:             "reconnectDenied",
+			signers:          []*fakeSigner{{err: errDropped}},
+			factoryErrs:      []error{nil, denied},
+			wantErr:          denied,
+			wantFactoryCalls: 2,
+			wantSleeps:       []time.Duration{time.Second},
+		},
+		{
+			desc:             "attemptsExhausted",
+			signers:          []*fakeSigner{{err: errDropped}, {err: errDropped}, {err: errDropped}, {err: errDropped}},
+			wantErr:          errDropped,
+			wantFactoryCalls: 4,
+			wantSleeps:       []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(trees.NewContext(context.Background(), &trillian.Tree{TreeId: 12345}))
+	for _, test := range tests {
+		factory := &fakeSignerFactory{signers: test.signers, errs: test.factoryErrs}
+		sf := NewSignerFactory(factory, 3, time.Second, 3*time.Second, nil)
+
+		cs, err := sf.NewSigner(ctx, nil)
+		if err != nil {
+			t.Fatalf("%v: NewSigner() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		s := cs.(*Signer)
+		var sleeps []time.Duration
+		s.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+		// Reconnecting doesn't depend on the context the signer was created with.
+		cancel()
+		sig, err := s.Sign(nil, []byte("digest"), crypto.SHA256)
+		if err != test.wantErr {
+			t.Errorf("%v: Sign() = (_, %v), want (_, %v)", test.desc, err, test.wantErr)
+		} else if err == nil && string(sig) != "signature" {
+			t.Errorf("%v: Sign() = (%s, nil), want (signature, nil)", test.desc, sig)
+		}
+		if got, want := factory.calls, test.wantFactoryCalls; got != want {
+			t.Errorf("%v: NewSigner called %d times, want %d", test.desc, got, want)
+		}
+		for _, c := range factory.ctxs[1:] {
+			if c.Err() != nil {
+				t.Errorf("%v: reconnected with a done context", test.desc)
+			}
+			if tree, ok := trees.FromContext(c); !ok || tree.TreeId != 12345 {
+				t.Errorf("%v: reconnected without the tree in the context", test.desc)
+			}
+		}
+		if got, want := len(sleeps), len(test.wantSleeps); got != want {
+			t.Errorf("%v: slept %d times, want %d", test.desc, got, want)
+			continue
+		}
+		for i, d := range sleeps {
+			// Jitter adds up to the backoff again.
+			if min := test.wantSleeps[i]; d < min || d >= 2*min {
+				t.Errorf("%v: sleep %d = %v, want backoff from %v", test.desc, i, d, min)
+			}
+		}
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{err: io.EOF, want: true},
+		{err: syscall.ECONNREFUSED, want: true},
+		{err: errDropped, want: true},
+		{err: status.Errorf(codes.Unavailable, "transport is closing"), want: true},
+		{err: status.Errorf(codes.PermissionDenied, "not allowed")},
+		{err: status.Errorf(codes.Unauthenticated, "bad token")},
+		{err: errors.New("key not found")},
+	} {
+		if got := IsConnectionError(test.err); got != test.want {
+			t.Errorf("IsConnectionError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
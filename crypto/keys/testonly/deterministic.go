@@ -0,0 +1,175 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package testonly
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"flag"
+	"io"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/crypto/keys"
+)
+
+// DeterministicSignerFactory is a keys.SignerFactory whose signers always produce the same
+// signature for the same key and digest, so tests can compare signed data byte for byte.
+// ECDSA signatures use the deterministic nonces of RFC 6979; other signature algorithms
+// supported by the wrapped factory, such as RSA PKCS #1 v1.5, are deterministic already.
+// The signatures are ordinary signatures that verify like any other.
+//
+// The factory refuses to create signers outside of tests, as deterministic nonces make
+// the keys' safety depend entirely on the implementation of this test-only code.
+type DeterministicSignerFactory struct {
+	keys.SignerFactory
+}
+
+// NewDeterministicSignerFactory returns a DeterministicSignerFactory that loads and
+// generates keys with keys.DefaultSignerFactory.
+func NewDeterministicSignerFactory() *DeterministicSignerFactory {
+	return &DeterministicSignerFactory{SignerFactory: &keys.DefaultSignerFactory{}}
+}
+
+// NewSigner returns a deterministic signer for the key described by pb.
+func (f *DeterministicSignerFactory) NewSigner(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
+	if flag.Lookup("test.v") == nil {
+		return nil, errors.New("deterministic signers are only available in tests")
+	}
+	signer, err := f.SignerFactory.NewSigner(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := signer.(*ecdsa.PrivateKey); ok {
+		return deterministicECDSASigner{key}, nil
+	}
+	return signer, nil
+}
+
+// deterministicECDSASigner signs with an ECDSA key using the nonces of RFC 6979, ignoring the
+// randomness source it is given.
+type deterministicECDSASigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s deterministicECDSASigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s deterministicECDSASigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash := opts.HashFunc()
+	if !hash.Available() {
+		return nil, errors.New("deterministic ECDSA signing requires a hash function")
+	}
+	n := s.key.Params().N
+	e := bitsToInt(digest, n.BitLen())
+
+	var r, sig *big.Int
+	nonces := newRFC6979Nonces(s.key, digest, hash)
+	for {
+		k := nonces.next()
+		x, _ := s.key.Curve.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = k^-1 * (e + r*d) mod n
+		sig = new(big.Int).Mul(r, s.key.D)
+		sig.Add(sig, e)
+		sig.Mul(sig, new(big.Int).ModInverse(k, n))
+		sig.Mod(sig, n)
+		if sig.Sign() != 0 {
+			break
+		}
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, sig})
+}
+
+// rfc6979Nonces generates the candidate nonces of section 3.2 of RFC 6979.
+type rfc6979Nonces struct {
+	hash crypto.Hash
+	n    *big.Int
+	k, v []byte
+}
+
+func newRFC6979Nonces(key *ecdsa.PrivateKey, digest []byte, hash crypto.Hash) *rfc6979Nonces {
+	n := key.Params().N
+	rolen := (n.BitLen() + 7) / 8
+	g := &rfc6979Nonces{
+		hash: hash,
+		n:    n,
+		k:    make([]byte, hash.Size()),
+		v:    make([]byte, hash.Size()),
+	}
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+
+	x := intToOctets(key.D, rolen)
+	h := intToOctets(new(big.Int).Mod(bitsToInt(digest, n.BitLen()), n), rolen)
+	for _, sep := range []byte{0x00, 0x01} {
+		g.k = g.mac(g.k, g.v, []byte{sep}, x, h)
+		g.v = g.mac(g.k, g.v)
+	}
+	return g
+}
+
+// next returns the next candidate nonce, in [1, n).
+func (g *rfc6979Nonces) next() *big.Int {
+	for {
+		var t []byte
+		for len(t)*8 < g.n.BitLen() {
+			g.v = g.mac(g.k, g.v)
+			t = append(t, g.v...)
+		}
+		k := bitsToInt(t, g.n.BitLen())
+		// Prepare the state for the candidate after this one, in case k is rejected here
+		// or by the caller.
+		g.k = g.mac(g.k, g.v, []byte{0x00})
+		g.v = g.mac(g.k, g.v)
+		if k.Sign() > 0 && k.Cmp(g.n) < 0 {
+			return k
+		}
+	}
+}
+
+func (g *rfc6979Nonces) mac(key []byte, data ...[]byte) []byte {
+	m := hmac.New(g.hash.New, key)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+// bitsToInt interprets the leftmost qlen bits of b as an integer.
+func bitsToInt(b []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - qlen; excess > 0 {
+		v.Rsh(v, uint(excess))
+	}
+	return v
+}
+
+// intToOctets returns v as a big-endian byte string of length rolen.
+func intToOctets(v *big.Int, rolen int) []byte {
+	b := v.Bytes()
+	if len(b) >= rolen {
+		return b[len(b)-rolen:]
+	}
+	return append(make([]byte, rolen-len(b)), b...)
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package testonly
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	tcrypto "github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/testonly"
+)
+
+func mustParseHex(t *testing.T, s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("invalid hex %q", s)
+	}
+	return v
+}
+
+// TestDeterministicECDSA checks signatures against the P-256 and SHA-256 test vector of
+// RFC 6979, appendix A.2.5.
+func TestDeterministicECDSA(t *testing.T) {
+	d := mustParseHex(t, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	key := &ecdsa.PrivateKey{D: d}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(d.Bytes())
+
+	digest := sha256.Sum256([]byte("sample"))
+	sig, err := deterministicECDSASigner{key}.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign() = (_, %v), want (_, nil)", err)
+	}
+	var got struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &got); err != nil {
+		t.Fatalf("asn1.Unmarshal(signature) = %v", err)
+	}
+	wantR := mustParseHex(t, "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716")
+	wantS := mustParseHex(t, "F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8")
+	if got.R.Cmp(wantR) != 0 || got.S.Cmp(wantS) != 0 {
+		t.Errorf("Sign() = (r: %X, s: %X), want (r: %X, s: %X)", got.R, got.S, wantR, wantS)
+	}
+}
+
+func TestDeterministicSignerFactory(t *testing.T) {
+	ctx := context.Background()
+	sf := NewDeterministicSignerFactory()
+
+	for _, test := range []struct {
+		desc string
+		pb   proto.Message
+	}{
+		{
+			desc: "demoKey",
+			pb:   &keyspb.PrivateKey{Der: MustMarshalPrivatePEMToDER(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)},
+		},
+		{
+			desc: "generatedKey",
+			pb: func() proto.Message {
+				pb, err := sf.Generate(ctx, &keyspb.Specification{Params: &keyspb.Specification_EcdsaParams{}})
+				if err != nil {
+					t.Fatalf("Generate() = (_, %v), want (_, nil)", err)
+				}
+				return pb
+			}(),
+		},
+	} {
+		signer, err := sf.NewSigner(ctx, test.pb)
+		if err != nil {
+			t.Errorf("%v: NewSigner() = (_, %v), want (_, nil)", test.desc, err)
+			continue
+		}
+		if _, ok := signer.(deterministicECDSASigner); !ok {
+			t.Errorf("%v: NewSigner() = %T, want deterministicECDSASigner", test.desc, signer)
+		}
+
+		data := []byte("signed root")
+		s := tcrypto.NewSHA256Signer(signer)
+		sig1, err := s.Sign(data)
+		if err != nil {
+			t.Fatalf("%v: Sign() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		sig2, err := s.Sign(data)
+		if err != nil {
+			t.Fatalf("%v: Sign() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		if !bytes.Equal(sig1.Signature, sig2.Signature) {
+			t.Errorf("%v: Sign() returned different signatures of the same data", test.desc)
+		}
+		if err := tcrypto.Verify(signer.Public(), data, sig1); err != nil {
+			t.Errorf("%v: Verify() = %v, want nil", test.desc, err)
+		}
+		if got, want := sig1.SignatureAlgorithm, keys.SignatureAlgorithm(signer.Public()); got != want {
+			t.Errorf("%v: SignatureAlgorithm = %v, want %v", test.desc, got, want)
+		}
+	}
+}
@@ -289,7 +289,9 @@ func (m *PublicKey) GetDer() []byte {
 	return nil
 }
 
-// PKCS11Config identifies a private key accessed using PKCS #11.
+// PKCS11Config identifies a private key accessed using PKCS #11, e.g. one
+// held in an HSM.
+// The PIN of the token is taken from exactly one of pin, pin_file or pin_env.
 type PKCS11Config struct {
 	// The label of the PKCS#11 token.
 	TokenLabel string `protobuf:"bytes,1,opt,name=token_label,json=tokenLabel" json:"token_label,omitempty"`
@@ -297,6 +299,15 @@ type PKCS11Config struct {
 	Pin string `protobuf:"bytes,2,opt,name=pin" json:"pin,omitempty"`
 	// The PEM public key assosciated with the private key to be used.
 	PublicKey string `protobuf:"bytes,3,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	// Path to the PKCS#11 module (shared library) used to access the token.
+	// If empty, the module the server was configured with is used.
+	ModulePath string `protobuf:"bytes,4,opt,name=module_path,json=modulePath" json:"module_path,omitempty"`
+	// Path to a file, local to the server, containing the PIN for the token.
+	// Keeps the PIN out of tree storage.
+	PinFile string `protobuf:"bytes,5,opt,name=pin_file,json=pinFile" json:"pin_file,omitempty"`
+	// Name of an environment variable of the server containing the PIN for the
+	// token. Keeps the PIN out of tree storage.
+	PinEnv string `protobuf:"bytes,6,opt,name=pin_env,json=pinEnv" json:"pin_env,omitempty"`
 }
 
 func (m *PKCS11Config) Reset()                    { *m = PKCS11Config{} }
@@ -325,6 +336,27 @@ func (m *PKCS11Config) GetPublicKey() string {
 	return ""
 }
 
+func (m *PKCS11Config) GetModulePath() string {
+	if m != nil {
+		return m.ModulePath
+	}
+	return ""
+}
+
+func (m *PKCS11Config) GetPinFile() string {
+	if m != nil {
+		return m.PinFile
+	}
+	return ""
+}
+
+func (m *PKCS11Config) GetPinEnv() string {
+	if m != nil {
+		return m.PinEnv
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Specification)(nil), "keyspb.Specification")
 	proto.RegisterType((*Specification_ECDSA)(nil), "keyspb.Specification.ECDSA")
@@ -339,28 +371,31 @@ func init() {
 func init() { proto.RegisterFile("keyspb.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 361 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcf, 0xef, 0xd2, 0x40,
-	0x10, 0xc5, 0x81, 0x52, 0x42, 0x87, 0x62, 0xea, 0x9e, 0x00, 0x83, 0x3f, 0x7a, 0xf2, 0xd4, 0x84,
-	0x22, 0x4a, 0x8c, 0x07, 0xb1, 0x94, 0x98, 0x80, 0x49, 0xb3, 0x15, 0xaf, 0x75, 0x5b, 0x16, 0xdd,
-	0x50, 0xdb, 0xcd, 0xb6, 0x60, 0xea, 0x1f, 0xe5, 0xdf, 0x68, 0x3a, 0x05, 0x8d, 0x09, 0xf9, 0xde,
-	0xde, 0xec, 0xbc, 0xcf, 0xbc, 0x99, 0x64, 0xc1, 0x3c, 0xf1, 0xaa, 0x90, 0xb1, 0x23, 0x55, 0x5e,
-	0xe6, 0xa4, 0xd7, 0x54, 0xf6, 0xef, 0x0e, 0x0c, 0x43, 0xc9, 0x13, 0x71, 0x14, 0x09, 0x2b, 0x45,
-	0x9e, 0x91, 0xf7, 0x60, 0xf2, 0xe4, 0x50, 0xb0, 0x48, 0x32, 0xc5, 0x7e, 0x14, 0xa3, 0xf6, 0xf3,
-	0xf6, 0xcb, 0x81, 0xfb, 0xc4, 0xb9, 0xe2, 0xff, 0x99, 0x1d, 0xdf, 0x5b, 0x87, 0xab, 0x8f, 0x2d,
-	0x3a, 0x40, 0x24, 0x40, 0x82, 0xbc, 0x05, 0x50, 0xff, 0xf8, 0x0e, 0xf2, 0xe3, 0xfb, 0x3c, 0x45,
-	0xda, 0x50, 0x37, 0x76, 0xf2, 0x0b, 0x74, 0x9c, 0x49, 0xde, 0x80, 0x9e, 0x9c, 0xd5, 0x85, 0x63,
-	0xfe, 0x23, 0xf7, 0xc5, 0x03, 0xf9, 0x8e, 0x57, 0x1b, 0x69, 0xe3, 0xb7, 0x97, 0xa0, 0x63, 0x4d,
-	0x1e, 0xc3, 0x70, 0xed, 0x6f, 0x56, 0xfb, 0xdd, 0xe7, 0xc8, 0xdb, 0xd3, 0x2f, 0xbe, 0xd5, 0x22,
-	0x7d, 0xe8, 0x06, 0xee, 0xe2, 0xb5, 0xd5, 0x46, 0x35, 0x5f, 0xbe, 0xb2, 0x3a, 0xa8, 0x16, 0xee,
-	0xcc, 0xd2, 0x26, 0x63, 0xd0, 0x68, 0xb8, 0x22, 0x04, 0xba, 0xb1, 0x28, 0x9b, 0xc3, 0x75, 0x8a,
-	0xfa, 0x43, 0x1f, 0x7a, 0xcd, 0x39, 0xf6, 0x3b, 0x80, 0xc0, 0xff, 0xb4, 0xe5, 0xd5, 0x46, 0xa4,
-	0xbc, 0xf6, 0x4a, 0x56, 0x7e, 0x47, 0xaf, 0x41, 0x51, 0x93, 0x09, 0xf4, 0x25, 0x2b, 0x8a, 0x9f,
-	0xb9, 0x3a, 0xe0, 0xf1, 0x06, 0xfd, 0x5b, 0xdb, 0x4f, 0x01, 0x02, 0x25, 0x2e, 0xac, 0xe4, 0x5b,
-	0x5e, 0x11, 0x0b, 0xb4, 0x03, 0x57, 0x08, 0x9b, 0xb4, 0x96, 0xf6, 0x14, 0x8c, 0xe0, 0x1c, 0xa7,
-	0x22, 0xb9, 0xdf, 0xfe, 0x0a, 0x66, 0xb0, 0xf5, 0xc2, 0xd9, 0xcc, 0xcb, 0xb3, 0xa3, 0xf8, 0x46,
-	0x9e, 0xc1, 0xa0, 0xcc, 0x4f, 0x3c, 0x8b, 0x52, 0x16, 0xf3, 0xf4, 0xba, 0x05, 0xe0, 0xd3, 0xae,
-	0x7e, 0xa9, 0x47, 0x48, 0x91, 0x5d, 0xd7, 0xa8, 0x25, 0x99, 0x02, 0x48, 0x4c, 0x88, 0x4e, 0xbc,
-	0x1a, 0x69, 0xd8, 0x30, 0xe4, 0x2d, 0x33, 0xee, 0xe1, 0xf7, 0x98, 0xff, 0x09, 0x00, 0x00, 0xff,
-	0xff, 0xf4, 0x33, 0xd7, 0x5f, 0x2e, 0x02, 0x00, 0x00,
+	// 411 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0x4f, 0x6f, 0xd3, 0x40,
+	0x10, 0xc5, 0x9b, 0x3f, 0x36, 0xf1, 0x24, 0x45, 0x66, 0x2f, 0x24, 0x41, 0xa5, 0xe0, 0x13, 0x27,
+	0x4b, 0x71, 0x29, 0x54, 0x88, 0x03, 0xc1, 0x75, 0x85, 0x94, 0x22, 0x59, 0x6b, 0xca, 0xd5, 0x5a,
+	0xdb, 0x1b, 0x58, 0xc5, 0x5d, 0xaf, 0xd6, 0x8e, 0x91, 0xf9, 0x50, 0xdc, 0xf8, 0x7e, 0x68, 0xc7,
+	0xa1, 0x08, 0xa9, 0xea, 0xed, 0xcd, 0xcc, 0xfb, 0xcd, 0xec, 0xb3, 0x0c, 0xb3, 0x1d, 0xef, 0x6a,
+	0x95, 0xf9, 0x4a, 0x57, 0x4d, 0x45, 0xec, 0xbe, 0xf2, 0x7e, 0x0d, 0xe1, 0x38, 0x51, 0x3c, 0x17,
+	0x5b, 0x91, 0xb3, 0x46, 0x54, 0x92, 0x7c, 0x80, 0x19, 0xcf, 0x8b, 0x9a, 0xa5, 0x8a, 0x69, 0x76,
+	0x5b, 0xcf, 0x07, 0x2f, 0x06, 0xaf, 0xa6, 0xc1, 0x33, 0xff, 0x80, 0xff, 0x67, 0xf6, 0xa3, 0xf0,
+	0x32, 0x59, 0x7f, 0x3a, 0xa2, 0x53, 0x44, 0x62, 0x24, 0xc8, 0x3b, 0x00, 0xfd, 0x8f, 0x1f, 0x22,
+	0xbf, 0xb8, 0x9f, 0xa7, 0x48, 0x3b, 0xfa, 0x2f, 0xbb, 0xfc, 0x09, 0x16, 0xee, 0x24, 0x6f, 0xc1,
+	0xca, 0xf7, 0xba, 0xe5, 0x78, 0xff, 0x71, 0xf0, 0xf2, 0x81, 0xfb, 0x7e, 0x68, 0x8c, 0xb4, 0xf7,
+	0x7b, 0x17, 0x60, 0x61, 0x4d, 0x9e, 0xc0, 0xf1, 0x65, 0x74, 0xb5, 0xbe, 0xb9, 0xfe, 0x92, 0x86,
+	0x37, 0xf4, 0x6b, 0xe4, 0x1e, 0x91, 0x09, 0x8c, 0xe3, 0xe0, 0xfc, 0x8d, 0x3b, 0x40, 0x75, 0x76,
+	0xf1, 0xda, 0x1d, 0xa2, 0x3a, 0x0f, 0x56, 0xee, 0x68, 0xb9, 0x80, 0x11, 0x4d, 0xd6, 0x84, 0xc0,
+	0x38, 0x13, 0x4d, 0x1f, 0xdc, 0xa2, 0xa8, 0x3f, 0x4e, 0xc0, 0xee, 0xe3, 0x78, 0xef, 0x01, 0xe2,
+	0xe8, 0xf3, 0x86, 0x77, 0x57, 0xa2, 0xe4, 0xc6, 0xab, 0x58, 0xf3, 0x1d, 0xbd, 0x0e, 0x45, 0x4d,
+	0x96, 0x30, 0x51, 0xac, 0xae, 0x7f, 0x54, 0xba, 0xc0, 0xf0, 0x0e, 0xbd, 0xab, 0xbd, 0xe7, 0x00,
+	0xb1, 0x16, 0x2d, 0x6b, 0xf8, 0x86, 0x77, 0xc4, 0x85, 0x51, 0xc1, 0x35, 0xc2, 0x33, 0x6a, 0xa4,
+	0x77, 0x02, 0x4e, 0xbc, 0xcf, 0x4a, 0x91, 0xdf, 0x3f, 0xfe, 0x3d, 0x80, 0x59, 0xbc, 0x09, 0x93,
+	0xd5, 0x2a, 0xac, 0xe4, 0x56, 0x7c, 0x23, 0xa7, 0x30, 0x6d, 0xaa, 0x1d, 0x97, 0x69, 0xc9, 0x32,
+	0x5e, 0x1e, 0x9e, 0x01, 0xd8, 0xba, 0x36, 0x1d, 0xb3, 0x43, 0x09, 0x79, 0x78, 0x87, 0x91, 0xe4,
+	0x04, 0x40, 0xe1, 0x89, 0x74, 0xc7, 0xbb, 0xf9, 0x08, 0x07, 0x8e, 0xba, 0x3b, 0x7a, 0x0a, 0xd3,
+	0xdb, 0xaa, 0xd8, 0x97, 0x3c, 0xc5, 0x60, 0xe3, 0x7e, 0x63, 0xdf, 0x8a, 0x4d, 0xbc, 0x05, 0x4c,
+	0x94, 0x90, 0xe9, 0x56, 0x94, 0x7c, 0x6e, 0xe1, 0xf4, 0x91, 0x12, 0x12, 0xbf, 0xc6, 0x53, 0x30,
+	0x32, 0xe5, 0xb2, 0x9d, 0xdb, 0x38, 0xb1, 0x95, 0x90, 0x91, 0x6c, 0x33, 0x1b, 0x7f, 0xba, 0xb3,
+	0x3f, 0x03, 0x00, 0xe0, 0xb4, 0x24, 0xf5, 0x84, 0x02, 0x00, 0x00,
 }
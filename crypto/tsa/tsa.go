@@ -0,0 +1,223 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tsa requests timestamp tokens from RFC 3161 timestamp authorities.
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+)
+
+// maxResponseBytes bounds the size of the responses read from a timestamp authority.
+const maxResponseBytes = 1 << 20
+
+var (
+	oidSHA256     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+)
+
+// PKIStatus values of a TimeStampResp that mean the token was granted.
+const (
+	statusGranted         = 0
+	statusGrantedWithMods = 1
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timeStampReq is a TimeStampReq, RFC 3161 section 2.4.1.
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString asn1.RawValue  `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// timeStampResp is a TimeStampResp, RFC 3161 section 2.4.2.
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// The types below are the parts of a TimeStampToken, a CMS ContentInfo wrapping SignedData
+// with a TSTInfo, that are needed to check it matches its request.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+}
+
+type encapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,tag:0"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        asn1.RawValue
+	Accuracy       accuracy `asn1:"optional"`
+	Ordering       bool     `asn1:"optional,default:false"`
+	Nonce          *big.Int `asn1:"optional"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// Client requests timestamp tokens from an RFC 3161 timestamp authority over HTTP.
+type Client struct {
+	// URL of the timestamp authority.
+	URL string
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Timestamp requests a timestamp token for a SHA-256 digest, returning the DER-encoded
+// TimeStampToken. The token is checked to cover digest and the request's nonce, but its
+// signature isn't verified: that's left to whoever relies on the token, as it needs the
+// authority's certificate.
+func (c *Client) Timestamp(ctx context.Context, digest []byte) ([]byte, error) {
+	if len(digest) != 32 {
+		return nil, fmt.Errorf("tsa: digest has %d bytes, want a 32-byte SHA-256 digest", len(digest))
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, fmt.Errorf("tsa: failed to generate nonce: %v", err)
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tsa: failed to marshal request: %v", err)
+	}
+
+	body, err := c.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return parseResponse(body, digest, nonce)
+}
+
+func (c *Client) post(ctx context.Context, req []byte) ([]byte, error) {
+	httpReq, err := http.NewRequest("POST", c.URL, bytes.NewReader(req))
+	if err != nil {
+		return nil, fmt.Errorf("tsa: failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	rsp, err := httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("tsa: request failed: %v", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tsa: authority returned HTTP status %v", rsp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(rsp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("tsa: failed to read response: %v", err)
+	}
+	return body, nil
+}
+
+// parseResponse returns the token of a DER-encoded TimeStampResp, checking it was granted
+// for digest and nonce.
+func parseResponse(der, digest []byte, nonce *big.Int) ([]byte, error) {
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, fmt.Errorf("tsa: failed to parse response: %v", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("tsa: trailing data after response")
+	}
+	if s := resp.Status.Status; s != statusGranted && s != statusGrantedWithMods {
+		return nil, fmt.Errorf("tsa: request rejected with status %d", s)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("tsa: response has no token")
+	}
+
+	info, err := parseToken(resp.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return nil, errors.New("tsa: token doesn't cover the requested digest")
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("tsa: token doesn't match the request's nonce")
+	}
+	return resp.TimeStampToken.FullBytes, nil
+}
+
+// parseToken extracts the TSTInfo of a TimeStampToken.
+func parseToken(der []byte) (*tstInfo, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("tsa: failed to parse token: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("tsa: token has content type %v, want SignedData", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("tsa: failed to parse token SignedData: %v", err)
+	}
+	if !sd.EncapContentInfo.ContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("tsa: token encapsulates %v, want TSTInfo", sd.EncapContentInfo.ContentType)
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.Content, &info); err != nil {
+		return nil, fmt.Errorf("tsa: failed to parse TSTInfo: %v", err)
+	}
+	return &info, nil
+}
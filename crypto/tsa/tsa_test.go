@@ -0,0 +1,185 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tsa
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test-only encodings of the response types: the [0] wrapper of ContentInfo is built by
+// hand, as asn1.Marshal writes RawValues verbatim.
+type testContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type testSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+}
+
+type testTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        asn1.RawValue
+	Accuracy       accuracy
+	Nonce          *big.Int `asn1:"optional"`
+}
+
+type testTimeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// fakeAuthority answers timestamp requests, letting tests tamper with its answers.
+type fakeAuthority struct {
+	status     int
+	httpStatus int
+	digest     []byte   // If set, replaces the requested digest.
+	nonce      *big.Int // If set, replaces the requested nonce.
+}
+
+func (f *fakeAuthority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.httpStatus != 0 {
+		w.WriteHeader(f.httpStatus)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "application/timestamp-query" {
+		http.Error(w, "bad content type "+ct, http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := testTimeStampResp{Status: pkiStatusInfo{Status: f.status}}
+	if f.status == statusGranted {
+		token, err := f.token(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+	}
+	der, err := asn1.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(der)
+}
+
+func (f *fakeAuthority) token(req timeStampReq) ([]byte, error) {
+	imprint, nonce := req.MessageImprint, req.Nonce
+	if f.digest != nil {
+		imprint.HashedMessage = f.digest
+	}
+	if f.nonce != nil {
+		nonce = f.nonce
+	}
+	genTime, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagGeneralizedTime, Bytes: []byte("20171015000000Z")})
+	if err != nil {
+		return nil, err
+	}
+	info, err := asn1.Marshal(testTSTInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(1),
+		GenTime:        asn1.RawValue{FullBytes: genTime},
+		Accuracy:       accuracy{Seconds: 1},
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(testSignedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{imprint.HashAlgorithm},
+		EncapContentInfo: encapContentInfo{ContentType: oidTSTInfo, Content: info},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(testContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+func TestTimestamp(t *testing.T) {
+	digest := sha256.Sum256([]byte("root"))
+	other := sha256.Sum256([]byte("other root"))
+
+	tests := []struct {
+		desc    string
+		fake    fakeAuthority
+		digest  []byte
+		wantErr string
+	}{
+		{desc: "granted", digest: digest[:]},
+		{desc: "rejected", fake: fakeAuthority{status: 2}, digest: digest[:], wantErr: "rejected"},
+		{desc: "httpError", fake: fakeAuthority{httpStatus: http.StatusServiceUnavailable}, digest: digest[:], wantErr: "HTTP status"},
+		{desc: "wrongDigest", fake: fakeAuthority{digest: other[:]}, digest: digest[:], wantErr: "digest"},
+		{desc: "wrongNonce", fake: fakeAuthority{nonce: big.NewInt(-1)}, digest: digest[:], wantErr: "nonce"},
+		{desc: "shortDigest", digest: digest[:16], wantErr: "32-byte"},
+	}
+	for _, test := range tests {
+		fake := test.fake
+		srv := httptest.NewServer(&fake)
+		c := &Client{URL: srv.URL}
+
+		token, err := c.Timestamp(context.Background(), test.digest)
+		srv.Close()
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%v: Timestamp() returned err = %v, want an error containing %q", test.desc, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Timestamp() returned err = %v", test.desc, err)
+			continue
+		}
+		info, err := parseToken(token)
+		if err != nil {
+			t.Errorf("%v: parseToken() returned err = %v", test.desc, err)
+			continue
+		}
+		if got := info.MessageImprint.HashedMessage; string(got) != string(test.digest) {
+			t.Errorf("%v: token covers digest %x, want %x", test.desc, got, test.digest)
+		}
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"math/big"
 
 	"github.com/benlaurie/objecthash/go/objecthash"
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
 )
@@ -77,6 +78,24 @@ func Verify(pub crypto.PublicKey, data []byte, sig *sigpb.DigitallySigned) error
 	}
 }
 
+// VerifyLogRoot verifies the signature of root made with pub's signature algorithm, which is
+// either root.Signature or one of root.AdditionalSignatures. This lets verifiers supporting a
+// single algorithm verify roots signed with several.
+func VerifyLogRoot(pub crypto.PublicKey, root trillian.SignedLogRoot) error {
+	alg := keys.SignatureAlgorithm(pub)
+	hash := HashLogRoot(root)
+	err := fmt.Errorf("log root has no %v signature", alg)
+	for _, sig := range append([]*sigpb.DigitallySigned{root.Signature}, root.AdditionalSignatures...) {
+		if sig.GetSignatureAlgorithm() != alg {
+			continue
+		}
+		if err = Verify(pub, hash, sig); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
 func verifyRSA(pub *rsa.PublicKey, hashed, sig []byte, hasher crypto.Hash, opts crypto.SignerOpts) error {
 	if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
 		return rsa.VerifyPSS(pub, hasher, hashed, sig, pssOpts)
@@ -15,8 +15,12 @@
 package crypto
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
 
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/testonly"
@@ -81,3 +85,54 @@ func TestSignVerify(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyLogRoot(t *testing.T) {
+	ecdsaKey, err := keys.NewFromPrivatePEM(privPEM, "")
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM() returned err = %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() returned err = %v", err)
+	}
+	otherKey, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM() returned err = %v", err)
+	}
+
+	root := trillian.SignedLogRoot{TimestampNanos: 1, TreeSize: 2, RootHash: []byte("root")}
+	ecdsaSig, err := NewSHA256Signer(ecdsaKey).Sign(HashLogRoot(root))
+	if err != nil {
+		t.Fatalf("Sign() returned err = %v", err)
+	}
+	rsaSig, err := NewSHA256Signer(rsaKey).Sign(HashLogRoot(root))
+	if err != nil {
+		t.Fatalf("Sign() returned err = %v", err)
+	}
+	dualRoot := root
+	dualRoot.Signature = ecdsaSig
+	dualRoot.AdditionalSignatures = []*sigpb.DigitallySigned{rsaSig}
+	singleRoot := root
+	singleRoot.Signature = ecdsaSig
+	tamperedRoot := dualRoot
+	tamperedRoot.TreeSize++
+
+	for _, test := range []struct {
+		desc    string
+		root    trillian.SignedLogRoot
+		signer  crypto.Signer
+		wantErr bool
+	}{
+		{desc: "primary", root: dualRoot, signer: ecdsaKey},
+		{desc: "additional", root: dualRoot, signer: rsaKey},
+		{desc: "singleSignature", root: singleRoot, signer: ecdsaKey},
+		{desc: "noMatchingAlgorithm", root: singleRoot, signer: rsaKey, wantErr: true},
+		{desc: "wrongKey", root: dualRoot, signer: otherKey, wantErr: true},
+		{desc: "tampered", root: tamperedRoot, signer: rsaKey, wantErr: true},
+	} {
+		err := VerifyLogRoot(test.signer.Public(), test.root)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: VerifyLogRoot()=%v, want err? %t", test.desc, err, test.wantErr)
+		}
+	}
+}
@@ -34,7 +34,7 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func TestAdminServer_Unimplemented(t *testing.T) {
+func TestAdminServer_DeleteTree(t *testing.T) {
 	client, closeFn, err := setupAdminServer()
 	if err != nil {
 		t.Fatalf("setupAdminServer() failed: %v", err)
@@ -47,24 +47,40 @@ func TestAdminServer_Unimplemented(t *testing.T) {
 		t.Fatalf("CreateTree returned err = %v", err)
 	}
 
-	tests := []struct {
-		desc string
-		fn   func(context.Context, trillian.TrillianAdminClient) error
-	}{
-		{
-			desc: "DeleteTree",
-			fn: func(ctx context.Context, c trillian.TrillianAdminClient) error {
-				_, err := c.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: tree.TreeId})
-				return err
-			},
-		},
+	if _, err := client.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: tree.TreeId}); err != nil {
+		t.Fatalf("DeleteTree returned err = %v", err)
 	}
-	for _, test := range tests {
-		err := test.fn(ctx, client)
-		if s, ok := status.FromError(err); !ok || s.Code() != codes.Unimplemented {
-			t.Errorf("%v: got = %v, want = %s", test.desc, err, codes.Unimplemented)
+	deleted, err := client.GetTree(ctx, &trillian.GetTreeRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("GetTree returned err = %v", err)
+	}
+	if got, want := deleted.TreeState, trillian.TreeState_SOFT_DELETED; got != want {
+		t.Errorf("DeleteTree: TreeState = %s, want = %s", got, want)
+	}
+	if deleted.DeleteTime == nil {
+		t.Error("DeleteTree: DeleteTime = nil, want non-nil")
+	}
+
+	resp, err := client.ListTrees(ctx, &trillian.ListTreesRequest{})
+	if err != nil {
+		t.Fatalf("ListTrees returned err = %v", err)
+	}
+	for _, listed := range resp.Tree {
+		if listed.TreeId == tree.TreeId {
+			t.Errorf("ListTrees returned deleted tree %v", tree.TreeId)
 		}
 	}
+
+	undeleted, err := client.UndeleteTree(ctx, &trillian.UndeleteTreeRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("UndeleteTree returned err = %v", err)
+	}
+	if got, want := undeleted.TreeState, trillian.TreeState_FROZEN; got != want {
+		t.Errorf("UndeleteTree: TreeState = %s, want = %s", got, want)
+	}
+	if undeleted.DeleteTime != nil {
+		t.Errorf("UndeleteTree: DeleteTime = %v, want nil", undeleted.DeleteTime)
+	}
 }
 
 func TestAdminServer_CreateTree(t *testing.T) {
@@ -0,0 +1,129 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+)
+
+// LeafFailures counts the consecutive sequencing passes that each queued leaf has failed,
+// so leaves that keep failing can be dead-lettered. A LeafFailures is shared by the
+// sequencers of successive passes and is safe for concurrent use.
+type LeafFailures struct {
+	mu sync.Mutex
+	// counts is keyed by leafFailureKey.
+	counts map[string]int
+}
+
+// NewLeafFailures creates an empty LeafFailures.
+func NewLeafFailures() *LeafFailures {
+	return &LeafFailures{counts: make(map[string]int)}
+}
+
+func leafFailureKey(logID int64, leafIdentityHash []byte) string {
+	return fmt.Sprintf("%d/%x", logID, leafIdentityHash)
+}
+
+// Add records a failed pass for a leaf, returning the number of passes it has failed.
+func (f *LeafFailures) Add(logID int64, leafIdentityHash []byte) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := leafFailureKey(logID, leafIdentityHash)
+	f.counts[k]++
+	return f.counts[k]
+}
+
+// Forget drops the failures recorded for a leaf.
+func (f *LeafFailures) Forget(logID int64, leafIdentityHash []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, leafFailureKey(logID, leafIdentityHash))
+}
+
+// validateLeaf checks that a dequeued leaf can be integrated into the Merkle tree.
+func (s Sequencer) validateLeaf(leaf *trillian.LogLeaf) error {
+	if got, want := len(leaf.MerkleLeafHash), s.hasher.Size(); got != want {
+		return fmt.Errorf("Merkle leaf hash has length %d, want %d", got, want)
+	}
+	return nil
+}
+
+// checkLeaves returns the dequeued leaves that can be sequenced. Without dead-lettering any
+// leaf that can't be sequenced fails the pass. With dead-lettering such leaves fail the pass
+// until they've failed deadLetterAttempts passes, and are then dead-lettered so the
+// remaining leaves can be sequenced.
+func (s Sequencer) checkLeaves(ctx context.Context, tx storage.LogTreeTX, logID int64, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	valid := make([]*trillian.LogLeaf, 0, len(leaves))
+	var dead []*trillian.DeadLetteredLeaf
+	var failed error
+	// Duplicate leaves share an identity hash, and only count as one failure per pass.
+	attempts := make(map[string]int)
+	for _, leaf := range leaves {
+		err := s.validateLeaf(leaf)
+		if err == nil {
+			valid = append(valid, leaf)
+			continue
+		}
+		if s.deadLetterAttempts <= 0 || s.leafFailures == nil {
+			return nil, fmt.Errorf("%v: leaf %x can't be sequenced: %v", logID, leaf.LeafIdentityHash, err)
+		}
+		h := string(leaf.LeafIdentityHash)
+		if _, ok := attempts[h]; !ok {
+			attempts[h] = s.leafFailures.Add(logID, leaf.LeafIdentityHash)
+		}
+		if attempts[h] < s.deadLetterAttempts {
+			if failed == nil {
+				failed = fmt.Errorf("%v: leaf %x can't be sequenced (attempt %d of %d): %v", logID, leaf.LeafIdentityHash, attempts[h], s.deadLetterAttempts, err)
+			}
+			continue
+		}
+		dead = append(dead, &trillian.DeadLetteredLeaf{
+			Leaf:     leaf,
+			Reason:   err.Error(),
+			Attempts: int32(attempts[h]),
+		})
+	}
+	if failed != nil {
+		return nil, failed
+	}
+
+	if len(dead) > 0 {
+		now, err := ptypes.TimestampProto(s.timeSource.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, dl := range dead {
+			dl.DeadLetterTime = now
+		}
+		if err := tx.DeadLetterLeaves(ctx, dead); err != nil {
+			glog.Warningf("%v: Sequencer failed to dead-letter leaves: %v", logID, err)
+			return nil, err
+		}
+		for _, dl := range dead {
+			s.leafFailures.Forget(logID, dl.Leaf.LeafIdentityHash)
+			glog.Errorf("%v: dead-lettered leaf %x after %d attempts: %v", logID, dl.Leaf.LeafIdentityHash, dl.Attempts, dl.Reason)
+		}
+		seqDeadLettered.Add(float64(len(dead)), strconv.FormatInt(logID, 10))
+	}
+	return valid, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// LeafValidator checks that leaves are well-formed according to the rules of a personality,
+// for example that a CT log leaf holds a parseable certificate. The log server calls it on
+// every leaf of a QueueLeaves request, before anything is written to storage.
+type LeafValidator interface {
+	// ValidateLeaf returns an error describing why leaf can't be added to tree, or nil if
+	// the leaf is acceptable. The leaf's MerkleLeafHash is already set.
+	ValidateLeaf(ctx context.Context, tree *trillian.Tree, leaf *trillian.LogLeaf) error
+}
+
+var leafValidators = make(map[trillian.TreeType]LeafValidator)
+
+// RegisterLeafValidator makes v validate the leaves queued to all trees of treeType. It
+// should be called from an init function linked into the log server.
+func RegisterLeafValidator(treeType trillian.TreeType, v LeafValidator) {
+	if treeType == trillian.TreeType_UNKNOWN_TREE_TYPE {
+		panic(fmt.Sprintf("RegisterLeafValidator(%s) of unknown tree type", treeType))
+	}
+	if leafValidators[treeType] != nil {
+		panic(fmt.Sprintf("%v already has a LeafValidator", treeType))
+	}
+	leafValidators[treeType] = v
+}
+
+// GetLeafValidator returns the LeafValidator registered for treeType, or nil if there is
+// none, in which case leaves aren't validated.
+func GetLeafValidator(treeType trillian.TreeType) LeafValidator {
+	return leafValidators[treeType]
+}
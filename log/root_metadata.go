@@ -0,0 +1,54 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// RootMetadataHook returns the metadata to bind into a signed log root. It's called with the
+// new root, without metadata or signature, just before the root is signed. If the hook
+// fails, no root is signed and the signer retries on its next pass.
+type RootMetadataHook func(ctx context.Context, root trillian.SignedLogRoot) ([]byte, error)
+
+var rootMetadataHooks = make(map[string]RootMetadataHook)
+
+// RegisterRootMetadataHook makes a hook available under name, for trees to select with
+// their root_metadata_hook field. It should be called from an init function linked into
+// the log signer.
+func RegisterRootMetadataHook(name string, hook RootMetadataHook) {
+	if name == "" {
+		panic("RegisterRootMetadataHook() of hook with empty name")
+	}
+	if rootMetadataHooks[name] != nil {
+		panic(fmt.Sprintf("%v already registered as a RootMetadataHook", name))
+	}
+	rootMetadataHooks[name] = hook
+}
+
+// GetRootMetadataHook returns the hook registered under name. An empty name returns a nil
+// hook, meaning roots have no metadata.
+func GetRootMetadataHook(name string) (RootMetadataHook, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if hook := rootMetadataHooks[name]; hook != nil {
+		return hook, nil
+	}
+	return nil, fmt.Errorf("RootMetadataHook(%v) is an unknown hook", name)
+}
@@ -17,8 +17,10 @@
 package log
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"runtime"
 	"strconv"
 	"sync"
 	"time"
@@ -37,6 +39,9 @@ import (
 
 const logIDLabel = "logid"
 
+// instrumentation identifies the sequencing instrumentation to the tracer.
+const instrumentation = "github.com/google/trillian/log"
+
 var (
 	once                   sync.Once
 	seqBatches             monitoring.Counter
@@ -51,6 +56,9 @@ var (
 	seqStoreRootLatency    monitoring.Histogram
 	seqCommitLatency       monitoring.Histogram
 	seqCounter             monitoring.Counter
+	seqConsistencyFailures monitoring.Counter
+	seqDeadLettered        monitoring.Counter
+	seqClampedTimestamps   monitoring.Counter
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -69,11 +77,24 @@ func createMetrics(mf monitoring.MetricFactory) {
 	seqStoreRootLatency = mf.NewHistogram("sequencer_latency_store_root", "Latency of store-root part of sequencer batch operation in seconds", logIDLabel)
 	seqCommitLatency = mf.NewHistogram("sequencer_latency_commit", "Latency of commit part of sequencer batch operation in seconds", logIDLabel)
 	seqCounter = mf.NewCounter("sequencer_sequenced", "Number of leaves sequenced", logIDLabel)
+	seqConsistencyFailures = mf.NewCounter("sequencer_consistency_check_failures", "Number of new roots not published because they failed the consistency check", logIDLabel)
+	seqDeadLettered = mf.NewCounter("sequencer_dead_lettered", "Number of queued leaves dead-lettered because they repeatedly failed to be sequenced", logIDLabel)
+	seqClampedTimestamps = mf.NewCounter("sequencer_clamped_root_timestamps", "Number of new roots whose timestamp was raised past that of the previous root because the clock hadn't moved past it", logIDLabel)
 }
 
 // TODO(Martin2112): Add admin support for safely changing params like guard window during operation
 // TODO(Martin2112): Add support for enabling and controlling sequencing as part of admin API
 
+// SigningError is returned when a new root couldn't be signed, e.g. because the log's key
+// is unavailable. Nothing is stored in that case, so the leaves of the batch stay queued.
+type SigningError struct {
+	Err error
+}
+
+func (e SigningError) Error() string {
+	return fmt.Sprintf("failed to sign root: %v", e.Err)
+}
+
 // Sequencer instances are responsible for integrating new leaves into a single log.
 // Leaves will be assigned unique sequence numbers when they are processed.
 // There is no strong ordering guarantee but in general entries will be processed
@@ -84,6 +105,26 @@ type Sequencer struct {
 	logStorage storage.LogStorage
 	signer     *crypto.Signer
 	qm         quota.Manager
+	// hashWorkers is the number of goroutines hashing Merkle tree updates, zero means GOMAXPROCS.
+	hashWorkers int
+	// checkConsistency enables verifying each new root against the previous one before storing it.
+	checkConsistency bool
+	// rootMetadata supplies the metadata of each new root, nil means roots have no metadata.
+	rootMetadata RootMetadataHook
+	// deadLetterAttempts is the number of passes a leaf must fail before it's dead-lettered,
+	// zero disables dead-lettering. Failures are counted in leafFailures.
+	deadLetterAttempts int
+	leafFailures       *LeafFailures
+	// forceRoot makes SequenceBatch store a new root even if there are no leaves to integrate.
+	forceRoot bool
+	// rootTimeSource supplies the timestamps of new roots, nil means timeSource.
+	rootTimeSource util.TimeSource
+	// secondarySigner also signs each new root, nil means roots are only signed by signer.
+	secondarySigner *crypto.Signer
+	// rotationSigner replaces signer for roots of at least rotationTreeSize leaves, nil means
+	// there's no key rotation in progress.
+	rotationSigner   *crypto.Signer
+	rotationTreeSize int64
 }
 
 // maxTreeDepth sets an upper limit on the size of Log trees.
@@ -92,6 +133,10 @@ type Sequencer struct {
 //           the subtrees.
 const maxTreeDepth = 64
 
+// minParallelBatch is the smallest batch whose Merkle tree updates are spread across hash
+// workers. Below this the cost of coordinating the workers outweighs the hashing saved.
+const minParallelBatch = 256
+
 // NewSequencer creates a new Sequencer instance for the specified inputs.
 func NewSequencer(
 	hasher hashers.LogHasher,
@@ -112,6 +157,117 @@ func NewSequencer(
 	}
 }
 
+// TreeSigners are the signers of a log's roots: its own, and those configured by its
+// SecondarySigner and KeyRotation, if any.
+type TreeSigners struct {
+	Signer    *crypto.Signer
+	Secondary *crypto.Signer
+	Rotation  *crypto.Signer
+}
+
+// NewTreeSequencer creates a Sequencer for tree, configured as the tree calls for: with its
+// hasher, root metadata hook, secondary signer and key rotation. New roots are timestamped by
+// rootTimeSource, see SetRootTimeSource. Anything that signs the tree's roots should create
+// its Sequencer this way, so all roots are signed alike.
+func NewTreeSequencer(
+	tree *trillian.Tree,
+	signers TreeSigners,
+	timeSource, rootTimeSource util.TimeSource,
+	logStorage storage.LogStorage,
+	mf monitoring.MetricFactory,
+	qm quota.Manager) (*Sequencer, error) {
+	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("error getting hasher: %v", err)
+	}
+	hook, err := GetRootMetadataHook(tree.RootMetadataHook)
+	if err != nil {
+		return nil, fmt.Errorf("error getting root metadata hook: %v", err)
+	}
+	s := NewSequencer(hasher, timeSource, logStorage, signers.Signer, mf, qm)
+	s.SetRootMetadataHook(hook)
+	s.SetRootTimeSource(rootTimeSource)
+	s.SetSecondarySigner(signers.Secondary)
+	if signers.Rotation != nil {
+		s.SetKeyRotation(signers.Rotation, tree.KeyRotation.ActivationTreeSize)
+	}
+	return s, nil
+}
+
+// SetHashWorkers sets the number of goroutines used to hash the Merkle tree updates of large
+// batches. Zero (the default) means runtime.GOMAXPROCS(0), one disables parallel hashing.
+func (s *Sequencer) SetHashWorkers(n int) {
+	s.hashWorkers = n
+}
+
+// SetConsistencyCheck sets whether a consistency proof between the previous root and the new root
+// of each batch is built and verified before the new root is stored. A batch failing the check
+// isn't published. This catches bugs in updating the Merkle tree, at the cost of reading the
+// proof nodes from storage.
+func (s *Sequencer) SetConsistencyCheck(check bool) {
+	s.checkConsistency = check
+}
+
+// SetRootMetadataHook sets the hook supplying the metadata bound into each new signed root.
+// A nil hook (the default) means roots have no metadata.
+func (s *Sequencer) SetRootMetadataHook(hook RootMetadataHook) {
+	s.rootMetadata = hook
+}
+
+// SetDeadLettering enables dead-lettering queued leaves that fail maxAttempts sequencing
+// passes, counting the failed passes in failures. Until then a leaf that can't be sequenced
+// fails the whole pass. Zero maxAttempts (the default) disables dead-lettering.
+func (s *Sequencer) SetDeadLettering(maxAttempts int, failures *LeafFailures) {
+	s.deadLetterAttempts = maxAttempts
+	s.leafFailures = failures
+}
+
+// SetForceRoot sets whether SequenceBatch always stores a new signed root, even if there are
+// no leaves to integrate and the current root isn't older than the maximum root duration.
+func (s *Sequencer) SetForceRoot(force bool) {
+	s.forceRoot = force
+}
+
+// SetRootTimeSource sets the clock that the timestamps of new signed roots are taken from. A nil
+// source (the default) means the time source the sequencer was created with. Whatever the
+// source, a new root is never timestamped before the previous root.
+func (s *Sequencer) SetRootTimeSource(ts util.TimeSource) {
+	s.rootTimeSource = ts
+}
+
+// SetSecondarySigner sets a signer whose signature of each new root is stored alongside the
+// signature by the sequencer's signer, see trillian.SignedLogRoot.AdditionalSignatures. A nil
+// signer (the default) means roots only have one signature.
+func (s *Sequencer) SetSecondarySigner(signer *crypto.Signer) {
+	s.secondarySigner = signer
+}
+
+// SetKeyRotation sets a signer that signs roots of at least activationTreeSize leaves instead of
+// the sequencer's signer, see trillian.Tree.KeyRotation. A nil signer (the default) means all
+// roots are signed by the sequencer's signer.
+func (s *Sequencer) SetKeyRotation(signer *crypto.Signer, activationTreeSize int64) {
+	s.rotationSigner = signer
+	s.rotationTreeSize = activationTreeSize
+}
+
+// rootTimestamp returns the timestamp of a new root following prev. If the clock hasn't moved
+// past prev's timestamp, e.g. because a different signer with a skewed clock signed it, the
+// timestamp just after prev's is used instead. Root timestamps must strictly increase, as
+// storage keys roots by tree and timestamp.
+func (s Sequencer) rootTimestamp(logID int64, prev trillian.SignedLogRoot) int64 {
+	ts := s.rootTimeSource
+	if ts == nil {
+		ts = s.timeSource
+	}
+	now := ts.Now().UnixNano()
+	if now <= prev.TimestampNanos {
+		glog.Warningf("%v: clock is %v behind the previous root, using the timestamp after it", logID, time.Duration(prev.TimestampNanos-now))
+		seqClampedTimestamps.Inc(strconv.FormatInt(logID, 10))
+		return prev.TimestampNanos + 1
+	}
+	return now
+}
+
 // TODO: This currently doesn't use the batch api for fetching the required nodes. This
 // would be more efficient but requires refactoring.
 func (s Sequencer) buildMerkleTreeFromStorageAtRoot(ctx context.Context, root trillian.SignedLogRoot, tx storage.TreeTX) (*merkle.CompactMerkleTree, error) {
@@ -150,7 +306,21 @@ func (s Sequencer) buildNodesFromNodeMap(nodeMap map[string]storage.Node, newVer
 	return targetNodes, nil
 }
 
+// sequenceLeaves assigns sequence numbers to leaves, adds them to mt and returns the Merkle
+// tree nodes that were updated. Large batches are hashed by multiple workers, which results in
+// exactly the same tree and node updates as hashing them serially.
 func (s Sequencer) sequenceLeaves(mt *merkle.CompactMerkleTree, leaves []*trillian.LogLeaf) (map[string]storage.Node, []*trillian.LogLeaf, error) {
+	workers := s.hashWorkers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > 1 && len(leaves) >= minParallelBatch {
+		return s.sequenceLeavesParallel(mt, leaves, workers)
+	}
+	return s.sequenceLeavesSerial(mt, leaves)
+}
+
+func (s Sequencer) sequenceLeavesSerial(mt *merkle.CompactMerkleTree, leaves []*trillian.LogLeaf) (map[string]storage.Node, []*trillian.LogLeaf, error) {
 	nodeMap := make(map[string]storage.Node)
 	// Update the tree state and sequence the leaves and assign sequence numbers to the new leaves
 	for i, leaf := range leaves {
@@ -184,6 +354,125 @@ func (s Sequencer) sequenceLeaves(mt *merkle.CompactMerkleTree, leaves []*trilli
 	return nodeMap, leaves, nil
 }
 
+// sequenceLeavesParallel splits the batch into aligned perfect subtrees, hashes them across
+// workers and then appends the subtree roots to mt in order, so the resulting tree doesn't
+// depend on the order the workers finish in.
+func (s Sequencer) sequenceLeavesParallel(mt *merkle.CompactMerkleTree, leaves []*trillian.LogLeaf, workers int) (map[string]storage.Node, []*trillian.LogLeaf, error) {
+	start := mt.Size()
+	maxSubtree := int64(len(leaves) / workers)
+	if maxSubtree < 1 {
+		maxSubtree = 1
+	}
+	levels := batchSubtrees(start, int64(len(leaves)), maxSubtree)
+
+	type subtree struct {
+		leaves []*trillian.LogLeaf
+		start  int64
+		root   []byte
+		nodes  map[string]storage.Node
+		err    error
+	}
+	subtrees := make([]subtree, len(levels))
+	offset := int64(0)
+	for i, level := range levels {
+		size := int64(1) << uint(level)
+		subtrees[i] = subtree{leaves: leaves[offset : offset+size], start: start + offset}
+		offset += size
+	}
+
+	work := make(chan *subtree)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for st := range work {
+				st.root, st.nodes, st.err = s.hashSubtree(st.start, st.leaves)
+			}
+		}()
+	}
+	for i := range subtrees {
+		work <- &subtrees[i]
+	}
+	close(work)
+	wg.Wait()
+
+	nodeMap := make(map[string]storage.Node, 2*len(leaves))
+	setNode := func(depth int, index int64, hash []byte) error {
+		nodeID, err := storage.NewNodeIDForTreeCoords(int64(depth), index, maxTreeDepth)
+		if err != nil {
+			return err
+		}
+		nodeMap[nodeID.String()] = storage.Node{
+			NodeID: nodeID,
+			Hash:   hash,
+		}
+		return nil
+	}
+	for i, st := range subtrees {
+		if st.err != nil {
+			return nil, nil, st.err
+		}
+		for k, node := range st.nodes {
+			nodeMap[k] = node
+		}
+		if err := mt.AddSubtreeHash(levels[i], st.root, setNode); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The leaves have now been sequenced.
+	for i := range leaves {
+		leaves[i].LeafIndex = start + int64(i)
+	}
+	return nodeMap, leaves, nil
+}
+
+// hashSubtree calculates the nodes of the perfect subtree whose leaves start at index start, and
+// returns its root hash along with all of its nodes.
+func (s Sequencer) hashSubtree(start int64, leaves []*trillian.LogLeaf) ([]byte, map[string]storage.Node, error) {
+	nodes := make(map[string]storage.Node, 2*len(leaves))
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i] = leaf.MerkleLeafHash
+	}
+	for depth := 0; ; depth++ {
+		for i, hash := range hashes {
+			nodeID, err := storage.NewNodeIDForTreeCoords(int64(depth), start+int64(i), maxTreeDepth)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes[nodeID.String()] = storage.Node{
+				NodeID: nodeID,
+				Hash:   hash,
+			}
+		}
+		if len(hashes) == 1 {
+			return hashes[0], nodes, nil
+		}
+		for i := 0; i < len(hashes)/2; i++ {
+			hashes[i] = s.hasher.HashChildren(hashes[2*i], hashes[2*i+1])
+		}
+		hashes = hashes[:len(hashes)/2]
+		start >>= 1
+	}
+}
+
+// batchSubtrees splits the leaf range [start, start+count) into consecutive aligned perfect
+// subtrees of at most maxSize leaves, and returns the level of each subtree in order.
+func batchSubtrees(start, count, maxSize int64) []int {
+	var levels []int
+	for end := start + count; start < end; {
+		level := 0
+		for size := int64(2); start%size == 0 && start+size <= end && size <= maxSize; size <<= 1 {
+			level++
+		}
+		levels = append(levels, level)
+		start += 1 << uint(level)
+	}
+	return levels
+}
+
 func (s Sequencer) initMerkleTreeFromStorage(ctx context.Context, currentRoot trillian.SignedLogRoot, tx storage.LogTreeTX) (*merkle.CompactMerkleTree, error) {
 	if currentRoot.TreeSize == 0 {
 		return merkle.NewCompactMerkleTree(s.hasher), nil
@@ -193,11 +482,101 @@ func (s Sequencer) initMerkleTreeFromStorage(ctx context.Context, currentRoot tr
 	return s.buildMerkleTreeFromStorageAtRoot(ctx, currentRoot, tx)
 }
 
-func (s Sequencer) createRootSignature(ctx context.Context, root trillian.SignedLogRoot) (*sigpb.DigitallySigned, error) {
-	signature, err := s.signer.Sign(crypto.HashLogRoot(root))
+// verifyConsistency checks that newRoot is consistent with oldRoot. The proof is built from the
+// nodes updated by the batch, in nodeMap, and the nodes stored at the revision of oldRoot.
+func (s Sequencer) verifyConsistency(ctx context.Context, tx storage.NodeReader, oldRoot, newRoot trillian.SignedLogRoot, nodeMap map[string]storage.Node) error {
+	if oldRoot.TreeSize == 0 {
+		// Everything is consistent with the empty tree.
+		return nil
+	}
+	fetches, err := merkle.CalcConsistencyProofNodeAddresses(oldRoot.TreeSize, newRoot.TreeSize, newRoot.TreeSize, maxTreeDepth)
+	if err != nil {
+		return err
+	}
+
+	hashes := make([][]byte, len(fetches))
+	var storedIDs []storage.NodeID
+	var storedPos []int
+	for i, fetch := range fetches {
+		if node, ok := nodeMap[fetch.NodeID.String()]; ok {
+			hashes[i] = node.Hash
+			continue
+		}
+		storedIDs = append(storedIDs, fetch.NodeID)
+		storedPos = append(storedPos, i)
+	}
+	if len(storedIDs) > 0 {
+		nodes, err := tx.GetMerkleNodes(ctx, oldRoot.TreeRevision, storedIDs)
+		if err != nil {
+			return err
+		}
+		if got, want := len(nodes), len(storedIDs); got != want {
+			return fmt.Errorf("got %d nodes from storage, want %d", got, want)
+		}
+		for i, node := range nodes {
+			if !node.NodeID.Equivalent(storedIDs[i]) {
+				return fmt.Errorf("got node %v from storage, want %v", node.NodeID, storedIDs[i])
+			}
+			hashes[storedPos[i]] = node.Hash
+		}
+	}
+
+	// Nodes marked for rehashing are combined into the single proof node they make up.
+	var proof [][]byte
+	var rehashed []byte
+	for i, fetch := range fetches {
+		switch {
+		case fetch.Rehash && rehashed == nil:
+			rehashed = hashes[i]
+		case fetch.Rehash:
+			rehashed = s.hasher.HashChildren(hashes[i], rehashed)
+		default:
+			if rehashed != nil {
+				proof = append(proof, rehashed)
+				rehashed = nil
+			}
+			proof = append(proof, hashes[i])
+		}
+	}
+	if rehashed != nil {
+		proof = append(proof, rehashed)
+	}
+
+	return merkle.NewLogVerifier(s.hasher).VerifyConsistencyProof(oldRoot.TreeSize, newRoot.TreeSize, oldRoot.RootHash, newRoot.RootHash, proof)
+}
+
+// createRootSignature sets the metadata of root from the root metadata hook, if there is
+// one, and returns the signature over the result. Roots at or past the activation size of a
+// key rotation are signed by the rotation signer. The secondary signer's signature, if there
+// is a secondary signer, is set as the additional signature of root. Signer failures are
+// returned as a SigningError.
+func (s Sequencer) createRootSignature(ctx context.Context, root *trillian.SignedLogRoot) (*sigpb.DigitallySigned, error) {
+	if s.rootMetadata != nil {
+		metadata, err := s.rootMetadata(ctx, *root)
+		if err != nil {
+			glog.Warningf("%v: root metadata hook failed: %v", root.LogId, err)
+			return nil, err
+		}
+		root.Metadata = metadata
+	}
+
+	hash := crypto.HashLogRoot(*root)
+	signer := s.signer
+	if s.rotationSigner != nil && root.TreeSize >= s.rotationTreeSize {
+		signer = s.rotationSigner
+	}
+	signature, err := signer.Sign(hash)
 	if err != nil {
 		glog.Warningf("%v: signer failed to sign root: %v", root.LogId, err)
-		return nil, err
+		return nil, SigningError{Err: err}
+	}
+	if s.secondarySigner != nil {
+		sig, err := s.secondarySigner.Sign(hash)
+		if err != nil {
+			glog.Warningf("%v: secondary signer failed to sign root: %v", root.LogId, err)
+			return nil, SigningError{Err: err}
+		}
+		root.AdditionalSignatures = []*sigpb.DigitallySigned{sig}
 	}
 
 	return signature, nil
@@ -209,6 +588,18 @@ func (s Sequencer) createRootSignature(ctx context.Context, root trillian.Signed
 // which will fail if the tx was committed. Should only do this if we can hide the details of
 // the underlying storage transactions and it doesn't create other problems.
 func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int, guardWindow, maxRootDurationInterval time.Duration) (int, error) {
+	ctx, span := monitoring.StartSpan(ctx, instrumentation, "Sequencer.SequenceBatch")
+	defer span.End()
+	span.SetAttribute("trillian.tree_id", logID)
+	n, err := s.sequenceBatch(ctx, logID, limit, guardWindow, maxRootDurationInterval)
+	span.SetAttribute("trillian.leaves_sequenced", n)
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return n, err
+}
+
+func (s Sequencer) sequenceBatch(ctx context.Context, logID int64, limit int, guardWindow, maxRootDurationInterval time.Duration) (int, error) {
 	start := s.timeSource.Now()
 	stageStart := start
 	label := strconv.FormatInt(logID, 10)
@@ -254,12 +645,18 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int, gu
 		return 0, s.SignRoot(ctx, logID)
 	}
 
+	// Set aside leaves that can't be sequenced, or fail the pass because of them.
+	leaves, err = s.checkLeaves(ctx, tx, logID, leaves)
+	if err != nil {
+		return 0, err
+	}
+
 	// There might be no work to be done. But we possibly still need to create an signed root if the
 	// current one is too old. If there's work to be done then we'll be creating a root anyway.
 	if len(leaves) == 0 {
 		nowNanos := s.timeSource.Now().UnixNano()
 		interval := time.Duration(nowNanos - currentRoot.TimestampNanos)
-		if maxRootDurationInterval == 0 || interval < maxRootDurationInterval {
+		if !s.forceRoot && (maxRootDurationInterval == 0 || interval < maxRootDurationInterval) {
 			// We have nothing to integrate into the tree
 			glog.V(1).Infof("No leaves sequenced in this signing operation.")
 			return 0, tx.Commit()
@@ -325,15 +722,23 @@ func (s Sequencer) SequenceBatch(ctx context.Context, logID int64, limit int, gu
 	// Create the log root ready for signing
 	newLogRoot := trillian.SignedLogRoot{
 		RootHash:       merkleTree.CurrentRoot(),
-		TimestampNanos: s.timeSource.Now().UnixNano(),
+		TimestampNanos: s.rootTimestamp(logID, currentRoot),
 		TreeSize:       merkleTree.Size(),
 		LogId:          currentRoot.LogId,
 		TreeRevision:   newVersion,
 	}
 	seqTreeSize.Set(float64(merkleTree.Size()), label)
 
+	if s.checkConsistency {
+		if err := s.verifyConsistency(ctx, tx, currentRoot, newLogRoot, nodeMap); err != nil {
+			seqConsistencyFailures.Inc(label)
+			glog.Errorf("%v: new root at size %v failed consistency check against size %v, not publishing it: %v", logID, newLogRoot.TreeSize, currentRoot.TreeSize, err)
+			return 0, err
+		}
+	}
+
 	// Hash and sign the root, update it with the signature
-	signature, err := s.createRootSignature(ctx, newLogRoot)
+	signature, err := s.createRootSignature(ctx, &newLogRoot)
 	if err != nil {
 		glog.Warningf("%v: signer failed to sign root: %v", logID, err)
 		return 0, err
@@ -400,14 +805,14 @@ func (s Sequencer) SignRoot(ctx context.Context, logID int64) error {
 	// Build the updated root, ready for signing
 	newLogRoot := trillian.SignedLogRoot{
 		RootHash:       merkleTree.CurrentRoot(),
-		TimestampNanos: s.timeSource.Now().UnixNano(),
+		TimestampNanos: s.rootTimestamp(logID, currentRoot),
 		TreeSize:       merkleTree.Size(),
 		LogId:          currentRoot.LogId,
 		TreeRevision:   currentRoot.TreeRevision + 1,
 	}
 
 	// Hash and sign the root
-	signature, err := s.createRootSignature(ctx, newLogRoot)
+	signature, err := s.createRootSignature(ctx, &newLogRoot)
 	if err != nil {
 		glog.Warningf("%v: signer failed to sign root: %v", logID, err)
 		return err
@@ -424,6 +829,74 @@ func (s Sequencer) SignRoot(ctx context.Context, logID int64) error {
 	return tx.Commit()
 }
 
+// RepairRoot recomputes the root hash of the log from the Merkle nodes in storage, at the size
+// of the latest signed root. If the recomputed hash doesn't match the stored root, a corrected
+// root is signed and stored at the next tree revision.
+// Returns the latest signed root as found in storage and the corrected root, which is nil if no
+// repair was necessary.
+func (s Sequencer) RepairRoot(ctx context.Context, logID int64) (*trillian.SignedLogRoot, *trillian.SignedLogRoot, error) {
+	tx, err := s.logStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		glog.Warningf("%v: repair failed to start tx: %v", logID, err)
+		return nil, nil, err
+	}
+	defer tx.Close()
+
+	currentRoot, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		glog.Warningf("%v: repair failed to get latest root: %v", logID, err)
+		return nil, nil, err
+	}
+
+	rootHash, err := merkle.RecomputeRoot(s.hasher, currentRoot.TreeSize, func(depth int, index int64) ([]byte, error) {
+		nodeID, err := storage.NewNodeIDForTreeCoords(int64(depth), index, maxTreeDepth)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := tx.GetMerkleNodes(ctx, currentRoot.TreeRevision, []storage.NodeID{nodeID})
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) != 1 {
+			return nil, fmt.Errorf("%v: did not retrieve one node while recomputing root, got %#v for ID %v@%v", logID, nodes, nodeID.String(), currentRoot.TreeRevision)
+		}
+		return nodes[0].Hash, nil
+	})
+	if err != nil {
+		glog.Warningf("%v: repair failed to recompute root: %v", logID, err)
+		return nil, nil, err
+	}
+
+	if bytes.Equal(rootHash, currentRoot.RootHash) {
+		glog.Infof("%v: stored root at size %v matches recomputed root, no repair needed", logID, currentRoot.TreeSize)
+		return &currentRoot, nil, tx.Commit()
+	}
+	glog.Warningf("%v: stored root at size %v, tree-revision %v has hash %x but recomputed hash is %x, repairing", logID, currentRoot.TreeSize, currentRoot.TreeRevision, currentRoot.RootHash, rootHash)
+
+	newLogRoot := trillian.SignedLogRoot{
+		RootHash:       rootHash,
+		TimestampNanos: s.rootTimestamp(logID, currentRoot),
+		TreeSize:       currentRoot.TreeSize,
+		LogId:          currentRoot.LogId,
+		TreeRevision:   currentRoot.TreeRevision + 1,
+	}
+	signature, err := s.createRootSignature(ctx, &newLogRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	newLogRoot.Signature = signature
+
+	if err := tx.StoreSignedLogRoot(ctx, newLogRoot); err != nil {
+		glog.Warningf("%v: repair failed to write corrected root: %v", logID, err)
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	glog.Infof("%v: repaired root, size %v, tree-revision %v", logID, newLogRoot.TreeSize, newLogRoot.TreeRevision)
+	return &currentRoot, &newLogRoot, nil
+}
+
 // since() returns the time in seconds since a particular time, according to
 // the TimeSource used by this sequencer.
 func (s *Sequencer) since(start time.Time) float64 {
@@ -15,8 +15,11 @@
 package log
 
 import (
+	"bytes"
 	"context"
 	gocrypto "crypto"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"strings"
@@ -24,10 +27,12 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
@@ -303,6 +308,7 @@ func TestSequenceBatch(t *testing.T) {
 		params          testParameters
 		guardWindow     time.Duration
 		maxRootDuration time.Duration
+		forceRoot       bool
 		wantCount       int
 		errStr          string
 	}{
@@ -375,6 +381,24 @@ func TestSequenceBatch(t *testing.T) {
 			maxRootDuration: 10 * time.Millisecond,
 			wantCount:       0,
 		},
+		{
+			desc: "nothing-queued-within-max-forced",
+			params: testParameters{
+				logID:            154035,
+				dequeueLimit:     1,
+				shouldCommit:     true,
+				latestSignedRoot: &testRoot16,
+				dequeuedLeaves:   noLeaves,
+				writeRevision:    testRoot16.TreeRevision + 1,
+				updatedLeaves:    &noLeaves,
+				merkleNodesSet:   &noNodes,
+				signer:           signer16,
+				storeSignedRoot:  &newRoot16,
+			},
+			maxRootDuration: 15 * time.Millisecond,
+			forceRoot:       true,
+			wantCount:       0,
+		},
 		{
 			// Tests that the guard interval is being passed to storage correctly.
 			// Actual operation of the window is tested by storage tests.
@@ -521,6 +545,7 @@ func TestSequenceBatch(t *testing.T) {
 			}
 			defer ctrl.Finish()
 			c, ctx := createTestContext(ctrl, test.params)
+			c.sequencer.SetForceRoot(test.forceRoot)
 
 			got, err := c.sequencer.SequenceBatch(ctx, test.params.logID, 1, test.guardWindow, test.maxRootDuration)
 			if err != nil {
@@ -529,6 +554,10 @@ func TestSequenceBatch(t *testing.T) {
 				} else if !strings.Contains(err.Error(), test.errStr) || got != 0 {
 					t.Errorf("SequenceBatch(%+v)=%v,%v; want 0, error with %q", test.params, got, err, test.errStr)
 				}
+				// Only signer failures are reported as such.
+				if _, ok := err.(SigningError); ok != (test.errStr == "signerfailed") {
+					t.Errorf("SequenceBatch(%+v)=%v,%v; SigningError: %v", test.params, got, err, ok)
+				}
 				return
 			}
 			if got != test.wantCount {
@@ -538,6 +567,105 @@ func TestSequenceBatch(t *testing.T) {
 	}
 }
 
+func TestSequenceBatchDeadLettering(t *testing.T) {
+	signer1, err := newSignerWithFixedSig(expectedSignedRoot.Signature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer (%v)", err)
+	}
+	leaves16 := []*trillian.LogLeaf{testLeaf16}
+	// badLeaf can't be sequenced as its Merkle leaf hash is too short.
+	badLeaf := func() *trillian.LogLeaf {
+		return &trillian.LogLeaf{LeafIdentityHash: []byte("bad"), MerkleLeafHash: []byte("short"), LeafValue: []byte("bad")}
+	}
+	failed := testParameters{
+		logID:               154035,
+		dequeueLimit:        1,
+		dequeuedLeaves:      []*trillian.LogLeaf{badLeaf(), getLeaf42()},
+		latestSignedRoot:    &testRoot16,
+		skipStoreSignedRoot: true,
+	}
+	// Shared by all passes, as it would be by a SequencerManager.
+	failures := NewLeafFailures()
+
+	var tests = []struct {
+		desc         string
+		maxAttempts  int
+		params       testParameters
+		wantDeadLeaf bool
+		wantCount    int
+		errStr       string
+	}{
+		{
+			desc:   "disabled",
+			params: failed,
+			errStr: "can't be sequenced",
+		},
+		{
+			desc:        "first-attempt",
+			maxAttempts: 2,
+			params:      failed,
+			errStr:      "attempt 1 of 2",
+		},
+		{
+			desc:        "dead-lettered",
+			maxAttempts: 2,
+			params: testParameters{
+				logID:            154035,
+				writeRevision:    testRoot16.TreeRevision + 1,
+				dequeueLimit:     1,
+				shouldCommit:     true,
+				dequeuedLeaves:   []*trillian.LogLeaf{badLeaf(), getLeaf42()},
+				latestSignedRoot: &testRoot16,
+				updatedLeaves:    &leaves16,
+				merkleNodesSet:   &updatedNodes,
+				storeSignedRoot:  &expectedSignedRoot,
+				signer:           signer1,
+			},
+			wantDeadLeaf: true,
+			wantCount:    1,
+		},
+		{
+			// Dead-lettering forgets the failures, so a requeued leaf starts afresh.
+			desc:        "requeued",
+			maxAttempts: 2,
+			params:      failed,
+			errStr:      "attempt 1 of 2",
+		},
+	}
+
+	for _, test := range tests {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, ctx := createTestContext(ctrl, test.params)
+			c.sequencer.SetDeadLettering(test.maxAttempts, failures)
+			var deadLeaves []*trillian.DeadLetteredLeaf
+			if test.wantDeadLeaf {
+				c.mockTx.EXPECT().DeadLetterLeaves(gomock.Any(), gomock.Any()).Do(func(_ context.Context, leaves []*trillian.DeadLetteredLeaf) {
+					deadLeaves = leaves
+				}).Return(nil)
+			}
+
+			got, err := c.sequencer.SequenceBatch(ctx, test.params.logID, 1, 0, 0)
+			if test.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errStr) {
+					t.Errorf("%v: SequenceBatch()=%v,%v; want 0, error with %q", test.desc, got, err, test.errStr)
+				}
+				return
+			}
+			if err != nil || got != test.wantCount {
+				t.Errorf("%v: SequenceBatch()=%v,%v; want %v,nil", test.desc, got, err, test.wantCount)
+			}
+			if len(deadLeaves) != 1 {
+				t.Fatalf("%v: dead-lettered %v leaves, want 1", test.desc, len(deadLeaves))
+			}
+			if dl := deadLeaves[0]; string(dl.Leaf.LeafIdentityHash) != "bad" || dl.Attempts != 2 || dl.Reason == "" || dl.DeadLetterTime == nil {
+				t.Errorf("%v: dead-lettered %+v, want leaf \"bad\" after 2 attempts", test.desc, dl)
+			}
+		}()
+	}
+}
+
 func TestSignRoot(t *testing.T) {
 	signer0, err := newSignerWithFixedSig(expectedSignedRoot0.Signature)
 	if err != nil {
@@ -667,3 +795,486 @@ func TestSignRoot(t *testing.T) {
 		}()
 	}
 }
+
+func TestSignRootMetadataHook(t *testing.T) {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+
+	for _, test := range []struct {
+		desc    string
+		hookErr error
+	}{
+		{desc: "metadata"},
+		{desc: "hook-fails", hookErr: errors.New("hook")},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, ctx := createTestContext(ctrl, testParameters{
+				logID:               154035,
+				writeRevision:       testRoot16.TreeRevision + 1,
+				latestSignedRoot:    &testRoot16,
+				signer:              key,
+				shouldCommit:        test.hookErr == nil,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			})
+			var stored trillian.SignedLogRoot
+			if test.hookErr == nil {
+				c.mockTx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Do(func(_ context.Context, root trillian.SignedLogRoot) {
+					stored = root
+				}).Return(nil)
+			}
+			c.sequencer.SetRootMetadataHook(func(_ context.Context, root trillian.SignedLogRoot) ([]byte, error) {
+				if root.TreeSize != testRoot16.TreeSize || root.Signature != nil || root.Metadata != nil {
+					t.Errorf("%v: hook called with root %+v, want unsigned root of size %v", test.desc, root, testRoot16.TreeSize)
+				}
+				return []byte("epoch 7"), test.hookErr
+			})
+
+			err := c.sequencer.SignRoot(ctx, 154035)
+			if test.hookErr != nil {
+				if err != test.hookErr {
+					t.Errorf("%v: SignRoot()=%v; want %v", test.desc, err, test.hookErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v: SignRoot()=%v; want nil", test.desc, err)
+			}
+			if got, want := string(stored.Metadata), "epoch 7"; got != want {
+				t.Errorf("%v: stored root metadata = %q, want %q", test.desc, got, want)
+			}
+			if err := crypto.Verify(key.Public(), crypto.HashLogRoot(stored), stored.Signature); err != nil {
+				t.Errorf("%v: signature of stored root doesn't verify: %v", test.desc, err)
+			}
+			stored.Metadata = nil
+			if err := crypto.Verify(key.Public(), crypto.HashLogRoot(stored), stored.Signature); err == nil {
+				t.Errorf("%v: signature of stored root verifies without its metadata", test.desc)
+			}
+		}()
+	}
+}
+
+func TestSignRootSecondarySigner(t *testing.T) {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	secondaryKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	for _, test := range []struct {
+		desc      string
+		secondary gocrypto.Signer
+		wantSigs  int
+	}{
+		{desc: "single", wantSigs: 1},
+		{desc: "dual", secondary: secondaryKey, wantSigs: 2},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, ctx := createTestContext(ctrl, testParameters{
+				logID:               154035,
+				writeRevision:       testRoot16.TreeRevision + 1,
+				latestSignedRoot:    &testRoot16,
+				signer:              key,
+				shouldCommit:        true,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			})
+			var stored trillian.SignedLogRoot
+			c.mockTx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Do(func(_ context.Context, root trillian.SignedLogRoot) {
+				stored = root
+			}).Return(nil)
+			if test.secondary != nil {
+				c.sequencer.SetSecondarySigner(crypto.NewSHA256Signer(test.secondary))
+			}
+
+			if err := c.sequencer.SignRoot(ctx, 154035); err != nil {
+				t.Fatalf("%v: SignRoot()=%v; want nil", test.desc, err)
+			}
+			if got := 1 + len(stored.AdditionalSignatures); got != test.wantSigs {
+				t.Errorf("%v: stored root has %v signatures, want %v", test.desc, got, test.wantSigs)
+			}
+
+			// Round-trip the root, as a verifier would see it.
+			b, err := proto.Marshal(&stored)
+			if err != nil {
+				t.Fatalf("%v: proto.Marshal()=%v", test.desc, err)
+			}
+			var root trillian.SignedLogRoot
+			if err := proto.Unmarshal(b, &root); err != nil {
+				t.Fatalf("%v: proto.Unmarshal()=%v", test.desc, err)
+			}
+			if err := crypto.VerifyLogRoot(key.Public(), root); err != nil {
+				t.Errorf("%v: VerifyLogRoot(primary)=%v, want nil", test.desc, err)
+			}
+			err = crypto.VerifyLogRoot(secondaryKey.Public(), root)
+			if gotErr, wantErr := err != nil, test.secondary == nil; gotErr != wantErr {
+				t.Errorf("%v: VerifyLogRoot(secondary)=%v, want err: %v", test.desc, err, wantErr)
+			}
+		}()
+	}
+}
+
+func TestSignRootKeyRotation(t *testing.T) {
+	key, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("Failed to load private key: %v", err)
+	}
+	rotationKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	// testRoot16 has 16 leaves, so SignRoot signs a root of size 16.
+	for _, test := range []struct {
+		desc               string
+		activationTreeSize int64
+		wantKey            gocrypto.Signer
+	}{
+		{desc: "notActive", activationTreeSize: 17, wantKey: key},
+		{desc: "activeAtSize", activationTreeSize: 16, wantKey: rotationKey},
+		{desc: "active", activationTreeSize: 1, wantKey: rotationKey},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, ctx := createTestContext(ctrl, testParameters{
+				logID:               154035,
+				writeRevision:       testRoot16.TreeRevision + 1,
+				latestSignedRoot:    &testRoot16,
+				signer:              key,
+				shouldCommit:        true,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			})
+			var stored trillian.SignedLogRoot
+			c.mockTx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Do(func(_ context.Context, root trillian.SignedLogRoot) {
+				stored = root
+			}).Return(nil)
+			c.sequencer.SetKeyRotation(crypto.NewSHA256Signer(rotationKey), test.activationTreeSize)
+
+			if err := c.sequencer.SignRoot(ctx, 154035); err != nil {
+				t.Fatalf("%v: SignRoot()=%v; want nil", test.desc, err)
+			}
+			if err := crypto.VerifyLogRoot(test.wantKey.Public(), stored); err != nil {
+				t.Errorf("%v: VerifyLogRoot()=%v, want nil", test.desc, err)
+			}
+		}()
+	}
+}
+
+func TestSignRootClockSkew(t *testing.T) {
+	signer16, err := newSignerWithFixedSig(expectedSignedRoot16.Signature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer (%v)", err)
+	}
+	for _, test := range []struct {
+		desc     string
+		prevTime time.Time
+		// rootTime is the time of the root time source, zero means none is set.
+		rootTime time.Time
+		want     time.Time
+	}{
+		{desc: "clock-ahead", prevTime: fakeTimeForTest.Add(-10 * time.Millisecond), want: fakeTimeForTest},
+		{desc: "clock-equal", prevTime: fakeTimeForTest, want: fakeTimeForTest.Add(time.Nanosecond)},
+		{desc: "clock-behind", prevTime: fakeTimeForTest.Add(time.Hour), want: fakeTimeForTest.Add(time.Hour + time.Nanosecond)},
+		{desc: "root-clock-ahead", prevTime: fakeTimeForTest, rootTime: fakeTimeForTest.Add(time.Second), want: fakeTimeForTest.Add(time.Second)},
+		{desc: "root-clock-behind", prevTime: fakeTimeForTest, rootTime: fakeTimeForTest.Add(-time.Hour), want: fakeTimeForTest.Add(time.Nanosecond)},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			prev := testRoot16
+			prev.TimestampNanos = test.prevTime.UnixNano()
+			c, ctx := createTestContext(ctrl, testParameters{
+				logID:               154035,
+				writeRevision:       testRoot16.TreeRevision + 1,
+				latestSignedRoot:    &prev,
+				signer:              signer16,
+				shouldCommit:        true,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			})
+			var stored trillian.SignedLogRoot
+			c.mockTx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Do(func(_ context.Context, root trillian.SignedLogRoot) {
+				stored = root
+			}).Return(nil)
+			if !test.rootTime.IsZero() {
+				c.sequencer.SetRootTimeSource(util.NewFakeTimeSource(test.rootTime))
+			}
+
+			if err := c.sequencer.SignRoot(ctx, 154035); err != nil {
+				t.Fatalf("%v: SignRoot()=%v; want nil", test.desc, err)
+			}
+			if got, want := stored.TimestampNanos, test.want.UnixNano(); got != want {
+				t.Errorf("%v: stored root timestamp = %v, want %v", test.desc, got, want)
+			}
+		}()
+	}
+}
+
+func TestRepairRoot(t *testing.T) {
+	signer16, err := newSignerWithFixedSig(expectedSignedRoot16.Signature)
+	if err != nil {
+		t.Fatalf("Failed to create test signer (%v)", err)
+	}
+	goodHash := testonly.MustDecodeBase64("qFI0t/tZ1MdOYgyPpPzHFiZVw86koScXy9q3FU5casA=")
+	goodRoot16 := testRoot16
+	goodRoot16.RootHash = goodHash
+	repairedRoot16 := expectedSignedRoot16
+	repairedRoot16.RootHash = goodHash
+
+	var tests = []struct {
+		desc         string
+		params       testParameters
+		nodes        []storage.Node
+		nodesErr     error
+		wantRepaired *trillian.SignedLogRoot
+		errStr       string
+	}{
+		{
+			desc: "get-nodes-fails",
+			params: testParameters{
+				logID:               154035,
+				latestSignedRoot:    &testRoot16,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			},
+			nodesErr: errors.New("getmerklenodes"),
+			errStr:   "getmerklenodes",
+		},
+		{
+			desc: "root-matches",
+			params: testParameters{
+				logID:               154035,
+				latestSignedRoot:    &goodRoot16,
+				shouldCommit:        true,
+				skipDequeue:         true,
+				skipStoreSignedRoot: true,
+			},
+			nodes: []storage.Node{{Hash: goodHash}},
+		},
+		{
+			desc: "root-mismatch",
+			params: testParameters{
+				logID:            154035,
+				latestSignedRoot: &testRoot16,
+				storeSignedRoot:  &repairedRoot16,
+				signer:           signer16,
+				shouldCommit:     true,
+				skipDequeue:      true,
+			},
+			nodes:        []storage.Node{{Hash: goodHash}},
+			wantRepaired: &repairedRoot16,
+		},
+		{
+			desc: "store-root-fails",
+			params: testParameters{
+				logID:                154035,
+				latestSignedRoot:     &testRoot16,
+				storeSignedRootError: errors.New("storesignedroot"),
+				signer:               signer16,
+				skipDequeue:          true,
+			},
+			nodes:  []storage.Node{{Hash: goodHash}},
+			errStr: "storesignedroot",
+		},
+	}
+
+	for _, test := range tests {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c, ctx := createTestContext(ctrl, test.params)
+			c.mockTx.EXPECT().GetMerkleNodes(gomock.Any(), test.params.latestSignedRoot.TreeRevision, gomock.Any()).Return(test.nodes, test.nodesErr)
+
+			prev, repaired, err := c.sequencer.RepairRoot(ctx, test.params.logID)
+			if test.errStr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errStr) {
+					t.Errorf("%v: RepairRoot()=%v; want error with %q", test.desc, err, test.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("%v: RepairRoot()=%v; want nil", test.desc, err)
+				return
+			}
+			if got, want := *prev, *test.params.latestSignedRoot; !proto.Equal(&got, &want) {
+				t.Errorf("%v: RepairRoot() previous root = %v, want %v", test.desc, got, want)
+			}
+			switch {
+			case test.wantRepaired == nil && repaired != nil:
+				t.Errorf("%v: RepairRoot() repaired root = %v, want nil", test.desc, repaired)
+			case test.wantRepaired != nil && !proto.Equal(repaired, test.wantRepaired):
+				t.Errorf("%v: RepairRoot() repaired root = %v, want %v", test.desc, repaired, test.wantRepaired)
+			}
+		}()
+	}
+}
+
+// makeLeaves returns count leaves with hashes set, as they would be when dequeued.
+func makeLeaves(first, count int64) []*trillian.LogLeaf {
+	leaves := make([]*trillian.LogLeaf, 0, count)
+	for i := first; i < first+count; i++ {
+		data := []byte(fmt.Sprintf("leaf %d", i))
+		leaves = append(leaves, &trillian.LogLeaf{
+			MerkleLeafHash: rfc6962.DefaultHasher.HashLeaf(data),
+			LeafValue:      data,
+		})
+	}
+	return leaves
+}
+
+// makeTree returns a compact tree containing size leaves from makeLeaves.
+func makeTree(t testing.TB, size int64) *merkle.CompactMerkleTree {
+	mt := merkle.NewCompactMerkleTree(rfc6962.DefaultHasher)
+	for _, leaf := range makeLeaves(0, size) {
+		if _, err := mt.AddLeafHash(leaf.MerkleLeafHash, func(int, int64, []byte) error { return nil }); err != nil {
+			t.Fatalf("AddLeafHash(): %v", err)
+		}
+	}
+	return mt
+}
+
+func TestSequenceLeavesParallelMatchesSerial(t *testing.T) {
+	s := Sequencer{hasher: rfc6962.DefaultHasher}
+	for _, size := range []int64{0, 1, 5, 8, 13, 1000, 1024} {
+		for _, count := range []int64{1, 2, 7, 8, 255, 256, 1000, 1025} {
+			for _, workers := range []int{2, 3, 8, 64} {
+				desc := fmt.Sprintf("size %d count %d workers %d", size, count, workers)
+				serialTree, parallelTree := makeTree(t, size), makeTree(t, size)
+
+				wantNodes, wantLeaves, err := s.sequenceLeavesSerial(serialTree, makeLeaves(size, count))
+				if err != nil {
+					t.Fatalf("%v: sequenceLeavesSerial(): %v", desc, err)
+				}
+				gotNodes, gotLeaves, err := s.sequenceLeavesParallel(parallelTree, makeLeaves(size, count), workers)
+				if err != nil {
+					t.Fatalf("%v: sequenceLeavesParallel(): %v", desc, err)
+				}
+
+				if got, want := parallelTree.Size(), serialTree.Size(); got != want {
+					t.Errorf("%v: Size() = %v, want %v", desc, got, want)
+				}
+				if got, want := parallelTree.CurrentRoot(), serialTree.CurrentRoot(); !bytes.Equal(got, want) {
+					t.Errorf("%v: CurrentRoot() = %x, want %x", desc, got, want)
+				}
+				for i := range wantLeaves {
+					if got, want := gotLeaves[i].LeafIndex, wantLeaves[i].LeafIndex; got != want {
+						t.Errorf("%v: leaf %d: LeafIndex = %v, want %v", desc, i, got, want)
+					}
+				}
+				if got, want := len(gotNodes), len(wantNodes); got != want {
+					t.Errorf("%v: got %d updated nodes, want %d", desc, got, want)
+				}
+				for k, want := range wantNodes {
+					if got, ok := gotNodes[k]; !ok || !bytes.Equal(got.Hash, want.Hash) {
+						t.Errorf("%v: node %v = %x, want %x", desc, want.NodeID, got.Hash, want.Hash)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBatchSubtrees(t *testing.T) {
+	tests := []struct {
+		start, count, maxSize int64
+		want                  []int
+	}{
+		{start: 0, count: 1, maxSize: 8, want: []int{0}},
+		{start: 0, count: 8, maxSize: 8, want: []int{3}},
+		{start: 0, count: 8, maxSize: 4, want: []int{2, 2}},
+		{start: 0, count: 7, maxSize: 8, want: []int{2, 1, 0}},
+		{start: 3, count: 8, maxSize: 8, want: []int{0, 2, 1, 0}},
+		{start: 5, count: 3, maxSize: 1, want: []int{0, 0, 0}},
+	}
+	for _, test := range tests {
+		if got := batchSubtrees(test.start, test.count, test.maxSize); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("batchSubtrees(%v, %v, %v) = %v, want %v", test.start, test.count, test.maxSize, got, test.want)
+		}
+	}
+}
+
+func benchmarkSequenceLeaves(b *testing.B, count int64, workers int) {
+	s := Sequencer{hasher: rfc6962.DefaultHasher, hashWorkers: workers}
+	leaves := makeLeaves(1000, count)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		mt := makeTree(b, 1000)
+		b.StartTimer()
+		if _, _, err := s.sequenceLeaves(mt, leaves); err != nil {
+			b.Fatalf("sequenceLeaves(): %v", err)
+		}
+	}
+}
+
+func BenchmarkSequenceLeaves10kSerial(b *testing.B)    { benchmarkSequenceLeaves(b, 10000, 1) }
+func BenchmarkSequenceLeaves10kParallel(b *testing.B)  { benchmarkSequenceLeaves(b, 10000, 0) }
+func BenchmarkSequenceLeaves100kSerial(b *testing.B)   { benchmarkSequenceLeaves(b, 100000, 1) }
+func BenchmarkSequenceLeaves100kParallel(b *testing.B) { benchmarkSequenceLeaves(b, 100000, 0) }
+
+// nodeMapReader is a NodeReader serving the nodes in a map, regardless of revision.
+type nodeMapReader map[string]storage.Node
+
+func (r nodeMapReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	nodes := make([]storage.Node, 0, len(ids))
+	for _, id := range ids {
+		node, ok := r[id.String()]
+		if !ok {
+			return nil, fmt.Errorf("node %v not found", id)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func TestVerifyConsistency(t *testing.T) {
+	ctx := context.Background()
+	s := Sequencer{hasher: rfc6962.DefaultHasher, hashWorkers: 1}
+	for _, size := range []int64{1, 2, 5, 8, 13} {
+		for _, count := range []int64{1, 3, 8, 19} {
+			desc := fmt.Sprintf("size %d count %d", size, count)
+			mt := merkle.NewCompactMerkleTree(rfc6962.DefaultHasher)
+			stored, _, err := s.sequenceLeaves(mt, makeLeaves(0, size))
+			if err != nil {
+				t.Fatalf("%v: sequenceLeaves(): %v", desc, err)
+			}
+			oldRoot := trillian.SignedLogRoot{TreeSize: mt.Size(), RootHash: mt.CurrentRoot(), TreeRevision: 1}
+			nodeMap, _, err := s.sequenceLeaves(mt, makeLeaves(size, count))
+			if err != nil {
+				t.Fatalf("%v: sequenceLeaves(): %v", desc, err)
+			}
+			newRoot := trillian.SignedLogRoot{TreeSize: mt.Size(), RootHash: mt.CurrentRoot(), TreeRevision: 2}
+
+			if err := s.verifyConsistency(ctx, nodeMapReader(stored), oldRoot, newRoot, nodeMap); err != nil {
+				t.Errorf("%v: verifyConsistency()=%v, want: nil", desc, err)
+			}
+
+			// Corrupting the updated nodes must be detected.
+			corrupt := make(map[string]storage.Node)
+			for k, node := range nodeMap {
+				node.Hash = rfc6962.DefaultHasher.HashLeaf([]byte("bad"))
+				corrupt[k] = node
+			}
+			if err := s.verifyConsistency(ctx, nodeMapReader(stored), oldRoot, newRoot, corrupt); err == nil {
+				t.Errorf("%v: verifyConsistency(corrupt nodes)=nil, want: error", desc)
+			}
+
+			badRoot := newRoot
+			badRoot.RootHash = rfc6962.DefaultHasher.HashLeaf([]byte("bad"))
+			if err := s.verifyConsistency(ctx, nodeMapReader(stored), oldRoot, badRoot, nodeMap); err == nil {
+				t.Errorf("%v: verifyConsistency(bad root)=nil, want: error", desc)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maps holds extension points of the map server.
+package maps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// ValueValidator checks that map leaf values are well-formed according to the rules of a
+// personality, for example that they parse as the records the map is meant to hold. The map
+// server calls it on every leaf of a SetLeaves request, before the new revision is committed.
+type ValueValidator interface {
+	// ValidateValue returns an error describing why leaf can't be set in tree, or nil if the
+	// leaf is acceptable. Maps with CLIENT_HASHED_LEAVES may set leaves without a value.
+	ValidateValue(ctx context.Context, tree *trillian.Tree, leaf *trillian.MapLeaf) error
+}
+
+var valueValidators = make(map[trillian.TreeType]ValueValidator)
+
+// RegisterValueValidator makes v validate the leaves set in all trees of treeType. It should
+// be called from an init function linked into the map server.
+func RegisterValueValidator(treeType trillian.TreeType, v ValueValidator) {
+	if treeType == trillian.TreeType_UNKNOWN_TREE_TYPE {
+		panic(fmt.Sprintf("RegisterValueValidator(%s) of unknown tree type", treeType))
+	}
+	if valueValidators[treeType] != nil {
+		panic(fmt.Sprintf("%v already has a ValueValidator", treeType))
+	}
+	valueValidators[treeType] = v
+}
+
+// GetValueValidator returns the ValueValidator registered for treeType, or nil if there is
+// none, in which case values aren't validated.
+func GetValueValidator(treeType trillian.TreeType) ValueValidator {
+	return valueValidators[treeType]
+}
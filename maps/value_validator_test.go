@@ -0,0 +1,60 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/trillian"
+)
+
+type acceptAll struct{}
+
+func (acceptAll) ValidateValue(context.Context, *trillian.Tree, *trillian.MapLeaf) error {
+	return nil
+}
+
+func TestRegisterValueValidator(t *testing.T) {
+	defer delete(valueValidators, trillian.TreeType_MAP)
+
+	if v := GetValueValidator(trillian.TreeType_MAP); v != nil {
+		t.Fatalf("GetValueValidator(MAP) before registration = %v, want nil", v)
+	}
+	RegisterValueValidator(trillian.TreeType_MAP, acceptAll{})
+	if v := GetValueValidator(trillian.TreeType_MAP); v == nil {
+		t.Error("GetValueValidator(MAP) after registration = nil, want validator")
+	}
+	if v := GetValueValidator(trillian.TreeType_LOG); v != nil {
+		t.Errorf("GetValueValidator(LOG) = %v, want nil", v)
+	}
+
+	for _, test := range []struct {
+		desc     string
+		treeType trillian.TreeType
+	}{
+		{desc: "duplicate", treeType: trillian.TreeType_MAP},
+		{desc: "unknown", treeType: trillian.TreeType_UNKNOWN_TREE_TYPE},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: RegisterValueValidator(%v) didn't panic", test.desc, test.treeType)
+				}
+			}()
+			RegisterValueValidator(test.treeType, acceptAll{})
+		}()
+	}
+}
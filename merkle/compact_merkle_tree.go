@@ -83,18 +83,8 @@ func NewCompactMerkleTreeWithState(hasher hashers.LogHasher, size int64, f GetNo
 		r.nodes[sizeBits-1] = r.root
 	} else {
 		// Pull in the nodes we need to repopulate our compact tree and verify the root
-		for depth := 0; depth < sizeBits; depth++ {
-			if size&1 == 1 {
-				index := size - 1
-				log.V(1).Infof("fetching d: %d i: %d, leaving size %d", depth, index, size)
-				h, err := f(depth, index)
-				if err != nil {
-					log.Warningf("Failed to fetch node depth %d index %d: %s", depth, index, err)
-					return nil, err
-				}
-				r.nodes[depth] = h
-			}
-			size >>= 1
+		if err := r.fetchNodes(f); err != nil {
+			return nil, err
 		}
 		r.recalculateRoot(func(depth int, index int64, hash []byte) error {
 			return nil
@@ -108,6 +98,44 @@ func NewCompactMerkleTreeWithState(hasher hashers.LogHasher, size int64, f GetNo
 	return &r, nil
 }
 
+// RecomputeRoot calculates the root hash of a tree of the given |size| from the nodes returned by |f|.
+// Unlike NewCompactMerkleTreeWithState no known-good root is required: the root of a perfect tree is
+// fetched through |f| rather than taken on trust, so the result reflects only what |f| returns.
+func RecomputeRoot(hasher hashers.LogHasher, size int64, f GetNodeFunc) ([]byte, error) {
+	r := CompactMerkleTree{
+		hasher: hasher,
+		nodes:  make([][]byte, bitLen(size)),
+		root:   hasher.EmptyRoot(),
+		size:   size,
+	}
+	if err := r.fetchNodes(f); err != nil {
+		return nil, err
+	}
+	r.recalculateRoot(func(depth int, index int64, hash []byte) error {
+		return nil
+	})
+	return r.root, nil
+}
+
+// fetchNodes populates the compact representation of a tree of size c.size using |f|.
+func (c *CompactMerkleTree) fetchNodes(f GetNodeFunc) error {
+	size := c.size
+	for depth := 0; depth < len(c.nodes); depth++ {
+		if size&1 == 1 {
+			index := size - 1
+			log.V(1).Infof("fetching d: %d i: %d, leaving size %d", depth, index, size)
+			h, err := f(depth, index)
+			if err != nil {
+				log.Warningf("Failed to fetch node depth %d index %d: %s", depth, index, err)
+				return err
+			}
+			c.nodes[depth] = h
+		}
+		size >>= 1
+	}
+	return nil
+}
+
 // NewCompactMerkleTree creates a new CompactMerkleTree with size zero. This always succeeds.
 func NewCompactMerkleTree(hasher hashers.LogHasher) *CompactMerkleTree {
 	r := CompactMerkleTree{
@@ -250,6 +278,43 @@ func (c *CompactMerkleTree) AddLeafHash(leafHash []byte, f setNodeFunc) (int64,
 	return 0, fmt.Errorf("AddLeaf failed running hash not cleared: h: %v seq: %d", leafHash, assignedSeq)
 }
 
+// AddSubtreeHash appends a perfect subtree of 2^|level| leaves, whose root hash is |hash|, to the tree.
+// The current size of the tree must be a multiple of 2^|level|. Only the subtree root and the nodes it
+// completes above it are passed to |f|; the caller is responsible for the nodes within the subtree.
+// Appending the subtree results in the same tree state as adding each of its leaves with AddLeafHash.
+func (c *CompactMerkleTree) AddSubtreeHash(level int, hash []byte, f setNodeFunc) error {
+	if level < 0 || level >= 63 || c.size&(1<<uint(level)-1) != 0 {
+		return fmt.Errorf("AddSubtreeHash: can't append subtree at level %d to tree of size %d", level, c.size)
+	}
+
+	index := c.size >> uint(level)
+	if err := f(level, index, hash); err != nil {
+		return err
+	}
+	for len(c.nodes) <= level {
+		c.nodes = append(c.nodes, nil)
+	}
+	for bit := level; ; bit++ {
+		if bit == len(c.nodes) {
+			c.nodes = append(c.nodes, hash)
+			break
+		}
+		if c.size&(1<<uint(bit)) == 0 {
+			c.nodes[bit] = hash
+			break
+		}
+		// There's a dangling node at this level, so merge it with our running hash and carry on up.
+		hash = c.hasher.HashChildren(c.nodes[bit], hash)
+		index >>= 1
+		if err := f(bit+1, index, hash); err != nil {
+			return err
+		}
+		c.nodes[bit] = nil
+	}
+	c.size += 1 << uint(level)
+	return c.recalculateRoot(f)
+}
+
 // Size returns the current size of the tree, that is, the number of leaves ever added to the tree.
 func (c CompactMerkleTree) Size() int64 {
 	return c.size
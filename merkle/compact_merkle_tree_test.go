@@ -290,3 +290,98 @@ func TestRootHashForVariousTreeSizes(t *testing.T) {
 		}
 	}
 }
+
+func TestRecomputeRoot(t *testing.T) {
+	nodes := make(map[string][]byte)
+	getNode := func(depth int, index int64) ([]byte, error) {
+		k, err := nodeKey(depth, index)
+		if err != nil {
+			return nil, err
+		}
+		return nodes[k], nil
+	}
+
+	cmt := NewCompactMerkleTree(rfc6962.DefaultHasher)
+	for i := int64(0); i < 70; i++ {
+		if _, _, err := cmt.AddLeaf([]byte(fmt.Sprintf("Leaf %d", i)), func(depth int, index int64, hash []byte) error {
+			k, err := nodeKey(depth, index)
+			if err != nil {
+				return err
+			}
+			nodes[k] = hash
+			return nil
+		}); err != nil {
+			t.Fatalf("AddLeaf(): %v", err)
+		}
+
+		root, err := RecomputeRoot(rfc6962.DefaultHasher, cmt.Size(), getNode)
+		if err != nil {
+			t.Fatalf("size %d: RecomputeRoot(): %v", cmt.Size(), err)
+		}
+		if got, want := root, cmt.CurrentRoot(); !bytes.Equal(got, want) {
+			t.Errorf("size %d: RecomputeRoot() = %x, want %x", cmt.Size(), got, want)
+		}
+	}
+
+	// The root of a perfect tree must come from storage, not be assumed.
+	k, err := nodeKey(6, 0)
+	if err != nil {
+		t.Fatalf("nodeKey(): %v", err)
+	}
+	want := []byte("12345678901234567890123456789012")
+	nodes[k] = want
+	root, err := RecomputeRoot(rfc6962.DefaultHasher, 64, getNode)
+	if err != nil {
+		t.Fatalf("RecomputeRoot(): %v", err)
+	}
+	if !bytes.Equal(root, want) {
+		t.Errorf("RecomputeRoot() = %x, want %x", root, want)
+	}
+
+	if _, err := RecomputeRoot(rfc6962.DefaultHasher, 237, failingGetNodeFunc); err == nil || !strings.Contains(err.Error(), "bang") {
+		t.Errorf("RecomputeRoot() did not return correctly on failed node fetch: %v", err)
+	}
+}
+
+func TestAddSubtreeHash(t *testing.T) {
+	noop := func(int, int64, []byte) error { return nil }
+	leafData := func(i int64) []byte { return []byte(fmt.Sprintf("Leaf %d", i)) }
+
+	for start := int64(0); start < 40; start++ {
+		for level := 0; level < 5; level++ {
+			count := int64(1) << uint(level)
+			want := NewCompactMerkleTree(rfc6962.DefaultHasher)
+			got := NewCompactMerkleTree(rfc6962.DefaultHasher)
+			for i := int64(0); i < start; i++ {
+				want.AddLeaf(leafData(i), noop)
+				got.AddLeaf(leafData(i), noop)
+			}
+
+			if start%count != 0 {
+				if err := got.AddSubtreeHash(level, []byte("hash"), noop); err == nil {
+					t.Errorf("start %d level %d: AddSubtreeHash() = nil, want err", start, level)
+				}
+				continue
+			}
+
+			subtree := NewCompactMerkleTree(rfc6962.DefaultHasher)
+			for i := start; i < start+count; i++ {
+				want.AddLeaf(leafData(i), noop)
+				subtree.AddLeaf(leafData(i), noop)
+			}
+			if err := got.AddSubtreeHash(level, subtree.CurrentRoot(), noop); err != nil {
+				t.Fatalf("start %d level %d: AddSubtreeHash(): %v", start, level, err)
+			}
+
+			if got, want := got.Size(), want.Size(); got != want {
+				t.Errorf("start %d level %d: Size() = %d, want %d", start, level, got, want)
+			}
+			if got, want := got.CurrentRoot(), want.CurrentRoot(); !bytes.Equal(got, want) {
+				t.Errorf("start %d level %d: CurrentRoot() = %x, want %x", start, level, got, want)
+			}
+			if err := checkUnusedNodesInvariant(got); err != nil {
+				t.Errorf("start %d level %d: %v", start, level, err)
+			}
+		}
+	}
+}
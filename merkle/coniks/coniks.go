@@ -18,6 +18,7 @@ package coniks
 import (
 	"crypto"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/google/trillian"
@@ -26,6 +27,23 @@ import (
 
 func init() {
 	hashers.RegisterMapHasher(trillian.HashStrategy_CONIKS_SHA512_256, Default)
+	hashers.RegisterNullHashVector(trillian.HashStrategy_CONIKS_SHA512_256, NullHashVector)
+}
+
+// NullHashVector is the known answer for the null hashes of Default, for tree ID 0 and the
+// all-zero index. CONIKS null hashes also commit to the tree ID and index.
+var NullHashVector = hashers.NullHashVector{
+	Index:     make([]byte, 32),
+	EmptyRoot: mustDecodeHex("2b71932d625e7b83ce864f8092ae4eb470670ccff37eaac83f21679bb3b24bbb"),
+	Digest:    mustDecodeHex("1914e24622ae5589f1d199b67706784143d9a2d05a04652c36531912591a7cd0"),
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
 }
 
 // Domain separation prefixes
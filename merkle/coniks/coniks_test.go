@@ -83,3 +83,18 @@ func TestMaskIndex(t *testing.T) {
 		}
 	}
 }
+
+func TestNullHashVector(t *testing.T) {
+	if err := NullHashVector.Verify(Default); err != nil {
+		t.Errorf("NullHashVector.Verify(Default) = %v, want nil", err)
+	}
+	if err := NullHashVector.Verify(New(crypto.SHA256)); err == nil {
+		t.Error("NullHashVector.Verify(New(SHA256)) = nil, want error")
+	}
+	// The null hashes commit to the tree ID.
+	v := NullHashVector
+	v.TreeID = 1
+	if err := v.Verify(Default); err == nil {
+		t.Error("Verify(Default) for another tree ID = nil, want error")
+	}
+}
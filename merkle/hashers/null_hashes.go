@@ -0,0 +1,95 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/google/trillian"
+)
+
+// NullHashVector is a known answer for the null hashes of a MapHasher, i.e. the hashes of
+// empty branches at every height of the tree. These determine the root of every map, so a
+// change to them, e.g. by a dependency update, silently changes all map roots.
+type NullHashVector struct {
+	// TreeID and Index are passed to HashEmpty, for hashers whose null hashes depend on them.
+	TreeID int64
+	Index  []byte
+	// EmptyRoot is the root hash of an empty tree, i.e. the null hash at height BitLen().
+	EmptyRoot []byte
+	// Digest is the SHA-256 hash of the concatenated null hashes from height 0 to BitLen().
+	Digest []byte
+}
+
+var nullHashVectors = make(map[trillian.HashStrategy]NullHashVector)
+
+// NullHashes returns the null hashes of h for treeID and index, indexed by height from 0
+// (an empty leaf) to h.BitLen() (an empty tree).
+func NullHashes(h MapHasher, treeID int64, index []byte) [][]byte {
+	hashes := make([][]byte, h.BitLen()+1)
+	for height := range hashes {
+		hashes[height] = h.HashEmpty(treeID, index, height)
+	}
+	return hashes
+}
+
+// Verify checks the null hashes of h against the vector.
+func (v NullHashVector) Verify(h MapHasher) error {
+	hashes := NullHashes(h, v.TreeID, v.Index)
+	if got := hashes[len(hashes)-1]; !bytes.Equal(got, v.EmptyRoot) {
+		return fmt.Errorf("empty root of %v is %x, want %x", h, got, v.EmptyRoot)
+	}
+	d := sha256.New()
+	for _, hash := range hashes {
+		d.Write(hash)
+	}
+	if got := d.Sum(nil); !bytes.Equal(got, v.Digest) {
+		return fmt.Errorf("digest of the null hashes of %v is %x, want %x", h, got, v.Digest)
+	}
+	return nil
+}
+
+// RegisterNullHashVector registers the known answer for the null hashes of the MapHasher
+// registered for h, to be checked by VerifyNullHashes.
+func RegisterNullHashVector(h trillian.HashStrategy, v NullHashVector) {
+	if _, ok := nullHashVectors[h]; ok {
+		panic(fmt.Sprintf("%v already has a null hash vector", h))
+	}
+	nullHashVectors[h] = v
+}
+
+// VerifyNullHashes checks the null hashes of all registered MapHashers that have a registered
+// NullHashVector, returning an error for the first mismatch.
+func VerifyNullHashes() error {
+	strategies := make([]trillian.HashStrategy, 0, len(nullHashVectors))
+	for h := range nullHashVectors {
+		strategies = append(strategies, h)
+	}
+	sort.Slice(strategies, func(i, j int) bool { return strategies[i] < strategies[j] })
+
+	for _, h := range strategies {
+		hasher, err := NewMapHasher(h)
+		if err != nil {
+			return err
+		}
+		if err := nullHashVectors[h].Verify(hasher); err != nil {
+			return fmt.Errorf("%v: %v", h, err)
+		}
+	}
+	return nil
+}
@@ -16,7 +16,6 @@ package merkle
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 
 	"github.com/google/trillian/merkle/hashers"
@@ -32,6 +31,37 @@ func (e RootMismatchError) Error() string {
 	return fmt.Sprintf("calculated root:\n%v\n does not match expected root:\n%v", e.CalculatedRoot, e.ExpectedRoot)
 }
 
+// IndexOutOfRangeError occurs when a leaf index is not within a tree of the given size.
+type IndexOutOfRangeError struct {
+	Index    int64
+	TreeSize int64
+}
+
+func (e IndexOutOfRangeError) Error() string {
+	return fmt.Sprintf("leafIndex %d is not in a tree of size %d", e.Index, e.TreeSize)
+}
+
+// SnapshotOrderError occurs when the tree sizes of a consistency proof are negative or the
+// wrong way round.
+type SnapshotOrderError struct {
+	Snapshot1 int64
+	Snapshot2 int64
+}
+
+func (e SnapshotOrderError) Error() string {
+	return fmt.Sprintf("invalid snapshots, want 0 <= snapshot1 (%d) <= snapshot2 (%d)", e.Snapshot1, e.Snapshot2)
+}
+
+// ProofLengthError occurs when a proof has the wrong number of components.
+type ProofLengthError struct {
+	Got  int
+	Want int
+}
+
+func (e ProofLengthError) Error() string {
+	return fmt.Sprintf("invalid proof, expected %d components, but have %d", e.Want, e.Got)
+}
+
 // LogVerifier verifies inclusion and consistency proofs for append only logs.
 type LogVerifier struct {
 	hasher hashers.LogHasher
@@ -59,20 +89,23 @@ func (v LogVerifier) VerifyInclusionProof(leafIndex, treeSize int64, proof [][]b
 	return nil
 }
 
+// InclusionProofValid returns true if VerifyInclusionProof succeeds, for callers that don't need
+// to know why a proof failed.
+func (v LogVerifier) InclusionProofValid(leafIndex, treeSize int64, proof [][]byte, root []byte, leafHash []byte) bool {
+	return v.VerifyInclusionProof(leafIndex, treeSize, proof, root, leafHash) == nil
+}
+
 // RootFromInclusionProof calculates the expected tree root given the proof and leaf.
 // leafIndex starts at 0.  treeSize is the number of nodes in the tree.
 // proof is an array of neighbor nodes from the bottom to the root.
 func (v LogVerifier) RootFromInclusionProof(leafIndex, treeSize int64, proof [][]byte, leafHash []byte) ([]byte, error) {
-	if leafIndex < 0 {
-		return nil, errors.New("invalid leafIndex < 0")
+	if leafIndex < 0 || leafIndex >= treeSize {
+		return nil, IndexOutOfRangeError{Index: leafIndex, TreeSize: treeSize}
 	}
-	if treeSize < 0 {
-		return nil, errors.New("invalid treeSize < 0")
+	if got, want := len(proof), inclusionProofLength(leafIndex, treeSize); got != want {
+		return nil, ProofLengthError{Got: got, Want: want}
 	}
 	lastIndex := treeSize - 1 // Rightmost node in tree.
-	if leafIndex > lastIndex {
-		return nil, fmt.Errorf("leafIndex is not in a tree of size %d, want %d<%d", treeSize, leafIndex, treeSize)
-	}
 
 	cntIndex := leafIndex
 	cntHash := leafHash
@@ -86,9 +119,6 @@ func (v LogVerifier) RootFromInclusionProof(leafIndex, treeSize int64, proof [][
 	// Hash sibling nodes into the current hash starting at the leaf and continuing to the root.
 	// Use the highest order 1 bit in the rightmost node as the stopping condition.
 	for lastIndex > 0 {
-		if proofIndex >= len(proof) {
-			return nil, fmt.Errorf("insuficient number of proof components (%d) for treeSize %d", len(proof), treeSize)
-		}
 		if isRightChild(cntIndex) {
 			cntHash = v.hasher.HashChildren(proof[proofIndex], cntHash)
 			proofIndex++
@@ -101,20 +131,36 @@ func (v LogVerifier) RootFromInclusionProof(leafIndex, treeSize int64, proof [][
 		cntIndex = parent(cntIndex)
 		lastIndex = parent(lastIndex)
 	}
-	if proofIndex != len(proof) {
-		return nil, fmt.Errorf("invalid proof, expected %d components, but have %d", proofIndex, len(proof))
-	}
 	return cntHash, nil
 }
 
+// inclusionProofLength returns the number of components in an inclusion proof for leafIndex in a
+// tree of size treeSize, i.e. the number of levels at which the path to the leaf has a sibling.
+func inclusionProofLength(leafIndex, treeSize int64) int {
+	length := 0
+	for lastIndex := treeSize - 1; lastIndex > 0; lastIndex = parent(lastIndex) {
+		if isRightChild(leafIndex) || leafIndex < lastIndex {
+			length++
+		}
+		leafIndex = parent(leafIndex)
+	}
+	return length
+}
+
+// ConsistencyProofValid returns true if VerifyConsistencyProof succeeds, for callers that don't
+// need to know why a proof failed.
+func (v LogVerifier) ConsistencyProofValid(snapshot1, snapshot2 int64, root1, root2 []byte, proof [][]byte) bool {
+	return v.VerifyConsistencyProof(snapshot1, snapshot2, root1, root2, proof) == nil
+}
+
 // VerifyConsistencyProof checks that the passed in consistency proof is valid between the passed in tree snapshots.
 // Snapshots are the respective treeSizes. shapshot2 >= snapshot1 >= 0.
 func (v LogVerifier) VerifyConsistencyProof(snapshot1, snapshot2 int64, root1, root2 []byte, proof [][]byte) error {
-	if snapshot1 < 0 {
-		return fmt.Errorf("snapshot1 (%d) < 0 ", snapshot1)
+	if snapshot1 < 0 || snapshot2 < snapshot1 {
+		return SnapshotOrderError{Snapshot1: snapshot1, Snapshot2: snapshot2}
 	}
-	if snapshot2 < snapshot1 {
-		return fmt.Errorf("snapshot2 (%d) < snapshot1 (%d)", snapshot1, snapshot2)
+	if got, want := len(proof), consistencyProofLength(snapshot1, snapshot2); got != want {
+		return ProofLengthError{Got: got, Want: want}
 	}
 	if snapshot1 == snapshot2 {
 		if !bytes.Equal(root1, root2) {
@@ -123,22 +169,13 @@ func (v LogVerifier) VerifyConsistencyProof(snapshot1, snapshot2 int64, root1, r
 				ExpectedRoot:   root2,
 			}
 		}
-		if len(proof) > 0 {
-			return errors.New("root1 and root2 match, but proof is non-empty")
-		}
 		// proof ok.
 		return nil
 	}
 	if snapshot1 == 0 {
 		// Any snapshot greater than 0 is consistent with snapshot 0.
-		if len(proof) > 0 {
-			return fmt.Errorf("expected empty proof, but provided proof has %d components", len(proof))
-		}
 		return nil
 	}
-	if len(proof) == 0 {
-		return errors.New("empty proof")
-	}
 
 	node := snapshot1 - 1
 	lastNode := snapshot2 - 1
@@ -164,10 +201,6 @@ func (v LogVerifier) VerifyConsistencyProof(snapshot1, snapshot2 int64, root1, r
 
 	// Use the highest order 1 bit in the rightmost node of snapshot1 as the stopping condition.
 	for node > 0 {
-		if proofIndex >= len(proof) {
-			return errors.New("insufficient number of proof components")
-		}
-
 		if isRightChild(node) {
 			node1Hash = v.hasher.HashChildren(proof[proofIndex], node1Hash)
 			node2Hash = v.hasher.HashChildren(proof[proofIndex], node2Hash)
@@ -194,10 +227,6 @@ func (v LogVerifier) VerifyConsistencyProof(snapshot1, snapshot2 int64, root1, r
 
 	// Use the highest order 1 bit in the rightmost node of snapshot2 as the stopping condition.
 	for lastNode > 0 {
-		if proofIndex >= len(proof) {
-			return errors.New("can't verify newer root; insufficient number of proof components")
-		}
-
 		node2Hash = v.hasher.HashChildren(node2Hash, proof[proofIndex])
 		proofIndex++
 		lastNode = parent(lastNode)
@@ -210,9 +239,35 @@ func (v LogVerifier) VerifyConsistencyProof(snapshot1, snapshot2 int64, root1, r
 			ExpectedRoot:   root2,
 		}
 	}
-	if proofIndex != len(proof) {
-		return errors.New("proof has too many components")
-	}
 
 	return nil // Proof OK.
 }
+
+// consistencyProofLength returns the number of components in a consistency proof between trees
+// of sizes snapshot1 and snapshot2, where 0 <= snapshot1 <= snapshot2.
+func consistencyProofLength(snapshot1, snapshot2 int64) int {
+	if snapshot1 == 0 || snapshot1 == snapshot2 {
+		return 0
+	}
+	node := snapshot1 - 1
+	lastNode := snapshot2 - 1
+	for isRightChild(node) {
+		node = parent(node)
+		lastNode = parent(lastNode)
+	}
+
+	length := 0
+	if node > 0 {
+		// The root of the subtree containing snapshot1's rightmost node.
+		length++
+	}
+	for ; node > 0; node, lastNode = parent(node), parent(lastNode) {
+		if isRightChild(node) || node < lastNode {
+			length++
+		}
+	}
+	for ; lastNode > 0; lastNode = parent(lastNode) {
+		length++
+	}
+	return length
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/google/trillian/merkle/rfc6962"
@@ -414,6 +415,91 @@ func TestVerifyConsistencyProof(t *testing.T) {
 	}
 }
 
+func TestVerifyInclusionProofErrors(t *testing.T) {
+	v := NewLogVerifier(rfc6962.DefaultHasher)
+	leafHash := rfc6962.DefaultHasher.HashLeaf(leaves[0].h)
+	proof := [][]byte{inclusionProofs[2].proof[0].h, inclusionProofs[2].proof[1].h, inclusionProofs[2].proof[2].h}
+
+	tests := []struct {
+		desc                string
+		leafIndex, treeSize int64
+		proof               [][]byte
+		root                []byte
+		want                error
+	}{
+		{desc: "negativeIndex", leafIndex: -1, treeSize: 8, proof: proof, root: roots[7].h, want: IndexOutOfRangeError{Index: -1, TreeSize: 8}},
+		{desc: "indexBeyondTree", leafIndex: 8, treeSize: 8, proof: proof, root: roots[7].h, want: IndexOutOfRangeError{Index: 8, TreeSize: 8}},
+		{desc: "shortProof", leafIndex: 0, treeSize: 8, proof: proof[:2], root: roots[7].h, want: ProofLengthError{Got: 2, Want: 3}},
+		{desc: "longProof", leafIndex: 0, treeSize: 4, proof: proof, root: roots[3].h, want: ProofLengthError{Got: 3, Want: 2}},
+	}
+	for _, test := range tests {
+		err := v.VerifyInclusionProof(test.leafIndex, test.treeSize, test.proof, test.root, leafHash)
+		if !reflect.DeepEqual(err, test.want) {
+			t.Errorf("%v: VerifyInclusionProof() = %v, want %v", test.desc, err, test.want)
+		}
+		if v.InclusionProofValid(test.leafIndex, test.treeSize, test.proof, test.root, leafHash) {
+			t.Errorf("%v: InclusionProofValid() = true, want false", test.desc)
+		}
+	}
+
+	err := v.VerifyInclusionProof(0, 8, proof, roots[6].h, leafHash)
+	if _, ok := err.(RootMismatchError); !ok {
+		t.Errorf("VerifyInclusionProof() with wrong root = %v, want RootMismatchError", err)
+	}
+	if !v.InclusionProofValid(0, 8, proof, roots[7].h, leafHash) {
+		t.Error("InclusionProofValid() = false for a valid proof")
+	}
+}
+
+func TestVerifyConsistencyProofErrors(t *testing.T) {
+	v := NewLogVerifier(rfc6962.DefaultHasher)
+	proof := [][]byte{consistencyProofs[1].proof[0].h, consistencyProofs[1].proof[1].h, consistencyProofs[1].proof[2].h}
+
+	tests := []struct {
+		desc                 string
+		snapshot1, snapshot2 int64
+		proof                [][]byte
+		want                 error
+	}{
+		{desc: "negative", snapshot1: -1, snapshot2: 8, proof: proof, want: SnapshotOrderError{Snapshot1: -1, Snapshot2: 8}},
+		{desc: "timeTravel", snapshot1: 8, snapshot2: 1, proof: proof, want: SnapshotOrderError{Snapshot1: 8, Snapshot2: 1}},
+		{desc: "sameSizeNonEmpty", snapshot1: 8, snapshot2: 8, proof: proof[:1], want: ProofLengthError{Got: 1, Want: 0}},
+		{desc: "fromEmptyNonEmpty", snapshot1: 0, snapshot2: 8, proof: proof[:1], want: ProofLengthError{Got: 1, Want: 0}},
+		{desc: "shortProof", snapshot1: 1, snapshot2: 8, proof: proof[:2], want: ProofLengthError{Got: 2, Want: 3}},
+		{desc: "emptyProof", snapshot1: 1, snapshot2: 8, want: ProofLengthError{Got: 0, Want: 3}},
+	}
+	for _, test := range tests {
+		err := v.VerifyConsistencyProof(test.snapshot1, test.snapshot2, roots[0].h, roots[7].h, test.proof)
+		if !reflect.DeepEqual(err, test.want) {
+			t.Errorf("%v: VerifyConsistencyProof() = %v, want %v", test.desc, err, test.want)
+		}
+		if v.ConsistencyProofValid(test.snapshot1, test.snapshot2, roots[0].h, roots[7].h, test.proof) {
+			t.Errorf("%v: ConsistencyProofValid() = true, want false", test.desc)
+		}
+	}
+
+	err := v.VerifyConsistencyProof(1, 8, roots[0].h, roots[6].h, proof)
+	if _, ok := err.(RootMismatchError); !ok {
+		t.Errorf("VerifyConsistencyProof() with wrong root = %v, want RootMismatchError", err)
+	}
+	if !v.ConsistencyProofValid(1, 8, roots[0].h, roots[7].h, proof) {
+		t.Error("ConsistencyProofValid() = false for a valid proof")
+	}
+}
+
+func TestProofLengths(t *testing.T) {
+	for _, p := range inclusionProofs[1:] {
+		if got, want := inclusionProofLength(p.leaf-1, p.snapshot), int(p.proofLength); got != want {
+			t.Errorf("inclusionProofLength(%d, %d) = %d, want %d", p.leaf-1, p.snapshot, got, want)
+		}
+	}
+	for _, p := range consistencyProofs {
+		if got, want := consistencyProofLength(p.snapshot1, p.snapshot2), int(p.proofLen); got != want {
+			t.Errorf("consistencyProofLength(%d, %d) = %d, want %d", p.snapshot1, p.snapshot2, got, want)
+		}
+	}
+}
+
 func dh(h string) []byte {
 	r, err := hex.DecodeString(h)
 	if err != nil {
@@ -33,7 +33,7 @@ func VerifyMapInclusionProof(treeID int64, index, leafHash, expectedRoot []byte,
 		return fmt.Errorf("index len: %d, want %d", got, want)
 	}
 	if got, want := len(proof), h.BitLen(); got != want {
-		return fmt.Errorf("proof len: %d, want %d", got, want)
+		return ProofLengthError{Got: got, Want: want}
 	}
 	for i, element := range proof {
 		if got, wanta, wantb := len(element), 0, h.Size(); got != wanta && got != wantb {
@@ -57,8 +57,11 @@ func VerifyMapInclusionProof(treeID int64, index, leafHash, expectedRoot []byte,
 		}
 	}
 
-	if got, want := runningHash, expectedRoot; !bytes.Equal(got, want) {
-		return fmt.Errorf("calculated root: %x, want \n%x", got, want)
+	if !bytes.Equal(runningHash, expectedRoot) {
+		return RootMismatchError{
+			CalculatedRoot: runningHash,
+			ExpectedRoot:   expectedRoot,
+		}
 	}
 	return nil
 }
@@ -17,6 +17,7 @@ package maphasher
 
 import (
 	"crypto"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/google/trillian"
@@ -25,6 +26,21 @@ import (
 
 func init() {
 	hashers.RegisterMapHasher(trillian.HashStrategy_TEST_MAP_HASHER, Default)
+	hashers.RegisterNullHashVector(trillian.HashStrategy_TEST_MAP_HASHER, NullHashVector)
+}
+
+// NullHashVector is the known answer for the null hashes of Default.
+var NullHashVector = hashers.NullHashVector{
+	EmptyRoot: mustDecodeHex("c6689f10812a0980976d9533d83875282166159567ec35155716c1413af53d6a"),
+	Digest:    mustDecodeHex("505243d53f4624868f4e1ec2533600bd63b9efd83c869a16d8a9963fc27e5d77"),
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
 }
 
 // Domain separation prefixes
@@ -72,3 +72,22 @@ func (s *hstar) hStarEmpty(n int) []byte {
 	}
 	return s.hStarEmptyCache[n]
 }
+
+func TestNullHashVector(t *testing.T) {
+	if err := NullHashVector.Verify(Default); err != nil {
+		t.Errorf("NullHashVector.Verify(Default) = %v, want nil", err)
+	}
+	if err := hashers.VerifyNullHashes(); err != nil {
+		t.Errorf("VerifyNullHashes() = %v, want nil", err)
+	}
+	// A hasher with the same tree height but a different hash function has different null hashes.
+	if err := NullHashVector.Verify(New(crypto.SHA512_256)); err == nil {
+		t.Error("NullHashVector.Verify(New(SHA512_256)) = nil, want error")
+	}
+	v := NullHashVector
+	v.Digest = append([]byte{}, v.Digest...)
+	v.Digest[0] ^= 1
+	if err := v.Verify(Default); err == nil {
+		t.Error("Verify(Default) with a modified digest = nil, want error")
+	}
+}
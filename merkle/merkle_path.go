@@ -72,6 +72,13 @@ func CalcInclusionProofNodeAddresses(snapshot, index, treeSize int64, maxBitLen
 	return pathFromNodeToRootAtSnapshot(index, 0, snapshot, treeSize, maxBitLen)
 }
 
+// MaxProofNodeFetches returns the most NodeFetches that an inclusion or consistency proof at
+// any snapshot of a tree of treeSize leaves can need: one per level of the tree for the proof
+// itself, and as many again for the nodes rehashed when the snapshot is below treeSize.
+func MaxProofNodeFetches(treeSize int64) int {
+	return 2 * bitLen(treeSize)
+}
+
 // CalcConsistencyProofNodeAddresses returns the tree node IDs needed to
 // build a consistency proof between two specified tree sizes. snapshot1 and snapshot2 represent
 // the two tree sizes for which consistency should be proved, treeSize is the actual size of the
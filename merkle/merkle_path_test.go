@@ -358,6 +358,32 @@ func TestConsistencySucceedsUpToTreeSize(t *testing.T) {
 	}
 }
 
+func TestMaxProofNodeFetches(t *testing.T) {
+	for ts := int64(1); ts < testUpToTreeSize; ts++ {
+		max := MaxProofNodeFetches(ts)
+		for snapshot := int64(1); snapshot <= ts; snapshot++ {
+			for i := int64(0); i < snapshot; i++ {
+				fetches, err := CalcInclusionProofNodeAddresses(snapshot, i, ts, 64)
+				if err != nil {
+					t.Fatalf("CalcInclusionProofNodeAddresses(%d, %d, %d) = %v", snapshot, i, ts, err)
+				}
+				if got := len(fetches); got > max {
+					t.Errorf("CalcInclusionProofNodeAddresses(%d, %d, %d) returned %d fetches, want <= %d", snapshot, i, ts, got, max)
+				}
+			}
+			for s1 := int64(1); s1 <= snapshot; s1++ {
+				fetches, err := CalcConsistencyProofNodeAddresses(s1, snapshot, ts, 64)
+				if err != nil {
+					t.Fatalf("CalcConsistencyProofNodeAddresses(%d, %d, %d) = %v", s1, snapshot, ts, err)
+				}
+				if got := len(fetches); got > max {
+					t.Errorf("CalcConsistencyProofNodeAddresses(%d, %d, %d) returned %d fetches, want <= %d", s1, snapshot, ts, got, max)
+				}
+			}
+		}
+	}
+}
+
 func MustCreateNodeFetchForTreeCoords(depth, index int64, maxPathBits int, rehash bool) NodeFetch {
 	n, err := storage.NewNodeIDForTreeCoords(depth, index, maxPathBits)
 	if err != nil {
@@ -0,0 +1,278 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otel
+// +build otel
+
+// Package opentelemetry provides an OpenTelemetry-based implementation of the
+// MetricFactory abstraction.
+//
+// Metrics are named and labelled as by the Prometheus-based implementation, so
+// dashboards work with either of them.
+package opentelemetry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"golang.org/x/net/context"
+)
+
+// meterName identifies the instrumentation library to OpenTelemetry.
+const meterName = "github.com/google/trillian"
+
+// MetricFactory allows the creation of OpenTelemetry-based metrics.
+type MetricFactory struct {
+	Prefix string
+	Meter  metric.Meter
+}
+
+// ExportingMetricFactory is a MetricFactory that owns the MeterProvider its
+// metrics are exported through.
+type ExportingMetricFactory struct {
+	MetricFactory
+	provider *sdkmetric.MeterProvider
+}
+
+// NewExportingMetricFactory creates a MetricFactory that exports metrics every
+// interval via OTLP/gRPC to endpoint (host:port), usually an OpenTelemetry
+// collector. Shutdown should be called before exiting, so pending metrics are
+// exported.
+func NewExportingMetricFactory(ctx context.Context, endpoint string, interval time.Duration) (*ExportingMetricFactory, error) {
+	exporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpoint(endpoint), otlpmetricgrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter for %v: %v", endpoint, err)
+	}
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))))
+	return &ExportingMetricFactory{
+		MetricFactory: MetricFactory{Meter: provider.Meter(meterName)},
+		provider:      provider,
+	}, nil
+}
+
+// Shutdown exports pending metrics and stops the exporter.
+func (emf *ExportingMetricFactory) Shutdown(ctx context.Context) error {
+	return emf.provider.Shutdown(ctx)
+}
+
+// NewCounter creates a new Counter object backed by OpenTelemetry.
+func (omf MetricFactory) NewCounter(name, help string, labelNames ...string) monitoring.Counter {
+	c := &Counter{newValues(labelNames)}
+	if _, err := omf.Meter.Float64ObservableCounter(
+		omf.Prefix+name,
+		metric.WithDescription(help),
+		metric.WithFloat64Callback(c.observe)); err != nil {
+		panic(fmt.Sprintf("failed to create counter %v: %v", omf.Prefix+name, err))
+	}
+	return c
+}
+
+// NewGauge creates a new Gauge object backed by OpenTelemetry.
+func (omf MetricFactory) NewGauge(name, help string, labelNames ...string) monitoring.Gauge {
+	g := &Gauge{newValues(labelNames)}
+	if _, err := omf.Meter.Float64ObservableGauge(
+		omf.Prefix+name,
+		metric.WithDescription(help),
+		metric.WithFloat64Callback(g.observe)); err != nil {
+		panic(fmt.Sprintf("failed to create gauge %v: %v", omf.Prefix+name, err))
+	}
+	return g
+}
+
+// NewHistogram creates a new Histogram object backed by OpenTelemetry.
+func (omf MetricFactory) NewHistogram(name, help string, labelNames ...string) monitoring.Histogram {
+	histogram, err := omf.Meter.Float64Histogram(omf.Prefix+name, metric.WithDescription(help))
+	if err != nil {
+		panic(fmt.Sprintf("failed to create histogram %v: %v", omf.Prefix+name, err))
+	}
+	return &Histogram{
+		labelNames: labelNames,
+		histogram:  histogram,
+		counts:     make(map[string]uint64),
+		sums:       make(map[string]float64),
+	}
+}
+
+// values holds the current values of a counter or gauge, so they can be read
+// back and reported to OpenTelemetry when it collects metrics.
+type values struct {
+	labelNames []string
+	mu         sync.Mutex
+	vals       map[string]*point
+}
+
+// point is the value of a metric for a set of labels.
+type point struct {
+	attrs attribute.Set
+	val   float64
+}
+
+func newValues(labelNames []string) values {
+	return values{labelNames: labelNames, vals: make(map[string]*point)}
+}
+
+// update applies fn to the value for labelVals.
+func (v *values) update(fn func(float64) float64, labelVals []string) {
+	key, err := keyFor(v.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	p, ok := v.vals[key]
+	if !ok {
+		p = &point{attrs: attributesFor(v.labelNames, labelVals)}
+		v.vals[key] = p
+	}
+	p.val = fn(p.val)
+}
+
+func (v *values) value(labelVals []string) float64 {
+	key, err := keyFor(v.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return 0.0
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if p, ok := v.vals[key]; ok {
+		return p.val
+	}
+	return 0.0
+}
+
+func (v *values) observe(ctx context.Context, o metric.Float64Observer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, p := range v.vals {
+		o.Observe(p.val, metric.WithAttributeSet(p.attrs))
+	}
+	return nil
+}
+
+// Counter is a counter reported to OpenTelemetry as a cumulative sum.
+type Counter struct {
+	values
+}
+
+// Inc adds 1 to a counter.
+func (m *Counter) Inc(labelVals ...string) {
+	m.Add(1.0, labelVals...)
+}
+
+// Add adds the given amount to a counter.
+func (m *Counter) Add(val float64, labelVals ...string) {
+	if val < 0 {
+		glog.Errorf("counters can't be decreased, got %v", val)
+		return
+	}
+	m.update(func(v float64) float64 { return v + val }, labelVals)
+}
+
+// Value returns the current amount of a counter.
+func (m *Counter) Value(labelVals ...string) float64 {
+	return m.value(labelVals)
+}
+
+// Gauge is a gauge reported to OpenTelemetry.
+type Gauge struct {
+	values
+}
+
+// Inc adds 1 to a gauge.
+func (m *Gauge) Inc(labelVals ...string) {
+	m.Add(1.0, labelVals...)
+}
+
+// Dec subtracts 1 from a gauge.
+func (m *Gauge) Dec(labelVals ...string) {
+	m.Add(-1.0, labelVals...)
+}
+
+// Add adds given value to a gauge.
+func (m *Gauge) Add(val float64, labelVals ...string) {
+	m.update(func(v float64) float64 { return v + val }, labelVals)
+}
+
+// Set sets the value of a gauge.
+func (m *Gauge) Set(val float64, labelVals ...string) {
+	m.update(func(float64) float64 { return val }, labelVals)
+}
+
+// Value returns the current amount of a gauge.
+func (m *Gauge) Value(labelVals ...string) float64 {
+	return m.value(labelVals)
+}
+
+// Histogram is a wrapper around an OpenTelemetry Float64Histogram. Counts and
+// sums of observations are also kept locally, so they can be read back.
+type Histogram struct {
+	labelNames []string
+	histogram  metric.Float64Histogram
+	mu         sync.Mutex
+	counts     map[string]uint64
+	sums       map[string]float64
+}
+
+// Observe adds a single observation to the histogram.
+func (m *Histogram) Observe(val float64, labelVals ...string) {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return
+	}
+	m.histogram.Record(context.Background(), val, metric.WithAttributeSet(attributesFor(m.labelNames, labelVals)))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[key]++
+	m.sums[key] += val
+}
+
+// Info returns the count and sum of observations for the histogram.
+func (m *Histogram) Info(labelVals ...string) (uint64, float64) {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return 0, 0.0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[key], m.sums[key]
+}
+
+func keyFor(names, values []string) (string, error) {
+	if len(names) != len(values) {
+		return "", fmt.Errorf("got %d (%v) values for %d labels (%v)", len(values), values, len(names), names)
+	}
+	return strings.Join(values, "|"), nil
+}
+
+func attributesFor(names, values []string) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(names))
+	for i, name := range names {
+		kvs = append(kvs, attribute.String(name, values[i]))
+	}
+	return attribute.NewSet(kvs...)
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otel
+// +build otel
+
+package opentelemetry
+
+import (
+	"testing"
+
+	"github.com/google/trillian/monitoring/testonly"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"golang.org/x/net/context"
+)
+
+func newFactory(prefix string) (MetricFactory, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	return MetricFactory{Prefix: prefix, Meter: provider.Meter(meterName)}, reader
+}
+
+func TestCounter(t *testing.T) {
+	mf, _ := newFactory("TestCounter")
+	testonly.TestCounter(t, mf)
+}
+func TestGauge(t *testing.T) {
+	mf, _ := newFactory("TestGauge")
+	testonly.TestGauge(t, mf)
+}
+func TestHistogram(t *testing.T) {
+	mf, _ := newFactory("TestHistogram")
+	testonly.TestHistogram(t, mf)
+}
+
+func TestExportedNamesAndLabels(t *testing.T) {
+	ctx := context.Background()
+	mf, reader := newFactory("trillian_")
+	mf.NewCounter("requests", "help", "method").Add(3, "GetLeaves")
+	mf.NewGauge("queue_size", "help", "tree_id").Set(7, "12")
+	mf.NewHistogram("latency", "help", "method").Observe(0.5, "GetLeaves")
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect()=%v, want: nil", err)
+	}
+	got := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	var tests = []struct {
+		name  string
+		label attribute.KeyValue
+		want  float64
+	}{
+		{name: "trillian_requests", label: attribute.String("method", "GetLeaves"), want: 3},
+		{name: "trillian_queue_size", label: attribute.String("tree_id", "12"), want: 7},
+		{name: "trillian_latency", label: attribute.String("method", "GetLeaves"), want: 0.5},
+	}
+	for _, test := range tests {
+		var attrs attribute.Set
+		var val float64
+		switch data := got[test.name].(type) {
+		case metricdata.Sum[float64]:
+			if len(data.DataPoints) != 1 {
+				t.Errorf("%v: got %d data points, want 1", test.name, len(data.DataPoints))
+				continue
+			}
+			attrs, val = data.DataPoints[0].Attributes, data.DataPoints[0].Value
+		case metricdata.Gauge[float64]:
+			if len(data.DataPoints) != 1 {
+				t.Errorf("%v: got %d data points, want 1", test.name, len(data.DataPoints))
+				continue
+			}
+			attrs, val = data.DataPoints[0].Attributes, data.DataPoints[0].Value
+		case metricdata.Histogram[float64]:
+			if len(data.DataPoints) != 1 {
+				t.Errorf("%v: got %d data points, want 1", test.name, len(data.DataPoints))
+				continue
+			}
+			attrs, val = data.DataPoints[0].Attributes, data.DataPoints[0].Sum
+		default:
+			t.Errorf("%v: unexpected data %T", test.name, data)
+			continue
+		}
+		if v, ok := attrs.Value(test.label.Key); !ok || v != test.label.Value {
+			t.Errorf("%v: label %v=%v, want %v", test.name, test.label.Key, v.Emit(), test.label.Value.Emit())
+		}
+		if val != test.want {
+			t.Errorf("%v: value=%v, want %v", test.name, val, test.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otel
+// +build otel
+
+package opentelemetry
+
+import (
+	"fmt"
+
+	"github.com/google/trillian/monitoring"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// TracerProvider exports the spans of traces sampled by this process.
+type TracerProvider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// NewTracerProvider creates a TracerProvider that exports spans via OTLP to endpoint
+// (host:port), and installs it as the global OpenTelemetry tracer provider along with the
+// W3C trace context propagator, and Tracer as the monitoring.Tracer. Collectors that accept OTLP, such as Jaeger, can be used
+// directly. Traces started by this process are sampled with probability sampleFraction;
+// those started by a caller keep the caller's sampling decision.
+func NewTracerProvider(ctx context.Context, endpoint, serviceName string, sampleFraction float64) (*TracerProvider, error) {
+	if sampleFraction < 0 || sampleFraction > 1 {
+		return nil, fmt.Errorf("trace sample fraction must be in [0, 1], got %v", sampleFraction)
+	}
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter for %v: %v", endpoint, err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleFraction))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	monitoring.SetTracer(Tracer{})
+	return &TracerProvider{provider: provider}, nil
+}
+
+// Shutdown exports pending spans and stops the exporter.
+func (tp *TracerProvider) Shutdown(ctx context.Context) error {
+	return tp.provider.Shutdown(ctx)
+}
+
+// Tracer is a monitoring.Tracer that traces with the global OpenTelemetry tracer provider,
+// and propagates spans with the global propagator.
+type Tracer struct{}
+
+// StartSpan starts a span with the tracer named instrumentation.
+func (Tracer) StartSpan(ctx context.Context, instrumentation, name string) (context.Context, monitoring.Span) {
+	ctx, s := otel.Tracer(instrumentation).Start(ctx, name)
+	return ctx, span{s}
+}
+
+// StartServerSpan starts a server span with the tracer named instrumentation.
+func (Tracer) StartServerSpan(ctx context.Context, instrumentation, name string, md map[string][]string) (context.Context, monitoring.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, s := otel.Tracer(instrumentation).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+	return ctx, span{s}
+}
+
+// InjectSpan propagates the span in ctx, if any, in md.
+func (Tracer) InjectSpan(ctx context.Context, md map[string][]string) {
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+}
+
+// span is a monitoring.Span that wraps an OpenTelemetry span.
+type span struct {
+	s trace.Span
+}
+
+func (s span) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case int:
+		s.s.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.s.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.s.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.s.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s span) SetError(msg string) {
+	s.s.SetStatus(otelcodes.Error, msg)
+}
+
+func (s span) End() {
+	s.s.End()
+}
+
+// metadataCarrier lets OpenTelemetry propagators read and write trace context in gRPC
+// metadata.
+type metadataCarrier map[string][]string
+
+func (c metadataCarrier) Get(key string) string {
+	if v := c[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	c[key] = []string{value}
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otel
+// +build otel
+
+package opentelemetry
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	oldProvider, oldPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(oldProvider)
+		otel.SetTextMapPropagator(oldPropagator)
+	}()
+
+	// The client propagates its span to the server in the request metadata.
+	tracer := Tracer{}
+	ctx, clientSpan := tracer.StartSpan(context.Background(), "test", "client")
+	md := make(map[string][]string)
+	tracer.InjectSpan(ctx, md)
+	clientSpan.End()
+
+	serverCtx, serverSpan := tracer.StartServerSpan(context.Background(), "test", "server", md)
+	serverSpan.SetAttribute("trillian.tree_id", int64(12))
+	serverSpan.SetAttribute("trillian.leaves", 3)
+	serverSpan.SetAttribute("trillian.readonly", true)
+	serverSpan.SetAttribute("rpc.grpc.status_code", "NotFound")
+	serverSpan.SetError("tree not found")
+	serverSpan.End()
+
+	spans := recorder.Ended()
+	if got, want := len(spans), 2; got != want {
+		t.Fatalf("got %v spans, want %v", got, want)
+	}
+	client, server := spans[0], spans[1]
+	if got, want := server.Name(), "server"; got != want {
+		t.Errorf("span name = %v, want %v", got, want)
+	}
+	if got, want := server.SpanKind(), trace.SpanKindServer; got != want {
+		t.Errorf("span kind = %v, want %v", got, want)
+	}
+	if got, want := server.Parent().SpanID(), client.SpanContext().SpanID(); got != want {
+		t.Errorf("server span parent = %v, want client span %v", got, want)
+	}
+	if got, want := trace.SpanContextFromContext(serverCtx).SpanID(), server.SpanContext().SpanID(); got != want {
+		t.Errorf("StartServerSpan() returned context with span %v, want %v", got, want)
+	}
+	if got, want := server.Status().Code, otelcodes.Error; got != want {
+		t.Errorf("span status = %v, want %v", got, want)
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range server.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got, want := attrs["trillian.tree_id"].AsInt64(), int64(12); got != want {
+		t.Errorf("trillian.tree_id = %v, want %v", got, want)
+	}
+	if got, want := attrs["trillian.leaves"].AsInt64(), int64(3); got != want {
+		t.Errorf("trillian.leaves = %v, want %v", got, want)
+	}
+	if got, want := attrs["trillian.readonly"].AsBool(), true; got != want {
+		t.Errorf("trillian.readonly = %v, want %v", got, want)
+	}
+	if got, want := attrs["rpc.grpc.status_code"].AsString(), "NotFound"; got != want {
+		t.Errorf("rpc.grpc.status_code = %v, want %v", got, want)
+	}
+}
@@ -18,6 +18,8 @@ package prometheus
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/monitoring"
@@ -28,68 +30,128 @@ import (
 // MetricFactory allows the creation of Prometheus-based metrics.
 type MetricFactory struct {
 	Prefix string
+	// Namespace is prepended to the names of metrics, separated by an underscore.
+	Namespace string
+	// ConstLabels are labels with fixed values attached to every metric, e.g. the
+	// cluster or region a server runs in.
+	ConstLabels map[string]string
+	// Buckets are the upper bounds of the buckets of histograms, in increasing order.
+	// Nil means Prometheus' default buckets.
+	Buckets []float64
+	// HistogramBuckets overrides Buckets for the histograms with the given names.
+	HistogramBuckets map[string][]float64
+}
+
+// NewMetricFactory creates a MetricFactory from flag-style strings. constLabels is a
+// comma-separated list of name=value pairs. buckets is a semicolon-separated list of
+// comma-separated bucket bounds, applying to every histogram if unnamed, or to one
+// histogram if prefixed with its name and "=", e.g. "0.1,1,10;queued_leaves=1,10,100,1000".
+func NewMetricFactory(namespace, constLabels, buckets string) (MetricFactory, error) {
+	mf := MetricFactory{Namespace: namespace}
+	if constLabels != "" {
+		mf.ConstLabels = make(map[string]string)
+		for _, pair := range strings.Split(constLabels, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				return MetricFactory{}, fmt.Errorf("malformed label %q, want name=value", pair)
+			}
+			mf.ConstLabels[kv[0]] = kv[1]
+		}
+	}
+	if buckets != "" {
+		for _, spec := range strings.Split(buckets, ";") {
+			name := ""
+			if i := strings.Index(spec, "="); i >= 0 {
+				name, spec = spec[:i], spec[i+1:]
+			}
+			bounds, err := parseBuckets(spec)
+			if err != nil {
+				return MetricFactory{}, err
+			}
+			if name == "" {
+				mf.Buckets = bounds
+				continue
+			}
+			if mf.HistogramBuckets == nil {
+				mf.HistogramBuckets = make(map[string][]float64)
+			}
+			mf.HistogramBuckets[name] = bounds
+		}
+	}
+	return mf, nil
+}
+
+func parseBuckets(spec string) ([]float64, error) {
+	var bounds []float64
+	for _, b := range strings.Split(spec, ",") {
+		bound, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bucket bound %q: %v", b, err)
+		}
+		if len(bounds) > 0 && bound <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("bucket bounds %q aren't in increasing order", spec)
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
 }
 
 // NewCounter creates a new Counter object backed by Prometheus.
 func (pmf MetricFactory) NewCounter(name, help string, labelNames ...string) monitoring.Counter {
+	opts := prometheus.CounterOpts{
+		Namespace:   pmf.Namespace,
+		Name:        pmf.Prefix + name,
+		Help:        help,
+		ConstLabels: pmf.ConstLabels,
+	}
 	if labelNames == nil || len(labelNames) == 0 {
-		counter := prometheus.NewCounter(
-			prometheus.CounterOpts{
-				Name: pmf.Prefix + name,
-				Help: help,
-			})
+		counter := prometheus.NewCounter(opts)
 		prometheus.MustRegister(counter)
 		return &Counter{single: counter}
 	}
 
-	vec := prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: pmf.Prefix + name,
-			Help: help,
-		},
-		labelNames)
+	vec := prometheus.NewCounterVec(opts, labelNames)
 	prometheus.MustRegister(vec)
 	return &Counter{labelNames: labelNames, vec: vec}
 }
 
 // NewGauge creates a new Gauge object backed by Prometheus.
 func (pmf MetricFactory) NewGauge(name, help string, labelNames ...string) monitoring.Gauge {
+	opts := prometheus.GaugeOpts{
+		Namespace:   pmf.Namespace,
+		Name:        pmf.Prefix + name,
+		Help:        help,
+		ConstLabels: pmf.ConstLabels,
+	}
 	if labelNames == nil || len(labelNames) == 0 {
-		gauge := prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: pmf.Prefix + name,
-				Help: help,
-			})
+		gauge := prometheus.NewGauge(opts)
 		prometheus.MustRegister(gauge)
 		return &Gauge{single: gauge}
 	}
-	vec := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: pmf.Prefix + name,
-			Help: help,
-		},
-		labelNames)
+	vec := prometheus.NewGaugeVec(opts, labelNames)
 	prometheus.MustRegister(vec)
 	return &Gauge{labelNames: labelNames, vec: vec}
 }
 
 // NewHistogram creates a new Histogram object backed by Prometheus.
 func (pmf MetricFactory) NewHistogram(name, help string, labelNames ...string) monitoring.Histogram {
+	buckets, ok := pmf.HistogramBuckets[name]
+	if !ok {
+		buckets = pmf.Buckets
+	}
+	opts := prometheus.HistogramOpts{
+		Namespace:   pmf.Namespace,
+		Name:        pmf.Prefix + name,
+		Help:        help,
+		ConstLabels: pmf.ConstLabels,
+		Buckets:     buckets,
+	}
 	if labelNames == nil || len(labelNames) == 0 {
-		histogram := prometheus.NewHistogram(
-			prometheus.HistogramOpts{
-				Name: pmf.Prefix + name,
-				Help: help,
-			})
+		histogram := prometheus.NewHistogram(opts)
 		prometheus.MustRegister(histogram)
 		return &Histogram{single: histogram}
 	}
-	vec := prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: pmf.Prefix + name,
-			Help: help,
-		},
-		labelNames)
+	vec := prometheus.NewHistogramVec(opts, labelNames)
 	prometheus.MustRegister(vec)
 	return &Histogram{labelNames: labelNames, vec: vec}
 }
@@ -15,9 +15,11 @@
 package prometheus
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/google/trillian/monitoring/testonly"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestCounter(t *testing.T) {
@@ -29,3 +31,73 @@ func TestGauge(t *testing.T) {
 func TestHistogram(t *testing.T) {
 	testonly.TestHistogram(t, MetricFactory{Prefix: "TestHistogram"})
 }
+
+func TestNewMetricFactory(t *testing.T) {
+	tests := []struct {
+		desc, labels, buckets string
+		want                  MetricFactory
+		wantErr               bool
+	}{
+		{desc: "empty", want: MetricFactory{Namespace: "ns"}},
+		{
+			desc:    "full",
+			labels:  "cluster=c1,region=",
+			buckets: "0.1,1,10;queue_size=1,100",
+			want: MetricFactory{
+				Namespace:        "ns",
+				ConstLabels:      map[string]string{"cluster": "c1", "region": ""},
+				Buckets:          []float64{0.1, 1, 10},
+				HistogramBuckets: map[string][]float64{"queue_size": {1, 100}},
+			},
+		},
+		{desc: "labelWithoutValue", labels: "cluster", wantErr: true},
+		{desc: "labelWithoutName", labels: "=c1", wantErr: true},
+		{desc: "badBound", buckets: "1,two", wantErr: true},
+		{desc: "unorderedBounds", buckets: "1,10,5", wantErr: true},
+		{desc: "duplicateBounds", buckets: "x=1,1", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := NewMetricFactory("ns", test.labels, test.buckets)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: NewMetricFactory() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		} else if hasErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: NewMetricFactory() = %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	mf := MetricFactory{
+		Namespace:        "TestHistogramBuckets",
+		ConstLabels:      map[string]string{"cluster": "c1"},
+		Buckets:          []float64{1, 2},
+		HistogramBuckets: map[string][]float64{"custom": {10, 20, 30}},
+	}
+	for _, test := range []struct {
+		name string
+		want []float64
+	}{
+		{name: "default", want: []float64{1, 2}},
+		{name: "custom", want: []float64{10, 20, 30}},
+	} {
+		h := mf.NewHistogram(test.name, "help").(*Histogram)
+		var metricpb dto.Metric
+		if err := h.single.Write(&metricpb); err != nil {
+			t.Fatalf("%v: Write() returned err = %v", test.name, err)
+		}
+		var got []float64
+		for _, b := range metricpb.GetHistogram().GetBucket() {
+			got = append(got, b.GetUpperBound())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: buckets = %v, want %v", test.name, got, test.want)
+		}
+		if labels := metricpb.GetLabel(); len(labels) != 1 || labels[0].GetName() != "cluster" || labels[0].GetValue() != "c1" {
+			t.Errorf("%v: labels = %v, want cluster=c1", test.name, labels)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statsd provides a StatsD-based implementation of the MetricFactory
+// abstraction, for collectors that speak the StatsD or DogStatsD protocol.
+//
+// Plain StatsD has no labels, so label values are appended to metric names,
+// separated by dots. DogStatsD sends them as tags instead.
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+)
+
+// maxPacketSize keeps packets within the MTU of most networks, so they aren't fragmented.
+const maxPacketSize = 1432
+
+// Client sends metric updates to a StatsD collector over UDP. Updates are buffered, and
+// sent when a packet is full or every flush interval.
+type Client struct {
+	conn    net.Conn
+	tags    bool
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewClient creates a Client that sends updates to endpoint (host:port) at least every
+// flushInterval. If dogStatsD is true, labels are sent as DogStatsD tags. Close should be
+// called before exiting, so buffered updates are sent.
+func NewClient(endpoint string, flushInterval time.Duration, dogStatsD bool) (*Client, error) {
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("StatsD flush interval must be positive, got %v", flushInterval)
+	}
+	conn, err := net.Dial("udp", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial StatsD collector %v: %v", endpoint, err)
+	}
+	c := &Client{
+		conn:    conn,
+		tags:    dogStatsD,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go c.run(flushInterval)
+	return c, nil
+}
+
+func (c *Client) run(flushInterval time.Duration) {
+	defer close(c.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.Flush()
+		}
+	}
+}
+
+// Flush sends the buffered updates.
+func (c *Client) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+func (c *Client) flushLocked() {
+	if c.buf.Len() == 0 {
+		return
+	}
+	// UDP is lossy anyway, so failures are only logged.
+	if _, err := c.conn.Write(c.buf.Bytes()); err != nil {
+		glog.Warningf("Failed to send StatsD metrics: %v", err)
+	}
+	c.buf.Reset()
+}
+
+// Close sends the buffered updates, and stops the client.
+func (c *Client) Close() error {
+	close(c.done)
+	<-c.stopped
+	c.Flush()
+	return c.conn.Close()
+}
+
+// send buffers an update of the given StatsD type, e.g. "c" for counters.
+func (c *Client) send(name string, labelNames, labelVals []string, val float64, typ string) {
+	var line bytes.Buffer
+	line.WriteString(sanitize(name))
+	if !c.tags {
+		for _, v := range labelVals {
+			line.WriteByte('.')
+			line.WriteString(sanitize(v))
+		}
+	}
+	line.WriteByte(':')
+	line.WriteString(strconv.FormatFloat(val, 'f', -1, 64))
+	line.WriteByte('|')
+	line.WriteString(typ)
+	if c.tags && len(labelNames) > 0 {
+		line.WriteString("|#")
+		for i, n := range labelNames {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.WriteString(sanitize(n))
+			line.WriteByte(':')
+			line.WriteString(sanitize(labelVals[i]))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.buf.Len() > 0 && c.buf.Len()+1+line.Len() > maxPacketSize {
+		c.flushLocked()
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(line.String())
+}
+
+// sanitize replaces the characters that are special to the StatsD protocol.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// MetricFactory allows the creation of StatsD-based metrics.
+type MetricFactory struct {
+	Prefix string
+	Client *Client
+}
+
+// NewCounter creates a new Counter object backed by StatsD.
+func (smf MetricFactory) NewCounter(name, help string, labelNames ...string) monitoring.Counter {
+	return &Counter{metric: newMetric(smf, name, labelNames)}
+}
+
+// NewGauge creates a new Gauge object backed by StatsD.
+func (smf MetricFactory) NewGauge(name, help string, labelNames ...string) monitoring.Gauge {
+	return &Gauge{metric: newMetric(smf, name, labelNames)}
+}
+
+// NewHistogram creates a new Histogram object backed by StatsD.
+func (smf MetricFactory) NewHistogram(name, help string, labelNames ...string) monitoring.Histogram {
+	return &Histogram{
+		metric: newMetric(smf, name, labelNames),
+		counts: make(map[string]uint64),
+	}
+}
+
+// metric holds the current values of a metric for each set of labels, so they can be read
+// back; StatsD collectors can't be queried.
+type metric struct {
+	client     *Client
+	name       string
+	labelNames []string
+	mu         sync.Mutex
+	vals       map[string]float64
+}
+
+func newMetric(smf MetricFactory, name string, labelNames []string) metric {
+	return metric{
+		client:     smf.Client,
+		name:       smf.Prefix + name,
+		labelNames: labelNames,
+		vals:       make(map[string]float64),
+	}
+}
+
+// update applies fn to the value for labelVals and returns the new value. ok is false if
+// labelVals doesn't match the metric's labels.
+func (m *metric) update(fn func(float64) float64, labelVals []string) (float64, bool) {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return 0, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v := fn(m.vals[key])
+	m.vals[key] = v
+	return v, true
+}
+
+func (m *metric) value(labelVals []string) float64 {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return 0.0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.vals[key]
+}
+
+func (m *metric) send(val float64, labelVals []string, typ string) {
+	if m.client != nil {
+		m.client.send(m.name, m.labelNames, labelVals, val, typ)
+	}
+}
+
+// Counter is a counter sent to StatsD as increments.
+type Counter struct {
+	metric
+}
+
+// Inc adds 1 to a counter.
+func (m *Counter) Inc(labelVals ...string) {
+	m.Add(1.0, labelVals...)
+}
+
+// Add adds the given amount to a counter.
+func (m *Counter) Add(val float64, labelVals ...string) {
+	if val < 0 {
+		glog.Errorf("counters can't be decreased, got %v", val)
+		return
+	}
+	if _, ok := m.update(func(v float64) float64 { return v + val }, labelVals); ok {
+		m.send(val, labelVals, "c")
+	}
+}
+
+// Value returns the current amount of a counter.
+func (m *Counter) Value(labelVals ...string) float64 {
+	return m.value(labelVals)
+}
+
+// Gauge is a gauge sent to StatsD as absolute values.
+type Gauge struct {
+	metric
+}
+
+// Inc adds 1 to a gauge.
+func (m *Gauge) Inc(labelVals ...string) {
+	m.Add(1.0, labelVals...)
+}
+
+// Dec subtracts 1 from a gauge.
+func (m *Gauge) Dec(labelVals ...string) {
+	m.Add(-1.0, labelVals...)
+}
+
+// Add adds given value to a gauge.
+func (m *Gauge) Add(val float64, labelVals ...string) {
+	m.set(func(v float64) float64 { return v + val }, labelVals)
+}
+
+// Set sets the value of a gauge.
+func (m *Gauge) Set(val float64, labelVals ...string) {
+	m.set(func(float64) float64 { return val }, labelVals)
+}
+
+func (m *Gauge) set(fn func(float64) float64, labelVals []string) {
+	// StatsD treats signed gauge values as relative, so negative values are sent as a reset
+	// to zero followed by a decrement.
+	if v, ok := m.update(fn, labelVals); ok {
+		if v < 0 {
+			m.send(0, labelVals, "g")
+			m.send(v, labelVals, "g")
+			return
+		}
+		m.send(v, labelVals, "g")
+	}
+}
+
+// Value returns the current amount of a gauge.
+func (m *Gauge) Value(labelVals ...string) float64 {
+	return m.value(labelVals)
+}
+
+// Histogram is a histogram sent to StatsD as timings, which StatsD collectors aggregate
+// into percentiles. Counts and sums of observations are kept locally.
+type Histogram struct {
+	metric
+	counts map[string]uint64
+}
+
+// Observe adds a single observation to the histogram.
+func (m *Histogram) Observe(val float64, labelVals ...string) {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return
+	}
+	m.mu.Lock()
+	m.counts[key]++
+	m.vals[key] += val
+	m.mu.Unlock()
+
+	typ := "ms"
+	if m.client != nil && m.client.tags {
+		typ = "h"
+	}
+	m.send(val, labelVals, typ)
+}
+
+// Info returns the count and sum of observations for the histogram.
+func (m *Histogram) Info(labelVals ...string) (uint64, float64) {
+	key, err := keyFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return 0, 0.0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[key], m.vals[key]
+}
+
+func keyFor(names, values []string) (string, error) {
+	if len(names) != len(values) {
+		return "", fmt.Errorf("got %d (%v) values for %d labels (%v)", len(values), values, len(names), names)
+	}
+	return strings.Join(values, "|"), nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/monitoring/testonly"
+)
+
+func TestCounter(t *testing.T) {
+	testonly.TestCounter(t, MetricFactory{Prefix: "TestCounter"})
+}
+func TestGauge(t *testing.T) {
+	testonly.TestGauge(t, MetricFactory{Prefix: "TestGauge"})
+}
+func TestHistogram(t *testing.T) {
+	testonly.TestHistogram(t, MetricFactory{Prefix: "TestHistogram"})
+}
+
+func TestClient(t *testing.T) {
+	for _, test := range []struct {
+		desc      string
+		dogStatsD bool
+		want      []string
+	}{
+		{
+			desc: "statsd",
+			want: []string{
+				"trillian.requests.QueueLeaf.OK:1|c",
+				"trillian.requests.QueueLeaf.OK:2.5|c",
+				"trillian.queue_size:3|g",
+				"trillian.queue_size:0|g",
+				"trillian.queue_size:-1|g",
+				"trillian.latency.Get_Leaf:0.25|ms",
+			},
+		},
+		{
+			desc:      "dogstatsd",
+			dogStatsD: true,
+			want: []string{
+				"trillian.requests:1|c|#method:QueueLeaf,code:OK",
+				"trillian.requests:2.5|c|#method:QueueLeaf,code:OK",
+				"trillian.queue_size:3|g",
+				"trillian.queue_size:0|g",
+				"trillian.queue_size:-1|g",
+				"trillian.latency:0.25|h|#method:Get_Leaf",
+			},
+		},
+	} {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("ListenPacket() returned err = %v", err)
+		}
+		defer conn.Close()
+		// A long flush interval, so everything is sent by Close in one packet.
+		client, err := NewClient(conn.LocalAddr().String(), time.Hour, test.dogStatsD)
+		if err != nil {
+			t.Fatalf("%v: NewClient() returned err = %v", test.desc, err)
+		}
+
+		mf := MetricFactory{Prefix: "trillian.", Client: client}
+		counter := mf.NewCounter("requests", "help", "method", "code")
+		counter.Inc("QueueLeaf", "OK")
+		counter.Add(2.5, "QueueLeaf", "OK")
+		counter.Inc("QueueLeaf") // Wrong number of labels, not sent.
+		gauge := mf.NewGauge("queue_size", "help")
+		gauge.Set(3)
+		gauge.Add(-4)
+		mf.NewHistogram("latency", "help", "method").Observe(0.25, "Get Leaf")
+		if err := client.Close(); err != nil {
+			t.Fatalf("%v: Close() returned err = %v", test.desc, err)
+		}
+
+		buf := make([]byte, maxPacketSize)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("%v: ReadFrom() returned err = %v", test.desc, err)
+		}
+		if got, want := string(buf[:n]), strings.Join(test.want, "\n"); got != want {
+			t.Errorf("%v: sent:\n%v\nwant:\n%v", test.desc, got, want)
+		}
+	}
+}
+
+func TestClientSplitsPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() returned err = %v", err)
+	}
+	defer conn.Close()
+	client, err := NewClient(conn.LocalAddr().String(), time.Hour, false)
+	if err != nil {
+		t.Fatalf("NewClient() returned err = %v", err)
+	}
+	counter := MetricFactory{Client: client}.NewCounter("a_fairly_long_counter_name", "help")
+	const updates = 200
+	for i := 0; i < updates; i++ {
+		counter.Inc()
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() returned err = %v", err)
+	}
+
+	lines := 0
+	buf := make([]byte, 64*1024)
+	for lines < updates {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom() after %v lines returned err = %v", lines, err)
+		}
+		if n > maxPacketSize {
+			t.Errorf("got a packet of %v bytes, want at most %v", n, maxPacketSize)
+		}
+		lines += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	if lines != updates {
+		t.Errorf("got %v lines, want %v", lines, updates)
+	}
+}
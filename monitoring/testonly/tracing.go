@@ -0,0 +1,134 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testonly
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+)
+
+// spanMetadataKey is the request metadata key Tracer propagates spans under.
+const spanMetadataKey = "testonly-span"
+
+type spanKey struct{}
+
+// Tracer is a monitoring.Tracer that records the spans it starts, for tests.
+type Tracer struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Span is a span started by Tracer.
+type Span struct {
+	Instrumentation string
+	Name            string
+	// Parent is the span this one is a child of, or nil.
+	Parent *Span
+	// Server is true for the spans of RPCs, started by StartServerSpan.
+	Server     bool
+	Attributes map[string]interface{}
+	// Err is the message of the failure set by SetError, if any.
+	Err   string
+	Ended bool
+
+	mu sync.Mutex
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Spans returns the spans started so far, in the order they were started.
+func (t *Tracer) Spans() []*Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Span(nil), t.spans...)
+}
+
+func (t *Tracer) start(ctx context.Context, instrumentation, name string, parent *Span, server bool) (context.Context, monitoring.Span) {
+	s := &Span{
+		Instrumentation: instrumentation,
+		Name:            name,
+		Parent:          parent,
+		Server:          server,
+		Attributes:      make(map[string]interface{}),
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// StartSpan starts a span that's a child of the span in ctx, if any.
+func (t *Tracer) StartSpan(ctx context.Context, instrumentation, name string) (context.Context, monitoring.Span) {
+	return t.start(ctx, instrumentation, name, SpanFromContext(ctx), false)
+}
+
+// StartServerSpan starts a span that's a child of the span propagated in md by InjectSpan,
+// if any.
+func (t *Tracer) StartServerSpan(ctx context.Context, instrumentation, name string, md map[string][]string) (context.Context, monitoring.Span) {
+	var parent *Span
+	if v := md[spanMetadataKey]; len(v) > 0 {
+		if i, err := strconv.Atoi(v[0]); err == nil {
+			t.mu.Lock()
+			if i < len(t.spans) {
+				parent = t.spans[i]
+			}
+			t.mu.Unlock()
+		}
+	}
+	return t.start(ctx, instrumentation, name, parent, true)
+}
+
+// InjectSpan propagates the span in ctx, if any, in md.
+func (t *Tracer) InjectSpan(ctx context.Context, md map[string][]string) {
+	s := SpanFromContext(ctx)
+	if s == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, span := range t.spans {
+		if span == s {
+			md[spanMetadataKey] = []string{strconv.Itoa(i)}
+		}
+	}
+}
+
+// SetAttribute records an attribute of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// SetError records the failure of the span.
+func (s *Span) SetError(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = msg
+}
+
+// End marks the span ended.
+func (s *Span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Ended = true
+}
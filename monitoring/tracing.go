@@ -0,0 +1,93 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitoring
+
+import (
+	"golang.org/x/net/context"
+)
+
+// Tracer traces operations in spans. Spans are carried in contexts, and a span started with
+// a context carrying another is its child.
+type Tracer interface {
+	// StartSpan starts a span named name, reported as instrumented by the package
+	// instrumentation, and returns a context carrying it.
+	StartSpan(ctx context.Context, instrumentation, name string) (context.Context, Span)
+	// StartServerSpan starts the span of an RPC served by this process, like StartSpan, as
+	// a child of the span the caller propagated in the request metadata md, if any.
+	StartServerSpan(ctx context.Context, instrumentation, name string, md map[string][]string) (context.Context, Span)
+	// InjectSpan propagates the span in ctx, if any, to a server in the request metadata md.
+	InjectSpan(ctx context.Context, md map[string][]string)
+}
+
+// Span is an operation traced by a Tracer.
+type Span interface {
+	// SetAttribute records an attribute of the operation. value is an int, int64, bool or
+	// string.
+	SetAttribute(key string, value interface{})
+	// SetError records that the operation failed.
+	SetError(msg string)
+	// End records the end of the operation.
+	End()
+}
+
+// tracer is the Tracer operations are traced with, see SetTracer.
+var tracer Tracer = inertTracer{}
+
+// SetTracer sets the Tracer StartSpan, StartServerSpan and InjectSpan use. It should be called
+// before anything is traced, e.g. in main. Nothing is traced until it's called, or if t is
+// nil.
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = inertTracer{}
+	}
+	tracer = t
+}
+
+// StartSpan starts a span with the Tracer set by SetTracer, see Tracer.StartSpan.
+func StartSpan(ctx context.Context, instrumentation, name string) (context.Context, Span) {
+	return tracer.StartSpan(ctx, instrumentation, name)
+}
+
+// StartServerSpan starts the span of an RPC with the Tracer set by SetTracer, see
+// Tracer.StartServerSpan.
+func StartServerSpan(ctx context.Context, instrumentation, name string, md map[string][]string) (context.Context, Span) {
+	return tracer.StartServerSpan(ctx, instrumentation, name, md)
+}
+
+// InjectSpan propagates the span in ctx with the Tracer set by SetTracer, see
+// Tracer.InjectSpan.
+func InjectSpan(ctx context.Context, md map[string][]string) {
+	tracer.InjectSpan(ctx, md)
+}
+
+// inertTracer is a Tracer that traces nothing.
+type inertTracer struct{}
+
+func (inertTracer) StartSpan(ctx context.Context, instrumentation, name string) (context.Context, Span) {
+	return ctx, inertSpan{}
+}
+
+func (inertTracer) StartServerSpan(ctx context.Context, instrumentation, name string, md map[string][]string) (context.Context, Span) {
+	return ctx, inertSpan{}
+}
+
+func (inertTracer) InjectSpan(ctx context.Context, md map[string][]string) {}
+
+// inertSpan is the Span of inertTracer.
+type inertSpan struct{}
+
+func (inertSpan) SetAttribute(key string, value interface{}) {}
+func (inertSpan) SetError(msg string)                        {}
+func (inertSpan) End()                                       {}
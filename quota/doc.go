@@ -18,11 +18,12 @@
 // that may put servers out of capacity or indirectly cause MMDs (maximum merge delays) to be
 // missed.
 //
-// Each Trillian request, be it either a read or write request, requires certain tokens to be
+// Each Trillian request, be it a read, write or admin request, requires certain tokens to be
 // allowed to continue. Tokens exist at multiple layers: per-user, per-tree and global tokens.
 // For example, a TrillianLog.QueueLeaves request consumes a Write token from User, Tree and Global
 // quotas. If any of those quotas is out of tokens, the request is denied with a ResourceExhausted
-// error code.
+// error code. Admin requests consume Admin tokens, so they're limited independently of reads and
+// writes of tree data.
 //
 // Tokens are replenished according to each implementation. For example, User tokens may replenish
 // over time, whereas {Write, Tree} tokens may replenish as sequencing happens. Implementations are
@@ -0,0 +1,258 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package dynamic contains a quota.Manager configured at runtime through the
+// admin API.
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// Manager is a quota.Manager whose buckets are configured by the quota
+// configurations in AdminStorage, as created, updated and deleted by the
+// admin API. Buckets without a configuration are unlimited.
+//
+// Configured buckets are token buckets kept in memory, so each server
+// enforces them on its own. Configurations are loaded by Refresh, which Run
+// calls periodically, so changes apply without restarting servers.
+//
+// Tokens are also acquired from the wrapped quota.Manager, so configured
+// buckets add to its limits rather than replace them.
+type Manager struct {
+	qm         quota.Manager
+	as         storage.AdminStorage
+	timeSource util.TimeSource
+
+	// mu guards buckets.
+	mu      sync.Mutex
+	buckets map[quota.Spec]*bucket
+}
+
+type bucket struct {
+	maxTokens, tokensToReplenish int64
+	replenishInterval            time.Duration
+
+	tokens        int64
+	lastReplenish time.Time
+}
+
+// replenish adds the tokens due since the last replenishment, up to maxTokens.
+func (b *bucket) replenish(now time.Time) {
+	n := int64(now.Sub(b.lastReplenish) / b.replenishInterval)
+	if n <= 0 {
+		return
+	}
+	b.lastReplenish = b.lastReplenish.Add(time.Duration(n) * b.replenishInterval)
+	if missing := b.maxTokens - b.tokens; n > missing/b.tokensToReplenish {
+		b.tokens = b.maxTokens
+	} else {
+		b.tokens += n * b.tokensToReplenish
+	}
+}
+
+// NewManager returns a Manager that wraps qm and reads its configuration from
+// as. It has no buckets configured until Refresh or Run is called.
+func NewManager(qm quota.Manager, as storage.AdminStorage, timeSource util.TimeSource) *Manager {
+	return &Manager{
+		qm:         qm,
+		as:         as,
+		timeSource: timeSource,
+		buckets:    make(map[quota.Spec]*bucket),
+	}
+}
+
+// Run calls Refresh every interval, until ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Refresh(ctx); err != nil {
+			glog.Warningf("Failed to refresh quota configs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh loads the quota configurations from storage. Buckets that are newly
+// configured start full, buckets whose configuration changed keep their
+// tokens (up to the new maximum), and buckets whose configuration was deleted
+// become unlimited.
+func (m *Manager) Refresh(ctx context.Context) error {
+	tx, err := m.as.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	cfgs, err := tx.ListQuotaConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	now := m.timeSource.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	buckets := make(map[quota.Spec]*bucket)
+	for _, cfg := range cfgs {
+		spec, err := toSpec(cfg.Bucket)
+		if err != nil {
+			glog.Warningf("Ignoring invalid quota config %v: %v", cfg, err)
+			continue
+		}
+		interval, err := ptypes.Duration(cfg.ReplenishInterval)
+		if err != nil || interval <= 0 || cfg.MaxTokens <= 0 || cfg.TokensToReplenish <= 0 {
+			glog.Warningf("Ignoring invalid quota config %v", cfg)
+			continue
+		}
+		b, ok := m.buckets[spec]
+		if ok {
+			b.replenish(now)
+		} else {
+			b = &bucket{tokens: cfg.MaxTokens, lastReplenish: now}
+		}
+		b.maxTokens = cfg.MaxTokens
+		b.tokensToReplenish = cfg.TokensToReplenish
+		b.replenishInterval = interval
+		if b.tokens > b.maxTokens {
+			b.tokens = b.maxTokens
+		}
+		buckets[spec] = b
+	}
+	m.buckets = buckets
+	return nil
+}
+
+func toSpec(b *trillian.QuotaBucket) (quota.Spec, error) {
+	if err := storage.ValidateQuotaBucket(b); err != nil {
+		return quota.Spec{}, err
+	}
+	// Both parse, as the bucket is valid.
+	group, _ := quota.ParseGroup(b.Group)
+	kind, _ := quota.ParseKind(b.Kind)
+	return quota.Spec{Group: group, Kind: kind, TreeID: b.TreeId, User: b.User}, nil
+}
+
+// GetUser implements quota.Manager.GetUser. Users are as defined by the
+// wrapped quota.Manager.
+func (m *Manager) GetUser(ctx context.Context, req interface{}) string {
+	return m.qm.GetUser(ctx, req)
+}
+
+// GetTokens implements quota.Manager.GetTokens.
+func (m *Manager) GetTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
+	if err := m.getTokens(numTokens, specs); err != nil {
+		return err
+	}
+	if err := m.qm.GetTokens(ctx, numTokens, specs); err != nil {
+		m.putTokens(numTokens, specs)
+		return err
+	}
+	return nil
+}
+
+// getTokens acquires numTokens from all configured buckets of specs, or from
+// none of them if any is short of tokens.
+func (m *Manager) getTokens(numTokens int, specs []quota.Spec) error {
+	now := m.timeSource.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, spec := range specs {
+		if b, ok := m.buckets[spec]; ok {
+			b.replenish(now)
+			if b.tokens < int64(numTokens) {
+				return quota.NewExhaustedError(fmt.Sprintf("%v/%v quota exhausted: %v tokens available, %v requested", spec.Group, spec.Kind, b.tokens, numTokens))
+			}
+		}
+	}
+	for _, spec := range specs {
+		if b, ok := m.buckets[spec]; ok {
+			b.tokens -= int64(numTokens)
+		}
+	}
+	return nil
+}
+
+// PeekTokens implements quota.Manager.PeekTokens. The tokens of each spec are
+// the lesser of those of its configured bucket and of the wrapped
+// quota.Manager.
+func (m *Manager) PeekTokens(ctx context.Context, specs []quota.Spec) (map[quota.Spec]int, error) {
+	tokens, err := m.qm.PeekTokens(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+	now := m.timeSource.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, spec := range specs {
+		if b, ok := m.buckets[spec]; ok {
+			b.replenish(now)
+			if n, ok := tokens[spec]; !ok || b.tokens < int64(n) {
+				tokens[spec] = int(b.tokens)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// PutTokens implements quota.Manager.PutTokens.
+func (m *Manager) PutTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
+	m.putTokens(numTokens, specs)
+	return m.qm.PutTokens(ctx, numTokens, specs)
+}
+
+// putTokens adds numTokens to all configured buckets of specs, up to their
+// maximum.
+func (m *Manager) putTokens(numTokens int, specs []quota.Spec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, spec := range specs {
+		if b, ok := m.buckets[spec]; ok {
+			b.tokens += int64(numTokens)
+			if b.tokens > b.maxTokens {
+				b.tokens = b.maxTokens
+			}
+		}
+	}
+}
+
+// ResetQuota implements quota.Manager.ResetQuota. Configured buckets are
+// filled up.
+func (m *Manager) ResetQuota(ctx context.Context, specs []quota.Spec) error {
+	now := m.timeSource.Now()
+	m.mu.Lock()
+	for _, spec := range specs {
+		if b, ok := m.buckets[spec]; ok {
+			b.tokens = b.maxTokens
+			b.lastReplenish = now
+		}
+	}
+	m.mu.Unlock()
+	return m.qm.ResetQuota(ctx, specs)
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/util"
+)
+
+const treeID = 12345
+
+var treeWrite = quota.Spec{Group: quota.Tree, Kind: quota.Write, TreeID: treeID}
+
+// failingManager is a quota.Manager that never has tokens.
+type failingManager struct {
+	quota.Manager
+}
+
+func (failingManager) GetTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
+	return errors.New("no tokens")
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	as := memory.NewAdminStorage(memory.NewLogStorage(nil))
+	ts := util.NewFakeTimeSource(time.Now())
+	m := NewManager(quota.Noop(), as, ts)
+
+	bucket := &trillian.QuotaBucket{Group: "Tree", Kind: "Write", TreeId: treeID}
+	runTX(ctx, t, as, func(tx storage.AdminTX) error {
+		_, err := tx.CreateQuotaConfig(ctx, &trillian.QuotaConfig{
+			Bucket:            bucket,
+			MaxTokens:         10,
+			TokensToReplenish: 2,
+			ReplenishInterval: ptypes.DurationProto(time.Second),
+		})
+		return err
+	})
+
+	// Configs don't apply until refreshed.
+	if err := m.GetTokens(ctx, 100, []quota.Spec{treeWrite}); err != nil {
+		t.Errorf("GetTokens() before Refresh() returned err = %v", err)
+	}
+	refresh(ctx, t, m)
+	if err := m.GetTokens(ctx, 8, []quota.Spec{treeWrite}); err != nil {
+		t.Errorf("GetTokens(8) returned err = %v", err)
+	}
+	if err := m.GetTokens(ctx, 3, []quota.Spec{treeWrite}); !quota.IsExhausted(err) {
+		t.Errorf("GetTokens(3) returned err = %v, want exhausted", err)
+	}
+	checkTokens(ctx, t, m, "after GetTokens()", 2)
+
+	// Tokens are replenished every second, up to the maximum.
+	ts.Set(ts.Now().Add(1500 * time.Millisecond))
+	checkTokens(ctx, t, m, "after 1.5s", 4)
+	ts.Set(ts.Now().Add(500 * time.Millisecond))
+	checkTokens(ctx, t, m, "after 2s", 6)
+	ts.Set(ts.Now().Add(time.Hour))
+	checkTokens(ctx, t, m, "after 1h", 10)
+
+	if err := m.PutTokens(ctx, 5, []quota.Spec{treeWrite}); err != nil {
+		t.Errorf("PutTokens() returned err = %v", err)
+	}
+	checkTokens(ctx, t, m, "after PutTokens()", 10)
+
+	// Tokens are put back if the wrapped manager fails.
+	m.qm = failingManager{quota.Noop()}
+	if err := m.GetTokens(ctx, 5, []quota.Spec{treeWrite}); err == nil {
+		t.Error("GetTokens() with failing wrapped manager returned err = nil, want non-nil")
+	}
+	m.qm = quota.Noop()
+	checkTokens(ctx, t, m, "after failed GetTokens()", 10)
+
+	// Updated configs keep the bucket's tokens, up to the new maximum.
+	runTX(ctx, t, as, func(tx storage.AdminTX) error {
+		_, err := tx.UpdateQuotaConfig(ctx, bucket, func(cfg *trillian.QuotaConfig) { cfg.MaxTokens = 5 })
+		return err
+	})
+	refresh(ctx, t, m)
+	checkTokens(ctx, t, m, "after update", 5)
+
+	// Deleted configs make the bucket unlimited.
+	runTX(ctx, t, as, func(tx storage.AdminTX) error {
+		return tx.DeleteQuotaConfig(ctx, bucket)
+	})
+	refresh(ctx, t, m)
+	checkTokens(ctx, t, m, "after delete", quota.MaxTokens)
+}
+
+func runTX(ctx context.Context, t *testing.T, as storage.AdminStorage, f func(storage.AdminTX) error) {
+	tx, err := as.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() returned err = %v", err)
+	}
+	defer tx.Close()
+	if err := f(tx); err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+}
+
+func refresh(ctx context.Context, t *testing.T, m *Manager) {
+	if err := m.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() returned err = %v", err)
+	}
+}
+
+func checkTokens(ctx context.Context, t *testing.T, m *Manager, desc string, want int) {
+	tokens, err := m.PeekTokens(ctx, []quota.Spec{treeWrite})
+	if err != nil {
+		t.Fatalf("%v: PeekTokens() returned err = %v", desc, err)
+	}
+	if got := tokens[treeWrite]; got != want {
+		t.Errorf("%v: PeekTokens() = %v, want %v", desc, got, want)
+	}
+}
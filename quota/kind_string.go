@@ -4,9 +4,9 @@ package quota
 
 import "fmt"
 
-const _Kind_name = "ReadWrite"
+const _Kind_name = "ReadWriteAdmin"
 
-var _Kind_index = [...]uint8{0, 4, 9}
+var _Kind_index = [...]uint8{0, 4, 9, 14}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
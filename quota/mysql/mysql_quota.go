@@ -19,7 +19,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"sync"
 
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/quota"
 )
 
@@ -40,9 +42,19 @@ const (
 var (
 	// ErrTooManyUnsequencedRows is returned when tokens are requested but Unsequenced has grown
 	// beyond the configured limit.
-	ErrTooManyUnsequencedRows = errors.New("too many unsequenced rows")
+	ErrTooManyUnsequencedRows = quota.NewExhaustedError("too many unsequenced rows")
+
+	once         sync.Once
+	globalWrites monitoring.Gauge
 )
 
+func createMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	globalWrites = mf.NewGauge("mysql_quota_global_write_tokens", "Global/Write tokens available as of the latest check, MaxUnsequencedRows minus the number of unsequenced rows")
+}
+
 // QuotaManager is a MySQL-based quota.Manager implementation.
 //
 // It has two working modes: one queries the information schema for the number of Unsequenced rows,
@@ -53,10 +65,25 @@ var (
 // QuotaManager only implements Global/Write quotas, which is based on the number of Unsequenced
 // rows (to be exact, tokens = MaxUnsequencedRows - actualUnsequencedRows).
 // Other quotas are considered infinite.
+//
+// The number of Global/Write tokens found by the latest check is exported through MetricFactory,
+// if set, as the mysql_quota_global_write_tokens gauge.
 type QuotaManager struct {
-	DB                 *sql.DB
+	DB *sql.DB
+	// MaxUnsequencedRows mustn't be changed once the QuotaManager is in use, other than by
+	// SetMaxUnsequencedRows.
 	MaxUnsequencedRows int
 	UseSelectCount     bool
+	MetricFactory      monitoring.MetricFactory
+
+	mu sync.RWMutex
+}
+
+// SetMaxUnsequencedRows changes MaxUnsequencedRows, e.g. when it's reloaded from a flag file.
+func (m *QuotaManager) SetMaxUnsequencedRows(maxRows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.MaxUnsequencedRows = maxRows
 }
 
 // GetUser implements quota.Manager.GetUser.
@@ -74,11 +101,11 @@ func (m *QuotaManager) GetTokens(ctx context.Context, numTokens int, specs []quo
 			continue
 		}
 		// Only allow global writes if Unsequenced is under the expected limit
-		count, err := m.countUnsequenced(ctx)
+		tokens, err := m.globalWriteTokens(ctx)
 		if err != nil {
 			return err
 		}
-		if count+numTokens > m.MaxUnsequencedRows {
+		if numTokens > tokens {
 			return ErrTooManyUnsequencedRows
 		}
 	}
@@ -93,11 +120,11 @@ func (m *QuotaManager) PeekTokens(ctx context.Context, specs []quota.Spec) (map[
 	for _, spec := range specs {
 		var num int
 		if spec.Group == quota.Global && spec.Kind == quota.Write {
-			count, err := m.countUnsequenced(ctx)
+			var err error
+			num, err = m.globalWriteTokens(ctx)
 			if err != nil {
 				return nil, err
 			}
-			num = m.MaxUnsequencedRows - count
 		} else {
 			num = quota.MaxTokens
 		}
@@ -118,6 +145,21 @@ func (m *QuotaManager) ResetQuota(ctx context.Context, specs []quota.Spec) error
 	return nil
 }
 
+// globalWriteTokens returns the number of Global/Write tokens available and records it in the
+// mysql_quota_global_write_tokens gauge.
+func (m *QuotaManager) globalWriteTokens(ctx context.Context) (int, error) {
+	once.Do(func() { createMetrics(m.MetricFactory) })
+	count, err := m.countUnsequenced(ctx)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.RLock()
+	tokens := m.MaxUnsequencedRows - count
+	m.mu.RUnlock()
+	globalWrites.Set(float64(tokens))
+	return tokens, nil
+}
+
 func (m *QuotaManager) countUnsequenced(ctx context.Context) (int, error) {
 	if m.UseSelectCount {
 		return countFromTable(ctx, m.DB)
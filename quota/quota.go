@@ -16,6 +16,7 @@ package quota
 
 import (
 	"context"
+	"fmt"
 )
 
 // MaxTokens is the maximum number of available tokens a quota may have.
@@ -38,7 +39,7 @@ const (
 	User
 )
 
-// Kind represents the purpose of each token (Read or Write).
+// Kind represents the purpose of each token (Read, Write or Admin).
 type Kind int
 
 const (
@@ -47,8 +48,32 @@ const (
 
 	// Write represents tokens used by modifying RPCs.
 	Write
+
+	// Admin represents tokens used by RPCs that administer trees, whether or not they modify
+	// them, so tree administration doesn't compete with reads and writes of tree data.
+	Admin
 )
 
+// ParseGroup returns the Group named s, as returned by Group.String().
+func ParseGroup(s string) (Group, error) {
+	for g := Global; g <= User; g++ {
+		if g.String() == s {
+			return g, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown quota group: %q", s)
+}
+
+// ParseKind returns the Kind named s, as returned by Kind.String().
+func ParseKind(s string) (Kind, error) {
+	for k := Read; k <= Admin; k++ {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown quota kind: %q", s)
+}
+
 // Spec represents a combination of Group and Kind, with all additional data required to get / put
 // tokens.
 type Spec struct {
@@ -75,7 +100,9 @@ type Manager interface {
 
 	// GetTokens acquires numTokens from all specs. Tokens are taken in the order specified by
 	// specs.
-	// Returns error if numTokens could not be acquired for all specs.
+	// Returns error if numTokens could not be acquired for all specs. Errors caused by a quota
+	// running out of tokens should be created by NewExhaustedError, so callers can tell them
+	// apart from failures of the Manager itself.
 	GetTokens(ctx context.Context, numTokens int, specs []Spec) error
 
 	// PeekTokens returns how many tokens are available for each spec, without acquiring any.
@@ -88,3 +115,24 @@ type Manager interface {
 	// ResetQuota resets the quota for all specs.
 	ResetQuota(ctx context.Context, specs []Spec) error
 }
+
+// exhaustedError is an error caused by a quota running out of tokens.
+type exhaustedError struct {
+	msg string
+}
+
+func (e *exhaustedError) Error() string {
+	return e.msg
+}
+
+// NewExhaustedError returns an error signalling that a quota is out of tokens.
+func NewExhaustedError(msg string) error {
+	return &exhaustedError{msg}
+}
+
+// IsExhausted returns true if err signals that a quota is out of tokens, as opposed to the
+// Manager failing to work out whether tokens are available.
+func IsExhausted(err error) bool {
+	_, ok := err.(*exhaustedError)
+	return ok
+}
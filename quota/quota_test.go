@@ -0,0 +1,52 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package quota
+
+import "testing"
+
+func TestParseGroup(t *testing.T) {
+	for _, want := range []Group{Global, Tree, User} {
+		got, err := ParseGroup(want.String())
+		if err != nil {
+			t.Errorf("ParseGroup(%q) returned err = %v", want, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseGroup(%q) = %v, want %v", want, got, want)
+		}
+	}
+	for _, s := range []string{"", "global", "Group(3)"} {
+		if _, err := ParseGroup(s); err == nil {
+			t.Errorf("ParseGroup(%q) returned err = nil, want non-nil", s)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, want := range []Kind{Read, Write, Admin} {
+		got, err := ParseKind(want.String())
+		if err != nil {
+			t.Errorf("ParseKind(%q) returned err = %v", want, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseKind(%q) = %v, want %v", want, got, want)
+		}
+	}
+	for _, s := range []string{"", "write", "Kind(3)"} {
+		if _, err := ParseKind(s); err == nil {
+			t.Errorf("ParseKind(%q) returned err = nil, want non-nil", s)
+		}
+	}
+}
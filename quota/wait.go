@@ -0,0 +1,66 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package quota
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWaitPollInterval is the WaitOptions.PollInterval used when none is set.
+const DefaultWaitPollInterval = 100 * time.Millisecond
+
+// WaitOptions configures WaitForTokens.
+type WaitOptions struct {
+	// MaxWait is how long to wait for tokens before giving up. Zero means waiting until the
+	// context is done.
+	MaxWait time.Duration
+
+	// PollInterval is the time between attempts to acquire the tokens. Zero means
+	// DefaultWaitPollInterval.
+	PollInterval time.Duration
+}
+
+// WaitForTokens acquires numTokens from all specs like Manager.GetTokens, but waits for the
+// quotas to be replenished instead of failing when they're exhausted. This allows callers
+// that can hold off their clients, such as streaming RPC handlers that stop reading from
+// their stream, to turn quota shortages into back-pressure.
+// Errors other than exhaustion are returned immediately. If the tokens still can't be
+// acquired when MaxWait elapses or ctx is done, the last exhaustion error is returned.
+func WaitForTokens(ctx context.Context, qm Manager, numTokens int, specs []Spec, opts WaitOptions) error {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultWaitPollInterval
+	}
+	var deadline <-chan time.Time
+	if opts.MaxWait > 0 {
+		timer := time.NewTimer(opts.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		err := qm.GetTokens(ctx, numTokens, specs)
+		if err == nil || !IsExhausted(err) {
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			return err
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWaitForTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	exhausted := NewExhaustedError("exhausted")
+	failed := errors.New("failed")
+	specs := []Spec{{Group: Global, Kind: Write}}
+	opts := WaitOptions{MaxWait: 50 * time.Millisecond, PollInterval: time.Millisecond}
+
+	tests := []struct {
+		desc    string
+		errs    []error
+		wantErr error
+	}{
+		{desc: "immediate", errs: []error{nil}},
+		{desc: "replenished", errs: []error{exhausted, exhausted, nil}},
+		{desc: "failure", errs: []error{exhausted, failed}, wantErr: failed},
+		{desc: "starved", errs: []error{exhausted}, wantErr: exhausted},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		qm := NewMockManager(ctrl)
+		var calls []*gomock.Call
+		for i, err := range test.errs {
+			call := qm.EXPECT().GetTokens(ctx, 2, specs).Return(err)
+			if i == len(test.errs)-1 && err == exhausted {
+				// Starved until MaxWait elapses.
+				call.AnyTimes()
+			}
+			calls = append(calls, call)
+		}
+		gomock.InOrder(calls...)
+
+		if err := WaitForTokens(ctx, qm, 2, specs, opts); err != test.wantErr {
+			t.Errorf("%v: WaitForTokens() returned err = %v, want %v", test.desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestWaitForTokens_ContextDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	exhausted := NewExhaustedError("exhausted")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	qm := NewMockManager(ctrl)
+	qm.EXPECT().GetTokens(ctx, 1, gomock.Any()).AnyTimes().Return(exhausted)
+
+	// No MaxWait, so only the context ends the wait.
+	if err := WaitForTokens(ctx, qm, 1, []Spec{{Group: Global, Kind: Write}}, WaitOptions{PollInterval: time.Millisecond}); err != exhausted {
+		t.Errorf("WaitForTokens() returned err = %v, want %v", err, exhausted)
+	}
+}
@@ -17,6 +17,10 @@ package admin
 import (
 	"bytes"
 	"crypto/x509"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/ptypes"
@@ -25,25 +29,77 @@ import (
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
 	"github.com/google/trillian/merkle/hashers"
 	_ "github.com/google/trillian/merkle/rfc6962" // Make hashers available
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	serrors "github.com/google/trillian/server/errors"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-var errNotImplemented = status.Errorf(codes.Unimplemented, "not implemented")
+// defaultUpdateBatchSize is the number of trees updated per transaction by BatchUpdateTrees, if
+// the request doesn't specify it.
+const defaultUpdateBatchSize = 100
+
+// footprintInFlight is set to 1 while a GetTreeFootprint query is running.
+// Footprint queries may scan all of a tree's data, so only one is allowed at
+// a time.
+var footprintInFlight int32
+
+// pendingInFlight is set to 1 while a ListPendingTrees query is running.
+var pendingInFlight int32
+
+var (
+	once        sync.Once
+	activeTrees monitoring.Gauge
+)
+
+func createMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	activeTrees = mf.NewGauge("active_trees", "Number of trees that aren't deleted, as of the latest CreateTree request")
+}
 
 // Server is an implementation of trillian.TrillianAdminServer.
 type Server struct {
-	registry extension.Registry
+	registry       extension.Registry
+	maxActiveTrees int64
+	rootTimeSource util.TimeSource
 }
 
 // New returns a trillian.TrillianAdminServer implementation.
 func New(registry extension.Registry) *Server {
-	return &Server{registry}
+	s := &Server{registry: registry}
+	s.initMetrics()
+	return s
+}
+
+func (s *Server) initMetrics() {
+	once.Do(func() {
+		createMetrics(s.registry.MetricFactory)
+	})
+}
+
+// SetMaxActiveTrees limits the number of trees that aren't deleted, beyond which CreateTree
+// fails with ResourceExhausted. Zero means no limit.
+func (s *Server) SetMaxActiveTrees(max int64) {
+	s.maxActiveTrees = max
+}
+
+// SetRootTimeSource sets the clock that roots signed by RepairTreeRoot are timestamped with,
+// see log.Sequencer.SetRootTimeSource. It should match the signer's, so repaired roots are
+// timestamped like any other.
+func (s *Server) SetRootTimeSource(ts util.TimeSource) {
+	s.rootTimeSource = ts
 }
 
 // IsHealthy returns nil if the server is healthy, error otherwise.
@@ -68,10 +124,14 @@ func (s *Server) ListTrees(ctx context.Context, req *trillian.ListTreesRequest)
 		return nil, err
 	}
 
+	trees := make([]*trillian.Tree, 0, len(resp))
 	for _, tree := range resp {
-		redact(tree)
+		if storage.IsDeleted(tree.TreeState) && !req.GetShowDeleted() {
+			continue
+		}
+		trees = append(trees, redact(tree))
 	}
-	return &trillian.ListTreesResponse{Tree: resp}, nil
+	return &trillian.ListTreesResponse{Tree: trees}, nil
 }
 
 // GetTree implements trillian.TrillianAdminServer.GetTree.
@@ -154,11 +214,26 @@ func (s *Server) CreateTree(ctx context.Context, request *trillian.CreateTreeReq
 		tree.PublicKey = &keyspb.PublicKey{Der: publicKeyDER}
 	}
 
+	if err := s.checkSecondarySigner(ctx, tree); err != nil {
+		return nil, err
+	}
+
 	tx, err := s.registry.AdminStorage.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Close()
+	// Counting in the creating transaction stops concurrent requests from going past the
+	// limit.
+	count, err := tx.CountActiveTrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.initMetrics()
+	activeTrees.Set(float64(count))
+	if s.maxActiveTrees > 0 && count >= s.maxActiveTrees {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many trees: %v trees exist, the maximum is %v", count, s.maxActiveTrees)
+	}
 	newTree, err := tx.CreateTree(ctx, tree)
 	if err != nil {
 		return nil, err
@@ -166,6 +241,7 @@ func (s *Server) CreateTree(ctx context.Context, request *trillian.CreateTreeReq
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	activeTrees.Set(float64(count + 1))
 	return redact(newTree), nil
 }
 
@@ -180,6 +256,9 @@ func (s *Server) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest
 	if err := applyUpdateMask(&trillian.Tree{}, &trillian.Tree{}, mask); err != nil {
 		return nil, err
 	}
+	if err := s.checkUpdatedSecondarySigner(ctx, tree, mask); err != nil {
+		return nil, err
+	}
 
 	tx, err := s.registry.AdminStorage.Begin(ctx)
 	if err != nil {
@@ -187,10 +266,12 @@ func (s *Server) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest
 	}
 	defer tx.Close()
 	updatedTree, err := tx.UpdateTree(ctx, tree.TreeId, func(other *trillian.Tree) {
+		prevState := other.TreeState
 		if err := applyUpdateMask(tree, other, mask); err != nil {
 			// Should never happen (famous last words).
 			glog.Errorf("Error applying mask on tree update: %v", err)
 		}
+		updateDrainDeadline(other, prevState, time.Now())
 	})
 	if err != nil {
 		return nil, err
@@ -201,6 +282,156 @@ func (s *Server) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest
 	return redact(updatedTree), nil
 }
 
+// BatchUpdateTrees implements trillian.TrillianAdminServer.BatchUpdateTrees.
+func (s *Server) BatchUpdateTrees(ctx context.Context, req *trillian.BatchUpdateTreesRequest) (*trillian.BatchUpdateTreesResponse, error) {
+	tree := req.GetTree()
+	mask := req.GetUpdateMask()
+	if tree == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "a tree is required")
+	}
+	// Apply the mask to a couple of empty trees just to check that the paths are correct.
+	if err := applyUpdateMask(&trillian.Tree{}, &trillian.Tree{}, mask); err != nil {
+		return nil, err
+	}
+	if err := s.checkUpdatedSecondarySigner(ctx, tree, mask); err != nil {
+		return nil, err
+	}
+	batchSize := int(req.BatchSize)
+	switch {
+	case batchSize < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "batch_size must be >= 0, got %v", batchSize)
+	case batchSize == 0:
+		batchSize = defaultUpdateBatchSize
+	}
+
+	treeIDs := req.TreeIds
+	if len(treeIDs) == 0 {
+		var err error
+		if treeIDs, err = s.matchingTreeIDs(ctx, req.TreeType, req.TreeState); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*trillian.TreeUpdateResult, 0, len(treeIDs))
+	for start := 0; start < len(treeIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(treeIDs) {
+			end = len(treeIDs)
+		}
+		results = append(results, s.updateTreeBatch(ctx, treeIDs[start:end], tree, mask)...)
+	}
+	return &trillian.BatchUpdateTreesResponse{Results: results}, nil
+}
+
+// matchingTreeIDs returns the IDs of all trees of the given type and state. Unknown type matches
+// all trees, unknown state all trees that aren't deleted.
+func (s *Server) matchingTreeIDs(ctx context.Context, treeType trillian.TreeType, treeState trillian.TreeState) ([]int64, error) {
+	tx, err := s.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	allTrees, err := tx.ListTrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, tree := range allTrees {
+		if treeType != trillian.TreeType_UNKNOWN_TREE_TYPE && tree.TreeType != treeType {
+			continue
+		}
+		if treeState == trillian.TreeState_UNKNOWN_TREE_STATE && storage.IsDeleted(tree.TreeState) {
+			continue
+		}
+		if treeState != trillian.TreeState_UNKNOWN_TREE_STATE && tree.TreeState != treeState {
+			continue
+		}
+		ids = append(ids, tree.TreeId)
+	}
+	return ids, nil
+}
+
+// updateTreeBatch applies mask to treeIDs in a single transaction. Trees whose update is rejected
+// (e.g. by validation or because they don't exist) are reported in their results and don't
+// prevent the rest of the batch from being committed. Any other error rolls back and fails the
+// whole batch, as the transaction may no longer be usable.
+func (s *Server) updateTreeBatch(ctx context.Context, treeIDs []int64, tree *trillian.Tree, mask *field_mask.FieldMask) []*trillian.TreeUpdateResult {
+	results := make([]*trillian.TreeUpdateResult, 0, len(treeIDs))
+	for _, id := range treeIDs {
+		results = append(results, &trillian.TreeUpdateResult{TreeId: id})
+	}
+	failAll := func(err error) []*trillian.TreeUpdateResult {
+		for _, r := range results {
+			if r.Status == nil {
+				r.Tree = nil
+				r.Status = errorStatus(err)
+			}
+		}
+		return results
+	}
+
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return failAll(err)
+	}
+	defer tx.Close()
+	for _, r := range results {
+		updatedTree, err := tx.UpdateTree(ctx, r.TreeId, func(other *trillian.Tree) {
+			prevState := other.TreeState
+			if err := applyUpdateMask(tree, other, mask); err != nil {
+				// Should never happen, the mask was checked up front.
+				glog.Errorf("Error applying mask on tree update: %v", err)
+			}
+			updateDrainDeadline(other, prevState, time.Now())
+		})
+		if err != nil {
+			r.Status = errorStatus(err)
+			switch codes.Code(r.Status.Code) {
+			case codes.InvalidArgument, codes.FailedPrecondition, codes.NotFound:
+				continue
+			}
+			return failAll(err)
+		}
+		r.Tree = redact(updatedTree)
+	}
+	if err := tx.Commit(); err != nil {
+		return failAll(err)
+	}
+	return results
+}
+
+// errorStatus converts err to the status it would have if returned from an RPC.
+func errorStatus(err error) *rpcstatus.Status {
+	s, ok := status.FromError(serrors.WrapError(err))
+	if !ok {
+		s = status.New(codes.Unknown, err.Error())
+	}
+	return s.Proto()
+}
+
+// updateDrainDeadline sets the drain deadline of a tree entering the DRAINING state to now plus
+// its drain grace period, and clears it if the tree is leaving the DRAINING state. Trees with a
+// malformed grace period are left without a deadline, so they fail validation.
+func updateDrainDeadline(tree *trillian.Tree, prevState trillian.TreeState, now time.Time) {
+	switch {
+	case tree.TreeState != trillian.TreeState_DRAINING:
+		tree.DrainDeadline = nil
+	case prevState != trillian.TreeState_DRAINING:
+		var gracePeriod time.Duration
+		if tree.DrainGracePeriod != nil {
+			var err error
+			if gracePeriod, err = ptypes.Duration(tree.DrainGracePeriod); err != nil {
+				return
+			}
+		}
+		tree.DrainDeadline, _ = ptypes.TimestampProto(now.Add(gracePeriod))
+	}
+}
+
 func applyUpdateMask(from, to *trillian.Tree, mask *field_mask.FieldMask) error {
 	if mask == nil || len(mask.Paths) == 0 {
 		return status.Errorf(codes.InvalidArgument, "an update_mask is required")
@@ -217,6 +448,28 @@ func applyUpdateMask(from, to *trillian.Tree, mask *field_mask.FieldMask) error
 			to.StorageSettings = from.StorageSettings
 		case "max_root_duration":
 			to.MaxRootDuration = from.MaxRootDuration
+		case "max_client_timestamp_skew":
+			to.MaxClientTimestampSkew = from.MaxClientTimestampSkew
+		case "witnesses":
+			to.Witnesses = from.Witnesses
+		case "root_retention":
+			to.RootRetention = from.RootRetention
+		case "root_metadata_hook":
+			to.RootMetadataHook = from.RootMetadataHook
+		case "dead_letter_policy":
+			to.DeadLetterPolicy = from.DeadLetterPolicy
+		case "max_revision_lookback":
+			to.MaxRevisionLookback = from.MaxRevisionLookback
+		case "additional_public_keys":
+			to.AdditionalPublicKeys = from.AdditionalPublicKeys
+		case "drain_grace_period":
+			to.DrainGracePeriod = from.DrainGracePeriod
+		case "secondary_signer":
+			to.SecondarySigner = from.SecondarySigner
+		case "verify_leaf_identity_hash":
+			to.VerifyLeafIdentityHash = from.VerifyLeafIdentityHash
+		case "access_policy":
+			to.AccessPolicy = from.AccessPolicy
 		default:
 			return status.Errorf(codes.InvalidArgument, "invalid update_mask path: %q", path)
 		}
@@ -225,12 +478,541 @@ func applyUpdateMask(from, to *trillian.Tree, mask *field_mask.FieldMask) error
 }
 
 // DeleteTree implements trillian.TrillianAdminServer.DeleteTree.
-func (s *Server) DeleteTree(context.Context, *trillian.DeleteTreeRequest) (*empty.Empty, error) {
-	return nil, errNotImplemented
+func (s *Server) DeleteTree(ctx context.Context, req *trillian.DeleteTreeRequest) (*empty.Empty, error) {
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	if _, err := tx.SoftDeleteTree(ctx, req.GetTreeId()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("Soft-deleted tree: %v", req.GetTreeId())
+	return &empty.Empty{}, nil
+}
+
+// UndeleteTree implements trillian.TrillianAdminServer.UndeleteTree.
+func (s *Server) UndeleteTree(ctx context.Context, req *trillian.UndeleteTreeRequest) (*trillian.Tree, error) {
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	tree, err := tx.UndeleteTree(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("Undeleted tree: %v", req.GetTreeId())
+	return redact(tree), nil
+}
+
+// RepairTreeRoot implements trillian.TrillianAdminServer.RepairTreeRoot.
+func (s *Server) RepairTreeRoot(ctx context.Context, req *trillian.RepairTreeRootRequest) (*trillian.RepairTreeRootResponse, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.Unimplemented, "log storage not available on this server")
+	}
+	// Repairs write a new root, but FROZEN trees are only fetched for reading. Their roots
+	// are what repairs are for, so the tree's state is checked below instead.
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case tree.TreeState == trillian.TreeState_FROZEN:
+	case !req.GetForce():
+		return nil, status.Errorf(codes.FailedPrecondition, "refusing to repair %s tree %v without force", tree.TreeState, tree.TreeId)
+	}
+
+	signer, err := trees.Signer(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to create signer for tree %v: %v", tree.TreeId, err)
+	}
+	secondary, err := trees.SecondarySigner(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to create secondary signer for tree %v: %v", tree.TreeId, err)
+	}
+	rotation, err := trees.RotationSigner(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to create key rotation signer for tree %v: %v", tree.TreeId, err)
+	}
+
+	signers := log.TreeSigners{Signer: signer, Secondary: secondary, Rotation: rotation}
+	seq, err := log.NewTreeSequencer(tree, signers, util.SystemTimeSource{}, s.rootTimeSource, s.registry.LogStorage, s.registry.MetricFactory, s.registry.QuotaManager)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to create sequencer for tree %v: %v", tree.TreeId, err)
+	}
+	prev, repaired, err := seq.RepairRoot(ctx, tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	if repaired != nil {
+		glog.Warningf("%v: signed root repaired: was %+v, now %+v", tree.TreeId, prev, repaired)
+	}
+	return &trillian.RepairTreeRootResponse{
+		Repaired:     repaired != nil,
+		PreviousRoot: prev,
+		RepairedRoot: repaired,
+	}, nil
+}
+
+// GetTreeFootprint implements trillian.TrillianAdminServer.GetTreeFootprint.
+func (s *Server) GetTreeFootprint(ctx context.Context, req *trillian.GetTreeFootprintRequest) (*trillian.GetTreeFootprintResponse, error) {
+	if _, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{Readonly: true}); err != nil {
+		return nil, err
+	}
+
+	if !atomic.CompareAndSwapInt32(&footprintInFlight, 0, 1) {
+		return nil, status.Errorf(codes.ResourceExhausted, "another footprint query is in progress, try again later")
+	}
+	defer atomic.StoreInt32(&footprintInFlight, 0)
+
+	tx, err := s.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	fp, err := tx.GetTreeFootprint(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &trillian.GetTreeFootprintResponse{
+		LeafCount:       fp.LeafCount,
+		LeafDataBytes:   fp.LeafDataBytes,
+		SubtreeCount:    fp.SubtreeCount,
+		SignedRootCount: fp.SignedRootCount,
+	}, nil
+}
+
+// ListDeadLetteredLeaves implements trillian.TrillianAdminServer.ListDeadLetteredLeaves.
+func (s *Server) ListDeadLetteredLeaves(ctx context.Context, req *trillian.ListDeadLetteredLeavesRequest) (*trillian.ListDeadLetteredLeavesResponse, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.Unimplemented, "log storage not available on this server")
+	}
+	if _, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true}); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.registry.LogStorage.SnapshotForTree(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	leaves, err := tx.GetDeadLetteredLeaves(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &trillian.ListDeadLetteredLeavesResponse{Leaves: leaves}, nil
+}
+
+// RequeueDeadLetteredLeaves implements trillian.TrillianAdminServer.RequeueDeadLetteredLeaves.
+func (s *Server) RequeueDeadLetteredLeaves(ctx context.Context, req *trillian.RequeueDeadLetteredLeavesRequest) (*trillian.RequeueDeadLetteredLeavesResponse, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.Unimplemented, "log storage not available on this server")
+	}
+	if _, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{TreeType: trillian.TreeType_LOG}); err != nil {
+		return nil, err
+	}
+	if len(req.GetLeafIdentityHashes()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no leaf_identity_hashes to requeue")
+	}
+
+	tx, err := s.registry.LogStorage.BeginForTree(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	requeued, err := tx.RequeueDeadLetteredLeaves(ctx, req.GetLeafIdentityHashes(), util.SystemTimeSource{}.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("%v: requeued %v dead-lettered leaves", req.GetTreeId(), requeued)
+	return &trillian.RequeueDeadLetteredLeavesResponse{RequeuedCount: int32(requeued)}, nil
+}
+
+// GetQuotaTokens implements trillian.TrillianAdminServer.GetQuotaTokens.
+func (s *Server) GetQuotaTokens(ctx context.Context, req *trillian.GetQuotaTokensRequest) (*trillian.GetQuotaTokensResponse, error) {
+	if s.registry.QuotaManager == nil {
+		return nil, status.Errorf(codes.Unimplemented, "quota manager not available on this server")
+	}
+	kinds := []quota.Kind{quota.Read, quota.Write, quota.Admin}
+	var specs []quota.Spec
+	for _, kind := range kinds {
+		specs = append(specs, quota.Spec{Group: quota.Global, Kind: kind})
+	}
+	if treeID := req.GetTreeId(); treeID != 0 {
+		for _, kind := range kinds {
+			specs = append(specs, quota.Spec{Group: quota.Tree, Kind: kind, TreeID: treeID})
+		}
+	}
+	if user := req.GetUser(); user != "" {
+		for _, kind := range kinds {
+			specs = append(specs, quota.Spec{Group: quota.User, Kind: kind, User: user})
+		}
+	}
+
+	tokens, err := s.registry.QuotaManager.PeekTokens(ctx, specs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to peek quota tokens: %v", err)
+	}
+	resp := &trillian.GetQuotaTokensResponse{}
+	for _, spec := range specs {
+		bucket := &trillian.QuotaTokens{Group: spec.Group.String(), Kind: spec.Kind.String()}
+		if n := tokens[spec]; n == quota.MaxTokens {
+			bucket.Unlimited = true
+		} else {
+			bucket.Tokens = int64(n)
+		}
+		resp.Buckets = append(resp.Buckets, bucket)
+	}
+	return resp, nil
+}
+
+// ListPendingTrees implements trillian.TrillianAdminServer.ListPendingTrees.
+func (s *Server) ListPendingTrees(ctx context.Context, req *trillian.ListPendingTreesRequest) (*trillian.ListPendingTreesResponse, error) {
+	if !atomic.CompareAndSwapInt32(&pendingInFlight, 0, 1) {
+		return nil, status.Errorf(codes.ResourceExhausted, "another pending trees query is in progress, try again later")
+	}
+	defer atomic.StoreInt32(&pendingInFlight, 0)
+
+	tx, err := s.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	pending, err := tx.ListPendingTrees(ctx, req.GetIncludeCounts())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	resp := &trillian.ListPendingTreesResponse{}
+	for treeID, count := range pending {
+		resp.Trees = append(resp.Trees, &trillian.PendingTree{TreeId: treeID, UnsequencedCount: count})
+	}
+	sort.Slice(resp.Trees, func(i, j int) bool { return resp.Trees[i].TreeId < resp.Trees[j].TreeId })
+	return resp, nil
+}
+
+// CreateQuotaConfig implements trillian.TrillianAdminServer.CreateQuotaConfig.
+func (s *Server) CreateQuotaConfig(ctx context.Context, req *trillian.CreateQuotaConfigRequest) (*trillian.QuotaConfig, error) {
+	cfg := req.GetConfig()
+	if cfg == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "a config is required")
+	}
+
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	newCfg, err := tx.CreateQuotaConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("Created quota config: %v", newCfg)
+	return newCfg, nil
+}
+
+// UpdateQuotaConfig implements trillian.TrillianAdminServer.UpdateQuotaConfig.
+func (s *Server) UpdateQuotaConfig(ctx context.Context, req *trillian.UpdateQuotaConfigRequest) (*trillian.QuotaConfig, error) {
+	cfg := req.GetConfig()
+	mask := req.GetUpdateMask()
+	if cfg == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "a config is required")
+	}
+	// Apply the mask to a couple of empty configs just to check that the paths are correct.
+	if err := applyQuotaConfigUpdateMask(&trillian.QuotaConfig{}, &trillian.QuotaConfig{}, mask); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	updatedCfg, err := tx.UpdateQuotaConfig(ctx, cfg.Bucket, func(other *trillian.QuotaConfig) {
+		if err := applyQuotaConfigUpdateMask(cfg, other, mask); err != nil {
+			// Should never happen, the mask was checked above.
+			glog.Errorf("Error applying mask on quota config update: %v", err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("Updated quota config: %v", updatedCfg)
+	return updatedCfg, nil
+}
+
+func applyQuotaConfigUpdateMask(from, to *trillian.QuotaConfig, mask *field_mask.FieldMask) error {
+	if mask == nil || len(mask.Paths) == 0 {
+		return status.Errorf(codes.InvalidArgument, "an update_mask is required")
+	}
+	for _, path := range mask.Paths {
+		switch path {
+		case "max_tokens":
+			to.MaxTokens = from.MaxTokens
+		case "tokens_to_replenish":
+			to.TokensToReplenish = from.TokensToReplenish
+		case "replenish_interval":
+			to.ReplenishInterval = from.ReplenishInterval
+		default:
+			return status.Errorf(codes.InvalidArgument, "invalid update_mask path: %q", path)
+		}
+	}
+	return nil
+}
+
+// DeleteQuotaConfig implements trillian.TrillianAdminServer.DeleteQuotaConfig.
+func (s *Server) DeleteQuotaConfig(ctx context.Context, req *trillian.DeleteQuotaConfigRequest) (*empty.Empty, error) {
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	if err := tx.DeleteQuotaConfig(ctx, req.GetBucket()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("Deleted quota config: %v", req.GetBucket())
+	return &empty.Empty{}, nil
+}
+
+// ListQuotaConfigs implements trillian.TrillianAdminServer.ListQuotaConfigs.
+func (s *Server) ListQuotaConfigs(ctx context.Context, req *trillian.ListQuotaConfigsRequest) (*trillian.ListQuotaConfigsResponse, error) {
+	tx, err := s.registry.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	cfgs, err := tx.ListQuotaConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &trillian.ListQuotaConfigsResponse{Configs: cfgs}, nil
+}
+
+// RotateTreeKey implements trillian.TrillianAdminServer.RotateTreeKey.
+func (s *Server) RotateTreeKey(ctx context.Context, req *trillian.RotateTreeKeyRequest) (*trillian.Tree, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.Unimplemented, "log storage not available on this server")
+	}
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &trillian.KeyRotation{
+		PrivateKey:         req.GetPrivateKey(),
+		ActivationTreeSize: req.GetActivationTreeSize(),
+	}
+	if req.KeySpec != nil {
+		if rotation.PrivateKey != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "the private_key and key_spec fields are mutually exclusive")
+		}
+		key, err := s.registry.SignerFactory.Generate(ctx, req.KeySpec)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to generate private key: %v", err.Error())
+		}
+		rotation.PrivateKey, err = ptypes.MarshalAny(key)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal private key: %v", err.Error())
+		}
+	}
+	if rotation.PrivateKey == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "private_key or key_spec is required")
+	}
+
+	// Check that the new key is valid and derive its public key, as CreateTree does.
+	withRotation := *tree
+	withRotation.KeyRotation = rotation
+	signer, err := trees.RotationSigner(ctx, s.registry.SignerFactory, &withRotation)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to create signer for new key: %v", err.Error())
+	}
+	if treeSigAlgo, keySigAlgo := tree.GetSignatureAlgorithm(), keys.SignatureAlgorithm(signer.Public()); treeSigAlgo != keySigAlgo {
+		return nil, status.Errorf(codes.InvalidArgument, "tree.signature_algorithm = %v, but SignatureAlgorithm(private_key) = %v", treeSigAlgo, keySigAlgo)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to marshal public key: %v", err.Error())
+	}
+	rotation.PublicKey = &keyspb.PublicKey{Der: publicKeyDER}
+
+	// Roots that were already signed by the current key can't change keys.
+	if err := s.checkActivationTreeSize(ctx, tree.TreeId, rotation.ActivationTreeSize); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	tree, err = tx.RotateTreeKey(ctx, tree.TreeId, rotation)
+	if err != nil {
+		return nil, err
+	}
+	// The log may have grown past the activation size since it was checked, in which case
+	// the transaction is rolled back.
+	if err := s.checkActivationTreeSize(ctx, tree.TreeId, rotation.ActivationTreeSize); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("%v: key rotation scheduled at tree size %v", tree.TreeId, rotation.ActivationTreeSize)
+	return redact(tree), nil
+}
+
+// RetireTreeKey implements trillian.TrillianAdminServer.RetireTreeKey.
+func (s *Server) RetireTreeKey(ctx context.Context, req *trillian.RetireTreeKeyRequest) (*trillian.Tree, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.Unimplemented, "log storage not available on this server")
+	}
+	if _, err := trees.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId(), trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true}); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	// The rotation is read in the transaction, so it's the one retired even if another was
+	// scheduled meanwhile.
+	tree, err := tx.GetTree(ctx, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	kr := tree.KeyRotation
+	if kr == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "tree %v has no key rotation in progress", tree.TreeId)
+	}
+	// Retiring the current key before the new one signed a root would leave clients unable to
+	// verify the latest root with the tree's public key.
+	root, err := s.latestSignedLogRoot(ctx, tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	if root.TreeSize < kr.ActivationTreeSize {
+		return nil, status.Errorf(codes.FailedPrecondition, "tree %v has size %v, key rotation activates at size %v", tree.TreeId, root.TreeSize, kr.ActivationTreeSize)
+	}
+	tree, err = tx.RetireTreeKey(ctx, tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("%v: retired previous key, new key active from tree size %v", tree.TreeId, kr.ActivationTreeSize)
+	return redact(tree), nil
+}
+
+// checkActivationTreeSize checks that the log treeID hasn't reached activationTreeSize yet, so
+// a key rotation activating at that size doesn't apply to roots already signed.
+func (s *Server) checkActivationTreeSize(ctx context.Context, treeID, activationTreeSize int64) error {
+	root, err := s.latestSignedLogRoot(ctx, treeID)
+	if err != nil {
+		return err
+	}
+	if activationTreeSize <= root.TreeSize {
+		return status.Errorf(codes.InvalidArgument, "activation_tree_size = %v, must be greater than the current tree size (%v)", activationTreeSize, root.TreeSize)
+	}
+	return nil
+}
+
+// latestSignedLogRoot returns the latest signed root of the log treeID.
+func (s *Server) latestSignedLogRoot(ctx context.Context, treeID int64) (*trillian.SignedLogRoot, error) {
+	tx, err := s.registry.LogStorage.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// checkUpdatedSecondarySigner checks the secondary signer of tree if mask updates it.
+func (s *Server) checkUpdatedSecondarySigner(ctx context.Context, tree *trillian.Tree, mask *field_mask.FieldMask) error {
+	for _, path := range mask.GetPaths() {
+		if path == "secondary_signer" {
+			return s.checkSecondarySigner(ctx, tree)
+		}
+	}
+	return nil
+}
+
+// checkSecondarySigner checks that the secondary signer of tree, if any, can be created and that
+// its keys and algorithm match.
+func (s *Server) checkSecondarySigner(ctx context.Context, tree *trillian.Tree) error {
+	ss := tree.SecondarySigner
+	if ss == nil {
+		return nil
+	}
+	signer, err := trees.SecondarySigner(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to create secondary signer for tree: %v", err.Error())
+	}
+	if keySigAlgo := keys.SignatureAlgorithm(signer.Public()); ss.SignatureAlgorithm != keySigAlgo {
+		return status.Errorf(codes.InvalidArgument, "tree.secondary_signer.signature_algorithm = %v, but SignatureAlgorithm(tree.secondary_signer.private_key) = %v", ss.SignatureAlgorithm, keySigAlgo)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to marshal secondary public key: %v", err.Error())
+	}
+	if !bytes.Equal(ss.PublicKey.GetDer(), publicKeyDER) {
+		return status.Error(codes.InvalidArgument, "the secondary public and private keys are not a pair")
+	}
+	return nil
 }
 
 // redact removes sensitive information from t. Returns t for convenience.
 func redact(t *trillian.Tree) *trillian.Tree {
 	t.PrivateKey = nil
+	if t.SecondarySigner != nil {
+		// Copy, so that cached or stored configs aren't modified.
+		ss := *t.SecondarySigner
+		ss.PrivateKey = nil
+		t.SecondarySigner = &ss
+	}
+	if t.KeyRotation != nil {
+		kr := *t.KeyRotation
+		kr.PrivateKey = nil
+		t.KeyRotation = &kr
+	}
 	return t
 }
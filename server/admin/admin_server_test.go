@@ -15,10 +15,12 @@
 package admin
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"testing"
@@ -28,14 +30,20 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/google/trillian/testonly/matchers"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
 	"github.com/kylelemons/godebug/pretty"
 	"golang.org/x/net/context"
 	"google.golang.org/genproto/protobuf/field_mask"
@@ -43,26 +51,62 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func TestServer_Unimplemented(t *testing.T) {
-	tests := []struct {
-		desc string
-		fn   func(context.Context, *Server) error
-	}{
-		{
-			desc: "DeleteTree",
-			fn: func(ctx context.Context, s *Server) error {
-				_, err := s.DeleteTree(ctx, &trillian.DeleteTreeRequest{})
-				return err
-			},
-		},
-	}
+func TestServer_DeleteTree(t *testing.T) {
 	ctx := context.Background()
-	s := &Server{}
-	for _, test := range tests {
-		err := test.fn(ctx, s)
-		if s, ok := status.FromError(err); !ok || s.Code() != codes.Unimplemented {
-			t.Errorf("%v: got = %v, want = %s", test.desc, err, codes.Unimplemented)
+	as := memory.NewAdminStorage(memory.NewLogStorage(nil))
+	s := &Server{registry: extension.Registry{AdminStorage: as}}
+
+	tx, err := as.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() returned err = %v", err)
+	}
+	tree, err := tx.CreateTree(ctx, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+
+	listTrees := func(showDeleted bool) []*trillian.Tree {
+		resp, err := s.ListTrees(ctx, &trillian.ListTreesRequest{ShowDeleted: showDeleted})
+		if err != nil {
+			t.Fatalf("ListTrees() returned err = %v", err)
 		}
+		return resp.Tree
+	}
+
+	if _, err := s.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: tree.TreeId}); err != nil {
+		t.Fatalf("DeleteTree() returned err = %v", err)
+	}
+	if _, err := s.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: tree.TreeId}); err == nil {
+		t.Error("DeleteTree() of deleted tree returned err = nil, want non-nil")
+	}
+	if got := listTrees(false); len(got) != 0 {
+		t.Errorf("ListTrees() = %v, want no trees", got)
+	}
+	switch got := listTrees(true); {
+	case len(got) != 1:
+		t.Errorf("ListTrees(show_deleted) returned %v trees, want 1", len(got))
+	case got[0].TreeState != trillian.TreeState_SOFT_DELETED || got[0].DeleteTime == nil:
+		t.Errorf("ListTrees(show_deleted) = %v, want a SOFT_DELETED tree with a delete_time", got[0])
+	}
+
+	undeleted, err := s.UndeleteTree(ctx, &trillian.UndeleteTreeRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("UndeleteTree() returned err = %v", err)
+	}
+	if undeleted.TreeState != trillian.TreeState_FROZEN || undeleted.DeleteTime != nil {
+		t.Errorf("UndeleteTree() = %v, want a FROZEN tree without delete_time", undeleted)
+	}
+	if undeleted.PrivateKey != nil {
+		t.Error("UndeleteTree() returned the tree's private key")
+	}
+	if _, err := s.UndeleteTree(ctx, &trillian.UndeleteTreeRequest{TreeId: tree.TreeId}); err == nil {
+		t.Error("UndeleteTree() of undeleted tree returned err = nil, want non-nil")
+	}
+	if got := listTrees(false); len(got) != 1 {
+		t.Errorf("ListTrees() returned %v trees, want 1", len(got))
 	}
 }
 
@@ -134,6 +178,142 @@ func TestServer_BeginError(t *testing.T) {
 	}
 }
 
+func TestServer_KeyRotation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	as := memory.NewAdminStorage(memory.NewLogStorage(nil))
+	tx, err := as.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() returned err = %v", err)
+	}
+	tree, err := tx.CreateTree(ctx, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test ECDSA key: %v", err)
+	}
+	newKeyDER, err := keys.MarshalPrivateKey(newKey)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey() returned err = %v", err)
+	}
+	newPrivateKey, err := ptypes.MarshalAny(&keyspb.PrivateKey{Der: newKeyDER})
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+	newPublicKeyDER, err := x509.MarshalPKIXPublicKey(newKey.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() returned err = %v", err)
+	}
+
+	s := &Server{registry: extension.Registry{AdminStorage: as, SignerFactory: &keys.DefaultSignerFactory{}}}
+	// setTreeSize makes the latest root of the tree have size leaves.
+	setTreeSize := func(size int64) {
+		ls := storage.NewMockLogStorage(ctrl)
+		tx := storage.NewMockLogTreeTX(ctrl)
+		ls.EXPECT().SnapshotForTree(gomock.Any(), tree.TreeId).AnyTimes().Return(tx, nil)
+		tx.EXPECT().LatestSignedLogRoot(gomock.Any()).AnyTimes().Return(trillian.SignedLogRoot{LogId: tree.TreeId, TreeSize: size}, nil)
+		tx.EXPECT().Commit().AnyTimes().Return(nil)
+		tx.EXPECT().Close().AnyTimes().Return(nil)
+		s.registry.LogStorage = ls
+	}
+	setTreeSize(10)
+
+	for _, test := range []struct {
+		desc     string
+		req      *trillian.RotateTreeKeyRequest
+		wantCode codes.Code
+	}{
+		{
+			desc:     "noKey",
+			req:      &trillian.RotateTreeKeyRequest{TreeId: tree.TreeId, ActivationTreeSize: 20},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "keyAndKeySpec",
+			req: &trillian.RotateTreeKeyRequest{
+				TreeId:             tree.TreeId,
+				PrivateKey:         newPrivateKey,
+				KeySpec:            &keyspb.Specification{Params: &keyspb.Specification_EcdsaParams{}},
+				ActivationTreeSize: 20,
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "mismatchedAlgorithm",
+			req: &trillian.RotateTreeKeyRequest{
+				TreeId:             tree.TreeId,
+				KeySpec:            &keyspb.Specification{Params: &keyspb.Specification_RsaParams{}},
+				ActivationTreeSize: 20,
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "activationInThePast",
+			req:      &trillian.RotateTreeKeyRequest{TreeId: tree.TreeId, PrivateKey: newPrivateKey, ActivationTreeSize: 10},
+			wantCode: codes.InvalidArgument,
+		},
+	} {
+		if _, err := s.RotateTreeKey(ctx, test.req); status.Code(err) != test.wantCode {
+			t.Errorf("%v: RotateTreeKey() returned err = %v, want code %s", test.desc, err, test.wantCode)
+		}
+	}
+
+	if _, err := s.RetireTreeKey(ctx, &trillian.RetireTreeKeyRequest{TreeId: tree.TreeId}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("RetireTreeKey() without rotation returned err = %v, want code %s", err, codes.FailedPrecondition)
+	}
+
+	rotated, err := s.RotateTreeKey(ctx, &trillian.RotateTreeKeyRequest{TreeId: tree.TreeId, PrivateKey: newPrivateKey, ActivationTreeSize: 20})
+	if err != nil {
+		t.Fatalf("RotateTreeKey() returned err = %v", err)
+	}
+	if kr := rotated.KeyRotation; kr == nil || kr.ActivationTreeSize != 20 || !bytes.Equal(kr.PublicKey.GetDer(), newPublicKeyDER) {
+		t.Errorf("RotateTreeKey().KeyRotation = %v, want the new public key, activating at 20", kr)
+	}
+	if rotated.PrivateKey != nil || rotated.KeyRotation.GetPrivateKey() != nil {
+		t.Error("RotateTreeKey() returned private keys")
+	}
+
+	if _, err := s.RetireTreeKey(ctx, &trillian.RetireTreeKeyRequest{TreeId: tree.TreeId}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("RetireTreeKey() before activation returned err = %v, want code %s", err, codes.FailedPrecondition)
+	}
+
+	setTreeSize(20)
+	retired, err := s.RetireTreeKey(ctx, &trillian.RetireTreeKeyRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("RetireTreeKey() returned err = %v", err)
+	}
+	if retired.KeyRotation != nil || !bytes.Equal(retired.PublicKey.GetDer(), newPublicKeyDER) {
+		t.Errorf("RetireTreeKey() = %v, want the new public key and no key rotation", retired)
+	}
+	wantHistory := []*trillian.RetiredKey{{PublicKey: tree.PublicKey, EndTreeSize: 20}}
+	if diff := pretty.Compare(retired.KeyHistory, wantHistory); diff != "" {
+		t.Errorf("RetireTreeKey().KeyHistory diff:\n%v", diff)
+	}
+
+	// The log grows past the activation size while the rotation is written.
+	ls := storage.NewMockLogStorage(ctrl)
+	ltx := storage.NewMockLogTreeTX(ctrl)
+	ls.EXPECT().SnapshotForTree(gomock.Any(), tree.TreeId).Times(2).Return(ltx, nil)
+	gomock.InOrder(
+		ltx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{LogId: tree.TreeId, TreeSize: 20}, nil),
+		ltx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{LogId: tree.TreeId, TreeSize: 40}, nil),
+	)
+	ltx.EXPECT().Commit().Times(2).Return(nil)
+	ltx.EXPECT().Close().Times(2).Return(nil)
+	s.registry.LogStorage = ls
+	if _, err := s.RotateTreeKey(ctx, &trillian.RotateTreeKeyRequest{TreeId: tree.TreeId, PrivateKey: newPrivateKey, ActivationTreeSize: 30}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("RotateTreeKey() past a concurrent activation returned err = %v, want code %s", err, codes.InvalidArgument)
+	}
+}
+
 func TestServer_ListTrees(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -470,6 +650,7 @@ func TestServer_CreateTree(t *testing.T) {
 		nowPB, _ := ptypes.TimestampProto(time.Now())
 
 		if test.req.Tree != nil {
+			tx.EXPECT().CountActiveTrees(ctx).MaxTimes(1).Return(int64(0), nil)
 			var newTree trillian.Tree
 			tx.EXPECT().CreateTree(ctx, gomock.Any()).MaxTimes(1).Do(func(ctx context.Context, tree *trillian.Tree) {
 				newTree = *tree
@@ -501,6 +682,50 @@ func TestServer_CreateTree(t *testing.T) {
 	}
 }
 
+func TestServer_CreateTree_MaxActiveTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test key: %v", err)
+	}
+	tree := *testonly.LogTree
+	tree.PublicKey = nil
+
+	tests := []struct {
+		desc       string
+		count      int64
+		countErr   error
+		wantCreate bool
+		wantCode   codes.Code
+	}{
+		{desc: "belowMax", count: 1, wantCreate: true},
+		{desc: "atMax", count: 2, wantCode: codes.ResourceExhausted},
+		{desc: "aboveMax", count: 3, wantCode: codes.ResourceExhausted},
+		{desc: "countErr", countErr: errors.New("count failed"), wantCode: codes.Unknown},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		sf := keys.NewMockSignerFactory(ctrl)
+		sf.EXPECT().NewSigner(gomock.Any(), gomock.Any()).Return(privateKey, nil)
+
+		setup := setupAdminServer(ctrl, sf, false /* snapshot */, test.wantCreate, false /* commitErr */)
+		setup.tx.EXPECT().CountActiveTrees(ctx).Return(test.count, test.countErr)
+		if test.wantCreate {
+			setup.tx.EXPECT().CreateTree(ctx, gomock.Any()).Return(proto.Clone(&tree).(*trillian.Tree), nil)
+		}
+		s := setup.server
+		s.SetMaxActiveTrees(2)
+
+		_, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: proto.Clone(&tree).(*trillian.Tree)})
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: CreateTree() = (_, %v), want code %v", test.desc, err, test.wantCode)
+		}
+	}
+}
+
 func marshalECPrivateKeyAsAnyProto(key *ecdsa.PrivateKey) (*any.Any, error) {
 	der, err := x509.MarshalECPrivateKey(key)
 	if err != nil {
@@ -635,8 +860,599 @@ func TestServer_UpdateTree(t *testing.T) {
 	}
 }
 
+func TestUpdateDrainDeadline(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	nowPB, _ := ptypes.TimestampProto(now)
+	laterPB, _ := ptypes.TimestampProto(now.Add(time.Hour))
+
+	tests := []struct {
+		desc         string
+		prevState    trillian.TreeState
+		tree         *trillian.Tree
+		wantDeadline *timestamp.Timestamp
+	}{
+		{
+			desc:      "active",
+			prevState: trillian.TreeState_ACTIVE,
+			tree:      &trillian.Tree{TreeState: trillian.TreeState_ACTIVE},
+		},
+		{
+			desc:         "startDraining",
+			prevState:    trillian.TreeState_ACTIVE,
+			tree:         &trillian.Tree{TreeState: trillian.TreeState_DRAINING, DrainGracePeriod: ptypes.DurationProto(time.Hour)},
+			wantDeadline: laterPB,
+		},
+		{
+			desc:         "startDrainingWithoutGracePeriod",
+			prevState:    trillian.TreeState_ACTIVE,
+			tree:         &trillian.Tree{TreeState: trillian.TreeState_DRAINING},
+			wantDeadline: nowPB,
+		},
+		{
+			desc:         "keepDraining",
+			prevState:    trillian.TreeState_DRAINING,
+			tree:         &trillian.Tree{TreeState: trillian.TreeState_DRAINING, DrainGracePeriod: ptypes.DurationProto(time.Hour), DrainDeadline: nowPB},
+			wantDeadline: nowPB,
+		},
+		{
+			desc:      "stopDraining",
+			prevState: trillian.TreeState_DRAINING,
+			tree:      &trillian.Tree{TreeState: trillian.TreeState_FROZEN, DrainDeadline: nowPB},
+		},
+	}
+	for _, test := range tests {
+		updateDrainDeadline(test.tree, test.prevState, now)
+		if got := test.tree.DrainDeadline; !proto.Equal(got, test.wantDeadline) {
+			t.Errorf("%v: DrainDeadline = %v, want = %v", test.desc, got, test.wantDeadline)
+		}
+	}
+}
+
+func TestServer_CheckSecondarySigner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating test RSA key: %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() returned err = %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test ECDSA key: %v", err)
+	}
+	otherDER, err := x509.MarshalPKIXPublicKey(otherKey.Public())
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() returned err = %v", err)
+	}
+	privateKey, err := ptypes.MarshalAny(&keyspb.PEMKeyFile{Path: "secondary.pem"})
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+
+	sf := keys.NewMockSignerFactory(ctrl)
+	sf.EXPECT().NewSigner(gomock.Any(), gomock.Any()).AnyTimes().Return(key, nil)
+	s := &Server{registry: extension.Registry{SignerFactory: sf}}
+
+	tests := []struct {
+		desc    string
+		ss      *trillian.SecondarySigner
+		wantErr bool
+	}{
+		{desc: "none"},
+		{
+			desc: "valid",
+			ss: &trillian.SecondarySigner{
+				SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+				PrivateKey:         privateKey,
+				PublicKey:          &keyspb.PublicKey{Der: keyDER},
+			},
+		},
+		{
+			desc: "wrongAlgorithm",
+			ss: &trillian.SecondarySigner{
+				SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+				PrivateKey:         privateKey,
+				PublicKey:          &keyspb.PublicKey{Der: keyDER},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "notAPair",
+			ss: &trillian.SecondarySigner{
+				SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+				PrivateKey:         privateKey,
+				PublicKey:          &keyspb.PublicKey{Der: otherDER},
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		tree := *testonly.LogTree
+		tree.SecondarySigner = test.ss
+		err := s.checkSecondarySigner(context.Background(), &tree)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: checkSecondarySigner() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		} else if gotErr && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: checkSecondarySigner() = %v, want code %v", test.desc, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	ss := &trillian.SecondarySigner{
+		SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+		PrivateKey:         &any.Any{Value: []byte("secret")},
+		PublicKey:          &keyspb.PublicKey{Der: []byte("public")},
+	}
+	tree := *testonly.LogTree
+	tree.SecondarySigner = ss
+
+	got := redact(&tree)
+	if got.PrivateKey != nil {
+		t.Errorf("redact().PrivateKey = %v, want nil", got.PrivateKey)
+	}
+	if got.SecondarySigner.PrivateKey != nil {
+		t.Errorf("redact().SecondarySigner.PrivateKey = %v, want nil", got.SecondarySigner.PrivateKey)
+	}
+	if !proto.Equal(got.SecondarySigner.PublicKey, ss.PublicKey) {
+		t.Errorf("redact().SecondarySigner.PublicKey = %v, want %v", got.SecondarySigner.PublicKey, ss.PublicKey)
+	}
+	if ss.PrivateKey == nil {
+		t.Error("redact() modified the original SecondarySigner")
+	}
+}
+
+func TestServer_BatchUpdateTrees_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := &trillian.Tree{DisplayName: "New Name"}
+	mask := &field_mask.FieldMask{Paths: []string{"display_name"}}
+	tests := []struct {
+		desc string
+		req  *trillian.BatchUpdateTreesRequest
+	}{
+		{desc: "nilTree", req: &trillian.BatchUpdateTreesRequest{TreeIds: []int64{1}, UpdateMask: mask}},
+		{desc: "nilUpdateMask", req: &trillian.BatchUpdateTreesRequest{TreeIds: []int64{1}, Tree: tree}},
+		{
+			desc: "readonlyField",
+			req: &trillian.BatchUpdateTreesRequest{
+				TreeIds:    []int64{1},
+				Tree:       tree,
+				UpdateMask: &field_mask.FieldMask{Paths: []string{"tree_type"}},
+			},
+		},
+		{desc: "negativeBatchSize", req: &trillian.BatchUpdateTreesRequest{TreeIds: []int64{1}, Tree: tree, UpdateMask: mask, BatchSize: -1}},
+	}
+
+	ctx := context.Background()
+	// No storage expectations: invalid requests must not touch storage.
+	s := &Server{registry: extension.Registry{AdminStorage: storage.NewMockAdminStorage(ctrl)}}
+	for _, test := range tests {
+		_, err := s.BatchUpdateTrees(ctx, test.req)
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.InvalidArgument {
+			t.Errorf("%v: BatchUpdateTrees() returned err = %v, want code %v", test.desc, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestServer_BatchUpdateTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree1 := *testonly.LogTree
+	tree1.TreeId = 1
+	tree2 := *testonly.MapTree
+	tree2.TreeId = 2
+	tree3 := *testonly.LogTree
+	tree3.TreeId = 3
+	tree3.TreeState = trillian.TreeState_FROZEN
+
+	template := &trillian.Tree{DisplayName: "New Name"}
+	mask := &field_mask.FieldMask{Paths: []string{"display_name"}}
+	updateErr := status.Errorf(codes.InvalidArgument, "invalid update")
+
+	ctx := context.Background()
+	as := storage.NewMockAdminStorage(ctrl)
+	tx1 := storage.NewMockAdminTX(ctrl)
+	tx2 := storage.NewMockAdminTX(ctrl)
+	// Batches of two: trees 1 and 2 go in the first transaction, tree 3 in the second.
+	gomock.InOrder(
+		as.EXPECT().Begin(gomock.Any()).Return(tx1, nil),
+		as.EXPECT().Begin(gomock.Any()).Return(tx2, nil),
+	)
+	tx1.EXPECT().UpdateTree(ctx, int64(1), gomock.Any()).Return(&tree1, nil)
+	tx1.EXPECT().UpdateTree(ctx, int64(2), gomock.Any()).Return(nil, updateErr)
+	tx1.EXPECT().Commit().Return(nil)
+	tx1.EXPECT().Close().Return(nil)
+	tx2.EXPECT().UpdateTree(ctx, int64(3), gomock.Any()).Return(&tree3, nil)
+	tx2.EXPECT().Commit().Return(errors.New("commit error"))
+	tx2.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: as}}
+	rsp, err := s.BatchUpdateTrees(ctx, &trillian.BatchUpdateTreesRequest{
+		TreeIds:    []int64{1, 2, 3},
+		Tree:       template,
+		UpdateMask: mask,
+		BatchSize:  2,
+	})
+	if err != nil {
+		t.Fatalf("BatchUpdateTrees() returned err = %v", err)
+	}
+
+	wantCodes := []codes.Code{codes.OK, codes.InvalidArgument, codes.Unknown}
+	if got, want := len(rsp.Results), len(wantCodes); got != want {
+		t.Fatalf("BatchUpdateTrees() returned %v results, want %v", got, want)
+	}
+	for i, r := range rsp.Results {
+		if got, want := r.TreeId, int64(i+1); got != want {
+			t.Errorf("Results[%v].TreeId = %v, want %v", i, got, want)
+		}
+		if got, want := codes.Code(r.GetStatus().GetCode()), wantCodes[i]; got != want {
+			t.Errorf("Results[%v].Status.Code = %v, want %v", i, got, want)
+		}
+		if hasTree, wantTree := r.Tree != nil, wantCodes[i] == codes.OK; hasTree != wantTree {
+			t.Errorf("Results[%v].Tree = %v, want tree = %v", i, r.Tree, wantTree)
+		}
+		if r.Tree != nil && r.Tree.PrivateKey != nil {
+			t.Errorf("Results[%v].Tree.PrivateKey = %v, want nil", i, r.Tree.PrivateKey)
+		}
+	}
+}
+
+func TestServer_BatchUpdateTrees_StorageError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree1 := *testonly.LogTree
+	tree1.TreeId = 1
+
+	ctx := context.Background()
+	as := storage.NewMockAdminStorage(ctrl)
+	tx := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Begin(gomock.Any()).Return(tx, nil)
+	tx.EXPECT().UpdateTree(ctx, int64(1), gomock.Any()).Return(&tree1, nil)
+	tx.EXPECT().UpdateTree(ctx, int64(2), gomock.Any()).Return(nil, status.Errorf(codes.NotFound, "tree 2 not found"))
+	tx.EXPECT().UpdateTree(ctx, int64(3), gomock.Any()).Return(nil, errors.New("storage error"))
+	// Tree 4 isn't updated and the transaction is rolled back.
+	tx.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: as}}
+	rsp, err := s.BatchUpdateTrees(ctx, &trillian.BatchUpdateTreesRequest{
+		TreeIds:    []int64{1, 2, 3, 4},
+		Tree:       &trillian.Tree{DisplayName: "New Name"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"display_name"}},
+	})
+	if err != nil {
+		t.Fatalf("BatchUpdateTrees() returned err = %v", err)
+	}
+
+	wantCodes := []codes.Code{codes.Unknown, codes.NotFound, codes.Unknown, codes.Unknown}
+	if got, want := len(rsp.Results), len(wantCodes); got != want {
+		t.Fatalf("BatchUpdateTrees() returned %v results, want %v", got, want)
+	}
+	for i, r := range rsp.Results {
+		if got, want := codes.Code(r.GetStatus().GetCode()), wantCodes[i]; got != want {
+			t.Errorf("Results[%v].Status.Code = %v, want %v", i, got, want)
+		}
+		if r.Tree != nil {
+			t.Errorf("Results[%v].Tree = %v, want nil", i, r.Tree)
+		}
+	}
+}
+
+func TestServer_BatchUpdateTrees_Filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	activeLog := *testonly.LogTree
+	activeLog.TreeId = 1
+	activeMap := *testonly.MapTree
+	activeMap.TreeId = 2
+	frozenLog := *testonly.LogTree
+	frozenLog.TreeId = 3
+	frozenLog.TreeState = trillian.TreeState_FROZEN
+
+	ctx := context.Background()
+	as := storage.NewMockAdminStorage(ctrl)
+	snapshot := storage.NewMockReadOnlyAdminTX(ctrl)
+	tx := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(snapshot, nil)
+	snapshot.EXPECT().ListTrees(ctx).Return([]*trillian.Tree{&activeLog, &activeMap, &frozenLog}, nil)
+	snapshot.EXPECT().Commit().Return(nil)
+	snapshot.EXPECT().Close().Return(nil)
+	as.EXPECT().Begin(gomock.Any()).Return(tx, nil)
+	tx.EXPECT().UpdateTree(ctx, activeLog.TreeId, gomock.Any()).Return(&activeLog, nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: as}}
+	rsp, err := s.BatchUpdateTrees(ctx, &trillian.BatchUpdateTreesRequest{
+		TreeType:   trillian.TreeType_LOG,
+		TreeState:  trillian.TreeState_ACTIVE,
+		Tree:       &trillian.Tree{DisplayName: "New Name"},
+		UpdateMask: &field_mask.FieldMask{Paths: []string{"display_name"}},
+	})
+	if err != nil {
+		t.Fatalf("BatchUpdateTrees() returned err = %v", err)
+	}
+	if got, want := len(rsp.Results), 1; got != want {
+		t.Fatalf("BatchUpdateTrees() returned %v results, want %v", got, want)
+	}
+	if got, want := rsp.Results[0].TreeId, activeLog.TreeId; got != want {
+		t.Errorf("Results[0].TreeId = %v, want %v", got, want)
+	}
+}
+
+func TestServer_RepairTreeRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	frozenTree := *testonly.LogTree
+	frozenTree.TreeId = 12345
+	frozenTree.TreeState = trillian.TreeState_FROZEN
+
+	activeTree := frozenTree
+	activeTree.TreeState = trillian.TreeState_ACTIVE
+
+	mapTree := *testonly.MapTree
+	mapTree.TreeId = 12345
+	mapTree.TreeState = trillian.TreeState_FROZEN
+
+	// Repaired roots are signed like any other, including by the secondary signer.
+	secondaryTree := frozenTree
+	secondaryTree.SecondarySigner = &trillian.SecondarySigner{
+		SignatureAlgorithm: frozenTree.SignatureAlgorithm,
+		PrivateKey:         frozenTree.PrivateKey,
+	}
+
+	emptyRoot := rfc6962.DefaultHasher.EmptyRoot()
+	rootTime := time.Unix(1500000000, 0)
+
+	tests := []struct {
+		desc         string
+		tree         *trillian.Tree
+		force        bool
+		storedRoot   []byte
+		wantErr      bool
+		wantCode     codes.Code
+		wantRepaired bool
+		wantSigs     int
+	}{
+		{desc: "activeTree", tree: &activeTree, wantErr: true, wantCode: codes.FailedPrecondition},
+		{desc: "mapTree", tree: &mapTree, wantErr: true},
+		{desc: "frozenTreeOK", tree: &frozenTree, storedRoot: emptyRoot},
+		{desc: "activeTreeForced", tree: &activeTree, force: true, storedRoot: emptyRoot},
+		{desc: "frozenTreeRepaired", tree: &frozenTree, storedRoot: []byte("bad root"), wantRepaired: true},
+		{desc: "secondarySignerRepaired", tree: &secondaryTree, storedRoot: []byte("bad root"), wantRepaired: true, wantSigs: 1},
+	}
+
+	for _, test := range tests {
+		ls := storage.NewMockLogStorage(ctrl)
+		if test.storedRoot != nil {
+			tx := storage.NewMockLogTreeTX(ctrl)
+			ls.EXPECT().BeginForTree(gomock.Any(), test.tree.TreeId).Return(tx, nil)
+			tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{LogId: test.tree.TreeId, RootHash: test.storedRoot, TreeRevision: 3}, nil)
+			if test.wantRepaired {
+				tx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Return(nil)
+			}
+			tx.EXPECT().Commit().Return(nil)
+			tx.EXPECT().Close().Return(nil)
+		}
+
+		s := &Server{registry: extension.Registry{
+			AdminStorage:  storage.NewMockAdminStorage(ctrl),
+			LogStorage:    ls,
+			SignerFactory: &keys.DefaultSignerFactory{},
+		}}
+		s.SetRootTimeSource(util.NewFakeTimeSource(rootTime))
+		ctx := trees.NewContext(context.Background(), test.tree)
+
+		resp, err := s.RepairTreeRoot(ctx, &trillian.RepairTreeRootRequest{TreeId: test.tree.TreeId, Force: test.force})
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: RepairTreeRoot() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		} else if hasErr {
+			if s, ok := status.FromError(err); test.wantCode != codes.OK && (!ok || s.Code() != test.wantCode) {
+				t.Errorf("%v: RepairTreeRoot() returned err = %v, want code %s", test.desc, err, test.wantCode)
+			}
+			continue
+		}
+		if got, want := resp.Repaired, test.wantRepaired; got != want {
+			t.Errorf("%v: RepairTreeRoot().Repaired = %v, want %v", test.desc, got, want)
+		}
+		if !test.wantRepaired {
+			continue
+		}
+		if !bytes.Equal(resp.RepairedRoot.RootHash, emptyRoot) {
+			t.Errorf("%v: RepairTreeRoot().RepairedRoot.RootHash = %x, want %x", test.desc, resp.RepairedRoot.RootHash, emptyRoot)
+		}
+		if got, want := resp.RepairedRoot.TimestampNanos, rootTime.UnixNano(); got != want {
+			t.Errorf("%v: RepairTreeRoot().RepairedRoot.TimestampNanos = %v, want %v", test.desc, got, want)
+		}
+		if got, want := len(resp.RepairedRoot.AdditionalSignatures), test.wantSigs; got != want {
+			t.Errorf("%v: RepairTreeRoot().RepairedRoot has %v additional signatures, want %v", test.desc, got, want)
+		}
+	}
+}
+
 // adminTestSetup contains an operational Server and required dependencies.
 // It's created via setupAdminServer.
+func TestServer_GetTreeFootprint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := *testonly.LogTree
+	tree.TreeId = 12345
+	fp := &storage.TreeFootprint{LeafCount: 10, LeafDataBytes: 1000, SubtreeCount: 3, SignedRootCount: 4}
+
+	ctx := trees.NewContext(context.Background(), &tree)
+	as := storage.NewMockAdminStorage(ctrl)
+	tx := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(tx, nil)
+	tx.EXPECT().GetTreeFootprint(gomock.Any(), tree.TreeId).Return(fp, nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: as}}
+	rsp, err := s.GetTreeFootprint(ctx, &trillian.GetTreeFootprintRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("GetTreeFootprint() returned err = %v", err)
+	}
+	want := &trillian.GetTreeFootprintResponse{LeafCount: 10, LeafDataBytes: 1000, SubtreeCount: 3, SignedRootCount: 4}
+	if !proto.Equal(rsp, want) {
+		t.Errorf("GetTreeFootprint() = %v, want %v", rsp, want)
+	}
+}
+
+func TestServer_GetTreeFootprint_Busy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := *testonly.LogTree
+	tree.TreeId = 12345
+
+	footprintInFlight = 1
+	defer func() { footprintInFlight = 0 }()
+
+	// No storage expectations: concurrent queries must be rejected up front.
+	ctx := trees.NewContext(context.Background(), &tree)
+	s := &Server{registry: extension.Registry{AdminStorage: storage.NewMockAdminStorage(ctrl)}}
+	_, err := s.GetTreeFootprint(ctx, &trillian.GetTreeFootprintRequest{TreeId: tree.TreeId})
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.ResourceExhausted {
+		t.Errorf("GetTreeFootprint() returned err = %v, want code %v", err, codes.ResourceExhausted)
+	}
+}
+
+func TestServer_ListPendingTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		desc          string
+		includeCounts bool
+		pending       map[int64]int64
+		want          []*trillian.PendingTree
+	}{
+		{desc: "none", pending: map[int64]int64{}},
+		{
+			desc:    "noCounts",
+			pending: map[int64]int64{3: 0, 1: 0},
+			want:    []*trillian.PendingTree{{TreeId: 1}, {TreeId: 3}},
+		},
+		{
+			desc:          "counts",
+			includeCounts: true,
+			pending:       map[int64]int64{3: 7, 1: 2, 2: 5},
+			want: []*trillian.PendingTree{
+				{TreeId: 1, UnsequencedCount: 2},
+				{TreeId: 2, UnsequencedCount: 5},
+				{TreeId: 3, UnsequencedCount: 7},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		as := storage.NewMockAdminStorage(ctrl)
+		tx := storage.NewMockAdminTX(ctrl)
+		as.EXPECT().Snapshot(gomock.Any()).Return(tx, nil)
+		tx.EXPECT().ListPendingTrees(gomock.Any(), test.includeCounts).Return(test.pending, nil)
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+
+		s := &Server{registry: extension.Registry{AdminStorage: as}}
+		rsp, err := s.ListPendingTrees(ctx, &trillian.ListPendingTreesRequest{IncludeCounts: test.includeCounts})
+		if err != nil {
+			t.Errorf("%v: ListPendingTrees() returned err = %v", test.desc, err)
+			continue
+		}
+		if want := (&trillian.ListPendingTreesResponse{Trees: test.want}); !proto.Equal(rsp, want) {
+			t.Errorf("%v: ListPendingTrees() = %v, want %v", test.desc, rsp, want)
+		}
+	}
+}
+
+func TestServer_ListPendingTrees_Busy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pendingInFlight = 1
+	defer func() { pendingInFlight = 0 }()
+
+	// No storage expectations: concurrent queries must be rejected up front.
+	s := &Server{registry: extension.Registry{AdminStorage: storage.NewMockAdminStorage(ctrl)}}
+	_, err := s.ListPendingTrees(context.Background(), &trillian.ListPendingTreesRequest{})
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.ResourceExhausted {
+		t.Errorf("ListPendingTrees() returned err = %v, want code %v", err, codes.ResourceExhausted)
+	}
+}
+
+func TestServer_ListDeadLetteredLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := *testonly.LogTree
+	tree.TreeId = 12345
+	leaves := []*trillian.DeadLetteredLeaf{
+		{Leaf: &trillian.LogLeaf{LeafIdentityHash: []byte("leaf1")}, Reason: "bad", Attempts: 3},
+	}
+
+	ctx := trees.NewContext(context.Background(), &tree)
+	ls := storage.NewMockLogStorage(ctrl)
+	tx := storage.NewMockReadOnlyLogTreeTX(ctrl)
+	ls.EXPECT().SnapshotForTree(gomock.Any(), tree.TreeId).Return(tx, nil)
+	tx.EXPECT().GetDeadLetteredLeaves(gomock.Any()).Return(leaves, nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: storage.NewMockAdminStorage(ctrl), LogStorage: ls}}
+	rsp, err := s.ListDeadLetteredLeaves(ctx, &trillian.ListDeadLetteredLeavesRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("ListDeadLetteredLeaves() returned err = %v", err)
+	}
+	want := &trillian.ListDeadLetteredLeavesResponse{Leaves: leaves}
+	if !proto.Equal(rsp, want) {
+		t.Errorf("ListDeadLetteredLeaves() = %v, want %v", rsp, want)
+	}
+}
+
+func TestServer_RequeueDeadLetteredLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := *testonly.LogTree
+	tree.TreeId = 12345
+	hashes := [][]byte{[]byte("leaf1"), []byte("leaf2")}
+
+	ctx := trees.NewContext(context.Background(), &tree)
+	ls := storage.NewMockLogStorage(ctrl)
+	tx := storage.NewMockLogTreeTX(ctrl)
+	ls.EXPECT().BeginForTree(gomock.Any(), tree.TreeId).Return(tx, nil)
+	tx.EXPECT().RequeueDeadLetteredLeaves(gomock.Any(), hashes, gomock.Any()).Return(1, nil)
+	tx.EXPECT().Commit().Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	s := &Server{registry: extension.Registry{AdminStorage: storage.NewMockAdminStorage(ctrl), LogStorage: ls}}
+	rsp, err := s.RequeueDeadLetteredLeaves(ctx, &trillian.RequeueDeadLetteredLeavesRequest{TreeId: tree.TreeId, LeafIdentityHashes: hashes})
+	if err != nil {
+		t.Fatalf("RequeueDeadLetteredLeaves() returned err = %v", err)
+	}
+	if got, want := rsp.GetRequeuedCount(), int32(1); got != want {
+		t.Errorf("RequeueDeadLetteredLeaves().RequeuedCount = %v, want %v", got, want)
+	}
+
+	// Requests without hashes are rejected before storage is touched.
+	_, err = s.RequeueDeadLetteredLeaves(ctx, &trillian.RequeueDeadLetteredLeavesRequest{TreeId: tree.TreeId})
+	if st, ok := status.FromError(err); !ok || st.Code() != codes.InvalidArgument {
+		t.Errorf("RequeueDeadLetteredLeaves() returned err = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
 type adminTestSetup struct {
 	registry   extension.Registry
 	as         *storage.MockAdminStorage
@@ -683,7 +1499,161 @@ func setupAdminServer(ctrl *gomock.Controller, sf keys.SignerFactory, snapshot,
 		SignerFactory: sf,
 	}
 
-	s := &Server{registry}
+	s := &Server{registry: registry}
 
 	return adminTestSetup{registry, as, tx, snapshotTX, s}
 }
+
+func TestServer_GetQuotaTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	globalWrite := quota.Spec{Group: quota.Global, Kind: quota.Write}
+	treeRead := quota.Spec{Group: quota.Tree, Kind: quota.Read, TreeID: 12345}
+	userWrite := quota.Spec{Group: quota.User, Kind: quota.Write, User: "alice"}
+	tests := []struct {
+		desc      string
+		req       *trillian.GetQuotaTokensRequest
+		wantSpecs []quota.Spec
+		want      []*trillian.QuotaTokens
+	}{
+		{
+			desc: "global",
+			req:  &trillian.GetQuotaTokensRequest{},
+			wantSpecs: []quota.Spec{
+				{Group: quota.Global, Kind: quota.Read},
+				globalWrite,
+				{Group: quota.Global, Kind: quota.Admin},
+			},
+			want: []*trillian.QuotaTokens{
+				{Group: "Global", Kind: "Read", Unlimited: true},
+				{Group: "Global", Kind: "Write", Tokens: 100},
+				{Group: "Global", Kind: "Admin", Unlimited: true},
+			},
+		},
+		{
+			desc: "treeAndUser",
+			req:  &trillian.GetQuotaTokensRequest{TreeId: 12345, User: "alice"},
+			wantSpecs: []quota.Spec{
+				{Group: quota.Global, Kind: quota.Read},
+				globalWrite,
+				{Group: quota.Global, Kind: quota.Admin},
+				treeRead,
+				{Group: quota.Tree, Kind: quota.Write, TreeID: 12345},
+				{Group: quota.Tree, Kind: quota.Admin, TreeID: 12345},
+				{Group: quota.User, Kind: quota.Read, User: "alice"},
+				userWrite,
+				{Group: quota.User, Kind: quota.Admin, User: "alice"},
+			},
+			want: []*trillian.QuotaTokens{
+				{Group: "Global", Kind: "Read", Unlimited: true},
+				{Group: "Global", Kind: "Write", Tokens: 100},
+				{Group: "Global", Kind: "Admin", Unlimited: true},
+				{Group: "Tree", Kind: "Read", Tokens: 5},
+				{Group: "Tree", Kind: "Write", Unlimited: true},
+				{Group: "Tree", Kind: "Admin", Unlimited: true},
+				{Group: "User", Kind: "Read", Unlimited: true},
+				{Group: "User", Kind: "Write", Tokens: -2},
+				{Group: "User", Kind: "Admin", Unlimited: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		qm := quota.NewMockManager(ctrl)
+		tokens := make(map[quota.Spec]int)
+		for _, spec := range test.wantSpecs {
+			tokens[spec] = quota.MaxTokens
+		}
+		tokens[globalWrite] = 100
+		tokens[treeRead] = 5
+		tokens[userWrite] = -2
+		qm.EXPECT().PeekTokens(gomock.Any(), test.wantSpecs).Return(tokens, nil)
+
+		s := &Server{registry: extension.Registry{QuotaManager: qm}}
+		rsp, err := s.GetQuotaTokens(context.Background(), test.req)
+		if err != nil {
+			t.Errorf("%v: GetQuotaTokens() returned err = %v", test.desc, err)
+			continue
+		}
+		want := &trillian.GetQuotaTokensResponse{Buckets: test.want}
+		if !proto.Equal(rsp, want) {
+			t.Errorf("%v: GetQuotaTokens() = %v, want %v", test.desc, rsp, want)
+		}
+	}
+}
+
+func TestServer_GetQuotaTokens_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := &Server{registry: extension.Registry{}}
+	if _, err := s.GetQuotaTokens(context.Background(), &trillian.GetQuotaTokensRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("GetQuotaTokens() without quota manager returned err = %v, want code %v", err, codes.Unimplemented)
+	}
+
+	qm := quota.NewMockManager(ctrl)
+	qm.EXPECT().PeekTokens(gomock.Any(), gomock.Any()).Return(nil, errors.New("peek failed"))
+	s = &Server{registry: extension.Registry{QuotaManager: qm}}
+	if _, err := s.GetQuotaTokens(context.Background(), &trillian.GetQuotaTokensRequest{}); status.Code(err) != codes.Internal {
+		t.Errorf("GetQuotaTokens() returned err = %v, want code %v", err, codes.Internal)
+	}
+}
+
+func TestServer_QuotaConfigs(t *testing.T) {
+	ctx := context.Background()
+	s := &Server{registry: extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewLogStorage(nil))}}
+
+	cfg := &trillian.QuotaConfig{
+		Bucket:            &trillian.QuotaBucket{Group: "Tree", Kind: "Write", TreeId: 12345},
+		MaxTokens:         100,
+		TokensToReplenish: 10,
+		ReplenishInterval: ptypes.DurationProto(time.Second),
+	}
+	if got, err := s.CreateQuotaConfig(ctx, &trillian.CreateQuotaConfigRequest{Config: cfg}); err != nil || !proto.Equal(got, cfg) {
+		t.Fatalf("CreateQuotaConfig() = (%v, %v), want = (%v, nil)", got, err, cfg)
+	}
+	if _, err := s.CreateQuotaConfig(ctx, &trillian.CreateQuotaConfigRequest{}); err == nil {
+		t.Error("CreateQuotaConfig(nil config) returned err = nil, want non-nil")
+	}
+
+	update := &trillian.QuotaConfig{
+		Bucket:            cfg.Bucket,
+		MaxTokens:         200,
+		TokensToReplenish: 1, // Not in the mask, so not updated.
+	}
+	for _, test := range []struct {
+		desc    string
+		mask    *field_mask.FieldMask
+		wantErr bool
+	}{
+		{desc: "nilUpdateMask", wantErr: true},
+		{desc: "unknownPath", mask: &field_mask.FieldMask{Paths: []string{"bucket"}}, wantErr: true},
+		{desc: "invalidValue", mask: &field_mask.FieldMask{Paths: []string{"replenish_interval"}}, wantErr: true},
+		{desc: "success", mask: &field_mask.FieldMask{Paths: []string{"max_tokens"}}},
+	} {
+		_, err := s.UpdateQuotaConfig(ctx, &trillian.UpdateQuotaConfigRequest{Config: update, UpdateMask: test.mask})
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: UpdateQuotaConfig() = (_, %v), wantErr = %v", test.desc, err, test.wantErr)
+		}
+	}
+
+	want := proto.Clone(cfg).(*trillian.QuotaConfig)
+	want.MaxTokens = update.MaxTokens
+	resp, err := s.ListQuotaConfigs(ctx, &trillian.ListQuotaConfigsRequest{})
+	if err != nil {
+		t.Fatalf("ListQuotaConfigs() returned err = %v", err)
+	}
+	if len(resp.Configs) != 1 || !proto.Equal(resp.Configs[0], want) {
+		t.Errorf("ListQuotaConfigs() = %v, want [%v]", resp.Configs, want)
+	}
+
+	if _, err := s.DeleteQuotaConfig(ctx, &trillian.DeleteQuotaConfigRequest{Bucket: cfg.Bucket}); err != nil {
+		t.Fatalf("DeleteQuotaConfig() returned err = %v", err)
+	}
+	if _, err := s.DeleteQuotaConfig(ctx, &trillian.DeleteQuotaConfigRequest{Bucket: cfg.Bucket}); err == nil {
+		t.Error("DeleteQuotaConfig(deleted bucket) returned err = nil, want non-nil")
+	}
+	if resp, err := s.ListQuotaConfigs(ctx, &trillian.ListQuotaConfigsRequest{}); err != nil || len(resp.Configs) != 0 {
+		t.Errorf("ListQuotaConfigs() after delete = (%v, %v), want = (empty, nil)", resp, err)
+	}
+}
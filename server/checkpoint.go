@@ -0,0 +1,182 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/trillian"
+	tcrypto "github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/trees"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkpointPrefix is the path prefix that CheckpointHandler serves checkpoints under,
+// followed by the log ID.
+const checkpointPrefix = "/checkpoint/"
+
+// checkpoint is a rendered checkpoint, along with what it was rendered from.
+type checkpoint struct {
+	revision int64
+	origin   string
+	text     []byte
+}
+
+// checkpointCache keeps the latest checkpoint rendered for each log. Signatures aren't
+// necessarily deterministic, e.g. ECDSA ones, so a checkpoint is only re-rendered when its
+// root or origin change, keeping checkpoints byte-for-byte stable across calls.
+type checkpointCache struct {
+	mu          sync.Mutex
+	checkpoints map[int64]checkpoint
+}
+
+func (c *checkpointCache) get(logID, revision int64, origin string) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cp, ok := c.checkpoints[logID]; ok && cp.revision == revision && cp.origin == origin {
+		return cp.text
+	}
+	return nil
+}
+
+func (c *checkpointCache) put(logID int64, cp checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checkpoints == nil {
+		c.checkpoints = make(map[int64]checkpoint)
+	}
+	if old, ok := c.checkpoints[logID]; !ok || old.revision <= cp.revision {
+		c.checkpoints[logID] = cp
+	}
+}
+
+// GetLatestCheckpoint returns the latest signed log root as a signed checkpoint. The
+// checkpoint is signed with the tree's key, under the tree's checkpoint origin.
+func (t *TrillianLogRPCServer) GetLatestCheckpoint(ctx context.Context, req *trillian.GetLatestCheckpointRequest) (*trillian.GetLatestCheckpointResponse, error) {
+	logID := req.LogId
+	tree, err := trees.GetTree(ctx, t.registry.AdminStorage, logID, trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true})
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	resp, err := t.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: logID})
+	if err != nil {
+		return nil, err
+	}
+	root := resp.SignedLogRoot
+	if root.RootHash == nil {
+		return nil, status.Errorf(codes.NotFound, "log %v has no signed root yet", logID)
+	}
+
+	origin := checkpointOrigin(tree)
+	text := t.checkpoints.get(logID, root.TreeRevision, origin)
+	if text == nil {
+		signer, err := checkpointSigner(ctx, t.registry.SignerFactory, tree, root)
+		if err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "failed to create signer for log %v: %v", logID, err)
+		}
+		if text, err = signCheckpoint(origin, root, signer); err != nil {
+			return nil, err
+		}
+		t.checkpoints.put(logID, checkpoint{revision: root.TreeRevision, origin: origin, text: text})
+	}
+	return &trillian.GetLatestCheckpointResponse{Checkpoint: text, SignedLogRoot: root}, nil
+}
+
+// checkpointSigner returns the signer of the checkpoint of root: the key rotation's signer if root
+// is past the rotation's activation size, so that checkpoints and roots use the same key.
+func checkpointSigner(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree, root *trillian.SignedLogRoot) (*tcrypto.Signer, error) {
+	if kr := tree.KeyRotation; kr != nil && root.TreeSize >= kr.ActivationTreeSize {
+		return trees.RotationSigner(ctx, sf, tree)
+	}
+	return trees.Signer(ctx, sf, tree)
+}
+
+// checkpointOrigin returns the origin line of the checkpoints of tree.
+func checkpointOrigin(tree *trillian.Tree) string {
+	if tree.CheckpointOrigin != "" {
+		return tree.CheckpointOrigin
+	}
+	return strconv.FormatInt(tree.TreeId, 10)
+}
+
+// checkpointBody returns the signed part of the checkpoint of root.
+func checkpointBody(origin string, root *trillian.SignedLogRoot) []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n", origin, root.TreeSize, base64.StdEncoding.EncodeToString(root.RootHash)))
+}
+
+// signCheckpoint renders the checkpoint of root and signs it with signer. The signature line
+// names the key by the origin and is the base64 encoding of a 4 byte key hint, the first bytes
+// of the SHA-256 hash of the DER public key, followed by the signature of the body.
+func signCheckpoint(origin string, root *trillian.SignedLogRoot, signer *tcrypto.Signer) ([]byte, error) {
+	body := checkpointBody(origin, root)
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %v", err)
+	}
+	hint := sha256.Sum256(der)
+	sig, err := signer.Sign(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign checkpoint: %v", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(body)
+	fmt.Fprintf(&buf, "\n— %s %s\n", origin, base64.StdEncoding.EncodeToString(append(hint[:4], sig.Signature...)))
+	return buf.Bytes(), nil
+}
+
+// checkpointGetter is the part of trillian.TrillianLogServer needed by CheckpointHandler.
+type checkpointGetter interface {
+	GetLatestCheckpoint(context.Context, *trillian.GetLatestCheckpointRequest) (*trillian.GetLatestCheckpointResponse, error)
+}
+
+// CheckpointHandler returns an HTTP handler serving the latest checkpoint of a log as plain
+// text, at /checkpoint/<log ID>.
+func CheckpointHandler(s checkpointGetter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logID, err := strconv.ParseInt(strings.TrimPrefix(req.URL.Path, checkpointPrefix), 10, 64)
+		if !strings.HasPrefix(req.URL.Path, checkpointPrefix) || err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		resp, err := s.GetLatestCheckpoint(req.Context(), &trillian.GetLatestCheckpointRequest{LogId: logID})
+		if err != nil {
+			code := http.StatusInternalServerError
+			switch status.Code(err) {
+			case codes.NotFound:
+				code = http.StatusNotFound
+			case codes.InvalidArgument:
+				code = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(resp.Checkpoint)
+	})
+}
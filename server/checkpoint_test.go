@@ -0,0 +1,184 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	tcrypto "github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/storage"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/testonly"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newCheckpointServer(ctrl *gomock.Controller, tree *trillian.Tree, root trillian.SignedLogRoot) *TrillianLogRPCServer {
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	adminStorage.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).AnyTimes().Return(tree, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), tree.TreeId).AnyTimes().Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).AnyTimes().Return(root, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage:  adminStorage,
+		LogStorage:    mockStorage,
+		SignerFactory: &keys.DefaultSignerFactory{},
+	}
+	return NewTrillianLogRPCServer(registry, fakeTimeSource)
+}
+
+func TestGetLatestCheckpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pub, err := keys.NewFromPublicPEM(testonly.DemoPublicKey)
+	if err != nil {
+		t.Fatalf("NewFromPublicPEM() = %v", err)
+	}
+	hashB64 := base64.StdEncoding.EncodeToString(signedRoot1.RootHash)
+
+	for _, test := range []struct {
+		desc, origin, wantOrigin string
+	}{
+		{desc: "origin", origin: "example.com/log", wantOrigin: "example.com/log"},
+		{desc: "defaultOrigin", wantOrigin: fmt.Sprint(logID1)},
+	} {
+		tree := *stestonly.LogTree
+		tree.TreeId = logID1
+		tree.CheckpointOrigin = test.origin
+		server := newCheckpointServer(ctrl, &tree, signedRoot1)
+
+		req := &trillian.GetLatestCheckpointRequest{LogId: logID1}
+		resp, err := server.GetLatestCheckpoint(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%v: GetLatestCheckpoint() = (_, %v), want (_, nil)", test.desc, err)
+		}
+
+		parts := strings.SplitN(string(resp.Checkpoint), "\n\n", 2)
+		if len(parts) != 2 {
+			t.Fatalf("%v: checkpoint %q has no blank line", test.desc, resp.Checkpoint)
+		}
+		body := parts[0] + "\n"
+		if want := fmt.Sprintf("%s\n7\n%s\n", test.wantOrigin, hashB64); body != want {
+			t.Errorf("%v: checkpoint body = %q, want %q", test.desc, body, want)
+		}
+		sigPrefix := "— " + test.wantOrigin + " "
+		if !strings.HasPrefix(parts[1], sigPrefix) || !strings.HasSuffix(parts[1], "\n") {
+			t.Fatalf("%v: signature line = %q, want %q prefix", test.desc, parts[1], sigPrefix)
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(parts[1], sigPrefix), "\n"))
+		if err != nil || len(sig) < 4 {
+			t.Fatalf("%v: bad signature line %q: %v", test.desc, parts[1], err)
+		}
+		ds := &sigpb.DigitallySigned{
+			SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+			HashAlgorithm:      sigpb.DigitallySigned_SHA256,
+			Signature:          sig[4:],
+		}
+		if err := tcrypto.Verify(pub, []byte(body), ds); err != nil {
+			t.Errorf("%v: checkpoint signature doesn't verify: %v", test.desc, err)
+		}
+
+		// ECDSA signatures are randomized, but the checkpoint of a root must not change.
+		resp2, err := server.GetLatestCheckpoint(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%v: GetLatestCheckpoint() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		if !bytes.Equal(resp2.Checkpoint, resp.Checkpoint) {
+			t.Errorf("%v: second checkpoint = %q, want %q", test.desc, resp2.Checkpoint, resp.Checkpoint)
+		}
+	}
+}
+
+func TestGetLatestCheckpointNoRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := *stestonly.LogTree
+	tree.TreeId = logID1
+	server := newCheckpointServer(ctrl, &tree, trillian.SignedLogRoot{})
+	_, err := server.GetLatestCheckpoint(context.Background(), &trillian.GetLatestCheckpointRequest{LogId: logID1})
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Errorf("GetLatestCheckpoint() = (_, %v), want (_, code %v)", err, want)
+	}
+}
+
+type fakeCheckpointGetter struct {
+	checkpoint []byte
+	err        error
+}
+
+func (f fakeCheckpointGetter) GetLatestCheckpoint(ctx context.Context, req *trillian.GetLatestCheckpointRequest) (*trillian.GetLatestCheckpointResponse, error) {
+	if req.LogId != logID1 {
+		return nil, status.Errorf(codes.NotFound, "log %v not found", req.LogId)
+	}
+	return &trillian.GetLatestCheckpointResponse{Checkpoint: f.checkpoint}, f.err
+}
+
+func TestCheckpointHandler(t *testing.T) {
+	checkpoint := []byte("origin\n7\nQQ==\n\n— origin c2ln\n")
+	for _, test := range []struct {
+		desc     string
+		path     string
+		err      error
+		wantCode int
+		wantBody []byte
+	}{
+		{desc: "ok", path: fmt.Sprintf("/checkpoint/%d", logID1), wantCode: http.StatusOK, wantBody: checkpoint},
+		{desc: "unknownLog", path: "/checkpoint/12345", wantCode: http.StatusNotFound},
+		{desc: "badLogID", path: "/checkpoint/llama", wantCode: http.StatusNotFound},
+		{desc: "otherPath", path: fmt.Sprintf("/roots/%d", logID1), wantCode: http.StatusNotFound},
+		{desc: "error", path: fmt.Sprintf("/checkpoint/%d", logID1), err: status.Errorf(codes.Unavailable, "storage down"), wantCode: http.StatusInternalServerError},
+	} {
+		h := CheckpointHandler(fakeCheckpointGetter{checkpoint: checkpoint, err: test.err})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if got := w.Code; got != test.wantCode {
+			t.Errorf("%v: GET %v returned status %v, want %v", test.desc, test.path, got, test.wantCode)
+		}
+		if test.wantBody == nil {
+			continue
+		}
+		body, _ := ioutil.ReadAll(w.Body)
+		if !bytes.Equal(body, test.wantBody) {
+			t.Errorf("%v: GET %v returned %q, want %q", test.desc, test.path, body, test.wantBody)
+		}
+		if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+			t.Errorf("%v: GET %v returned Content-Type %q, want %q", test.desc, test.path, got, want)
+		}
+	}
+}
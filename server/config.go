@@ -0,0 +1,250 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	durpb "github.com/golang/protobuf/ptypes/duration"
+	"github.com/google/trillian/server/configpb"
+	"github.com/google/trillian/storage/factory"
+)
+
+// ParseConfigFile reads the ServerConfig in proto text format at path, validates it, and sets
+// the flags that its settings correspond to. Settings of flags the binary doesn't have are
+// ignored. As with cmd.ParseFlagFile, flag.Parse() is called again afterwards, so that flags
+// given on the command line take precedence over the file.
+func ParseConfigFile(path string) error {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	cfg, err := parseConfig(string(text))
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if err := applyConfig(cfg, flag.CommandLine); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	flag.Parse()
+	return nil
+}
+
+// parseConfig parses and validates a ServerConfig in proto text format.
+func parseConfig(text string) (*configpb.ServerConfig, error) {
+	var cfg configpb.ServerConfig
+	if err := proto.UnmarshalText(text, &cfg); err != nil {
+		return nil, err
+	}
+	if _, err := configFlags(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyConfig sets the flags of fs that the settings of cfg correspond to.
+func applyConfig(cfg *configpb.ServerConfig, fs *flag.FlagSet) error {
+	flags, err := configFlags(cfg)
+	if err != nil {
+		return err
+	}
+	for _, f := range flags {
+		if fs.Lookup(f.flag) == nil {
+			// Not a flag of this binary.
+			continue
+		}
+		if err := fs.Set(f.flag, f.value); err != nil {
+			return fmt.Errorf("%v: invalid value %q for --%v: %v", f.field, f.value, f.flag, err)
+		}
+	}
+	return nil
+}
+
+// configFlag is a setting of a ServerConfig, and the flag it corresponds to.
+type configFlag struct {
+	field, flag, value string
+}
+
+// configBuilder collects the configFlags of a ServerConfig, and the first error found in it.
+type configBuilder struct {
+	flags []configFlag
+	err   error
+}
+
+func (b *configBuilder) fail(field, format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = fmt.Errorf("%v: %v", field, fmt.Sprintf(format, args...))
+	}
+}
+
+func (b *configBuilder) str(field, flag, value string) {
+	if value != "" {
+		b.flags = append(b.flags, configFlag{field: field, flag: flag, value: value})
+	}
+}
+
+func (b *configBuilder) endpoint(field, flag, value string) {
+	if value == "" {
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		b.fail(field, "%q isn't a host:port endpoint: %v", value, err)
+	}
+	b.str(field, flag, value)
+}
+
+func (b *configBuilder) num(field, flag string, value int64) {
+	if value < 0 {
+		b.fail(field, "must not be negative, got %v", value)
+	}
+	if value != 0 {
+		b.str(field, flag, strconv.FormatInt(value, 10))
+	}
+}
+
+func (b *configBuilder) boolean(field, flag string, value bool) {
+	if value {
+		b.str(field, flag, "true")
+	}
+}
+
+func (b *configBuilder) duration(field, flag string, value *durpb.Duration) {
+	if value == nil {
+		return
+	}
+	d, err := ptypes.Duration(value)
+	if err != nil {
+		b.fail(field, "%v", err)
+		return
+	}
+	if d < 0 {
+		b.fail(field, "must not be negative, got %v", d)
+	}
+	b.str(field, flag, d.String())
+}
+
+// configFlags returns the flags that the settings of cfg correspond to. It fails if any
+// setting is invalid.
+func configFlags(cfg *configpb.ServerConfig) ([]configFlag, error) {
+	b := &configBuilder{}
+
+	if s := cfg.Storage; s != nil {
+		if s.System != "" {
+			known := false
+			for _, system := range factory.Systems() {
+				known = known || system == s.System
+			}
+			if !known {
+				b.fail("storage.system", "unknown storage system %q, want one of: %v", s.System, strings.Join(factory.Systems(), ", "))
+			}
+		}
+		b.str("storage.system", "storage_system", s.System)
+		b.str("storage.uri", "storage_uri", s.Uri)
+		if m := s.Mysql; m != nil {
+			b.str("storage.mysql.isolation_level", "mysql_isolation_level", m.IsolationLevel)
+			b.str("storage.mysql.readonly_isolation_level", "mysql_readonly_isolation_level", m.ReadonlyIsolationLevel)
+			b.str("storage.mysql.extra_data_codec", "mysql_extra_data_codec", m.ExtraDataCodec)
+		}
+	}
+
+	if e := cfg.Endpoints; e != nil {
+		b.endpoint("endpoints.rpc", "rpc_endpoint", e.Rpc)
+		b.endpoint("endpoints.http", "http_endpoint", e.Http)
+		b.str("endpoints.unix_socket", "listen_unix_socket", e.UnixSocket)
+		b.boolean("endpoints.single_port", "single_port", e.SinglePort)
+		b.endpoint("endpoints.health_rpc", "health_rpc_endpoint", e.HealthRpc)
+		if e.SinglePort && e.UnixSocket != "" {
+			b.fail("endpoints.single_port", "can't be combined with endpoints.unix_socket")
+		}
+		if t := e.Tls; t != nil {
+			if (t.CertFile == "") != (t.KeyFile == "") {
+				b.fail("endpoints.tls", "cert_file and key_file must be set together")
+			}
+			b.str("endpoints.tls.cert_file", "tls_cert_file", t.CertFile)
+			b.str("endpoints.tls.key_file", "tls_key_file", t.KeyFile)
+			b.str("endpoints.tls.client_ca_file", "tls_client_ca_file", t.ClientCaFile)
+			b.str("endpoints.tls.min_version", "tls_min_version", t.MinVersion)
+		}
+		if et := e.Etcd; et != nil {
+			b.str("endpoints.etcd.servers", "etcd_servers", et.Servers)
+			b.str("endpoints.etcd.service", "etcd_service", et.Service)
+			b.str("endpoints.etcd.http_service", "etcd_http_service", et.HttpService)
+		}
+	}
+
+	if q := cfg.Quota; q != nil {
+		b.num("quota.max_unsequenced_rows", "max_unsequenced_rows", q.MaxUnsequencedRows)
+		b.boolean("quota.fail_open", "quota_fail_open", q.FailOpen)
+		b.duration("quota.config_refresh_interval", "quota_config_refresh_interval", q.ConfigRefreshInterval)
+	}
+
+	if k := cfg.Keys; k != nil {
+		b.str("keys.pkcs11_module_path", "pkcs11_module_path", k.Pkcs11ModulePath)
+		b.num("keys.breaker_failures", "signer_breaker_failures", int64(k.BreakerFailures))
+		b.duration("keys.breaker_cooldown", "signer_breaker_cooldown", k.BreakerCooldown)
+		b.num("keys.reconnect_attempts", "signer_reconnect_attempts", int64(k.ReconnectAttempts))
+		b.duration("keys.reconnect_backoff", "signer_reconnect_backoff", k.ReconnectBackoff)
+		b.duration("keys.max_reconnect_backoff", "signer_max_reconnect_backoff", k.MaxReconnectBackoff)
+	}
+
+	if s := cfg.Sequencing; s != nil {
+		b.duration("sequencing.interval", "sequencer_interval", s.Interval)
+		b.num("sequencing.batch_size", "batch_size", int64(s.BatchSize))
+		b.num("sequencing.num_sequencers", "num_sequencers", int64(s.NumSequencers))
+		b.num("sequencing.hash_workers", "sequencer_hash_workers", int64(s.HashWorkers))
+		if s.ConsistencyCheck != nil {
+			b.str("sequencing.consistency_check", "sequencer_consistency_check", strconv.FormatBool(s.ConsistencyCheck.Value))
+		}
+		b.boolean("sequencing.adaptive_batch_size", "adaptive_batch_size", s.AdaptiveBatchSize)
+		b.num("sequencing.max_batch_size", "max_batch_size", int64(s.MaxBatchSize))
+		b.duration("sequencing.guard_window", "sequencer_guard_window", s.GuardWindow)
+		b.num("sequencing.shard_index", "shard_index", int64(s.ShardIndex))
+		b.num("sequencing.shard_count", "shard_count", int64(s.ShardCount))
+		if s.ShardIndex > 0 && s.ShardIndex >= s.ShardCount {
+			b.fail("sequencing.shard_index", "must be below sequencing.shard_count (%v), got %v", s.ShardCount, s.ShardIndex)
+		}
+		b.boolean("sequencing.skip_clean_logs", "skip_clean_logs", s.SkipCleanLogs)
+	}
+
+	if e := cfg.Election; e != nil {
+		b.boolean("election.force_master", "force_master", e.ForceMaster)
+		b.str("election.lock_file_path", "lock_file_path", e.LockFilePath)
+		b.duration("election.lock_ttl", "etcd_lock_ttl", e.LockTtl)
+		b.duration("election.pre_election_pause", "pre_election_pause", e.PreElectionPause)
+		b.duration("election.master_check_interval", "master_check_interval", e.MasterCheckInterval)
+		b.duration("election.master_hold_interval", "master_hold_interval", e.MasterHoldInterval)
+		b.num("election.resign_odds", "resign_odds", int64(e.ResignOdds))
+	}
+
+	b.duration("drain_timeout", "drain_timeout", cfg.DrainTimeout)
+	if cfg.HandlerDeadlineFraction < 0 || cfg.HandlerDeadlineFraction > 1 {
+		b.fail("handler_deadline_fraction", "must be in [0, 1], got %v", cfg.HandlerDeadlineFraction)
+	}
+	if cfg.HandlerDeadlineFraction != 0 {
+		b.str("handler_deadline_fraction", "handler_deadline_fraction", strconv.FormatFloat(cfg.HandlerDeadlineFraction, 'g', -1, 64))
+	}
+	b.duration("max_handler_deadline", "max_handler_deadline", cfg.MaxHandlerDeadline)
+
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.flags, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage/factory"
+)
+
+func init() {
+	// testdata/server_config.textproto uses MySQL, which isn't linked into these tests.
+	for _, system := range factory.Systems() {
+		if system == "mysql" {
+			return
+		}
+	}
+	factory.Register("mysql", func(string, monitoring.MetricFactory) (factory.Provider, error) {
+		return nil, nil
+	})
+}
+
+func TestParseConfigExample(t *testing.T) {
+	text, err := ioutil.ReadFile("../testdata/server_config.textproto")
+	if err != nil {
+		t.Fatalf("ReadFile()=_,%v", err)
+	}
+	cfg, err := parseConfig(string(text))
+	if err != nil {
+		t.Fatalf("parseConfig()=_,%v, want: _,nil", err)
+	}
+
+	// A log server's subset of the flags.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	storageURI := fs.String("storage_uri", "", "")
+	rpcEndpoint := fs.String("rpc_endpoint", "localhost:8090", "")
+	maxUnsequencedRows := fs.Int("max_unsequenced_rows", 500000, "")
+	quotaFailOpen := fs.Bool("quota_fail_open", false, "")
+	drainTimeout := fs.Duration("drain_timeout", time.Minute, "")
+	maxHandlerDeadline := fs.Duration("max_handler_deadline", 0, "")
+	consistencyCheck := fs.Bool("sequencer_consistency_check", true, "")
+	if err := applyConfig(cfg, fs); err != nil {
+		t.Fatalf("applyConfig()=%v, want: nil", err)
+	}
+
+	if got, want := *storageURI, "test:zaphod@tcp(127.0.0.1:3306)/test"; got != want {
+		t.Errorf("--storage_uri=%q, want %q", got, want)
+	}
+	if got, want := *rpcEndpoint, "localhost:8090"; got != want {
+		t.Errorf("--rpc_endpoint=%q, want %q", got, want)
+	}
+	if got, want := *maxUnsequencedRows, 100000; got != want {
+		t.Errorf("--max_unsequenced_rows=%v, want %v", got, want)
+	}
+	if *quotaFailOpen {
+		t.Error("--quota_fail_open=true, want false")
+	}
+	if got, want := *drainTimeout, 30*time.Second; got != want {
+		t.Errorf("--drain_timeout=%v, want %v", got, want)
+	}
+	if got, want := *maxHandlerDeadline, 5*time.Second; got != want {
+		t.Errorf("--max_handler_deadline=%v, want %v", got, want)
+	}
+	if *consistencyCheck {
+		t.Error("--sequencer_consistency_check=true, want false")
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		text    string
+		wantErr string
+	}{
+		{text: `storage { sytem: "mysql" }`, wantErr: "sytem"},
+		{text: `storage { system: "cassandra" }`, wantErr: "storage.system"},
+		{text: `endpoints { rpc: "8090" }`, wantErr: "endpoints.rpc"},
+		{text: `endpoints { unix_socket: "rpc.sock" single_port: true }`, wantErr: "endpoints.single_port"},
+		{text: `endpoints { tls { cert_file: "cert.pem" } }`, wantErr: "endpoints.tls"},
+		{text: `quota { max_unsequenced_rows: -1 }`, wantErr: "quota.max_unsequenced_rows"},
+		{text: `sequencing { batch_size: -10 }`, wantErr: "sequencing.batch_size"},
+		{text: `sequencing { shard_index: 2 shard_count: 2 }`, wantErr: "sequencing.shard_index"},
+		{text: `election { lock_ttl { seconds: -1 } }`, wantErr: "election.lock_ttl"},
+		{text: `handler_deadline_fraction: 1.5`, wantErr: "handler_deadline_fraction"},
+	} {
+		if _, err := parseConfig(test.text); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("parseConfig(%q)=_,%v, want err containing %q", test.text, err, test.wantErr)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	cfg, err := parseConfig(`sequencing { batch_size: 100 } election { resign_odds: 5 }`)
+	if err != nil {
+		t.Fatalf("parseConfig()=_,%v, want: _,nil", err)
+	}
+
+	// Settings of flags the binary doesn't have are ignored.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	batchSize := fs.Int("batch_size", 50, "")
+	if err := applyConfig(cfg, fs); err != nil {
+		t.Fatalf("applyConfig()=%v, want: nil", err)
+	}
+	if got, want := *batchSize, 100; got != want {
+		t.Errorf("--batch_size=%v, want %v", got, want)
+	}
+
+	// Values the flag doesn't accept are reported against their setting.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Duration("batch_size", 0, "")
+	if err := applyConfig(cfg, fs); err == nil || !strings.Contains(err.Error(), "sequencing.batch_size") {
+		t.Errorf("applyConfig()=%v, want err containing %q", err, "sequencing.batch_size")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// DedupPruner periodically prunes the dedup index of logs that have a DedupWindow,
+// reclaiming the space taken by the identity hashes of leaves outside the window.
+// Whether a leaf is a duplicate doesn't depend on when the index is pruned, as
+// QueueLeaves checks the window itself.
+type DedupPruner struct {
+	registry   extension.Registry
+	timeSource util.TimeSource
+	interval   time.Duration
+	pruned     monitoring.Counter
+}
+
+// NewDedupPruner creates a DedupPruner that prunes every interval.
+func NewDedupPruner(registry extension.Registry, timeSource util.TimeSource, interval time.Duration) *DedupPruner {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &DedupPruner{
+		registry:   registry,
+		timeSource: timeSource,
+		interval:   interval,
+		pruned:     mf.NewCounter("pruned_dedup_entries", "Number of leaf identity hashes removed from dedup indexes by dedup windows"),
+	}
+}
+
+// Run prunes dedup indexes until ctx is done.
+func (p *DedupPruner) Run(ctx context.Context) {
+	runPeriodically(ctx, p.interval, "prune dedup indexes", p.Prune)
+}
+
+// Prune prunes the dedup index of every log with a DedupWindow once.
+func (p *DedupPruner) Prune(ctx context.Context) error {
+	if p.registry.LogStorage == nil {
+		return nil
+	}
+	trees, err := listTrees(ctx, p.registry.AdminStorage)
+	if err != nil {
+		return err
+	}
+	now := p.timeSource.Now()
+	for _, tree := range trees {
+		if tree.TreeType != trillian.TreeType_LOG || tree.DedupWindow == nil {
+			continue
+		}
+		if tree.TreeState == trillian.TreeState_SOFT_DELETED || tree.TreeState == trillian.TreeState_HARD_DELETED {
+			continue
+		}
+		n, err := p.pruneTree(ctx, tree, now)
+		if err != nil {
+			glog.Warningf("%v: failed to prune dedup index: %v", tree.TreeId, err)
+			continue
+		}
+		if n > 0 {
+			glog.V(1).Infof("%v: pruned %v dedup entries", tree.TreeId, n)
+			p.pruned.Add(float64(n))
+		}
+	}
+	return nil
+}
+
+func (p *DedupPruner) pruneTree(ctx context.Context, tree *trillian.Tree, now time.Time) (int64, error) {
+	tx, err := p.registry.LogStorage.BeginForTree(ctx, tree.TreeId)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	minIndex, minQueueNanos, _, err := storage.DedupWindowLimits(tree.DedupWindow, root.TreeSize, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := tx.PruneDedupIndex(ctx, minIndex, minQueueNanos)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
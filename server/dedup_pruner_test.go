@@ -0,0 +1,86 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+func TestDedupPruner_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1500000000, 0)
+	trees := []*trillian.Tree{
+		{TreeId: 1, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE, DedupWindow: &trillian.DedupWindow{MaxLeaves: 10}},
+		{TreeId: 2, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE}, // No window.
+		{TreeId: 3, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_FROZEN, DedupWindow: &trillian.DedupWindow{MaxAge: ptypes.DurationProto(time.Hour)}},
+		{TreeId: 4, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_SOFT_DELETED, DedupWindow: &trillian.DedupWindow{MaxLeaves: 10}},
+		{TreeId: 5, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE, DedupWindow: &trillian.DedupWindow{MaxLeaves: 10}},
+		{TreeId: 6, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE},
+	}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+	adminTX.EXPECT().ListTrees(gomock.Any()).Return(trees, nil)
+	adminTX.EXPECT().Commit().Return(nil)
+	adminTX.EXPECT().Close().Return(nil)
+
+	ls := storage.NewMockLogStorage(ctrl)
+	tx1 := storage.NewMockLogTreeTX(ctrl)
+	ls.EXPECT().BeginForTree(gomock.Any(), int64(1)).Return(tx1, nil)
+	tx1.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{TreeSize: 100}, nil)
+	// Entries of leaves before the last 10 are pruned, regardless of their age.
+	tx1.EXPECT().PruneDedupIndex(gomock.Any(), int64(90), int64(math.MinInt64)).Return(int64(4), nil)
+	tx1.EXPECT().Commit().Return(nil)
+	tx1.EXPECT().Close().Return(nil)
+
+	tx3 := storage.NewMockLogTreeTX(ctrl)
+	ls.EXPECT().BeginForTree(gomock.Any(), int64(3)).Return(tx3, nil)
+	tx3.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{TreeSize: 100}, nil)
+	// Entries of leaves queued over an hour ago are pruned, whatever their index.
+	tx3.EXPECT().PruneDedupIndex(gomock.Any(), int64(0), now.Add(-time.Hour).UnixNano()).Return(int64(2), nil)
+	tx3.EXPECT().Commit().Return(nil)
+	tx3.EXPECT().Close().Return(nil)
+
+	// Failing to prune one tree mustn't stop the others from being pruned.
+	ls.EXPECT().BeginForTree(gomock.Any(), int64(5)).Return(nil, errors.New("begin failed"))
+
+	registry := extension.Registry{
+		AdminStorage:  as,
+		LogStorage:    ls,
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	p := NewDedupPruner(registry, util.NewFakeTimeSource(now), time.Hour)
+	if err := p.Prune(context.Background()); err != nil {
+		t.Fatalf("Prune() returned err = %v", err)
+	}
+	if got, want := p.pruned.Value(), 6.0; got != want {
+		t.Errorf("pruned dedup entries = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// DeletedTreeGC periodically hard-deletes the trees that have been SOFT_DELETED for longer
+// than a retention period, permanently removing their data from storage. Until then, deleted
+// trees may be undeleted.
+type DeletedTreeGC struct {
+	admin      storage.AdminStorage
+	timeSource util.TimeSource
+	retention  time.Duration
+	interval   time.Duration
+
+	pending     monitoring.Gauge
+	hardDeleted monitoring.Counter
+	failures    monitoring.Counter
+}
+
+// NewDeletedTreeGC creates a DeletedTreeGC that looks for trees to hard-delete every interval.
+func NewDeletedTreeGC(admin storage.AdminStorage, timeSource util.TimeSource, retention, interval time.Duration, mf monitoring.MetricFactory) *DeletedTreeGC {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &DeletedTreeGC{
+		admin:       admin,
+		timeSource:  timeSource,
+		retention:   retention,
+		interval:    interval,
+		pending:     mf.NewGauge("pending_tree_deletions", "Number of SOFT_DELETED trees still within their retention period, as of the latest deleted tree GC pass"),
+		hardDeleted: mf.NewCounter("hard_deleted_trees", "Number of trees hard-deleted by the deleted tree GC"),
+		failures:    mf.NewCounter("hard_delete_failures", "Number of failed attempts to hard-delete a tree"),
+	}
+}
+
+// Run hard-deletes trees until ctx is done.
+func (g *DeletedTreeGC) Run(ctx context.Context) {
+	runPeriodically(ctx, g.interval, "collect deleted trees", func(ctx context.Context) error {
+		_, err := g.RunOnce(ctx)
+		return err
+	})
+}
+
+// RunOnce hard-deletes every SOFT_DELETED tree past its retention period, returning the number
+// of trees deleted.
+func (g *DeletedTreeGC) RunOnce(ctx context.Context) (int, error) {
+	trees, err := listTrees(ctx, g.admin)
+	if err != nil {
+		return 0, err
+	}
+	now := g.timeSource.Now()
+	deleted, pending := 0, 0
+	for _, tree := range trees {
+		if tree.TreeState != trillian.TreeState_SOFT_DELETED {
+			continue
+		}
+		deleteTime, err := ptypes.Timestamp(tree.DeleteTime)
+		if err != nil {
+			glog.Warningf("%v: invalid delete_time, not hard-deleting: %v", tree.TreeId, err)
+			pending++
+			continue
+		}
+		if now.Sub(deleteTime) < g.retention {
+			pending++
+			continue
+		}
+		if err := g.hardDelete(ctx, tree.TreeId); err != nil {
+			glog.Warningf("%v: failed to hard-delete tree: %v", tree.TreeId, err)
+			g.failures.Inc()
+			pending++
+			continue
+		}
+		glog.Infof("%v: hard-deleted tree, soft-deleted at %v", tree.TreeId, deleteTime)
+		g.hardDeleted.Inc()
+		deleted++
+	}
+	g.pending.Set(float64(pending))
+	return deleted, nil
+}
+
+func (g *DeletedTreeGC) hardDelete(ctx context.Context, treeID int64) error {
+	tx, err := g.admin.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if err := tx.HardDeleteTree(ctx, treeID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
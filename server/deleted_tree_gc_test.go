@@ -0,0 +1,102 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+func TestDeletedTreeGC_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1500000000, 0)
+	retention := 24 * time.Hour
+	deletedAt := func(d time.Duration) *trillian.Tree {
+		ts, err := ptypes.TimestampProto(now.Add(-d))
+		if err != nil {
+			t.Fatalf("TimestampProto() returned err = %v", err)
+		}
+		return &trillian.Tree{TreeState: trillian.TreeState_SOFT_DELETED, DeleteTime: ts}
+	}
+	expired, recent, failing := deletedAt(retention), deletedAt(time.Hour), deletedAt(2*retention)
+	expired.TreeId, recent.TreeId, failing.TreeId = 2, 3, 5
+	trees := []*trillian.Tree{
+		{TreeId: 1, TreeState: trillian.TreeState_ACTIVE},
+		expired,
+		recent,
+		{TreeId: 4, TreeState: trillian.TreeState_HARD_DELETED},
+		failing,
+	}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	snapshot := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(snapshot, nil)
+	snapshot.EXPECT().ListTrees(gomock.Any()).Return(trees, nil)
+	snapshot.EXPECT().Commit().Return(nil)
+	snapshot.EXPECT().Close().Return(nil)
+
+	tx2 := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Begin(gomock.Any()).Return(tx2, nil)
+	tx2.EXPECT().HardDeleteTree(gomock.Any(), int64(2)).Return(nil)
+	tx2.EXPECT().Commit().Return(nil)
+	tx2.EXPECT().Close().Return(nil)
+
+	// Failing to delete one tree mustn't stop the others from being deleted.
+	tx5 := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Begin(gomock.Any()).Return(tx5, nil)
+	tx5.EXPECT().HardDeleteTree(gomock.Any(), int64(5)).Return(errors.New("delete failed"))
+	tx5.EXPECT().Close().Return(nil)
+
+	gc := NewDeletedTreeGC(as, util.NewFakeTimeSource(now), retention, time.Hour, monitoring.InertMetricFactory{})
+	n, err := gc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() returned err = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("RunOnce() = %v, want 1", n)
+	}
+	if got, want := gc.pending.Value(), 2.0; got != want {
+		t.Errorf("pending tree deletions = %v, want %v", got, want)
+	}
+	if got, want := gc.hardDeleted.Value(), 1.0; got != want {
+		t.Errorf("hard-deleted trees = %v, want %v", got, want)
+	}
+	if got, want := gc.failures.Value(), 1.0; got != want {
+		t.Errorf("hard delete failures = %v, want %v", got, want)
+	}
+}
+
+func TestDeletedTreeGC_ListError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	as := storage.NewMockAdminStorage(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(nil, errors.New("snapshot failed"))
+
+	gc := NewDeletedTreeGC(as, util.SystemTimeSource{}, time.Hour, time.Hour, nil)
+	if _, err := gc.RunOnce(context.Background()); err == nil {
+		t.Error("RunOnce() returned err = nil, want non-nil")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Names of the health checks of the backends servers depend on.
+const (
+	StorageHealthCheck  = "storage"
+	ElectionHealthCheck = "election"
+)
+
+// HealthCheck is a check of one of the backends a server depends on, e.g. its database.
+type HealthCheck struct {
+	// Name is the service name the backend's own status is reported under.
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthChecker serves the standard gRPC health checking protocol (grpc.health.v1.Health).
+// It runs its checks every interval. The status of each check is reported under the check's
+// name, and the status of the server as a whole (the empty service name) and of each of its
+// services is SERVING only if all of the checks passed.
+type HealthChecker struct {
+	server   *health.Server
+	services []string
+	checks   []HealthCheck
+	interval time.Duration
+	mu       sync.Mutex
+	failures map[string]error
+}
+
+// NewHealthChecker creates a HealthChecker reporting the status of services, which runs
+// checks every interval. Until the checks first run everything is reported NOT_SERVING.
+func NewHealthChecker(services []string, interval time.Duration, checks ...HealthCheck) *HealthChecker {
+	h := &HealthChecker{
+		server:   health.NewServer(),
+		services: append([]string{""}, services...),
+		checks:   checks,
+		interval: interval,
+		failures: make(map[string]error),
+	}
+	for _, s := range h.services {
+		h.server.SetServingStatus(s, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	for _, c := range checks {
+		h.server.SetServingStatus(c.Name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	return h
+}
+
+// Register registers the health service with s.
+func (h *HealthChecker) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.server)
+}
+
+// Run runs the checks until ctx is done, after which everything is reported NOT_SERVING.
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.Check(ctx)
+		select {
+		case <-ctx.Done():
+			h.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports everything NOT_SERVING from now on, e.g. so that load balancers stop
+// sending requests to a server that's about to stop.
+func (h *HealthChecker) Shutdown() {
+	h.server.Shutdown()
+}
+
+// Check runs the checks once, and updates the statuses reported accordingly. Each check gets
+// at most one interval to complete.
+func (h *HealthChecker) Check(ctx context.Context) {
+	healthy := true
+	for _, c := range h.checks {
+		cctx, cancel := context.WithTimeout(ctx, h.interval)
+		err := c.Check(cctx)
+		cancel()
+		h.setStatus(c.Name, err)
+		if err != nil {
+			healthy = false
+		}
+	}
+	status := healthpb.HealthCheckResponse_SERVING
+	if !healthy {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, s := range h.services {
+		h.server.SetServingStatus(s, status)
+	}
+}
+
+func (h *HealthChecker) setStatus(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Only changes are logged, so a persistent failure doesn't flood the log.
+	if prev := h.failures[name]; (prev == nil) != (err == nil) {
+		if err != nil {
+			glog.Warningf("Health check %v failed: %v", name, err)
+		} else {
+			glog.Infof("Health check %v passed", name)
+		}
+	}
+	h.failures[name] = err
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	h.server.SetServingStatus(name, status)
+}
+
+// serviceNames returns the names of the services registered with s, in order.
+func serviceNames(s *grpc.Server) []string {
+	var names []string
+	for name := range s.GetServiceInfo() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	var electionErr error
+	hc := NewHealthChecker([]string{"trillian.TrillianLog"}, time.Second,
+		HealthCheck{Name: StorageHealthCheck, Check: func(context.Context) error { return nil }},
+		HealthCheck{Name: ElectionHealthCheck, Check: func(context.Context) error { return electionErr }})
+
+	const (
+		serving    = healthpb.HealthCheckResponse_SERVING
+		notServing = healthpb.HealthCheckResponse_NOT_SERVING
+	)
+	for _, test := range []struct {
+		desc        string
+		check       bool
+		electionErr error
+		want        map[string]healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			desc: "notChecked",
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"": notServing, "trillian.TrillianLog": notServing, StorageHealthCheck: notServing, ElectionHealthCheck: notServing,
+			},
+		},
+		{
+			desc:  "healthy",
+			check: true,
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"": serving, "trillian.TrillianLog": serving, StorageHealthCheck: serving, ElectionHealthCheck: serving,
+			},
+		},
+		{
+			desc:        "electionFailed",
+			check:       true,
+			electionErr: errors.New("etcd unreachable"),
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"": notServing, "trillian.TrillianLog": notServing, StorageHealthCheck: serving, ElectionHealthCheck: notServing,
+			},
+		},
+		{
+			desc:  "recovered",
+			check: true,
+			want: map[string]healthpb.HealthCheckResponse_ServingStatus{
+				"": serving, "trillian.TrillianLog": serving, StorageHealthCheck: serving, ElectionHealthCheck: serving,
+			},
+		},
+	} {
+		electionErr = test.electionErr
+		if test.check {
+			hc.Check(ctx)
+		}
+		for service, want := range test.want {
+			rsp, err := hc.server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Errorf("%v: Check(%q) returned err = %v", test.desc, service, err)
+				continue
+			}
+			if got := rsp.Status; got != want {
+				t.Errorf("%v: Check(%q) = %v, want %v", test.desc, service, got, want)
+			}
+		}
+	}
+}
+
+func TestHealthCheckerRunShutsDown(t *testing.T) {
+	hc := NewHealthChecker(nil, time.Hour, HealthCheck{Name: StorageHealthCheck, Check: func(context.Context) error { return nil }})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hc.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	rsp, err := hc.server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() returned err = %v", err)
+	}
+	if got, want := rsp.Status, healthpb.HealthCheckResponse_NOT_SERVING; got != want {
+		t.Errorf("Check() after Run returned = %v, want %v", got, want)
+	}
+}
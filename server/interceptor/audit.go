@@ -0,0 +1,142 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/audit"
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AuditLog records the admin RPCs that change trees to an audit.Sink, before and after each
+// change is made.
+type AuditLog struct {
+	// Sink stores the records.
+	Sink audit.Sink
+	// FailClosed refuses changes that can't be recorded, with Unavailable. Otherwise they're
+	// made regardless. Failures to record the outcome of a change are only reported, as the
+	// change has been made by then.
+	FailClosed bool
+
+	failures monitoring.Counter
+}
+
+// NewAuditLog creates an AuditLog interceptor. Records that can't be written are logged and
+// counted in the "audit_write_failures" metric.
+func NewAuditLog(sink audit.Sink, failClosed bool, mf monitoring.MetricFactory) *AuditLog {
+	return &AuditLog{
+		Sink:       sink,
+		FailClosed: failClosed,
+		failures:   mf.NewCounter("audit_write_failures", "Number of audit records that couldn't be written, by RPC method", "method"),
+	}
+}
+
+// UnaryInterceptor executes the AuditLog logic for unary RPCs.
+func (a *AuditLog) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !isAuditedRequest(req) {
+		return handler(ctx, req)
+	}
+
+	r := newAuditRecord(ctx, info.FullMethod, req)
+	if err := a.write(r); err != nil && a.FailClosed {
+		return nil, status.Errorf(codes.Unavailable, "change refused as it can't be audited: %v", err)
+	}
+
+	rsp, err := handler(ctx, req)
+	done := *r
+	done.Time = time.Now()
+	done.Status = status.Code(err).String()
+	if err != nil {
+		done.Error = err.Error()
+	}
+	a.write(&done)
+	return rsp, err
+}
+
+func (a *AuditLog) write(r *audit.Record) error {
+	err := a.Sink.Write(r)
+	if err != nil {
+		glog.Errorf("Failed to write audit record of %v: %v", r.Method, err)
+		a.failures.Inc(r.Method)
+	}
+	return err
+}
+
+// isAuditedRequest returns true if req is the request of an admin RPC that changes trees.
+func isAuditedRequest(req interface{}) bool {
+	switch req.(type) {
+	case *trillian.BatchUpdateTreesRequest,
+		*trillian.CreateTreeRequest,
+		*trillian.DeleteTreeRequest,
+		*trillian.RepairTreeRootRequest,
+		*trillian.RequeueDeadLetteredLeavesRequest,
+		*trillian.RetireTreeKeyRequest,
+		*trillian.RotateTreeKeyRequest,
+		*trillian.UndeleteTreeRequest,
+		*trillian.UpdateTreeRequest:
+		return true
+	}
+	return false
+}
+
+// newAuditRecord returns the record of a request about to be handled.
+func newAuditRecord(ctx context.Context, method string, req interface{}) *audit.Record {
+	r := &audit.Record{Time: time.Now(), Method: method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.Peer = p.Addr.String()
+	}
+	if msg, ok := req.(proto.Message); ok {
+		js, err := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(redactRequest(msg))
+		if err != nil {
+			glog.Warningf("Failed to marshal %v request for the audit log: %v", method, err)
+		} else {
+			r.Request = []byte(js)
+		}
+	}
+	return r
+}
+
+// redactRequest returns a copy of req without the private keys it holds, if any.
+func redactRequest(req proto.Message) proto.Message {
+	if rr, ok := req.(*trillian.RotateTreeKeyRequest); ok {
+		rr = proto.Clone(rr).(*trillian.RotateTreeKeyRequest)
+		rr.PrivateKey = nil
+		return rr
+	}
+	tr, ok := req.(treeRequest)
+	if !ok || tr.GetTree() == nil {
+		return req
+	}
+	req = proto.Clone(req)
+	tree := req.(treeRequest).GetTree()
+	tree.PrivateKey = nil
+	if tree.SecondarySigner != nil {
+		tree.SecondarySigner.PrivateKey = nil
+	}
+	if tree.KeyRotation != nil {
+		tree.KeyRotation.PrivateKey = nil
+	}
+	return req
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/trillian"
+	"github.com/google/trillian/audit"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSink keeps the records written to it, or fails with err.
+type fakeSink struct {
+	records []*audit.Record
+	err     error
+}
+
+func (s *fakeSink) Write(r *audit.Record) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func TestAuditLog_UnaryInterceptor(t *testing.T) {
+	key, err := ptypes.MarshalAny(&keyspb.PrivateKey{Der: []byte("secret")})
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+	createReq := &trillian.CreateTreeRequest{Tree: &trillian.Tree{TreeType: trillian.TreeType_LOG, PrivateKey: key}}
+	info := &grpc.UnaryServerInfo{FullMethod: "/trillian.TrillianAdmin/CreateTree"}
+	sinkErr := errors.New("sink unavailable")
+	rpcErr := status.Errorf(codes.InvalidArgument, "bad tree")
+
+	tests := []struct {
+		desc       string
+		req        interface{}
+		sinkErr    error
+		failClosed bool
+		handlerErr error
+		// wantCalled is whether the handler is expected to be called.
+		wantCalled  bool
+		wantCode    codes.Code
+		wantRecords int
+		wantStatus  string
+	}{
+		{desc: "notAudited", req: &trillian.GetTreeRequest{TreeId: 1}, wantCalled: true},
+		{desc: "audited", req: createReq, wantCalled: true, wantRecords: 2, wantStatus: "OK"},
+		{desc: "failedChange", req: createReq, handlerErr: rpcErr, wantCalled: true, wantCode: codes.InvalidArgument, wantRecords: 2, wantStatus: "InvalidArgument"},
+		{desc: "failOpen", req: createReq, sinkErr: sinkErr, wantCalled: true},
+		{desc: "failClosed", req: createReq, sinkErr: sinkErr, failClosed: true, wantCode: codes.Unavailable},
+	}
+	for _, test := range tests {
+		sink := &fakeSink{err: test.sinkErr}
+		a := NewAuditLog(sink, test.failClosed, monitoring.InertMetricFactory{})
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			if test.handlerErr != nil {
+				return nil, test.handlerErr
+			}
+			return &empty.Empty{}, nil
+		}
+
+		_, err := a.UnaryInterceptor(context.Background(), test.req, info, handler)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: UnaryInterceptor() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+		if called != test.wantCalled {
+			t.Errorf("%v: handler called = %v, want %v", test.desc, called, test.wantCalled)
+		}
+		if got := len(sink.records); got != test.wantRecords {
+			t.Errorf("%v: wrote %v records, want %v", test.desc, got, test.wantRecords)
+			continue
+		}
+		if test.wantRecords == 0 {
+			continue
+		}
+
+		before, after := sink.records[0], sink.records[1]
+		if before.Status != "" || after.Status != test.wantStatus {
+			t.Errorf("%v: record statuses = %q, %q, want %q, %q", test.desc, before.Status, after.Status, "", test.wantStatus)
+		}
+		if got, want := after.Error != "", test.handlerErr != nil; got != want {
+			t.Errorf("%v: record error = %q, want error: %v", test.desc, after.Error, want)
+		}
+		for _, r := range sink.records {
+			if r.Method != info.FullMethod {
+				t.Errorf("%v: record method = %v, want %v", test.desc, r.Method, info.FullMethod)
+			}
+			var req struct {
+				Tree map[string]interface{} `json:"tree"`
+			}
+			if err := json.Unmarshal(r.Request, &req); err != nil {
+				t.Fatalf("%v: failed to unmarshal recorded request %s: %v", test.desc, r.Request, err)
+			}
+			if req.Tree["tree_type"] != "LOG" {
+				t.Errorf("%v: recorded request %s, want the tree", test.desc, r.Request)
+			}
+			if _, ok := req.Tree["private_key"]; ok {
+				t.Errorf("%v: recorded request %s has the private key", test.desc, r.Request)
+			}
+		}
+	}
+
+	// The request itself isn't redacted.
+	if createReq.Tree.PrivateKey == nil {
+		t.Errorf("CreateTreeRequest private key removed by the audit log")
+	}
+}
+
+func TestRedactRequest_RotateTreeKey(t *testing.T) {
+	key, err := ptypes.MarshalAny(&keyspb.PrivateKey{Der: []byte("secret")})
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+	req := &trillian.RotateTreeKeyRequest{TreeId: 10, PrivateKey: key, ActivationTreeSize: 100}
+	got := redactRequest(req).(*trillian.RotateTreeKeyRequest)
+	if got.PrivateKey != nil {
+		t.Errorf("redactRequest() = %v, want no private key", got)
+	}
+	if got.TreeId != req.TreeId || got.ActivationTreeSize != req.ActivationTreeSize {
+		t.Errorf("redactRequest() = %v, want the rest of %v", got, req)
+	}
+	if req.PrivateKey == nil {
+		t.Errorf("RotateTreeKeyRequest private key removed by redactRequest")
+	}
+}
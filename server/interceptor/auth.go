@@ -0,0 +1,231 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package interceptor
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/quota"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator identifies the principal that made an RPC.
+type Authenticator interface {
+	// Authenticate returns the principal that made the RPC of ctx, or "" if the RPC carries no
+	// credentials the Authenticator understands. Credentials it understands but can't verify
+	// are an error.
+	Authenticate(ctx context.Context) (string, error)
+}
+
+// TokenAuthenticator authenticates RPCs by the bearer token in their "authorization" metadata,
+// which is of the form "Bearer <token>".
+type TokenAuthenticator struct {
+	// principals maps the SHA-256 digest of each token to its principal, so looking up a
+	// token doesn't compare it byte by byte.
+	principals map[[sha256.Size]byte]string
+}
+
+// NewTokenAuthenticator returns a TokenAuthenticator that accepts the given tokens, which map
+// principals to their token.
+func NewTokenAuthenticator(tokens map[string]string) *TokenAuthenticator {
+	principals := make(map[[sha256.Size]byte]string)
+	for principal, token := range tokens {
+		principals[sha256.Sum256([]byte(token))] = principal
+	}
+	return &TokenAuthenticator{principals: principals}
+}
+
+// ReadTokenFile reads the tokens for NewTokenAuthenticator from a file. Each line of the file
+// holds a principal and its token, separated by whitespace. Blank lines and lines starting with
+// '#' are ignored.
+func ReadTokenFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	lineNum := 0
+	for s := bufio.NewScanner(f); s.Scan(); {
+		lineNum++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%v:%v: want <principal> <token>, got %v fields", path, lineNum, len(fields))
+		}
+		if _, ok := tokens[fields[0]]; ok {
+			return nil, fmt.Errorf("%v:%v: duplicate principal: %v", path, lineNum, fields[0])
+		}
+		tokens[fields[0]] = fields[1]
+	}
+	return tokens, nil
+}
+
+// Authenticate implements Authenticator.Authenticate.
+func (a *TokenAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	const scheme = "bearer "
+	for _, value := range md["authorization"] {
+		if len(value) <= len(scheme) || !strings.EqualFold(value[:len(scheme)], scheme) {
+			continue
+		}
+		if principal, ok := a.principals[sha256.Sum256([]byte(value[len(scheme):]))]; ok {
+			return principal, nil
+		}
+		return "", errors.New("unknown bearer token")
+	}
+	return "", nil
+}
+
+// CertAuthenticator authenticates RPCs by the TLS client certificate they're made with, which
+// the server must have verified (see server.NewTLSConfig). The principal is the subject common
+// name of the certificate.
+type CertAuthenticator struct {
+	// GatewayCert is the DER certificate the REST gateway makes RPCs with, i.e. the server's
+	// own. It doesn't identify the caller of the REST request, so RPCs made with it are
+	// left to other Authenticators, e.g. a TokenAuthenticator checking the bearer token the
+	// gateway forwards.
+	GatewayCert []byte
+}
+
+// Authenticate implements Authenticator.Authenticate.
+func (c CertAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", nil
+	}
+	cert := info.State.VerifiedChains[0][0]
+	if len(c.GatewayCert) > 0 && bytes.Equal(cert.Raw, c.GatewayCert) {
+		return "", nil
+	}
+	principal := cert.Subject.CommonName
+	if principal == "" {
+		return "", errors.New("client certificate has no subject common name")
+	}
+	return principal, nil
+}
+
+// MultiAuthenticator tries each of its Authenticators in turn, returning the first principal
+// found.
+type MultiAuthenticator []Authenticator
+
+// Authenticate implements Authenticator.Authenticate.
+func (m MultiAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	for _, a := range m {
+		principal, err := a.Authenticate(ctx)
+		if err != nil || principal != "" {
+			return principal, err
+		}
+	}
+	return "", nil
+}
+
+// Authorizer authorizes RPCs against the access policies of the trees they address, see
+// trillian.AccessPolicy. Admin RPCs, and RPCs that don't address a single tree, may only be
+// made by admins.
+type Authorizer struct {
+	// Authenticator identifies the principals that make RPCs.
+	Authenticator Authenticator
+	// OpenTrees lets any authenticated principal read and write trees without an access
+	// policy. Otherwise only admins may use them.
+	OpenTrees bool
+
+	admins map[string]bool
+}
+
+// NewAuthorizer returns an Authorizer that lets admins make any RPC.
+func NewAuthorizer(authenticator Authenticator, admins []string) *Authorizer {
+	a := &Authorizer{Authenticator: authenticator, admins: make(map[string]bool)}
+	for _, admin := range admins {
+		if admin != "" {
+			a.admins[admin] = true
+		}
+	}
+	return a
+}
+
+// authenticate returns the principal that made the RPC of ctx, or "" if it's unauthenticated.
+func (a *Authorizer) authenticate(ctx context.Context) (string, error) {
+	principal, err := a.Authenticator.Authenticate(ctx)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
+	}
+	return principal, nil
+}
+
+// authorize returns nil if principal may make the RPC described by info, which addresses tree
+// (nil if the RPC doesn't address a single tree). principal is "" if the RPC is
+// unauthenticated.
+func (a *Authorizer) authorize(principal string, info *rpcInfo, tree *trillian.Tree) error {
+	dataRPC := tree != nil && info.kind != quota.Admin
+	readonly := info.opts.Readonly
+	policy := tree.GetAccessPolicy()
+
+	switch {
+	case principal == "":
+		if dataRPC && readonly && policy.GetPublicRead() {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "request requires authentication")
+	case a.admins[principal]:
+		return nil
+	case !dataRPC:
+		return status.Errorf(codes.PermissionDenied, "%v isn't an admin", principal)
+	case policy == nil:
+		if a.OpenTrees {
+			return nil
+		}
+		return status.Errorf(codes.PermissionDenied, "tree %v has no access policy, only admins may use it", tree.TreeId)
+	case containsPrincipal(policy.Owners, principal):
+		return nil
+	case readonly && (policy.PublicRead || containsPrincipal(policy.Readers, principal)):
+		return nil
+	case readonly:
+		return status.Errorf(codes.PermissionDenied, "%v may not read tree %v", principal, tree.TreeId)
+	default:
+		return status.Errorf(codes.PermissionDenied, "%v may not write to tree %v", principal, tree.TreeId)
+	}
+}
+
+func containsPrincipal(principals []string, principal string) bool {
+	for _, p := range principals {
+		if p == principal {
+			return true
+		}
+	}
+	return false
+}
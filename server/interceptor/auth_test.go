@@ -0,0 +1,371 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package interceptor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+func TestReadTokenFile(t *testing.T) {
+	tests := []struct {
+		desc     string
+		contents string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			desc:     "valid",
+			contents: "# Comment\nalice token1\n\n  bob\ttoken2  \n",
+			want:     map[string]string{"alice": "token1", "bob": "token2"},
+		},
+		{
+			desc:     "empty",
+			contents: "",
+			want:     map[string]string{},
+		},
+		{
+			desc:     "missingToken",
+			contents: "alice\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "tooManyFields",
+			contents: "alice token1 token2\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "duplicatePrincipal",
+			contents: "alice token1\nalice token2\n",
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		f, err := ioutil.TempFile("", "tokens")
+		if err != nil {
+			t.Fatalf("TempFile() returned err = %v", err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(test.contents); err != nil {
+			t.Fatalf("WriteString() returned err = %v", err)
+		}
+		f.Close()
+
+		got, err := ReadTokenFile(f.Name())
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: ReadTokenFile() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		} else if hasErr {
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: ReadTokenFile() = %v, want = %v", test.desc, got, test.want)
+		}
+	}
+
+	if _, err := ReadTokenFile("/does/not/exist"); err == nil {
+		t.Error("ReadTokenFile() of missing file returned err = nil")
+	}
+}
+
+func tokenContext(values ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.MD{"authorization": values})
+}
+
+func certContext(commonName string) context.Context {
+	cert := &x509.Certificate{Raw: []byte(commonName), Subject: pkix.Name{CommonName: commonName}}
+	info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+}
+
+func TestAuthenticators(t *testing.T) {
+	tokens := NewTokenAuthenticator(map[string]string{"alice": "token1", "bob": "token2"})
+	certs := CertAuthenticator{GatewayCert: []byte("gateway")}
+	multi := MultiAuthenticator{tokens, certs}
+
+	tests := []struct {
+		desc          string
+		authenticator Authenticator
+		ctx           context.Context
+		want          string
+		wantErr       bool
+	}{
+		{
+			desc:          "token",
+			authenticator: tokens,
+			ctx:           tokenContext("Bearer token1"),
+			want:          "alice",
+		},
+		{
+			desc:          "tokenSchemeCase",
+			authenticator: tokens,
+			ctx:           tokenContext("bearer token2"),
+			want:          "bob",
+		},
+		{
+			desc:          "tokenOtherScheme",
+			authenticator: tokens,
+			ctx:           tokenContext("Basic YWxpY2U6dG9rZW4x"),
+		},
+		{
+			desc:          "tokenUnknown",
+			authenticator: tokens,
+			ctx:           tokenContext("Bearer token3"),
+			wantErr:       true,
+		},
+		{
+			desc:          "tokenNoMetadata",
+			authenticator: tokens,
+			ctx:           context.Background(),
+		},
+		{
+			desc:          "cert",
+			authenticator: certs,
+			ctx:           certContext("carol"),
+			want:          "carol",
+		},
+		{
+			desc:          "certNoCommonName",
+			authenticator: certs,
+			ctx:           certContext(""),
+			wantErr:       true,
+		},
+		{
+			desc:          "certGateway",
+			authenticator: certs,
+			ctx:           certContext("gateway"),
+		},
+		{
+			desc:          "certNoPeer",
+			authenticator: certs,
+			ctx:           context.Background(),
+		},
+		{
+			desc:          "certUnverified",
+			authenticator: certs,
+			ctx:           peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}),
+		},
+		{
+			desc:          "multiToken",
+			authenticator: multi,
+			ctx:           tokenContext("Bearer token1"),
+			want:          "alice",
+		},
+		{
+			desc:          "multiCert",
+			authenticator: multi,
+			ctx:           certContext("carol"),
+			want:          "carol",
+		},
+		{
+			desc:          "multiError",
+			authenticator: multi,
+			ctx:           tokenContext("Bearer token3"),
+			wantErr:       true,
+		},
+		{
+			desc:          "multiNone",
+			authenticator: multi,
+			ctx:           context.Background(),
+		},
+	}
+	for _, test := range tests {
+		got, err := test.authenticator.Authenticate(test.ctx)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: Authenticate() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%v: Authenticate() = %q, want = %q", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestTrillianInterceptor_Auth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownedLog := *testonly.LogTree
+	ownedLog.TreeId = 10
+	ownedLog.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"owner"}, Readers: []string{"reader"}}
+	publicLog := *testonly.LogTree
+	publicLog.TreeId = 11
+	publicLog.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"owner"}, PublicRead: true}
+	openMap := *testonly.MapTree
+	openMap.TreeId = 12
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	for _, tree := range []*trillian.Tree{&ownedLog, &publicLog, &openMap} {
+		adminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).AnyTimes().Return(tree, nil)
+	}
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	authenticator := NewTokenAuthenticator(map[string]string{
+		"admin":    "admin-token",
+		"owner":    "owner-token",
+		"reader":   "reader-token",
+		"stranger": "stranger-token",
+	})
+	queueLeaves := func(treeID int64) interface{} { return &trillian.QueueLeavesRequest{LogId: treeID} }
+	getRoot := func(treeID int64) interface{} { return &trillian.GetLatestSignedLogRootRequest{LogId: treeID} }
+
+	tests := []struct {
+		desc      string
+		token     string
+		openTrees bool
+		req       interface{}
+		wantCode  codes.Code
+	}{
+		{desc: "adminDeletesTree", token: "admin-token", req: &trillian.DeleteTreeRequest{TreeId: ownedLog.TreeId}},
+		{desc: "adminCreatesTree", token: "admin-token", req: &trillian.CreateTreeRequest{}},
+		{desc: "adminWrites", token: "admin-token", req: queueLeaves(ownedLog.TreeId)},
+		{desc: "ownerDeletesTree", token: "owner-token", req: &trillian.DeleteTreeRequest{TreeId: ownedLog.TreeId}, wantCode: codes.PermissionDenied},
+		{desc: "ownerListsTrees", token: "owner-token", req: &trillian.ListTreesRequest{}, wantCode: codes.PermissionDenied},
+		{desc: "ownerRepairsRoot", token: "owner-token", req: &trillian.RepairTreeRootRequest{TreeId: ownedLog.TreeId}, wantCode: codes.PermissionDenied},
+		{desc: "adminRepairsRoot", token: "admin-token", req: &trillian.RepairTreeRootRequest{TreeId: ownedLog.TreeId}},
+		{desc: "ownerWrites", token: "owner-token", req: queueLeaves(ownedLog.TreeId)},
+		{desc: "ownerReads", token: "owner-token", req: getRoot(ownedLog.TreeId)},
+		{desc: "readerWrites", token: "reader-token", req: queueLeaves(ownedLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "readerReads", token: "reader-token", req: getRoot(ownedLog.TreeId)},
+		{desc: "strangerReads", token: "stranger-token", req: getRoot(ownedLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "strangerReadsPublic", token: "stranger-token", req: getRoot(publicLog.TreeId)},
+		{desc: "strangerWritesPublic", token: "stranger-token", req: queueLeaves(publicLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "strangerWritesOpen", token: "stranger-token", req: &trillian.SetMapLeavesRequest{MapId: openMap.TreeId}, wantCode: codes.PermissionDenied},
+		{desc: "strangerReadsOpen", token: "stranger-token", req: &trillian.GetSignedMapRootRequest{MapId: openMap.TreeId}, wantCode: codes.PermissionDenied},
+		{desc: "adminWritesOpen", token: "admin-token", req: &trillian.SetMapLeavesRequest{MapId: openMap.TreeId}},
+		{desc: "strangerWritesOpenTrees", token: "stranger-token", openTrees: true, req: &trillian.SetMapLeavesRequest{MapId: openMap.TreeId}},
+		{desc: "strangerWritesOwnedOpenTrees", token: "stranger-token", openTrees: true, req: queueLeaves(ownedLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "anonymousReadsPublic", req: getRoot(publicLog.TreeId)},
+		{desc: "anonymousReads", req: getRoot(ownedLog.TreeId), wantCode: codes.Unauthenticated},
+		{desc: "anonymousReadsOpen", req: &trillian.GetSignedMapRootRequest{MapId: openMap.TreeId}, wantCode: codes.Unauthenticated},
+		{desc: "anonymousCreatesTree", req: &trillian.CreateTreeRequest{}, wantCode: codes.Unauthenticated},
+		{desc: "badToken", token: "bad-token", req: getRoot(publicLog.TreeId), wantCode: codes.Unauthenticated},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.token != "" {
+			ctx = tokenContext("Bearer " + test.token)
+		}
+		authorizer := NewAuthorizer(authenticator, []string{"admin"})
+		authorizer.OpenTrees = test.openTrees
+		intercept := &TrillianInterceptor{
+			Admin:        admin,
+			QuotaManager: quota.Noop(),
+			Authorizer:   authorizer,
+		}
+		handler := &fakeHandler{resp: "handler response"}
+
+		_, err := intercept.UnaryInterceptor(ctx, test.req, &grpc.UnaryServerInfo{}, handler.run)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: UnaryInterceptor() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+		if wantCalled := test.wantCode == codes.OK; handler.called != wantCalled {
+			t.Errorf("%v: handler called = %v, want = %v", test.desc, handler.called, wantCalled)
+		}
+	}
+}
+
+func TestTrillianInterceptor_StreamAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownedLog := *testonly.LogTree
+	ownedLog.TreeId = 10
+	ownedLog.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"owner"}, Readers: []string{"reader"}}
+	publicLog := *testonly.LogTree
+	publicLog.TreeId = 11
+	publicLog.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"owner"}, PublicRead: true}
+	openMap := *testonly.MapTree
+	openMap.TreeId = 12
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	for _, tree := range []*trillian.Tree{&ownedLog, &publicLog, &openMap} {
+		adminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).AnyTimes().Return(tree, nil)
+	}
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	authenticator := NewTokenAuthenticator(map[string]string{
+		"admin":    "admin-token",
+		"reader":   "reader-token",
+		"stranger": "stranger-token",
+	})
+	getEntries := func(treeID int64) interface{} { return &trillian.GetEntriesRequest{LogId: treeID} }
+	getRoots := func(treeID int64) interface{} { return &trillian.GetSignedLogRootHistoryRequest{LogId: treeID} }
+	setLeaves := func(treeID int64) interface{} { return &trillian.SetMapLeavesRequest{MapId: treeID} }
+
+	tests := []struct {
+		desc     string
+		token    string
+		req      interface{}
+		wantCode codes.Code
+	}{
+		{desc: "readerGetsEntries", token: "reader-token", req: getEntries(ownedLog.TreeId)},
+		{desc: "strangerGetsEntries", token: "stranger-token", req: getEntries(ownedLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "anonymousGetsEntries", req: getEntries(ownedLog.TreeId), wantCode: codes.Unauthenticated},
+		{desc: "anonymousGetsPublicEntries", req: getEntries(publicLog.TreeId)},
+		{desc: "readerGetsRoots", token: "reader-token", req: getRoots(ownedLog.TreeId)},
+		{desc: "strangerGetsRoots", token: "stranger-token", req: getRoots(ownedLog.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "anonymousGetsRoots", req: getRoots(ownedLog.TreeId), wantCode: codes.Unauthenticated},
+		{desc: "adminStreamsLeaves", token: "admin-token", req: setLeaves(openMap.TreeId)},
+		{desc: "strangerStreamsLeaves", token: "stranger-token", req: setLeaves(openMap.TreeId), wantCode: codes.PermissionDenied},
+		{desc: "anonymousStreamsLeaves", req: setLeaves(openMap.TreeId), wantCode: codes.Unauthenticated},
+		{desc: "anonymousReflection", req: &rpb.ServerReflectionRequest{}},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.token != "" {
+			ctx = tokenContext("Bearer " + test.token)
+		}
+		intercept := &TrillianInterceptor{
+			Admin:        admin,
+			QuotaManager: quota.Noop(),
+			Authorizer:   NewAuthorizer(authenticator, []string{"admin"}),
+		}
+		handler := &fakeStreamHandler{reqType: test.req}
+		stream := &fakeServerStream{ctx: ctx, reqs: []interface{}{test.req}}
+
+		err := intercept.StreamInterceptor(nil, stream, &grpc.StreamServerInfo{}, handler.run)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: StreamInterceptor() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+		if wantCalled := test.wantCode == codes.OK; handler.called != wantCalled {
+			t.Errorf("%v: handler received request = %v, want = %v", test.desc, handler.called, wantCalled)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	handlerCause = "handler"
+	rpcCause     = "rpc"
+)
+
+// HandlerDeadline runs the handlers below it with a deadline shorter than the RPC deadline.
+// This leaves time to marshal and send a response when a handler is slow, and lets handler
+// timeouts be told apart from the RPC running out of time.
+// The deadline covers everything the handler does, not only its storage operations: storage
+// transactions are run under the handler's context, so time spent on storage can't be bounded
+// separately from the rest of the handler.
+type HandlerDeadline struct {
+	// Fraction of the remaining RPC deadline given to handlers, in (0, 1]. Zero means 1.
+	Fraction float64
+	// Max caps the handler deadline. Zero means no cap.
+	Max time.Duration
+
+	timeouts monitoring.Counter
+}
+
+// NewHandlerDeadline creates a HandlerDeadline interceptor. Timeouts are counted in the
+// "<prefix>_deadline_exceeded" metric, labelled by whether the handler or the RPC ran out of
+// time.
+func NewHandlerDeadline(prefix string, fraction float64, max time.Duration, mf monitoring.MetricFactory) *HandlerDeadline {
+	return &HandlerDeadline{
+		Fraction: fraction,
+		Max:      max,
+		timeouts: mf.NewCounter(prefix+"_deadline_exceeded", "Number of requests that ran out of time, by cause", "cause"),
+	}
+}
+
+// UnaryInterceptor executes the HandlerDeadline logic for unary RPCs.
+func (h *HandlerDeadline) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	hctx, cancel := h.handlerContext(ctx, time.Now())
+	defer cancel()
+
+	rsp, err := handler(hctx, req)
+	if err == nil || hctx.Err() != context.DeadlineExceeded {
+		return rsp, err
+	}
+	if ctx.Err() != nil {
+		h.timeouts.Inc(rpcCause)
+		return rsp, err
+	}
+	h.timeouts.Inc(handlerCause)
+	return nil, status.Errorf(codes.DeadlineExceeded, "handler deadline exceeded: %v", err)
+}
+
+// handlerContext derives the handler context from ctx.
+func (h *HandlerDeadline) handlerContext(ctx context.Context, now time.Time) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok && h.Fraction > 0 && h.Fraction < 1 {
+		timeout = time.Duration(float64(deadline.Sub(now)) * h.Fraction)
+		if timeout <= 0 {
+			// Out of time already, nothing to gain from a shorter deadline.
+			return context.WithCancel(ctx)
+		}
+	}
+	if h.Max > 0 && (timeout == 0 || h.Max < timeout) {
+		timeout = h.Max
+	}
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,146 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandlerDeadline_HandlerContext(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		desc         string
+		fraction     float64
+		max          time.Duration
+		rpcTimeout   time.Duration
+		wantDeadline bool
+		wantTimeout  time.Duration
+	}{
+		{desc: "noConfig", rpcTimeout: 10 * time.Second},
+		{desc: "noRPCDeadline", fraction: 0.5},
+		{desc: "fraction", fraction: 0.5, rpcTimeout: 10 * time.Second, wantDeadline: true, wantTimeout: 5 * time.Second},
+		{desc: "fullFraction", fraction: 1, rpcTimeout: 10 * time.Second},
+		{desc: "maxOnly", max: time.Second, wantDeadline: true, wantTimeout: time.Second},
+		{desc: "maxBelowFraction", fraction: 0.5, max: time.Second, rpcTimeout: 10 * time.Second, wantDeadline: true, wantTimeout: time.Second},
+		{desc: "fractionBelowMax", fraction: 0.5, max: time.Minute, rpcTimeout: 10 * time.Second, wantDeadline: true, wantTimeout: 5 * time.Second},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.rpcTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, now.Add(test.rpcTimeout))
+			defer cancel()
+		}
+		s := NewHandlerDeadline("test", test.fraction, test.max, monitoring.InertMetricFactory{})
+
+		hctx, cancel := s.handlerContext(ctx, now)
+		defer cancel()
+
+		rpcDeadline, _ := ctx.Deadline()
+		deadline, ok := hctx.Deadline()
+		if !test.wantDeadline {
+			if deadline != rpcDeadline {
+				t.Errorf("%v: handler deadline = %v, want RPC deadline %v", test.desc, deadline, rpcDeadline)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%v: handler context has no deadline", test.desc)
+			continue
+		}
+		// WithTimeout measures from the real clock, so allow for some slack.
+		if got := deadline.Sub(time.Now()); got > test.wantTimeout || got < test.wantTimeout-time.Second {
+			t.Errorf("%v: handler timeout = %v, want ~%v", test.desc, got, test.wantTimeout)
+		}
+	}
+}
+
+func TestHandlerDeadline_UnaryInterceptor(t *testing.T) {
+	handlerTimeout := func(ctx context.Context, req interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	otherErr := errors.New("other error")
+
+	tests := []struct {
+		desc        string
+		rpcTimeout  time.Duration
+		max         time.Duration
+		handler     func(ctx context.Context, req interface{}) (interface{}, error)
+		wantErr     error
+		wantCode    codes.Code
+		wantHandler float64
+		wantRPC     float64
+	}{
+		{
+			desc:    "ok",
+			max:     time.Minute,
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+		},
+		{
+			desc:    "otherError",
+			max:     time.Minute,
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) { return nil, otherErr },
+			wantErr: otherErr,
+		},
+		{
+			desc:        "handlerTimeout",
+			max:         10 * time.Millisecond,
+			handler:     handlerTimeout,
+			wantCode:    codes.DeadlineExceeded,
+			wantHandler: 1,
+		},
+		{
+			desc:       "rpcTimeout",
+			rpcTimeout: 10 * time.Millisecond,
+			max:        time.Minute,
+			handler:    handlerTimeout,
+			wantErr:    context.DeadlineExceeded,
+			wantRPC:    1,
+		},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.rpcTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, test.rpcTimeout)
+			defer cancel()
+		}
+		s := NewHandlerDeadline("test_"+test.desc, 0, test.max, monitoring.InertMetricFactory{})
+
+		_, err := s.UnaryInterceptor(ctx, "req", nil, test.handler)
+		switch {
+		case test.wantCode != codes.OK:
+			if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
+				t.Errorf("%v: UnaryInterceptor() returned err = %v, want code %v", test.desc, err, test.wantCode)
+			}
+		case err != test.wantErr:
+			t.Errorf("%v: UnaryInterceptor() returned err = %v, want %v", test.desc, err, test.wantErr)
+		}
+		if got := s.timeouts.Value(handlerCause); got != test.wantHandler {
+			t.Errorf("%v: handler timeouts = %v, want %v", test.desc, got, test.wantHandler)
+		}
+		if got := s.timeouts.Value(rpcCause); got != test.wantRPC {
+			t.Errorf("%v: rpc timeouts = %v, want %v", test.desc, got, test.wantRPC)
+		}
+	}
+}
@@ -17,8 +17,12 @@ package interceptor
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/golang/glog"
 	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/server/errors"
 	"github.com/google/trillian/storage"
@@ -26,44 +30,156 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/grpc/status"
 )
 
-// TrillianInterceptor checks that:
-// * Requests addressing a tree have the correct tree type and tree state;
-// * TODO(codingllama): Requests are properly authenticated / authorized ; and
+// TrillianInterceptor checks, for unary and streaming RPCs, that:
+// * Requests addressing a tree have the correct tree type and tree state, including writes to
+//   DRAINING trees past their drain deadline;
+// * Requests are authenticated and authorized, if an Authorizer is set; and
 // * Requests are rate limited appropriately.
 type TrillianInterceptor struct {
 	Admin        storage.AdminStorage
 	QuotaManager quota.Manager
+	// Authorizer checks that requests are allowed by the access policies of their trees. Nil
+	// means requests aren't authenticated.
+	Authorizer *Authorizer
+
+	// QuotaFailOpen lets requests through without tokens when the QuotaManager fails, as
+	// opposed to a quota running out of tokens. If false, such requests are rejected.
+	QuotaFailOpen bool
+	// MetricFactory is used to create the interceptor's metrics. Nil means no metrics.
+	MetricFactory monitoring.MetricFactory
+
+	metricsOnce    sync.Once
+	quotaDegraded  monitoring.Counter
+	quotaThrottled monitoring.Counter
 }
 
 // UnaryInterceptor executes the TrillianInterceptor logic for unary RPCs.
 func (i *TrillianInterceptor) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	// IMPORTANT: Do not rely on grpc.UnaryServerInfo in this filter. It makes life a lot harder
 	// when adapting the code to other environments.
+	ctx, err := i.checkRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor executes the TrillianInterceptor logic for streaming RPCs. The checks are
+// those of UnaryInterceptor, run on the first request received on the stream, which is the only
+// request of server-streaming RPCs. Quota is charged once per stream. Later requests of
+// client-streaming RPCs aren't checked, so their handlers must reject requests addressing
+// another tree than the first.
+func (i *TrillianInterceptor) StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &checkedServerStream{ServerStream: ss, interceptor: i, ctx: ss.Context()})
+}
+
+// checkedServerStream is a grpc.ServerStream that runs the TrillianInterceptor checks on the
+// first request received.
+type checkedServerStream struct {
+	grpc.ServerStream
+	interceptor *TrillianInterceptor
+	ctx         context.Context
+	checked     bool
+	// err is the result of the checks, returned by every RecvMsg once they fail.
+	err error
+}
+
+// Context returns the context of the stream, carrying the tree of the first request once it's
+// received.
+func (s *checkedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *checkedServerStream) RecvMsg(m interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.checked {
+		return nil
+	}
+	s.checked = true
+	s.ctx, s.err = s.interceptor.checkRequest(s.ctx, m)
+	return s.err
+}
+
+// checkRequest runs the checks of the interceptor on req, returning the context the RPC
+// continues with.
+func (i *TrillianInterceptor) checkRequest(ctx context.Context, req interface{}) (context.Context, error) {
+	// Health checks come from load balancers and orchestrators, which can't authenticate, and
+	// reflection only describes the services served. Neither addresses a tree or uses quota.
+	switch req.(type) {
+	case *healthpb.HealthCheckRequest, *rpb.ServerReflectionRequest:
+		return ctx, nil
+	}
 
 	quotaUser := i.QuotaManager.GetUser(ctx, req)
 	rpcInfo, err := getRPCInfo(req, quotaUser)
 	if err != nil {
-		return nil, err
+		return ctx, err
+	}
+
+	var principal string
+	if i.Authorizer != nil {
+		if principal, err = i.Authorizer.authenticate(ctx); err != nil {
+			return ctx, err
+		}
 	}
 
+	var tree *trillian.Tree
 	if rpcInfo.treeID != 0 {
-		tree, err := trees.GetTree(ctx, i.Admin, rpcInfo.treeID, rpcInfo.opts)
+		// Unknown and deleted trees are rejected here, with NotFound and FailedPrecondition
+		// respectively, so RPCs don't have to deal with them.
+		tree, err = trees.GetTree(ctx, i.Admin, rpcInfo.treeID, rpcInfo.opts)
 		if err != nil {
-			return nil, err
+			return ctx, errors.WrapError(err)
 		}
-		ctx = trees.NewContext(ctx, tree)
+	}
+	if i.Authorizer != nil {
+		if err := i.Authorizer.authorize(principal, rpcInfo, tree); err != nil {
+			return ctx, err
+		}
+	}
 
-		// TODO(codingllama): Add auth interception
+	if tree != nil {
+		// Draining trees stop accepting writes once their grace period is over, but
+		// admin RPCs are let through so the tree can still be frozen or reactivated.
+		if !rpcInfo.opts.Readonly && rpcInfo.kind != quota.Admin {
+			if passed, err := trees.DrainDeadlinePassed(tree, time.Now()); err != nil {
+				return ctx, status.Errorf(codes.Internal, "%v", err)
+			} else if passed {
+				return ctx, status.Errorf(codes.FailedPrecondition, "tree %v is %s and no longer accepts writes", tree.TreeId, tree.TreeState)
+			}
+		}
+		ctx = trees.NewContext(ctx, tree)
 	}
 
 	if err := i.QuotaManager.GetTokens(ctx, 1 /* numTokens */, rpcInfo.specs); err != nil {
-		return nil, status.Errorf(codes.ResourceExhausted, "quota exhausted: %v", err)
+		i.metricsOnce.Do(i.createMetrics)
+		if !i.QuotaFailOpen || quota.IsExhausted(err) {
+			i.quotaThrottled.Inc(rpcInfo.kind.String())
+			return ctx, status.Errorf(codes.ResourceExhausted, "quota exhausted: %v", err)
+		}
+		glog.Warningf("Quota manager failed, letting request through: %v", err)
+		i.quotaDegraded.Inc()
 	}
+	return ctx, nil
+}
 
-	return handler(ctx, req)
+func (i *TrillianInterceptor) createMetrics() {
+	mf := i.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	i.quotaDegraded = mf.NewCounter("quota_degraded_requests", "Number of requests let through without quota because the quota manager failed")
+	i.quotaThrottled = mf.NewCounter("quota_throttled_requests", "Number of requests rejected for lack of quota, by quota kind", "kind")
 }
 
 // rpcInfo contains information about an RPC, as extracted from its request message.
@@ -75,6 +191,9 @@ type rpcInfo struct {
 	// opts is not set if doesNotHaveTree is true.
 	opts trees.GetOpts
 
+	// kind is the kind of quota tokens charged to this RPC.
+	kind quota.Kind
+
 	// specs contains the quota specifications for this RPC.
 	specs []quota.Spec
 }
@@ -89,8 +208,18 @@ func getRPCInfo(req interface{}, quotaUser string) (*rpcInfo, error) {
 	switch req := req.(type) {
 	case *trillian.CreateTreeRequest:
 		// OK, tree is being created
-	case *trillian.ListTreesRequest:
+	case *trillian.UndeleteTreeRequest:
+		// OK, tree is deleted, so it can't be fetched like other trees
+	case *trillian.RepairTreeRootRequest:
+		// OK, repairs write to FROZEN trees, which can't be fetched for writing, so
+		// RepairTreeRoot fetches the tree itself
+	case *trillian.ListTreesRequest, *trillian.ListPendingTreesRequest, *trillian.BatchUpdateTreesRequest:
 		// OK, no single tree ID (potentially many trees)
+	case *trillian.CreateQuotaConfigRequest,
+		*trillian.UpdateQuotaConfigRequest,
+		*trillian.DeleteQuotaConfigRequest,
+		*trillian.ListQuotaConfigsRequest:
+		// OK, quota configs aren't charged against the tree they describe
 	case treeIDRequest:
 		treeID = req.GetTreeId()
 	case treeRequest:
@@ -103,15 +232,11 @@ func getRPCInfo(req interface{}, quotaUser string) (*rpcInfo, error) {
 		return nil, status.Errorf(codes.Internal, "cannot retrieve treeID from request: %T", req)
 	}
 
-	treeType, readonly, err := getRequestInfo(req)
+	treeType, readonly, kind, err := getRequestInfo(req)
 	if err != nil {
 		return nil, err
 	}
 
-	kind := quota.Read
-	if !readonly {
-		kind = quota.Write
-	}
 	var specs []quota.Spec
 	if treeID == 0 {
 		specs = []quota.Spec{
@@ -129,32 +254,58 @@ func getRPCInfo(req interface{}, quotaUser string) (*rpcInfo, error) {
 	return &rpcInfo{
 		treeID: treeID,
 		opts:   trees.GetOpts{TreeType: treeType, Readonly: readonly},
+		kind:   kind,
 		specs:  specs,
 	}, nil
 }
 
-func getRequestInfo(req interface{}) (trillian.TreeType, bool, error) {
+// getRequestInfo returns the tree type an RPC addresses, whether it's readonly and the kind
+// of quota tokens it's charged. Admin RPCs are charged Admin tokens; other RPCs are charged
+// Read or Write tokens, depending on whether they're readonly.
+func getRequestInfo(req interface{}) (trillian.TreeType, bool, quota.Kind, error) {
 	if ok, readonly := getAdminRequestInfo(req); ok {
-		return trillian.TreeType_UNKNOWN_TREE_TYPE, readonly, nil
+		return trillian.TreeType_UNKNOWN_TREE_TYPE, readonly, quota.Admin, nil
 	}
 	if ok, readonly := getLogRequestInfo(req); ok {
-		return trillian.TreeType_LOG, readonly, nil
+		return trillian.TreeType_LOG, readonly, dataQuotaKind(readonly), nil
 	}
 	if ok, readonly := getMapRequestInfo(req); ok {
-		return trillian.TreeType_MAP, readonly, nil
+		return trillian.TreeType_MAP, readonly, dataQuotaKind(readonly), nil
+	}
+	return trillian.TreeType_UNKNOWN_TREE_TYPE, false, quota.Read, fmt.Errorf("unmapped request type: %T", req)
+}
+
+// dataQuotaKind returns the kind of quota tokens charged to a log or map RPC.
+func dataQuotaKind(readonly bool) quota.Kind {
+	if readonly {
+		return quota.Read
 	}
-	return trillian.TreeType_UNKNOWN_TREE_TYPE, false, fmt.Errorf("unmapped request type: %T", req)
+	return quota.Write
 }
 
 func getAdminRequestInfo(req interface{}) (bool, bool) {
 	isAdmin := true
 	readonly := false
 	switch req.(type) {
-	case *trillian.GetTreeRequest,
+	case *trillian.GetQuotaTokensRequest,
+		*trillian.GetTreeRequest,
+		*trillian.GetTreeFootprintRequest,
+		*trillian.ListDeadLetteredLeavesRequest,
+		*trillian.ListPendingTreesRequest,
+		*trillian.ListQuotaConfigsRequest,
 		*trillian.ListTreesRequest:
 		readonly = true
-	case *trillian.CreateTreeRequest,
+	case *trillian.BatchUpdateTreesRequest,
+		*trillian.CreateQuotaConfigRequest,
+		*trillian.CreateTreeRequest,
+		*trillian.DeleteQuotaConfigRequest,
 		*trillian.DeleteTreeRequest,
+		*trillian.RepairTreeRootRequest,
+		*trillian.RequeueDeadLetteredLeavesRequest,
+		*trillian.RetireTreeKeyRequest,
+		*trillian.RotateTreeKeyRequest,
+		*trillian.UndeleteTreeRequest,
+		*trillian.UpdateQuotaConfigRequest,
 		*trillian.UpdateTreeRequest:
 	default:
 		isAdmin = false
@@ -166,16 +317,28 @@ func getLogRequestInfo(req interface{}) (bool, bool) {
 	isLog := true
 	readonly := false
 	switch req.(type) {
-	case *trillian.GetConsistencyProofRequest,
+	case *trillian.CheckConsistencyProofRequest,
+		*trillian.CountLeavesRequest,
+		*trillian.GetConsistencyProofRequest,
+		*trillian.GetEntriesRequest,
 		*trillian.GetEntryAndProofRequest,
 		*trillian.GetInclusionProofByHashRequest,
 		*trillian.GetInclusionProofRequest,
+		*trillian.GetInclusionProofWithRootRequest,
+		*trillian.GetLatestCosignedLogRootRequest,
+		*trillian.GetLatestLeafByIdentityHashPrefixRequest,
 		*trillian.GetLatestSignedLogRootRequest,
+		*trillian.GetLatestCheckpointRequest,
 		*trillian.GetLeavesByHashRequest,
 		*trillian.GetLeavesByIndexRequest,
-		*trillian.GetSequencedLeafCountRequest:
+		*trillian.GetProofByMerkleHashRequest,
+		*trillian.GetSequencedLeafCountRequest,
+		*trillian.GetSignedLogRootAtTimeRequest,
+		*trillian.GetSignedLogRootHistoryRequest,
+		*trillian.HasLeavesRequest:
 		readonly = true
-	case *trillian.QueueLeafRequest,
+	case *trillian.AddCosignatureRequest,
+		*trillian.QueueLeafRequest,
 		*trillian.QueueLeavesRequest:
 	default:
 		isLog = false
@@ -188,10 +351,12 @@ func getMapRequestInfo(req interface{}) (bool, bool) {
 	readonly := false
 	switch req.(type) {
 	case *trillian.GetMapLeavesRequest,
+		*trillian.GetMapLeavesByRevisionsRequest,
 		*trillian.GetSignedMapRootByRevisionRequest,
 		*trillian.GetSignedMapRootRequest:
 		readonly = true
-	case *trillian.SetMapLeavesRequest:
+	case *trillian.QueueMapLeavesRequest,
+		*trillian.SetMapLeavesRequest:
 	default:
 		isMap = false
 	}
@@ -229,6 +394,20 @@ func Combine(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterc
 	}
 }
 
+// CombineStream combines stream interceptors, nesting them in order like Combine.
+func CombineStream(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor := interceptors[i]
+			baseHandler := handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, baseHandler)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
 // ErrorWrapper is a grpc.UnaryServerInterceptor that wraps the errors emitted by the underlying handler.
 func ErrorWrapper(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	rsp, err := handler(ctx, req)
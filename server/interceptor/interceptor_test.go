@@ -16,10 +16,15 @@ package interceptor
 
 import (
 	"errors"
+	"io"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/trillian"
 	terrors "github.com/google/trillian/errors"
 	"github.com/google/trillian/quota"
@@ -31,6 +36,7 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 )
 
@@ -127,6 +133,174 @@ func TestTrillianInterceptor_TreeInterception(t *testing.T) {
 	}
 }
 
+func TestTrillianInterceptor_DeletedAndUnknownTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	softDeletedLog := *testonly.LogTree
+	softDeletedLog.TreeId = 10
+	softDeletedLog.TreeState = trillian.TreeState_SOFT_DELETED
+	hardDeletedMap := *testonly.MapTree
+	hardDeletedMap.TreeId = 11
+	hardDeletedMap.TreeState = trillian.TreeState_HARD_DELETED
+	unknownTreeID := int64(999)
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), softDeletedLog.TreeId).AnyTimes().Return(&softDeletedLog, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), hardDeletedMap.TreeId).AnyTimes().Return(&hardDeletedMap, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), unknownTreeID).AnyTimes().Return(nil, terrors.Errorf(terrors.NotFound, "tree %v not found", unknownTreeID))
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	tests := []struct {
+		desc     string
+		req      interface{}
+		wantCode codes.Code
+	}{
+		{desc: "deletedAdminRead", req: &trillian.GetTreeRequest{TreeId: softDeletedLog.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "deletedAdminWrite", req: &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: softDeletedLog.TreeId}}, wantCode: codes.FailedPrecondition},
+		{desc: "deletedLogRead", req: &trillian.GetLatestSignedLogRootRequest{LogId: softDeletedLog.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "deletedLogWrite", req: &trillian.QueueLeafRequest{LogId: softDeletedLog.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "deletedMapRead", req: &trillian.GetSignedMapRootRequest{MapId: hardDeletedMap.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "deletedMapWrite", req: &trillian.SetMapLeavesRequest{MapId: hardDeletedMap.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "unknownAdminRead", req: &trillian.GetTreeRequest{TreeId: unknownTreeID}, wantCode: codes.NotFound},
+		{desc: "unknownAdminWrite", req: &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: unknownTreeID}}, wantCode: codes.NotFound},
+		{desc: "unknownLogRead", req: &trillian.GetLatestSignedLogRootRequest{LogId: unknownTreeID}, wantCode: codes.NotFound},
+		{desc: "unknownLogWrite", req: &trillian.QueueLeafRequest{LogId: unknownTreeID}, wantCode: codes.NotFound},
+		{desc: "unknownMapRead", req: &trillian.GetSignedMapRootRequest{MapId: unknownTreeID}, wantCode: codes.NotFound},
+		{desc: "unknownMapWrite", req: &trillian.SetMapLeavesRequest{MapId: unknownTreeID}, wantCode: codes.NotFound},
+	}
+
+	ctx := context.Background()
+	intercept := TrillianInterceptor{Admin: admin, QuotaManager: quota.Noop()}
+	for _, test := range tests {
+		handler := &fakeHandler{resp: "handler response"}
+
+		_, err := intercept.UnaryInterceptor(ctx, test.req, &grpc.UnaryServerInfo{}, handler.run)
+		if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
+			t.Errorf("%v: UnaryInterceptor() returned err = %v, wantCode = %v", test.desc, err, test.wantCode)
+		}
+		if handler.called {
+			t.Errorf("%v: handler called", test.desc)
+		}
+	}
+}
+
+func TestTrillianInterceptor_DrainingTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	drainingLog := *testonly.LogTree
+	drainingLog.TreeId = 10
+	drainingLog.TreeState = trillian.TreeState_DRAINING
+	drainingLog.DrainDeadline = timestampProto(t, time.Now().Add(time.Hour))
+	drainedLog := *testonly.LogTree
+	drainedLog.TreeId = 11
+	drainedLog.TreeState = trillian.TreeState_DRAINING
+	drainedLog.DrainDeadline = timestampProto(t, time.Now().Add(-time.Hour))
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), drainingLog.TreeId).AnyTimes().Return(&drainingLog, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), drainedLog.TreeId).AnyTimes().Return(&drainedLog, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	tests := []struct {
+		desc     string
+		req      interface{}
+		wantCode codes.Code
+	}{
+		{desc: "drainingRead", req: &trillian.GetLatestSignedLogRootRequest{LogId: drainingLog.TreeId}},
+		{desc: "drainingWrite", req: &trillian.QueueLeafRequest{LogId: drainingLog.TreeId}},
+		{desc: "drainedRead", req: &trillian.GetLatestSignedLogRootRequest{LogId: drainedLog.TreeId}},
+		{desc: "drainedWrite", req: &trillian.QueueLeafRequest{LogId: drainedLog.TreeId}, wantCode: codes.FailedPrecondition},
+		{desc: "drainedAdminWrite", req: &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: drainedLog.TreeId}}},
+	}
+
+	ctx := context.Background()
+	intercept := TrillianInterceptor{Admin: admin, QuotaManager: quota.Noop()}
+	for _, test := range tests {
+		handler := &fakeHandler{resp: "handler response"}
+
+		_, err := intercept.UnaryInterceptor(ctx, test.req, &grpc.UnaryServerInfo{}, handler.run)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: UnaryInterceptor() returned err = %v, wantCode = %v", test.desc, err, test.wantCode)
+		}
+		if want := test.wantCode == codes.OK; handler.called != want {
+			t.Errorf("%v: handler called = %v, want = %v", test.desc, handler.called, want)
+		}
+	}
+}
+
+func TestTrillianInterceptor_StreamWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	drainingMap := *testonly.MapTree
+	drainingMap.TreeId = 10
+	drainingMap.TreeState = trillian.TreeState_DRAINING
+	drainingMap.DrainDeadline = timestampProto(t, time.Now().Add(time.Hour))
+	drainedMap := drainingMap
+	drainedMap.TreeId = 11
+	drainedMap.DrainDeadline = timestampProto(t, time.Now().Add(-time.Hour))
+	frozenMap := *testonly.MapTree
+	frozenMap.TreeId = 12
+	frozenMap.TreeState = trillian.TreeState_FROZEN
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	for _, tree := range []*trillian.Tree{&drainingMap, &drainedMap, &frozenMap} {
+		adminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).AnyTimes().Return(tree, nil)
+	}
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	tests := []struct {
+		desc     string
+		treeID   int64
+		wantCode codes.Code
+	}{
+		{desc: "draining", treeID: drainingMap.TreeId},
+		{desc: "drained", treeID: drainedMap.TreeId, wantCode: codes.FailedPrecondition},
+		{desc: "frozen", treeID: frozenMap.TreeId, wantCode: codes.FailedPrecondition},
+	}
+
+	intercept := TrillianInterceptor{Admin: admin, QuotaManager: quota.Noop()}
+	for _, test := range tests {
+		req := &trillian.SetMapLeavesRequest{MapId: test.treeID}
+		handler := &fakeStreamHandler{reqType: req}
+		stream := &fakeServerStream{ctx: context.Background(), reqs: []interface{}{req}}
+
+		err := intercept.StreamInterceptor(nil, stream, &grpc.StreamServerInfo{}, handler.run)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: StreamInterceptor() returned err = %v, wantCode = %v", test.desc, err, test.wantCode)
+		}
+		if want := test.wantCode == codes.OK; handler.called != want {
+			t.Errorf("%v: handler received request = %v, want = %v", test.desc, handler.called, want)
+			continue
+		}
+		if !handler.called {
+			continue
+		}
+		if tree, ok := trees.FromContext(handler.ctx); !ok || tree.TreeId != test.treeID {
+			t.Errorf("%v: stream context has tree %v, want tree %v", test.desc, tree, test.treeID)
+		}
+	}
+}
+
+func timestampProto(t *testing.T, ts time.Time) *timestamp.Timestamp {
+	pb, err := ptypes.TimestampProto(ts)
+	if err != nil {
+		t.Fatalf("TimestampProto() returned err = %v", err)
+	}
+	return pb
+}
+
 func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -143,26 +317,29 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 
 	user := "llama"
 	tests := []struct {
-		desc         string
-		req          interface{}
-		spec         []quota.Spec
-		getTokensErr error
-		wantCode     codes.Code
+		desc          string
+		req           interface{}
+		spec          []quota.Spec
+		getTokensErr  error
+		failOpen      bool
+		wantCode      codes.Code
+		wantDegraded  float64
+		wantThrottled map[string]float64
 	}{
 		{
 			desc: "createTree",
 			req:  &trillian.CreateTreeRequest{Tree: testonly.LogTree},
 			spec: []quota.Spec{
-				{Group: quota.User, Kind: quota.Write, User: user},
-				{Group: quota.Global, Kind: quota.Write},
+				{Group: quota.User, Kind: quota.Admin, User: user},
+				{Group: quota.Global, Kind: quota.Admin},
 			},
 		},
 		{
 			desc: "listTrees",
 			req:  &trillian.ListTreesRequest{},
 			spec: []quota.Spec{
-				{Group: quota.User, Kind: quota.Read, User: user},
-				{Group: quota.Global, Kind: quota.Read},
+				{Group: quota.User, Kind: quota.Admin, User: user},
+				{Group: quota.Global, Kind: quota.Admin},
 			},
 		},
 		{
@@ -191,8 +368,46 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 				{Group: quota.Tree, Kind: quota.Read, TreeID: logTree.TreeId},
 				{Group: quota.Global, Kind: quota.Read},
 			},
-			getTokensErr: errors.New("not enough tokens"),
-			wantCode:     codes.ResourceExhausted,
+			getTokensErr:  errors.New("not enough tokens"),
+			wantCode:      codes.ResourceExhausted,
+			wantThrottled: map[string]float64{"Read": 1},
+		},
+		{
+			desc: "adminQuotaError",
+			req:  &trillian.GetTreeRequest{TreeId: logTree.TreeId},
+			spec: []quota.Spec{
+				{Group: quota.User, Kind: quota.Admin, User: user},
+				{Group: quota.Tree, Kind: quota.Admin, TreeID: logTree.TreeId},
+				{Group: quota.Global, Kind: quota.Admin},
+			},
+			getTokensErr:  quota.NewExhaustedError("not enough tokens"),
+			wantCode:      codes.ResourceExhausted,
+			wantThrottled: map[string]float64{"Admin": 1},
+		},
+		{
+			desc: "quotaExhaustedFailOpen",
+			req:  &trillian.QueueLeafRequest{LogId: logTree.TreeId},
+			spec: []quota.Spec{
+				{Group: quota.User, Kind: quota.Write, User: user},
+				{Group: quota.Tree, Kind: quota.Write, TreeID: logTree.TreeId},
+				{Group: quota.Global, Kind: quota.Write},
+			},
+			getTokensErr:  quota.NewExhaustedError("not enough tokens"),
+			failOpen:      true,
+			wantCode:      codes.ResourceExhausted,
+			wantThrottled: map[string]float64{"Write": 1},
+		},
+		{
+			desc: "quotaManagerErrorFailOpen",
+			req:  &trillian.QueueLeafRequest{LogId: logTree.TreeId},
+			spec: []quota.Spec{
+				{Group: quota.User, Kind: quota.Write, User: user},
+				{Group: quota.Tree, Kind: quota.Write, TreeID: logTree.TreeId},
+				{Group: quota.Global, Kind: quota.Write},
+			},
+			getTokensErr: errors.New("quota storage unavailable"),
+			failOpen:     true,
+			wantDegraded: 1,
 		},
 	}
 
@@ -203,7 +418,7 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 		qm.EXPECT().GetTokens(gomock.Any(), 1 /* numTokens */, test.spec).Return(test.getTokensErr)
 
 		handler := &fakeHandler{resp: "ok"}
-		intercept := &TrillianInterceptor{Admin: admin, QuotaManager: qm}
+		intercept := &TrillianInterceptor{Admin: admin, QuotaManager: qm, QuotaFailOpen: test.failOpen}
 
 		// resp and handler assertions are done by TestTrillianInterceptor_TreeInterception,
 		// we're only concerned with the quota logic here.
@@ -211,6 +426,103 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 		if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
 			t.Errorf("%v: UnaryInterceptor() returned err = %q, wantCode = %v", test.desc, err, test.wantCode)
 		}
+		intercept.metricsOnce.Do(intercept.createMetrics)
+		if got := intercept.quotaDegraded.Value(); got != test.wantDegraded {
+			t.Errorf("%v: degraded requests = %v, want %v", test.desc, got, test.wantDegraded)
+		}
+		for _, kind := range []quota.Kind{quota.Read, quota.Write, quota.Admin} {
+			if got, want := intercept.quotaThrottled.Value(kind.String()), test.wantThrottled[kind.String()]; got != want {
+				t.Errorf("%v: throttled %v requests = %v, want %v", test.desc, kind, got, want)
+			}
+		}
+	}
+}
+
+func TestTrillianInterceptor_StreamQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logTree := *testonly.LogTree
+	logTree.TreeId = 10
+
+	admin := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	admin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), logTree.TreeId).AnyTimes().Return(&logTree, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	user := "llama"
+	readSpec := []quota.Spec{
+		{Group: quota.User, Kind: quota.Read, User: user},
+		{Group: quota.Tree, Kind: quota.Read, TreeID: logTree.TreeId},
+		{Group: quota.Global, Kind: quota.Read},
+	}
+	tests := []struct {
+		desc         string
+		req          interface{}
+		getTokensErr error
+		wantCode     codes.Code
+	}{
+		{
+			desc: "getEntries",
+			req:  &trillian.GetEntriesRequest{LogId: logTree.TreeId, EndIndex: 10},
+		},
+		{
+			desc:         "getEntriesQuotaError",
+			req:          &trillian.GetEntriesRequest{LogId: logTree.TreeId, EndIndex: 10},
+			getTokensErr: quota.NewExhaustedError("not enough tokens"),
+			wantCode:     codes.ResourceExhausted,
+		},
+		{
+			desc: "getSignedLogRootHistory",
+			req:  &trillian.GetSignedLogRootHistoryRequest{LogId: logTree.TreeId},
+		},
+		{
+			desc:         "getSignedLogRootHistoryQuotaError",
+			req:          &trillian.GetSignedLogRootHistoryRequest{LogId: logTree.TreeId},
+			getTokensErr: quota.NewExhaustedError("not enough tokens"),
+			wantCode:     codes.ResourceExhausted,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		qm := quota.NewMockManager(ctrl)
+		qm.EXPECT().GetUser(gomock.Any(), gomock.Any()).Return(user)
+		qm.EXPECT().GetTokens(gomock.Any(), 1 /* numTokens */, readSpec).Return(test.getTokensErr)
+
+		handler := &fakeStreamHandler{reqType: test.req}
+		stream := &fakeServerStream{ctx: ctx, reqs: []interface{}{test.req}}
+		intercept := &TrillianInterceptor{Admin: admin, QuotaManager: qm}
+
+		err := intercept.StreamInterceptor(nil, stream, &grpc.StreamServerInfo{}, handler.run)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: StreamInterceptor() returned err = %v, wantCode = %v", test.desc, err, test.wantCode)
+		}
+		if want := test.wantCode == codes.OK; handler.called != want {
+			t.Errorf("%v: handler received request = %v, want = %v", test.desc, handler.called, want)
+		}
+	}
+}
+
+func TestTrillianInterceptor_HealthCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Health checks are unauthenticated, and use neither trees nor quota, so none of these
+	// are called.
+	intercept := TrillianInterceptor{
+		Admin:        storage.NewMockAdminStorage(ctrl),
+		QuotaManager: quota.NewMockManager(ctrl),
+		Authorizer:   NewAuthorizer(NewTokenAuthenticator(map[string]string{"token": "llama"}), nil),
+	}
+	handler := &fakeHandler{resp: &healthpb.HealthCheckResponse{}}
+	if _, err := intercept.UnaryInterceptor(context.Background(), &healthpb.HealthCheckRequest{}, &grpc.UnaryServerInfo{}, handler.run); err != nil {
+		t.Errorf("UnaryInterceptor(HealthCheckRequest) returned err = %v", err)
+	}
+	if !handler.called {
+		t.Error("UnaryInterceptor(HealthCheckRequest) didn't call the handler")
 	}
 }
 
@@ -221,31 +533,103 @@ func TestGetRPCInfo(t *testing.T) {
 		wantID                int64
 		wantType              trillian.TreeType
 		wantReadonly, wantErr bool
+		wantKind              quota.Kind
 	}{
 		{
-			desc: "createTree",
-			req:  &trillian.CreateTreeRequest{},
+			desc:     "createTree",
+			req:      &trillian.CreateTreeRequest{},
+			wantKind: quota.Admin,
 		},
 		{
 			desc:         "listTrees",
 			req:          &trillian.ListTreesRequest{},
 			wantReadonly: true,
+			wantKind:     quota.Admin,
+		},
+		{
+			desc:         "listPendingTrees",
+			req:          &trillian.ListPendingTreesRequest{},
+			wantReadonly: true,
+			wantKind:     quota.Admin,
+		},
+		{
+			desc:     "undeleteTree",
+			req:      &trillian.UndeleteTreeRequest{TreeId: 10},
+			wantKind: quota.Admin,
+		},
+		{
+			desc:         "listQuotaConfigs",
+			req:          &trillian.ListQuotaConfigsRequest{},
+			wantReadonly: true,
+			wantKind:     quota.Admin,
+		},
+		{
+			desc: "createQuotaConfig",
+			req: &trillian.CreateQuotaConfigRequest{
+				Config: &trillian.QuotaConfig{Bucket: &trillian.QuotaBucket{Group: "TREE", Kind: "WRITE", TreeId: 10}},
+			},
+			wantKind: quota.Admin,
+		},
+		{
+			desc:     "batchUpdateTrees",
+			req:      &trillian.BatchUpdateTreesRequest{Tree: &trillian.Tree{TreeId: 10}},
+			wantKind: quota.Admin,
 		},
 		{
 			desc:         "getAdminRequest",
 			req:          &trillian.GetTreeRequest{TreeId: 10},
 			wantID:       10,
 			wantReadonly: true,
+			wantKind:     quota.Admin,
+		},
+		{
+			desc:     "rwTreeIDAdminRequest",
+			req:      &trillian.DeleteTreeRequest{TreeId: 10},
+			wantID:   10,
+			wantKind: quota.Admin,
+		},
+		{
+			desc:     "rwTreeAdminRequest",
+			req:      &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: 10}},
+			wantID:   10,
+			wantKind: quota.Admin,
+		},
+		{
+			desc:     "repairTreeRootRequest",
+			req:      &trillian.RepairTreeRootRequest{TreeId: 10},
+			wantKind: quota.Admin,
+		},
+		{
+			desc:         "listDeadLetteredLeavesRequest",
+			req:          &trillian.ListDeadLetteredLeavesRequest{TreeId: 10},
+			wantID:       10,
+			wantReadonly: true,
+			wantKind:     quota.Admin,
+		},
+		{
+			desc:     "requeueDeadLetteredLeavesRequest",
+			req:      &trillian.RequeueDeadLetteredLeavesRequest{TreeId: 10},
+			wantID:   10,
+			wantKind: quota.Admin,
 		},
 		{
-			desc:   "rwTreeIDAdminRequest",
-			req:    &trillian.DeleteTreeRequest{TreeId: 10},
-			wantID: 10,
+			desc:     "rotateTreeKeyRequest",
+			req:      &trillian.RotateTreeKeyRequest{TreeId: 10, ActivationTreeSize: 100},
+			wantID:   10,
+			wantKind: quota.Admin,
 		},
 		{
-			desc:   "rwTreeAdminRequest",
-			req:    &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: 10}},
-			wantID: 10,
+			desc:     "retireTreeKeyRequest",
+			req:      &trillian.RetireTreeKeyRequest{TreeId: 10},
+			wantID:   10,
+			wantKind: quota.Admin,
+		},
+		{
+			desc:         "getQuotaTokensRequest",
+			req:          &trillian.GetQuotaTokensRequest{TreeId: 10},
+			wantID:       10,
+			wantReadonly: true,
+			wantKind:     quota.Admin,
 		},
 		{
 			desc:         "getLogRequest",
@@ -259,6 +643,7 @@ func TestGetRPCInfo(t *testing.T) {
 			req:      &trillian.QueueLeafRequest{LogId: 20},
 			wantID:   20,
 			wantType: trillian.TreeType_LOG,
+			wantKind: quota.Write,
 		},
 		{
 			desc:         "getMapRequest",
@@ -272,6 +657,14 @@ func TestGetRPCInfo(t *testing.T) {
 			req:      &trillian.SetMapLeavesRequest{MapId: 30},
 			wantID:   30,
 			wantType: trillian.TreeType_MAP,
+			wantKind: quota.Write,
+		},
+		{
+			desc:     "queueMapRequest",
+			req:      &trillian.QueueMapLeavesRequest{MapId: 30},
+			wantID:   30,
+			wantType: trillian.TreeType_MAP,
+			wantKind: quota.Write,
 		},
 		{
 			desc:    "unknownRequestType",
@@ -295,6 +688,9 @@ func TestGetRPCInfo(t *testing.T) {
 		if diff := pretty.Compare(info.opts, wantOpts); diff != "" {
 			t.Errorf("%v: info.opts diff:\n%v", test.desc, diff)
 		}
+		if got, want := info.kind, test.wantKind; got != want {
+			t.Errorf("%v: info.kind = %v, want = %v", test.desc, got, want)
+		}
 	}
 }
 
@@ -464,6 +860,48 @@ func (f *fakeHandler) run(ctx context.Context, req interface{}) (interface{}, er
 	return f.resp, f.err
 }
 
+// fakeServerStream is a grpc.ServerStream that receives reqs, then io.EOF.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	reqs []interface{}
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	if len(s.reqs) == 0 {
+		return io.EOF
+	}
+	reflect.ValueOf(m).Elem().Set(reflect.ValueOf(s.reqs[0]).Elem())
+	s.reqs = s.reqs[1:]
+	return nil
+}
+
+// fakeStreamHandler receives the first request of a stream, as gRPC does before calling the
+// handler of a server-streaming RPC.
+type fakeStreamHandler struct {
+	// reqType is a request of the type the stream receives.
+	reqType interface{}
+	called  bool
+	// Attributes recorded by run calls
+	ctx context.Context
+	req interface{}
+}
+
+func (f *fakeStreamHandler) run(_ interface{}, ss grpc.ServerStream) error {
+	req := reflect.New(reflect.TypeOf(f.reqType).Elem()).Interface()
+	if err := ss.RecvMsg(req); err != nil {
+		return err
+	}
+	f.called = true
+	f.ctx = ss.Context()
+	f.req = req
+	return nil
+}
+
 type fakeInterceptor struct {
 	key    interface{}
 	val    interface{}
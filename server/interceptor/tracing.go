@@ -0,0 +1,100 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package interceptor
+
+import (
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// instrumentation identifies the RPC instrumentation to the tracer.
+const instrumentation = "github.com/google/trillian/server/interceptor"
+
+// startServerSpan starts the span of an RPC, as a child of the span propagated by the
+// caller in ctx's metadata, if any.
+func startServerSpan(ctx context.Context, method string) (context.Context, monitoring.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return monitoring.StartServerSpan(ctx, instrumentation, method, md)
+}
+
+// endSpan records the outcome of an RPC in its span and ends it.
+func endSpan(span monitoring.Span, err error) {
+	if err != nil {
+		s, _ := status.FromError(err)
+		span.SetAttribute("rpc.grpc.status_code", s.Code().String())
+		span.SetError(s.Message())
+	}
+	span.End()
+}
+
+// TracingUnaryInterceptor is a grpc.UnaryServerInterceptor that traces each RPC in a span,
+// continuing the trace of the caller if it propagated one in the request metadata.
+func TracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	if treeID := requestTreeID(req); treeID != 0 {
+		span.SetAttribute("trillian.tree_id", treeID)
+	}
+	rsp, err := handler(ctx, req)
+	endSpan(span, err)
+	return rsp, err
+}
+
+// tracedServerStream is a grpc.ServerStream whose context carries the span of the RPC.
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// TracingStreamInterceptor is a grpc.StreamServerInterceptor that traces each streaming RPC
+// in a span, like TracingUnaryInterceptor.
+func TracingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+	endSpan(span, err)
+	return err
+}
+
+// TracingClientInterceptor is a grpc.UnaryClientInterceptor that propagates the trace in
+// ctx, if any, to the server in the request metadata.
+func TracingClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	monitoring.InjectSpan(ctx, md)
+	return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+}
+
+// requestTreeID returns the ID of the tree req addresses, or zero if it doesn't address a
+// single tree.
+func requestTreeID(req interface{}) int64 {
+	switch req := req.(type) {
+	case treeIDRequest:
+		return req.GetTreeId()
+	case logIDRequest:
+		return req.GetLogId()
+	case mapIDRequest:
+		return req.GetMapId()
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/monitoring/testonly"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTracingInterceptors(t *testing.T) {
+	tracer := &testonly.Tracer{}
+	monitoring.SetTracer(tracer)
+	defer monitoring.SetTracer(nil)
+
+	// The client propagates its span to the server in the request metadata.
+	ctx, clientSpan := tracer.StartSpan(context.Background(), "test", "client")
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := TracingClientInterceptor(ctx, "/trillian.TrillianLog/QueueLeaf", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("TracingClientInterceptor() returned err = %v", err)
+	}
+	clientSpan.End()
+
+	wantErr := status.Errorf(codes.NotFound, "tree not found")
+	var handlerSpan *testonly.Span
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerSpan = testonly.SpanFromContext(ctx)
+		return nil, wantErr
+	}
+	serverCtx := metadata.NewIncomingContext(context.Background(), md)
+	info := &grpc.UnaryServerInfo{FullMethod: "/trillian.TrillianLog/QueueLeaf"}
+	if _, err := TracingUnaryInterceptor(serverCtx, &trillian.QueueLeafRequest{LogId: 12}, info, handler); err != wantErr {
+		t.Fatalf("TracingUnaryInterceptor() returned err = %v, want %v", err, wantErr)
+	}
+
+	spans := tracer.Spans()
+	if got, want := len(spans), 2; got != want {
+		t.Fatalf("got %v spans, want %v", got, want)
+	}
+	client, server := spans[0], spans[1]
+	if got, want := server.Name, info.FullMethod; got != want {
+		t.Errorf("span name = %v, want %v", got, want)
+	}
+	if !server.Server || !server.Ended {
+		t.Errorf("span server = %v, ended = %v, want both", server.Server, server.Ended)
+	}
+	if server.Parent != client {
+		t.Errorf("server span parent = %+v, want client span", server.Parent)
+	}
+	if handlerSpan != server {
+		t.Errorf("handler ran in span %+v, want server span", handlerSpan)
+	}
+	if got, want := server.Err, "tree not found"; got != want {
+		t.Errorf("span error = %q, want %q", got, want)
+	}
+	if got, want := server.Attributes["trillian.tree_id"], int64(12); got != want {
+		t.Errorf("trillian.tree_id = %v, want %v", got, want)
+	}
+	if got, want := server.Attributes["rpc.grpc.status_code"], codes.NotFound.String(); got != want {
+		t.Errorf("rpc.grpc.status_code = %v, want %v", got, want)
+	}
+}
+
+func TestTracingStreamInterceptor(t *testing.T) {
+	tracer := &testonly.Tracer{}
+	monitoring.SetTracer(tracer)
+	defer monitoring.SetTracer(nil)
+
+	var handlerSpan *testonly.Span
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		handlerSpan = testonly.SpanFromContext(ss.Context())
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/trillian.TrillianMap/SetLeavesStream"}
+	if err := TracingStreamInterceptor(nil, &fakeServerStream{ctx: context.Background()}, info, handler); err != nil {
+		t.Fatalf("TracingStreamInterceptor() returned err = %v", err)
+	}
+	spans := tracer.Spans()
+	if len(spans) != 1 || spans[0].Name != info.FullMethod {
+		t.Fatalf("got spans %+v, want one named %v", spans, info.FullMethod)
+	}
+	if handlerSpan != spans[0] {
+		t.Errorf("handler ran in span %+v, want %+v", handlerSpan, spans[0])
+	}
+	if got := spans[0].Err; got != "" {
+		t.Errorf("span error = %q, want none", got)
+	}
+}
@@ -16,14 +16,18 @@ package server
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"reflect"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/util"
@@ -33,14 +37,21 @@ const (
 	minPreElectionPause    = 10 * time.Millisecond
 	minMasterCheckInterval = 50 * time.Millisecond
 	minMasterHoldInterval  = 10 * time.Second
+	electionCloseTimeout   = 10 * time.Second
 	logIDLabel             = "logid"
 )
 
 var (
-	once         sync.Once
-	knownLogs    monitoring.Gauge
-	resignations monitoring.Counter
-	isMaster     monitoring.Gauge
+	once           sync.Once
+	knownLogs      monitoring.Gauge
+	resignations   monitoring.Counter
+	isMaster       monitoring.Gauge
+	behind         monitoring.Gauge
+	batchSize      monitoring.Gauge
+	skippedClean   monitoring.Gauge
+	logOrigin      monitoring.Gauge
+	roundItems     monitoring.Counter
+	unsignableLogs monitoring.Gauge
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -50,6 +61,12 @@ func createMetrics(mf monitoring.MetricFactory) {
 	knownLogs = mf.NewGauge("known_logs", "Set to 1 for known logs (whether this instance is master or not)", logIDLabel)
 	resignations = mf.NewCounter("master_resignations", "Number of mastership resignations", logIDLabel)
 	isMaster = mf.NewGauge("is_master", "Whether this instance is master (0/1)", logIDLabel)
+	behind = mf.NewGauge("sequencer_behind", "Whether the log's unsequenced queue is growing faster than it is sequenced (0/1)", logIDLabel)
+	batchSize = mf.NewGauge("sequencer_batch_size", "Batch size used by the latest sequencing pass", logIDLabel)
+	skippedClean = mf.NewGauge("skipped_clean_logs", "Number of logs skipped by the latest pass as they had no pending work")
+	logOrigin = mf.NewGauge("log_origin", "Set to 1 for the origin of each log that has one, to relate log IDs to origins", logIDLabel, "origin")
+	roundItems = mf.NewCounter("round_items", "Number of items processed for each log in each round of a pass", logIDLabel, "round")
+	unsignableLogs = mf.NewGauge("tree_unsignable", "Whether the log's roots persistently fail to be signed, so it's being backed off (0/1)", logIDLabel)
 }
 
 // LogOperation defines a task that operates on a log. Examples are scheduling, signing,
@@ -73,6 +90,43 @@ type LogOperationInfo struct {
 	BatchSize int
 	// TimeSource should be used by the LogOperation to allow mocking for tests.
 	TimeSource util.TimeSource
+	// RootTimeSource supplies the timestamps of new signed roots, nil means
+	// TimeSource. Roots are never timestamped before the previous root of the
+	// log, whichever clock is used.
+	RootTimeSource util.TimeSource
+	// HashWorkers is the number of goroutines each sequencing pass uses to hash
+	// large batches into the Merkle tree, zero means GOMAXPROCS.
+	HashWorkers int
+	// CheckConsistency enables verifying a consistency proof between the
+	// previous and the new root of each sequencing pass before the new root is
+	// published.
+	CheckConsistency bool
+	// BehindThreshold is the number of consecutive passes that fill their batch
+	// after which a log is considered to be behind. Values below 1 mean 1.
+	BehindThreshold int
+	// AdaptiveBatch lets the batch size of a log that is behind double on each
+	// pass, up to MaxBatchSize. It drops back to BatchSize once the log catches
+	// up. If false, every pass processes at most BatchSize leaves.
+	AdaptiveBatch bool
+	// MaxBatchSize caps the batch size of logs that are behind when
+	// AdaptiveBatch is set. The batch size never grows if it's not above
+	// BatchSize.
+	MaxBatchSize int
+	// UnsignableThreshold is the number of consecutive passes that fail to sign
+	// a root, e.g. because the log's key is unavailable, after which a log is
+	// considered unsignable. Values below 1 mean 1.
+	UnsignableThreshold int
+	// UnsignableBackoff is how long passes skip an unsignable log for before
+	// retrying it. It doubles after each failed retry, up to
+	// MaxUnsignableBackoff. Zero means unsignable logs are retried on every
+	// pass.
+	UnsignableBackoff time.Duration
+	// MaxUnsignableBackoff caps the backoff of unsignable logs. The backoff
+	// never grows if it's not above UnsignableBackoff.
+	MaxUnsignableBackoff time.Duration
+	// ForceRoot makes a sequencing pass sign a new root even if there are no
+	// leaves to integrate. It's set for the passes run by SequenceNow.
+	ForceRoot bool
 
 	// The following parameters govern the overall scheduling of LogOperations
 	// by a LogOperationManager.
@@ -89,11 +143,38 @@ type LogOperationInfo struct {
 	MasterCheckInterval time.Duration
 	// MasterHoldInterval is the minimum interval to hold mastership for.
 	MasterHoldInterval time.Duration
+	// DrainTimeout is how long the pass in flight when OperationLoop is told to
+	// exit is given to complete before it's aborted. Mastership of the logs is
+	// only resigned afterwards. Zero means the pass is aborted straight away.
+	DrainTimeout time.Duration
 	// ResignOdds gives the chance of resigning mastership after each
 	// check interval, as the N for 1-in-N.
 	ResignOdds int
 	// NumWorkers is the number of worker goroutines to run in parallel.
 	NumWorkers int
+	// ShardCount is the number of instances logs are partitioned across. If it
+	// is above 1, only the logs whose ID hashes to ShardIndex are processed.
+	ShardCount int
+	// ShardIndex is the partition of logs processed by this instance, in
+	// [0, ShardCount).
+	ShardIndex int
+	// SkipCleanLogs makes each pass process logs with unsequenced leaves first,
+	// and skip logs without any unless they haven't been processed for
+	// CleanLogInterval.
+	SkipCleanLogs bool
+	// CleanLogInterval is the longest a log without pending work goes
+	// unprocessed if SkipCleanLogs is set. It should be below the
+	// MaxRootDuration of the logs, so their roots are still refreshed in time.
+	CleanLogInterval time.Duration
+	// FairBatchCap caps the number of items each log processes per round of a
+	// pass, including logs whose batch has grown through AdaptiveBatch, so a
+	// log with a large backlog can't hold up the others. Zero means no cap.
+	FairBatchCap int
+	// FairRounds is the number of rounds in each pass. Every log is processed
+	// in the first round, and in each later round the logs that reached
+	// FairBatchCap in the previous one are processed again, round-robin.
+	// Values below 2 mean a single round, as do passes without FairBatchCap.
+	FairRounds int
 }
 
 type electionRunner struct {
@@ -119,10 +200,15 @@ func (er *electionRunner) Run(ctx context.Context) {
 		glog.Errorf("%d: election.Start() failed: %v", er.logID, err)
 		return
 	}
-	defer func(ctx context.Context, er *electionRunner) {
+	defer func(er *electionRunner) {
 		glog.Infof("%d: shutdown election-monitoring loop", er.logID)
-		er.election.Close(ctx)
-	}(ctx, er)
+		// ctx is done by now, so resigning needs a context of its own.
+		cctx, cancel := context.WithTimeout(context.Background(), electionCloseTimeout)
+		defer cancel()
+		if err := er.election.Close(cctx); err != nil {
+			glog.Errorf("%d: election.Close() failed: %v", er.logID, err)
+		}
+	}(er)
 
 	for {
 		glog.V(1).Infof("%d: When I left you, I was but the learner", er.logID)
@@ -197,6 +283,22 @@ type LogOperationManager struct {
 	tracker        *util.MasterTracker
 	heldMutex      sync.Mutex
 	lastHeld       []int64
+
+	// passLocks serializes the passes over each log, so a pass run by SequenceNow
+	// never overlaps one run by the scheduling loop. Guarded by passLocksMutex.
+	passLocks      map[int64]*sync.Mutex
+	passLocksMutex sync.Mutex
+
+	// lastRun holds the time each log was last scheduled, for SkipCleanLogs.
+	lastRun map[int64]time.Time
+
+	// passErr is the error of the last pass, if it couldn't list logs or run mastership
+	// elections. Guarded by passErrMutex.
+	passErr      error
+	passErrMutex sync.Mutex
+
+	// batchSize, if positive, overrides info.BatchSize. Accessed atomically.
+	batchSize int64
 }
 
 // fixupElectionInfo ensures operation parameters have required minimum values.
@@ -225,26 +327,87 @@ func NewLogOperationManager(info LogOperationInfo, logOperation LogOperation) *L
 		info:           fixupElectionInfo(info),
 		logOperation:   logOperation,
 		electionRunner: make(map[int64]*electionRunner),
+		lastRun:        make(map[int64]time.Time),
+		passLocks:      make(map[int64]*sync.Mutex),
 	}
 }
 
-// getLogIDs returns the current set of active log IDs, whether we are master for them or not.
-func (l *LogOperationManager) getLogIDs(ctx context.Context) ([]int64, error) {
+// getLogIDs returns the current set of active log IDs in this instance's shard, whether we
+// are master for them or not. If SkipCleanLogs is set, it also returns the set of those
+// logs that have pending work.
+func (l *LogOperationManager) getLogIDs(ctx context.Context) ([]int64, map[int64]bool, error) {
 	tx, err := l.info.Registry.LogStorage.Snapshot(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tx for retrieving logIDs: %v", err)
+		return nil, nil, fmt.Errorf("failed to get tx for retrieving logIDs: %v", err)
 	}
 	defer tx.Close()
 
 	logIDs, err := tx.GetActiveLogIDs(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active logIDs: %v", err)
+		return nil, nil, fmt.Errorf("failed to get active logIDs: %v", err)
+	}
+
+	var pending map[int64]bool
+	if l.info.SkipCleanLogs {
+		pendingIDs, err := tx.GetActiveLogIDsWithPendingWork(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get active logIDs with pending work: %v", err)
+		}
+		pending = make(map[int64]bool)
+		for _, logID := range pendingIDs {
+			pending[logID] = true
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit getting logs: %v", err)
+		return nil, nil, fmt.Errorf("failed to commit getting logs: %v", err)
+	}
+
+	if l.info.ShardCount > 1 {
+		inShard := make([]int64, 0, len(logIDs))
+		for _, logID := range logIDs {
+			if shardFor(logID, l.info.ShardCount) == l.info.ShardIndex {
+				inShard = append(inShard, logID)
+			}
+		}
+		logIDs = inShard
+	}
+	return logIDs, pending, nil
+}
+
+// shardFor returns the shard in [0, shardCount) that logID belongs to.
+func shardFor(logID int64, shardCount int) int {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(logID))
+	h := fnv.New64a()
+	h.Write(b[:])
+	return int(h.Sum64() % uint64(shardCount))
+}
+
+// schedule orders logIDs for a pass. Logs with pending work go first. If SkipCleanLogs is
+// set, logs without pending work are dropped unless they have not been scheduled for
+// CleanLogInterval.
+func (l *LogOperationManager) schedule(logIDs []int64, pending map[int64]bool) []int64 {
+	if !l.info.SkipCleanLogs {
+		return logIDs
+	}
+	now := l.info.TimeSource.Now()
+	ret := make([]int64, 0, len(logIDs))
+	var clean []int64
+	for _, logID := range logIDs {
+		switch {
+		case pending[logID]:
+			ret = append(ret, logID)
+		case now.Sub(l.lastRun[logID]) >= l.info.CleanLogInterval:
+			clean = append(clean, logID)
+		}
+	}
+	skippedClean.Set(float64(len(logIDs) - len(ret) - len(clean)))
+	ret = append(ret, clean...)
+	for _, logID := range ret {
+		l.lastRun[logID] = now
 	}
-	return logIDs, nil
+	return ret
 }
 
 func (l *LogOperationManager) masterFor(ctx context.Context, allIDs []int64) ([]int64, error) {
@@ -297,7 +460,7 @@ func (l *LogOperationManager) updateHeldIDs(logIDs, allIDs []int64) {
 }
 
 func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
-	allIDs, err := l.getLogIDs(ctx)
+	allIDs, pending, err := l.getLogIDs(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve full list of log IDs: %v", err)
 	}
@@ -306,6 +469,7 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 		return fmt.Errorf("failed to determine log IDs we're master for: %v", err)
 	}
 	l.updateHeldIDs(logIDs, allIDs)
+	logIDs = l.schedule(logIDs, pending)
 
 	numWorkers := l.info.NumWorkers
 	if numWorkers == 0 {
@@ -314,9 +478,48 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 	}
 	glog.V(1).Infof("Beginning run for %v active log(s) using %d workers", len(logIDs), numWorkers)
 
-	var mu sync.Mutex
+	info := l.passInfo()
+	startBatch := time.Now()
+	runCount := 0
 	successCount := 0
 	itemCount := 0
+	for round := 0; round < fairRounds(&l.info) && len(logIDs) > 0; round++ {
+		counts := l.executeRound(ctx, round, logIDs, numWorkers, &info)
+		runCount += len(logIDs)
+		successCount += len(counts)
+
+		// Logs that reached the cap likely have more work, so get another round.
+		var next []int64
+		for _, logID := range logIDs {
+			count := counts[logID]
+			itemCount += count
+			if count >= l.info.FairBatchCap {
+				next = append(next, logID)
+			}
+		}
+		logIDs = next
+	}
+
+	d := time.Now().Sub(startBatch).Seconds()
+	glog.Infof("Group run completed in %.2f seconds: %v succeeded, %v failed, %v items processed", d, successCount, runCount-successCount, itemCount)
+
+	return nil
+}
+
+// fairRounds returns the number of rounds in each pass.
+func fairRounds(info *LogOperationInfo) int {
+	if info.FairBatchCap <= 0 || info.FairRounds < 1 {
+		return 1
+	}
+	return info.FairRounds
+}
+
+// executeRound runs the log operation once over each of logIDs with info, using numWorkers
+// goroutines. It returns the number of items processed for each log whose pass succeeded.
+func (l *LogOperationManager) executeRound(ctx context.Context, round int, logIDs []int64, numWorkers int, info *LogOperationInfo) map[int64]int {
+	var mu sync.Mutex
+	counts := make(map[int64]int)
+	roundLabel := strconv.Itoa(round)
 
 	// Build a channel of the logIDs that need to be processed.
 	toProcess := make(chan int64, len(logIDs))
@@ -326,7 +529,6 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 	close(toProcess)
 
 	// Set off a collection of transient worker goroutines to process the pending logIDs.
-	startBatch := time.Now()
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
@@ -339,7 +541,7 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 				}
 
 				start := time.Now()
-				count, err := l.logOperation.ExecutePass(ctx, logID, &l.info)
+				count, err := l.executePass(ctx, logID, info)
 				if err != nil {
 					glog.Warningf("ExecutePass(%v) failed: %v", logID, err)
 					continue
@@ -351,9 +553,9 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 				} else {
 					glog.V(1).Infof("%v: no items to process", logID)
 				}
+				roundItems.Add(float64(count), strconv.FormatInt(logID, 10), roundLabel)
 				mu.Lock()
-				successCount++
-				itemCount += count
+				counts[logID] = count
 				mu.Unlock()
 			}
 		}()
@@ -361,30 +563,139 @@ func (l *LogOperationManager) getLogsAndExecutePass(ctx context.Context) error {
 
 	// Wait for the workers to consume all of the logIDs
 	wg.Wait()
-	d := time.Now().Sub(startBatch).Seconds()
-	glog.Infof("Group run completed in %.2f seconds: %v succeeded, %v failed, %v items processed", d, successCount, len(logIDs)-successCount, itemCount)
+	return counts
+}
 
-	return nil
+// SetBatchSize changes the BatchSize of the passes started from now on, e.g. when
+// it's reloaded from a flag file. Values below 1 revert to the BatchSize the
+// manager was created with.
+func (l *LogOperationManager) SetBatchSize(batchSize int) {
+	atomic.StoreInt64(&l.batchSize, int64(batchSize))
+}
+
+// passInfo returns the info passes are run with.
+func (l *LogOperationManager) passInfo() LogOperationInfo {
+	info := l.info
+	if batchSize := atomic.LoadInt64(&l.batchSize); batchSize > 0 {
+		info.BatchSize = int(batchSize)
+	}
+	return info
+}
+
+// passLock returns the mutex serializing passes over logID.
+func (l *LogOperationManager) passLock(logID int64) *sync.Mutex {
+	l.passLocksMutex.Lock()
+	defer l.passLocksMutex.Unlock()
+	mu, ok := l.passLocks[logID]
+	if !ok {
+		mu = &sync.Mutex{}
+		l.passLocks[logID] = mu
+	}
+	return mu
+}
+
+// executePass runs the log operation over logID, waiting for any pass over the
+// same log that is already running to finish first.
+func (l *LogOperationManager) executePass(ctx context.Context, logID int64, info *LogOperationInfo) (int, error) {
+	mu := l.passLock(logID)
+	mu.Lock()
+	defer mu.Unlock()
+	return l.logOperation.ExecutePass(ctx, logID, info)
+}
+
+// isMasterFor returns whether this instance sequences logID: it's in this
+// instance's shard, and mastership for it was held at the start of the latest
+// pass. Without an ElectionFactory the instance acts as master for every log.
+func (l *LogOperationManager) isMasterFor(logID int64) bool {
+	if l.info.ShardCount > 1 && shardFor(logID, l.info.ShardCount) != l.info.ShardIndex {
+		return false
+	}
+	if l.info.Registry.ElectionFactory == nil {
+		return true
+	}
+	l.heldMutex.Lock()
+	defer l.heldMutex.Unlock()
+	for _, id := range l.lastHeld {
+		if id == logID {
+			return true
+		}
+	}
+	return false
+}
+
+// SequenceNow runs a sequencing pass over logID immediately, outside the
+// scheduling loop, and returns the resulting signed root. The pass signs a new
+// root even if there are no leaves to integrate. If the scheduling loop is
+// processing the same log, SequenceNow waits for it to finish, so the two never
+// sequence the log concurrently. It fails if this instance isn't currently the
+// master for logID.
+func (l *LogOperationManager) SequenceNow(ctx context.Context, logID int64) (*trillian.SignedLogRoot, error) {
+	if !l.isMasterFor(logID) {
+		return nil, fmt.Errorf("not master for log %d", logID)
+	}
+	info := l.passInfo()
+	info.ForceRoot = true
+	if _, err := l.executePass(ctx, logID, &info); err != nil {
+		return nil, fmt.Errorf("failed to sequence log %d: %v", logID, err)
+	}
+
+	tx, err := l.info.Registry.LogStorage.SnapshotForTree(ctx, logID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx for log %d: %v", logID, err)
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest root of log %d: %v", logID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit getting root of log %d: %v", logID, err)
+	}
+	return &root, nil
 }
 
 // OperationSingle performs a single pass of the manager.
 func (l *LogOperationManager) OperationSingle(ctx context.Context) {
-	if err := l.getLogsAndExecutePass(ctx); err != nil {
+	err := l.getLogsAndExecutePass(ctx)
+	l.setPassErr(err)
+	if err != nil {
 		glog.Errorf("failed to perform operation: %v", err)
 	}
 }
 
+func (l *LogOperationManager) setPassErr(err error) {
+	l.passErrMutex.Lock()
+	defer l.passErrMutex.Unlock()
+	l.passErr = err
+}
+
+// Healthy returns nil if the last pass could list the logs to operate on and run their
+// mastership elections, and the error it failed with otherwise. Failures of individual
+// logs' operations don't count.
+func (l *LogOperationManager) Healthy() error {
+	l.passErrMutex.Lock()
+	defer l.passErrMutex.Unlock()
+	return l.passErr
+}
+
 // OperationLoop starts the manager working. It continues until told to exit.
 // TODO(Martin2112): No mechanism for error reporting etc., this is OK for v1 but needs work
 func (l *LogOperationManager) OperationLoop(ctx context.Context) {
 	glog.Infof("Log operation manager starting")
 
+	// Passes, and the election runners, are only cancelled DrainTimeout after ctx is,
+	// so that a pass in flight can complete while we're still master of its logs.
+	passCtx, cancel := drainContext(ctx, l.info.DrainTimeout)
+	defer cancel()
+
 	// Outer loop, runs until terminated
 loop:
 	for {
 		// TODO(alcutter): want a child context with deadline here?
 		start := time.Now()
-		if err := l.getLogsAndExecutePass(ctx); err != nil {
+		err := l.getLogsAndExecutePass(passCtx)
+		l.setPassErr(err)
+		if err != nil {
 			glog.Errorf("failed to execute operation on logs: %v", err)
 		}
 
@@ -403,7 +714,12 @@ loop:
 		wait := l.info.RunInterval - duration
 		if wait > 0 {
 			glog.V(1).Infof("Processing started at %v for %v; wait %v before next run", start, duration, wait)
-			time.Sleep(wait)
+			select {
+			case <-ctx.Done():
+				glog.Infof("Log operation manager shutting down")
+				break loop
+			case <-time.After(wait):
+			}
 		} else {
 			glog.V(1).Infof("Processing started at %v for %v; start next run immediately", start, duration)
 		}
@@ -421,3 +737,34 @@ loop:
 	l.runnerWG.Wait()
 	glog.Infof("wait for termination of election runners...done")
 }
+
+// detachedContext carries the values of its parent, but never has a deadline
+// and is never cancelled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// drainContext returns a context carrying the values of ctx, which is only
+// cancelled timeout after ctx is done, or when the returned function is called.
+func drainContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	dctx, cancel := context.WithCancel(detachedContext{ctx})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-dctx.Done():
+			return
+		}
+		select {
+		case <-time.After(timeout):
+			glog.Warningf("Log operation manager pass still running after %v, aborting it", timeout)
+			cancel()
+		case <-dctx.Done():
+		}
+	}()
+	return dctx, cancel
+}
@@ -21,10 +21,12 @@ import (
 	"math"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/util"
@@ -58,6 +60,9 @@ func TestLogOperationManagerSnapshotFails(t *testing.T) {
 	lom := NewLogOperationManager(info, mockLogOp)
 
 	lom.OperationSingle(ctx)
+	if err := lom.Healthy(); err == nil {
+		t.Error("Healthy() = nil after a pass that couldn't list logs, want err")
+	}
 }
 
 func TestLogOperationManagerGetLogsFails(t *testing.T) {
@@ -150,6 +155,198 @@ func TestLogOperationManagerPassesIDs(t *testing.T) {
 	lom := NewLogOperationManager(info, mockLogOp)
 
 	lom.OperationSingle(ctx)
+	if err := lom.Healthy(); err != nil {
+		t.Errorf("Healthy() = %v, want nil", err)
+	}
+}
+
+func TestLogOperationManagerSetBatchSize(t *testing.T) {
+	ctx := context.Background()
+	logID := int64(451)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).Times(2).Return([]int64{logID}, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).Times(2).Return(mockTx, nil)
+
+	mockLogOp := NewMockLogOperation(ctrl)
+	gomock.InOrder(
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, logOpInfoMatcher{20}),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, logOpInfoMatcher{50}),
+	)
+
+	info := defaultLogOperationInfo(extension.Registry{LogStorage: mockStorage})
+	lom := NewLogOperationManager(info, mockLogOp)
+
+	lom.SetBatchSize(20)
+	lom.OperationSingle(ctx)
+	// Resetting the batch size reverts to the original one.
+	lom.SetBatchSize(0)
+	lom.OperationSingle(ctx)
+}
+
+func TestLogOperationManagerShards(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var logIDs []int64
+	for logID := int64(1); logID <= 20; logID++ {
+		logIDs = append(logIDs, logID)
+	}
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).Return(logIDs, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).Return(mockTx, nil)
+
+	registry := extension.Registry{
+		LogStorage: mockStorage,
+	}
+
+	mockLogOp := NewMockLogOperation(ctrl)
+	for _, logID := range logIDs {
+		if shardFor(logID, 3) == 1 {
+			mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, gomock.Any())
+		}
+	}
+
+	info := defaultLogOperationInfo(registry)
+	info.ShardCount = 3
+	info.ShardIndex = 1
+	lom := NewLogOperationManager(info, mockLogOp)
+
+	lom.OperationSingle(ctx)
+}
+
+func TestShardFor(t *testing.T) {
+	const shardCount = 4
+	counts := make([]int, shardCount)
+	for logID := int64(0); logID < 1000; logID++ {
+		if got := shardFor(logID, 1); got != 0 {
+			t.Errorf("shardFor(%v, 1) = %v, want 0", logID, got)
+		}
+		shard := shardFor(logID, shardCount)
+		if shard < 0 || shard >= shardCount {
+			t.Fatalf("shardFor(%v, %v) = %v, want in [0, %v)", logID, shardCount, shard, shardCount)
+		}
+		counts[shard]++
+	}
+	for shard, count := range counts {
+		if count == 0 {
+			t.Errorf("shard %v has no logs", shard)
+		}
+	}
+}
+
+func TestLogOperationManagerSkipsCleanLogs(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cleanID1 := int64(451)
+	pendingID := int64(145)
+	cleanID2 := int64(514)
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).AnyTimes().Return([]int64{cleanID1, pendingID, cleanID2}, nil)
+	mockTx.EXPECT().GetActiveLogIDsWithPendingWork(gomock.Any()).AnyTimes().Return([]int64{pendingID}, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(mockTx, nil)
+
+	registry := extension.Registry{
+		LogStorage: mockStorage,
+	}
+
+	mockLogOp := NewMockLogOperation(ctrl)
+	gomock.InOrder(
+		// The first pass visits every log, the one with pending work first.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), pendingID, gomock.Any()),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), cleanID1, gomock.Any()),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), cleanID2, gomock.Any()),
+		// The second pass skips the clean logs.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), pendingID, gomock.Any()),
+		// The third pass is after CleanLogInterval, so visits every log again.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), pendingID, gomock.Any()),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), cleanID1, gomock.Any()),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), cleanID2, gomock.Any()),
+	)
+
+	timeSource := util.NewFakeTimeSource(fakeTime)
+	info := defaultLogOperationInfo(registry)
+	info.TimeSource = timeSource
+	info.SkipCleanLogs = true
+	info.CleanLogInterval = time.Minute
+	lom := NewLogOperationManager(info, mockLogOp)
+
+	lom.OperationSingle(ctx)
+	timeSource.Set(fakeTime.Add(30 * time.Second))
+	lom.OperationSingle(ctx)
+	timeSource.Set(fakeTime.Add(time.Minute))
+	lom.OperationSingle(ctx)
+}
+
+func TestLogOperationManagerFairRounds(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	busyID := int64(451)
+	quietID := int64(145)
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).AnyTimes().Return([]int64{busyID, quietID}, nil)
+	mockTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockTx.EXPECT().Close().AnyTimes().Return(nil)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(mockTx, nil)
+
+	registry := extension.Registry{
+		LogStorage: mockStorage,
+	}
+
+	const batchCap = 10
+	mockLogOp := NewMockLogOperation(ctrl)
+	gomock.InOrder(
+		// Every log is processed in the first round.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), busyID, gomock.Any()).Return(batchCap, nil),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), quietID, gomock.Any()).Return(3, nil),
+		// Only the log that reached the cap gets later rounds.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), busyID, gomock.Any()).Return(batchCap, nil),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), busyID, gomock.Any()).Return(batchCap, nil),
+		// The next pass starts over, and the busy log runs out of work.
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), busyID, gomock.Any()).Return(batchCap-1, nil),
+		mockLogOp.EXPECT().ExecutePass(gomock.Any(), quietID, gomock.Any()).Return(0, nil),
+	)
+
+	info := defaultLogOperationInfo(registry)
+	info.FairBatchCap = batchCap
+	info.FairRounds = 3
+	lom := NewLogOperationManager(info, mockLogOp)
+
+	lom.OperationSingle(ctx)
+	lom.OperationSingle(ctx)
+}
+
+func TestFairRounds(t *testing.T) {
+	for _, test := range []struct {
+		info *LogOperationInfo
+		want int
+	}{
+		{info: &LogOperationInfo{}, want: 1},
+		{info: &LogOperationInfo{FairRounds: 5}, want: 1},
+		{info: &LogOperationInfo{FairBatchCap: 10}, want: 1},
+		{info: &LogOperationInfo{FairBatchCap: 10, FairRounds: 5}, want: 5},
+	} {
+		if got := fairRounds(test.info); got != test.want {
+			t.Errorf("fairRounds(%+v) = %v, want %v", test.info, got, test.want)
+		}
+	}
 }
 
 func TestShouldResign(t *testing.T) {
@@ -393,3 +590,197 @@ func TestElectionRunnerRun(t *testing.T) {
 		}
 	}
 }
+
+func TestLogOperationManagerSequenceNow(t *testing.T) {
+	ctx := context.Background()
+	logID := int64(451)
+	root := trillian.SignedLogRoot{LogId: logID, TreeSize: 5, TreeRevision: 3, RootHash: []byte("root")}
+
+	tests := []struct {
+		desc    string
+		info    LogOperationInfo
+		passErr error
+		wantErr bool
+		noPass  bool
+	}{
+		{desc: "ok"},
+		{desc: "pass-fails", passErr: errors.New("sequence"), wantErr: true},
+		{
+			desc:    "not-master",
+			info:    LogOperationInfo{Registry: extension.Registry{ElectionFactory: util.NoopElectionFactory{InstanceID: "test"}}},
+			wantErr: true,
+			noPass:  true,
+		},
+		{
+			desc:    "other-shard",
+			info:    LogOperationInfo{ShardCount: 2, ShardIndex: 1 - shardFor(logID, 2)},
+			wantErr: true,
+			noPass:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockLogOp := NewMockLogOperation(ctrl)
+			if !test.noPass {
+				mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, gomock.Any()).Do(func(_ context.Context, _ int64, info *LogOperationInfo) {
+					if !info.ForceRoot {
+						t.Error("ExecutePass() called without ForceRoot")
+					}
+				}).Return(0, test.passErr)
+			}
+			if !test.wantErr {
+				mockTx := storage.NewMockReadOnlyLogTreeTX(ctrl)
+				mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID).Return(mockTx, nil)
+				mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(root, nil)
+				mockTx.EXPECT().Commit().Return(nil)
+				mockTx.EXPECT().Close().Return(nil)
+			}
+
+			info := test.info
+			info.Registry.LogStorage = mockStorage
+			lom := NewLogOperationManager(info, mockLogOp)
+			got, err := lom.SequenceNow(ctx, logID)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("SequenceNow()=_,%v; want err: %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(*got, root) {
+				t.Errorf("SequenceNow()=%v; want %v", got, root)
+			}
+		})
+	}
+}
+
+func TestLogOperationManagerSequenceNowWaitsForPass(t *testing.T) {
+	ctx := context.Background()
+	logID := int64(451)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTx := storage.NewMockReadOnlyLogTreeTX(ctrl)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID).Return(mockTx, nil)
+
+	// The first pass blocks until released, the second must not start before then.
+	var running, passes int32
+	release := make(chan bool)
+	started := make(chan bool)
+	mockLogOp := NewMockLogOperation(ctrl)
+	mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, gomock.Any()).Times(2).Do(func(_ context.Context, _ int64, info *LogOperationInfo) {
+		if atomic.AddInt32(&running, 1) != 1 {
+			t.Error("ExecutePass() called while another pass over the log was running")
+		}
+		if atomic.AddInt32(&passes, 1) == 1 {
+			started <- true
+			<-release
+		}
+		atomic.AddInt32(&running, -1)
+	}).Return(0, nil)
+
+	info := defaultLogOperationInfo(extension.Registry{LogStorage: mockStorage})
+	lom := NewLogOperationManager(info, mockLogOp)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := lom.executePass(ctx, logID, &lom.info); err != nil {
+			t.Errorf("executePass()=_,%v; want _,nil", err)
+		}
+	}()
+	<-started
+	done := make(chan bool)
+	go func() {
+		if _, err := lom.SequenceNow(ctx, logID); err != nil {
+			t.Errorf("SequenceNow()=_,%v; want _,nil", err)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("SequenceNow() returned while a pass over the log was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	wg.Wait()
+}
+
+func TestLogOperationManagerOperationLoopDrains(t *testing.T) {
+	logID := int64(451)
+	var tests = []struct {
+		drainTimeout time.Duration
+		wantAborted  bool
+	}{
+		{drainTimeout: time.Minute, wantAborted: false},
+		{drainTimeout: 0, wantAborted: true},
+	}
+	for _, test := range tests {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+			mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).Return([]int64{logID}, nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockStorage.EXPECT().Snapshot(gomock.Any()).Return(mockTx, nil)
+
+			// The loop is told to exit while the first pass is in flight, there must be no
+			// second pass.
+			ctx, cancel := context.WithCancel(context.Background())
+			var aborted bool
+			mockLogOp := NewMockLogOperation(ctrl)
+			mockLogOp.EXPECT().ExecutePass(gomock.Any(), logID, gomock.Any()).Do(func(passCtx context.Context, _ int64, _ *LogOperationInfo) {
+				cancel()
+				select {
+				case <-passCtx.Done():
+					aborted = true
+				case <-time.After(50 * time.Millisecond):
+				}
+			}).Return(0, nil)
+
+			info := defaultLogOperationInfo(extension.Registry{LogStorage: mockStorage})
+			info.DrainTimeout = test.drainTimeout
+			lom := NewLogOperationManager(info, mockLogOp)
+
+			lom.OperationLoop(ctx)
+			if aborted != test.wantAborted {
+				t.Errorf("OperationLoop(DrainTimeout=%v) aborted pass: %v, want %v", test.drainTimeout, aborted, test.wantAborted)
+			}
+		}()
+	}
+}
+
+func TestDrainContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "value"), time.Minute)
+	dctx, dcancel := drainContext(ctx, 50*time.Millisecond)
+	defer dcancel()
+
+	if got := dctx.Value(ctxKey{}); got != "value" {
+		t.Errorf("drainContext().Value()=%v, want %q", got, "value")
+	}
+	if _, ok := dctx.Deadline(); ok {
+		t.Error("drainContext().Deadline() set, want none")
+	}
+	cancel()
+	if err := dctx.Err(); err != nil {
+		t.Errorf("drainContext().Err()=%v straight after ctx is done, want nil", err)
+	}
+	select {
+	case <-dctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("drainContext() not done after timeout")
+	}
+}
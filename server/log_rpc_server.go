@@ -15,13 +15,23 @@
 package server
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
 	"github.com/google/trillian/trees"
 	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
@@ -35,11 +45,33 @@ import (
 // Pass this as a fixed value to proof calculations. It's used as the max depth of the tree
 const proofMaxBitLen = 64
 
+// getEntriesChunkSize is the maximum number of leaves sent in each GetEntries response.
+var getEntriesChunkSize = int64(1000)
+
+// rootHistoryChunkSize is the maximum number of roots sent in each GetSignedLogRootHistory
+// response.
+var rootHistoryChunkSize = 1000
+
 // TrillianLogRPCServer implements the RPC API defined in the proto
 type TrillianLogRPCServer struct {
 	registry    extension.Registry
 	timeSource  util.TimeSource
 	leafCounter monitoring.Counter
+
+	leafHashMismatches monitoring.Counter
+
+	verifyProofs         bool
+	verificationFailures monitoring.Counter
+	proofChecks          bool
+
+	checkpoints checkpointCache
+	queueBuffer *queueBuffer
+	nodeCache   *cache.NodeCache
+
+	signerProgress *signerProgressCheck
+
+	staleRoots *staleCache
+	staleReads monitoring.Counter
 }
 
 // NewTrillianLogRPCServer creates a new RPC server backed by a LogStorageProvider.
@@ -56,6 +88,60 @@ func NewTrillianLogRPCServer(registry extension.Registry, timeSource util.TimeSo
 			"Number of leaves requested to be queued",
 			"status",
 		),
+		verificationFailures: mf.NewCounter(
+			"proof_self_verification_failures",
+			"Number of proofs computed by the server that failed to verify before being returned",
+			"method",
+		),
+		leafHashMismatches: mf.NewCounter(
+			"leaf_identity_hash_mismatches",
+			"Number of queued leaves rejected as their identity hash isn't the tree's leaf hash of their value",
+			logIDLabel,
+		),
+		staleReads: mf.NewCounter(
+			"stale_reads",
+			"Number of read requests answered from memory with possibly stale data while storage was unavailable",
+			"method",
+		),
+	}
+}
+
+// SetProofVerification controls whether inclusion and consistency proofs are verified
+// against the latest signed root before being returned. A proof that fails verification
+// is never returned; the request fails with an Internal error instead.
+func (t *TrillianLogRPCServer) SetProofVerification(verify bool) {
+	t.verifyProofs = verify
+}
+
+// SetConsistencyProofChecks controls whether CheckConsistencyProof is served. It's disabled
+// by default, as clients relying on it trust the server to check the log's proofs.
+func (t *TrillianLogRPCServer) SetConsistencyProofChecks(enabled bool) {
+	t.proofChecks = enabled
+}
+
+// SetNodeCache makes the server read the Merkle tree nodes of read-only requests, such as
+// those of proofs, through c, so they can be shared by concurrent requests.
+func (t *TrillianLogRPCServer) SetNodeCache(c *cache.NodeCache) {
+	t.nodeCache = c
+}
+
+// SetQueueBuffer makes QueueLeaves buffer leaves in memory and write those of concurrent
+// requests for the same log in batches, smoothing bursts of submissions. Requests still only
+// succeed once their leaves are written. Close must be called to write any buffered leaves
+// before the server exits.
+func (t *TrillianLogRPCServer) SetQueueBuffer(opts QueueBufferOptions) {
+	mf := t.registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	t.queueBuffer = newQueueBuffer(t.writeQueued, opts, mf)
+}
+
+// Close writes the leaves buffered by QueueLeaves and makes later QueueLeaves requests fail.
+// It does nothing unless SetQueueBuffer was called.
+func (t *TrillianLogRPCServer) Close() {
+	if t.queueBuffer != nil {
+		t.queueBuffer.close()
 	}
 }
 
@@ -95,23 +181,44 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 		return nil, err
 	}
 	ctx = trees.NewContext(ctx, tree)
-
-	for i := range req.Leaves {
-		req.Leaves[i].MerkleLeafHash = hasher.HashLeaf(req.Leaves[i].LeafValue)
+	if err := t.checkSignerProgress(ctx, tree); err != nil {
+		return nil, err
 	}
 
-	tx, err := t.prepareStorageTx(ctx, logID)
-	if err != nil {
-		return nil, err
+	now := t.timeSource.Now()
+	validator := log.GetLeafValidator(tree.TreeType)
+	for i, leaf := range req.Leaves {
+		if err := validateLeafQueueTimestamp(tree, leaf, now); err != nil {
+			return nil, err
+		}
+		leaf.MerkleLeafHash = hasher.HashLeaf(leaf.LeafValue)
+		if tree.VerifyLeafIdentityHash && !bytes.Equal(leaf.LeafIdentityHash, leaf.MerkleLeafHash) {
+			t.leafHashMismatches.Inc(strconv.FormatInt(logID, 10))
+			return nil, status.Errorf(codes.InvalidArgument, "leaf %d has identity hash %x, want the leaf hash of its value %x", i, leaf.LeafIdentityHash, leaf.MerkleLeafHash)
+		}
+		if validator != nil {
+			if err := validator.ValidateLeaf(ctx, tree, leaf); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "leaf %d is invalid: %v", i, err)
+			}
+		}
 	}
-	defer tx.Close()
 
-	existingLeaves, err := tx.QueueLeaves(ctx, req.Leaves, t.timeSource.Now())
-	if err != nil {
-		return nil, err
+	// The queue is counted before the leaves are added to it, so that new leaves
+	// count the earlier ones of the request instead.
+	var queueDepth int64
+	if req.ReturnQueueDepth {
+		if queueDepth, err = t.countQueuedLeaves(ctx, logID); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := t.commitAndLog(ctx, logID, tx, "QueueLeaves"); err != nil {
+	var existingLeaves []*trillian.LogLeaf
+	if t.queueBuffer != nil {
+		existingLeaves, err = t.queueBuffer.add(ctx, tree, req.Leaves)
+	} else {
+		existingLeaves, err = t.writeQueued(ctx, tree, req.Leaves)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -128,6 +235,10 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 		} else {
 			// Return the leaf from the request if it is new.
 			queuedLeaf := trillian.QueuedLogLeaf{Leaf: req.Leaves[i]}
+			if req.ReturnQueueDepth {
+				queuedLeaf.QueueDepth = queueDepth
+				queueDepth++
+			}
 			queuedLeaves = append(queuedLeaves, &queuedLeaf)
 			t.leafCounter.Inc("new")
 		}
@@ -135,6 +246,44 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 	return &trillian.QueueLeavesResponse{QueuedLeaves: queuedLeaves}, nil
 }
 
+// writeQueued queues leaves of tree in storage.
+func (t *TrillianLogRPCServer) writeQueued(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	ctx = trees.NewContext(ctx, tree)
+	tx, err := t.prepareStorageTx(ctx, tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	existingLeaves, err := tx.QueueLeaves(ctx, leaves, t.timeSource.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "QueueLeaves"); err != nil {
+		return nil, err
+	}
+	return existingLeaves, nil
+}
+
+// countQueuedLeaves returns the number of leaves queued but not yet sequenced in a log. Leaves
+// still held by the queue buffer aren't counted.
+func (t *TrillianLogRPCServer) countQueuedLeaves(ctx context.Context, logID int64) (int64, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	count, err := tx.CountLeaves(ctx, storage.LeafFilter{UnsequencedOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	if err := t.commitAndLog(ctx, logID, tx, "CountQueuedLeaves"); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // GetInclusionProof obtains the proof of inclusion in the tree for a leaf that has been sequenced.
 // Similar to the get proof by hash handler but one less step as we don't need to look up the index
 func (t *TrillianLogRPCServer) GetInclusionProof(ctx context.Context, req *trillian.GetInclusionProofRequest) (*trillian.GetInclusionProofResponse, error) {
@@ -153,20 +302,27 @@ func (t *TrillianLogRPCServer) GetInclusionProof(ctx context.Context, req *trill
 	// have a usable tree revision
 	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
 	if err != nil {
-		return nil, err
+		return t.staleInclusionProof(ctx, req, hasher, err)
 	}
 	defer tx.Close()
 
 	root, err := tx.LatestSignedLogRoot(ctx)
 	if err != nil {
-		return nil, err
+		return t.staleInclusionProof(ctx, req, hasher, err)
 	}
+	t.rememberRoot(logID, root)
 
 	proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, req.LeafIndex, root.TreeSize)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := t.checkProof(logID, "GetInclusionProof", func() error {
+		return newProofVerifier(tx, hasher, &root).verifyInclusionAtIndex(ctx, req.TreeSize, &proof)
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -174,6 +330,58 @@ func (t *TrillianLogRPCServer) GetInclusionProof(ctx context.Context, req *trill
 	return &trillian.GetInclusionProofResponse{Proof: &proof}, nil
 }
 
+// GetInclusionProofWithRoot obtains the proof of inclusion of a sequenced leaf in the tree of the
+// requested size, along with the root that was signed at that size. The proof is verified
+// against that root, rather than the latest, when proof verification is enabled.
+func (t *TrillianLogRPCServer) GetInclusionProofWithRoot(ctx context.Context, req *trillian.GetInclusionProofWithRootRequest) (*trillian.GetInclusionProofWithRootResponse, error) {
+	if err := validateGetInclusionProofWithRootRequest(req); err != nil {
+		return nil, err
+	}
+	logID := req.LogId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, logID, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	signedRoot, err := tx.SignedLogRootAtSize(ctx, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	// An empty root means no retained root was signed at that size.
+	if signedRoot.RootHash == nil {
+		return nil, status.Errorf(codes.NotFound, "no signed root at tree size %v", req.TreeSize)
+	}
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, req.LeafIndex, root.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.checkProof(logID, "GetInclusionProofWithRoot", func() error {
+		return newProofVerifier(tx, hasher, &signedRoot).verifyInclusionAtIndex(ctx, req.TreeSize, &proof)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, logID, tx, "GetInclusionProofWithRoot"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetInclusionProofWithRootResponse{Proof: &proof, SignedLogRoot: &signedRoot}, nil
+}
+
 // GetInclusionProofByHash obtains proofs of inclusion by leaf hash. Because some logs can
 // contain duplicate hashes it is possible for multiple proofs to be returned.
 func (t *TrillianLogRPCServer) GetInclusionProofByHash(ctx context.Context, req *trillian.GetInclusionProofByHashRequest) (*trillian.GetInclusionProofByHashResponse, error) {
@@ -221,6 +429,18 @@ func (t *TrillianLogRPCServer) GetInclusionProofByHash(ctx context.Context, req
 		proofs = append(proofs, &proof)
 	}
 
+	if err := t.checkProof(logID, "GetInclusionProofByHash", func() error {
+		v := newProofVerifier(tx, hasher, &root)
+		for i, leaf := range leaves {
+			if err := v.verifyInclusion(ctx, req.TreeSize, leaf.MerkleLeafHash, proofs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -256,10 +476,17 @@ func (t *TrillianLogRPCServer) GetConsistencyProof(ctx context.Context, req *tri
 		return nil, err
 	}
 
+	if req.SecondTreeSize > root.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.SecondTreeSize: %v > signed tree size: %v, want <= ", req.SecondTreeSize, root.TreeSize)
+	}
+
 	nodeFetches, err := merkle.CalcConsistencyProofNodeAddresses(req.FirstTreeSize, req.SecondTreeSize, root.TreeSize, proofMaxBitLen)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkProofFetches(nodeFetches, root.TreeSize); err != nil {
+		return nil, err
+	}
 
 	// Do all the node fetches at the second tree revision, which is what the node ids were calculated
 	// against.
@@ -268,6 +495,12 @@ func (t *TrillianLogRPCServer) GetConsistencyProof(ctx context.Context, req *tri
 		return nil, err
 	}
 
+	if err := t.checkProof(logID, "GetConsistencyProof", func() error {
+		return newProofVerifier(tx, hasher, &root).verifyConsistency(ctx, req.FirstTreeSize, req.SecondTreeSize, &proof)
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -276,25 +509,310 @@ func (t *TrillianLogRPCServer) GetConsistencyProof(ctx context.Context, req *tri
 	return &trillian.GetConsistencyProofResponse{Proof: &proof}, nil
 }
 
+// CheckConsistencyProof checks a consistency proof held by the client. The client's root
+// hashes are first compared with the log's roots at their tree sizes, then the proof is
+// verified between them with the same code clients use.
+func (t *TrillianLogRPCServer) CheckConsistencyProof(ctx context.Context, req *trillian.CheckConsistencyProofRequest) (*trillian.CheckConsistencyProofResponse, error) {
+	if !t.proofChecks {
+		return nil, status.Errorf(codes.Unimplemented, "CheckConsistencyProof is disabled on this server")
+	}
+	if err := validateCheckConsistencyProofRequest(req); err != nil {
+		return nil, err
+	}
+	logID := req.LogId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, logID, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.SecondTreeSize > root.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "CheckConsistencyProofRequest.SecondTreeSize: %v > signed tree size %v", req.SecondTreeSize, root.TreeSize)
+	}
+
+	resp, err := checkConsistencyProof(ctx, newProofVerifier(tx, hasher, &root), req)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.commitAndLog(ctx, logID, tx, "CheckConsistencyProof"); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func checkConsistencyProof(ctx context.Context, v *proofVerifier, req *trillian.CheckConsistencyProofRequest) (*trillian.CheckConsistencyProofResponse, error) {
+	for _, r := range []struct {
+		treeSize int64
+		rootHash []byte
+	}{
+		{req.FirstTreeSize, req.FirstRootHash},
+		{req.SecondTreeSize, req.SecondRootHash},
+	} {
+		logRoot, err := v.rootAtSize(ctx, r.treeSize)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(logRoot, r.rootHash) {
+			return &trillian.CheckConsistencyProofResponse{
+				Result: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_ROOT_MISMATCH,
+				Detail: fmt.Sprintf("root hash %x at tree size %v, log has %x", r.rootHash, r.treeSize, logRoot),
+			}, nil
+		}
+	}
+
+	if err := v.verifier.VerifyConsistencyProof(req.FirstTreeSize, req.SecondTreeSize, req.FirstRootHash, req.SecondRootHash, req.GetProof().GetHashes()); err != nil {
+		return &trillian.CheckConsistencyProofResponse{
+			Result: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_INVALID,
+			Detail: err.Error(),
+		}, nil
+	}
+	return &trillian.CheckConsistencyProofResponse{Result: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_VALID}, nil
+}
+
+// GetProofByMerkleHash returns an inclusion proof for the leaf with the given Merkle hash
+// together with a consistency proof from req.FirstTreeSize, both at req.TreeSize. The two
+// proofs are served from one storage transaction and share their node reads.
+func (t *TrillianLogRPCServer) GetProofByMerkleHash(ctx context.Context, req *trillian.GetProofByMerkleHashRequest) (*trillian.GetProofByMerkleHashResponse, error) {
+	if err := validateGetProofByMerkleHashRequest(req); err != nil {
+		return nil, err
+	}
+	logID := req.LogId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, logID, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.TreeSize > root.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "GetProofByMerkleHashRequest.TreeSize: %v, want <= signed tree size (%v)", req.TreeSize, root.TreeSize)
+	}
+
+	leaves, err := tx.GetLeavesByHash(ctx, [][]byte{req.LeafHash}, req.OrderBySequence)
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) < 1 {
+		return nil, status.Errorf(codes.NotFound, "No leaves for hash: %x", req.LeafHash)
+	}
+	// The same hash may have been sequenced more than once, so use the first
+	// leaf that is included at the requested tree size.
+	var leaf *trillian.LogLeaf
+	for _, l := range leaves {
+		if l.LeafIndex < req.TreeSize {
+			leaf = l
+			break
+		}
+	}
+	if leaf == nil {
+		return nil, status.Errorf(codes.NotFound, "No leaf for hash %x is included at tree size %v", req.LeafHash, req.TreeSize)
+	}
+	leafIndex := leaf.LeafIndex
+
+	inclusionFetches, err := merkle.CalcInclusionProofNodeAddresses(req.TreeSize, leafIndex, root.TreeSize, proofMaxBitLen)
+	if err != nil {
+		return nil, err
+	}
+	consistencyFetches, err := merkle.CalcConsistencyProofNodeAddresses(req.FirstTreeSize, req.TreeSize, root.TreeSize, proofMaxBitLen)
+	if err != nil {
+		return nil, err
+	}
+	for _, fetches := range [][]merkle.NodeFetch{inclusionFetches, consistencyFetches} {
+		if err := checkProofFetches(fetches, root.TreeSize); err != nil {
+			return nil, err
+		}
+	}
+
+	proofs, err := fetchNodesAndBuildProofs(ctx, tx, hasher, tx.ReadRevision(),
+		[]int64{leafIndex, 0}, [][]merkle.NodeFetch{inclusionFetches, consistencyFetches})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.checkProof(logID, "GetProofByMerkleHash", func() error {
+		v := newProofVerifier(tx, hasher, &root)
+		if err := v.verifyInclusion(ctx, req.TreeSize, leaf.MerkleLeafHash, &proofs[0]); err != nil {
+			return err
+		}
+		return v.verifyConsistency(ctx, req.FirstTreeSize, req.TreeSize, &proofs[1])
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, logID, tx, "GetProofByMerkleHash"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetProofByMerkleHashResponse{
+		InclusionProof:   &proofs[0],
+		ConsistencyProof: &proofs[1],
+	}, nil
+}
+
 // GetLatestSignedLogRoot obtains the latest published tree root for the Merkle Tree that
 // underlies the log.
 func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *trillian.GetLatestSignedLogRootRequest) (*trillian.GetLatestSignedLogRootResponse, error) {
 	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
 	if err != nil {
-		return nil, err
+		return t.staleLatestSignedLogRoot(req.LogId, err)
 	}
 	defer tx.Close()
 
 	signedRoot, err := tx.LatestSignedLogRoot(ctx)
 	if err != nil {
-		return nil, err
+		return t.staleLatestSignedLogRoot(req.LogId, err)
+	}
+	var token []byte
+	if req.IncludeTimestampToken {
+		if token, err = tx.GetTimestampToken(ctx, signedRoot.TreeRevision); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLatestSignedLogRoot"); err != nil {
 		return nil, err
 	}
+	t.rememberRoot(req.LogId, signedRoot)
+
+	return &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: &signedRoot, TimestampToken: token}, nil
+}
+
+// GetSignedLogRootAtTime obtains the signed root that was in effect at the requested time,
+// which is the retained root with the latest timestamp not after it.
+func (t *TrillianLogRPCServer) GetSignedLogRootAtTime(ctx context.Context, req *trillian.GetSignedLogRootAtTimeRequest) (*trillian.GetSignedLogRootAtTimeResponse, error) {
+	if req.Timestamp == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "a timestamp is required")
+	}
+	ts, err := ptypes.Timestamp(req.Timestamp)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid timestamp: %v", err)
+	}
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	signedRoot, err := tx.SignedLogRootAtTime(ctx, ts.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetSignedLogRootAtTime"); err != nil {
+		return nil, err
+	}
 
-	return &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: &signedRoot}, nil
+	// An empty root means the timestamp predates all the roots that have been kept.
+	if signedRoot.RootHash == nil {
+		return nil, status.Errorf(codes.NotFound, "no signed root at or before %v", ts)
+	}
+	return &trillian.GetSignedLogRootAtTimeResponse{SignedLogRoot: &signedRoot}, nil
+}
+
+// AddCosignature stores a witness's cosignature of the log's latest signed root.
+func (t *TrillianLogRPCServer) AddCosignature(ctx context.Context, req *trillian.AddCosignatureRequest) (*trillian.AddCosignatureResponse, error) {
+	root, cosig := req.GetSignedLogRoot(), req.GetCosignature()
+	switch {
+	case root == nil:
+		return nil, status.Error(codes.InvalidArgument, "a signed_log_root is required")
+	case cosig == nil:
+		return nil, status.Error(codes.InvalidArgument, "a cosignature is required")
+	}
+
+	tree, _, err := t.getTreeAndHasher(ctx, req.LogId, false /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	var witness *trillian.Witness
+	for _, w := range tree.Witnesses {
+		if w.Name == cosig.WitnessName {
+			witness = w
+			break
+		}
+	}
+	if witness == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown witness: %q", cosig.WitnessName)
+	}
+	pub, err := keys.NewFromPublicDER(witness.GetPublicKey().GetDer())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse public key of witness %q: %v", witness.Name, err)
+	}
+	if err := crypto.Verify(pub, crypto.HashLogRoot(*root), cosig.Signature); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cosignature by witness %q failed to verify: %v", witness.Name, err)
+	}
+
+	tx, err := t.prepareStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	// Roots are identified by their tree revision, which the log doesn't sign. Insisting on
+	// the latest root lets us take the revision from storage rather than from the request.
+	latest, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest.TreeSize != root.TreeSize || latest.TimestampNanos != root.TimestampNanos || !bytes.Equal(latest.RootHash, root.RootHash) {
+		return nil, status.Errorf(codes.FailedPrecondition, "signed_log_root is not the latest root of log %v", req.LogId)
+	}
+
+	if err := tx.StoreCosignature(ctx, latest.TreeRevision, cosig); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "AddCosignature"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.AddCosignatureResponse{}, nil
+}
+
+// GetLatestCosignedLogRoot returns the most recent root that has been cosigned by at least
+// one witness, along with its cosignatures.
+func (t *TrillianLogRPCServer) GetLatestCosignedLogRoot(ctx context.Context, req *trillian.GetLatestCosignedLogRootRequest) (*trillian.GetLatestCosignedLogRootResponse, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	root, cosigs, err := tx.LatestCosignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLatestCosignedLogRoot"); err != nil {
+		return nil, err
+	}
+
+	resp := &trillian.GetLatestCosignedLogRootResponse{Cosignatures: cosigs}
+	if len(cosigs) > 0 {
+		resp.SignedLogRoot = &root
+	}
+	return resp, nil
 }
 
 // GetSequencedLeafCount returns the number of leaves that have been integrated into the Merkle
@@ -320,9 +838,13 @@ func (t *TrillianLogRPCServer) GetSequencedLeafCount(ctx context.Context, req *t
 
 // GetLeavesByIndex obtains one or more leaves based on their sequence number within the
 // tree. It is not possible to fetch leaves that have been queued but not yet integrated.
+// If the request allows partial results, see getLeavesByIndexPartial.
 // TODO: Validate indices against published tree size in case we implement write sharding that
 // can get ahead of this point. Not currently clear what component should own this state.
 func (t *TrillianLogRPCServer) GetLeavesByIndex(ctx context.Context, req *trillian.GetLeavesByIndexRequest) (*trillian.GetLeavesByIndexResponse, error) {
+	if req.AllowPartial {
+		return t.getLeavesByIndexPartial(ctx, req)
+	}
 	if !validateLeafIndices(req.LeafIndex) {
 		return &trillian.GetLeavesByIndexResponse{}, nil
 	}
@@ -347,6 +869,66 @@ func (t *TrillianLogRPCServer) GetLeavesByIndex(ctx context.Context, req *trilli
 	}, nil
 }
 
+// getLeavesByIndexPartial returns the leaves that could be found, along with
+// the status of each requested index. Indices are checked against the size of
+// the latest signed root rather than failing the whole request.
+func (t *TrillianLogRPCServer) getLeavesByIndexPartial(ctx context.Context, req *trillian.GetLeavesByIndexRequest) (*trillian.GetLeavesByIndexResponse, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]trillian.LeafIndexStatus, len(req.LeafIndex))
+	requested := make(map[int64]bool)
+	var indices []int64
+	for i, index := range req.LeafIndex {
+		if index < 0 || index >= root.TreeSize {
+			status[i] = trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE
+			continue
+		}
+		if !requested[index] {
+			requested[index] = true
+			indices = append(indices, index)
+		}
+	}
+
+	var leaves []*trillian.LogLeaf
+	if len(indices) > 0 {
+		if leaves, err = tx.GetLeavesByIndex(ctx, indices); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLeavesByIndex"); err != nil {
+		return nil, err
+	}
+
+	found := make(map[int64]bool)
+	for _, leaf := range leaves {
+		found[leaf.LeafIndex] = true
+	}
+	for i, index := range req.LeafIndex {
+		switch {
+		case status[i] == trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE:
+		case found[index]:
+			status[i] = trillian.LeafIndexStatus_LEAF_FOUND
+		default:
+			status[i] = trillian.LeafIndexStatus_LEAF_NOT_FOUND
+		}
+	}
+
+	return &trillian.GetLeavesByIndexResponse{
+		Leaves:     leaves,
+		LeafStatus: status,
+	}, nil
+}
+
 // GetLeavesByHash obtains one or more leaves based on their tree hash. It is not possible
 // to fetch leaves that have been queued but not yet integrated. Logs may accept duplicate
 // entries so this may return more results than the number of hashes in the request.
@@ -398,6 +980,12 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 		return nil, status.Errorf(codes.Internal, "expected one leaf from storage but got: %d", len(leaves))
 	}
 
+	if err := t.checkProof(logID, "GetEntryAndProof", func() error {
+		return newProofVerifier(tx, hasher, &root).verifyInclusion(ctx, req.TreeSize, leaves[0].MerkleLeafHash, &proof)
+	}); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -409,6 +997,291 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 	}, nil
 }
 
+// GetLatestLeafByIdentityHashPrefix returns the highest-indexed sequenced leaf whose identity
+// hash starts with the requested prefix, along with its inclusion proof against the latest
+// signed root. If several leaves match, the latest is returned and the response counts them
+// all, so callers can tell that the prefix was ambiguous.
+func (t *TrillianLogRPCServer) GetLatestLeafByIdentityHashPrefix(ctx context.Context, req *trillian.GetLatestLeafByIdentityHashPrefixRequest) (*trillian.GetLatestLeafByIdentityHashPrefixResponse, error) {
+	if len(req.LeafIdentityHashPrefix) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "GetLatestLeafByIdentityHashPrefixRequest.LeafIdentityHashPrefix empty")
+	}
+	logID := req.LogId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, logID, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := tx.GetLatestLeafByIdentityHashPrefix(ctx, req.LeafIdentityHashPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		return nil, status.Errorf(codes.NotFound, "no leaf with identity hash prefix %x", req.LeafIdentityHashPrefix)
+	}
+	count, err := tx.CountLeaves(ctx, storage.LeafFilter{LeafIdentityHashPrefix: req.LeafIdentityHashPrefix, SequencedOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, root.TreeSize, leaf.LeafIndex, root.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.checkProof(logID, "GetLatestLeafByIdentityHashPrefix", func() error {
+		return newProofVerifier(tx, hasher, &root).verifyInclusion(ctx, root.TreeSize, leaf.MerkleLeafHash, &proof)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, logID, tx, "GetLatestLeafByIdentityHashPrefix"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetLatestLeafByIdentityHashPrefixResponse{
+		Leaf:          leaf,
+		Proof:         &proof,
+		SignedLogRoot: &root,
+		MatchCount:    count,
+	}, nil
+}
+
+// HasLeaves reports whether leaves with the given identity hashes are present in the log.
+// Leaves that are queued but not yet integrated are only reported if the request asks for
+// unsequenced leaves to be included.
+func (t *TrillianLogRPCServer) HasLeaves(ctx context.Context, req *trillian.HasLeavesRequest) (*trillian.HasLeavesResponse, error) {
+	if len(req.LeafIdentityHash) == 0 || !validateLeafHashes(req.LeafIdentityHash) {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid leaf identity hash")
+	}
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	present, err := tx.HasLeaves(ctx, req.LeafIdentityHash, req.IncludeUnsequenced)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "HasLeaves"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.HasLeavesResponse{Present: present}, nil
+}
+
+// CountLeaves returns the number of leaves matching the request, computed by storage. Counts
+// including leaves that are queued but not yet integrated are best-effort.
+func (t *TrillianLogRPCServer) CountLeaves(ctx context.Context, req *trillian.CountLeavesRequest) (*trillian.CountLeavesResponse, error) {
+	filter := storage.LeafFilter{
+		LeafIdentityHashPrefix: req.LeafIdentityHashPrefix,
+		SequencedOnly:          req.SequencedOnly,
+	}
+	if req.MinQueueTimestamp != nil {
+		ts, err := ptypes.Timestamp(req.MinQueueTimestamp)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid min_queue_timestamp: %v", err)
+		}
+		filter.MinQueueTimestampNanos = ts.UnixNano()
+	}
+	if req.MaxQueueTimestamp != nil {
+		ts, err := ptypes.Timestamp(req.MaxQueueTimestamp)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid max_queue_timestamp: %v", err)
+		}
+		filter.MaxQueueTimestampNanos = ts.UnixNano()
+	}
+	if min, max := filter.MinQueueTimestampNanos, filter.MaxQueueTimestampNanos; min != 0 && max != 0 && min >= max {
+		return nil, status.Errorf(codes.InvalidArgument, "min_queue_timestamp must be before max_queue_timestamp")
+	}
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	count, err := tx.CountLeaves(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "CountLeaves"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.CountLeavesResponse{LeafCount: count}, nil
+}
+
+// GetEntries streams the requested range of leaves, one chunk per response. Each chunk is
+// read in its own transaction, so none is held open while Send blocks on gRPC flow control
+// waiting for a slow client. The range is capped at the size of the latest signed root
+// read when the call starts. Like unary RPCs, it's authorized and charged quota by the
+// interceptor, so the tree is taken from the stream's context.
+func (t *TrillianLogRPCServer) GetEntries(req *trillian.GetEntriesRequest, stream trillian.TrillianLog_GetEntriesServer) error {
+	if err := validateGetEntriesRequest(req); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	tree, _, err := t.getTreeAndHasher(ctx, req.LogId, true /* readonly */)
+	if err != nil {
+		return err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return err
+	}
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err == nil {
+		err = t.commitAndLog(ctx, req.LogId, tx, "GetEntries")
+	}
+	tx.Close()
+	if err != nil {
+		return err
+	}
+
+	end := root.TreeSize
+	if req.EndIndex != 0 && req.EndIndex < end {
+		end = req.EndIndex
+	}
+	for start := req.StartIndex; start < end; {
+		count := end - start
+		if count > getEntriesChunkSize {
+			count = getEntriesChunkSize
+		}
+		leaves, err := t.getLeafRange(ctx, req.LogId, start, count)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&trillian.GetEntriesResponse{Leaves: leaves}); err != nil {
+			return err
+		}
+		start += count
+	}
+	return nil
+}
+
+// GetSignedLogRootHistory streams the retained signed roots of a log from the requested
+// revision, one chunk per response. As with GetEntries, each chunk is read in its own
+// transaction, the stream ends at the latest signed root read when the call starts, and
+// the interceptor authorizes the call and charges its quota.
+func (t *TrillianLogRPCServer) GetSignedLogRootHistory(req *trillian.GetSignedLogRootHistoryRequest, stream trillian.TrillianLog_GetSignedLogRootHistoryServer) error {
+	if err := validateGetSignedLogRootHistoryRequest(req); err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	tree, _, err := t.getTreeAndHasher(ctx, req.LogId, true /* readonly */)
+	if err != nil {
+		return err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, req.LogId)
+	if err != nil {
+		return err
+	}
+	latest, err := tx.LatestSignedLogRoot(ctx)
+	if err == nil {
+		err = t.commitAndLog(ctx, req.LogId, tx, "GetSignedLogRootHistory")
+	}
+	tx.Close()
+	if err != nil {
+		return err
+	}
+	if latest.TimestampNanos == 0 {
+		// The log has no roots yet.
+		return nil
+	}
+
+	for after := req.StartRevision - 1; after < latest.TreeRevision; {
+		roots, err := t.getRootRange(ctx, req.LogId, after)
+		if err != nil {
+			return err
+		}
+		// Drop any roots signed since the call started.
+		for len(roots) > 0 && roots[len(roots)-1].TreeRevision > latest.TreeRevision {
+			roots = roots[:len(roots)-1]
+		}
+		if len(roots) == 0 {
+			return nil
+		}
+		if err := stream.Send(&trillian.GetSignedLogRootHistoryResponse{SignedLogRoots: roots}); err != nil {
+			return err
+		}
+		after = roots[len(roots)-1].TreeRevision
+	}
+	return nil
+}
+
+// getRootRange returns up to rootHistoryChunkSize roots after revision after, in revision
+// order.
+func (t *TrillianLogRPCServer) getRootRange(ctx context.Context, logID, after int64) ([]*trillian.SignedLogRoot, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	roots, err := tx.SignedLogRoots(ctx, after, rootHistoryChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.commitAndLog(ctx, logID, tx, "GetSignedLogRootHistory"); err != nil {
+		return nil, err
+	}
+
+	ret := make([]*trillian.SignedLogRoot, 0, len(roots))
+	for i := range roots {
+		ret = append(ret, &roots[i])
+	}
+	return ret, nil
+}
+
+// getLeafRange returns the count leaves starting at index start, in index order.
+func (t *TrillianLogRPCServer) getLeafRange(ctx context.Context, logID, start, count int64) ([]*trillian.LogLeaf, error) {
+	indices := make([]int64, count)
+	for i := range indices {
+		indices[i] = start + int64(i)
+	}
+
+	tx, err := t.prepareReadOnlyStorageTx(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	leaves, err := tx.GetLeavesByIndex(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.commitAndLog(ctx, logID, tx, "GetEntries"); err != nil {
+		return nil, err
+	}
+
+	if got, want := len(leaves), len(indices); got != want {
+		return nil, status.Errorf(codes.Internal, "got %v leaves from index %v, want %v", got, start, want)
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].LeafIndex < leaves[j].LeafIndex })
+	return leaves, nil
+}
+
 func (t *TrillianLogRPCServer) prepareStorageTx(ctx context.Context, treeID int64) (storage.LogTreeTX, error) {
 	tx, err := t.registry.LogStorage.BeginForTree(ctx, treeID)
 	if err != nil {
@@ -422,9 +1295,39 @@ func (t *TrillianLogRPCServer) prepareReadOnlyStorageTx(ctx context.Context, tre
 	if err != nil {
 		return nil, err
 	}
+	if t.nodeCache != nil {
+		return cachedNodesTX{ReadOnlyLogTreeTX: tx, treeID: treeID, cache: t.nodeCache}, nil
+	}
 	return tx, err
 }
 
+// cachedNodesTX reads the nodes of a snapshot through a NodeCache. Snapshots read committed
+// revisions only, so their nodes can be cached.
+type cachedNodesTX struct {
+	storage.ReadOnlyLogTreeTX
+	treeID int64
+	cache  *cache.NodeCache
+}
+
+func (t cachedNodesTX) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return t.cache.GetMerkleNodes(ctx, t.ReadOnlyLogTreeTX, t.treeID, treeRevision, ids)
+}
+
+// checkProof runs verify if proof verification is enabled. A failed verification is counted
+// against method and turned into an Internal error; other errors are returned unchanged.
+func (t *TrillianLogRPCServer) checkProof(logID int64, method string, verify func() error) error {
+	if !t.verifyProofs {
+		return nil
+	}
+	err := verify()
+	if _, ok := err.(proofVerificationError); ok {
+		t.verificationFailures.Inc(method)
+		glog.Errorf("%v: %v: %v", logID, method, err)
+		return status.Errorf(codes.Internal, "%v: %v", method, err)
+	}
+	return err
+}
+
 func (t *TrillianLogRPCServer) commitAndLog(ctx context.Context, logID int64, tx storage.ReadOnlyLogTreeTX, op string) error {
 	err := tx.Commit()
 	if err != nil {
@@ -458,11 +1361,22 @@ func validateLeafHashes(leafHashes [][]byte) bool {
 // and makes additional checks on the returned proof. Returns a Proof suitable for inclusion in
 // an RPC response
 func getInclusionProofForLeafIndex(ctx context.Context, tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher, snapshot, leafIndex, treeSize int64) (trillian.Proof, error) {
+	// Reject impossible requests before computing anything for them.
+	if snapshot > treeSize {
+		return trillian.Proof{}, status.Errorf(codes.InvalidArgument, "tree size %v > signed tree size %v, want <= ", snapshot, treeSize)
+	}
+	if leafIndex < 0 || leafIndex >= snapshot {
+		return trillian.Proof{}, status.Errorf(codes.InvalidArgument, "leaf index %v not in tree of size %v", leafIndex, snapshot)
+	}
+
 	// We have the tree size and leaf index so we know the nodes that we need to serve the proof
 	proofNodeIDs, err := merkle.CalcInclusionProofNodeAddresses(snapshot, leafIndex, treeSize, proofMaxBitLen)
 	if err != nil {
 		return trillian.Proof{}, err
 	}
+	if err := checkProofFetches(proofNodeIDs, treeSize); err != nil {
+		return trillian.Proof{}, err
+	}
 
 	return fetchNodesAndBuildProof(ctx, tx, hasher, tx.ReadRevision(), leafIndex, proofNodeIDs)
 }
@@ -15,20 +15,36 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"math"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys"
+	ktestonly "github.com/google/trillian/crypto/keys/testonly"
+	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
 	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
 	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/util"
 	"github.com/kylelemons/godebug/pretty"
 	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -68,6 +84,9 @@ var (
 	getConsistencyProofRequest25 = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 10, SecondTreeSize: 25}
 	getConsistencyProofRequest7  = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 4, SecondTreeSize: 7}
 
+	getProofByMerkleHashRequest7  = trillian.GetProofByMerkleHashRequest{LogId: logID1, LeafHash: []byte("ahash"), FirstTreeSize: 4, TreeSize: 7}
+	getProofByMerkleHashRequest25 = trillian.GetProofByMerkleHashRequest{LogId: logID1, LeafHash: []byte("ahash"), FirstTreeSize: 4, TreeSize: 25}
+
 	nodeIdsInclusionSize7Index2 = []storage.NodeID{
 		stestonly.MustCreateNodeIDForTreeCoords(0, 3, 64),
 		stestonly.MustCreateNodeIDForTreeCoords(1, 0, 64),
@@ -180,6 +199,302 @@ func TestGetLeavesByIndex(t *testing.T) {
 	}
 }
 
+func TestGetLeavesByIndexPartial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// signedRoot1 has a tree size of 7, and leaf 3 is missing from storage.
+	req := &trillian.GetLeavesByIndexRequest{LogId: logID1, LeafIndex: []int64{1, 3, 7, -2, 1}, AllowPartial: true}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), req.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{1, 3}).Return([]*trillian.LogLeaf{leaf1}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	mockTx.EXPECT().IsOpen().AnyTimes().Return(false)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, req.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	resp, err := server.GetLeavesByIndex(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetLeavesByIndex()=_,%v, want: _,nil", err)
+	}
+	if len(resp.Leaves) != 1 || !proto.Equal(resp.Leaves[0], leaf1) {
+		t.Errorf("GetLeavesByIndex().Leaves=%v, want: [%v]", resp.Leaves, leaf1)
+	}
+	want := []trillian.LeafIndexStatus{
+		trillian.LeafIndexStatus_LEAF_FOUND,
+		trillian.LeafIndexStatus_LEAF_NOT_FOUND,
+		trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE,
+		trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE,
+		trillian.LeafIndexStatus_LEAF_FOUND,
+	}
+	if diff := pretty.Compare(resp.LeafStatus, want); diff != "" {
+		t.Errorf("GetLeavesByIndex().LeafStatus diff:\n%v", diff)
+	}
+}
+
+func TestGetLeavesByIndexPartialNoneInRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &trillian.GetLeavesByIndexRequest{LogId: logID1, LeafIndex: []int64{7, 100}, AllowPartial: true}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), req.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	mockTx.EXPECT().IsOpen().AnyTimes().Return(false)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, req.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	resp, err := server.GetLeavesByIndex(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetLeavesByIndex()=_,%v, want: _,nil", err)
+	}
+	if len(resp.Leaves) != 0 {
+		t.Errorf("GetLeavesByIndex().Leaves=%v, want: []", resp.Leaves)
+	}
+	want := []trillian.LeafIndexStatus{
+		trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE,
+		trillian.LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE,
+	}
+	if diff := pretty.Compare(resp.LeafStatus, want); diff != "" {
+		t.Errorf("GetLeavesByIndex().LeafStatus diff:\n%v", diff)
+	}
+}
+
+// fakeGetEntriesStream records the responses sent on a GetEntries stream.
+type fakeGetEntriesStream struct {
+	grpc.ServerStream
+	resps []*trillian.GetEntriesResponse
+}
+
+func (s *fakeGetEntriesStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeGetEntriesStream) Send(resp *trillian.GetEntriesResponse) error {
+	s.resps = append(s.resps, resp)
+	return nil
+}
+
+func TestGetEntries(t *testing.T) {
+	defer func(size int64) { getEntriesChunkSize = size }(getEntriesChunkSize)
+	getEntriesChunkSize = 3
+
+	leaf := func(index int64) *trillian.LogLeaf {
+		return &trillian.LogLeaf{LeafIndex: index, LeafValue: []byte{byte(index)}}
+	}
+	// signedRoot1 has a tree size of 7.
+	tests := []struct {
+		desc       string
+		start, end int64
+		chunks     [][]int64
+	}{
+		{desc: "toTreeSize", start: 1, chunks: [][]int64{{1, 2, 3}, {4, 5, 6}}},
+		{desc: "partialChunk", start: 0, end: 4, chunks: [][]int64{{0, 1, 2}, {3}}},
+		{desc: "cappedAtTreeSize", start: 5, end: 100, chunks: [][]int64{{5, 6}}},
+		{desc: "empty", start: 7},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		rootTx := storage.NewMockLogTreeTX(ctrl)
+		calls := []*gomock.Call{mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(rootTx, nil)}
+		rootTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+		rootTx.EXPECT().Commit().Return(nil)
+		rootTx.EXPECT().Close().Return(nil)
+		for _, chunk := range test.chunks {
+			// Storage may return leaves in any order.
+			var leaves []*trillian.LogLeaf
+			for i := len(chunk) - 1; i >= 0; i-- {
+				leaves = append(leaves, leaf(chunk[i]))
+			}
+			tx := storage.NewMockLogTreeTX(ctrl)
+			calls = append(calls, mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(tx, nil))
+			tx.EXPECT().GetLeavesByIndex(gomock.Any(), chunk).Return(leaves, nil)
+			tx.EXPECT().Commit().Return(nil)
+			tx.EXPECT().Close().Return(nil)
+		}
+		gomock.InOrder(calls...)
+
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorage(ctrl, logID1),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+		stream := &fakeGetEntriesStream{}
+		req := &trillian.GetEntriesRequest{LogId: logID1, StartIndex: test.start, EndIndex: test.end}
+		if err := server.GetEntries(req, stream); err != nil {
+			t.Errorf("%v: GetEntries()=%v, want: nil", test.desc, err)
+			ctrl.Finish()
+			continue
+		}
+		if got, want := len(stream.resps), len(test.chunks); got != want {
+			t.Errorf("%v: GetEntries() sent %v responses, want %v", test.desc, got, want)
+			ctrl.Finish()
+			continue
+		}
+		for i, chunk := range test.chunks {
+			var want []*trillian.LogLeaf
+			for _, index := range chunk {
+				want = append(want, leaf(index))
+			}
+			if diff := pretty.Compare(stream.resps[i].Leaves, want); diff != "" {
+				t.Errorf("%v: GetEntries() response %v diff:\n%v", test.desc, i, diff)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestGetEntriesMissingLeaf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	rootTx := storage.NewMockLogTreeTX(ctrl)
+	leafTx := storage.NewMockLogTreeTX(ctrl)
+	gomock.InOrder(
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(rootTx, nil),
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(leafTx, nil),
+	)
+	rootTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	rootTx.EXPECT().Commit().Return(nil)
+	rootTx.EXPECT().Close().Return(nil)
+	leafTx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{1, 2, 3}).Return([]*trillian.LogLeaf{leaf1, leaf3}, nil)
+	leafTx.EXPECT().Commit().Return(nil)
+	leafTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	stream := &fakeGetEntriesStream{}
+	err := server.GetEntries(&trillian.GetEntriesRequest{LogId: logID1, StartIndex: 1, EndIndex: 4}, stream)
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.Internal {
+		t.Errorf("GetEntries()=%v, want code %v", err, codes.Internal)
+	}
+	if len(stream.resps) != 0 {
+		t.Errorf("GetEntries() sent %v responses, want none", len(stream.resps))
+	}
+}
+
+// fakeRootHistoryStream records the responses sent on a GetSignedLogRootHistory stream.
+type fakeRootHistoryStream struct {
+	grpc.ServerStream
+	resps []*trillian.GetSignedLogRootHistoryResponse
+}
+
+func (s *fakeRootHistoryStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeRootHistoryStream) Send(resp *trillian.GetSignedLogRootHistoryResponse) error {
+	s.resps = append(s.resps, resp)
+	return nil
+}
+
+func TestGetSignedLogRootHistory(t *testing.T) {
+	defer func(size int) { rootHistoryChunkSize = size }(rootHistoryChunkSize)
+	rootHistoryChunkSize = 2
+
+	root := func(rev int64) trillian.SignedLogRoot {
+		return trillian.SignedLogRoot{TimestampNanos: rev + 1, TreeSize: rev * 10, TreeRevision: rev}
+	}
+	// signedRoot1 is at revision 5. Revision 2 has been pruned, and revision 6 is signed after
+	// the call starts.
+	stored := []int64{0, 1, 3, 4, 5, 6}
+	rootsAfter := func(after int64) []trillian.SignedLogRoot {
+		var roots []trillian.SignedLogRoot
+		for _, rev := range stored {
+			if rev > after && len(roots) < rootHistoryChunkSize {
+				roots = append(roots, root(rev))
+			}
+		}
+		return roots
+	}
+
+	tests := []struct {
+		desc   string
+		start  int64
+		latest trillian.SignedLogRoot
+		// reads holds the revision after which each chunk is read.
+		reads  []int64
+		chunks [][]int64
+	}{
+		{desc: "all", latest: signedRoot1, reads: []int64{-1, 1, 4}, chunks: [][]int64{{0, 1}, {3, 4}, {5}}},
+		{desc: "resumed", start: 4, latest: signedRoot1, reads: []int64{3}, chunks: [][]int64{{4, 5}}},
+		{desc: "afterLatest", start: 6, latest: signedRoot1},
+		{desc: "noRoots", latest: trillian.SignedLogRoot{}},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		rootTx := storage.NewMockLogTreeTX(ctrl)
+		calls := []*gomock.Call{mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(rootTx, nil)}
+		rootTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(test.latest, nil)
+		rootTx.EXPECT().Commit().Return(nil)
+		rootTx.EXPECT().Close().Return(nil)
+		for _, after := range test.reads {
+			tx := storage.NewMockLogTreeTX(ctrl)
+			calls = append(calls, mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(tx, nil))
+			tx.EXPECT().SignedLogRoots(gomock.Any(), after, rootHistoryChunkSize).Return(rootsAfter(after), nil)
+			tx.EXPECT().Commit().Return(nil)
+			tx.EXPECT().Close().Return(nil)
+		}
+		gomock.InOrder(calls...)
+
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorage(ctrl, logID1),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+		stream := &fakeRootHistoryStream{}
+		req := &trillian.GetSignedLogRootHistoryRequest{LogId: logID1, StartRevision: test.start}
+		if err := server.GetSignedLogRootHistory(req, stream); err != nil {
+			t.Errorf("%v: GetSignedLogRootHistory()=%v, want: nil", test.desc, err)
+			ctrl.Finish()
+			continue
+		}
+		if got, want := len(stream.resps), len(test.chunks); got != want {
+			t.Errorf("%v: GetSignedLogRootHistory() sent %v responses, want %v", test.desc, got, want)
+			ctrl.Finish()
+			continue
+		}
+		for i, chunk := range test.chunks {
+			var want []*trillian.SignedLogRoot
+			for _, rev := range chunk {
+				r := root(rev)
+				want = append(want, &r)
+			}
+			if diff := pretty.Compare(stream.resps[i].SignedLogRoots, want); diff != "" {
+				t.Errorf("%v: GetSignedLogRootHistory() response %v diff:\n%v", test.desc, i, diff)
+			}
+		}
+
+		ctrl.Finish()
+	}
+}
+
 func TestGetLeavesByIndexMultiple(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -262,6 +577,48 @@ func TestQueueLeavesCommitFails(t *testing.T) {
 	test.executeCommitFailsTest(t, queueRequest0.LogId)
 }
 
+// prefixValidator rejects leaves whose value starts with "malformed". It's registered for
+// all LOG trees, so it must accept the leaves used by the other tests.
+type prefixValidator struct{}
+
+func (prefixValidator) ValidateLeaf(ctx context.Context, tree *trillian.Tree, leaf *trillian.LogLeaf) error {
+	if bytes.HasPrefix(leaf.LeafValue, []byte("malformed")) {
+		return errors.New("malformed leaf")
+	}
+	return nil
+}
+
+var registerValidator sync.Once
+
+func TestQueueLeavesInvalidLeaf(t *testing.T) {
+	registerValidator.Do(func() { log.RegisterLeafValidator(trillian.TreeType_LOG, prefixValidator{}) })
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No storage expectations: invalid leaves must be rejected before storage is touched.
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   storage.NewMockLogStorage(ctrl),
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	req := &trillian.QueueLeavesRequest{
+		LogId: logID1,
+		Leaves: []*trillian.LogLeaf{
+			{LeafIdentityHash: []byte("id1"), LeafValue: []byte("fine")},
+			{LeafIdentityHash: []byte("id2"), LeafValue: []byte("malformed leaf")},
+		},
+	}
+	_, err := server.QueueLeaves(ctx, req)
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Fatalf("QueueLeaves()=_,%v; want code %v", err, want)
+	}
+	if !strings.Contains(err.Error(), "leaf 1") {
+		t.Errorf("QueueLeaves()=_,%v; want error naming leaf 1", err)
+	}
+}
+
 func TestQueueLeaves(t *testing.T) {
 	ctx := context.Background()
 	ctrl := gomock.NewController(t)
@@ -321,6 +678,204 @@ func TestQueueLeaves(t *testing.T) {
 	}
 }
 
+func TestQueueLeavesQueueDepth(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	leaves := []*trillian.LogLeaf{leaf1, leaf3, leaf1}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockSnapshot := storage.NewMockReadOnlyLogTreeTX(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockSnapshot, nil)
+	mockSnapshot.EXPECT().CountLeaves(gomock.Any(), storage.LeafFilter{UnsequencedOnly: true}).Return(int64(42), nil)
+	mockSnapshot.EXPECT().Commit().Return(nil)
+	mockSnapshot.EXPECT().Close().Return(nil)
+	mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	// The last leaf is a duplicate.
+	mockTx.EXPECT().QueueLeaves(gomock.Any(), leaves, fakeTime).Return([]*trillian.LogLeaf{nil, nil, leaf1}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	mockTx.EXPECT().IsOpen().AnyTimes().Return(false)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	rsp, err := server.QueueLeaves(ctx, &trillian.QueueLeavesRequest{LogId: logID1, Leaves: leaves, ReturnQueueDepth: true})
+	if err != nil {
+		t.Fatalf("QueueLeaves() = (_, %v), want (_, nil)", err)
+	}
+	if len(rsp.QueuedLeaves) != len(leaves) {
+		t.Fatalf("QueueLeaves() returns %d leaves; want %d", len(rsp.QueuedLeaves), len(leaves))
+	}
+	for i, want := range []int64{42, 43, 0} {
+		if got := rsp.QueuedLeaves[i].QueueDepth; got != want {
+			t.Errorf("QueueLeaves().QueuedLeaves[%d].QueueDepth = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestQueueLeavesBuffered(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().BeginForTree(gomock.Any(), queueRequest0.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().QueueLeaves(gomock.Any(), []*trillian.LogLeaf{leaf1}, fakeTime).Return([]*trillian.LogLeaf{leaf1}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, queueRequest0.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetQueueBuffer(QueueBufferOptions{MaxBytes: 1 << 20, BatchSize: 1, FlushInterval: time.Hour})
+	defer server.Close()
+
+	rsp, err := server.QueueLeaves(ctx, &queueRequest0)
+	if err != nil {
+		t.Fatalf("QueueLeaves() returned err = %v", err)
+	}
+	if len(rsp.QueuedLeaves) != 1 {
+		t.Fatalf("QueueLeaves() returns %d leaves; want 1", len(rsp.QueuedLeaves))
+	}
+	if got, want := rsp.QueuedLeaves[0].Status.GetCode(), int32(code.Code_ALREADY_EXISTS); got != want {
+		t.Errorf("QueueLeaves().Status=%d; want %d", got, want)
+	}
+}
+
+func TestQueueLeavesVerifyLeafIdentityHash(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	value := []byte("value")
+	leafHash := th.HashLeaf(value)
+	for _, test := range []struct {
+		desc         string
+		verify       bool
+		identityHash []byte
+		wantCode     codes.Code
+	}{
+		{desc: "matching", verify: true, identityHash: leafHash},
+		{desc: "mismatch", verify: true, identityHash: []byte("opaque"), wantCode: codes.InvalidArgument},
+		{desc: "missing", verify: true, wantCode: codes.InvalidArgument},
+		{desc: "opaque", identityHash: []byte("opaque")},
+	} {
+		leaf := &trillian.LogLeaf{LeafValue: value, LeafIdentityHash: test.identityHash}
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().QueueLeaves(gomock.Any(), []*trillian.LogLeaf{leaf}, fakeTime).Return([]*trillian.LogLeaf{nil}, nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		tree := *stestonly.LogTree
+		tree.TreeId = logID1
+		tree.VerifyLeafIdentityHash = test.verify
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorageForTree(ctrl, &tree),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+		req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{leaf}}
+		_, err := server.QueueLeaves(ctx, req)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: QueueLeaves() = (_, %v), want code %v", test.desc, err, test.wantCode)
+		}
+		mismatches := server.leafHashMismatches.Value("1")
+		if got, want := mismatches > 0, test.wantCode != codes.OK; got != want {
+			t.Errorf("%v: mismatch counted = %v, want %v", test.desc, got, want)
+		}
+	}
+}
+
+func TestQueueLeavesSignerProgress(t *testing.T) {
+	ctx := context.Background()
+	const maxAge = 10 * time.Minute
+
+	tests := []struct {
+		desc     string
+		rootTime time.Time
+		wantCode codes.Code
+	}{
+		{desc: "recentRoot", rootTime: fakeTime.Add(-time.Minute), wantCode: codes.OK},
+		{desc: "staleRoot", rootTime: fakeTime.Add(-maxAge - time.Second), wantCode: codes.Unavailable},
+		// Trees without a root or a creation time aren't rejected.
+		{desc: "noRoot", wantCode: codes.OK},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+
+		var rootNanos int64
+		if !test.rootTime.IsZero() {
+			rootNanos = test.rootTime.UnixNano()
+		}
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		mockSnapshot := storage.NewMockLogTreeTX(ctrl)
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), queueRequest0.LogId).Return(mockSnapshot, nil)
+		mockSnapshot.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(trillian.SignedLogRoot{TimestampNanos: rootNanos}, nil)
+		mockSnapshot.EXPECT().Commit().Return(nil)
+		mockSnapshot.EXPECT().Close().Return(nil)
+		if test.wantCode == codes.OK {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), queueRequest0.LogId).Return(mockTx, nil)
+			mockTx.EXPECT().QueueLeaves(gomock.Any(), []*trillian.LogLeaf{leaf1}, fakeTime).Return([]*trillian.LogLeaf{nil}, nil)
+			mockTx.EXPECT().Commit().Return(nil)
+			mockTx.EXPECT().Close().Return(nil)
+		}
+
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorage(ctrl, queueRequest0.LogId),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+		server.SetMaxRootAgeForWrites(maxAge)
+
+		_, err := server.QueueLeaves(ctx, &queueRequest0)
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: QueueLeaves() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestQueueLeavesSignerProgressCached(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ts := util.NewFakeTimeSource(fakeTime)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockSnapshot := storage.NewMockLogTreeTX(ctrl)
+	// The root is read once, and reused until signerProgressRefresh passes.
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), queueRequest0.LogId).Times(2).Return(mockSnapshot, nil)
+	mockSnapshot.EXPECT().LatestSignedLogRoot(gomock.Any()).Times(2).Return(trillian.SignedLogRoot{TimestampNanos: fakeTime.UnixNano()}, nil)
+	mockSnapshot.EXPECT().Commit().Times(2).Return(nil)
+	mockSnapshot.EXPECT().Close().Times(2).Return(nil)
+
+	server := NewTrillianLogRPCServer(extension.Registry{LogStorage: mockStorage}, ts)
+	server.SetMaxRootAgeForWrites(time.Hour)
+	tree := *stestonly.LogTree
+	tree.TreeId = queueRequest0.LogId
+
+	for _, advance := range []time.Duration{0, signerProgressRefresh / 2, signerProgressRefresh} {
+		ts.Set(fakeTime.Add(advance))
+		if err := server.checkSignerProgress(ctx, &tree); err != nil {
+			t.Errorf("checkSignerProgress() after %v returned err = %v", advance, err)
+		}
+	}
+}
+
 func TestQueueLeavesNoLeavesRejected(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -439,6 +994,38 @@ func TestGetLatestSignedLogRoot(t *testing.T) {
 	}
 }
 
+func TestGetLatestSignedLogRootWithTimestampToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	token := []byte("token")
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getLogRootRequest1.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().GetTimestampToken(gomock.Any(), signedRoot1.TreeRevision).Return(token, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, getLogRootRequest1.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	req := trillian.GetLatestSignedLogRootRequest{LogId: getLogRootRequest1.LogId, IncludeTimestampToken: true}
+	resp, err := server.GetLatestSignedLogRoot(context.Background(), &req)
+	if err != nil {
+		t.Fatalf("Failed to get log root: %v", err)
+	}
+	if !proto.Equal(&signedRoot1, resp.SignedLogRoot) {
+		t.Errorf("Log root proto mismatch:\n%v\n%v", signedRoot1, resp.SignedLogRoot)
+	}
+	if !bytes.Equal(resp.TimestampToken, token) {
+		t.Errorf("GetLatestSignedLogRoot().TimestampToken = %q, want %q", resp.TimestampToken, token)
+	}
+}
+
 func TestGetLeavesByHashInvalidHash(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -874,6 +1461,46 @@ func TestGetProofByIndex(t *testing.T) {
 	}
 }
 
+func TestGetProofByIndexNodeCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getInclusionProofByIndexRequest7.LogId).Times(2).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Times(2).Return(signedRoot1, nil)
+	mockTx.EXPECT().ReadRevision().Times(2).Return(signedRoot1.TreeRevision)
+	// The second request is served from the cache.
+	mockTx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	mockTx.EXPECT().Commit().Times(2).Return(nil)
+	mockTx.EXPECT().Close().Times(2).Return(nil)
+
+	wantProof := &trillian.Proof{
+		LeafIndex: 2,
+		Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+	}
+	nodeCache := cache.NewNodeCache(100, nil)
+	for i := 0; i < 2; i++ {
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorage(ctrl, getInclusionProofByIndexRequest7.LogId),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+		server.SetNodeCache(nodeCache)
+
+		resp, err := server.GetInclusionProof(context.Background(), &getInclusionProofByIndexRequest7)
+		if err != nil {
+			t.Fatalf("GetInclusionProof() #%d = (_, %v), want (_, nil)", i, err)
+		}
+		if !proto.Equal(resp.Proof, wantProof) {
+			t.Errorf("GetInclusionProof() #%d = %v, want %v", i, resp.Proof, wantProof)
+		}
+	}
+}
+
 func TestGetEntryAndProofBeginTXFails(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1055,13 +1682,93 @@ func TestGetEntryAndProof(t *testing.T) {
 	}
 }
 
-func TestGetSequencedLeafCountBeginTXFails(t *testing.T) {
+func TestGetLatestLeafByIdentityHashPrefix(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly,
-		func(t *storage.MockLogTreeTX) {},
-		func(s *TrillianLogRPCServer) error {
+	prefix := []byte{0x01, 0x02}
+	leaf := &trillian.LogLeaf{LeafIndex: 2, LeafIdentityHash: []byte{0x01, 0x02, 0x03}, MerkleLeafHash: []byte("mlh")}
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLatestLeafByIdentityHashPrefix(gomock.Any(), prefix).Return(leaf, nil)
+	mockTx.EXPECT().CountLeaves(gomock.Any(), storage.LeafFilter{LeafIdentityHashPrefix: prefix, SequencedOnly: true}).Return(int64(2), nil)
+	mockTx.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+	mockTx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	req := &trillian.GetLatestLeafByIdentityHashPrefixRequest{LogId: logID1, LeafIdentityHashPrefix: prefix}
+	resp, err := server.GetLatestLeafByIdentityHashPrefix(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetLatestLeafByIdentityHashPrefix() = (_, %v), want (_, nil)", err)
+	}
+	wantProof := &trillian.Proof{
+		LeafIndex: 2,
+		Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+	}
+	if !proto.Equal(resp.Proof, wantProof) {
+		t.Errorf("GetLatestLeafByIdentityHashPrefix().Proof = %v, want %v", resp.Proof, wantProof)
+	}
+	if !proto.Equal(resp.Leaf, leaf) {
+		t.Errorf("GetLatestLeafByIdentityHashPrefix().Leaf = %v, want %v", resp.Leaf, leaf)
+	}
+	if got, want := resp.MatchCount, int64(2); got != want {
+		t.Errorf("GetLatestLeafByIdentityHashPrefix().MatchCount = %v, want %v", got, want)
+	}
+}
+
+func TestGetLatestLeafByIdentityHashPrefixNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prefix := []byte{0x01, 0x02}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLatestLeafByIdentityHashPrefix(gomock.Any(), prefix).Return(nil, nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	req := &trillian.GetLatestLeafByIdentityHashPrefixRequest{LogId: logID1, LeafIdentityHashPrefix: prefix}
+	if _, err := server.GetLatestLeafByIdentityHashPrefix(context.Background(), req); status.Code(err) != codes.NotFound {
+		t.Errorf("GetLatestLeafByIdentityHashPrefix() = (_, %v), want (_, code %v)", err, codes.NotFound)
+	}
+}
+
+func TestGetLatestLeafByIdentityHashPrefixEmptyPrefix(t *testing.T) {
+	server := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
+	req := &trillian.GetLatestLeafByIdentityHashPrefixRequest{LogId: logID1}
+	if _, err := server.GetLatestLeafByIdentityHashPrefix(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetLatestLeafByIdentityHashPrefix() = (_, %v), want (_, code %v)", err, codes.InvalidArgument)
+	}
+}
+
+func TestGetSequencedLeafCountBeginTXFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly,
+		func(t *storage.MockLogTreeTX) {},
+		func(s *TrillianLogRPCServer) error {
 			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
 			return err
 		})
@@ -1266,6 +1973,196 @@ func TestGetConsistencyProof(t *testing.T) {
 	}
 }
 
+func TestGetProofByMerkleHashBeyondSignedSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getProofByMerkleHashRequest25.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, getProofByMerkleHashRequest25.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	_, err := server.GetProofByMerkleHash(context.Background(), &getProofByMerkleHashRequest25)
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+		t.Fatalf("GetProofByMerkleHash() beyond signed tree size returned err = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestProofRequestsBeyondSignedSize(t *testing.T) {
+	// signedRoot1 has a tree size of 7.
+	leaves := []*trillian.LogLeaf{{LeafIndex: 2}}
+	for _, test := range []struct {
+		desc string
+		// getLeaves is whether the leaves are read before the proof is refused.
+		getLeaves bool
+		call      func(s *TrillianLogRPCServer) error
+	}{
+		{
+			desc: "inclusionHugeSize",
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetInclusionProof(context.Background(), &trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: math.MaxInt64, LeafIndex: math.MaxInt64 - 1})
+				return err
+			},
+		},
+		{
+			desc: "inclusionNextSize",
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetInclusionProof(context.Background(), &trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: 8, LeafIndex: 7})
+				return err
+			},
+		},
+		{
+			desc: "entryAndProofHugeSize",
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetEntryAndProof(context.Background(), &trillian.GetEntryAndProofRequest{LogId: logID1, TreeSize: math.MaxInt64, LeafIndex: 0})
+				return err
+			},
+		},
+		{
+			desc:      "inclusionByHashHugeSize",
+			getLeaves: true,
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetInclusionProofByHash(context.Background(), &trillian.GetInclusionProofByHashRequest{LogId: logID1, TreeSize: math.MaxInt64, LeafHash: []byte("ahash")})
+				return err
+			},
+		},
+		{
+			desc: "consistencyHugeSize",
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetConsistencyProof(context.Background(), &trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 1, SecondTreeSize: math.MaxInt64})
+				return err
+			},
+		},
+		{
+			desc: "consistencyNextSize",
+			call: func(s *TrillianLogRPCServer) error {
+				_, err := s.GetConsistencyProof(context.Background(), &trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 7, SecondTreeSize: 8})
+				return err
+			},
+		},
+	} {
+		ctrl := gomock.NewController(t)
+
+		// No nodes are read for the refused proofs.
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		mockTx := storage.NewMockLogTreeTX(ctrl)
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+		if test.getLeaves {
+			mockTx.EXPECT().GetLeavesByHash(gomock.Any(), gomock.Any(), false).Return(leaves, nil)
+		}
+		mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+		mockTx.EXPECT().Close().Return(nil)
+
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorage(ctrl, logID1),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+		if err := test.call(server); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: got err = %v, want code %v", test.desc, err, codes.InvalidArgument)
+		}
+		ctrl.Finish()
+	}
+}
+
+func TestGetProofByMerkleHashCommitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newParameterizedTest(ctrl, "GetProofByMerkleHash", readOnly,
+		func(t *storage.MockLogTreeTX) {
+			t.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+			t.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+			t.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{[]byte("ahash")}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
+			t.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
+		},
+		func(s *TrillianLogRPCServer) error {
+			_, err := s.GetProofByMerkleHash(context.Background(), &getProofByMerkleHashRequest7)
+			return err
+		})
+
+	test.executeCommitFailsTest(t, getProofByMerkleHashRequest7.LogId)
+}
+
+func TestGetProofByMerkleHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getProofByMerkleHashRequest7.LogId).Return(mockTx, nil)
+
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+	// The hash was sequenced twice, and only the second copy is included at tree size 7.
+	mockTx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{[]byte("ahash")}, false).Return([]*trillian.LogLeaf{{LeafIndex: 9}, {LeafIndex: 2}}, nil)
+	// The consistency proof node (2, 1) is also part of the inclusion proof, so only the
+	// inclusion proof nodes should be read, and only once.
+	mockTx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, getProofByMerkleHashRequest7.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	response, err := server.GetProofByMerkleHash(context.Background(), &getProofByMerkleHashRequest7)
+	if err != nil {
+		t.Fatalf("failed to get proof by merkle hash: %v", err)
+	}
+
+	wantInclusion := trillian.Proof{
+		LeafIndex: 2,
+		Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+	}
+	if !proto.Equal(response.InclusionProof, &wantInclusion) {
+		t.Errorf("InclusionProof=%v, want %v", response.InclusionProof, wantInclusion)
+	}
+	wantConsistency := trillian.Proof{
+		LeafIndex: 0,
+		Hashes:    [][]byte{[]byte("nodehash2")},
+	}
+	if !proto.Equal(response.ConsistencyProof, &wantConsistency) {
+		t.Errorf("ConsistencyProof=%v, want %v", response.ConsistencyProof, wantConsistency)
+	}
+}
+
+func TestGetProofByMerkleHashNotIncluded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getProofByMerkleHashRequest7.LogId).Return(mockTx, nil)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{[]byte("ahash")}, false).Return([]*trillian.LogLeaf{{LeafIndex: 7}, {LeafIndex: 9}}, nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, getProofByMerkleHashRequest7.LogId),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	_, err := server.GetProofByMerkleHash(context.Background(), &getProofByMerkleHashRequest7)
+	if got, want := status.Code(err), codes.NotFound; got != want {
+		t.Errorf("GetProofByMerkleHash() with no leaf included at tree size returned err = %v, want code %v", err, want)
+	}
+}
+
 type prepareMockTXFunc func(*storage.MockLogTreeTX)
 type makeRPCFunc func(*TrillianLogRPCServer) error
 
@@ -1402,3 +2299,355 @@ func mockAdminStorage(ctrl *gomock.Controller, treeID int64) storage.AdminStorag
 
 	return adminStorage
 }
+
+func TestHasLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	req := &trillian.HasLeavesRequest{LogId: logID1, LeafIdentityHash: [][]byte{[]byte("test"), []byte("data")}, IncludeUnsequenced: true}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().HasLeaves(gomock.Any(), req.LeafIdentityHash, true).Return([]bool{true, false}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	resp, err := server.HasLeaves(context.Background(), req)
+	if err != nil {
+		t.Fatalf("HasLeaves() = (_, %v), want (_, nil)", err)
+	}
+	if got, want := resp.Present, []bool{true, false}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("HasLeaves().Present = %v, want %v", got, want)
+	}
+}
+
+func TestHasLeavesInvalidHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   storage.NewMockLogStorage(ctrl),
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	for _, hashes := range [][][]byte{nil, {[]byte("test"), {}}} {
+		_, err := server.HasLeaves(context.Background(), &trillian.HasLeavesRequest{LogId: logID1, LeafIdentityHash: hashes})
+		if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument {
+			t.Errorf("HasLeaves(%v) = (_, %v), want code %v", hashes, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestCountLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	min := time.Unix(1000, 0)
+	req := &trillian.CountLeavesRequest{
+		LogId:                  logID1,
+		MinQueueTimestamp:      &timestamp.Timestamp{Seconds: min.Unix()},
+		LeafIdentityHashPrefix: []byte("te"),
+		SequencedOnly:          true,
+	}
+	filter := storage.LeafFilter{
+		MinQueueTimestampNanos: min.UnixNano(),
+		LeafIdentityHashPrefix: []byte("te"),
+		SequencedOnly:          true,
+	}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().CountLeaves(gomock.Any(), filter).Return(int64(42), nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	resp, err := server.CountLeaves(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CountLeaves() = (_, %v), want (_, nil)", err)
+	}
+	if got, want := resp.LeafCount, int64(42); got != want {
+		t.Errorf("CountLeaves().LeafCount = %v, want %v", got, want)
+	}
+}
+
+func TestCountLeavesInvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   storage.NewMockLogStorage(ctrl),
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	for _, req := range []*trillian.CountLeavesRequest{
+		{LogId: logID1, MinQueueTimestamp: &timestamp.Timestamp{Seconds: 2000}, MaxQueueTimestamp: &timestamp.Timestamp{Seconds: 1000}},
+		{LogId: logID1, MinQueueTimestamp: &timestamp.Timestamp{Seconds: 1000}, MaxQueueTimestamp: &timestamp.Timestamp{Seconds: 1000}},
+		{LogId: logID1, MinQueueTimestamp: &timestamp.Timestamp{Nanos: -1}},
+	} {
+		_, err := server.CountLeaves(context.Background(), req)
+		if s, _ := status.FromError(err); s.Code() != codes.InvalidArgument {
+			t.Errorf("CountLeaves(%v) = (_, %v), want code %v", req, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestAddCosignature(t *testing.T) {
+	ctx := context.Background()
+	signer, err := keys.NewFromPrivatePEM(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("NewFromPrivatePEM(): %v", err)
+	}
+	sig, err := crypto.NewSHA256Signer(signer).Sign(crypto.HashLogRoot(signedRoot1))
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	cosig := &trillian.Cosignature{WitnessName: "witness", Signature: sig}
+	otherRoot := signedRoot1
+	otherRoot.TreeSize++
+
+	tests := []struct {
+		desc     string
+		root     trillian.SignedLogRoot
+		cosig    *trillian.Cosignature
+		latest   *trillian.SignedLogRoot
+		wantCode codes.Code
+	}{
+		{desc: "ok", root: signedRoot1, cosig: cosig, latest: &signedRoot1},
+		{desc: "noCosignature", root: signedRoot1, wantCode: codes.InvalidArgument},
+		{
+			desc:     "unknownWitness",
+			root:     signedRoot1,
+			cosig:    &trillian.Cosignature{WitnessName: "stranger", Signature: sig},
+			wantCode: codes.InvalidArgument,
+		},
+		{desc: "badSignature", root: otherRoot, cosig: cosig, wantCode: codes.InvalidArgument},
+		{desc: "notLatest", root: signedRoot1, cosig: cosig, latest: &otherRoot, wantCode: codes.FailedPrecondition},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		if test.latest != nil {
+			mockTx := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().BeginForTree(gomock.Any(), logID1).Return(mockTx, nil)
+			mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(*test.latest, nil)
+			if test.wantCode == codes.OK {
+				mockTx.EXPECT().StoreCosignature(gomock.Any(), revision1, test.cosig).Return(nil)
+				mockTx.EXPECT().Commit().Return(nil)
+			}
+			mockTx.EXPECT().Close().Return(nil)
+			mockTx.EXPECT().IsOpen().AnyTimes().Return(false)
+		}
+
+		registry := extension.Registry{
+			AdminStorage: mockAdminStorageWithWitness(ctrl, logID1),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+		root := test.root
+		_, err := server.AddCosignature(ctx, &trillian.AddCosignatureRequest{LogId: logID1, SignedLogRoot: &root, Cosignature: test.cosig})
+		if s, _ := status.FromError(err); s.Code() != test.wantCode {
+			t.Errorf("%v: AddCosignature() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestGetLatestCosignedLogRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cosigs := []*trillian.Cosignature{{WitnessName: "witness"}}
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+	mockTx.EXPECT().LatestCosignedLogRoot(gomock.Any()).Return(signedRoot1, cosigs, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: mockAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	resp, err := server.GetLatestCosignedLogRoot(context.Background(), &trillian.GetLatestCosignedLogRootRequest{LogId: logID1})
+	if err != nil {
+		t.Fatalf("Failed to get cosigned log root: %v", err)
+	}
+	want := &trillian.GetLatestCosignedLogRootResponse{SignedLogRoot: &signedRoot1, Cosignatures: cosigs}
+	if !proto.Equal(resp, want) {
+		t.Errorf("GetLatestCosignedLogRoot() diff:\n%v", pretty.Compare(resp, want))
+	}
+}
+
+func TestGetSignedLogRootAtTime(t *testing.T) {
+	at := &timestamp.Timestamp{Seconds: 1, Nanos: 500}
+	for _, test := range []struct {
+		desc     string
+		req      *trillian.GetSignedLogRootAtTimeRequest
+		root     *trillian.SignedLogRoot
+		wantCode codes.Code
+	}{
+		{
+			desc:     "no-timestamp",
+			req:      &trillian.GetSignedLogRootAtTimeRequest{LogId: logID1},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "invalid-timestamp",
+			req:      &trillian.GetSignedLogRootAtTimeRequest{LogId: logID1, Timestamp: &timestamp.Timestamp{Nanos: -1}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "before-earliest-root",
+			req:      &trillian.GetSignedLogRootAtTimeRequest{LogId: logID1, Timestamp: at},
+			root:     &trillian.SignedLogRoot{},
+			wantCode: codes.NotFound,
+		},
+		{
+			desc: "ok",
+			req:  &trillian.GetSignedLogRootAtTimeRequest{LogId: logID1, Timestamp: at},
+			root: &signedRoot1,
+		},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.root != nil {
+				mockTx := storage.NewMockLogTreeTX(ctrl)
+				mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+				mockTx.EXPECT().SignedLogRootAtTime(gomock.Any(), int64(1000000500)).Return(*test.root, nil)
+				mockTx.EXPECT().Commit().Return(nil)
+				mockTx.EXPECT().Close().Return(nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: mockAdminStorage(ctrl, logID1),
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			resp, err := server.GetSignedLogRootAtTime(context.Background(), test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("%v: GetSignedLogRootAtTime() returned err = %v, want code %v", test.desc, err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if !proto.Equal(resp.SignedLogRoot, test.root) {
+				t.Errorf("%v: GetSignedLogRootAtTime() = %v, want %v", test.desc, resp.SignedLogRoot, test.root)
+			}
+		}()
+	}
+}
+
+func TestGetInclusionProofWithRoot(t *testing.T) {
+	req := &trillian.GetInclusionProofWithRootRequest{LogId: logID1, TreeSize: 7, LeafIndex: 2}
+	rootAtSize7 := trillian.SignedLogRoot{TreeSize: 7, RootHash: []byte("A NICE HASH"), TreeRevision: 3}
+	for _, test := range []struct {
+		desc     string
+		req      *trillian.GetInclusionProofWithRootRequest
+		root     *trillian.SignedLogRoot
+		wantCode codes.Code
+	}{
+		{
+			desc:     "index-beyond-size",
+			req:      &trillian.GetInclusionProofWithRootRequest{LogId: logID1, TreeSize: 7, LeafIndex: 7},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "no-root-at-size",
+			req:      req,
+			root:     &trillian.SignedLogRoot{},
+			wantCode: codes.NotFound,
+		},
+		{
+			desc: "ok",
+			req:  req,
+			root: &rootAtSize7,
+		},
+	} {
+		func() {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.root != nil {
+				mockTx := storage.NewMockLogTreeTX(ctrl)
+				mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil)
+				mockTx.EXPECT().SignedLogRootAtSize(gomock.Any(), int64(7)).Return(*test.root, nil)
+				if test.wantCode == codes.OK {
+					mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+					mockTx.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+					mockTx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+						{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+						{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+						{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+					mockTx.EXPECT().Commit().Return(nil)
+				}
+				mockTx.EXPECT().Close().Return(nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: mockAdminStorage(ctrl, logID1),
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			resp, err := server.GetInclusionProofWithRoot(context.Background(), test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("%v: GetInclusionProofWithRoot() returned err = %v, want code %v", test.desc, err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			wantProof := &trillian.Proof{
+				LeafIndex: 2,
+				Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+			}
+			if !proto.Equal(resp.Proof, wantProof) {
+				t.Errorf("%v: GetInclusionProofWithRoot().Proof = %v, want %v", test.desc, resp.Proof, wantProof)
+			}
+			if !proto.Equal(resp.SignedLogRoot, test.root) {
+				t.Errorf("%v: GetInclusionProofWithRoot().SignedLogRoot = %v, want %v", test.desc, resp.SignedLogRoot, test.root)
+			}
+		}()
+	}
+}
+
+func mockAdminStorageWithWitness(ctrl *gomock.Controller, treeID int64) storage.AdminStorage {
+	tree := *stestonly.LogTree
+	tree.TreeId = treeID
+	tree.Witnesses = []*trillian.Witness{
+		{Name: "witness", PublicKey: &keyspb.PublicKey{Der: ktestonly.MustMarshalPublicPEMToDER(testonly.DemoPublicKey)}},
+	}
+
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+
+	adminStorage.EXPECT().Snapshot(gomock.Any()).MaxTimes(1).Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), treeID).MaxTimes(1).Return(&tree, nil)
+	adminTX.EXPECT().Close().MaxTimes(1).Return(nil)
+	adminTX.EXPECT().Commit().MaxTimes(1).Return(nil)
+
+	return adminStorage
+}
@@ -15,9 +15,11 @@
 package server
 
 import (
-	"database/sql"
+	"crypto/tls"
+	"errors"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -27,11 +29,14 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/server/admin"
+	"github.com/google/trillian/storage/factory"
 	"github.com/google/trillian/util"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/soheilhy/cmux"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/naming"
 	"google.golang.org/grpc/reflection"
 )
@@ -41,13 +46,53 @@ type Main struct {
 	// Endpoints for RPC and HTTP/REST servers.
 	// HTTP/REST is optional, if empty it'll not be bound.
 	RPCEndpoint, HTTPEndpoint string
-	DB                        *sql.DB
-	Registry                  extension.Registry
-	Server                    *grpc.Server
+	// RPCUnixSocket is the path of a Unix domain socket to serve RPCs on instead of
+	// RPCEndpoint, for deployments where only co-located processes talk to the server.
+	// HTTP/REST is still served on HTTPEndpoint. It can't be combined with SinglePort.
+	RPCUnixSocket string
+	// SinglePort serves HTTP/REST on RPCEndpoint alongside gRPC, telling them apart per
+	// connection. HTTPEndpoint is ignored if set.
+	SinglePort bool
+	// TLSConfig, if set, is the TLS configuration Server serves RPCs with (see NewTLSConfig).
+	// HTTP/REST is served with it too, so if it requires client certificates so does
+	// HTTP/REST. It can't be combined with SinglePort, as gRPC and HTTP requests can't be
+	// told apart once encrypted.
+	TLSConfig *tls.Config
+	// StorageProvider is the source of the storage in Registry, it's closed when the server exits.
+	StorageProvider factory.Provider
+	Registry        extension.Registry
+	Server          *grpc.Server
 	// RegisterHandlerFn is called to register REST-proxy handlers.
 	RegisterHandlerFn func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error
+	// DisableRESTGateway skips registration of the REST-proxy, so only metrics are served over
+	// HTTP.
+	DisableRESTGateway bool
+	// HTTPHandlers are further handlers served over HTTP, keyed by the path prefix they serve.
+	// They're served whether or not the REST-proxy is.
+	HTTPHandlers map[string]http.Handler
 	// RegisterServerFn is called to register RPC servers.
 	RegisterServerFn func(*grpc.Server, extension.Registry) error
+	// ShutdownFn, if set, is called once the RPC server stops, before storage is closed.
+	ShutdownFn func()
+	// MaxActiveTrees limits the number of trees that can exist before the admin server
+	// refuses to create more. Zero means no limit.
+	MaxActiveTrees int64
+	// RootTimeSource is the clock that roots signed by the admin RepairTreeRoot RPC are
+	// timestamped with, nil means the system clock. It should match the signer's.
+	RootTimeSource util.TimeSource
+	// HealthCheckInterval is how often the status reported by the gRPC health service is
+	// updated. Zero means the health service isn't served.
+	HealthCheckInterval time.Duration
+	// HealthChecks are checks of backends other than storage, which is always checked.
+	HealthChecks []HealthCheck
+	// DrainTimeout is how long RPCs in flight when the server is told to exit (by SIGINT or
+	// SIGTERM) are given to complete, before they're aborted. No new RPCs are accepted in the
+	// meantime. Zero means in-flight RPCs are aborted straight away.
+	DrainTimeout time.Duration
+	// DrainDelay is how long the health service reports NOT_SERVING before RPCs are drained,
+	// so load balancers stop sending new RPCs to the server first. Only used if the health
+	// service is served.
+	DrainDelay time.Duration
 }
 
 // Run starts the configured server. Blocks until the server exits.
@@ -55,47 +100,75 @@ func (m *Main) Run(ctx context.Context) error {
 	glog.CopyStandardLogTo("WARNING")
 
 	defer m.Server.GracefulStop()
-	defer m.DB.Close()
+	defer m.StorageProvider.Close()
 
 	if err := m.RegisterServerFn(m.Server, m.Registry); err != nil {
 		return err
 	}
-	trillian.RegisterTrillianAdminServer(m.Server, admin.New(m.Registry))
+	adminServer := admin.New(m.Registry)
+	adminServer.SetMaxActiveTrees(m.MaxActiveTrees)
+	adminServer.SetRootTimeSource(m.RootTimeSource)
+	trillian.RegisterTrillianAdminServer(m.Server, adminServer)
 	reflection.Register(m.Server)
+	var hc *HealthChecker
+	if m.HealthCheckInterval > 0 {
+		checks := append([]HealthCheck{{Name: StorageHealthCheck, Check: m.Registry.AdminStorage.CheckDatabaseAccessible}}, m.HealthChecks...)
+		hc = NewHealthChecker(serviceNames(m.Server), m.HealthCheckInterval, checks...)
+		hc.Register(m.Server)
+		hctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go hc.Run(hctx)
+	}
 
-	if endpoint := m.HTTPEndpoint; endpoint != "" {
-		mux := runtime.NewServeMux()
-		opts := []grpc.DialOption{grpc.WithInsecure()}
-		if err := m.RegisterHandlerFn(ctx, mux, m.RPCEndpoint, opts); err != nil {
+	var httpHandler http.Handler
+	if m.SinglePort || m.HTTPEndpoint != "" {
+		var err error
+		if httpHandler, err = m.newHTTPHandler(ctx); err != nil {
 			return err
 		}
-		if err := trillian.RegisterTrillianAdminHandlerFromEndpoint(ctx, mux, m.RPCEndpoint, opts); err != nil {
+	}
+	if m.HTTPEndpoint != "" && !m.SinglePort {
+		hlis, err := m.listenHTTP()
+		if err != nil {
 			return err
 		}
-		glog.Infof("HTTP server starting on %v", endpoint)
-
-		go http.ListenAndServe(endpoint, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			switch {
-			case req.RequestURI == "/metrics":
-				promhttp.Handler().ServeHTTP(w, req)
-			default:
-				mux.ServeHTTP(w, req)
-			}
-		}))
+		go http.Serve(hlis, httpHandler)
 	}
 
-	glog.Infof("RPC server starting on %v", m.RPCEndpoint)
-	lis, err := net.Listen("tcp", m.RPCEndpoint)
+	lis, err := m.listenRPC()
 	if err != nil {
 		return err
 	}
-	go util.AwaitSignal(m.Server.Stop)
+	if m.SinglePort {
+		glog.Infof("HTTP server starting on %v", m.RPCEndpoint)
+		cm := cmux.New(lis)
+		lis = cm.Match(cmux.HTTP2HeaderField("content-type", "application/grpc"))
+		go http.Serve(cm.Match(cmux.HTTP1Fast()), httpHandler)
+		go cm.Serve()
+	}
+	stopped := make(chan struct{})
+	go util.AwaitSignal(func() {
+		if hc != nil {
+			hc.Shutdown()
+			glog.Infof("Reporting NOT_SERVING for %v before draining RPCs", m.DrainDelay)
+			time.Sleep(m.DrainDelay)
+		}
+		m.drain()
+		close(stopped)
+	})
 
 	if err := m.Server.Serve(lis); err != nil {
 		glog.Errorf("RPC server terminated: %v", err)
+	} else {
+		// Serve returns as soon as the server stops accepting connections, RPCs in flight
+		// still need to be drained before storage can be closed.
+		<-stopped
 	}
 
 	glog.Infof("Stopping server, about to exit")
+	if m.ShutdownFn != nil {
+		m.ShutdownFn()
+	}
 	glog.Flush()
 
 	// Give things a few seconds to tidy up
@@ -104,6 +177,128 @@ func (m *Main) Run(ctx context.Context) error {
 	return nil
 }
 
+// drain stops the RPC server, giving in-flight RPCs DrainTimeout to complete before they're
+// aborted.
+func (m *Main) drain() {
+	glog.Infof("Draining RPCs for up to %v", m.DrainTimeout)
+	done := make(chan struct{})
+	go func() {
+		m.Server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(m.DrainTimeout):
+		glog.Warningf("RPCs still in flight after %v, aborting them", m.DrainTimeout)
+		m.Server.Stop()
+		<-done
+	}
+}
+
+// rpcSocketMode is the permissions of the socket created for RPCUnixSocket, so only
+// processes running as the server's user or group can connect.
+const rpcSocketMode = 0660
+
+// listenRPC returns the listener RPCs are served on, on RPCUnixSocket if set or
+// RPCEndpoint otherwise.
+func (m *Main) listenRPC() (net.Listener, error) {
+	if m.TLSConfig != nil && m.SinglePort {
+		return nil, errors.New("TLSConfig can't be combined with SinglePort")
+	}
+	if m.RPCUnixSocket == "" {
+		glog.Infof("RPC server starting on %v", m.RPCEndpoint)
+		return net.Listen("tcp", m.RPCEndpoint)
+	}
+	if m.SinglePort {
+		return nil, errors.New("RPCUnixSocket can't be combined with SinglePort")
+	}
+
+	glog.Infof("RPC server starting on unix socket %v", m.RPCUnixSocket)
+	// A socket left behind by a previous run would make Listen fail.
+	if err := os.Remove(m.RPCUnixSocket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	lis, err := net.Listen("unix", m.RPCUnixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(m.RPCUnixSocket, rpcSocketMode); err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return lis, nil
+}
+
+// listenHTTP returns the listener HTTP/REST requests are served on, which is encrypted
+// with TLSConfig if set.
+func (m *Main) listenHTTP() (net.Listener, error) {
+	glog.Infof("HTTP server starting on %v", m.HTTPEndpoint)
+	lis, err := net.Listen("tcp", m.HTTPEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if m.TLSConfig != nil {
+		lis = tls.NewListener(lis, m.TLSConfig)
+	}
+	return lis, nil
+}
+
+// newHTTPHandler returns a handler for metrics and REST requests, the latter being proxied to the
+// RPC server. REST requests aren't served if DisableRESTGateway is set.
+func (m *Main) newHTTPHandler(ctx context.Context) (http.Handler, error) {
+	if m.DisableRESTGateway {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.RequestURI == "/metrics":
+				promhttp.Handler().ServeHTTP(w, req)
+			case m.serveExtraHTTP(w, req):
+			default:
+				http.NotFound(w, req)
+			}
+		}), nil
+	}
+
+	mux := runtime.NewServeMux()
+	endpoint := m.RPCEndpoint
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if m.TLSConfig != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(gatewayTLSConfig(m.TLSConfig)))}
+	}
+	if m.RPCUnixSocket != "" {
+		endpoint = m.RPCUnixSocket
+		opts = append(opts, grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}))
+	}
+	if err := m.RegisterHandlerFn(ctx, mux, endpoint, opts); err != nil {
+		return nil, err
+	}
+	if err := trillian.RegisterTrillianAdminHandlerFromEndpoint(ctx, mux, endpoint, opts); err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.RequestURI == "/metrics":
+			promhttp.Handler().ServeHTTP(w, req)
+		case m.serveExtraHTTP(w, req):
+		default:
+			mux.ServeHTTP(w, req)
+		}
+	}), nil
+}
+
+// serveExtraHTTP serves req with the handler in HTTPHandlers whose prefix matches its path,
+// if any, and reports whether it did.
+func (m *Main) serveExtraHTTP(w http.ResponseWriter, req *http.Request) bool {
+	for prefix, h := range m.HTTPHandlers {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			h.ServeHTTP(w, req)
+			return true
+		}
+	}
+	return false
+}
+
 // AnnounceSelf announces this binary's presence to etcd.  Returns a function that
 // should be called on process exit.
 func AnnounceSelf(ctx context.Context, etcdServers, etcdService, endpoint string) func() {
@@ -0,0 +1,140 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNewHTTPHandlerRESTGatewayDisabled(t *testing.T) {
+	// RegisterHandlerFn is nil, so registering the REST-proxy would panic.
+	m := &Main{RPCEndpoint: "localhost:0", DisableRESTGateway: true}
+	handler, err := m.newHTTPHandler(context.Background())
+	if err != nil {
+		t.Fatalf("newHTTPHandler()=_,%v, want: _,nil", err)
+	}
+
+	for _, test := range []struct {
+		uri  string
+		want int
+	}{
+		{uri: "/metrics", want: http.StatusOK},
+		{uri: "/v1beta1/logs/1/roots:latest", want: http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", test.uri, nil))
+		if got := rec.Code; got != test.want {
+			t.Errorf("GET %v: status=%v, want: %v", test.uri, got, test.want)
+		}
+	}
+}
+
+func TestListenRPCUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trillian")
+	if err != nil {
+		t.Fatalf("TempDir()=_,%v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rpc.sock")
+	// A stale socket from an earlier run is replaced.
+	if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+		t.Fatalf("WriteFile()=%v", err)
+	}
+
+	m := &Main{RPCEndpoint: "localhost:0", RPCUnixSocket: path}
+	lis, err := m.listenRPC()
+	if err != nil {
+		t.Fatalf("listenRPC()=_,%v, want: _,nil", err)
+	}
+	defer lis.Close()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat()=_,%v", err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		t.Errorf("%v has mode %v, want a socket", path, fi.Mode())
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(rpcSocketMode); got != want {
+		t.Errorf("%v has permissions %v, want %v", path, got, want)
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial()=_,%v, want: _,nil", err)
+	}
+	conn.Close()
+}
+
+func TestListenRPCUnixSocketSinglePort(t *testing.T) {
+	m := &Main{RPCUnixSocket: "rpc.sock", SinglePort: true}
+	if lis, err := m.listenRPC(); err == nil {
+		lis.Close()
+		t.Errorf("listenRPC()=_,nil, want: _,error")
+	}
+}
+
+func TestMainDrain(t *testing.T) {
+	for _, test := range []struct {
+		drainTimeout time.Duration
+		wantErr      bool
+	}{
+		{drainTimeout: time.Minute, wantErr: false},
+		{drainTimeout: 0, wantErr: true},
+	} {
+		started := make(chan bool)
+		// Any RPC is served by a handler taking a while to respond.
+		s := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			var req healthpb.HealthCheckRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			started <- true
+			time.Sleep(100 * time.Millisecond)
+			return stream.SendMsg(&healthpb.HealthCheckResponse{})
+		}))
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Listen()=_,%v", err)
+		}
+		go s.Serve(lis)
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+		if err != nil {
+			t.Fatalf("Dial()=_,%v", err)
+		}
+
+		errc := make(chan error)
+		go func() {
+			errc <- conn.Invoke(context.Background(), "/test.Slow/Call", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+		}()
+		<-started
+		m := &Main{Server: s, DrainTimeout: test.drainTimeout}
+		m.drain()
+		if err := <-errc; (err != nil) != test.wantErr {
+			t.Errorf("drain(DrainTimeout=%v): in-flight RPC returned %v, wantErr: %v", test.drainTimeout, err, test.wantErr)
+		}
+		conn.Close()
+	}
+}
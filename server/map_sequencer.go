@@ -0,0 +1,178 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/util"
+)
+
+// MapSequencer periodically sets the leaves queued by QueueLeaves in new revisions of their
+// maps. If the registry has an ElectionFactory, maps are only sequenced by the instance that
+// is master for them, with elections run as for logs. Without one every map is sequenced, so
+// only one MapSequencer should be run against a storage backend, otherwise the same map may
+// be sequenced concurrently and one of the writes will fail.
+type MapSequencer struct {
+	registry  extension.Registry
+	server    *TrillianMapServer
+	batchSize int
+	interval  time.Duration
+	sequenced monitoring.Counter
+
+	// info holds the mastership election parameters.
+	info     LogOperationInfo
+	runners  map[int64]*electionRunner
+	runnerWG sync.WaitGroup
+	tracker  *util.MasterTracker
+}
+
+// NewMapSequencer creates a MapSequencer that sequences at most batchSize queued leaves per
+// map every interval, using server to write the new revisions.
+func NewMapSequencer(registry extension.Registry, server *TrillianMapServer, batchSize int, interval time.Duration) *MapSequencer {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	// The election runners report mastership with the log operation metrics.
+	once.Do(func() {
+		createMetrics(mf)
+	})
+	return &MapSequencer{
+		registry:  registry,
+		server:    server,
+		batchSize: batchSize,
+		interval:  interval,
+		sequenced: mf.NewCounter("sequenced_map_leaves", "Number of queued map leaves set by the map sequencer"),
+		info:      fixupElectionInfo(LogOperationInfo{Registry: registry, TimeSource: util.SystemTimeSource{}}),
+		runners:   make(map[int64]*electionRunner),
+	}
+}
+
+// SetElectionInfo sets the mastership election parameters from the PreElectionPause,
+// MasterCheckInterval, MasterHoldInterval, ResignOdds and TimeSource fields of info. The
+// other fields are ignored. It must be called before Run.
+func (s *MapSequencer) SetElectionInfo(info LogOperationInfo) {
+	s.info.PreElectionPause = info.PreElectionPause
+	s.info.MasterCheckInterval = info.MasterCheckInterval
+	s.info.MasterHoldInterval = info.MasterHoldInterval
+	s.info.ResignOdds = info.ResignOdds
+	if info.TimeSource != nil {
+		s.info.TimeSource = info.TimeSource
+	}
+	s.info = fixupElectionInfo(s.info)
+}
+
+// Run sequences queued map leaves until ctx is done. It returns once the mastership
+// elections it ran have been closed.
+func (s *MapSequencer) Run(ctx context.Context) {
+	defer s.stopElections()
+	runPeriodically(ctx, s.interval, "sequence queued map leaves", s.Sequence)
+}
+
+// Sequence sequences one batch of queued leaves for every active map this instance is master
+// for. Elections for maps not seen before are started in the background, and run until ctx is
+// done.
+func (s *MapSequencer) Sequence(ctx context.Context) error {
+	trees, err := listTrees(ctx, s.registry.AdminStorage)
+	if err != nil {
+		return err
+	}
+	var mapIDs []int64
+	for _, tree := range trees {
+		if tree.TreeType != trillian.TreeType_MAP || tree.TreeState != trillian.TreeState_ACTIVE {
+			continue
+		}
+		mapIDs = append(mapIDs, tree.TreeId)
+	}
+	mapIDs, err = s.masterFor(ctx, mapIDs)
+	if err != nil {
+		return err
+	}
+	for _, mapID := range mapIDs {
+		n, err := s.server.SequenceQueuedLeaves(ctx, mapID, s.batchSize)
+		if err != nil {
+			glog.Warningf("%v: failed to sequence queued map leaves: %v", mapID, err)
+			continue
+		}
+		if n > 0 {
+			glog.V(1).Infof("%v: sequenced %v queued leaves", mapID, n)
+			s.sequenced.Add(float64(n))
+		}
+	}
+	return nil
+}
+
+// masterFor returns the maps of allIDs this instance is master for, starting elections for
+// the maps that don't have one yet. Without an ElectionFactory it's master for every map.
+func (s *MapSequencer) masterFor(ctx context.Context, allIDs []int64) ([]int64, error) {
+	if s.registry.ElectionFactory == nil {
+		return allIDs, nil
+	}
+	if s.tracker == nil {
+		s.tracker = util.NewMasterTracker(allIDs)
+	}
+	for _, mapID := range allIDs {
+		if s.runners[mapID] != nil {
+			continue
+		}
+		glog.Infof("create master election goroutine for map %v", mapID)
+		innerCtx, cancel := context.WithCancel(ctx)
+		election, err := s.registry.ElectionFactory.NewElection(innerCtx, mapID)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create election for map %d: %v", mapID, err)
+		}
+		s.runners[mapID] = &electionRunner{
+			logID:    mapID,
+			info:     &s.info,
+			tracker:  s.tracker,
+			cancel:   cancel,
+			wg:       &s.runnerWG,
+			election: election,
+		}
+		s.runnerWG.Add(1)
+		go s.runners[mapID].Run(innerCtx)
+	}
+
+	// Maps that are no longer active keep their election until Run exits, but aren't
+	// sequenced.
+	active := make(map[int64]bool)
+	for _, mapID := range allIDs {
+		active[mapID] = true
+	}
+	var held []int64
+	for _, mapID := range s.tracker.Held() {
+		if active[mapID] {
+			held = append(held, mapID)
+		}
+	}
+	return held, nil
+}
+
+// stopElections closes the elections started by Sequence, and waits for them to finish.
+func (s *MapSequencer) stopElections() {
+	for mapID, runner := range s.runners {
+		glog.V(1).Infof("cancel election runner for map %d", mapID)
+		runner.cancel()
+	}
+	s.runnerWG.Wait()
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/util"
+)
+
+func TestMapSequencer_Sequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	active := *stestonly.MapTree
+	active.TreeId = 1
+	frozen := active
+	frozen.TreeId = 2
+	frozen.TreeState = trillian.TreeState_FROZEN
+	log := *stestonly.LogTree
+	log.TreeId = 3
+	trees := []*trillian.Tree{&active, &frozen, &log}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().ListTrees(gomock.Any()).AnyTimes().Return(trees, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), active.TreeId).AnyTimes().Return(&active, nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+
+	// Only the active map is sequenced, so all of the queued leaves are its.
+	ms := newFakeMapStorage(1)
+	ms.store.queue = testMapLeaves(0, 5)
+
+	registry := extension.Registry{
+		AdminStorage:  as,
+		MapStorage:    ms,
+		SignerFactory: &keys.DefaultSignerFactory{},
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	s := NewMapSequencer(registry, NewTrillianMapServer(registry), 3, time.Hour)
+	for _, want := range []float64{3, 5, 5} {
+		if err := s.Sequence(context.Background()); err != nil {
+			t.Fatalf("Sequence() returned err = %v", err)
+		}
+		if got := s.sequenced.Value(); got != want {
+			t.Errorf("sequenced map leaves = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMapSequencer_MasterFor(t *testing.T) {
+	mapIDs := []int64{1, 2, 3, 4}
+	for _, test := range []struct {
+		factory util.ElectionFactory
+		want    []int64
+		// wantFirst is the result once only the first two maps are active.
+		wantFirst []int64
+	}{
+		{factory: nil, want: mapIDs, wantFirst: []int64{1, 2}},
+		{factory: util.NoopElectionFactory{InstanceID: "test"}, want: mapIDs, wantFirst: []int64{1, 2}},
+		{factory: masterForEvenFactory{}, want: []int64{2, 4}, wantFirst: []int64{2}},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		registry := extension.Registry{
+			ElectionFactory: test.factory,
+			MetricFactory:   monitoring.InertMetricFactory{},
+		}
+		s := NewMapSequencer(registry, nil, 1, time.Hour)
+
+		// Check mastership twice, to give the elections a chance to get started and report.
+		s.masterFor(ctx, mapIDs)
+		time.Sleep(2 * minMasterCheckInterval)
+		if got, err := s.masterFor(ctx, mapIDs); err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("masterFor(factory=%T)=%v,%v; want %v,nil", test.factory, got, err, test.want)
+		}
+		if got, err := s.masterFor(ctx, mapIDs[:2]); err != nil || !reflect.DeepEqual(got, test.wantFirst) {
+			t.Errorf("masterFor(factory=%T, first maps)=%v,%v; want %v,nil", test.factory, got, err, test.wantFirst)
+		}
+
+		cancel()
+		s.stopElections()
+	}
+}
+
+func TestMapSequencer_MasterForFails(t *testing.T) {
+	registry := extension.Registry{
+		ElectionFactory: failureFactory{},
+		MetricFactory:   monitoring.InertMetricFactory{},
+	}
+	s := NewMapSequencer(registry, nil, 1, time.Hour)
+	if got, err := s.masterFor(context.Background(), []int64{1}); err == nil {
+		t.Errorf("masterFor()=%v,nil; want _,err", got)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/monitoring/prometheus"
+	"github.com/google/trillian/monitoring/statsd"
+	"golang.org/x/net/context"
+)
+
+// Metrics backends that MetricsConfig.Backend selects from.
+const (
+	PrometheusBackend = "prometheus"
+	OTLPBackend       = "otlp"
+	StatsDBackend     = "statsd"
+	DogStatsDBackend  = "dogstatsd"
+)
+
+// newOTLPMetricFactory creates the MetricFactory of OTLPBackend, and a function that
+// exports pending metrics and stops the exporter. OpenTelemetry isn't vendored, so it's
+// only set in binaries built with the otel tag, see otel.go.
+var newOTLPMetricFactory func(ctx context.Context, endpoint string, interval time.Duration) (monitoring.MetricFactory, func(), error)
+
+// MetricsConfig configures the backend a server's metrics are exported with.
+type MetricsConfig struct {
+	// Backend is one of the backends above. Empty means OTLPBackend if OTLPEndpoint is
+	// set, and PrometheusBackend otherwise.
+	Backend string
+	// Prometheus is the MetricFactory used by PrometheusBackend.
+	Prometheus prometheus.MetricFactory
+	// OTLPEndpoint (host:port) is where OTLPBackend exports metrics to, every
+	// OTLPExportInterval.
+	OTLPEndpoint       string
+	OTLPExportInterval time.Duration
+	// StatsDEndpoint (host:port) is where StatsDBackend and DogStatsDBackend send metrics to,
+	// at least every StatsDFlushInterval. Metric names are prefixed with StatsDPrefix.
+	StatsDEndpoint      string
+	StatsDFlushInterval time.Duration
+	StatsDPrefix        string
+}
+
+// NewMetricFactory creates a MetricFactory for cfg's backend. The returned function sends
+// pending metrics and releases the backend; it should be called before exiting.
+func NewMetricFactory(ctx context.Context, cfg MetricsConfig) (monitoring.MetricFactory, func(), error) {
+	backend := cfg.Backend
+	if backend == "" {
+		backend = PrometheusBackend
+		if cfg.OTLPEndpoint != "" {
+			backend = OTLPBackend
+		}
+	}
+	switch backend {
+	case PrometheusBackend:
+		return cfg.Prometheus, func() {}, nil
+	case OTLPBackend:
+		if cfg.OTLPEndpoint == "" {
+			return nil, nil, fmt.Errorf("metrics backend %v needs an OTLP endpoint", backend)
+		}
+		if newOTLPMetricFactory == nil {
+			return nil, nil, fmt.Errorf("metrics backend %v needs a binary built with -tags otel", backend)
+		}
+		return newOTLPMetricFactory(ctx, cfg.OTLPEndpoint, cfg.OTLPExportInterval)
+	case StatsDBackend, DogStatsDBackend:
+		if cfg.StatsDEndpoint == "" {
+			return nil, nil, fmt.Errorf("metrics backend %v needs a StatsD endpoint", backend)
+		}
+		client, err := statsd.NewClient(cfg.StatsDEndpoint, cfg.StatsDFlushInterval, backend == DogStatsDBackend)
+		if err != nil {
+			return nil, nil, err
+		}
+		return statsd.MetricFactory{Prefix: cfg.StatsDPrefix, Client: client}, func() { client.Close() }, nil
+	}
+	return nil, nil, fmt.Errorf("unknown metrics backend %q", backend)
+}
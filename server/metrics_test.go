@@ -0,0 +1,62 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/trillian/monitoring/prometheus"
+	"github.com/google/trillian/monitoring/statsd"
+	"golang.org/x/net/context"
+)
+
+func TestNewMetricFactory(t *testing.T) {
+	pmf := prometheus.MetricFactory{Prefix: "TestNewMetricFactory"}
+	tests := []struct {
+		desc    string
+		cfg     MetricsConfig
+		want    string
+		wantErr bool
+	}{
+		{desc: "default", cfg: MetricsConfig{Prometheus: pmf}, want: "prometheus.MetricFactory"},
+		{desc: "prometheus", cfg: MetricsConfig{Backend: PrometheusBackend, Prometheus: pmf}, want: "prometheus.MetricFactory"},
+		{desc: "statsd", cfg: MetricsConfig{Backend: StatsDBackend, StatsDEndpoint: "localhost:8125", StatsDFlushInterval: time.Second}, want: "statsd.MetricFactory"},
+		{desc: "dogstatsd", cfg: MetricsConfig{Backend: DogStatsDBackend, StatsDEndpoint: "localhost:8125", StatsDFlushInterval: time.Second}, want: "statsd.MetricFactory"},
+		{desc: "statsdWithoutEndpoint", cfg: MetricsConfig{Backend: StatsDBackend}, wantErr: true},
+		{desc: "otlpWithoutEndpoint", cfg: MetricsConfig{Backend: OTLPBackend}, wantErr: true},
+		{desc: "statsdWithoutInterval", cfg: MetricsConfig{Backend: StatsDBackend, StatsDEndpoint: "localhost:8125"}, wantErr: true},
+		{desc: "unknown", cfg: MetricsConfig{Backend: "graphite"}, wantErr: true},
+	}
+	for _, test := range tests {
+		mf, closeMetrics, err := NewMetricFactory(context.Background(), test.cfg)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: NewMetricFactory() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		} else if hasErr {
+			continue
+		}
+		closeMetrics()
+		var got string
+		switch mf.(type) {
+		case prometheus.MetricFactory:
+			got = "prometheus.MetricFactory"
+		case statsd.MetricFactory:
+			got = "statsd.MetricFactory"
+		}
+		if got != test.want {
+			t.Errorf("%v: NewMetricFactory() = %T, want %v", test.desc, mf, test.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build otel
+// +build otel
+
+package server
+
+import (
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/monitoring/opentelemetry"
+	"golang.org/x/net/context"
+)
+
+func init() {
+	newOTLPMetricFactory = func(ctx context.Context, endpoint string, interval time.Duration) (monitoring.MetricFactory, func(), error) {
+		omf, err := opentelemetry.NewExportingMetricFactory(ctx, endpoint, interval)
+		if err != nil {
+			return nil, nil, err
+		}
+		return omf, func() { omf.Shutdown(context.Background()) }, nil
+	}
+	newOTLPTracer = func(ctx context.Context, endpoint, serviceName string, sampleFraction float64) (func(), error) {
+		tp, err := opentelemetry.NewTracerProvider(ctx, endpoint, serviceName, sampleFraction)
+		if err != nil {
+			return nil, err
+		}
+		return func() { tp.Shutdown(context.Background()) }, nil
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+)
+
+// runPeriodically calls f every interval until ctx is done, starting immediately. Errors
+// returned by f are logged as failures to do what desc describes. f is expected to handle
+// trees independently, logging and skipping the trees it fails on rather than returning an
+// error, so that one bad tree doesn't hold up the others.
+func runPeriodically(ctx context.Context, interval time.Duration, desc string, f func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := f(ctx); err != nil {
+			glog.Warningf("Failed to %v: %v", desc, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listTrees returns all the trees in admin, deleted ones included.
+func listTrees(ctx context.Context, admin storage.AdminStorage) ([]*trillian.Tree, error) {
+	tx, err := admin.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx for listing trees: %v", err)
+	}
+	defer tx.Close()
+
+	trees, err := tx.ListTrees(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trees: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit listing trees: %v", err)
+	}
+	return trees, nil
+}
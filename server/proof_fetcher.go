@@ -22,6 +22,8 @@ import (
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // fetchNodesAndBuildProof is used by both inclusion and consistency proofs. It fetches the nodes
@@ -43,6 +45,53 @@ func fetchNodesAndBuildProof(ctx context.Context, tx storage.NodeReader, th hash
 	return r.rehashedProof(leafIndex)
 }
 
+// checkProofFetches returns an Internal error if fetches holds more nodes than any proof in a
+// tree of treeSize leaves needs, so that a bad computation can't make a request read and hash
+// an unbounded number of nodes.
+func checkProofFetches(fetches []merkle.NodeFetch, treeSize int64) error {
+	if got, max := len(fetches), merkle.MaxProofNodeFetches(treeSize); got > max {
+		return status.Errorf(codes.Internal, "proof needs %v nodes, but no proof in a tree of size %v needs more than %v", got, treeSize, max)
+	}
+	return nil
+}
+
+// fetchNodesAndBuildProofs is like fetchNodesAndBuildProof but builds several proofs at the
+// same tree revision with a single storage read. Nodes needed by more than one proof are only
+// fetched once.
+func fetchNodesAndBuildProofs(ctx context.Context, tx storage.NodeReader, th hashers.LogHasher, treeRevision int64, leafIndices []int64, proofNodeFetches [][]merkle.NodeFetch) ([]trillian.Proof, error) {
+	// Deduplicate the fetches, remembering where each node ends up.
+	var fetches []merkle.NodeFetch
+	pos := make(map[string]int)
+	for _, pf := range proofNodeFetches {
+		for _, fetch := range pf {
+			k := fetch.NodeID.String()
+			if _, ok := pos[k]; !ok {
+				pos[k] = len(fetches)
+				fetches = append(fetches, fetch)
+			}
+		}
+	}
+
+	nodes, err := fetchNodes(ctx, tx, treeRevision, fetches)
+	if err != nil {
+		return nil, err
+	}
+
+	proofs := make([]trillian.Proof, 0, len(proofNodeFetches))
+	for i, pf := range proofNodeFetches {
+		r := &rehasher{th: th}
+		for _, fetch := range pf {
+			r.process(nodes[pos[fetch.NodeID.String()]], fetch)
+		}
+		proof, err := r.rehashedProof(leafIndices[i])
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, proof)
+	}
+	return proofs, nil
+}
+
 // rehasher bundles the rehashing logic into a simple state machine
 type rehasher struct {
 	th         hashers.LogHasher
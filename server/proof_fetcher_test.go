@@ -18,6 +18,8 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"math/rand"
+	"sync/atomic"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -25,7 +27,10 @@ import (
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
 	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // rehashTest encapsulates one test case for the rehasher in isolation. Input data like the storage
@@ -286,6 +291,156 @@ func TestTree32ConsistencyProofFetchAll(t *testing.T) {
 	}
 }
 
+func TestTree32InclusionAndConsistencyProofsFetchAll(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 32
+	r := testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+		{TreeRevision: testTreeRevision, Leaves: expandLeaves(0, ts-1), ExpectedRoot: expectedRootAtSize(treeAtSize(ts))},
+	})
+
+	for s1 := int64(2); s1 < ts; s1++ {
+		for s2 := s1 + 1; s2 <= ts; s2++ {
+			for l := int64(0); l < s2; l++ {
+				incl, err := merkle.CalcInclusionProofNodeAddresses(s2, l, ts, 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cons, err := merkle.CalcConsistencyProofNodeAddresses(s1, s2, ts, 64)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := fetchNodesAndBuildProofs(ctx, r, hasher, testTreeRevision, []int64{l, 0}, [][]merkle.NodeFetch{incl, cons})
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantIncl, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, l, incl)
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantCons, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, 0, cons)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !proto.Equal(&got[0], &wantIncl) {
+					t.Errorf("(%d, %d, %d): inclusion proof %v, want %v", s1, s2, l, got[0], wantIncl)
+				}
+				if !proto.Equal(&got[1], &wantCons) {
+					t.Errorf("(%d, %d, %d): consistency proof %v, want %v", s1, s2, l, got[1], wantCons)
+				}
+			}
+		}
+	}
+}
+
+// benchmarkProofFetches serves an inclusion and a consistency proof for a large tree, either
+// with one combined read or with one read per proof.
+func benchmarkProofFetches(b *testing.B, combined bool) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 1 << 10
+	r := testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+		{TreeRevision: testTreeRevision, Leaves: expandLeaves(0, ts-1), ExpectedRoot: expectedRootAtSize(treeAtSize(ts))},
+	})
+	const s1, s2, l = ts/2 + 3, ts - 1, ts - 7
+	incl, err := merkle.CalcInclusionProofNodeAddresses(s2, l, ts, 64)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cons, err := merkle.CalcConsistencyProofNodeAddresses(s1, s2, ts, 64)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if combined {
+			if _, err := fetchNodesAndBuildProofs(ctx, r, hasher, testTreeRevision, []int64{l, 0}, [][]merkle.NodeFetch{incl, cons}); err != nil {
+				b.Fatal(err)
+			}
+			continue
+		}
+		if _, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, l, incl); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, 0, cons); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInclusionAndConsistencyProofsCombined(b *testing.B) {
+	benchmarkProofFetches(b, true)
+}
+
+func BenchmarkInclusionAndConsistencyProofsSeparate(b *testing.B) {
+	benchmarkProofFetches(b, false)
+}
+
+// countingNodeReader counts the nodes read from a NodeReader.
+type countingNodeReader struct {
+	storage.NodeReader
+	reads int64
+}
+
+func (c *countingNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	atomic.AddInt64(&c.reads, int64(len(ids)))
+	return c.NodeReader.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+// cachedNodeReader reads the nodes of a tree through a NodeCache.
+type cachedNodeReader struct {
+	storage.NodeReader
+	cache *cache.NodeCache
+}
+
+func (c cachedNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return c.cache.GetMerkleNodes(ctx, c.NodeReader, 1, treeRevision, ids)
+}
+
+// benchmarkConcurrentInclusionProofs serves inclusion proofs of random leaves of a large
+// tree from concurrent goroutines, optionally through a node cache, and reports the number
+// of nodes read from storage per proof.
+func benchmarkConcurrentInclusionProofs(b *testing.B, cacheSize int) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 1 << 12
+	counter := &countingNodeReader{NodeReader: testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+		{TreeRevision: testTreeRevision, Leaves: expandLeaves(0, ts-1), ExpectedRoot: expectedRootAtSize(treeAtSize(ts))},
+	})}
+	var r storage.NodeReader = counter
+	if cacheSize > 0 {
+		r = cachedNodeReader{NodeReader: counter, cache: cache.NewNodeCache(cacheSize, nil)}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			l := rnd.Int63n(ts)
+			fetches, err := merkle.CalcInclusionProofNodeAddresses(ts, l, ts, 64)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, l, fetches); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.StopTimer()
+	b.Logf("%.2f storage nodes read per op", float64(atomic.LoadInt64(&counter.reads))/float64(b.N))
+}
+
+func BenchmarkConcurrentInclusionProofsUncached(b *testing.B) {
+	benchmarkConcurrentInclusionProofs(b, 0)
+}
+
+func BenchmarkConcurrentInclusionProofsCached(b *testing.B) {
+	benchmarkConcurrentInclusionProofs(b, 1024)
+}
+
 func expandLeaves(n, m int) []string {
 	leaves := make([]string, 0, m-n+1)
 	for l := n; l <= m; l++ {
@@ -308,3 +463,14 @@ func treeAtSize(n int) *merkle.InMemoryMerkleTree {
 	}
 	return mt
 }
+
+func TestCheckProofFetches(t *testing.T) {
+	// A tree of size 7 is 3 levels high.
+	fetches := make([]merkle.NodeFetch, 7)
+	if err := checkProofFetches(fetches[:6], 7); err != nil {
+		t.Errorf("checkProofFetches(6 fetches, 7) = %v, want nil", err)
+	}
+	if err := checkProofFetches(fetches, 7); status.Code(err) != codes.Internal {
+		t.Errorf("checkProofFetches(7 fetches, 7) = %v, want code %v", err, codes.Internal)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// proofVerificationError is returned when a proof computed by the server fails to verify,
+// as opposed to when the data needed to verify it could not be read.
+type proofVerificationError struct {
+	err error
+}
+
+func (e proofVerificationError) Error() string {
+	return fmt.Sprintf("proof failed self-verification: %v", e.err)
+}
+
+// proofVerifier checks proofs computed by the server against the latest signed root of the
+// log, using the same verification code as clients. Roots of earlier tree sizes are derived
+// from the log's contents and are themselves checked for consistency with the signed root.
+type proofVerifier struct {
+	tx       storage.ReadOnlyLogTreeTX
+	hasher   hashers.LogHasher
+	verifier merkle.LogVerifier
+	root     *trillian.SignedLogRoot
+	roots    map[int64][]byte
+}
+
+func newProofVerifier(tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher, root *trillian.SignedLogRoot) *proofVerifier {
+	return &proofVerifier{
+		tx:       tx,
+		hasher:   hasher,
+		verifier: merkle.NewLogVerifier(hasher),
+		root:     root,
+		roots:    map[int64][]byte{root.TreeSize: root.RootHash},
+	}
+}
+
+// verifyInclusion checks that proof shows the leaf with the given hash to be included in
+// the tree of size treeSize.
+func (v *proofVerifier) verifyInclusion(ctx context.Context, treeSize int64, leafHash []byte, proof *trillian.Proof) error {
+	root, err := v.rootAtSize(ctx, treeSize)
+	if err != nil {
+		return err
+	}
+	if err := v.verifier.VerifyInclusionProof(proof.LeafIndex, treeSize, proof.Hashes, root, leafHash); err != nil {
+		return proofVerificationError{err}
+	}
+	return nil
+}
+
+// verifyInclusionAtIndex is like verifyInclusion, but reads the hash of the leaf at the
+// proof's index from storage.
+func (v *proofVerifier) verifyInclusionAtIndex(ctx context.Context, treeSize int64, proof *trillian.Proof) error {
+	leafHash, err := v.leafHash(ctx, proof.LeafIndex)
+	if err != nil {
+		return err
+	}
+	return v.verifyInclusion(ctx, treeSize, leafHash, proof)
+}
+
+// verifyConsistency checks that proof shows the tree of size first to be a prefix of the
+// tree of size second.
+func (v *proofVerifier) verifyConsistency(ctx context.Context, first, second int64, proof *trillian.Proof) error {
+	root1, err := v.rootAtSize(ctx, first)
+	if err != nil {
+		return err
+	}
+	root2, err := v.rootAtSize(ctx, second)
+	if err != nil {
+		return err
+	}
+	if err := v.verifier.VerifyConsistencyProof(first, second, root1, root2, proof.Hashes); err != nil {
+		return proofVerificationError{err}
+	}
+	return nil
+}
+
+// rootAtSize returns the root hash of the tree of the given size, which must not exceed the
+// size of the signed root. The root is computed from an inclusion proof of the last leaf of
+// the smaller tree, and only trusted once a consistency proof from it to the signed root
+// verifies.
+func (v *proofVerifier) rootAtSize(ctx context.Context, treeSize int64) ([]byte, error) {
+	if root, ok := v.roots[treeSize]; ok {
+		return root, nil
+	}
+	if treeSize == 0 {
+		return v.hasher.EmptyRoot(), nil
+	}
+	if treeSize > v.root.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "tree size %v > signed tree size %v", treeSize, v.root.TreeSize)
+	}
+
+	leafHash, err := v.leafHash(ctx, treeSize-1)
+	if err != nil {
+		return nil, err
+	}
+	inclusion, err := getInclusionProofForLeafIndex(ctx, v.tx, v.hasher, treeSize, treeSize-1, v.root.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	root, err := v.verifier.RootFromInclusionProof(treeSize-1, treeSize, inclusion.Hashes, leafHash)
+	if err != nil {
+		return nil, proofVerificationError{err}
+	}
+
+	nodeFetches, err := merkle.CalcConsistencyProofNodeAddresses(treeSize, v.root.TreeSize, v.root.TreeSize, proofMaxBitLen)
+	if err != nil {
+		return nil, err
+	}
+	consistency, err := fetchNodesAndBuildProof(ctx, v.tx, v.hasher, v.tx.ReadRevision(), 0, nodeFetches)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.verifier.VerifyConsistencyProof(treeSize, v.root.TreeSize, root, v.root.RootHash, consistency.Hashes); err != nil {
+		return nil, proofVerificationError{err}
+	}
+
+	v.roots[treeSize] = root
+	return root, nil
+}
+
+func (v *proofVerifier) leafHash(ctx context.Context, leafIndex int64) ([]byte, error) {
+	leaves, err := v.tx.GetLeavesByIndex(ctx, []int64{leafIndex})
+	if err != nil {
+		return nil, err
+	}
+	if len(leaves) != 1 {
+		return nil, status.Errorf(codes.Internal, "expected one leaf from storage but got: %d", len(leaves))
+	}
+	return leaves[0].MerkleLeafHash, nil
+}
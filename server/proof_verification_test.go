@@ -0,0 +1,313 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeProofTX serves the nodes and leaves of a log built from expandLeaves.
+type fakeProofTX struct {
+	storage.ReadOnlyLogTreeTX
+	nodes  *testonly.MultiFakeNodeReader
+	leaves []string
+}
+
+func (f fakeProofTX) ReadRevision() int64 {
+	return testTreeRevision
+}
+
+func (f fakeProofTX) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return f.nodes.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+func (f fakeProofTX) GetLeavesByIndex(ctx context.Context, indices []int64) ([]*trillian.LogLeaf, error) {
+	leaves := make([]*trillian.LogLeaf, 0, len(indices))
+	for _, i := range indices {
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIndex:      i,
+			MerkleLeafHash: rfc6962.DefaultHasher.HashLeaf([]byte(f.leaves[i])),
+		})
+	}
+	return leaves, nil
+}
+
+func newFakeProofTX(ts int) (fakeProofTX, trillian.SignedLogRoot) {
+	mt := treeAtSize(ts)
+	leaves := expandLeaves(0, ts-1)
+	tx := fakeProofTX{
+		nodes: testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+			{TreeRevision: testTreeRevision, Leaves: leaves, ExpectedRoot: expectedRootAtSize(mt)},
+		}),
+		leaves: leaves,
+	}
+	return tx, trillian.SignedLogRoot{TreeSize: int64(ts), RootHash: expectedRootAtSize(mt), TreeRevision: testTreeRevision}
+}
+
+func TestProofVerifier(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 13
+	tx, root := newFakeProofTX(ts)
+	v := newProofVerifier(tx, hasher, &root)
+
+	for s := int64(1); s <= ts; s++ {
+		for l := int64(0); l < s; l++ {
+			proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, s, l, ts)
+			if err != nil {
+				t.Fatalf("getInclusionProofForLeafIndex(%v, %v): %v", s, l, err)
+			}
+			if err := v.verifyInclusionAtIndex(ctx, s, &proof); err != nil {
+				t.Errorf("verifyInclusionAtIndex(%v, %v) = %v, want nil", s, l, err)
+			}
+		}
+		for f := int64(1); f < s; f++ {
+			fetches, err := merkle.CalcConsistencyProofNodeAddresses(f, s, ts, proofMaxBitLen)
+			if err != nil {
+				t.Fatalf("CalcConsistencyProofNodeAddresses(%v, %v): %v", f, s, err)
+			}
+			proof, err := fetchNodesAndBuildProof(ctx, tx, hasher, testTreeRevision, 0, fetches)
+			if err != nil {
+				t.Fatalf("fetchNodesAndBuildProof(%v, %v): %v", f, s, err)
+			}
+			if err := v.verifyConsistency(ctx, f, s, &proof); err != nil {
+				t.Errorf("verifyConsistency(%v, %v) = %v, want nil", f, s, err)
+			}
+		}
+	}
+}
+
+func TestProofVerifierBadProofs(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 13
+	tx, root := newFakeProofTX(ts)
+
+	inclusion, err := getInclusionProofForLeafIndex(ctx, tx, hasher, 10, 4, ts)
+	if err != nil {
+		t.Fatalf("getInclusionProofForLeafIndex(): %v", err)
+	}
+	fetches, err := merkle.CalcConsistencyProofNodeAddresses(5, 10, ts, proofMaxBitLen)
+	if err != nil {
+		t.Fatalf("CalcConsistencyProofNodeAddresses(): %v", err)
+	}
+	consistency, err := fetchNodesAndBuildProof(ctx, tx, hasher, testTreeRevision, 0, fetches)
+	if err != nil {
+		t.Fatalf("fetchNodesAndBuildProof(): %v", err)
+	}
+
+	corrupt := func(p trillian.Proof) *trillian.Proof {
+		hashes := make([][]byte, len(p.Hashes))
+		copy(hashes, p.Hashes)
+		hashes[0] = append([]byte{}, hashes[0]...)
+		hashes[0][0] ^= 1
+		return &trillian.Proof{LeafIndex: p.LeafIndex, Hashes: hashes}
+	}
+	badRoot := root
+	badRoot.RootHash = hasher.HashLeaf([]byte("not the root"))
+
+	for _, test := range []struct {
+		desc   string
+		root   *trillian.SignedLogRoot
+		verify func(v *proofVerifier) error
+	}{
+		{
+			desc:   "inclusionCorrupt",
+			root:   &root,
+			verify: func(v *proofVerifier) error { return v.verifyInclusionAtIndex(ctx, 10, corrupt(inclusion)) },
+		},
+		{
+			desc: "inclusionTruncated",
+			root: &root,
+			verify: func(v *proofVerifier) error {
+				return v.verifyInclusionAtIndex(ctx, 10, &trillian.Proof{LeafIndex: 4, Hashes: inclusion.Hashes[1:]})
+			},
+		},
+		{
+			desc: "inclusionWrongLeaf",
+			root: &root,
+			verify: func(v *proofVerifier) error {
+				return v.verifyInclusion(ctx, 10, hasher.HashLeaf([]byte("other")), &inclusion)
+			},
+		},
+		{
+			desc:   "inclusionBadSignedRoot",
+			root:   &badRoot,
+			verify: func(v *proofVerifier) error { return v.verifyInclusionAtIndex(ctx, 10, &inclusion) },
+		},
+		{
+			desc:   "consistencyCorrupt",
+			root:   &root,
+			verify: func(v *proofVerifier) error { return v.verifyConsistency(ctx, 5, 10, corrupt(consistency)) },
+		},
+		{
+			desc:   "consistencyBadSignedRoot",
+			root:   &badRoot,
+			verify: func(v *proofVerifier) error { return v.verifyConsistency(ctx, 5, 10, &consistency) },
+		},
+	} {
+		err := test.verify(newProofVerifier(tx, hasher, test.root))
+		if _, ok := err.(proofVerificationError); !ok {
+			t.Errorf("%v: verify() = %v, want proofVerificationError", test.desc, err)
+		}
+	}
+}
+
+func TestCheckProof(t *testing.T) {
+	mf := monitoring.InertMetricFactory{}
+	verificationErr := proofVerificationError{errors.New("bad proof")}
+	otherErr := errors.New("storage failed")
+
+	for _, test := range []struct {
+		desc        string
+		enabled     bool
+		verifyErr   error
+		wantErr     error
+		wantCode    codes.Code
+		wantFailure bool
+	}{
+		{desc: "disabled", verifyErr: verificationErr},
+		{desc: "verified", enabled: true},
+		{desc: "failed", enabled: true, verifyErr: verificationErr, wantCode: codes.Internal, wantFailure: true},
+		{desc: "otherError", enabled: true, verifyErr: otherErr, wantErr: otherErr},
+	} {
+		server := NewTrillianLogRPCServer(extension.Registry{MetricFactory: mf}, nil)
+		server.SetProofVerification(test.enabled)
+		before := server.verificationFailures.Value("GetInclusionProof")
+
+		called := false
+		err := server.checkProof(1, "GetInclusionProof", func() error {
+			called = true
+			return test.verifyErr
+		})
+		if called != test.enabled {
+			t.Errorf("%v: verify called = %v, want %v", test.desc, called, test.enabled)
+		}
+		switch {
+		case test.wantCode != codes.OK:
+			if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
+				t.Errorf("%v: checkProof() = %v, want code %v", test.desc, err, test.wantCode)
+			}
+		case err != test.wantErr:
+			t.Errorf("%v: checkProof() = %v, want %v", test.desc, err, test.wantErr)
+		}
+		failures := server.verificationFailures.Value("GetInclusionProof") - before
+		if got, want := failures > 0, test.wantFailure; got != want {
+			t.Errorf("%v: failure counted = %v, want %v", test.desc, got, want)
+		}
+	}
+}
+
+func TestCheckConsistencyProof(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 13
+	tx, root := newFakeProofTX(ts)
+
+	fetches, err := merkle.CalcConsistencyProofNodeAddresses(5, 10, ts, proofMaxBitLen)
+	if err != nil {
+		t.Fatalf("CalcConsistencyProofNodeAddresses(): %v", err)
+	}
+	proof, err := fetchNodesAndBuildProof(ctx, tx, hasher, testTreeRevision, 0, fetches)
+	if err != nil {
+		t.Fatalf("fetchNodesAndBuildProof(): %v", err)
+	}
+	v := newProofVerifier(tx, hasher, &root)
+	root5, err := v.rootAtSize(ctx, 5)
+	if err != nil {
+		t.Fatalf("rootAtSize(5): %v", err)
+	}
+	root10, err := v.rootAtSize(ctx, 10)
+	if err != nil {
+		t.Fatalf("rootAtSize(10): %v", err)
+	}
+	otherRoot := hasher.HashLeaf([]byte("not the root"))
+	badProof := &trillian.Proof{Hashes: append([][]byte{otherRoot}, proof.Hashes[1:]...)}
+
+	for _, test := range []struct {
+		desc string
+		req  *trillian.CheckConsistencyProofRequest
+		want trillian.ConsistencyProofCheck
+	}{
+		{
+			desc: "valid",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: root5, SecondRootHash: root10, Proof: &proof},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_VALID,
+		},
+		{
+			desc: "sameSize",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 10, SecondTreeSize: 10, FirstRootHash: root10, SecondRootHash: root10},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_VALID,
+		},
+		{
+			desc: "invalidProof",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: root5, SecondRootHash: root10, Proof: badProof},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_INVALID,
+		},
+		{
+			desc: "truncatedProof",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: root5, SecondRootHash: root10, Proof: &trillian.Proof{Hashes: proof.Hashes[1:]}},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_INVALID,
+		},
+		{
+			desc: "firstRootMismatch",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: otherRoot, SecondRootHash: root10, Proof: &proof},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_ROOT_MISMATCH,
+		},
+		{
+			desc: "secondRootMismatch",
+			req:  &trillian.CheckConsistencyProofRequest{FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: root5, SecondRootHash: otherRoot, Proof: badProof},
+			want: trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_ROOT_MISMATCH,
+		},
+	} {
+		resp, err := checkConsistencyProof(ctx, newProofVerifier(tx, hasher, &root), test.req)
+		if err != nil {
+			t.Errorf("%v: checkConsistencyProof() = (_, %v), want (_, nil)", test.desc, err)
+			continue
+		}
+		if got := resp.Result; got != test.want {
+			t.Errorf("%v: checkConsistencyProof() = %v, want %v", test.desc, got, test.want)
+		}
+		if got, want := resp.Detail != "", test.want != trillian.ConsistencyProofCheck_CONSISTENCY_PROOF_VALID; got != want {
+			t.Errorf("%v: checkConsistencyProof().Detail = %q, want detail: %v", test.desc, resp.Detail, want)
+		}
+	}
+}
+
+func TestCheckConsistencyProofDisabled(t *testing.T) {
+	server := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
+	req := &trillian.CheckConsistencyProofRequest{LogId: logID1, FirstTreeSize: 5, SecondTreeSize: 10, FirstRootHash: []byte("a"), SecondRootHash: []byte("b")}
+	if _, err := server.CheckConsistencyProof(context.Background(), req); status.Code(err) != codes.Unimplemented {
+		t.Errorf("CheckConsistencyProof() = (_, %v), want code %v", err, codes.Unimplemented)
+	}
+
+	server.SetConsistencyProofChecks(true)
+	req.SecondTreeSize = 4
+	if _, err := server.CheckConsistencyProof(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CheckConsistencyProof(%v) = (_, %v), want code %v", req, err, codes.InvalidArgument)
+	}
+}
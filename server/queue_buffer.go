@@ -0,0 +1,272 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// QueueBufferOptions configures the buffering of QueueLeaves requests, see
+// TrillianLogRPCServer.SetQueueBuffer.
+type QueueBufferOptions struct {
+	// MaxBytes bounds the size of the leaves buffered across all logs, counting their values,
+	// extra data and identity hashes. Requests that would exceed it fail with
+	// ResourceExhausted, except for requests larger than MaxBytes on their own, which are
+	// written straight away instead of being buffered.
+	MaxBytes int64
+	// BatchSize is the number of buffered leaves of a log that triggers writing them.
+	BatchSize int
+	// FlushInterval is the longest time leaves are buffered before they're written.
+	FlushInterval time.Duration
+}
+
+// writeQueuedFunc durably queues leaves of tree, returning the leaves that already existed as
+// storage.LogTreeTX.QueueLeaves does.
+type writeQueuedFunc func(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error)
+
+// queueBuffer batches the leaves of concurrent QueueLeaves requests for the same log into a
+// single storage write. Requests only complete once the write of their leaves has, so buffering
+// doesn't weaken durability. If a batch fails, its requests are written one by one, so each
+// gets its own result.
+type queueBuffer struct {
+	write    writeQueuedFunc
+	opts     QueueBufferOptions
+	rejected monitoring.Counter
+	buffered monitoring.Gauge
+
+	mu      sync.Mutex
+	batches map[int64]*queueBatch
+	bytes   int64
+	closed  bool
+	writes  sync.WaitGroup
+}
+
+// queueBatch contains the buffered requests of a log.
+type queueBatch struct {
+	tree    *trillian.Tree
+	entries []*queueEntry
+	leaves  int
+	bytes   int64
+	timer   *time.Timer
+}
+
+// queueEntry is a single buffered request.
+type queueEntry struct {
+	ctx    context.Context
+	leaves []*trillian.LogLeaf
+	done   chan queueResult
+}
+
+type queueResult struct {
+	existing []*trillian.LogLeaf
+	err      error
+}
+
+func newQueueBuffer(write writeQueuedFunc, opts QueueBufferOptions, mf monitoring.MetricFactory) *queueBuffer {
+	return &queueBuffer{
+		write:    write,
+		opts:     opts,
+		rejected: mf.NewCounter("queue_buffer_rejected_leaves", "Number of leaves rejected because the queue buffer was full"),
+		buffered: mf.NewGauge("queue_buffer_bytes", "Size of the leaves held in the queue buffer"),
+		batches:  make(map[int64]*queueBatch),
+	}
+}
+
+// add buffers leaves for tree and waits until they're written.
+func (b *queueBuffer) add(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	size := leavesSize(leaves)
+	entry := &queueEntry{ctx: ctx, leaves: leaves, done: make(chan queueResult, 1)}
+
+	b.mu.Lock()
+	switch {
+	case b.closed:
+		b.mu.Unlock()
+		return nil, status.Errorf(codes.Unavailable, "server is shutting down")
+	case size > b.opts.MaxBytes:
+		// The request would never fit in the buffer, so it's written on its own.
+		b.writes.Add(1)
+		b.mu.Unlock()
+		defer b.writes.Done()
+		return b.write(ctx, tree, leaves)
+	case b.bytes+size > b.opts.MaxBytes:
+		b.mu.Unlock()
+		b.rejected.Add(float64(len(leaves)))
+		return nil, status.Errorf(codes.ResourceExhausted, "queue buffer full, retry later")
+	}
+	b.bytes += size
+	b.buffered.Set(float64(b.bytes))
+
+	batch, ok := b.batches[tree.TreeId]
+	if !ok {
+		batch = &queueBatch{tree: tree}
+		b.batches[tree.TreeId] = batch
+		batch.timer = time.AfterFunc(b.opts.FlushInterval, func() { b.flushBatch(batch) })
+	}
+	batch.entries = append(batch.entries, entry)
+	batch.leaves += len(leaves)
+	batch.bytes += size
+	full := batch.leaves >= b.opts.BatchSize
+	if full {
+		b.detach(batch)
+	}
+	b.mu.Unlock()
+
+	if full {
+		go b.writeBatch(batch)
+	}
+
+	// If ctx is done before the batch is written the leaves are left out of it, otherwise
+	// they may be written anyway.
+	select {
+	case r := <-entry.done:
+		return r.existing, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flushBatch writes batch, unless it has already been written.
+func (b *queueBuffer) flushBatch(batch *queueBatch) {
+	b.mu.Lock()
+	if b.batches[batch.tree.TreeId] != batch {
+		b.mu.Unlock()
+		return
+	}
+	b.detach(batch)
+	b.mu.Unlock()
+	b.writeBatch(batch)
+}
+
+// detach removes batch from the buffer, so no more requests are added to it. b.mu must be held.
+func (b *queueBuffer) detach(batch *queueBatch) {
+	delete(b.batches, batch.tree.TreeId)
+	batch.timer.Stop()
+	b.writes.Add(1)
+}
+
+// writeBatch writes the leaves of a detached batch and completes its requests. Requests
+// whose ctx is done by then aren't written.
+func (b *queueBuffer) writeBatch(batch *queueBatch) {
+	defer b.writes.Done()
+	defer func() {
+		b.mu.Lock()
+		b.bytes -= batch.bytes
+		b.buffered.Set(float64(b.bytes))
+		b.mu.Unlock()
+	}()
+
+	entries := make([]*queueEntry, 0, len(batch.entries))
+	for _, entry := range batch.entries {
+		if err := entry.ctx.Err(); err != nil {
+			entry.done <- queueResult{err: err}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	ctx, cancel := mergedContext(entries)
+	defer cancel()
+	err := b.writeEntries(ctx, batch.tree, entries)
+	if err == nil {
+		return
+	}
+	if len(entries) == 1 {
+		entries[0].done <- queueResult{err: err}
+		return
+	}
+	// Any of the requests may have failed the batch, so they're retried one by one for
+	// their own results. Queueing leaves that already exist is harmless.
+	for _, entry := range entries {
+		if err := b.writeEntries(entry.ctx, batch.tree, []*queueEntry{entry}); err != nil {
+			entry.done <- queueResult{err: err}
+		}
+	}
+}
+
+// writeEntries writes the leaves of entries in a single write. If it succeeds the entries
+// are completed, otherwise the error is returned and they're left incomplete.
+func (b *queueBuffer) writeEntries(ctx context.Context, tree *trillian.Tree, entries []*queueEntry) error {
+	var leaves []*trillian.LogLeaf
+	for _, entry := range entries {
+		leaves = append(leaves, entry.leaves...)
+	}
+	existing, err := b.write(ctx, tree, leaves)
+	if err != nil {
+		return err
+	}
+	if len(existing) != len(leaves) {
+		return status.Errorf(codes.Internal, "got %v results for %v queued leaves", len(existing), len(leaves))
+	}
+	for _, entry := range entries {
+		var r queueResult
+		r.existing, existing = existing[:len(entry.leaves)], existing[len(entry.leaves):]
+		entry.done <- r
+	}
+	return nil
+}
+
+// mergedContext returns a context that is done once the contexts of all entries are, so a
+// shared write is only abandoned once none of its requests are waiting for it.
+func mergedContext(entries []*queueEntry) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for _, entry := range entries {
+			select {
+			case <-entry.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// close writes all buffered leaves, waiting for the writes to complete. Requests made after
+// close fail with Unavailable.
+func (b *queueBuffer) close() {
+	b.mu.Lock()
+	b.closed = true
+	batches := make([]*queueBatch, 0, len(b.batches))
+	for _, batch := range b.batches {
+		b.detach(batch)
+		batches = append(batches, batch)
+	}
+	b.mu.Unlock()
+
+	for _, batch := range batches {
+		go b.writeBatch(batch)
+	}
+	b.writes.Wait()
+}
+
+// leavesSize returns the number of bytes of leaves counted against QueueBufferOptions.MaxBytes.
+func leavesSize(leaves []*trillian.LogLeaf) int64 {
+	var size int64
+	for _, leaf := range leaves {
+		size += int64(len(leaf.LeafValue) + len(leaf.ExtraData) + len(leaf.LeafIdentityHash))
+	}
+	return size
+}
@@ -0,0 +1,278 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeQueueWriter records the batches written through a queueBuffer. Leaves whose value starts
+// with "dup" are reported as existing, and writes of leaves whose value starts with "bad" fail.
+type fakeQueueWriter struct {
+	mu      sync.Mutex
+	batches [][]*trillian.LogLeaf
+	err     error
+	block   chan struct{}
+}
+
+var errBadLeaf = errors.New("bad leaf")
+
+func (w *fakeQueueWriter) write(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	if w.block != nil {
+		<-w.block
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, leaves)
+	if w.err != nil {
+		return nil, w.err
+	}
+	existing := make([]*trillian.LogLeaf, len(leaves))
+	for i, leaf := range leaves {
+		if bytes.HasPrefix(leaf.LeafValue, []byte("bad")) {
+			return nil, errBadLeaf
+		}
+		if bytes.HasPrefix(leaf.LeafValue, []byte("dup")) {
+			existing[i] = leaf
+		}
+	}
+	return existing, nil
+}
+
+func (w *fakeQueueWriter) batchSizes() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var sizes []int
+	for _, b := range w.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func testLeaves(values ...string) []*trillian.LogLeaf {
+	leaves := make([]*trillian.LogLeaf, 0, len(values))
+	for _, v := range values {
+		leaves = append(leaves, &trillian.LogLeaf{LeafValue: []byte(v)})
+	}
+	return leaves
+}
+
+// bufferedBytes waits until b holds want bytes of leaves.
+func bufferedBytes(t *testing.T, b *queueBuffer, want int64) {
+	for i := 0; i < 100; i++ {
+		b.mu.Lock()
+		got := b.bytes
+		b.mu.Unlock()
+		if got == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("queue buffer never held %v bytes", want)
+}
+
+func TestQueueBuffer_BatchSize(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 4, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+	tree := &trillian.Tree{TreeId: 1}
+
+	var wg sync.WaitGroup
+	results := make([][]*trillian.LogLeaf, 2)
+	errs := make([]error, 2)
+	add := func(i int, leaves []*trillian.LogLeaf) {
+		defer wg.Done()
+		results[i], errs[i] = b.add(context.Background(), tree, leaves)
+	}
+	wg.Add(2)
+	go add(0, testLeaves("a", "b"))
+	bufferedBytes(t, b, 2)
+	go add(1, testLeaves("c", "dup"))
+	wg.Wait()
+
+	if got := w.batchSizes(); len(got) != 1 || got[0] != 4 {
+		t.Errorf("written batch sizes = %v, want [4]", got)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("add(%v) returned err = %v", i, err)
+		}
+	}
+	if got := results[0]; len(got) != 2 || got[0] != nil || got[1] != nil {
+		t.Errorf("add(0) = %v, want no existing leaves", got)
+	}
+	if got := results[1]; len(got) != 2 || got[0] != nil || got[1] == nil {
+		t.Errorf("add(1) = %v, want the second leaf to exist", got)
+	}
+	bufferedBytes(t, b, 0)
+}
+
+func TestQueueBuffer_FlushInterval(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 100, FlushInterval: 10 * time.Millisecond}, monitoring.InertMetricFactory{})
+
+	if _, err := b.add(context.Background(), &trillian.Tree{TreeId: 1}, testLeaves("a")); err != nil {
+		t.Fatalf("add() returned err = %v", err)
+	}
+	if got := w.batchSizes(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("written batch sizes = %v, want [1]", got)
+	}
+}
+
+func TestQueueBuffer_Full(t *testing.T) {
+	w := &fakeQueueWriter{block: make(chan struct{})}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 3, BatchSize: 2, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+	tree := &trillian.Tree{TreeId: 1}
+
+	done := make(chan error)
+	go func() {
+		_, err := b.add(context.Background(), tree, testLeaves("a", "b"))
+		done <- err
+	}()
+	bufferedBytes(t, b, 2)
+
+	// Leaves are counted against the buffer until they're written.
+	_, err := b.add(context.Background(), tree, testLeaves("cd"))
+	if got, want := status.Code(err), codes.ResourceExhausted; got != want {
+		t.Errorf("add() returned err = %v, want code %v", err, want)
+	}
+
+	close(w.block)
+	if err := <-done; err != nil {
+		t.Errorf("add() returned err = %v", err)
+	}
+	if _, err := b.add(context.Background(), tree, testLeaves("cd", "e")); err != nil {
+		t.Errorf("add() after write returned err = %v", err)
+	}
+}
+
+func TestQueueBuffer_WriteError(t *testing.T) {
+	w := &fakeQueueWriter{err: errors.New("write failed")}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 1, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+
+	if _, err := b.add(context.Background(), &trillian.Tree{TreeId: 1}, testLeaves("a")); err != w.err {
+		t.Errorf("add() returned err = %v, want %v", err, w.err)
+	}
+	bufferedBytes(t, b, 0)
+}
+
+func TestQueueBuffer_PerRequestErrors(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 2, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+	tree := &trillian.Tree{TreeId: 1}
+
+	done := make(chan error)
+	go func() {
+		_, err := b.add(context.Background(), tree, testLeaves("a"))
+		done <- err
+	}()
+	bufferedBytes(t, b, 1)
+
+	// The merged write fails, and only the request with the bad leaf fails when retried.
+	if _, err := b.add(context.Background(), tree, testLeaves("bad")); err != errBadLeaf {
+		t.Errorf("add(bad) returned err = %v, want %v", err, errBadLeaf)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("add(a) returned err = %v", err)
+	}
+	if got := w.batchSizes(); len(got) != 3 || got[0] != 2 {
+		t.Errorf("written batch sizes = %v, want [2 1 1]", got)
+	}
+	bufferedBytes(t, b, 0)
+}
+
+func TestQueueBuffer_CancelledRequest(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 2, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+	tree := &trillian.Tree{TreeId: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := b.add(ctx, tree, testLeaves("a"))
+		done <- err
+	}()
+	bufferedBytes(t, b, 1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("add() with cancelled ctx returned err = %v, want %v", err, context.Canceled)
+	}
+
+	// The cancelled request is left out of the batch it's in.
+	if _, err := b.add(context.Background(), tree, testLeaves("b")); err != nil {
+		t.Errorf("add() returned err = %v", err)
+	}
+	if got := w.batchSizes(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("written batch sizes = %v, want [1]", got)
+	}
+	bufferedBytes(t, b, 0)
+}
+
+func TestQueueBuffer_Oversized(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 3, BatchSize: 100, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+
+	// The request can never fit in the buffer, so it's written without waiting for a flush.
+	existing, err := b.add(context.Background(), &trillian.Tree{TreeId: 1}, testLeaves("abcd", "dup"))
+	if err != nil {
+		t.Fatalf("add() returned err = %v", err)
+	}
+	if len(existing) != 2 || existing[0] != nil || existing[1] == nil {
+		t.Errorf("add() = %v, want the second leaf to exist", existing)
+	}
+	if got := w.batchSizes(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("written batch sizes = %v, want [2]", got)
+	}
+	bufferedBytes(t, b, 0)
+}
+
+func TestQueueBuffer_Close(t *testing.T) {
+	w := &fakeQueueWriter{}
+	b := newQueueBuffer(w.write, QueueBufferOptions{MaxBytes: 1000, BatchSize: 100, FlushInterval: time.Hour}, monitoring.InertMetricFactory{})
+
+	done := make(chan error)
+	for _, id := range []int64{1, 2} {
+		tree := &trillian.Tree{TreeId: id}
+		go func() {
+			_, err := b.add(context.Background(), tree, testLeaves("a"))
+			done <- err
+		}()
+	}
+	bufferedBytes(t, b, 2)
+
+	b.close()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("add() returned err = %v", err)
+		}
+	}
+	if got := w.batchSizes(); len(got) != 2 {
+		t.Errorf("written batch sizes = %v, want two batches", got)
+	}
+
+	_, err := b.add(context.Background(), &trillian.Tree{TreeId: 1}, testLeaves("a"))
+	if got, want := status.Code(err), codes.Unavailable; got != want {
+		t.Errorf("add() after close returned err = %v, want code %v", err, want)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+)
+
+// RevisionPruner periodically deletes the leaves and subtrees of maps that are only needed to
+// read revisions older than the map's MaxRevisionLookback allows. Those revisions already
+// fail with OUT_OF_RANGE, and the latest revision is never affected. Signed map roots are
+// kept; they're pruned by RootPruner according to the map's RootRetention.
+type RevisionPruner struct {
+	registry extension.Registry
+	interval time.Duration
+	pruned   monitoring.Counter
+}
+
+// NewRevisionPruner creates a RevisionPruner that prunes every interval.
+func NewRevisionPruner(registry extension.Registry, interval time.Duration) *RevisionPruner {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &RevisionPruner{
+		registry: registry,
+		interval: interval,
+		pruned:   mf.NewCounter("pruned_map_revision_entries", "Number of map leaf and subtree versions deleted by revision lookbacks"),
+	}
+}
+
+// Run prunes map revisions until ctx is done.
+func (p *RevisionPruner) Run(ctx context.Context) {
+	runPeriodically(ctx, p.interval, "prune map revisions", p.Prune)
+}
+
+// Prune prunes the revisions of every map with a MaxRevisionLookback once.
+func (p *RevisionPruner) Prune(ctx context.Context) error {
+	if p.registry.MapStorage == nil {
+		return nil
+	}
+	trees, err := listTrees(ctx, p.registry.AdminStorage)
+	if err != nil {
+		return err
+	}
+	for _, tree := range trees {
+		if tree.TreeType != trillian.TreeType_MAP || tree.MaxRevisionLookback <= 0 {
+			continue
+		}
+		if tree.TreeState == trillian.TreeState_SOFT_DELETED || tree.TreeState == trillian.TreeState_HARD_DELETED {
+			continue
+		}
+		n, err := p.pruneTree(ctx, tree)
+		if err != nil {
+			glog.Warningf("%v: failed to prune map revisions: %v", tree.TreeId, err)
+			continue
+		}
+		if n > 0 {
+			glog.V(1).Infof("%v: pruned %v leaf and subtree versions", tree.TreeId, n)
+			p.pruned.Add(float64(n))
+		}
+	}
+	return nil
+}
+
+func (p *RevisionPruner) pruneTree(ctx context.Context, tree *trillian.Tree) (int64, error) {
+	tx, err := p.registry.MapStorage.BeginForTree(ctx, tree.TreeId)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedMapRoot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// The oldest revision that can still be read, see checkRevisionLookback.
+	minRevision := root.MapRevision - tree.MaxRevisionLookback
+	if minRevision <= 0 {
+		return 0, tx.Commit()
+	}
+	n, err := tx.PruneRevisions(ctx, minRevision)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+)
+
+func TestRevisionPruner_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trees := []*trillian.Tree{
+		{TreeId: 1, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE, MaxRevisionLookback: 10},
+		{TreeId: 2, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE}, // No lookback.
+		{TreeId: 3, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_FROZEN, MaxRevisionLookback: 100},
+		{TreeId: 4, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_SOFT_DELETED, MaxRevisionLookback: 10},
+		{TreeId: 5, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_ACTIVE, MaxRevisionLookback: 10},
+		{TreeId: 6, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE},
+	}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+	adminTX.EXPECT().ListTrees(gomock.Any()).Return(trees, nil)
+	adminTX.EXPECT().Commit().Return(nil)
+	adminTX.EXPECT().Close().Return(nil)
+
+	ms := storage.NewMockMapStorage(ctrl)
+	tx1 := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(1)).Return(tx1, nil)
+	tx1.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(trillian.SignedMapRoot{MapRevision: 25}, nil)
+	// Revisions 15 to 25 remain readable.
+	tx1.EXPECT().PruneRevisions(gomock.Any(), int64(15)).Return(int64(7), nil)
+	tx1.EXPECT().Commit().Return(nil)
+	tx1.EXPECT().Close().Return(nil)
+
+	// The map has fewer revisions than its lookback, so nothing is pruned.
+	tx3 := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(3)).Return(tx3, nil)
+	tx3.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(trillian.SignedMapRoot{MapRevision: 25}, nil)
+	tx3.EXPECT().Commit().Return(nil)
+	tx3.EXPECT().Close().Return(nil)
+
+	// Failing to prune one tree mustn't stop the others from being pruned.
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(5)).Return(nil, errors.New("begin failed"))
+
+	registry := extension.Registry{
+		AdminStorage:  as,
+		MapStorage:    ms,
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	p := NewRevisionPruner(registry, time.Hour)
+	if err := p.Prune(context.Background()); err != nil {
+		t.Fatalf("Prune() returned err = %v", err)
+	}
+	if got, want := p.pruned.Value(), 7.0; got != want {
+		t.Errorf("pruned map revision entries = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// RootAgeMonitor periodically samples the age of the latest signed root of each active log
+// and exports it as a gauge. Unlike the MaxRootDuration enforced by the signer this keeps
+// working when the signer is down, so it can be used to alert on logs going stale.
+type RootAgeMonitor struct {
+	logStorage storage.LogStorage
+	timeSource util.TimeSource
+	interval   time.Duration
+	rootAge    monitoring.Gauge
+}
+
+// NewRootAgeMonitor creates a RootAgeMonitor that samples every interval. Root ages are
+// exported in seconds, in the "latest_signed_root_age_seconds" gauge labelled by log ID.
+func NewRootAgeMonitor(logStorage storage.LogStorage, timeSource util.TimeSource, interval time.Duration, mf monitoring.MetricFactory) *RootAgeMonitor {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &RootAgeMonitor{
+		logStorage: logStorage,
+		timeSource: timeSource,
+		interval:   interval,
+		rootAge:    mf.NewGauge("latest_signed_root_age_seconds", "Age of the latest signed root of a log in seconds", logIDLabel),
+	}
+}
+
+// Run samples root ages until ctx is done.
+func (m *RootAgeMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Sample(ctx); err != nil {
+			glog.Warningf("Failed to sample signed root ages: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample updates the root age of every active log once. Logs that don't have a signed root
+// yet are skipped, as are logs whose root can't be read.
+func (m *RootAgeMonitor) Sample(ctx context.Context) error {
+	logIDs, err := m.activeLogIDs(ctx)
+	if err != nil {
+		return err
+	}
+	now := m.timeSource.Now()
+	for _, logID := range logIDs {
+		timestamp, err := m.latestRootTimestamp(ctx, logID)
+		if err != nil {
+			glog.Warningf("%v: failed to read latest signed root: %v", logID, err)
+			continue
+		}
+		if timestamp == 0 {
+			continue
+		}
+		age := now.Sub(time.Unix(0, timestamp))
+		m.rootAge.Set(age.Seconds(), strconv.FormatInt(logID, 10))
+	}
+	return nil
+}
+
+func (m *RootAgeMonitor) activeLogIDs(ctx context.Context) ([]int64, error) {
+	tx, err := m.logStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx for retrieving logIDs: %v", err)
+	}
+	defer tx.Close()
+
+	logIDs, err := tx.GetActiveLogIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active logIDs: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit getting logs: %v", err)
+	}
+	return logIDs, nil
+}
+
+func (m *RootAgeMonitor) latestRootTimestamp(ctx context.Context, logID int64) (int64, error) {
+	tx, err := m.logStorage.SnapshotForTree(ctx, logID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return root.TimestampNanos, nil
+}
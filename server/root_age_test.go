@@ -0,0 +1,91 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+func TestRootAgeMonitor_Sample(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1500000000, 0)
+	roots := map[int64]trillian.SignedLogRoot{
+		1: {LogId: 1, TimestampNanos: now.Add(-90 * time.Second).UnixNano()},
+		2: {LogId: 2, TimestampNanos: now.Add(-time.Hour).UnixNano()},
+		3: {LogId: 3}, // No signed root yet.
+	}
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).Return(mockTx, nil)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).Return([]int64{1, 2, 3, 4}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+	for logID, root := range roots {
+		treeTx := storage.NewMockReadOnlyLogTreeTX(ctrl)
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID).Return(treeTx, nil)
+		treeTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(root, nil)
+		treeTx.EXPECT().Commit().Return(nil)
+		treeTx.EXPECT().Close().Return(nil)
+	}
+	// Failing to read one log mustn't stop the others from being sampled.
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), int64(4)).Return(nil, errors.New("snapshot failed"))
+
+	m := NewRootAgeMonitor(mockStorage, util.NewFakeTimeSource(now), time.Minute, monitoring.InertMetricFactory{})
+	if err := m.Sample(context.Background()); err != nil {
+		t.Fatalf("Sample() returned err = %v", err)
+	}
+
+	for _, test := range []struct {
+		logID string
+		want  float64
+	}{
+		{logID: "1", want: 90},
+		{logID: "2", want: 3600},
+		{logID: "3", want: 0},
+		{logID: "4", want: 0},
+	} {
+		if got := m.rootAge.Value(test.logID); got != test.want {
+			t.Errorf("root age of log %v = %v, want %v", test.logID, got, test.want)
+		}
+	}
+}
+
+func TestRootAgeMonitor_SampleGetLogsFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockReadOnlyLogTX(ctrl)
+	mockStorage.EXPECT().Snapshot(gomock.Any()).Return(mockTx, nil)
+	mockTx.EXPECT().GetActiveLogIDs(gomock.Any()).Return(nil, errors.New("getactivelogs"))
+	mockTx.EXPECT().Close().Return(nil)
+
+	m := NewRootAgeMonitor(mockStorage, util.SystemTimeSource{}, time.Minute, monitoring.InertMetricFactory{})
+	if err := m.Sample(context.Background()); err == nil {
+		t.Error("Sample() returned err = nil, want non-nil")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/util"
+)
+
+// RootPruner periodically deletes the historical signed roots of trees that have a
+// RootRetention policy. Only signed roots are deleted, leaves and subtrees are kept, so
+// proofs can still be built at the sizes of pruned log roots.
+// Trees are pruned through the registry's LogStorage and MapStorage; trees whose storage
+// isn't set in the registry are skipped.
+type RootPruner struct {
+	registry   extension.Registry
+	timeSource util.TimeSource
+	interval   time.Duration
+	pruned     monitoring.Counter
+}
+
+// NewRootPruner creates a RootPruner that prunes every interval.
+func NewRootPruner(registry extension.Registry, timeSource util.TimeSource, interval time.Duration) *RootPruner {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &RootPruner{
+		registry:   registry,
+		timeSource: timeSource,
+		interval:   interval,
+		pruned:     mf.NewCounter("pruned_signed_roots", "Number of historical signed roots deleted by retention policies"),
+	}
+}
+
+// Run prunes signed roots until ctx is done.
+func (p *RootPruner) Run(ctx context.Context) {
+	runPeriodically(ctx, p.interval, "prune signed roots", p.Prune)
+}
+
+// Prune applies the RootRetention policy of every tree once.
+func (p *RootPruner) Prune(ctx context.Context) error {
+	trees, err := listTrees(ctx, p.registry.AdminStorage)
+	if err != nil {
+		return err
+	}
+	now := p.timeSource.Now()
+	for _, tree := range trees {
+		if tree.TreeState == trillian.TreeState_SOFT_DELETED || tree.TreeState == trillian.TreeState_HARD_DELETED {
+			continue
+		}
+		keepCount, keepAfter, ok, err := retentionLimits(tree.RootRetention, now)
+		if err != nil {
+			glog.Warningf("%v: invalid root retention policy: %v", tree.TreeId, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		n, err := p.pruneTree(ctx, tree, keepCount, keepAfter)
+		if err != nil {
+			glog.Warningf("%v: failed to prune signed roots: %v", tree.TreeId, err)
+			continue
+		}
+		if n > 0 {
+			glog.V(1).Infof("%v: pruned %v signed roots", tree.TreeId, n)
+			p.pruned.Add(float64(n))
+		}
+	}
+	return nil
+}
+
+// retentionLimits converts rr into the arguments of the storage prune methods. ok is false
+// if rr doesn't limit retention at all.
+func retentionLimits(rr *trillian.RootRetention, now time.Time) (keepCount, keepAfterNanos int64, ok bool, err error) {
+	if rr == nil {
+		return 0, 0, false, nil
+	}
+	var keepDuration time.Duration
+	if rr.KeepDuration != nil {
+		if keepDuration, err = ptypes.Duration(rr.KeepDuration); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if rr.KeepCount <= 0 && keepDuration <= 0 {
+		return 0, 0, false, nil
+	}
+
+	// An unset limit mustn't keep any roots by itself, as roots are kept if they're
+	// within either limit.
+	keepAfterNanos = math.MaxInt64
+	if keepDuration > 0 {
+		keepAfterNanos = now.Add(-keepDuration).UnixNano()
+	}
+	return rr.KeepCount, keepAfterNanos, true, nil
+}
+
+func (p *RootPruner) pruneTree(ctx context.Context, tree *trillian.Tree, keepCount, keepAfterNanos int64) (int64, error) {
+	switch {
+	case tree.TreeType == trillian.TreeType_LOG && p.registry.LogStorage != nil:
+		tx, err := p.registry.LogStorage.BeginForTree(ctx, tree.TreeId)
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Close()
+		n, err := tx.PruneSignedLogRoots(ctx, keepCount, keepAfterNanos)
+		if err != nil {
+			return 0, err
+		}
+		return n, tx.Commit()
+	case tree.TreeType == trillian.TreeType_MAP && p.registry.MapStorage != nil:
+		tx, err := p.registry.MapStorage.BeginForTree(ctx, tree.TreeId)
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Close()
+		n, err := tx.PruneSignedMapRoots(ctx, keepCount, keepAfterNanos)
+		if err != nil {
+			return 0, err
+		}
+		return n, tx.Commit()
+	}
+	return 0, nil
+}
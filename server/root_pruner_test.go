@@ -0,0 +1,126 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+func TestRetentionLimits(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	for _, test := range []struct {
+		desc          string
+		rr            *trillian.RootRetention
+		wantCount     int64
+		wantKeepAfter int64
+		wantOK        bool
+	}{
+		{desc: "unset"},
+		{desc: "empty", rr: &trillian.RootRetention{}},
+		{
+			desc:          "countOnly",
+			rr:            &trillian.RootRetention{KeepCount: 10},
+			wantCount:     10,
+			wantKeepAfter: math.MaxInt64,
+			wantOK:        true,
+		},
+		{
+			desc:          "durationOnly",
+			rr:            &trillian.RootRetention{KeepDuration: ptypes.DurationProto(time.Hour)},
+			wantKeepAfter: now.Add(-time.Hour).UnixNano(),
+			wantOK:        true,
+		},
+		{
+			desc:          "both",
+			rr:            &trillian.RootRetention{KeepCount: 10, KeepDuration: ptypes.DurationProto(time.Hour)},
+			wantCount:     10,
+			wantKeepAfter: now.Add(-time.Hour).UnixNano(),
+			wantOK:        true,
+		},
+	} {
+		count, keepAfter, ok, err := retentionLimits(test.rr, now)
+		if err != nil {
+			t.Errorf("%v: retentionLimits() returned err = %v", test.desc, err)
+			continue
+		}
+		if ok != test.wantOK || (ok && (count != test.wantCount || keepAfter != test.wantKeepAfter)) {
+			t.Errorf("%v: retentionLimits() = (%v, %v, %v), want (%v, %v, %v)", test.desc, count, keepAfter, ok, test.wantCount, test.wantKeepAfter, test.wantOK)
+		}
+	}
+}
+
+func TestRootPruner_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1500000000, 0)
+	retention := &trillian.RootRetention{KeepCount: 5}
+	trees := []*trillian.Tree{
+		{TreeId: 1, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE, RootRetention: retention},
+		{TreeId: 2, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_FROZEN, RootRetention: retention},
+		{TreeId: 3, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE}, // No policy.
+		{TreeId: 4, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_SOFT_DELETED, RootRetention: retention},
+		{TreeId: 5, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE, RootRetention: retention},
+	}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+	adminTX.EXPECT().ListTrees(gomock.Any()).Return(trees, nil)
+	adminTX.EXPECT().Commit().Return(nil)
+	adminTX.EXPECT().Close().Return(nil)
+
+	ls := storage.NewMockLogStorage(ctrl)
+	logTX := storage.NewMockLogTreeTX(ctrl)
+	ls.EXPECT().BeginForTree(gomock.Any(), int64(1)).Return(logTX, nil)
+	logTX.EXPECT().PruneSignedLogRoots(gomock.Any(), int64(5), int64(math.MaxInt64)).Return(int64(3), nil)
+	logTX.EXPECT().Commit().Return(nil)
+	logTX.EXPECT().Close().Return(nil)
+	// Failing to prune one tree mustn't stop the others from being pruned.
+	ls.EXPECT().BeginForTree(gomock.Any(), int64(5)).Return(nil, errors.New("begin failed"))
+
+	ms := storage.NewMockMapStorage(ctrl)
+	mapTX := storage.NewMockMapTreeTX(ctrl)
+	ms.EXPECT().BeginForTree(gomock.Any(), int64(2)).Return(mapTX, nil)
+	mapTX.EXPECT().PruneSignedMapRoots(gomock.Any(), int64(5), int64(math.MaxInt64)).Return(int64(2), nil)
+	mapTX.EXPECT().Commit().Return(nil)
+	mapTX.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage:  as,
+		LogStorage:    ls,
+		MapStorage:    ms,
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	p := NewRootPruner(registry, util.NewFakeTimeSource(now), time.Hour)
+	if err := p.Prune(context.Background()); err != nil {
+		t.Fatalf("Prune() returned err = %v", err)
+	}
+	if got, want := p.pruned.Value(), 5.0; got != want {
+		t.Errorf("pruned roots = %v, want %v", got, want)
+	}
+}
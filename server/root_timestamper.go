@@ -0,0 +1,155 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+)
+
+// rootsPerTimestampPass is the max number of roots of each log timestamped per pass, so
+// that a log with a backlog of roots doesn't hold up the others.
+const rootsPerTimestampPass = 100
+
+// Timestamper obtains timestamp tokens over digests from a trusted time source, such as an
+// RFC 3161 timestamp authority. *tsa.Client is a Timestamper.
+type Timestamper interface {
+	// Timestamp returns a token proving that digest existed at the time it was issued.
+	Timestamp(ctx context.Context, digest []byte) ([]byte, error)
+}
+
+// RootTimestamper periodically obtains timestamp tokens for the signed roots of logs, and
+// stores them alongside the roots. Tokens are over crypto.HashLogRoot of each root, and
+// roots are timestamped in revision order, so token times increase with the tree size.
+// Timestamping runs apart from sequencing, so a failing timestamp authority never holds up
+// signing: roots that can't be timestamped are retried on the next pass.
+// Logs are timestamped from their latest root at the time they're first timestamped;
+// earlier roots never get tokens.
+type RootTimestamper struct {
+	registry    extension.Registry
+	timestamper Timestamper
+	interval    time.Duration
+	tokens      monitoring.Counter
+	failures    monitoring.Counter
+}
+
+// NewRootTimestamper creates a RootTimestamper that obtains tokens from ts every interval.
+func NewRootTimestamper(registry extension.Registry, ts Timestamper, interval time.Duration) *RootTimestamper {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &RootTimestamper{
+		registry:    registry,
+		timestamper: ts,
+		interval:    interval,
+		tokens:      mf.NewCounter("root_timestamp_tokens", "Number of signed log roots timestamped by the timestamp authority"),
+		failures:    mf.NewCounter("root_timestamp_failures", "Number of failed attempts to timestamp a signed log root"),
+	}
+}
+
+// Run timestamps signed roots until ctx is done.
+func (r *RootTimestamper) Run(ctx context.Context) {
+	runPeriodically(ctx, r.interval, "timestamp signed roots", r.Timestamp)
+}
+
+// Timestamp obtains tokens for the roots of every log that don't have one yet.
+func (r *RootTimestamper) Timestamp(ctx context.Context) error {
+	trees, err := listTrees(ctx, r.registry.AdminStorage)
+	if err != nil {
+		return err
+	}
+	for _, tree := range trees {
+		if tree.TreeType != trillian.TreeType_LOG || tree.TreeState == trillian.TreeState_SOFT_DELETED || tree.TreeState == trillian.TreeState_HARD_DELETED {
+			continue
+		}
+		n, err := r.timestampTree(ctx, tree.TreeId)
+		if n > 0 {
+			glog.V(1).Infof("%v: timestamped %v signed roots", tree.TreeId, n)
+		}
+		if err != nil {
+			glog.Warningf("%v: failed to timestamp signed roots: %v", tree.TreeId, err)
+		}
+	}
+	return nil
+}
+
+// timestampTree timestamps the untimestamped roots of a log in revision order, stopping at
+// the first failure so that later roots are never timestamped before earlier ones.
+func (r *RootTimestamper) timestampTree(ctx context.Context, logID int64) (int, error) {
+	roots, err := r.untimestampedRoots(ctx, logID)
+	if err != nil {
+		return 0, err
+	}
+	for i, root := range roots {
+		token, err := r.timestamper.Timestamp(ctx, crypto.HashLogRoot(root))
+		if err != nil {
+			r.failures.Inc()
+			return i, fmt.Errorf("timestamp authority failed for revision %v: %v", root.TreeRevision, err)
+		}
+		if err := r.storeToken(ctx, logID, root.TreeRevision, token); err != nil {
+			return i, fmt.Errorf("failed to store token for revision %v: %v", root.TreeRevision, err)
+		}
+		r.tokens.Inc()
+	}
+	return len(roots), nil
+}
+
+// untimestampedRoots returns the roots of a log after the latest one with a token, or the
+// latest root if none has a token.
+func (r *RootTimestamper) untimestampedRoots(ctx context.Context, logID int64) ([]trillian.SignedLogRoot, error) {
+	tx, err := r.registry.LogStorage.SnapshotForTree(ctx, logID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	rev, err := tx.LatestTimestampedRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var roots []trillian.SignedLogRoot
+	if rev < 0 {
+		root, err := tx.LatestSignedLogRoot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if root.TimestampNanos != 0 {
+			roots = append(roots, root)
+		}
+	} else if roots, err = tx.SignedLogRoots(ctx, rev, rootsPerTimestampPass); err != nil {
+		return nil, err
+	}
+	return roots, tx.Commit()
+}
+
+func (r *RootTimestamper) storeToken(ctx context.Context, logID, treeRevision int64, token []byte) error {
+	tx, err := r.registry.LogStorage.BeginForTree(ctx, logID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if err := tx.StoreTimestampToken(ctx, treeRevision, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
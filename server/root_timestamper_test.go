@@ -0,0 +1,139 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	stestonly "github.com/google/trillian/storage/testonly"
+)
+
+// fakeTimestamper issues tokens that name the digest they cover, or fails if err is set.
+type fakeTimestamper struct {
+	err   error
+	calls int
+}
+
+func (f *fakeTimestamper) Timestamp(ctx context.Context, digest []byte) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(fmt.Sprintf("token-%x", digest)), nil
+}
+
+func storeTestRoots(ctx context.Context, t *testing.T, ls storage.LogStorage, logID int64, revisions ...int64) []trillian.SignedLogRoot {
+	tx, err := ls.BeginForTree(ctx, logID)
+	if err != nil {
+		t.Fatalf("BeginForTree() returned err = %v", err)
+	}
+	defer tx.Close()
+	var roots []trillian.SignedLogRoot
+	for _, rev := range revisions {
+		root := trillian.SignedLogRoot{LogId: logID, TimestampNanos: 1000 + rev, TreeRevision: rev, TreeSize: rev, RootHash: []byte{byte(rev)}}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("StoreSignedLogRoot() returned err = %v", err)
+		}
+		roots = append(roots, root)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+	return roots
+}
+
+func getTestToken(ctx context.Context, t *testing.T, ls storage.LogStorage, logID, rev int64) []byte {
+	tx, err := ls.SnapshotForTree(ctx, logID)
+	if err != nil {
+		t.Fatalf("SnapshotForTree() returned err = %v", err)
+	}
+	defer tx.Close()
+	token, err := tx.GetTimestampToken(ctx, rev)
+	if err != nil {
+		t.Fatalf("GetTimestampToken() returned err = %v", err)
+	}
+	return token
+}
+
+func TestRootTimestamper_Timestamp(t *testing.T) {
+	ctx := context.Background()
+	ls := memory.NewLogStorage(nil)
+	as := memory.NewAdminStorage(ls)
+	tx, err := as.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() returned err = %v", err)
+	}
+	tree, err := tx.CreateTree(ctx, stestonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+	logID := tree.TreeId
+
+	ts := &fakeTimestamper{}
+	r := NewRootTimestamper(extension.Registry{AdminStorage: as, LogStorage: ls}, ts, 0)
+
+	// A log without roots has nothing to timestamp.
+	if err := r.Timestamp(ctx); err != nil {
+		t.Fatalf("Timestamp() returned err = %v", err)
+	}
+	if ts.calls != 0 {
+		t.Errorf("Timestamp() of a log without roots made %v calls, want 0", ts.calls)
+	}
+
+	// Only the latest root is timestamped at first.
+	roots := storeTestRoots(ctx, t, ls, logID, 1, 2)
+	if err := r.Timestamp(ctx); err != nil {
+		t.Fatalf("Timestamp() returned err = %v", err)
+	}
+	if got := getTestToken(ctx, t, ls, logID, 1); got != nil {
+		t.Errorf("GetTimestampToken(1) = %q, want nil", got)
+	}
+	want := []byte(fmt.Sprintf("token-%x", crypto.HashLogRoot(roots[1])))
+	if got := getTestToken(ctx, t, ls, logID, 2); !bytes.Equal(got, want) {
+		t.Errorf("GetTimestampToken(2) = %q, want %q", got, want)
+	}
+
+	// Failures leave the roots to be timestamped by later passes.
+	roots = storeTestRoots(ctx, t, ls, logID, 3, 4)
+	ts.err = errors.New("authority unavailable")
+	if err := r.Timestamp(ctx); err != nil {
+		t.Fatalf("Timestamp() returned err = %v", err)
+	}
+	if got := getTestToken(ctx, t, ls, logID, 3); got != nil {
+		t.Errorf("GetTimestampToken(3) after failure = %q, want nil", got)
+	}
+	ts.err = nil
+	if err := r.Timestamp(ctx); err != nil {
+		t.Fatalf("Timestamp() returned err = %v", err)
+	}
+	for _, root := range roots {
+		want := []byte(fmt.Sprintf("token-%x", crypto.HashLogRoot(root)))
+		if got := getTestToken(ctx, t, ls, logID, root.TreeRevision); !bytes.Equal(got, want) {
+			t.Errorf("GetTimestampToken(%v) = %q, want %q", root.TreeRevision, got, want)
+		}
+	}
+}
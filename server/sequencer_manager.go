@@ -17,16 +17,17 @@ package server
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/log"
-	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/trees"
 )
 
@@ -34,17 +35,63 @@ import (
 type SequencerManager struct {
 	guardWindow  time.Duration
 	registry     extension.Registry
-	signers      map[int64]*crypto.Signer
+	signers      map[int64]cachedSigner
 	signersMutex sync.Mutex
+	// secondarySigners caches the secondary signers of logs, guarded by signersMutex.
+	secondarySigners map[int64]cachedSigner
+	// rotationSigners caches the signers of logs' key rotations, guarded by signersMutex.
+	rotationSigners map[int64]cachedSigner
+	// backlogs tracks how far behind each log is, guarded by backlogsMutex.
+	backlogs      map[int64]backlog
+	backlogsMutex sync.Mutex
+	// leafFailures counts the failed passes of leaves in logs with a DeadLetterPolicy.
+	leafFailures *log.LeafFailures
+	// unsignable tracks the logs whose roots recently failed to be signed, guarded by
+	// unsignableMutex.
+	unsignable      map[int64]unsignable
+	unsignableMutex sync.Mutex
+}
+
+// unsignable tracks the consecutive passes over a log that failed to sign a root.
+type unsignable struct {
+	// failures is the number of consecutive failed passes.
+	failures int
+	// retryAt is when the next pass may retry the log, if it's unsignable.
+	retryAt time.Time
+}
+
+// backlog tracks the recent sequencing passes of a log.
+type backlog struct {
+	// fullPasses is the number of consecutive passes that filled their batch.
+	fullPasses int
+	// batchSize is the batch size to use for the next pass, zero means the
+	// configured BatchSize.
+	batchSize int
+}
+
+// cachedSigner is a cached signer, together with the config it was created from. Any of the
+// keys of a tree may change, e.g. when its key rotation is retired, so the cache is only valid
+// while the config is unchanged.
+type cachedSigner struct {
+	config proto.Message
+	signer *crypto.Signer
 }
 
 // NewSequencerManager creates a new SequencerManager instance based on the provided KeyManager instance
 // and guard window.
 func NewSequencerManager(registry extension.Registry, gw time.Duration) *SequencerManager {
+	once.Do(func() {
+		createMetrics(registry.MetricFactory)
+	})
 	return &SequencerManager{
-		guardWindow: gw,
-		registry:    registry,
-		signers:     make(map[int64]*crypto.Signer),
+		guardWindow:      gw,
+		registry:         registry,
+		signers:          make(map[int64]cachedSigner),
+		secondarySigners: make(map[int64]cachedSigner),
+		rotationSigners:  make(map[int64]cachedSigner),
+		backlogs:         make(map[int64]backlog),
+		leafFailures:     log.NewLeafFailures(),
+		unsignable:       make(map[int64]unsignable),
 	}
 }
 
@@ -58,6 +105,11 @@ func (s *SequencerManager) ExecutePass(ctx context.Context, logID int64, info *L
 	// TODO(Martin2112): Honor the sequencing enabled in log parameters, needs an API change
 	// so deferring it
 
+	if s.backingOff(logID, info) {
+		glog.V(1).Infof("%v: skipping unsignable log until its backoff expires", logID)
+		return 0, nil
+	}
+
 	tree, err := trees.GetTree(
 		ctx,
 		s.registry.AdminStorage,
@@ -67,39 +119,190 @@ func (s *SequencerManager) ExecutePass(ctx context.Context, logID int64, info *L
 		return 0, fmt.Errorf("error retrieving log %v: %v", logID, err)
 	}
 	ctx = trees.NewContext(ctx, tree)
-
-	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
-	if err != nil {
-		return 0, fmt.Errorf("error getting hasher for log %v: %v", logID, err)
+	if tree.CheckpointOrigin != "" {
+		logOrigin.Set(1, strconv.FormatInt(logID, 10), tree.CheckpointOrigin)
 	}
 
 	signer, err := s.getSigner(ctx, tree)
 	if err != nil {
+		// The key may be temporarily unavailable, e.g. during a KMS outage.
+		s.signingFailed(logID, info)
 		return 0, fmt.Errorf("error getting signer for log %v: %v", logID, err)
 	}
 
-	sequencer := log.NewSequencer(hasher, info.TimeSource, s.registry.LogStorage, signer, s.registry.MetricFactory, s.registry.QuotaManager)
+	secondary, err := s.getSecondarySigner(ctx, tree)
+	if err != nil {
+		return 0, fmt.Errorf("error getting secondary signer for log %v: %v", logID, err)
+	}
+
+	rotation, err := s.getRotationSigner(ctx, tree)
+	if err != nil {
+		s.signingFailed(logID, info)
+		return 0, fmt.Errorf("error getting key rotation signer for log %v: %v", logID, err)
+	}
+
+	signers := log.TreeSigners{Signer: signer, Secondary: secondary, Rotation: rotation}
+	sequencer, err := log.NewTreeSequencer(tree, signers, info.TimeSource, info.RootTimeSource, s.registry.LogStorage, s.registry.MetricFactory, s.registry.QuotaManager)
+	if err != nil {
+		return 0, fmt.Errorf("error creating sequencer for log %v: %v", logID, err)
+	}
+	sequencer.SetHashWorkers(info.HashWorkers)
+	sequencer.SetConsistencyCheck(info.CheckConsistency)
+	sequencer.SetForceRoot(info.ForceRoot)
+	if p := tree.DeadLetterPolicy; p != nil {
+		sequencer.SetDeadLettering(int(p.MaxAttempts), s.leafFailures)
+	}
 
 	maxRootDuration, err := ptypes.Duration(tree.MaxRootDuration)
 	if err != nil {
 		glog.Warning("failed to parse tree.MaxRootDuration, using zero")
 		maxRootDuration = 0
 	}
-	leaves, err := sequencer.SequenceBatch(ctx, logID, info.BatchSize, s.guardWindow, maxRootDuration)
+	limit := s.batchSize(logID, info)
+	leaves, err := sequencer.SequenceBatch(ctx, logID, limit, s.guardWindow, maxRootDuration)
+	if _, ok := err.(log.SigningError); ok {
+		s.signingFailed(logID, info)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to sequence batch for %v: %v", logID, err)
 	}
+	s.signingSucceeded(logID)
+	s.recordPass(logID, info, limit, leaves)
 	return leaves, nil
 }
 
+// backingOff returns true if logID is unsignable and its backoff hasn't expired yet.
+func (s *SequencerManager) backingOff(logID int64, info *LogOperationInfo) bool {
+	s.unsignableMutex.Lock()
+	defer s.unsignableMutex.Unlock()
+	u, ok := s.unsignable[logID]
+	return ok && info.TimeSource.Now().Before(u.retryAt)
+}
+
+// signingFailed records a pass over logID that failed to sign a root. After
+// UnsignableThreshold consecutive failures the log is unsignable, and passes skip it until
+// its backoff expires. Its queued leaves are sequenced once a root can be signed again.
+func (s *SequencerManager) signingFailed(logID int64, info *LogOperationInfo) {
+	s.unsignableMutex.Lock()
+	defer s.unsignableMutex.Unlock()
+	u := s.unsignable[logID]
+	u.failures++
+	if extra := u.failures - unsignableThreshold(info); extra >= 0 {
+		backoff := unsignableBackoff(info, extra)
+		if extra == 0 {
+			glog.Errorf("%v: log is unsignable after %v failed passes, retrying every %v or more", logID, u.failures, backoff)
+		}
+		u.retryAt = info.TimeSource.Now().Add(backoff)
+		unsignableLogs.Set(1, strconv.FormatInt(logID, 10))
+	}
+	s.unsignable[logID] = u
+}
+
+// signingSucceeded records a pass over logID that didn't fail to sign a root, resuming the
+// log if it was unsignable.
+func (s *SequencerManager) signingSucceeded(logID int64) {
+	s.unsignableMutex.Lock()
+	defer s.unsignableMutex.Unlock()
+	if u, ok := s.unsignable[logID]; ok {
+		if !u.retryAt.IsZero() {
+			glog.Infof("%v: log is signable again after %v failed passes", logID, u.failures)
+		}
+		delete(s.unsignable, logID)
+	}
+	unsignableLogs.Set(0, strconv.FormatInt(logID, 10))
+}
+
+func unsignableThreshold(info *LogOperationInfo) int {
+	if info.UnsignableThreshold < 1 {
+		return 1
+	}
+	return info.UnsignableThreshold
+}
+
+// unsignableBackoff returns how long an unsignable log is skipped for after the given
+// number of failed retries: UnsignableBackoff, doubled on each retry up to
+// MaxUnsignableBackoff.
+func unsignableBackoff(info *LogOperationInfo, retries int) time.Duration {
+	backoff := info.UnsignableBackoff
+	for i := 0; i < retries && backoff < info.MaxUnsignableBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > info.MaxUnsignableBackoff && info.MaxUnsignableBackoff > info.UnsignableBackoff {
+		backoff = info.MaxUnsignableBackoff
+	}
+	return backoff
+}
+
+// batchSize returns the batch size to use for the next pass over logID, capped at
+// FairBatchCap if it's set.
+func (s *SequencerManager) batchSize(logID int64, info *LogOperationInfo) int {
+	s.backlogsMutex.Lock()
+	defer s.backlogsMutex.Unlock()
+	limit := info.BatchSize
+	if b := s.backlogs[logID]; b.batchSize > 0 {
+		limit = b.batchSize
+	}
+	if info.FairBatchCap > 0 && limit > info.FairBatchCap {
+		limit = info.FairBatchCap
+	}
+	return limit
+}
+
+// recordPass updates the backlog of logID after a pass that sequenced count
+// leaves out of a batch of limit.
+func (s *SequencerManager) recordPass(logID int64, info *LogOperationInfo, limit, count int) {
+	s.backlogsMutex.Lock()
+	defer s.backlogsMutex.Unlock()
+	b := nextBacklog(s.backlogs[logID], info, limit, count)
+	s.backlogs[logID] = b
+
+	label := strconv.FormatInt(logID, 10)
+	if b.fullPasses >= behindThreshold(info) {
+		behind.Set(1, label)
+	} else {
+		behind.Set(0, label)
+	}
+	batchSize.Set(float64(limit), label)
+}
+
+func behindThreshold(info *LogOperationInfo) int {
+	if info.BehindThreshold < 1 {
+		return 1
+	}
+	return info.BehindThreshold
+}
+
+// nextBacklog returns the backlog of a log after a pass that sequenced count
+// leaves out of a batch of limit. A log that fills its batch for
+// BehindThreshold consecutive passes is behind, and when AdaptiveBatch is set
+// its batch size doubles on each further full pass, up to MaxBatchSize. The
+// batch size is reset as soon as a pass doesn't fill its batch.
+func nextBacklog(b backlog, info *LogOperationInfo, limit, count int) backlog {
+	if count < limit {
+		return backlog{}
+	}
+	b.fullPasses++
+	if !info.AdaptiveBatch || b.fullPasses < behindThreshold(info) {
+		return b
+	}
+	next := limit * 2
+	if next > info.MaxBatchSize {
+		next = info.MaxBatchSize
+	}
+	if next > limit {
+		b.batchSize = next
+	}
+	return b
+}
+
 // getSigner returns a signer for the given tree.
-// Signers are cached, so only one will be created per tree.
+// Signers are cached until the tree's private key changes.
 func (s *SequencerManager) getSigner(ctx context.Context, tree *trillian.Tree) (*crypto.Signer, error) {
 	s.signersMutex.Lock()
 	defer s.signersMutex.Unlock()
 
-	if signer, ok := s.signers[tree.GetTreeId()]; ok {
-		return signer, nil
+	if cached, ok := s.signers[tree.TreeId]; ok && proto.Equal(cached.config, tree.PrivateKey) {
+		return cached.signer, nil
 	}
 
 	signer, err := trees.Signer(ctx, s.registry.SignerFactory, tree)
@@ -107,6 +310,44 @@ func (s *SequencerManager) getSigner(ctx context.Context, tree *trillian.Tree) (
 		return nil, err
 	}
 
-	s.signers[tree.GetTreeId()] = signer
+	s.signers[tree.TreeId] = cachedSigner{config: tree.PrivateKey, signer: signer}
+	return signer, nil
+}
+
+// getSecondarySigner returns the secondary signer of the given tree, or nil if it has none.
+// Signers are cached until the tree's secondary signer changes.
+func (s *SequencerManager) getSecondarySigner(ctx context.Context, tree *trillian.Tree) (*crypto.Signer, error) {
+	s.signersMutex.Lock()
+	defer s.signersMutex.Unlock()
+
+	if cached, ok := s.secondarySigners[tree.TreeId]; ok && proto.Equal(cached.config, tree.SecondarySigner) {
+		return cached.signer, nil
+	}
+
+	signer, err := trees.SecondarySigner(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	s.secondarySigners[tree.TreeId] = cachedSigner{config: tree.SecondarySigner, signer: signer}
+	return signer, nil
+}
+
+// getRotationSigner returns the signer of the given tree's key rotation, or nil if it has none.
+// Signers are cached until the tree's key rotation changes.
+func (s *SequencerManager) getRotationSigner(ctx context.Context, tree *trillian.Tree) (*crypto.Signer, error) {
+	s.signersMutex.Lock()
+	defer s.signersMutex.Unlock()
+
+	if cached, ok := s.rotationSigners[tree.TreeId]; ok && proto.Equal(cached.config, tree.KeyRotation) {
+		return cached.signer, nil
+	}
+
+	signer, err := trees.RotationSigner(ctx, s.registry.SignerFactory, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	s.rotationSigners[tree.TreeId] = cachedSigner{config: tree.KeyRotation, signer: signer}
 	return signer, nil
 }
@@ -324,3 +324,144 @@ func createTestInfo(registry extension.Registry) *LogOperationInfo {
 		TimeSource:  fakeTimeSource,
 	}
 }
+
+func TestNextBacklog(t *testing.T) {
+	capped := &LogOperationInfo{BatchSize: 50, BehindThreshold: 2}
+	adaptive := &LogOperationInfo{BatchSize: 50, BehindThreshold: 2, AdaptiveBatch: true, MaxBatchSize: 150}
+	tests := []struct {
+		desc         string
+		info         *LogOperationInfo
+		b            backlog
+		limit, count int
+		want         backlog
+	}{
+		{desc: "partial", info: adaptive, b: backlog{fullPasses: 5, batchSize: 100}, limit: 100, count: 99, want: backlog{}},
+		{desc: "firstFull", info: adaptive, limit: 50, count: 50, want: backlog{fullPasses: 1}},
+		{desc: "cappedBehind", info: capped, b: backlog{fullPasses: 1}, limit: 50, count: 50, want: backlog{fullPasses: 2}},
+		{desc: "adaptiveBehind", info: adaptive, b: backlog{fullPasses: 1}, limit: 50, count: 50, want: backlog{fullPasses: 2, batchSize: 100}},
+		{desc: "adaptiveMax", info: adaptive, b: backlog{fullPasses: 2, batchSize: 100}, limit: 100, count: 100, want: backlog{fullPasses: 3, batchSize: 150}},
+		{desc: "adaptiveAtMax", info: adaptive, b: backlog{fullPasses: 3, batchSize: 150}, limit: 150, count: 150, want: backlog{fullPasses: 4, batchSize: 150}},
+		{desc: "noMax", info: &LogOperationInfo{BatchSize: 50, AdaptiveBatch: true}, limit: 50, count: 50, want: backlog{fullPasses: 1}},
+	}
+	for _, test := range tests {
+		if got := nextBacklog(test.b, test.info, test.limit, test.count); got != test.want {
+			t.Errorf("%v: nextBacklog() = %+v, want %+v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestSequencerManagerBatchSize(t *testing.T) {
+	logID := int64(1)
+	for _, test := range []struct {
+		desc string
+		info *LogOperationInfo
+		b    backlog
+		want int
+	}{
+		{desc: "default", info: &LogOperationInfo{BatchSize: 50}, want: 50},
+		{desc: "adaptive", info: &LogOperationInfo{BatchSize: 50}, b: backlog{batchSize: 200}, want: 200},
+		{desc: "capped", info: &LogOperationInfo{BatchSize: 50, FairBatchCap: 20}, want: 20},
+		{desc: "adaptiveCapped", info: &LogOperationInfo{BatchSize: 50, FairBatchCap: 100}, b: backlog{batchSize: 200}, want: 100},
+		{desc: "belowCap", info: &LogOperationInfo{BatchSize: 50, FairBatchCap: 100}, want: 50},
+	} {
+		s := NewSequencerManager(extension.Registry{}, 0)
+		s.backlogs[logID] = test.b
+		if got := s.batchSize(logID, test.info); got != test.want {
+			t.Errorf("%v: batchSize() = %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestUnsignableBackoff(t *testing.T) {
+	info := &LogOperationInfo{UnsignableBackoff: time.Minute, MaxUnsignableBackoff: 5 * time.Minute}
+	for _, test := range []struct {
+		desc    string
+		info    *LogOperationInfo
+		retries int
+		want    time.Duration
+	}{
+		{desc: "first", info: info, want: time.Minute},
+		{desc: "doubled", info: info, retries: 2, want: 4 * time.Minute},
+		{desc: "capped", info: info, retries: 3, want: 5 * time.Minute},
+		{desc: "noMax", info: &LogOperationInfo{UnsignableBackoff: time.Minute}, retries: 3, want: time.Minute},
+		{desc: "noBackoff", info: &LogOperationInfo{MaxUnsignableBackoff: time.Minute}, retries: 3},
+	} {
+		if got := unsignableBackoff(test.info, test.retries); got != test.want {
+			t.Errorf("%v: unsignableBackoff(%v) = %v, want %v", test.desc, test.retries, got, test.want)
+		}
+	}
+}
+
+// Test that a log whose key is unavailable is backed off after UnsignableThreshold failed
+// passes, and resumed once it can be signed again.
+func TestSequencerManagerUnsignableLog(t *testing.T) {
+	ctx := context.Background()
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	logID := stestonly.LogTree.GetTreeId()
+	mockAdmin := storage.NewMockAdminStorage(mockCtrl)
+	mockAdminTx := storage.NewMockReadOnlyAdminTX(mockCtrl)
+	mockStorage := storage.NewMockLogStorage(mockCtrl)
+	mockTx := storage.NewMockLogTreeTX(mockCtrl)
+	mockSf := keys.NewMockSignerFactory(mockCtrl)
+
+	mockAdmin.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(mockAdminTx, nil)
+	mockAdminTx.EXPECT().GetTree(gomock.Any(), logID).AnyTimes().Return(stestonly.LogTree, nil)
+	mockAdminTx.EXPECT().Commit().AnyTimes().Return(nil)
+	mockAdminTx.EXPECT().Close().AnyTimes().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage:  mockAdmin,
+		LogStorage:    mockStorage,
+		SignerFactory: mockSf,
+		QuotaManager:  quota.Noop(),
+	}
+	ts := util.NewFakeTimeSource(fakeTime)
+	info := createTestInfo(registry)
+	info.TimeSource = ts
+	info.UnsignableThreshold = 2
+	info.UnsignableBackoff = time.Minute
+	info.MaxUnsignableBackoff = 10 * time.Minute
+	sm := NewSequencerManager(registry, zeroDuration)
+
+	keyErr := errors.New("key unavailable")
+	for _, step := range []struct {
+		desc    string
+		elapsed time.Duration
+		// tried is whether the pass is expected to try the log, and signable whether its
+		// key is available then.
+		tried, signable bool
+	}{
+		{desc: "firstFailure", tried: true},
+		{desc: "unsignable", tried: true},
+		{desc: "backingOff", elapsed: 59 * time.Second},
+		{desc: "failedRetry", elapsed: time.Minute, tried: true},
+		{desc: "doubledBackoff", elapsed: 2*time.Minute + 59*time.Second},
+		{desc: "resumed", elapsed: 3 * time.Minute, tried: true, signable: true},
+	} {
+		ts.Set(fakeTime.Add(step.elapsed))
+		switch {
+		case step.signable:
+			mockSf.EXPECT().NewSigner(gomock.Any(), gomock.Any()).Return(newSignerWithFixedSig(updatedRoot.Signature))
+			gomock.InOrder(
+				mockStorage.EXPECT().BeginForTree(gomock.Any(), logID).Return(mockTx, nil),
+				mockTx.EXPECT().DequeueLeaves(gomock.Any(), 50, ts.Now()).Return([]*trillian.LogLeaf{}, nil),
+				mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(testRoot0, nil),
+				mockTx.EXPECT().WriteRevision().AnyTimes().Return(writeRev),
+				mockTx.EXPECT().Commit().Return(nil),
+				mockTx.EXPECT().Close().Return(nil),
+			)
+		case step.tried:
+			mockSf.EXPECT().NewSigner(gomock.Any(), gomock.Any()).Return(nil, keyErr)
+		}
+
+		_, err := sm.ExecutePass(ctx, logID, info)
+		if gotErr, wantErr := err != nil, step.tried && !step.signable; gotErr != wantErr {
+			t.Errorf("%v: ExecutePass() = %v, want err: %v", step.desc, err, wantErr)
+		}
+	}
+	if _, ok := sm.unsignable[logID]; ok {
+		t.Errorf("log still tracked as unsignable after a successful pass")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// signerProgressRefresh is how long the latest root timestamp read for a log is reused
+// before being read again, so that checking signer progress doesn't cost a storage read
+// per write.
+var signerProgressRefresh = time.Second
+
+// rootSample is the timestamp of a log's latest signed root, and when it was read.
+type rootSample struct {
+	rootTime time.Time
+	readAt   time.Time
+}
+
+// signerProgressCheck rejects writes to logs whose signer doesn't appear to be making
+// progress, i.e. whose latest signed root is older than maxRootAge.
+type signerProgressCheck struct {
+	maxRootAge time.Duration
+
+	mu    sync.Mutex
+	roots map[int64]rootSample
+}
+
+func (c *signerProgressCheck) get(logID int64, now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.roots[logID]
+	if !ok || now.Sub(s.readAt) >= signerProgressRefresh {
+		return time.Time{}, false
+	}
+	return s.rootTime, true
+}
+
+func (c *signerProgressCheck) put(logID int64, s rootSample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.roots == nil {
+		c.roots = make(map[int64]rootSample)
+	}
+	c.roots[logID] = s
+}
+
+// SetMaxRootAgeForWrites makes QueueLeaves fail with Unavailable for logs whose latest
+// signed root is older than maxAge, which usually means their signer is stalled, while
+// reads keep being served. Logs that can be idle for longer than maxAge need a
+// MaxRootDuration below it, so that their roots are re-signed regardless. Zero disables
+// the check.
+func (t *TrillianLogRPCServer) SetMaxRootAgeForWrites(maxAge time.Duration) {
+	if maxAge <= 0 {
+		t.signerProgress = nil
+		return
+	}
+	t.signerProgress = &signerProgressCheck{maxRootAge: maxAge}
+}
+
+// checkSignerProgress returns an Unavailable error if tree's latest signed root is too
+// old. Logs without a root yet are measured from their creation time instead.
+func (t *TrillianLogRPCServer) checkSignerProgress(ctx context.Context, tree *trillian.Tree) error {
+	c := t.signerProgress
+	if c == nil {
+		return nil
+	}
+	now := t.timeSource.Now()
+	rootTime, ok := c.get(tree.TreeId, now)
+	if !ok {
+		var err error
+		if rootTime, err = t.latestRootTime(ctx, tree, now); err != nil {
+			return err
+		}
+		c.put(tree.TreeId, rootSample{rootTime: rootTime, readAt: now})
+	}
+	if age := now.Sub(rootTime); age > c.maxRootAge {
+		return status.Errorf(codes.Unavailable, "log %v isn't accepting writes: its latest signed root is %v old, over the allowed %v", tree.TreeId, age, c.maxRootAge)
+	}
+	return nil
+}
+
+// latestRootTime returns the time of tree's latest signed root, or its creation time if
+// it has no root yet. If neither is known, now is returned.
+func (t *TrillianLogRPCServer) latestRootTime(ctx context.Context, tree *trillian.Tree, now time.Time) (time.Time, error) {
+	tx, err := t.prepareReadOnlyStorageTx(ctx, tree.TreeId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "LatestSignedLogRoot"); err != nil {
+		return time.Time{}, err
+	}
+	if root.TimestampNanos > 0 {
+		return time.Unix(0, root.TimestampNanos), nil
+	}
+	created, err := ptypes.Timestamp(tree.CreateTime)
+	if err != nil {
+		// Without a root or a creation time there's nothing to measure progress from.
+		return now, nil
+	}
+	return created, nil
+}
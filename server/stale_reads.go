@@ -0,0 +1,256 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/hashers"
+	serrors "github.com/google/trillian/server/errors"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errNodeNotCached is returned when a node needed to serve a stale proof isn't cached.
+var errNodeNotCached = errors.New("node not cached")
+
+// isStorageUnavailable returns whether err looks like storage failing to answer, as opposed
+// to storage answering with an error, such as NotFound for unknown trees.
+func isStorageUnavailable(err error) bool {
+	switch status.Code(serrors.WrapError(err)) {
+	case codes.Unknown, codes.Unavailable, codes.Internal, codes.DeadlineExceeded, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// staleEntry is a value kept by a staleCache, and when it was read from storage.
+type staleEntry struct {
+	value  interface{}
+	readAt time.Time
+}
+
+// staleCache keeps the latest value read from storage for each tree, to be served for up to
+// maxAge while storage is unavailable.
+type staleCache struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[int64]staleEntry
+}
+
+func (c *staleCache) get(treeID int64, now time.Time) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[treeID]
+	if !ok || now.Sub(e.readAt) > c.maxAge {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *staleCache) put(treeID int64, value interface{}, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[int64]staleEntry)
+	}
+	c.entries[treeID] = staleEntry{value: value, readAt: now}
+}
+
+// NewStaleTreeStorage wraps s so that the trees read through it keep being served for up to
+// maxAge while s can't start transactions, as during a database failover. This lets read
+// requests get past tree lookups, to be answered by a TrillianLogRPCServer with stale reads
+// enabled. Only GetTree is served while s is unavailable: other reads fail with the error of
+// s, and read-write transactions aren't affected.
+func NewStaleTreeStorage(s storage.AdminStorage, maxAge time.Duration, timeSource util.TimeSource) storage.AdminStorage {
+	return &staleTreeStorage{AdminStorage: s, timeSource: timeSource, trees: &staleCache{maxAge: maxAge}}
+}
+
+type staleTreeStorage struct {
+	storage.AdminStorage
+	timeSource util.TimeSource
+	trees      *staleCache
+}
+
+func (s *staleTreeStorage) Snapshot(ctx context.Context) (storage.ReadOnlyAdminTX, error) {
+	tx, err := s.AdminStorage.Snapshot(ctx)
+	switch {
+	case err == nil:
+		return &treeRecordingTX{ReadOnlyAdminTX: tx, s: s}, nil
+	case isStorageUnavailable(err):
+		return &staleTreeTX{s: s, err: err}, nil
+	}
+	return nil, err
+}
+
+// treeRecordingTX remembers the trees read through it.
+type treeRecordingTX struct {
+	storage.ReadOnlyAdminTX
+	s *staleTreeStorage
+}
+
+func (t *treeRecordingTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tree, err := t.ReadOnlyAdminTX.GetTree(ctx, treeID)
+	if err == nil {
+		t.s.trees.put(treeID, proto.Clone(tree), t.s.timeSource.Now())
+	}
+	return tree, err
+}
+
+// staleTreeTX serves remembered trees while storage is unavailable. Everything else fails
+// with err, the error storage failed with.
+type staleTreeTX struct {
+	s      *staleTreeStorage
+	err    error
+	closed bool
+}
+
+func (t *staleTreeTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	if tree, ok := t.s.trees.get(treeID, t.s.timeSource.Now()); ok {
+		return proto.Clone(tree.(*trillian.Tree)).(*trillian.Tree), nil
+	}
+	return nil, t.err
+}
+
+func (t *staleTreeTX) ListTreeIDs(ctx context.Context) ([]int64, error) {
+	return nil, t.err
+}
+
+func (t *staleTreeTX) ListTrees(ctx context.Context) ([]*trillian.Tree, error) {
+	return nil, t.err
+}
+
+func (t *staleTreeTX) GetTreeFootprint(ctx context.Context, treeID int64) (*storage.TreeFootprint, error) {
+	return nil, t.err
+}
+
+func (t *staleTreeTX) ListPendingTrees(ctx context.Context, countLeaves bool) (map[int64]int64, error) {
+	return nil, t.err
+}
+
+func (t *staleTreeTX) ListQuotaConfigs(ctx context.Context) ([]*trillian.QuotaConfig, error) {
+	return nil, t.err
+}
+
+func (t *staleTreeTX) Commit() error {
+	t.closed = true
+	return nil
+}
+
+func (t *staleTreeTX) Rollback() error {
+	t.closed = true
+	return nil
+}
+
+func (t *staleTreeTX) IsClosed() bool {
+	return t.closed
+}
+
+func (t *staleTreeTX) Close() error {
+	t.closed = true
+	return nil
+}
+
+// SetStaleReads makes the server answer some reads from memory while log storage is
+// unavailable, for up to maxAge after the data was last read from storage:
+// GetLatestSignedLogRoot returns the latest root it has seen, and GetInclusionProof serves
+// proofs against that root whose nodes are all in the node cache (see SetNodeCache). Such
+// responses are marked stale. Other requests, including all writes, still fail.
+// Tree lookups also need storage, so the server's AdminStorage should be wrapped with
+// NewStaleTreeStorage. Zero disables stale reads.
+func (t *TrillianLogRPCServer) SetStaleReads(maxAge time.Duration) {
+	if maxAge <= 0 {
+		t.staleRoots = nil
+		return
+	}
+	t.staleRoots = &staleCache{maxAge: maxAge}
+}
+
+// rememberRoot keeps root to serve stale reads of logID, if they're enabled.
+func (t *TrillianLogRPCServer) rememberRoot(logID int64, root trillian.SignedLogRoot) {
+	if t.staleRoots != nil && root.TimestampNanos != 0 {
+		t.staleRoots.put(logID, root, t.timeSource.Now())
+	}
+}
+
+// staleRoot returns the root to serve a stale read of logID with, if stale reads are enabled
+// and storage failed with err because it's unavailable.
+func (t *TrillianLogRPCServer) staleRoot(logID int64, err error) (trillian.SignedLogRoot, bool) {
+	if t.staleRoots == nil || !isStorageUnavailable(err) {
+		return trillian.SignedLogRoot{}, false
+	}
+	root, ok := t.staleRoots.get(logID, t.timeSource.Now())
+	if !ok {
+		return trillian.SignedLogRoot{}, false
+	}
+	return root.(trillian.SignedLogRoot), true
+}
+
+// staleLatestSignedLogRoot answers GetLatestSignedLogRoot from memory, or returns err.
+func (t *TrillianLogRPCServer) staleLatestSignedLogRoot(logID int64, err error) (*trillian.GetLatestSignedLogRootResponse, error) {
+	root, ok := t.staleRoot(logID, err)
+	if !ok {
+		return nil, err
+	}
+	t.staleReads.Inc("GetLatestSignedLogRoot")
+	return &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: &root, Stale: true}, nil
+}
+
+// staleInclusionProof answers GetInclusionProof from memory, or returns err if the proof
+// can't be built from cached nodes.
+func (t *TrillianLogRPCServer) staleInclusionProof(ctx context.Context, req *trillian.GetInclusionProofRequest, hasher hashers.LogHasher, err error) (*trillian.GetInclusionProofResponse, error) {
+	root, ok := t.staleRoot(req.LogId, err)
+	if !ok || t.nodeCache == nil || req.TreeSize > root.TreeSize {
+		return nil, err
+	}
+	proofNodeIDs, perr := merkle.CalcInclusionProofNodeAddresses(req.TreeSize, req.LeafIndex, root.TreeSize, proofMaxBitLen)
+	if perr != nil {
+		return nil, err
+	}
+	nodes := cachedOnlyNodes{cache: t.nodeCache, treeID: req.LogId}
+	proof, perr := fetchNodesAndBuildProof(ctx, nodes, hasher, root.TreeRevision, req.LeafIndex, proofNodeIDs)
+	if perr != nil {
+		return nil, err
+	}
+	t.staleReads.Inc("GetInclusionProof")
+	return &trillian.GetInclusionProofResponse{Proof: &proof, Stale: true}, nil
+}
+
+// cachedOnlyNodes reads nodes from a NodeCache only, failing if any isn't cached.
+type cachedOnlyNodes struct {
+	cache  *cache.NodeCache
+	treeID int64
+}
+
+func (c cachedOnlyNodes) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return c.cache.GetMerkleNodes(ctx, uncachedNodes{}, c.treeID, treeRevision, ids)
+}
+
+// uncachedNodes is the NodeReader behind cachedOnlyNodes, reached only by cache misses.
+type uncachedNodes struct{}
+
+func (uncachedNodes) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	return nil, errNodeNotCached
+}
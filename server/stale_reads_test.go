@@ -0,0 +1,232 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetLatestSignedLogRootStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	maxAge := time.Minute
+	for _, test := range []struct {
+		desc      string
+		maxAge    time.Duration
+		advance   time.Duration
+		storeErr  error
+		wantStale bool
+	}{
+		{desc: "disabled", storeErr: errors.New("TX")},
+		{desc: "unavailable", maxAge: maxAge, storeErr: errors.New("TX"), wantStale: true},
+		{desc: "tooOld", maxAge: maxAge, advance: maxAge + time.Second, storeErr: errors.New("TX")},
+		{desc: "notFound", maxAge: maxAge, storeErr: status.Error(codes.NotFound, "TX")},
+	} {
+		ts := util.NewFakeTimeSource(fakeTime)
+		mockStorage := storage.NewMockLogStorage(ctrl)
+		mockTx := storage.NewMockLogTreeTX(ctrl)
+		gomock.InOrder(
+			mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getLogRootRequest1.LogId).Return(mockTx, nil),
+			mockStorage.EXPECT().SnapshotForTree(gomock.Any(), getLogRootRequest1.LogId).Return(nil, test.storeErr),
+		)
+		mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+		mockTx.EXPECT().Commit().Return(nil)
+		mockTx.EXPECT().Close().Return(nil)
+
+		registry := extension.Registry{
+			AdminStorage: staleAdminStorage(ctrl, getLogRootRequest1.LogId),
+			LogStorage:   mockStorage,
+		}
+		server := NewTrillianLogRPCServer(registry, ts)
+		server.SetStaleReads(test.maxAge)
+
+		if _, err := server.GetLatestSignedLogRoot(context.Background(), &getLogRootRequest1); err != nil {
+			t.Fatalf("%v: GetLatestSignedLogRoot() #1 = (_, %v), want (_, nil)", test.desc, err)
+		}
+		ts.Set(fakeTime.Add(test.advance))
+		resp, err := server.GetLatestSignedLogRoot(context.Background(), &getLogRootRequest1)
+		if !test.wantStale {
+			if err == nil {
+				t.Errorf("%v: GetLatestSignedLogRoot() #2 = (%v, nil), want err", test.desc, resp)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: GetLatestSignedLogRoot() #2 = (_, %v), want (_, nil)", test.desc, err)
+			continue
+		}
+		if !resp.Stale {
+			t.Errorf("%v: GetLatestSignedLogRoot() #2 not stale", test.desc)
+		}
+		if !proto.Equal(resp.SignedLogRoot, &signedRoot1) {
+			t.Errorf("%v: GetLatestSignedLogRoot() #2 = %v, want %v", test.desc, resp.SignedLogRoot, signedRoot1)
+		}
+	}
+}
+
+func TestGetInclusionProofStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTx := storage.NewMockLogTreeTX(ctrl)
+	gomock.InOrder(
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Return(mockTx, nil),
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), logID1).Times(2).Return(nil, errors.New("TX")),
+	)
+	mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	mockTx.EXPECT().ReadRevision().Return(signedRoot1.TreeRevision)
+	mockTx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]storage.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	mockTx.EXPECT().Commit().Return(nil)
+	mockTx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: staleAdminStorage(ctrl, logID1),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.SetNodeCache(cache.NewNodeCache(100, nil))
+	server.SetStaleReads(time.Minute)
+
+	want, err := server.GetInclusionProof(context.Background(), &getInclusionProofByIndexRequest7)
+	if err != nil {
+		t.Fatalf("GetInclusionProof() #1 = (_, %v), want (_, nil)", err)
+	}
+	got, err := server.GetInclusionProof(context.Background(), &getInclusionProofByIndexRequest7)
+	if err != nil {
+		t.Fatalf("GetInclusionProof() #2 = (_, %v), want (_, nil)", err)
+	}
+	if !got.Stale {
+		t.Errorf("GetInclusionProof() #2 not stale")
+	}
+	if !proto.Equal(got.Proof, want.Proof) {
+		t.Errorf("GetInclusionProof() #2 = %v, want %v", got.Proof, want.Proof)
+	}
+
+	// Nodes of other proofs aren't cached, so the storage error is returned.
+	req := trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: 5}
+	if _, err := server.GetInclusionProof(context.Background(), &req); err == nil {
+		t.Errorf("GetInclusionProof(%v) = (_, nil), want err", req)
+	}
+}
+
+func TestStaleTreeStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	tree := *stestonly.LogTree
+	tree.TreeId = logID1
+	maxAge := time.Minute
+	ts := util.NewFakeTimeSource(fakeTime)
+
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	unavailable := errors.New("connection refused")
+	notFound := status.Error(codes.NotFound, "not found")
+	gomock.InOrder(
+		adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil),
+		adminStorage.EXPECT().Snapshot(gomock.Any()).Times(2).Return(nil, unavailable),
+		adminStorage.EXPECT().Snapshot(gomock.Any()).Return(nil, notFound),
+	)
+	adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(&tree, nil)
+	adminTX.EXPECT().Close().Return(nil)
+
+	s := NewStaleTreeStorage(adminStorage, maxAge, ts)
+
+	tx, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() #1 = (_, %v), want (_, nil)", err)
+	}
+	if _, err := tx.GetTree(ctx, logID1); err != nil {
+		t.Fatalf("GetTree() #1 = (_, %v), want (_, nil)", err)
+	}
+	tx.Close()
+
+	tx, err = s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() #2 = (_, %v), want (_, nil)", err)
+	}
+	got, err := tx.GetTree(ctx, logID1)
+	if err != nil {
+		t.Fatalf("GetTree() #2 = (_, %v), want (_, nil)", err)
+	}
+	if !proto.Equal(got, &tree) {
+		t.Errorf("GetTree() #2 = %v, want %v", got, tree)
+	}
+	if _, err := tx.GetTree(ctx, logID2); err != unavailable {
+		t.Errorf("GetTree(%v) = (_, %v), want (_, %v)", logID2, err, unavailable)
+	}
+	if _, err := tx.ListTrees(ctx); err != unavailable {
+		t.Errorf("ListTrees() = (_, %v), want (_, %v)", err, unavailable)
+	}
+	if err := tx.Close(); err != nil {
+		t.Errorf("Close() = %v, want nil", err)
+	}
+
+	tx, err = s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() #3 = (_, %v), want (_, nil)", err)
+	}
+	ts.Set(fakeTime.Add(maxAge + time.Second))
+	if _, err := tx.GetTree(ctx, logID1); err != unavailable {
+		t.Errorf("GetTree() after maxAge = (_, %v), want (_, %v)", err, unavailable)
+	}
+
+	if _, err := s.Snapshot(ctx); err != notFound {
+		t.Errorf("Snapshot() = (_, %v), want (_, %v)", err, notFound)
+	}
+
+	// Read-write transactions aren't affected.
+	adminStorage.EXPECT().Begin(gomock.Any()).Return(nil, unavailable)
+	if _, err := s.Begin(ctx); err != unavailable {
+		t.Errorf("Begin() = (_, %v), want (_, %v)", err, unavailable)
+	}
+}
+
+// staleAdminStorage returns an AdminStorage that serves the test log tree with treeID
+// any number of times.
+func staleAdminStorage(ctrl *gomock.Controller, treeID int64) storage.AdminStorage {
+	tree := *stestonly.LogTree
+	tree.TreeId = treeID
+
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+
+	adminStorage.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), treeID).AnyTimes().Return(&tree, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	return adminStorage
+}
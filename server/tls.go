@@ -0,0 +1,89 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
+// NewTLSConfig returns the TLS configuration of an RPC server that presents the certificate
+// and key in the PEM files certFile and keyFile, or nil if certFile is empty.
+// If clientCAFile is set, clients must present a certificate issued by one of the CAs in
+// that PEM bundle (i.e. mutual TLS).
+// minVersion is the minimum TLS version accepted: "1.0", "1.1" or "1.2".
+func NewTLSConfig(certFile, keyFile, clientCAFile, minVersion string) (*tls.Config, error) {
+	if certFile == "" {
+		if keyFile != "" || clientCAFile != "" {
+			return nil, errors.New("TLS key and client CAs require a certificate")
+		}
+		return nil, nil
+	}
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("unknown TLS version: %q", minVersion)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   version,
+	}
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CAs: %v", err)
+		}
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %v", clientCAFile)
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// gatewayTLSConfig returns the TLS configuration the REST gateway connects to an RPC server
+// configured with cfg with.
+// The gateway presents the server's own certificate, so with mutual TLS that certificate must
+// also be valid for client authentication. It trusts only that certificate, rather than
+// checking the server's chain and host name, as it may connect to an address (e.g. localhost
+// or a Unix socket) the certificate isn't issued for.
+func gatewayTLSConfig(cfg *tls.Config) *tls.Config {
+	certs := cfg.Certificates
+	return &tls.Config{
+		Certificates: certs,
+		MinVersion:   cfg.MinVersion,
+		// Verification is done by VerifyPeerCertificate instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || len(certs) == 0 || !bytes.Equal(rawCerts[0], certs[0].Certificate[0]) {
+				return errors.New("RPC server didn't present its own certificate")
+			}
+			return nil
+		},
+	}
+}
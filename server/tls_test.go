@@ -0,0 +1,240 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a new self-signed certificate valid for server and client
+// authentication, and its key, as PEM files in dir.
+func writeCert(t *testing.T, dir, name string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey()=_,%v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate()=_,%v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey()=_,%v", err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("WriteFile()=%v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("WriteFile()=%v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trillian")
+	if err != nil {
+		t.Fatalf("TempDir()=_,%v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeCert(t, dir, "server")
+	otherCertFile, _ := writeCert(t, dir, "other")
+
+	for _, test := range []struct {
+		desc                             string
+		certFile, keyFile, clientCAFile  string
+		minVersion                       string
+		wantErr, wantNil, wantClientAuth bool
+		wantMinVersion                   uint16
+	}{
+		{desc: "disabled", minVersion: "1.2", wantNil: true},
+		{desc: "keyWithoutCert", keyFile: keyFile, minVersion: "1.2", wantErr: true},
+		{desc: "clientCAWithoutCert", clientCAFile: certFile, minVersion: "1.2", wantErr: true},
+		{desc: "tls", certFile: certFile, keyFile: keyFile, minVersion: "1.2", wantMinVersion: tls.VersionTLS12},
+		{desc: "mtls", certFile: certFile, keyFile: keyFile, clientCAFile: certFile, minVersion: "1.2", wantClientAuth: true, wantMinVersion: tls.VersionTLS12},
+		{desc: "unknownVersion", certFile: certFile, keyFile: keyFile, minVersion: "1.4", wantErr: true},
+		{desc: "missingKey", certFile: certFile, minVersion: "1.2", wantErr: true},
+		{desc: "mismatchedKey", certFile: otherCertFile, keyFile: keyFile, minVersion: "1.2", wantErr: true},
+		{desc: "badClientCAs", certFile: certFile, keyFile: keyFile, clientCAFile: keyFile, minVersion: "1.2", wantErr: true},
+	} {
+		cfg, err := NewTLSConfig(test.certFile, test.keyFile, test.clientCAFile, test.minVersion)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: NewTLSConfig()=_,%v, want err? %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if gotNil := cfg == nil; gotNil != test.wantNil {
+			t.Errorf("%v: NewTLSConfig()=%v,nil, want nil? %v", test.desc, cfg, test.wantNil)
+			continue
+		}
+		if cfg == nil {
+			continue
+		}
+		if got, want := cfg.ClientAuth == tls.RequireAndVerifyClientCert, test.wantClientAuth; got != want {
+			t.Errorf("%v: NewTLSConfig().ClientAuth=%v, want client certs required? %v", test.desc, cfg.ClientAuth, want)
+		}
+		if got, want := cfg.MinVersion, test.wantMinVersion; got != want {
+			t.Errorf("%v: NewTLSConfig().MinVersion=%v, want: %v", test.desc, got, want)
+		}
+	}
+}
+
+func TestGatewayTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trillian")
+	if err != nil {
+		t.Fatalf("TempDir()=_,%v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeCert(t, dir, "server")
+	otherCertFile, otherKeyFile := writeCert(t, dir, "other")
+
+	serverCfg, err := NewTLSConfig(certFile, keyFile, certFile, "1.2")
+	if err != nil {
+		t.Fatalf("NewTLSConfig()=_,%v", err)
+	}
+	otherCfg, err := NewTLSConfig(otherCertFile, otherKeyFile, "", "1.2")
+	if err != nil {
+		t.Fatalf("NewTLSConfig()=_,%v", err)
+	}
+
+	for _, test := range []struct {
+		desc      string
+		clientCfg *tls.Config
+		wantErr   bool
+	}{
+		// The gateway trusts the server despite its certificate not matching the address it
+		// connects to, and the server trusts the gateway as it presents a certificate issued
+		// by a client CA.
+		{desc: "gateway", clientCfg: gatewayTLSConfig(serverCfg)},
+		// A gateway configured for another server doesn't trust the server, and isn't trusted.
+		{desc: "otherGateway", clientCfg: gatewayTLSConfig(otherCfg), wantErr: true},
+		// Clients without a certificate aren't trusted.
+		{desc: "noClientCert", clientCfg: &tls.Config{InsecureSkipVerify: true}, wantErr: true},
+	} {
+		if err := handshake(serverCfg, test.clientCfg); (err != nil) != test.wantErr {
+			t.Errorf("%v: handshake()=%v, want err? %v", test.desc, err, test.wantErr)
+		}
+	}
+}
+
+// handshake makes a TLS connection between a server and a client, and returns the client's
+// error if either of them fails the handshake.
+func handshake(serverCfg, clientCfg *tls.Config) error {
+	lis, err := tls.Listen("tcp", "localhost:0", serverCfg)
+	if err != nil {
+		return err
+	}
+	defer lis.Close()
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Reading makes the server complete the handshake. The byte read is echoed back, so
+		// the client's read fails only if the handshake did.
+		buf := make([]byte, 1)
+		if _, err := conn.Read(buf); err == nil {
+			conn.Write(buf)
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte{0}); err != nil {
+		return err
+	}
+	// The server may reject the client's certificate after the client's side of the
+	// handshake is done, which is only seen when reading.
+	_, err = conn.Read(make([]byte, 1))
+	return err
+}
+
+func TestListenHTTPTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trillian")
+	if err != nil {
+		t.Fatalf("TempDir()=_,%v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeCert(t, dir, "server")
+	cfg, err := NewTLSConfig(certFile, keyFile, certFile, "1.2")
+	if err != nil {
+		t.Fatalf("NewTLSConfig()=_,%v", err)
+	}
+
+	m := &Main{HTTPEndpoint: "localhost:0", TLSConfig: cfg}
+	lis, err := m.listenHTTP()
+	if err != nil {
+		t.Fatalf("listenHTTP()=_,%v, want: _,nil", err)
+	}
+	defer lis.Close()
+	go http.Serve(lis, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	for _, test := range []struct {
+		desc      string
+		clientCfg *tls.Config
+		wantErr   bool
+	}{
+		{desc: "clientCert", clientCfg: gatewayTLSConfig(cfg)},
+		// HTTP requires client certificates just like RPCs do.
+		{desc: "noClientCert", clientCfg: &tls.Config{InsecureSkipVerify: true}, wantErr: true},
+	} {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: test.clientCfg}, Timeout: 5 * time.Second}
+		resp, err := client.Get("https://" + lis.Addr().String() + "/metrics")
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err != nil) != test.wantErr {
+			t.Errorf("%v: Get()=_,%v, want err? %v", test.desc, err, test.wantErr)
+		}
+	}
+}
+
+func TestListenRPCTLSSinglePort(t *testing.T) {
+	m := &Main{RPCEndpoint: "localhost:0", TLSConfig: &tls.Config{}, SinglePort: true}
+	if lis, err := m.listenRPC(); err == nil {
+		lis.Close()
+		t.Errorf("listenRPC()=_,nil, want: _,error")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+
+	"golang.org/x/net/context"
+)
+
+// newOTLPTracer starts tracing with OpenTelemetry, and returns a function that exports
+// pending spans and stops the exporter. OpenTelemetry isn't vendored, so it's only set in
+// binaries built with the otel tag, see otel.go.
+var newOTLPTracer func(ctx context.Context, endpoint, serviceName string, sampleFraction float64) (func(), error)
+
+// StartTracing traces this process with OpenTelemetry, exporting the spans of sampled traces
+// via OTLP to endpoint (host:port), see opentelemetry.NewTracerProvider. The returned
+// function exports pending spans and stops the exporter; it should be called before exiting.
+func StartTracing(ctx context.Context, endpoint, serviceName string, sampleFraction float64) (func(), error) {
+	if newOTLPTracer == nil {
+		return nil, errors.New("tracing needs a binary built with -tags otel")
+	}
+	return newOTLPTracer(ctx, endpoint, serviceName, sampleFraction)
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+)
+
+// TreeDrainer periodically freezes DRAINING trees whose drain deadline has passed. Past the
+// deadline, writes to the tree are already rejected, so freezing only waits for the leaves
+// queued before the deadline to be sequenced: logs with queued leaves are left DRAINING until
+// a later pass.
+type TreeDrainer struct {
+	registry   extension.Registry
+	timeSource util.TimeSource
+	interval   time.Duration
+	frozen     monitoring.Counter
+}
+
+// NewTreeDrainer creates a TreeDrainer that checks draining trees every interval.
+func NewTreeDrainer(registry extension.Registry, timeSource util.TimeSource, interval time.Duration) *TreeDrainer {
+	mf := registry.MetricFactory
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &TreeDrainer{
+		registry:   registry,
+		timeSource: timeSource,
+		interval:   interval,
+		frozen:     mf.NewCounter("drained_trees", "Number of DRAINING trees frozen after their drain deadline"),
+	}
+}
+
+// Run freezes drained trees until ctx is done.
+func (d *TreeDrainer) Run(ctx context.Context) {
+	runPeriodically(ctx, d.interval, "freeze drained trees", d.Drain)
+}
+
+// Drain freezes every DRAINING tree whose drain deadline has passed and that has no queued
+// leaves left.
+func (d *TreeDrainer) Drain(ctx context.Context) error {
+	expired, err := d.expiredTrees(ctx)
+	if err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	pending, err := d.pendingLogs(ctx, expired)
+	if err != nil {
+		return err
+	}
+	for _, tree := range expired {
+		if pending[tree.TreeId] {
+			glog.V(1).Infof("%v: drain deadline passed, waiting for queued leaves to be sequenced", tree.TreeId)
+			continue
+		}
+		if err := d.freeze(ctx, tree.TreeId); err != nil {
+			glog.Warningf("%v: failed to freeze drained tree: %v", tree.TreeId, err)
+			continue
+		}
+		glog.Infof("%v: drain deadline passed, tree frozen", tree.TreeId)
+		d.frozen.Inc()
+	}
+	return nil
+}
+
+// expiredTrees returns the DRAINING trees whose drain deadline has passed.
+func (d *TreeDrainer) expiredTrees(ctx context.Context) ([]*trillian.Tree, error) {
+	all, err := listTrees(ctx, d.registry.AdminStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := d.timeSource.Now()
+	var expired []*trillian.Tree
+	for _, tree := range all {
+		passed, err := trees.DrainDeadlinePassed(tree, now)
+		if err != nil {
+			glog.Warningf("%v: %v", tree.TreeId, err)
+			continue
+		}
+		if passed {
+			expired = append(expired, tree)
+		}
+	}
+	return expired, nil
+}
+
+// pendingLogs returns the IDs of the logs with queued leaves, if any of expired is a log.
+func (d *TreeDrainer) pendingLogs(ctx context.Context, expired []*trillian.Tree) (map[int64]bool, error) {
+	hasLogs := false
+	for _, tree := range expired {
+		hasLogs = hasLogs || tree.TreeType == trillian.TreeType_LOG
+	}
+	switch {
+	case !hasLogs:
+		return nil, nil
+	case d.registry.LogStorage == nil:
+		return nil, fmt.Errorf("can't check drained logs for queued leaves without a LogStorage")
+	}
+
+	tx, err := d.registry.LogStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tx for retrieving logs with pending work: %v", err)
+	}
+	defer tx.Close()
+	ids, err := tx.GetActiveLogIDsWithPendingWork(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs with pending work: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit getting logs with pending work: %v", err)
+	}
+
+	pending := make(map[int64]bool)
+	for _, id := range ids {
+		pending[id] = true
+	}
+	return pending, nil
+}
+
+// freeze moves the tree to the FROZEN state, unless it left the DRAINING state since it was
+// listed.
+func (d *TreeDrainer) freeze(ctx context.Context, treeID int64) error {
+	tx, err := d.registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if _, err := tx.UpdateTree(ctx, treeID, func(tree *trillian.Tree) {
+		if tree.TreeState == trillian.TreeState_DRAINING {
+			tree.TreeState = trillian.TreeState_FROZEN
+			tree.DrainDeadline = nil
+		}
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+func TestTreeDrainer_Drain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1500000000, 0)
+	past, err := ptypes.TimestampProto(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("TimestampProto() returned err = %v", err)
+	}
+	future, err := ptypes.TimestampProto(now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("TimestampProto() returned err = %v", err)
+	}
+	drainedLog := &trillian.Tree{TreeId: 1, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_DRAINING, DrainDeadline: past}
+	pendingLog := &trillian.Tree{TreeId: 2, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_DRAINING, DrainDeadline: past}
+	drainingLog := &trillian.Tree{TreeId: 3, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_DRAINING, DrainDeadline: future}
+	drainedMap := &trillian.Tree{TreeId: 4, TreeType: trillian.TreeType_MAP, TreeState: trillian.TreeState_DRAINING, DrainDeadline: past}
+	activeLog := &trillian.Tree{TreeId: 5, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE}
+
+	as := storage.NewMockAdminStorage(ctrl)
+	snapshotTX := storage.NewMockAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(snapshotTX, nil)
+	snapshotTX.EXPECT().ListTrees(gomock.Any()).Return([]*trillian.Tree{drainedLog, pendingLog, drainingLog, drainedMap, activeLog}, nil)
+	snapshotTX.EXPECT().Commit().Return(nil)
+	snapshotTX.EXPECT().Close().Return(nil)
+
+	// Only the expired trees without queued leaves are frozen.
+	for _, tree := range []*trillian.Tree{drainedLog, drainedMap} {
+		tree := tree
+		tx := storage.NewMockAdminTX(ctrl)
+		as.EXPECT().Begin(gomock.Any()).Return(tx, nil)
+		tx.EXPECT().UpdateTree(gomock.Any(), tree.TreeId, gomock.Any()).Do(func(_ context.Context, _ int64, updateFunc func(*trillian.Tree)) {
+			updateFunc(tree)
+		}).Return(tree, nil)
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+	}
+
+	ls := storage.NewMockLogStorage(ctrl)
+	logTX := storage.NewMockReadOnlyLogTX(ctrl)
+	ls.EXPECT().Snapshot(gomock.Any()).Return(logTX, nil)
+	logTX.EXPECT().GetActiveLogIDsWithPendingWork(gomock.Any()).Return([]int64{pendingLog.TreeId}, nil)
+	logTX.EXPECT().Commit().Return(nil)
+	logTX.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage:  as,
+		LogStorage:    ls,
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	d := NewTreeDrainer(registry, util.NewFakeTimeSource(now), time.Minute)
+	if err := d.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain() returned err = %v", err)
+	}
+	if got, want := d.frozen.Value(), 2.0; got != want {
+		t.Errorf("frozen trees = %v, want %v", got, want)
+	}
+
+	for _, test := range []struct {
+		tree         *trillian.Tree
+		want         trillian.TreeState
+		wantDeadline bool
+	}{
+		{tree: drainedLog, want: trillian.TreeState_FROZEN},
+		{tree: pendingLog, want: trillian.TreeState_DRAINING, wantDeadline: true},
+		{tree: drainingLog, want: trillian.TreeState_DRAINING, wantDeadline: true},
+		{tree: drainedMap, want: trillian.TreeState_FROZEN},
+		{tree: activeLog, want: trillian.TreeState_ACTIVE},
+	} {
+		if got := test.tree.TreeState; got != test.want {
+			t.Errorf("tree %v: TreeState = %s, want %s", test.tree.TreeId, got, test.want)
+		}
+		if got := test.tree.DrainDeadline != nil; got != test.wantDeadline {
+			t.Errorf("tree %v: has DrainDeadline = %v, want %v", test.tree.TreeId, got, test.wantDeadline)
+		}
+	}
+}
@@ -17,13 +17,16 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
+	"net/http"
 	_ "net/http/pprof"
-
-	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
+	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/google/trillian"
+	"github.com/google/trillian/audit"
 	"github.com/google/trillian/cmd"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
@@ -31,27 +34,79 @@ import (
 	_ "github.com/google/trillian/merkle/rfc6962"   // Load hashers
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/monitoring/prometheus"
-	mysqlq "github.com/google/trillian/quota/mysql"
+	"github.com/google/trillian/quota/dynamic"
 	"github.com/google/trillian/server"
 	"github.com/google/trillian/server/interceptor"
-	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/factory"
+	_ "github.com/google/trillian/storage/mysql" // Load MySQL storage
 	"github.com/google/trillian/util"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
-	mySQLURI           = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
-	rpcEndpoint        = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
-	httpEndpoint       = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics and REST requests on (host:port, empty means disabled)")
-	etcdServers        = flag.String("etcd_servers", "", "A comma-separated list of etcd servers; no etcd registration if empty")
-	etcdService        = flag.String("etcd_service", "trillian-logserver", "Service name to announce ourselves under")
-	etcdHTTPService    = flag.String("etcd_http_service", "trillian-logserver-http", "Service name to announce our HTTP endpoint under")
-	maxUnsequencedRows = flag.Int("max_unsequenced_rows", mysqlq.DefaultMaxUnsequenced, "Max number of unsequenced rows before rate limiting kicks in")
+	storageSystem       = flag.String("storage_system", "mysql", "Storage system to use, one of: "+strings.Join(factory.Systems(), ", "))
+	storageURI          = flag.String("storage_uri", "", "Connection URI for the storage system, empty means the storage system's default")
+	rpcEndpoint         = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
+	listenUnixSocket    = flag.String("listen_unix_socket", "", "Path of a Unix domain socket to serve RPC requests on instead of --rpc_endpoint; HTTP is still served on --http_endpoint")
+	httpEndpoint        = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics and REST requests on (host:port, empty means disabled)")
+	singlePort          = flag.Bool("single_port", false, "Serve HTTP metrics and REST requests on --rpc_endpoint alongside RPCs, instead of on --http_endpoint")
+	tlsCertFile         = flag.String("tls_cert_file", "", "PEM file of the certificate to serve RPCs over TLS with; empty means RPCs aren't encrypted")
+	tlsKeyFile          = flag.String("tls_key_file", "", "PEM file of the private key of --tls_cert_file")
+	tlsClientCAFile     = flag.String("tls_client_ca_file", "", "PEM bundle of CAs, one of which must have issued the certificate RPC clients present (mutual TLS); empty means clients aren't authenticated")
+	tlsMinVersion       = flag.String("tls_min_version", "1.2", "Minimum TLS version accepted for RPCs if --tls_cert_file is set: 1.0, 1.1 or 1.2")
+	authTokenFile       = flag.String("auth_token_file", "", "File of principals and their bearer tokens, one \"<principal> <token>\" pair per line, to authenticate RPCs with")
+	authClientCerts     = flag.Bool("auth_client_certs", false, "If true, authenticate RPCs by the subject common name of their TLS client certificate (requires --tls_client_ca_file)")
+	authAdmins          = flag.String("auth_admins", "", "Comma-separated principals allowed to make admin RPCs, and any RPC regardless of tree access policies; only used if RPCs are authenticated")
+	authOpenTrees       = flag.Bool("auth_open_trees", false, "If true, any authenticated principal may read and write trees without an access policy; otherwise only admins may")
+	enableRESTGateway   = flag.Bool("enable_rest_gateway", true, "If false, don't serve REST requests, only metrics, on the HTTP endpoint")
+	etcdServers         = flag.String("etcd_servers", "", "A comma-separated list of etcd servers; no etcd registration if empty")
+	etcdService         = flag.String("etcd_service", "trillian-logserver", "Service name to announce ourselves under")
+	etcdHTTPService     = flag.String("etcd_http_service", "trillian-logserver-http", "Service name to announce our HTTP endpoint under")
+	maxActiveTrees      = flag.Int64("max_active_trees", 0, "Max number of trees that aren't deleted, beyond which CreateTree is rejected; zero means no limit")
+	rootTimeSource      = flag.String("root_time_source", "app", "Clock that roots signed by RepairTreeRoot are timestamped with, which should match the signer's --root_time_source: app for the clock of this server, or db for the clock of the storage system's database")
+	healthCheckInterval = flag.Duration("health_check_interval", 10*time.Second, "Interval between checks of storage for the grpc.health.v1.Health service; zero means the health service isn't served")
+	drainTimeout        = flag.Duration("drain_timeout", 30*time.Second, "Time RPCs in flight on SIGINT or SIGTERM are given to complete before they're aborted")
+	drainDelay          = flag.Duration("drain_delay", 5*time.Second, "Time the health service reports NOT_SERVING on SIGINT or SIGTERM before RPCs are drained, for load balancers to stop sending RPCs")
+	quotaFailOpen       = flag.Bool("quota_fail_open", false, "If true, let requests through without quota when the quota manager fails, rather than rejecting them")
+	quotaConfigRefresh  = flag.Duration("quota_config_refresh_interval", time.Minute, "Interval between reloads of the quota configs set through the admin API, which limit requests on top of the storage system's quota; zero ignores quota configs")
+	auditSink           = flag.String("audit_sink", "", "Destination of the audit log of admin changes to trees: file:<path> or syslog:<tag>; empty means no audit log")
+	auditMaxFileBytes   = flag.Int64("audit_max_file_bytes", 100<<20, "Size at which a file audit log is rotated, zero means never")
+	auditFailClosed     = flag.Bool("audit_fail_closed", false, "If true, refuse admin changes that can't be written to the audit log")
+	verifyProofs        = flag.Bool("verify_proofs", false, "If true, verify each inclusion and consistency proof against the latest signed root before returning it, failing the request if it doesn't verify")
+	proofCheckRPC       = flag.Bool("enable_check_consistency_proof", false, "If true, serve CheckConsistencyProof, which checks consistency proofs held by clients; it shifts trust to the server, so is meant for debugging only")
+	nodeCacheSize       = flag.Int("node_cache_size", 0, "Number of Merkle tree nodes read by proof and other read-only requests to cache in memory, shared by all requests; zero disables the cache")
+
+	staleReadsMaxAge    = flag.Duration("stale_reads_max_age", 0, "If non-zero, serve the latest signed root and cached proofs of logs, marked stale, for up to this long after they were read while storage is unavailable; proofs need --node_cache_size, and MySQL quota needs --quota_fail_open")
+	maxRootAgeForWrites = flag.Duration("max_root_age_for_writes", 0, "If non-zero, reject QueueLeaves with Unavailable for logs whose latest signed root is older than this, as their signer is likely stalled; reads are still served")
+
+	queueBufferBytes   = flag.Int64("queue_buffer_bytes", 0, "Max size of leaves buffered in memory by QueueLeaves to be written in batches, beyond which requests are rejected; requests larger than it are written unbuffered; zero disables buffering")
+	queueBatchSize     = flag.Int("queue_batch_size", 1000, "Number of buffered leaves of a log that triggers writing them, if --queue_buffer_bytes is set")
+	queueFlushInterval = flag.Duration("queue_flush_interval", 50*time.Millisecond, "Longest time leaves are buffered before being written, if --queue_buffer_bytes is set")
+
+	pkcs11ModulePath = flag.String("pkcs11_module_path", "", "Path to the PKCS#11 module to use for keys that use the PKCS#11 interface and don't specify their own module")
+
+	handlerDeadlineFraction = flag.Float64("handler_deadline_fraction", 0, "Fraction of the remaining RPC deadline that RPC handlers, including their storage operations, are run under, in (0, 1]; zero means all of it")
+	maxHandlerDeadline      = flag.Duration("max_handler_deadline", 0, "Maximum deadline RPC handlers, including their storage operations, are run under; zero means no maximum")
+
+	metricsBackend      = flag.String("metrics_backend", "", "Backend to export metrics with: prometheus (served on the HTTP endpoint), otlp, statsd or dogstatsd; empty means otlp if --otlp_endpoint is set, prometheus otherwise")
+	otlpEndpoint        = flag.String("otlp_endpoint", "", "Endpoint to export metrics to via OTLP (host:port), for the otlp metrics backend; needs a binary built with -tags otel")
+	otlpExportInterval  = flag.Duration("otlp_export_interval", time.Minute, "Interval between exports of metrics via OTLP")
+	statsdEndpoint      = flag.String("statsd_endpoint", "localhost:8125", "Endpoint of the collector to send metrics to (host:port), for the statsd and dogstatsd metrics backends")
+	statsdFlush         = flag.Duration("statsd_flush_interval", time.Second, "Max interval between sends of buffered metrics to --statsd_endpoint")
+	traceEndpoint       = flag.String("trace_otlp_endpoint", "", "Endpoint to export trace spans to via OTLP (host:port), e.g. a Jaeger or OpenTelemetry collector; empty means tracing is disabled; needs a binary built with -tags otel")
+	traceSampleFraction = flag.Float64("trace_sample_fraction", 0.01, "Fraction of the traces started by this server that are sampled, if --trace_otlp_endpoint is set; traces started by callers keep their sampling decision")
+
+	metricNamespace   = flag.String("metric_namespace", "", "Namespace prepended to the names of Prometheus metrics, e.g. \"trillian\"")
+	metricConstLabels = flag.String("metric_const_labels", "", "Comma-separated name=value labels added to every Prometheus metric, e.g. \"cluster=c1,region=r1\"")
+	metricBuckets     = flag.String("metric_histogram_buckets", "", "Semicolon-separated lists of comma-separated Prometheus histogram bucket bounds, for every histogram or, prefixed with \"<name>=\", for one; empty means the Prometheus defaults")
 
-	pkcs11ModulePath = flag.String("pkcs11_module_path", "", "Path to the PKCS#11 module to use for keys that use the PKCS#11 interface")
+	rootAgeSampleInterval = flag.Duration("root_age_sample_interval", time.Minute, "Interval between samples of the latest_signed_root_age_seconds metric, zero disables sampling")
 
-	configFile = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
+	configFile   = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags. It's reloaded on SIGHUP, changing -v and --max_unsequenced_rows only")
+	serverConfig = flag.String("server_config", "", "ServerConfig file in proto text format (see server/configpb/config.proto), applied on top of --config; command line flags take precedence")
 )
 
 func main() {
@@ -62,71 +117,237 @@ func main() {
 			glog.Exitf("Failed to load flags from config file %q: %s", *configFile, err)
 		}
 	}
+	if *serverConfig != "" {
+		if err := server.ParseConfigFile(*serverConfig); err != nil {
+			glog.Exitf("Failed to load server config: %v", err)
+		}
+	}
+	if *handlerDeadlineFraction < 0 || *handlerDeadlineFraction > 1 {
+		glog.Exitf("--handler_deadline_fraction must be in [0, 1], got %v", *handlerDeadlineFraction)
+	}
 
 	ctx := context.Background()
 
-	// First make sure we can access the database, quit if not
-	db, err := mysql.OpenDB(*mySQLURI)
+	pmf, err := prometheus.NewMetricFactory(*metricNamespace, *metricConstLabels, *metricBuckets)
+	if err != nil {
+		glog.Exitf("Invalid Prometheus metric flags: %v", err)
+	}
+	var statsdPrefix string
+	if *metricNamespace != "" {
+		statsdPrefix = *metricNamespace + "."
+	}
+	mf, closeMetrics, err := server.NewMetricFactory(ctx, server.MetricsConfig{
+		Backend:             *metricsBackend,
+		Prometheus:          pmf,
+		OTLPEndpoint:        *otlpEndpoint,
+		OTLPExportInterval:  *otlpExportInterval,
+		StatsDEndpoint:      *statsdEndpoint,
+		StatsDFlushInterval: *statsdFlush,
+		StatsDPrefix:        statsdPrefix,
+	})
 	if err != nil {
-		glog.Exitf("Failed to open database: %v", err)
+		glog.Exitf("Failed to create metric factory: %v", err)
+	}
+	defer closeMetrics()
+	if *traceEndpoint != "" {
+		stopTracing, err := server.StartTracing(ctx, *traceEndpoint, "trillian_log_server", *traceSampleFraction)
+		if err != nil {
+			glog.Exitf("Failed to start tracing: %v", err)
+		}
+		defer stopTracing()
+	}
+
+	// First make sure we can access the storage, quit if not
+	sp, err := factory.NewProvider(*storageSystem, *storageURI, mf)
+	if err != nil {
+		glog.Exitf("Failed to open %v storage: %v", *storageSystem, err)
+	}
+	// No defer: storage ownership is delegated to server.Main
+
+	// Quota is backed by the storage system, if it supports it (e.g. MySQL counts unsequenced
+	// rows); otherwise there's no quota.
+	qm := factory.QuotaManager(sp)
+	if *configFile != "" {
+		reloadable := []cmd.Reloadable{{Name: "v"}}
+		if mqm, ok := qm.(interface {
+			SetMaxUnsequencedRows(int)
+		}); ok {
+			reloadable = append(reloadable, cmd.Reloadable{
+				Name: "max_unsequenced_rows",
+				Apply: func() {
+					mqm.SetMaxUnsequencedRows(flag.Lookup("max_unsequenced_rows").Value.(flag.Getter).Get().(int))
+				},
+			})
+		}
+		reloader, err := cmd.NewFlagFileReloader(*configFile, reloadable...)
+		if err != nil {
+			glog.Exitf("Failed to set up reloading of config file %q: %v", *configFile, err)
+		}
+		go reloader.Run(ctx)
+	}
+	// Quota configured through the admin API applies on top.
+	if *quotaConfigRefresh > 0 {
+		dqm := dynamic.NewManager(qm, sp.AdminStorage(), util.SystemTimeSource{})
+		go dqm.Run(ctx, *quotaConfigRefresh)
+		qm = dqm
 	}
-	// No defer: database ownership is delegated to server.Main
 
-	// Announce our endpoints to etcd if so configured.
-	unannounce := server.AnnounceSelf(ctx, *etcdServers, *etcdService, *rpcEndpoint)
-	if unannounce != nil {
-		defer unannounce()
+	// Announce our endpoints to etcd if so configured. RPCs served on a Unix socket are
+	// local-only, so they aren't announced.
+	if *listenUnixSocket == "" {
+		unannounce := server.AnnounceSelf(ctx, *etcdServers, *etcdService, *rpcEndpoint)
+		if unannounce != nil {
+			defer unannounce()
+		}
 	}
-	if *httpEndpoint != "" {
+	if *httpEndpoint != "" && !*singlePort {
 		unannounceHTTP := server.AnnounceSelf(ctx, *etcdServers, *etcdHTTPService, *httpEndpoint)
 		if unannounceHTTP != nil {
 			defer unannounceHTTP()
 		}
 	}
 
-	mf := prometheus.MetricFactory{}
-
-	sf := &keys.DefaultSignerFactory{}
-	if *pkcs11ModulePath != "" {
-		sf.SetPKCS11Module(*pkcs11ModulePath)
-	}
+	sf := keys.NewPKCS11SignerFactory(&keys.DefaultSignerFactory{}, *pkcs11ModulePath)
 
 	registry := extension.Registry{
-		AdminStorage:  mysql.NewAdminStorage(db),
-		LogStorage:    mysql.NewLogStorage(db, mf),
+		AdminStorage:  sp.AdminStorage(),
+		LogStorage:    sp.LogStorage(),
 		SignerFactory: sf,
-		QuotaManager:  &mysqlq.QuotaManager{DB: db, MaxUnsequencedRows: *maxUnsequencedRows},
+		QuotaManager:  qm,
 		MetricFactory: mf,
 	}
 
 	ts := util.SystemTimeSource{}
+	if *staleReadsMaxAge > 0 {
+		registry.AdminStorage = server.NewStaleTreeStorage(registry.AdminStorage, *staleReadsMaxAge, ts)
+	}
 	stats := monitoring.NewRPCStatsInterceptor(ts, "log", registry.MetricFactory)
+	tlsConfig, err := server.NewTLSConfig(*tlsCertFile, *tlsKeyFile, *tlsClientCAFile, *tlsMinVersion)
+	if err != nil {
+		glog.Exitf("Failed to configure TLS: %v", err)
+	}
 	ti := &interceptor.TrillianInterceptor{
-		Admin:        registry.AdminStorage,
-		QuotaManager: registry.QuotaManager,
+		Admin:         registry.AdminStorage,
+		QuotaManager:  registry.QuotaManager,
+		QuotaFailOpen: *quotaFailOpen,
+		MetricFactory: registry.MetricFactory,
+		Authorizer:    newAuthorizer(tlsConfig),
+	}
+	hd := interceptor.NewHandlerDeadline("log", *handlerDeadlineFraction, *maxHandlerDeadline, registry.MetricFactory)
+	interceptors := []grpc.UnaryServerInterceptor{interceptor.TracingUnaryInterceptor, stats.Interceptor(), interceptor.ErrorWrapper}
+	if *auditSink != "" {
+		sink, err := audit.NewSink(*auditSink, *auditMaxFileBytes)
+		if err != nil {
+			glog.Exitf("Failed to create audit sink: %v", err)
+		}
+		defer sink.Close()
+		interceptors = append(interceptors, interceptor.NewAuditLog(sink, *auditFailClosed, registry.MetricFactory).UnaryInterceptor)
 	}
-	netInterceptor := interceptor.Combine(stats.Interceptor(), interceptor.ErrorWrapper, ti.UnaryInterceptor)
-	s := grpc.NewServer(grpc.UnaryInterceptor(netInterceptor))
+	interceptors = append(interceptors, hd.UnaryInterceptor, ti.UnaryInterceptor)
+	netInterceptor := interceptor.Combine(interceptors...)
+	serverOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(netInterceptor),
+		grpc.StreamInterceptor(interceptor.CombineStream(interceptor.TracingStreamInterceptor, ti.StreamInterceptor)),
+	}
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := grpc.NewServer(serverOpts...)
 	// No defer: server ownership is delegated to server.Main
 
+	logServer := server.NewTrillianLogRPCServer(registry, ts)
+	logServer.SetProofVerification(*verifyProofs)
+	logServer.SetConsistencyProofChecks(*proofCheckRPC)
+	logServer.SetMaxRootAgeForWrites(*maxRootAgeForWrites)
+	logServer.SetStaleReads(*staleReadsMaxAge)
+	if *nodeCacheSize > 0 {
+		logServer.SetNodeCache(cache.NewNodeCache(*nodeCacheSize, registry.MetricFactory))
+	}
+	if *queueBufferBytes > 0 {
+		logServer.SetQueueBuffer(server.QueueBufferOptions{
+			MaxBytes:      *queueBufferBytes,
+			BatchSize:     *queueBatchSize,
+			FlushInterval: *queueFlushInterval,
+		})
+	}
+
+	var rts util.TimeSource
+	switch *rootTimeSource {
+	case "app":
+	case "db":
+		tsp, ok := sp.(factory.TimeSourceProvider)
+		if !ok {
+			glog.Exitf("--root_time_source=db isn't supported by %v storage", *storageSystem)
+		}
+		rts = tsp.TimeSource(ts)
+	default:
+		glog.Exitf("Unknown --root_time_source %q, want app or db", *rootTimeSource)
+	}
+
 	m := server.Main{
-		RPCEndpoint:       *rpcEndpoint,
-		HTTPEndpoint:      *httpEndpoint,
-		DB:                db,
-		Registry:          registry,
-		Server:            s,
-		RegisterHandlerFn: trillian.RegisterTrillianLogHandlerFromEndpoint,
+		RPCEndpoint:         *rpcEndpoint,
+		RPCUnixSocket:       *listenUnixSocket,
+		HTTPEndpoint:        *httpEndpoint,
+		SinglePort:          *singlePort,
+		TLSConfig:           tlsConfig,
+		DisableRESTGateway:  !*enableRESTGateway,
+		StorageProvider:     sp,
+		MaxActiveTrees:      *maxActiveTrees,
+		RootTimeSource:      rts,
+		HealthCheckInterval: *healthCheckInterval,
+		DrainTimeout:        *drainTimeout,
+		DrainDelay:          *drainDelay,
+		Registry:            registry,
+		Server:              s,
+		RegisterHandlerFn:   trillian.RegisterTrillianLogHandlerFromEndpoint,
+		HTTPHandlers:        map[string]http.Handler{"/checkpoint/": server.CheckpointHandler(logServer)},
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
-			logServer := server.NewTrillianLogRPCServer(registry, ts)
 			if err := logServer.IsHealthy(); err != nil {
 				return err
 			}
 			trillian.RegisterTrillianLogServer(s, logServer)
 			return err
 		},
+		ShutdownFn: logServer.Close,
+	}
+
+	if *rootAgeSampleInterval > 0 {
+		go server.NewRootAgeMonitor(registry.LogStorage, ts, *rootAgeSampleInterval, registry.MetricFactory).Run(ctx)
 	}
 
 	if err := m.Run(ctx); err != nil {
 		glog.Exitf("Server exited with error: %v", err)
 	}
 }
+
+// newAuthorizer returns the Authorizer configured by flags, or nil if RPCs aren't authenticated.
+// tlsConfig is the TLS configuration RPCs are served with.
+func newAuthorizer(tlsConfig *tls.Config) *interceptor.Authorizer {
+	var authenticators interceptor.MultiAuthenticator
+	if *authTokenFile != "" {
+		tokens, err := interceptor.ReadTokenFile(*authTokenFile)
+		if err != nil {
+			glog.Exitf("Failed to read --auth_token_file: %v", err)
+		}
+		authenticators = append(authenticators, interceptor.NewTokenAuthenticator(tokens))
+	}
+	if *authClientCerts {
+		if *tlsClientCAFile == "" {
+			glog.Exit("--auth_client_certs requires --tls_client_ca_file")
+		}
+		// The REST gateway calls the server with the server's own certificate.
+		authenticators = append(authenticators, interceptor.CertAuthenticator{GatewayCert: tlsConfig.Certificates[0].Certificate[0]})
+	}
+	if len(authenticators) == 0 {
+		return nil
+	}
+	var admins []string
+	if *authAdmins != "" {
+		admins = strings.Split(*authAdmins, ",")
+	} else {
+		glog.Warning("RPCs are authenticated but --auth_admins is empty, so no one may make admin RPCs")
+	}
+	a := interceptor.NewAuthorizer(authenticators, admins)
+	a.OpenTrees = *authOpenTrees
+	return a
+}
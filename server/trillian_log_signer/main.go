@@ -16,49 +16,108 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql" // Load MySQL driver
 	"github.com/golang/glog"
 	"github.com/google/trillian/cmd"
 	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/keys/breaker"
+	"github.com/google/trillian/crypto/keys/reconnect"
+	"github.com/google/trillian/crypto/tsa"
 	"github.com/google/trillian/extension"
 	_ "github.com/google/trillian/merkle/objhasher" // Load hashers
 	_ "github.com/google/trillian/merkle/rfc6962"   // Load hashers
 	"github.com/google/trillian/monitoring/prometheus"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/server"
-	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/storage/factory"
+	_ "github.com/google/trillian/storage/mysql" // Load MySQL storage
 	"github.com/google/trillian/util"
 	"github.com/google/trillian/util/etcd"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 )
 
 var (
-	mySQLURI                 = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
-	httpEndpoint             = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP (host:port, empty means disabled)")
-	sequencerIntervalFlag    = flag.Duration("sequencer_interval", time.Second*10, "Time between each sequencing pass through all logs")
-	batchSizeFlag            = flag.Int("batch_size", 50, "Max number of leaves to process per batch")
-	numSeqFlag               = flag.Int("num_sequencers", 10, "Number of sequencer workers to run in parallel")
-	sequencerGuardWindowFlag = flag.Duration("sequencer_guard_window", 0, "If set, the time elapsed before submitted leaves are eligible for sequencing")
-	forceMaster              = flag.Bool("force_master", false, "If true, assume master for all logs")
-	etcdServers              = flag.String("etcd_servers", "", "A comma-separated list of etcd servers")
-	etcdHTTPService          = flag.String("etcd_http_service", "trillian-logsigner-http", "Service name to announce our HTTP endpoint under")
-	lockDir                  = flag.String("lock_file_path", "/test/multimaster", "etcd lock file directory path")
+	storageSystem             = flag.String("storage_system", "mysql", "Storage system to use, one of: "+strings.Join(factory.Systems(), ", "))
+	storageURI                = flag.String("storage_uri", "", "Connection URI for the storage system, empty means the storage system's default")
+	httpEndpoint              = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP (host:port, empty means disabled)")
+	healthRPCEndpoint         = flag.String("health_rpc_endpoint", "", "Endpoint to serve the grpc.health.v1.Health service on (host:port), empty means disabled")
+	healthCheckInterval       = flag.Duration("health_check_interval", 10*time.Second, "Interval between checks of storage and mastership elections for the health service")
+	sequencerIntervalFlag     = flag.Duration("sequencer_interval", time.Second*10, "Time between each sequencing pass through all logs")
+	drainTimeoutFlag          = flag.Duration("drain_timeout", 30*time.Second, "Time the sequencing pass in flight on SIGINT or SIGTERM is given to complete before it's aborted and mastership is resigned")
+	batchSizeFlag             = flag.Int("batch_size", 50, "Max number of leaves to process per batch")
+	numSeqFlag                = flag.Int("num_sequencers", 10, "Number of sequencer workers to run in parallel")
+	hashWorkersFlag           = flag.Int("sequencer_hash_workers", runtime.GOMAXPROCS(0), "Number of goroutines each sequencer uses to hash large batches into the Merkle tree")
+	consistencyCheckFlag      = flag.Bool("sequencer_consistency_check", true, "If true, verify a consistency proof between the previous and the new root before publishing each new root")
+	behindThresholdFlag       = flag.Int("behind_threshold", 3, "Number of consecutive full batches after which a log is considered behind")
+	adaptiveBatchFlag         = flag.Bool("adaptive_batch_size", false, "If true, grow the batch size of logs that are behind up to --max_batch_size")
+	maxBatchSizeFlag          = flag.Int("max_batch_size", 1000, "Max number of leaves to process per batch for logs that are behind, if --adaptive_batch_size is set")
+	unsignableThresholdFlag   = flag.Int("unsignable_threshold", 3, "Number of consecutive passes failing to sign a root after which a log is considered unsignable and backed off")
+	unsignableBackoffFlag     = flag.Duration("unsignable_backoff", time.Minute, "Time an unsignable log is skipped for before it's retried, doubling after each failed retry up to --max_unsignable_backoff")
+	maxUnsignableBackoffFlag  = flag.Duration("max_unsignable_backoff", 15*time.Minute, "Max time an unsignable log is skipped for before it's retried")
+	sequencerGuardWindowFlag  = flag.Duration("sequencer_guard_window", 0, "If set, the time elapsed before submitted leaves are eligible for sequencing")
+	rootTimeSourceFlag        = flag.String("root_time_source", "app", "Clock that new signed roots are timestamped with: app for the clock of this server, or db for the clock of the storage system's database")
+	rootPruneIntervalFlag     = flag.Duration("root_prune_interval", time.Hour, "Time between each pass deleting signed roots of trees with a root retention policy, zero means disabled")
+	dedupPruneIntervalFlag    = flag.Duration("dedup_prune_interval", time.Hour, "Time between each pass forgetting the identity hashes of leaves outside the dedup window of logs with one, zero means disabled")
+	revisionPruneIntervalFlag = flag.Duration("revision_prune_interval", time.Hour, "Time between each pass deleting the leaf and subtree versions of maps only needed to read revisions outside their max_revision_lookback, zero means disabled")
+	drainIntervalFlag         = flag.Duration("drain_interval", time.Minute, "Time between each pass freezing DRAINING trees past their drain deadline, zero means disabled")
+	deletedTreeGCInterval     = flag.Duration("deleted_tree_gc_interval", time.Hour, "Time between each pass hard-deleting trees past --deleted_tree_retention, zero means disabled")
+	deletedTreeRetention      = flag.Duration("deleted_tree_retention", 7*24*time.Hour, "Time a soft-deleted tree can be undeleted for, before its data is permanently deleted")
+	shardIndexFlag            = flag.Int("shard_index", 0, "Index of the shard of logs this signer processes, in [0, --shard_count)")
+	shardCountFlag            = flag.Int("shard_count", 1, "Number of signers that logs are sharded across by a hash of their ID")
+	skipCleanLogsFlag         = flag.Bool("skip_clean_logs", false, "If true, each sequencing pass only processes logs with unsequenced leaves, and logs without any once per --clean_log_interval")
+	cleanLogIntervalFlag      = flag.Duration("clean_log_interval", time.Minute, "Longest time a log without unsequenced leaves goes unprocessed if --skip_clean_logs is set, should be below the MaxRootDuration of the logs")
+	fairBatchCapFlag          = flag.Int("fair_batch_cap", 0, "Max number of leaves each log sequences per round of a pass, so logs with large backlogs don't hold up others; zero means no cap")
+	fairRoundsFlag            = flag.Int("fair_rounds", 1, "Number of round-robin rounds per pass, each sequencing up to --fair_batch_cap leaves of every log that reached it in the previous round")
+	forceMaster               = flag.Bool("force_master", false, "If true, assume master for all logs")
+	etcdServers               = flag.String("etcd_servers", "", "A comma-separated list of etcd servers")
+	etcdHTTPService           = flag.String("etcd_http_service", "trillian-logsigner-http", "Service name to announce our HTTP endpoint under")
+	lockDir                   = flag.String("lock_file_path", "/test/multimaster", "etcd lock file directory path")
+	lockTTL                   = flag.Duration("etcd_lock_ttl", 60*time.Second, "TTL of the etcd leases holding mastership locks, i.e. how long a log's mastership outlives an instance that stops renewing it, rounded up to whole seconds")
 
 	preElectionPause    = flag.Duration("pre_election_pause", 1*time.Second, "Maximum time to wait before starting elections")
 	masterCheckInterval = flag.Duration("master_check_interval", 5*time.Second, "Interval between checking mastership still held")
 	masterHoldInterval  = flag.Duration("master_hold_interval", 60*time.Second, "Minimum interval to hold mastership for")
 	resignOdds          = flag.Int("resign_odds", 10, "Chance of resigning mastership after each check, the N in 1-in-N")
 
-	pkcs11ModulePath = flag.String("pkcs11_module_path", "", "Path to the PKCS#11 module to use for keys that use the PKCS#11 interface")
+	metricsBackend      = flag.String("metrics_backend", "", "Backend to export metrics with: prometheus (served on the HTTP endpoint), otlp, statsd or dogstatsd; empty means otlp if --otlp_endpoint is set, prometheus otherwise")
+	otlpEndpoint        = flag.String("otlp_endpoint", "", "Endpoint to export metrics to via OTLP (host:port), for the otlp metrics backend; needs a binary built with -tags otel")
+	otlpExportInterval  = flag.Duration("otlp_export_interval", time.Minute, "Interval between exports of metrics via OTLP")
+	statsdEndpoint      = flag.String("statsd_endpoint", "localhost:8125", "Endpoint of the collector to send metrics to (host:port), for the statsd and dogstatsd metrics backends")
+	statsdFlush         = flag.Duration("statsd_flush_interval", time.Second, "Max interval between sends of buffered metrics to --statsd_endpoint")
+	traceEndpoint       = flag.String("trace_otlp_endpoint", "", "Endpoint to export trace spans to via OTLP (host:port), e.g. a Jaeger or OpenTelemetry collector; empty means tracing is disabled; needs a binary built with -tags otel")
+	traceSampleFraction = flag.Float64("trace_sample_fraction", 0.01, "Fraction of the traces started by this server that are sampled, if --trace_otlp_endpoint is set; traces started by callers keep their sampling decision")
 
-	configFile = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
+	metricNamespace   = flag.String("metric_namespace", "", "Namespace prepended to the names of Prometheus metrics, e.g. \"trillian\"")
+	metricConstLabels = flag.String("metric_const_labels", "", "Comma-separated name=value labels added to every Prometheus metric, e.g. \"cluster=c1,region=r1\"")
+	metricBuckets     = flag.String("metric_histogram_buckets", "", "Semicolon-separated lists of comma-separated Prometheus histogram bucket bounds, for every histogram or, prefixed with \"<name>=\", for one; empty means the Prometheus defaults")
+
+	pkcs11ModulePath = flag.String("pkcs11_module_path", "", "Path to the PKCS#11 module to use for keys that use the PKCS#11 interface and don't specify their own module")
+
+	signerBreakerFailures = flag.Int("signer_breaker_failures", 0, "Number of consecutive signing failures of a tree after which signing fails fast for --signer_breaker_cooldown, zero means signing never fails fast")
+	signerBreakerCooldown = flag.Duration("signer_breaker_cooldown", 30*time.Second, "Time signing fails fast for once --signer_breaker_failures is reached, before the key backend is probed again")
+
+	signerReconnectAttempts   = flag.Int("signer_reconnect_attempts", 3, "Number of times a signer reconnects to its key backend after losing the connection, before the signing request fails; zero means never")
+	signerReconnectBackoff    = flag.Duration("signer_reconnect_backoff", 100*time.Millisecond, "Initial wait before a signer reconnects to its key backend, doubled for each further attempt up to --signer_max_reconnect_backoff")
+	signerMaxReconnectBackoff = flag.Duration("signer_max_reconnect_backoff", 2*time.Second, "Maximum wait before a signer reconnects to its key backend")
+
+	tsaURL      = flag.String("tsa_url", "", "URL of an RFC 3161 timestamp authority to timestamp signed log roots with, empty means disabled")
+	tsaInterval = flag.Duration("tsa_interval", 10*time.Second, "Time between each pass timestamping new signed log roots, if --tsa_url is set")
+	tsaTimeout  = flag.Duration("tsa_timeout", 10*time.Second, "Timeout of each request to the timestamp authority, if --tsa_url is set")
+
+	configFile   = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags. It's reloaded on SIGHUP, changing -v and --batch_size only")
+	serverConfig = flag.String("server_config", "", "ServerConfig file in proto text format (see server/configpb/config.proto), applied on top of --config; command line flags take precedence")
 )
 
 func main() {
@@ -69,16 +128,54 @@ func main() {
 			glog.Exitf("Failed to load flags from config file %q: %s", *configFile, err)
 		}
 	}
+	if *serverConfig != "" {
+		if err := server.ParseConfigFile(*serverConfig); err != nil {
+			glog.Exitf("Failed to load server config: %v", err)
+		}
+	}
+
+	if *shardCountFlag < 1 || *shardIndexFlag < 0 || *shardIndexFlag >= *shardCountFlag {
+		glog.Exitf("Invalid sharding: --shard_index=%d must be in [0, --shard_count=%d)", *shardIndexFlag, *shardCountFlag)
+	}
 
 	glog.CopyStandardLogTo("WARNING")
 	glog.Info("**** Log Signer Starting ****")
 
-	// First make sure we can access the database, quit if not
-	db, err := mysql.OpenDB(*mySQLURI)
+	pmf, err := prometheus.NewMetricFactory(*metricNamespace, *metricConstLabels, *metricBuckets)
+	if err != nil {
+		glog.Exitf("Invalid Prometheus metric flags: %v", err)
+	}
+	var statsdPrefix string
+	if *metricNamespace != "" {
+		statsdPrefix = *metricNamespace + "."
+	}
+	mf, closeMetrics, err := server.NewMetricFactory(context.Background(), server.MetricsConfig{
+		Backend:             *metricsBackend,
+		Prometheus:          pmf,
+		OTLPEndpoint:        *otlpEndpoint,
+		OTLPExportInterval:  *otlpExportInterval,
+		StatsDEndpoint:      *statsdEndpoint,
+		StatsDFlushInterval: *statsdFlush,
+		StatsDPrefix:        statsdPrefix,
+	})
+	if err != nil {
+		glog.Exitf("Failed to create metric factory: %v", err)
+	}
+	defer closeMetrics()
+	if *traceEndpoint != "" {
+		stopTracing, err := server.StartTracing(context.Background(), *traceEndpoint, "trillian_log_signer", *traceSampleFraction)
+		if err != nil {
+			glog.Exitf("Failed to start tracing: %v", err)
+		}
+		defer stopTracing()
+	}
+
+	// First make sure we can access the storage, quit if not
+	sp, err := factory.NewProvider(*storageSystem, *storageURI, mf)
 	if err != nil {
-		glog.Exitf("Failed to open MySQL database: %v", err)
+		glog.Exitf("Failed to open %v storage: %v", *storageSystem, err)
 	}
-	defer db.Close()
+	defer sp.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	go util.AwaitSignal(cancel)
@@ -90,19 +187,26 @@ func main() {
 		glog.Warning("**** Acting as master for all logs ****")
 		electionFactory = util.NoopElectionFactory{InstanceID: instanceID}
 	} else {
-		electionFactory = etcd.NewElectionFactory(instanceID, *etcdServers, *lockDir)
+		if *lockTTL <= *masterCheckInterval {
+			glog.Warningf("--etcd_lock_ttl (%v) isn't longer than --master_check_interval (%v), another instance may become master before this one notices it no longer is", *lockTTL, *masterCheckInterval)
+		}
+		electionFactory = etcd.NewElectionFactory(instanceID, *etcdServers, *lockDir, *lockTTL, mf)
 	}
 
-	mf := prometheus.MetricFactory{}
-
-	sf := &keys.DefaultSignerFactory{}
-	if *pkcs11ModulePath != "" {
-		sf.SetPKCS11Module(*pkcs11ModulePath)
+	var sf keys.SignerFactory = keys.NewPKCS11SignerFactory(&keys.DefaultSignerFactory{}, *pkcs11ModulePath)
+	if *signerReconnectAttempts > 0 {
+		sf = reconnect.NewSignerFactory(sf, *signerReconnectAttempts, *signerReconnectBackoff, *signerMaxReconnectBackoff, mf)
+	}
+	// The breaker goes outermost, so that only requests that failed despite
+	// reconnecting count towards opening it.
+	if *signerBreakerFailures > 0 {
+		sf = breaker.NewSignerFactory(sf, *signerBreakerFailures, *signerBreakerCooldown, mf)
 	}
 
 	registry := extension.Registry{
-		AdminStorage:    mysql.NewAdminStorage(db),
-		LogStorage:      mysql.NewLogStorage(db, mf),
+		AdminStorage:    sp.AdminStorage(),
+		LogStorage:      sp.LogStorage(),
+		MapStorage:      sp.MapStorage(),
 		SignerFactory:   sf,
 		ElectionFactory: electionFactory,
 		QuotaManager:    quota.Noop(),
@@ -124,22 +228,101 @@ func main() {
 		}
 	}
 
+	if *rootPruneIntervalFlag > 0 {
+		pruner := server.NewRootPruner(registry, util.SystemTimeSource{}, *rootPruneIntervalFlag)
+		go pruner.Run(ctx)
+	}
+
+	if *dedupPruneIntervalFlag > 0 {
+		go server.NewDedupPruner(registry, util.SystemTimeSource{}, *dedupPruneIntervalFlag).Run(ctx)
+	}
+
+	if *revisionPruneIntervalFlag > 0 {
+		go server.NewRevisionPruner(registry, *revisionPruneIntervalFlag).Run(ctx)
+	}
+
+	if *tsaURL != "" {
+		client := &tsa.Client{URL: *tsaURL, HTTPClient: &http.Client{Timeout: *tsaTimeout}}
+		go server.NewRootTimestamper(registry, client, *tsaInterval).Run(ctx)
+	}
+
+	if *drainIntervalFlag > 0 {
+		drainer := server.NewTreeDrainer(registry, util.SystemTimeSource{}, *drainIntervalFlag)
+		go drainer.Run(ctx)
+	}
+
+	if *deletedTreeGCInterval > 0 {
+		gc := server.NewDeletedTreeGC(registry.AdminStorage, util.SystemTimeSource{}, *deletedTreeRetention, *deletedTreeGCInterval, registry.MetricFactory)
+		go gc.Run(ctx)
+	}
+
 	// Start the sequencing loop, which will run until we terminate the process. This controls
 	// both sequencing and signing.
 	// TODO(Martin2112): Should respect read only mode and the flags in tree control etc
 	sequencerManager := server.NewSequencerManager(registry, *sequencerGuardWindowFlag)
 	info := server.LogOperationInfo{
-		Registry:            registry,
-		BatchSize:           *batchSizeFlag,
-		NumWorkers:          *numSeqFlag,
-		RunInterval:         *sequencerIntervalFlag,
-		TimeSource:          util.SystemTimeSource{},
-		PreElectionPause:    *preElectionPause,
-		MasterCheckInterval: *masterCheckInterval,
-		MasterHoldInterval:  *masterHoldInterval,
-		ResignOdds:          *resignOdds,
+		Registry:             registry,
+		BatchSize:            *batchSizeFlag,
+		HashWorkers:          *hashWorkersFlag,
+		CheckConsistency:     *consistencyCheckFlag,
+		BehindThreshold:      *behindThresholdFlag,
+		AdaptiveBatch:        *adaptiveBatchFlag,
+		MaxBatchSize:         *maxBatchSizeFlag,
+		UnsignableThreshold:  *unsignableThresholdFlag,
+		UnsignableBackoff:    *unsignableBackoffFlag,
+		MaxUnsignableBackoff: *maxUnsignableBackoffFlag,
+		NumWorkers:           *numSeqFlag,
+		RunInterval:          *sequencerIntervalFlag,
+		TimeSource:           util.SystemTimeSource{},
+		PreElectionPause:     *preElectionPause,
+		MasterCheckInterval:  *masterCheckInterval,
+		MasterHoldInterval:   *masterHoldInterval,
+		DrainTimeout:         *drainTimeoutFlag,
+		ResignOdds:           *resignOdds,
+		ShardCount:           *shardCountFlag,
+		ShardIndex:           *shardIndexFlag,
+		SkipCleanLogs:        *skipCleanLogsFlag,
+		CleanLogInterval:     *cleanLogIntervalFlag,
+		FairBatchCap:         *fairBatchCapFlag,
+		FairRounds:           *fairRoundsFlag,
+	}
+	switch *rootTimeSourceFlag {
+	case "app":
+	case "db":
+		tsp, ok := sp.(factory.TimeSourceProvider)
+		if !ok {
+			glog.Exitf("--root_time_source=db isn't supported by %v storage", *storageSystem)
+		}
+		info.RootTimeSource = tsp.TimeSource(info.TimeSource)
+	default:
+		glog.Exitf("Unknown --root_time_source %q, want app or db", *rootTimeSourceFlag)
 	}
 	sequencerTask := server.NewLogOperationManager(info, sequencerManager)
+	if *configFile != "" {
+		reloader, err := cmd.NewFlagFileReloader(*configFile,
+			cmd.Reloadable{Name: "v"},
+			cmd.Reloadable{
+				Name: "batch_size",
+				Check: func(value string) error {
+					if n, err := strconv.Atoi(value); err == nil && n < 1 {
+						return errors.New("must be positive")
+					}
+					return nil
+				},
+				Apply: func() { sequencerTask.SetBatchSize(*batchSizeFlag) },
+			})
+		if err != nil {
+			glog.Exitf("Failed to set up reloading of config file %q: %v", *configFile, err)
+		}
+		go reloader.Run(ctx)
+	}
+
+	if *healthRPCEndpoint != "" {
+		serveHealth(ctx, *healthRPCEndpoint, *healthCheckInterval,
+			server.HealthCheck{Name: server.StorageHealthCheck, Check: registry.LogStorage.CheckDatabaseAccessible},
+			server.HealthCheck{Name: server.ElectionHealthCheck, Check: func(context.Context) error { return sequencerTask.Healthy() }})
+	}
+
 	sequencerTask.OperationLoop(ctx)
 
 	// Give things a few seconds to tidy up
@@ -147,3 +330,22 @@ func main() {
 	glog.Flush()
 	time.Sleep(time.Second * 5)
 }
+
+// serveHealth serves the gRPC health service on endpoint until ctx is done. The signer has no
+// other RPC services, so only the status of the signer as a whole and of its checks is reported.
+func serveHealth(ctx context.Context, endpoint string, interval time.Duration, checks ...server.HealthCheck) {
+	lis, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		glog.Exitf("Failed to listen for health checks on %v: %v", endpoint, err)
+	}
+	s := grpc.NewServer()
+	hc := server.NewHealthChecker(nil, interval, checks...)
+	hc.Register(s)
+	go hc.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	glog.Infof("Health RPC server starting on %v", endpoint)
+	go s.Serve(lis)
+}
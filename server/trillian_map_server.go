@@ -15,13 +15,17 @@
 package server
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/maps"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/trees"
 
@@ -34,14 +38,33 @@ import (
 // TODO(codingllama): There is no access control in the server yet and clients could easily modify
 // any tree.
 
+// instrumentation identifies the map server's instrumentation to the tracer.
+const instrumentation = "github.com/google/trillian/server"
+
+// DefaultStreamBatchSize is the number of leaves SetLeavesStream applies to
+// the map at a time, unless set otherwise with SetStreamBatchSize.
+const DefaultStreamBatchSize = 1024
+
+// maxRevisionRangeLeaves is the maximum number of leaves a GetLeavesByRevisions
+// request may ask for over a range of revisions.
+const maxRevisionRangeLeaves = 10000
+
 // TrillianMapServer implements the RPC API defined in the proto
 type TrillianMapServer struct {
-	registry extension.Registry
+	registry        extension.Registry
+	streamBatchSize int
 }
 
 // NewTrillianMapServer creates a new RPC server backed by registry
 func NewTrillianMapServer(registry extension.Registry) *TrillianMapServer {
-	return &TrillianMapServer{registry}
+	return &TrillianMapServer{registry: registry}
+}
+
+// SetStreamBatchSize sets the maximum number of leaves SetLeavesStream holds
+// in memory before applying them to the map. Zero or less means
+// DefaultStreamBatchSize.
+func (t *TrillianMapServer) SetStreamBatchSize(n int) {
+	t.streamBatchSize = n
 }
 
 // IsHealthy returns nil if the server is healthy, error otherwise.
@@ -76,6 +99,15 @@ func (t *TrillianMapServer) GetLeaves(ctx context.Context, req *trillian.GetMapL
 		}
 		root = &r
 	} else {
+		if tree.MaxRevisionLookback > 0 {
+			latest, err := tx.LatestSignedMapRoot(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkRevisionLookback(tree, req.Revision, latest.MapRevision); err != nil {
+				return nil, err
+			}
+		}
 		r, err := tx.GetSignedMapRoot(ctx, req.Revision)
 		if err != nil {
 			return nil, err
@@ -103,11 +135,7 @@ func (t *TrillianMapServer) GetLeaves(ctx context.Context, req *trillian.GetMapL
 			leaf = &leaves[0]
 			found++
 		} else {
-			// Empty leaf for proof of non-existence.
-			leaf = &trillian.MapLeaf{
-				Index:     index,
-				LeafValue: nil,
-			}
+			leaf = emptyMapLeaf(tree, hasher, index)
 		}
 
 		// Fetch the proof regardless of whether the leaf exists.
@@ -133,6 +161,203 @@ func (t *TrillianMapServer) GetLeaves(ctx context.Context, req *trillian.GetMapL
 	}, nil
 }
 
+// GetLeavesByRevisions implements the GetLeavesByRevisions RPC method. Each
+// requested leaf is returned with an inclusion proof against the signed map root
+// at its revision. Leaves at the same revision are read together; as subtree
+// caches are not revision-aware, each distinct revision uses its own snapshot.
+func (t *TrillianMapServer) GetLeavesByRevisions(ctx context.Context, req *trillian.GetMapLeavesByRevisionsRequest) (*trillian.GetMapLeavesByRevisionsResponse, error) {
+	mapID := req.MapId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, mapID, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	for _, l := range req.Leaves {
+		if got, want := len(l.Index), hasher.Size(); got != want {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"index len(%x): %v, want %v", l.Index, got, want)
+		}
+	}
+	for _, index := range req.Index {
+		if got, want := len(index), hasher.Size(); got != want {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"index len(%x): %v, want %v", index, got, want)
+		}
+	}
+
+	latest, err := t.latestMapRevision(ctx, mapID)
+	if err != nil {
+		return nil, err
+	}
+
+	requested, err := expandRevisionRange(req, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*trillian.MapLeafRevisionInclusion, len(requested))
+	// Positions in requested of the leaves requested at each revision, the
+	// revisions in the order they were first seen.
+	byRevision := make(map[int64][]int)
+	var revisions []int64
+	for i, l := range requested {
+		if l.Revision < 0 || l.Revision > latest {
+			results[i] = &trillian.MapLeafRevisionInclusion{
+				Status:   status.Newf(codes.OutOfRange, "revision %v not in [0, %v]", l.Revision, latest).Proto(),
+				Index:    l.Index,
+				Revision: l.Revision,
+			}
+			continue
+		}
+		if err := checkRevisionLookback(tree, l.Revision, latest); err != nil {
+			s, _ := status.FromError(err)
+			results[i] = &trillian.MapLeafRevisionInclusion{
+				Status:   s.Proto(),
+				Index:    l.Index,
+				Revision: l.Revision,
+			}
+			continue
+		}
+		if _, ok := byRevision[l.Revision]; !ok {
+			revisions = append(revisions, l.Revision)
+		}
+		byRevision[l.Revision] = append(byRevision[l.Revision], i)
+	}
+
+	for _, rev := range revisions {
+		positions := byRevision[rev]
+		indices := make([][]byte, 0, len(positions))
+		for _, i := range positions {
+			indices = append(indices, requested[i].Index)
+		}
+		inclusions, root, err := t.getLeavesAtRevision(ctx, tree, hasher, rev, indices)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range positions {
+			results[i] = &trillian.MapLeafRevisionInclusion{
+				Status:           status.New(codes.OK, "").Proto(),
+				Index:            requested[i].Index,
+				Revision:         rev,
+				MapLeafInclusion: inclusions[j],
+				MapRoot:          root,
+			}
+		}
+	}
+
+	return &trillian.GetMapLeavesByRevisionsResponse{Results: results}, nil
+}
+
+// expandRevisionRange returns the leaves requested by req: its leaves, followed by each of its
+// indices at every revision of its range, in revision order. latest is the newest revision of
+// the map, which caps the range.
+func expandRevisionRange(req *trillian.GetMapLeavesByRevisionsRequest, latest int64) ([]*trillian.MapLeafAtRevision, error) {
+	if len(req.Index) == 0 {
+		return req.Leaves, nil
+	}
+	start, end := req.StartRevision, req.EndRevision
+	if end < 0 || end > latest {
+		end = latest
+	}
+	if start < 0 || (req.EndRevision >= 0 && start > req.EndRevision) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid revision range [%v, %v]", req.StartRevision, req.EndRevision)
+	}
+	if start > latest {
+		return nil, status.Errorf(codes.OutOfRange, "start_revision %v after latest revision %v", start, latest)
+	}
+	if n := int64(len(req.Index)) * (end - start + 1); n > maxRevisionRangeLeaves {
+		return nil, status.Errorf(codes.InvalidArgument, "%v indices over revisions [%v, %v] request %v leaves, want <= %v", len(req.Index), start, end, n, maxRevisionRangeLeaves)
+	}
+
+	leaves := make([]*trillian.MapLeafAtRevision, 0, len(req.Leaves)+len(req.Index)*int(end-start+1))
+	leaves = append(leaves, req.Leaves...)
+	for _, index := range req.Index {
+		for rev := start; rev <= end; rev++ {
+			leaves = append(leaves, &trillian.MapLeafAtRevision{Index: index, Revision: rev})
+		}
+	}
+	return leaves, nil
+}
+
+// latestMapRevision returns the revision of the newest signed root of the map.
+func (t *TrillianMapServer) latestMapRevision(ctx context.Context, mapID int64) (int64, error) {
+	tx, err := t.registry.MapStorage.SnapshotForTree(ctx, mapID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	root, err := tx.LatestSignedMapRoot(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return root.MapRevision, nil
+}
+
+// checkRevisionLookback returns an OutOfRange error if rev is older than the
+// oldest revision tree guarantees to serve, given that latest is its newest
+// revision. A MaxRevisionLookback of zero means all revisions are served.
+func checkRevisionLookback(tree *trillian.Tree, rev, latest int64) error {
+	if tree.MaxRevisionLookback <= 0 {
+		return nil
+	}
+	if oldest := latest - tree.MaxRevisionLookback; rev < oldest {
+		return status.Errorf(codes.OutOfRange, "revision %v older than oldest served revision %v", rev, oldest)
+	}
+	return nil
+}
+
+// getLeavesAtRevision returns an inclusion proof for each of indices, in order,
+// against the signed map root at rev, which is also returned.
+func (t *TrillianMapServer) getLeavesAtRevision(ctx context.Context, tree *trillian.Tree, hasher hashers.MapHasher, rev int64, indices [][]byte) ([]*trillian.MapLeafInclusion, *trillian.SignedMapRoot, error) {
+	tx, err := t.registry.MapStorage.SnapshotForTree(ctx, tree.TreeId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Close()
+
+	root, err := tx.GetSignedMapRoot(ctx, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaves, err := tx.Get(ctx, root.MapRevision, indices)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := make(map[string]*trillian.MapLeaf)
+	for i := range leaves {
+		found[string(leaves[i].Index)] = &leaves[i]
+	}
+
+	smtReader := merkle.NewSparseMerkleTreeReader(root.MapRevision, hasher, tx)
+	inclusions := make([]*trillian.MapLeafInclusion, 0, len(indices))
+	for _, index := range indices {
+		leaf, ok := found[string(index)]
+		if !ok {
+			leaf = emptyMapLeaf(tree, hasher, index)
+		}
+		proof, err := smtReader.InclusionProof(ctx, root.MapRevision, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		inclusions = append(inclusions, &trillian.MapLeafInclusion{
+			Leaf:      leaf,
+			Inclusion: proof,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return inclusions, &root, nil
+}
+
 // SetLeaves implements the SetLeaves RPC method.
 func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapLeavesRequest) (*trillian.SetMapLeavesResponse, error) {
 	mapID := req.MapId
@@ -142,6 +367,9 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 		return nil, err
 	}
 	ctx = trees.NewContext(ctx, tree)
+	if err := validateMapLeaves(ctx, tree, req.Leaves, 0); err != nil {
+		return nil, err
+	}
 
 	tx, err := t.registry.MapStorage.BeginForTree(ctx, req.MapId)
 	if err != nil {
@@ -150,24 +378,270 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 	defer tx.Close()
 
 	glog.V(2).Infof("%v: Writing at revision %v", mapID, tx.WriteRevision())
+	rootHash, err := t.setLeafBatch(ctx, tree, hasher, tx, req.Leaves)
+	if err != nil {
+		return nil, err
+	}
+	newRoot, err := t.storeMapRoot(ctx, tree, tx, rootHash, req.MapperData)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%v: Commit failed for SetLeaves: %v", mapID, err)
+		return nil, err
+	}
+
+	return &trillian.SetMapLeavesResponse{
+		MapRoot: newRoot,
+	}, nil
+}
+
+// SetLeavesStream implements the SetLeavesStream RPC method. Leaves are
+// applied in batches of at most streamBatchSize as they're received, each
+// batch with its own sparse Merkle tree writer at the same write revision.
+// Every batch builds on the nodes written by the batches before it, so the
+// root of the final batch is the root of the map with all of the leaves set,
+// and is the same as if they had been sent in a single SetLeaves request.
+// The stream interceptor checks the first request like SetLeaves requests, so
+// later requests must address the same map.
+func (t *TrillianMapServer) SetLeavesStream(stream trillian.TrillianMap_SetLeavesStreamServer) error {
+	req, err := stream.Recv()
+	if err == io.EOF {
+		return status.Errorf(codes.InvalidArgument, "SetLeavesStream received no requests")
+	}
+	if err != nil {
+		return err
+	}
+	// The context of the stream carries the checks of the first request.
+	ctx := stream.Context()
+	mapID := req.MapId
+	mapperData := req.MapperData
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, mapID, false /* readonly */)
+	if err != nil {
+		return err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.registry.MapStorage.BeginForTree(ctx, mapID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	glog.V(2).Infof("%v: Streaming writes at revision %v", mapID, tx.WriteRevision())
+	batchSize := t.streamBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultStreamBatchSize
+	}
+	batch := make([]*trillian.MapLeaf, 0, batchSize)
+	var rootHash []byte
+	received := 0
+	for {
+		// Nothing is committed until the stream ends, so an invalid leaf rejects the
+		// leaves already written too.
+		if err := validateMapLeaves(ctx, tree, req.Leaves, received); err != nil {
+			return err
+		}
+		received += len(req.Leaves)
+		for _, l := range req.Leaves {
+			batch = append(batch, l)
+			if len(batch) < batchSize {
+				continue
+			}
+			if rootHash, err = t.setLeafBatch(ctx, tree, hasher, tx, batch); err != nil {
+				return err
+			}
+			// Drop the references to the leaves just written, so they can be
+			// collected before the next batch is read.
+			for i := range batch {
+				batch[i] = nil
+			}
+			batch = batch[:0]
+		}
+
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.MapId != 0 && req.MapId != mapID {
+			return status.Errorf(codes.InvalidArgument, "SetLeavesStream request for map %v in stream for map %v", req.MapId, mapID)
+		}
+	}
+	// Flush what's left. A stream with no leaves at all is treated like a
+	// SetLeaves request with no leaves.
+	if len(batch) > 0 || rootHash == nil {
+		if rootHash, err = t.setLeafBatch(ctx, tree, hasher, tx, batch); err != nil {
+			return err
+		}
+	}
+
+	newRoot, err := t.storeMapRoot(ctx, tree, tx, rootHash, mapperData)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%v: Commit failed for SetLeavesStream: %v", mapID, err)
+		return err
+	}
+
+	return stream.SendAndClose(&trillian.SetMapLeavesResponse{
+		MapRoot: newRoot,
+	})
+}
+
+// QueueLeaves implements the QueueLeaves RPC method. Leaves are checked as
+// they would be by SetLeaves, so a queued leaf can't later fail the batch it's
+// sequenced in.
+func (t *TrillianMapServer) QueueLeaves(ctx context.Context, req *trillian.QueueMapLeavesRequest) (*trillian.QueueMapLeavesResponse, error) {
+	mapID := req.MapId
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, mapID, false /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	if err := validateMapLeaves(ctx, tree, req.Leaves, 0); err != nil {
+		return nil, err
+	}
+	for _, l := range req.Leaves {
+		if got, want := len(l.Index), hasher.Size(); got != want {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"len(%x): %v, want %v", l.Index, got, want)
+		}
+		if _, err := mapLeafHash(tree, hasher, l); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := t.registry.MapStorage.BeginForTree(ctx, mapID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	if err := tx.QueueMapLeaves(ctx, req.Leaves, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%v: Commit failed for QueueLeaves: %v", mapID, err)
+		return nil, err
+	}
+	return &trillian.QueueMapLeavesResponse{}, nil
+}
+
+// SequenceQueuedLeaves sets up to limit of the oldest leaves queued for the map
+// in a new revision, and returns the number of leaves dequeued. Where a leaf
+// was queued more than once the last one wins. No revision is written if the
+// queue is empty. The new root keeps the mapper metadata of the previous one.
+func (t *TrillianMapServer) SequenceQueuedLeaves(ctx context.Context, mapID int64, limit int) (int, error) {
+	ctx, span := monitoring.StartSpan(ctx, instrumentation, "TrillianMapServer.SequenceQueuedLeaves")
+	defer span.End()
+	span.SetAttribute("trillian.tree_id", mapID)
+	n, err := t.sequenceQueuedLeaves(ctx, mapID, limit)
+	span.SetAttribute("trillian.leaves_sequenced", n)
+	if err != nil {
+		span.SetError(err.Error())
+	}
+	return n, err
+}
+
+func (t *TrillianMapServer) sequenceQueuedLeaves(ctx context.Context, mapID int64, limit int) (int, error) {
+	tree, hasher, err := t.getTreeAndHasher(ctx, mapID, false /* readonly */)
+	if err != nil {
+		return 0, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.registry.MapStorage.BeginForTree(ctx, mapID)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+
+	queued, err := tx.DequeueMapLeaves(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(queued) == 0 {
+		return 0, tx.Commit()
+	}
+
+	latest, err := tx.LatestSignedMapRoot(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Each index can only be set once per revision.
+	last := make(map[string]int)
+	for i, l := range queued {
+		last[string(l.Index)] = i
+	}
+	leaves := make([]*trillian.MapLeaf, 0, len(last))
+	for i, l := range queued {
+		if last[string(l.Index)] == i {
+			leaves = append(leaves, l)
+		}
+	}
+
+	glog.V(2).Infof("%v: Sequencing %v queued leaves at revision %v", mapID, len(leaves), tx.WriteRevision())
+	rootHash, err := t.setLeafBatch(ctx, tree, hasher, tx, leaves)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := t.storeMapRoot(ctx, tree, tx, rootHash, latest.Metadata); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		glog.Warningf("%v: Commit failed for SequenceQueuedLeaves: %v", mapID, err)
+		return 0, err
+	}
+	return len(queued), nil
+}
+
+// validateMapLeaves checks leaves with the ValueValidator registered for the tree's type, if
+// any. Leaves are numbered from first in errors, so that the leaves of a stream are numbered
+// across its requests.
+func validateMapLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.MapLeaf, first int) error {
+	validator := maps.GetValueValidator(tree.TreeType)
+	if validator == nil {
+		return nil
+	}
+	for i, leaf := range leaves {
+		if err := validator.ValidateValue(ctx, tree, leaf); err != nil {
+			return status.Errorf(codes.InvalidArgument, "leaf %d (index %x) is invalid: %v", first+i, leaf.Index, err)
+		}
+	}
+	return nil
+}
+
+// setLeafBatch writes leaves to tx, and sets them in the sparse Merkle tree
+// at tx's write revision. It returns the root hash of the tree with the
+// leaves set.
+func (t *TrillianMapServer) setLeafBatch(ctx context.Context, tree *trillian.Tree, hasher hashers.MapHasher, tx storage.MapTreeTX, leaves []*trillian.MapLeaf) ([]byte, error) {
 	smtWriter, err := merkle.NewSparseMerkleTreeWriter(
 		ctx,
-		req.MapId,
+		tree.TreeId,
 		tx.WriteRevision(),
 		hasher, func() (storage.TreeTX, error) {
-			return t.registry.MapStorage.BeginForTree(ctx, req.MapId)
+			return t.registry.MapStorage.BeginForTree(ctx, tree.TreeId)
 		})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, l := range req.Leaves {
+	for _, l := range leaves {
 		if got, want := len(l.Index), hasher.Size(); got != want {
 			return nil, status.Errorf(codes.InvalidArgument,
 				"len(%x): %v, want %v", l.Index, got, want)
 		}
-		// TODO(gbelvin) use LeafHash rather than computing here. #423
-		l.LeafHash = hasher.HashLeaf(mapID, l.Index, hasher.BitLen(), l.LeafValue)
+		if l.LeafHash, err = mapLeafHash(tree, hasher, l); err != nil {
+			return nil, err
+		}
 
 		if err = tx.Set(ctx, l.Index, *l); err != nil {
 			return nil, err
@@ -186,12 +660,18 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 	if err != nil {
 		return nil, fmt.Errorf("CalculateRoot(): %v", err)
 	}
+	return rootHash, nil
+}
+
+// storeMapRoot signs rootHash as the root of tree at tx's write revision, and
+// stores the signed root in tx.
+func (t *TrillianMapServer) storeMapRoot(ctx context.Context, tree *trillian.Tree, tx storage.MapTreeTX, rootHash []byte, mapperData *trillian.MapperMetadata) (*trillian.SignedMapRoot, error) {
 	newRoot := trillian.SignedMapRoot{
 		TimestampNanos: time.Now().UnixNano(),
 		RootHash:       rootHash,
-		MapId:          req.MapId,
+		MapId:          tree.TreeId,
 		MapRevision:    tx.WriteRevision(),
-		Metadata:       req.MapperData,
+		Metadata:       mapperData,
 	}
 	// Sign the root.
 	signer, err := trees.Signer(ctx, t.registry.SignerFactory, tree)
@@ -208,15 +688,7 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 	if err = tx.StoreSignedMapRoot(ctx, newRoot); err != nil {
 		return nil, err
 	}
-
-	if err := tx.Commit(); err != nil {
-		glog.Warningf("%v: Commit failed for SetLeaves: %v", mapID, err)
-		return nil, err
-	}
-
-	return &trillian.SetMapLeavesResponse{
-		MapRoot: &newRoot,
-	}, nil
+	return &newRoot, nil
 }
 
 // GetSignedMapRoot implements the GetSignedMapRoot RPC method.
@@ -245,12 +717,28 @@ func (t *TrillianMapServer) GetSignedMapRoot(ctx context.Context, req *trillian.
 // GetSignedMapRootByRevision implements the GetSignedMapRootByRevision RPC
 // method.
 func (t *TrillianMapServer) GetSignedMapRootByRevision(ctx context.Context, req *trillian.GetSignedMapRootByRevisionRequest) (*trillian.GetSignedMapRootResponse, error) {
+	tree, _, err := t.getTreeAndHasher(ctx, req.MapId, true /* readonly */)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
 	tx, err := t.registry.MapStorage.SnapshotForTree(ctx, req.MapId)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Close()
 
+	if tree.MaxRevisionLookback > 0 {
+		latest, err := tx.LatestSignedMapRoot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRevisionLookback(tree, req.Revision, latest.MapRevision); err != nil {
+			return nil, err
+		}
+	}
+
 	r, err := tx.GetSignedMapRoot(ctx, req.Revision)
 	if err != nil {
 		return nil, err
@@ -281,3 +769,39 @@ func (t *TrillianMapServer) getTreeAndHasher(ctx context.Context, treeID int64,
 	}
 	return tree, th, nil
 }
+
+// emptyMapLeaf returns the leaf used in proofs of non-existence of index.
+func emptyMapLeaf(tree *trillian.Tree, hasher hashers.MapHasher, index []byte) *trillian.MapLeaf {
+	leaf := &trillian.MapLeaf{
+		Index:     index,
+		LeafValue: nil,
+	}
+	// Clients of client-hashed maps verify proofs using the returned leaf hash,
+	// so give them the one the tree uses for empty leaves.
+	if tree.MapLeafHashing == trillian.MapLeafHashing_CLIENT_HASHED_LEAVES {
+		leaf.LeafHash = hasher.HashEmpty(tree.TreeId, index, 0)
+	}
+	return leaf
+}
+
+// mapLeafHash returns the hash that leaf is stored under, according to the leaf hashing mode
+// of tree. Client-provided hashes are used verbatim for maps with CLIENT_HASHED_LEAVES, for
+// other maps the hash is computed from the leaf value and must match leaf.LeafHash, if set.
+func mapLeafHash(tree *trillian.Tree, hasher hashers.MapHasher, leaf *trillian.MapLeaf) ([]byte, error) {
+	switch tree.MapLeafHashing {
+	case trillian.MapLeafHashing_SERVER_HASHED_LEAVES:
+		leafHash := hasher.HashLeaf(tree.TreeId, leaf.Index, hasher.BitLen(), leaf.LeafValue)
+		if len(leaf.LeafHash) > 0 && !bytes.Equal(leaf.LeafHash, leafHash) {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"leaf_hash of %x doesn't match its leaf_value, and map %v doesn't accept client-hashed leaves", leaf.Index, tree.TreeId)
+		}
+		return leafHash, nil
+	case trillian.MapLeafHashing_CLIENT_HASHED_LEAVES:
+		if got, want := len(leaf.LeafHash), hasher.Size(); got != want {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"leaf_hash len(%x): %v, want %v", leaf.Index, got, want)
+		}
+		return leaf.LeafHash, nil
+	}
+	return nil, status.Errorf(codes.FailedPrecondition, "unknown map_leaf_hashing for map %v: %v", tree.TreeId, tree.MapLeafHashing)
+}
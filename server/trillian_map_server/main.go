@@ -15,36 +15,95 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
+	"fmt"
 	_ "net/http/pprof"
+	"os"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"              // Load MySQL driver
 	_ "github.com/google/trillian/merkle/coniks"    // Make hashers available
 	_ "github.com/google/trillian/merkle/maphasher" // Make hashers available
 
 	"github.com/golang/glog"
 	"github.com/google/trillian"
+	"github.com/google/trillian/audit"
 	"github.com/google/trillian/cmd"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/monitoring/prometheus"
-	mysqlq "github.com/google/trillian/quota/mysql"
+	"github.com/google/trillian/quota/dynamic"
 	"github.com/google/trillian/server"
 	"github.com/google/trillian/server/interceptor"
-	"github.com/google/trillian/storage/mysql"
+	"github.com/google/trillian/storage/factory"
+	_ "github.com/google/trillian/storage/mysql" // Load MySQL storage
 	"github.com/google/trillian/util"
+	"github.com/google/trillian/util/etcd"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
-	mySQLURI           = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
-	rpcEndpoint        = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
-	httpEndpoint       = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics and REST requests on (host:port, empty means disabled)")
-	maxUnsequencedRows = flag.Int("max_unsequenced_rows", mysqlq.DefaultMaxUnsequenced, "Max number of unsequenced rows before rate limiting kicks in")
+	storageSystem       = flag.String("storage_system", "mysql", "Storage system to use, one of: "+strings.Join(factory.Systems(), ", "))
+	storageURI          = flag.String("storage_uri", "", "Connection URI for the storage system, empty means the storage system's default")
+	rpcEndpoint         = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
+	listenUnixSocket    = flag.String("listen_unix_socket", "", "Path of a Unix domain socket to serve RPC requests on instead of --rpc_endpoint; HTTP is still served on --http_endpoint")
+	httpEndpoint        = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics and REST requests on (host:port, empty means disabled)")
+	singlePort          = flag.Bool("single_port", false, "Serve HTTP metrics and REST requests on --rpc_endpoint alongside RPCs, instead of on --http_endpoint")
+	tlsCertFile         = flag.String("tls_cert_file", "", "PEM file of the certificate to serve RPCs over TLS with; empty means RPCs aren't encrypted")
+	tlsKeyFile          = flag.String("tls_key_file", "", "PEM file of the private key of --tls_cert_file")
+	tlsClientCAFile     = flag.String("tls_client_ca_file", "", "PEM bundle of CAs, one of which must have issued the certificate RPC clients present (mutual TLS); empty means clients aren't authenticated")
+	tlsMinVersion       = flag.String("tls_min_version", "1.2", "Minimum TLS version accepted for RPCs if --tls_cert_file is set: 1.0, 1.1 or 1.2")
+	authTokenFile       = flag.String("auth_token_file", "", "File of principals and their bearer tokens, one \"<principal> <token>\" pair per line, to authenticate RPCs with")
+	authClientCerts     = flag.Bool("auth_client_certs", false, "If true, authenticate RPCs by the subject common name of their TLS client certificate (requires --tls_client_ca_file)")
+	authAdmins          = flag.String("auth_admins", "", "Comma-separated principals allowed to make admin RPCs, and any RPC regardless of tree access policies; only used if RPCs are authenticated")
+	authOpenTrees       = flag.Bool("auth_open_trees", false, "If true, any authenticated principal may read and write trees without an access policy; otherwise only admins may")
+	enableRESTGateway   = flag.Bool("enable_rest_gateway", true, "If false, don't serve REST requests, only metrics, on the HTTP endpoint")
+	maxActiveTrees      = flag.Int64("max_active_trees", 0, "Max number of trees that aren't deleted, beyond which CreateTree is rejected; zero means no limit")
+	healthCheckInterval = flag.Duration("health_check_interval", 10*time.Second, "Interval between checks of storage for the grpc.health.v1.Health service; zero means the health service isn't served")
+	drainTimeout        = flag.Duration("drain_timeout", 30*time.Second, "Time RPCs in flight on SIGINT or SIGTERM are given to complete before they're aborted")
+	drainDelay          = flag.Duration("drain_delay", 5*time.Second, "Time the health service reports NOT_SERVING on SIGINT or SIGTERM before RPCs are drained, for load balancers to stop sending RPCs")
+	quotaFailOpen       = flag.Bool("quota_fail_open", false, "If true, let requests through without quota when the quota manager fails, rather than rejecting them")
+	quotaConfigRefresh  = flag.Duration("quota_config_refresh_interval", time.Minute, "Interval between reloads of the quota configs set through the admin API, which limit requests on top of the storage system's quota; zero ignores quota configs")
+	auditSink           = flag.String("audit_sink", "", "Destination of the audit log of admin changes to trees: file:<path> or syslog:<tag>; empty means no audit log")
+	auditMaxFileBytes   = flag.Int64("audit_max_file_bytes", 100<<20, "Size at which a file audit log is rotated, zero means never")
+	auditFailClosed     = flag.Bool("audit_fail_closed", false, "If true, refuse admin changes that can't be written to the audit log")
+	verifyNullHashes    = flag.Bool("verify_null_hashes", true, "If true, check the empty-branch hashes of the map hashers against known answers at startup, and exit if they differ")
+	streamBatchSize     = flag.Int("set_leaves_stream_batch_size", server.DefaultStreamBatchSize, "Max number of leaves SetLeavesStream holds in memory before applying them to the map")
 
-	configFile = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
+	sequencerInterval  = flag.Duration("map_sequencer_interval", 0, "Interval between sets of the leaves queued by QueueLeaves, zero means queued leaves aren't set; needs --etcd_servers or --force_master")
+	sequencerBatchSize = flag.Int("map_sequencer_batch_size", 1000, "Max number of queued leaves set in each new map revision by the map sequencer")
+	forceMaster        = flag.Bool("force_master", false, "If true, assume master for all maps when setting queued leaves; only for a single map server with --map_sequencer_interval set")
+	etcdServers        = flag.String("etcd_servers", "", "A comma-separated list of etcd servers, for the mastership elections deciding which map server sets the queued leaves of each map")
+	lockDir            = flag.String("lock_file_path", "/test/multimaster", "etcd lock file directory path")
+	lockTTL            = flag.Duration("etcd_lock_ttl", 60*time.Second, "TTL of the etcd leases holding mastership locks, i.e. how long a map's mastership outlives an instance that stops renewing it, rounded up to whole seconds")
+
+	preElectionPause    = flag.Duration("pre_election_pause", 1*time.Second, "Maximum time to wait before starting elections")
+	masterCheckInterval = flag.Duration("master_check_interval", 5*time.Second, "Interval between checking mastership still held")
+	masterHoldInterval  = flag.Duration("master_hold_interval", 60*time.Second, "Minimum interval to hold mastership for")
+	resignOdds          = flag.Int("resign_odds", 10, "Chance of resigning mastership after each check, the N in 1-in-N")
+
+	metricsBackend      = flag.String("metrics_backend", "", "Backend to export metrics with: prometheus (served on the HTTP endpoint), otlp, statsd or dogstatsd; empty means otlp if --otlp_endpoint is set, prometheus otherwise")
+	otlpEndpoint        = flag.String("otlp_endpoint", "", "Endpoint to export metrics to via OTLP (host:port), for the otlp metrics backend; needs a binary built with -tags otel")
+	otlpExportInterval  = flag.Duration("otlp_export_interval", time.Minute, "Interval between exports of metrics via OTLP")
+	statsdEndpoint      = flag.String("statsd_endpoint", "localhost:8125", "Endpoint of the collector to send metrics to (host:port), for the statsd and dogstatsd metrics backends")
+	statsdFlush         = flag.Duration("statsd_flush_interval", time.Second, "Max interval between sends of buffered metrics to --statsd_endpoint")
+	traceEndpoint       = flag.String("trace_otlp_endpoint", "", "Endpoint to export trace spans to via OTLP (host:port), e.g. a Jaeger or OpenTelemetry collector; empty means tracing is disabled; needs a binary built with -tags otel")
+	traceSampleFraction = flag.Float64("trace_sample_fraction", 0.01, "Fraction of the traces started by this server that are sampled, if --trace_otlp_endpoint is set; traces started by callers keep their sampling decision")
+
+	metricNamespace   = flag.String("metric_namespace", "", "Namespace prepended to the names of Prometheus metrics, e.g. \"trillian\"")
+	metricConstLabels = flag.String("metric_const_labels", "", "Comma-separated name=value labels added to every Prometheus metric, e.g. \"cluster=c1,region=r1\"")
+	metricBuckets     = flag.String("metric_histogram_buckets", "", "Semicolon-separated lists of comma-separated Prometheus histogram bucket bounds, for every histogram or, prefixed with \"<name>=\", for one; empty means the Prometheus defaults")
+
+	handlerDeadlineFraction = flag.Float64("handler_deadline_fraction", 0, "Fraction of the remaining RPC deadline that RPC handlers, including their storage operations, are run under, in (0, 1]; zero means all of it")
+	maxHandlerDeadline      = flag.Duration("max_handler_deadline", 0, "Maximum deadline RPC handlers, including their storage operations, are run under; zero means no maximum")
+
+	configFile   = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags. It's reloaded on SIGHUP, changing -v only")
+	serverConfig = flag.String("server_config", "", "ServerConfig file in proto text format (see server/configpb/config.proto), applied on top of --config; command line flags take precedence")
 )
 
 func main() {
@@ -55,50 +114,221 @@ func main() {
 			glog.Exitf("Failed to load flags from config file %q: %s", *configFile, err)
 		}
 	}
+	if *serverConfig != "" {
+		if err := server.ParseConfigFile(*serverConfig); err != nil {
+			glog.Exitf("Failed to load server config: %v", err)
+		}
+	}
+	if *handlerDeadlineFraction < 0 || *handlerDeadlineFraction > 1 {
+		glog.Exitf("--handler_deadline_fraction must be in [0, 1], got %v", *handlerDeadlineFraction)
+	}
+	if *sequencerInterval > 0 && !*forceMaster && *etcdServers == "" {
+		glog.Exit("--map_sequencer_interval needs --etcd_servers to elect the map server that sets the queued leaves of each map, or --force_master if no other map server sets them")
+	}
+	if *verifyNullHashes {
+		if err := hashers.VerifyNullHashes(); err != nil {
+			glog.Exitf("Map hasher self-test failed, map roots would change: %v", err)
+		}
+	}
+
+	pmf, err := prometheus.NewMetricFactory(*metricNamespace, *metricConstLabels, *metricBuckets)
+	if err != nil {
+		glog.Exitf("Invalid Prometheus metric flags: %v", err)
+	}
+	var statsdPrefix string
+	if *metricNamespace != "" {
+		statsdPrefix = *metricNamespace + "."
+	}
+	mf, closeMetrics, err := server.NewMetricFactory(context.Background(), server.MetricsConfig{
+		Backend:             *metricsBackend,
+		Prometheus:          pmf,
+		OTLPEndpoint:        *otlpEndpoint,
+		OTLPExportInterval:  *otlpExportInterval,
+		StatsDEndpoint:      *statsdEndpoint,
+		StatsDFlushInterval: *statsdFlush,
+		StatsDPrefix:        statsdPrefix,
+	})
+	if err != nil {
+		glog.Exitf("Failed to create metric factory: %v", err)
+	}
+	defer closeMetrics()
+	if *traceEndpoint != "" {
+		stopTracing, err := server.StartTracing(context.Background(), *traceEndpoint, "trillian_map_server", *traceSampleFraction)
+		if err != nil {
+			glog.Exitf("Failed to start tracing: %v", err)
+		}
+		defer stopTracing()
+	}
 
-	db, err := mysql.OpenDB(*mySQLURI)
+	sp, err := factory.NewProvider(*storageSystem, *storageURI, mf)
 	if err != nil {
-		glog.Exitf("Failed to open database: %v", err)
+		glog.Exitf("Failed to open %v storage: %v", *storageSystem, err)
+	}
+	// No defer: storage ownership is delegated to server.Main
+
+	// Quota is backed by the storage system, if it supports it (e.g. MySQL counts unsequenced
+	// rows); otherwise there's no quota.
+	qm := factory.QuotaManager(sp)
+	// Quota configured through the admin API applies on top.
+	if *quotaConfigRefresh > 0 {
+		dqm := dynamic.NewManager(qm, sp.AdminStorage(), util.SystemTimeSource{})
+		go dqm.Run(context.Background(), *quotaConfigRefresh)
+		qm = dqm
 	}
-	// No defer: database ownership is delegated to server.Main
 
 	registry := extension.Registry{
-		AdminStorage:  mysql.NewAdminStorage(db),
+		AdminStorage:  sp.AdminStorage(),
 		SignerFactory: &keys.DefaultSignerFactory{},
-		MapStorage:    mysql.NewMapStorage(db),
-		QuotaManager:  &mysqlq.QuotaManager{DB: db, MaxUnsequencedRows: *maxUnsequencedRows},
-		MetricFactory: prometheus.MetricFactory{},
+		MapStorage:    sp.MapStorage(),
+		QuotaManager:  qm,
+		MetricFactory: mf,
+	}
+
+	if *sequencerInterval > 0 {
+		hostname, _ := os.Hostname()
+		instanceID := fmt.Sprintf("%s.%d", hostname, os.Getpid())
+		if *forceMaster {
+			glog.Warning("**** Acting as master for all maps ****")
+			registry.ElectionFactory = util.NoopElectionFactory{InstanceID: instanceID}
+		} else {
+			if *lockTTL <= *masterCheckInterval {
+				glog.Warningf("--etcd_lock_ttl (%v) isn't longer than --master_check_interval (%v), another instance may become master before this one notices it no longer is", *lockTTL, *masterCheckInterval)
+			}
+			registry.ElectionFactory = etcd.NewElectionFactory(instanceID, *etcdServers, *lockDir, *lockTTL, mf)
+		}
 	}
 
 	ts := util.SystemTimeSource{}
 	stats := monitoring.NewRPCStatsInterceptor(ts, "map", registry.MetricFactory)
+	tlsConfig, err := server.NewTLSConfig(*tlsCertFile, *tlsKeyFile, *tlsClientCAFile, *tlsMinVersion)
+	if err != nil {
+		glog.Exitf("Failed to configure TLS: %v", err)
+	}
 	ti := &interceptor.TrillianInterceptor{
-		Admin:        registry.AdminStorage,
-		QuotaManager: registry.QuotaManager,
+		Admin:         registry.AdminStorage,
+		QuotaManager:  registry.QuotaManager,
+		QuotaFailOpen: *quotaFailOpen,
+		MetricFactory: registry.MetricFactory,
+		Authorizer:    newAuthorizer(tlsConfig),
+	}
+	hd := interceptor.NewHandlerDeadline("map", *handlerDeadlineFraction, *maxHandlerDeadline, registry.MetricFactory)
+	interceptors := []grpc.UnaryServerInterceptor{interceptor.TracingUnaryInterceptor, stats.Interceptor(), interceptor.ErrorWrapper}
+	if *auditSink != "" {
+		sink, err := audit.NewSink(*auditSink, *auditMaxFileBytes)
+		if err != nil {
+			glog.Exitf("Failed to create audit sink: %v", err)
+		}
+		defer sink.Close()
+		interceptors = append(interceptors, interceptor.NewAuditLog(sink, *auditFailClosed, registry.MetricFactory).UnaryInterceptor)
+	}
+	interceptors = append(interceptors, hd.UnaryInterceptor, ti.UnaryInterceptor)
+	netInterceptor := interceptor.Combine(interceptors...)
+	serverOpts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(netInterceptor),
+		grpc.StreamInterceptor(interceptor.CombineStream(interceptor.TracingStreamInterceptor, ti.StreamInterceptor)),
 	}
-	netInterceptor := interceptor.Combine(stats.Interceptor(), interceptor.ErrorWrapper, ti.UnaryInterceptor)
-	s := grpc.NewServer(grpc.UnaryInterceptor(netInterceptor))
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s := grpc.NewServer(serverOpts...)
 	// No defer: server ownership is delegated to server.Main
 
+	ctx := context.Background()
+	// stopSequencer stops the map sequencer, if it's running.
+	var stopSequencer func()
+
 	m := server.Main{
-		RPCEndpoint:       *rpcEndpoint,
-		HTTPEndpoint:      *httpEndpoint,
-		DB:                db,
-		Registry:          registry,
-		Server:            s,
-		RegisterHandlerFn: trillian.RegisterTrillianMapHandlerFromEndpoint,
+		RPCEndpoint:         *rpcEndpoint,
+		RPCUnixSocket:       *listenUnixSocket,
+		HTTPEndpoint:        *httpEndpoint,
+		SinglePort:          *singlePort,
+		TLSConfig:           tlsConfig,
+		DisableRESTGateway:  !*enableRESTGateway,
+		StorageProvider:     sp,
+		MaxActiveTrees:      *maxActiveTrees,
+		HealthCheckInterval: *healthCheckInterval,
+		DrainTimeout:        *drainTimeout,
+		DrainDelay:          *drainDelay,
+		Registry:            registry,
+		Server:              s,
+		RegisterHandlerFn:   trillian.RegisterTrillianMapHandlerFromEndpoint,
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
 			mapServer := server.NewTrillianMapServer(registry)
+			mapServer.SetStreamBatchSize(*streamBatchSize)
 			if err := mapServer.IsHealthy(); err != nil {
 				return err
 			}
 			trillian.RegisterTrillianMapServer(s, mapServer)
+			if *sequencerInterval > 0 {
+				seq := server.NewMapSequencer(registry, mapServer, *sequencerBatchSize, *sequencerInterval)
+				seq.SetElectionInfo(server.LogOperationInfo{
+					PreElectionPause:    *preElectionPause,
+					MasterCheckInterval: *masterCheckInterval,
+					MasterHoldInterval:  *masterHoldInterval,
+					ResignOdds:          *resignOdds,
+					TimeSource:          ts,
+				})
+				sctx, cancel := context.WithCancel(ctx)
+				done := make(chan struct{})
+				go func() {
+					seq.Run(sctx)
+					close(done)
+				}()
+				stopSequencer = func() {
+					cancel()
+					<-done
+				}
+			}
 			return err
 		},
+		// The sequencer writes to storage, so it's stopped before storage is closed.
+		ShutdownFn: func() {
+			if stopSequencer != nil {
+				stopSequencer()
+			}
+		},
 	}
 
-	ctx := context.Background()
+	if *configFile != "" {
+		reloader, err := cmd.NewFlagFileReloader(*configFile, cmd.Reloadable{Name: "v"})
+		if err != nil {
+			glog.Exitf("Failed to set up reloading of config file %q: %v", *configFile, err)
+		}
+		go reloader.Run(ctx)
+	}
 	if err := m.Run(ctx); err != nil {
 		glog.Exitf("Server exited with error: %v", err)
 	}
 }
+
+// newAuthorizer returns the Authorizer configured by flags, or nil if RPCs aren't authenticated.
+// tlsConfig is the TLS configuration RPCs are served with.
+func newAuthorizer(tlsConfig *tls.Config) *interceptor.Authorizer {
+	var authenticators interceptor.MultiAuthenticator
+	if *authTokenFile != "" {
+		tokens, err := interceptor.ReadTokenFile(*authTokenFile)
+		if err != nil {
+			glog.Exitf("Failed to read --auth_token_file: %v", err)
+		}
+		authenticators = append(authenticators, interceptor.NewTokenAuthenticator(tokens))
+	}
+	if *authClientCerts {
+		if *tlsClientCAFile == "" {
+			glog.Exit("--auth_client_certs requires --tls_client_ca_file")
+		}
+		// The REST gateway calls the server with the server's own certificate.
+		authenticators = append(authenticators, interceptor.CertAuthenticator{GatewayCert: tlsConfig.Certificates[0].Certificate[0]})
+	}
+	if len(authenticators) == 0 {
+		return nil
+	}
+	var admins []string
+	if *authAdmins != "" {
+		admins = strings.Split(*authAdmins, ",")
+	} else {
+		glog.Warning("RPCs are authenticated but --auth_admins is empty, so no one may make admin RPCs")
+	}
+	a := interceptor.NewAuthorizer(authenticators, admins)
+	a.OpenTrees = *authOpenTrees
+	return a
+}
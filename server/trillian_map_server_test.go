@@ -0,0 +1,997 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/maps"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/maphasher"
+	"github.com/google/trillian/storage"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/testonly"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMapLeafHash(t *testing.T) {
+	hasher := maphasher.Default
+	index := testonly.HashKey("key")
+	value := []byte("value")
+	serverHash := hasher.HashLeaf(0, index, hasher.BitLen(), value)
+	// Stands in for an application-specific hash of value.
+	clientHash := testonly.HashKey("canonical value")
+
+	tests := []struct {
+		desc     string
+		hashing  trillian.MapLeafHashing
+		leaf     *trillian.MapLeaf
+		want     []byte
+		wantCode codes.Code
+	}{
+		{
+			desc:    "serverHashed",
+			hashing: trillian.MapLeafHashing_SERVER_HASHED_LEAVES,
+			leaf:    &trillian.MapLeaf{Index: index, LeafValue: value},
+			want:    serverHash,
+		},
+		{
+			desc:    "serverHashedMatchingHash",
+			hashing: trillian.MapLeafHashing_SERVER_HASHED_LEAVES,
+			leaf:    &trillian.MapLeaf{Index: index, LeafValue: value, LeafHash: serverHash},
+			want:    serverHash,
+		},
+		{
+			desc:     "serverHashedClientHash",
+			hashing:  trillian.MapLeafHashing_SERVER_HASHED_LEAVES,
+			leaf:     &trillian.MapLeaf{Index: index, LeafValue: value, LeafHash: clientHash},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:    "clientHashed",
+			hashing: trillian.MapLeafHashing_CLIENT_HASHED_LEAVES,
+			leaf:    &trillian.MapLeaf{Index: index, LeafValue: value, LeafHash: clientHash},
+			want:    clientHash,
+		},
+		{
+			desc:    "clientHashedNoValue",
+			hashing: trillian.MapLeafHashing_CLIENT_HASHED_LEAVES,
+			leaf:    &trillian.MapLeaf{Index: index, LeafHash: clientHash},
+			want:    clientHash,
+		},
+		{
+			desc:     "clientHashedNoHash",
+			hashing:  trillian.MapLeafHashing_CLIENT_HASHED_LEAVES,
+			leaf:     &trillian.MapLeaf{Index: index, LeafValue: value},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "clientHashedShortHash",
+			hashing:  trillian.MapLeafHashing_CLIENT_HASHED_LEAVES,
+			leaf:     &trillian.MapLeaf{Index: index, LeafHash: clientHash[1:]},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, test := range tests {
+		tree := &trillian.Tree{TreeType: trillian.TreeType_MAP, MapLeafHashing: test.hashing}
+		got, err := mapLeafHash(tree, hasher, test.leaf)
+		if test.wantCode != codes.OK {
+			if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
+				t.Errorf("%v: mapLeafHash() returned err = %v, want code %v", test.desc, err, test.wantCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: mapLeafHash() returned err = %v", test.desc, err)
+			continue
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%v: mapLeafHash() = %x, want %x", test.desc, got, test.want)
+		}
+	}
+}
+
+// TestMapLeafHashVerifies checks that the roots the server builds from stored leaf hashes
+// verify with the leaf hashes clients compute, in both leaf hashing modes.
+func TestMapLeafHashVerifies(t *testing.T) {
+	hasher := maphasher.Default
+	index := testonly.HashKey("key")
+	absentIndex := testonly.HashKey("absent key")
+	value := []byte("value")
+	clientHash := testonly.HashKey("canonical value")
+
+	for _, test := range []struct {
+		desc       string
+		hashing    trillian.MapLeafHashing
+		leaf       *trillian.MapLeaf
+		clientHash []byte
+	}{
+		{
+			desc:       "serverHashed",
+			hashing:    trillian.MapLeafHashing_SERVER_HASHED_LEAVES,
+			leaf:       &trillian.MapLeaf{Index: index, LeafValue: value},
+			clientHash: hasher.HashLeaf(0, index, hasher.BitLen(), value),
+		},
+		{
+			desc:       "clientHashed",
+			hashing:    trillian.MapLeafHashing_CLIENT_HASHED_LEAVES,
+			leaf:       &trillian.MapLeaf{Index: index, LeafHash: clientHash},
+			clientHash: clientHash,
+		},
+	} {
+		tree := &trillian.Tree{TreeType: trillian.TreeType_MAP, MapLeafHashing: test.hashing}
+		leafHash, err := mapLeafHash(tree, hasher, test.leaf)
+		if err != nil {
+			t.Errorf("%v: mapLeafHash() returned err = %v", test.desc, err)
+			continue
+		}
+
+		// A map holding a single leaf has empty proofs for every index.
+		hs2 := merkle.NewHStar2(tree.TreeId, hasher)
+		root, err := hs2.HStar2Root(hasher.BitLen(), []merkle.HStar2LeafHash{
+			{Index: new(big.Int).SetBytes(index), LeafHash: leafHash},
+		})
+		if err != nil {
+			t.Errorf("%v: HStar2Root() returned err = %v", test.desc, err)
+			continue
+		}
+		proof := make([][]byte, hasher.BitLen())
+		if err := merkle.VerifyMapInclusionProof(tree.TreeId, index, test.clientHash, root, proof, hasher); err != nil {
+			t.Errorf("%v: VerifyMapInclusionProof() returned err = %v", test.desc, err)
+		}
+
+		// Absent leaves are returned with the empty leaf hash in client-hashed maps.
+		if test.hashing == trillian.MapLeafHashing_CLIENT_HASHED_LEAVES {
+			emptyHash := hasher.HashEmpty(tree.TreeId, absentIndex, 0)
+			emptyRoot, err := hs2.HStar2Root(hasher.BitLen(), nil)
+			if err != nil {
+				t.Errorf("%v: HStar2Root() returned err = %v", test.desc, err)
+				continue
+			}
+			if err := merkle.VerifyMapInclusionProof(tree.TreeId, absentIndex, emptyHash, emptyRoot, proof, hasher); err != nil {
+				t.Errorf("%v: VerifyMapInclusionProof() of absent leaf returned err = %v", test.desc, err)
+			}
+		}
+	}
+}
+
+func TestGetLeavesByRevisions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	index1 := testonly.HashKey("key1")
+	index2 := testonly.HashKey("key2")
+	leaf1 := trillian.MapLeaf{Index: index1, LeafHash: testonly.HashKey("value1"), LeafValue: []byte("value1")}
+	root1 := trillian.SignedMapRoot{MapId: mapID, MapRevision: 1, RootHash: []byte("root1")}
+	root2 := trillian.SignedMapRoot{MapId: mapID, MapRevision: 2, RootHash: []byte("root2")}
+
+	mockStorage := storage.NewMockMapStorage(ctrl)
+	latestTX := storage.NewMockMapTreeTX(ctrl)
+	rev2TX := storage.NewMockMapTreeTX(ctrl)
+	rev1TX := storage.NewMockMapTreeTX(ctrl)
+	gomock.InOrder(
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(latestTX, nil),
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(rev2TX, nil),
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(rev1TX, nil),
+	)
+	latestTX.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(root2, nil)
+	// Leaves requested at the same revision are read with a single Get.
+	rev2TX.EXPECT().GetSignedMapRoot(gomock.Any(), int64(2)).Return(root2, nil)
+	rev2TX.EXPECT().Get(gomock.Any(), int64(2), [][]byte{index1, index2}).Return([]trillian.MapLeaf{leaf1}, nil)
+	rev2TX.EXPECT().GetMerkleNodes(gomock.Any(), int64(2), gomock.Any()).Times(2).Return(nil, nil)
+	rev1TX.EXPECT().GetSignedMapRoot(gomock.Any(), int64(1)).Return(root1, nil)
+	rev1TX.EXPECT().Get(gomock.Any(), int64(1), [][]byte{index2}).Return(nil, nil)
+	rev1TX.EXPECT().GetMerkleNodes(gomock.Any(), int64(1), gomock.Any()).Return(nil, nil)
+	for _, tx := range []*storage.MockMapTreeTX{latestTX, rev2TX, rev1TX} {
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+	}
+
+	server := NewTrillianMapServer(extension.Registry{
+		AdminStorage: mockMapAdminStorage(ctrl, mapID),
+		MapStorage:   mockStorage,
+	})
+	resp, err := server.GetLeavesByRevisions(context.Background(), &trillian.GetMapLeavesByRevisionsRequest{
+		MapId: mapID,
+		Leaves: []*trillian.MapLeafAtRevision{
+			{Index: index1, Revision: 2},
+			{Index: index2, Revision: 1},
+			{Index: index1, Revision: 3},
+			{Index: index2, Revision: 2},
+			{Index: index2, Revision: -1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GetLeavesByRevisions() returned err = %v", err)
+	}
+
+	emptyProof := make([][]byte, maphasher.Default.BitLen())
+	okStatus := status.New(codes.OK, "").Proto()
+	want := []*trillian.MapLeafRevisionInclusion{
+		{
+			Status:           okStatus,
+			Index:            index1,
+			Revision:         2,
+			MapLeafInclusion: &trillian.MapLeafInclusion{Leaf: &leaf1, Inclusion: emptyProof},
+			MapRoot:          &root2,
+		},
+		{
+			Status:           okStatus,
+			Index:            index2,
+			Revision:         1,
+			MapLeafInclusion: &trillian.MapLeafInclusion{Leaf: &trillian.MapLeaf{Index: index2}, Inclusion: emptyProof},
+			MapRoot:          &root1,
+		},
+		{
+			Status:   status.New(codes.OutOfRange, "revision 3 not in [0, 2]").Proto(),
+			Index:    index1,
+			Revision: 3,
+		},
+		{
+			Status:           okStatus,
+			Index:            index2,
+			Revision:         2,
+			MapLeafInclusion: &trillian.MapLeafInclusion{Leaf: &trillian.MapLeaf{Index: index2}, Inclusion: emptyProof},
+			MapRoot:          &root2,
+		},
+		{
+			Status:   status.New(codes.OutOfRange, "revision -1 not in [0, 2]").Proto(),
+			Index:    index2,
+			Revision: -1,
+		},
+	}
+	if got, want := len(resp.Results), len(want); got != want {
+		t.Fatalf("len(GetLeavesByRevisions().Results) = %v, want %v", got, want)
+	}
+	for i, w := range want {
+		if got := resp.Results[i]; !proto.Equal(got, w) {
+			t.Errorf("GetLeavesByRevisions().Results[%v] = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestGetLeavesByRevisionsRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	index := testonly.HashKey("key1")
+	roots := []trillian.SignedMapRoot{
+		{MapId: mapID, MapRevision: 1, RootHash: []byte("root1")},
+		{MapId: mapID, MapRevision: 2, RootHash: []byte("root2")},
+	}
+
+	mockStorage := storage.NewMockMapStorage(ctrl)
+	latestTX := storage.NewMockMapTreeTX(ctrl)
+	latestTX.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(roots[1], nil)
+	calls := []*gomock.Call{mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(latestTX, nil)}
+	txs := []*storage.MockMapTreeTX{latestTX}
+	for _, root := range roots {
+		tx := storage.NewMockMapTreeTX(ctrl)
+		tx.EXPECT().GetSignedMapRoot(gomock.Any(), root.MapRevision).Return(root, nil)
+		tx.EXPECT().Get(gomock.Any(), root.MapRevision, [][]byte{index}).Return(nil, nil)
+		tx.EXPECT().GetMerkleNodes(gomock.Any(), root.MapRevision, gomock.Any()).Return(nil, nil)
+		calls = append(calls, mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(tx, nil))
+		txs = append(txs, tx)
+	}
+	gomock.InOrder(calls...)
+	for _, tx := range txs {
+		tx.EXPECT().Commit().Return(nil)
+		tx.EXPECT().Close().Return(nil)
+	}
+
+	server := NewTrillianMapServer(extension.Registry{
+		AdminStorage: mockMapAdminStorage(ctrl, mapID),
+		MapStorage:   mockStorage,
+	})
+	resp, err := server.GetLeavesByRevisions(context.Background(), &trillian.GetMapLeavesByRevisionsRequest{
+		MapId:         mapID,
+		Index:         [][]byte{index},
+		StartRevision: 1,
+		EndRevision:   -1,
+	})
+	if err != nil {
+		t.Fatalf("GetLeavesByRevisions() returned err = %v", err)
+	}
+	if got, want := len(resp.Results), len(roots); got != want {
+		t.Fatalf("len(GetLeavesByRevisions().Results) = %v, want %v", got, want)
+	}
+	for i, r := range resp.Results {
+		if got, want := r.Status.GetCode(), int32(codes.OK); got != want {
+			t.Errorf("GetLeavesByRevisions().Results[%v].Status.Code = %v, want %v", i, got, want)
+		}
+		if !proto.Equal(r.MapRoot, &roots[i]) {
+			t.Errorf("GetLeavesByRevisions().Results[%v].MapRoot = %v, want %v", i, r.MapRoot, &roots[i])
+		}
+	}
+}
+
+func TestExpandRevisionRange(t *testing.T) {
+	index1 := testonly.HashKey("key1")
+	index2 := testonly.HashKey("key2")
+	leaf := &trillian.MapLeafAtRevision{Index: index1, Revision: 3}
+	manyIndices := make([][]byte, maxRevisionRangeLeaves/2+1)
+	for i := range manyIndices {
+		manyIndices[i] = index1
+	}
+
+	tests := []struct {
+		desc     string
+		req      *trillian.GetMapLeavesByRevisionsRequest
+		want     []*trillian.MapLeafAtRevision
+		wantCode codes.Code
+	}{
+		{
+			desc: "noRange",
+			req:  &trillian.GetMapLeavesByRevisionsRequest{Leaves: []*trillian.MapLeafAtRevision{leaf}},
+			want: []*trillian.MapLeafAtRevision{leaf},
+		},
+		{
+			desc: "range",
+			req: &trillian.GetMapLeavesByRevisionsRequest{
+				Leaves:        []*trillian.MapLeafAtRevision{leaf},
+				Index:         [][]byte{index1, index2},
+				StartRevision: 4,
+				EndRevision:   5,
+			},
+			want: []*trillian.MapLeafAtRevision{
+				leaf,
+				{Index: index1, Revision: 4},
+				{Index: index1, Revision: 5},
+				{Index: index2, Revision: 4},
+				{Index: index2, Revision: 5},
+			},
+		},
+		{
+			desc: "cappedAtLatest",
+			req:  &trillian.GetMapLeavesByRevisionsRequest{Index: [][]byte{index1}, StartRevision: 9, EndRevision: 20},
+			want: []*trillian.MapLeafAtRevision{{Index: index1, Revision: 9}, {Index: index1, Revision: 10}},
+		},
+		{
+			desc: "toLatest",
+			req:  &trillian.GetMapLeavesByRevisionsRequest{Index: [][]byte{index1}, StartRevision: 10, EndRevision: -1},
+			want: []*trillian.MapLeafAtRevision{{Index: index1, Revision: 10}},
+		},
+		{
+			desc:     "negativeStart",
+			req:      &trillian.GetMapLeavesByRevisionsRequest{Index: [][]byte{index1}, StartRevision: -1, EndRevision: 2},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "startAfterEnd",
+			req:      &trillian.GetMapLeavesByRevisionsRequest{Index: [][]byte{index1}, StartRevision: 3, EndRevision: 2},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "startAfterLatest",
+			req:      &trillian.GetMapLeavesByRevisionsRequest{Index: [][]byte{index1}, StartRevision: 11, EndRevision: -1},
+			wantCode: codes.OutOfRange,
+		},
+		{
+			desc:     "tooManyLeaves",
+			req:      &trillian.GetMapLeavesByRevisionsRequest{Index: manyIndices, StartRevision: 0, EndRevision: 1},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, test := range tests {
+		got, err := expandRevisionRange(test.req, 10)
+		if code := status.Code(err); code != test.wantCode {
+			t.Errorf("%v: expandRevisionRange() returned err = %v, want code %v", test.desc, err, test.wantCode)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%v: expandRevisionRange() = %v, want %v", test.desc, got, test.want)
+			continue
+		}
+		for i, w := range test.want {
+			if !proto.Equal(got[i], w) {
+				t.Errorf("%v: expandRevisionRange()[%v] = %v, want %v", test.desc, i, got[i], w)
+			}
+		}
+	}
+}
+
+func TestGetLeavesByRevisionsBadIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	server := NewTrillianMapServer(extension.Registry{
+		AdminStorage: mockMapAdminStorage(ctrl, mapID),
+		MapStorage:   storage.NewMockMapStorage(ctrl),
+	})
+	_, err := server.GetLeavesByRevisions(context.Background(), &trillian.GetMapLeavesByRevisionsRequest{
+		MapId:  mapID,
+		Leaves: []*trillian.MapLeafAtRevision{{Index: []byte("short"), Revision: 1}},
+	})
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+		t.Errorf("GetLeavesByRevisions() returned err = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestCheckRevisionLookback(t *testing.T) {
+	const latest = int64(10)
+	tests := []struct {
+		lookback, rev int64
+		wantErr       bool
+	}{
+		{lookback: 0, rev: 0},
+		{lookback: 3, rev: latest},
+		{lookback: 3, rev: latest - 3},
+		{lookback: 3, rev: latest - 4, wantErr: true},
+		{lookback: 3, rev: 0, wantErr: true},
+	}
+	for _, test := range tests {
+		tree := *stestonly.MapTree
+		tree.MaxRevisionLookback = test.lookback
+		err := checkRevisionLookback(&tree, test.rev, latest)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("checkRevisionLookback(lookback: %v, rev: %v) returned err = %v, wantErr = %v", test.lookback, test.rev, err, test.wantErr)
+			continue
+		}
+		if s, ok := status.FromError(err); err != nil && (!ok || s.Code() != codes.OutOfRange) {
+			t.Errorf("checkRevisionLookback(lookback: %v, rev: %v) returned err = %v, want code %v", test.lookback, test.rev, err, codes.OutOfRange)
+		}
+	}
+}
+
+func TestGetSignedMapRootByRevisionLookback(t *testing.T) {
+	const mapID = int64(7)
+	latest := trillian.SignedMapRoot{MapId: mapID, MapRevision: 10}
+	tests := []struct {
+		rev      int64
+		wantCode codes.Code
+	}{
+		{rev: 10, wantCode: codes.OK},
+		{rev: 7, wantCode: codes.OK},
+		{rev: 6, wantCode: codes.OutOfRange},
+	}
+	for _, test := range tests {
+		ctrl := gomock.NewController(t)
+
+		tree := *stestonly.MapTree
+		tree.TreeId = mapID
+		tree.MaxRevisionLookback = 3
+
+		mockStorage := storage.NewMockMapStorage(ctrl)
+		tx := storage.NewMockMapTreeTX(ctrl)
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(tx, nil)
+		tx.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(latest, nil)
+		if test.wantCode == codes.OK {
+			tx.EXPECT().GetSignedMapRoot(gomock.Any(), test.rev).Return(trillian.SignedMapRoot{MapId: mapID, MapRevision: test.rev}, nil)
+			tx.EXPECT().Commit().Return(nil)
+		}
+		tx.EXPECT().Close().Return(nil)
+
+		server := NewTrillianMapServer(extension.Registry{
+			AdminStorage: mockAdminStorageForTree(ctrl, &tree),
+			MapStorage:   mockStorage,
+		})
+		resp, err := server.GetSignedMapRootByRevision(context.Background(), &trillian.GetSignedMapRootByRevisionRequest{
+			MapId:    mapID,
+			Revision: test.rev,
+		})
+		if s, ok := status.FromError(err); !ok || s.Code() != test.wantCode {
+			t.Errorf("GetSignedMapRootByRevision(%v) returned err = %v, want code %v", test.rev, err, test.wantCode)
+		} else if err == nil && resp.MapRoot.MapRevision != test.rev {
+			t.Errorf("GetSignedMapRootByRevision(%v).MapRoot.MapRevision = %v, want %v", test.rev, resp.MapRoot.MapRevision, test.rev)
+		}
+
+		ctrl.Finish()
+	}
+}
+
+func TestGetLeavesByRevisionsLookback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	tree := *stestonly.MapTree
+	tree.TreeId = mapID
+	tree.MaxRevisionLookback = 3
+
+	mockStorage := storage.NewMockMapStorage(ctrl)
+	latestTX := storage.NewMockMapTreeTX(ctrl)
+	mockStorage.EXPECT().SnapshotForTree(gomock.Any(), mapID).Return(latestTX, nil)
+	latestTX.EXPECT().LatestSignedMapRoot(gomock.Any()).Return(trillian.SignedMapRoot{MapId: mapID, MapRevision: 10}, nil)
+	latestTX.EXPECT().Commit().Return(nil)
+	latestTX.EXPECT().Close().Return(nil)
+
+	server := NewTrillianMapServer(extension.Registry{
+		AdminStorage: mockAdminStorageForTree(ctrl, &tree),
+		MapStorage:   mockStorage,
+	})
+	index := testonly.HashKey("key1")
+	resp, err := server.GetLeavesByRevisions(context.Background(), &trillian.GetMapLeavesByRevisionsRequest{
+		MapId:  mapID,
+		Leaves: []*trillian.MapLeafAtRevision{{Index: index, Revision: 6}},
+	})
+	if err != nil {
+		t.Fatalf("GetLeavesByRevisions() returned err = %v", err)
+	}
+	if got, want := codes.Code(resp.Results[0].Status.Code), codes.OutOfRange; got != want {
+		t.Errorf("GetLeavesByRevisions().Results[0].Status.Code = %v, want %v", got, want)
+	}
+}
+
+func mockMapAdminStorage(ctrl *gomock.Controller, treeID int64) storage.AdminStorage {
+	tree := *stestonly.MapTree
+	tree.TreeId = treeID
+	return mockAdminStorageForTree(ctrl, &tree)
+}
+
+func mockAdminStorageForTree(ctrl *gomock.Controller, tree *trillian.Tree) storage.AdminStorage {
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+
+	adminStorage.EXPECT().Snapshot(gomock.Any()).MaxTimes(1).Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).MaxTimes(1).Return(tree, nil)
+	adminTX.EXPECT().Close().MaxTimes(1).Return(nil)
+	adminTX.EXPECT().Commit().MaxTimes(1).Return(nil)
+
+	return adminStorage
+}
+
+// fakeNodeStore holds the Merkle nodes of a map at a single revision, keyed by
+// node ID. If discard is set, nodes written are dropped instead, and
+// onWrite is called before each write.
+type fakeNodeStore struct {
+	mu      sync.Mutex
+	nodes   map[string][]byte
+	discard bool
+	onWrite func()
+	queue   []*trillian.MapLeaf
+	root    trillian.SignedMapRoot
+}
+
+// fakeMapStorage is a MapStorage whose transactions all share one
+// fakeNodeStore. Only the methods used by SetLeaves and the map leaf queue are
+// implemented.
+type fakeMapStorage struct {
+	storage.MapStorage
+	store *fakeNodeStore
+	rev   int64
+}
+
+func newFakeMapStorage(rev int64) *fakeMapStorage {
+	return &fakeMapStorage{store: &fakeNodeStore{nodes: make(map[string][]byte)}, rev: rev}
+}
+
+func (s *fakeMapStorage) BeginForTree(ctx context.Context, treeID int64) (storage.MapTreeTX, error) {
+	return &fakeMapTX{store: s.store, rev: s.rev}, nil
+}
+
+type fakeMapTX struct {
+	storage.MapTreeTX
+	store *fakeNodeStore
+	rev   int64
+}
+
+func (tx *fakeMapTX) WriteRevision() int64 { return tx.rev }
+
+func (tx *fakeMapTX) Set(ctx context.Context, keyHash []byte, value trillian.MapLeaf) error {
+	return nil
+}
+
+func (tx *fakeMapTX) StoreSignedMapRoot(ctx context.Context, root trillian.SignedMapRoot) error {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	tx.store.root = root
+	return nil
+}
+
+func (tx *fakeMapTX) LatestSignedMapRoot(ctx context.Context) (trillian.SignedMapRoot, error) {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	return tx.store.root, nil
+}
+
+func (tx *fakeMapTX) QueueMapLeaves(ctx context.Context, leaves []*trillian.MapLeaf, queueTimestamp time.Time) error {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	tx.store.queue = append(tx.store.queue, leaves...)
+	return nil
+}
+
+func (tx *fakeMapTX) DequeueMapLeaves(ctx context.Context, limit int) ([]*trillian.MapLeaf, error) {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	if limit > len(tx.store.queue) {
+		limit = len(tx.store.queue)
+	}
+	leaves := tx.store.queue[:limit]
+	tx.store.queue = tx.store.queue[limit:]
+	return leaves, nil
+}
+
+func (tx *fakeMapTX) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	var nodes []storage.Node
+	for _, id := range ids {
+		if h, ok := tx.store.nodes[id.String()]; ok {
+			nodes = append(nodes, storage.Node{NodeID: id, Hash: h, NodeRevision: tx.rev})
+		}
+	}
+	return nodes, nil
+}
+
+func (tx *fakeMapTX) SetMerkleNodes(ctx context.Context, nodes []storage.Node) error {
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	if tx.store.discard {
+		if tx.store.onWrite != nil {
+			tx.store.onWrite()
+		}
+		return nil
+	}
+	for _, n := range nodes {
+		tx.store.nodes[n.NodeID.String()] = n.Hash
+	}
+	return nil
+}
+
+func (tx *fakeMapTX) Commit() error { return nil }
+
+func (tx *fakeMapTX) Close() error { return nil }
+
+// fakeSetLeavesStream is a SetLeavesStream server stream whose requests are
+// produced by next, which returns nil at the end of the stream.
+type fakeSetLeavesStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	next func() *trillian.SetMapLeavesRequest
+	resp *trillian.SetMapLeavesResponse
+}
+
+func (s *fakeSetLeavesStream) Context() context.Context { return s.ctx }
+
+func (s *fakeSetLeavesStream) Recv() (*trillian.SetMapLeavesRequest, error) {
+	if req := s.next(); req != nil {
+		return req, nil
+	}
+	return nil, io.EOF
+}
+
+func (s *fakeSetLeavesStream) SendAndClose(resp *trillian.SetMapLeavesResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func streamOf(reqs ...*trillian.SetMapLeavesRequest) *fakeSetLeavesStream {
+	return &fakeSetLeavesStream{
+		ctx: context.Background(),
+		next: func() *trillian.SetMapLeavesRequest {
+			if len(reqs) == 0 {
+				return nil
+			}
+			req := reqs[0]
+			reqs = reqs[1:]
+			return req
+		},
+	}
+}
+
+// testMapLeaves returns n leaves with distinct indices, starting at the
+// from'th.
+func testMapLeaves(from, n int) []*trillian.MapLeaf {
+	leaves := make([]*trillian.MapLeaf, 0, n)
+	for i := from; i < from+n; i++ {
+		leaves = append(leaves, &trillian.MapLeaf{
+			Index:     testonly.HashKey(fmt.Sprintf("key-%d", i)),
+			LeafValue: []byte(fmt.Sprintf("value-%d", i)),
+		})
+	}
+	return leaves
+}
+
+func newStreamTestMapServer(ctrl *gomock.Controller, mapID int64, ms storage.MapStorage, batchSize int) *TrillianMapServer {
+	server := NewTrillianMapServer(extension.Registry{
+		AdminStorage:  mockMapAdminStorage(ctrl, mapID),
+		MapStorage:    ms,
+		SignerFactory: &keys.DefaultSignerFactory{},
+	})
+	server.SetStreamBatchSize(batchSize)
+	return server
+}
+
+func TestSetLeavesStreamMatchesSetLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	const rev = int64(3)
+	const numLeaves = 300
+	const perRequest = 40
+	ctx := context.Background()
+
+	resp, err := newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(rev), 0).SetLeaves(ctx, &trillian.SetMapLeavesRequest{
+		MapId:      mapID,
+		Leaves:     testMapLeaves(0, numLeaves),
+		MapperData: &trillian.MapperMetadata{HighestFullyCompletedSeq: 42},
+	})
+	if err != nil {
+		t.Fatalf("SetLeaves(): %v", err)
+	}
+	want := resp.MapRoot
+
+	for _, batchSize := range []int{1, 7, perRequest, 128, numLeaves, 1000} {
+		var reqs []*trillian.SetMapLeavesRequest
+		for i := 0; i < numLeaves; i += perRequest {
+			n := perRequest
+			if i+n > numLeaves {
+				n = numLeaves - i
+			}
+			reqs = append(reqs, &trillian.SetMapLeavesRequest{Leaves: testMapLeaves(i, n)})
+		}
+		reqs[0].MapId = mapID
+		reqs[0].MapperData = &trillian.MapperMetadata{HighestFullyCompletedSeq: 42}
+
+		stream := streamOf(reqs...)
+		server := newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(rev), batchSize)
+		if err := server.SetLeavesStream(stream); err != nil {
+			t.Errorf("SetLeavesStream(batch size %v): %v", batchSize, err)
+			continue
+		}
+		got := stream.resp.MapRoot
+		if !bytes.Equal(got.RootHash, want.RootHash) {
+			t.Errorf("SetLeavesStream(batch size %v).RootHash = %x, want %x", batchSize, got.RootHash, want.RootHash)
+		}
+		if got.MapRevision != rev || !proto.Equal(got.Metadata, want.Metadata) {
+			t.Errorf("SetLeavesStream(batch size %v) = revision %v, metadata %v, want %v, %v", batchSize, got.MapRevision, got.Metadata, rev, want.Metadata)
+		}
+	}
+}
+
+func TestSetLeavesStreamErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	tests := []struct {
+		desc   string
+		stream *fakeSetLeavesStream
+	}{
+		{
+			desc:   "empty",
+			stream: streamOf(),
+		},
+		{
+			desc: "otherMap",
+			stream: streamOf(
+				&trillian.SetMapLeavesRequest{MapId: mapID, Leaves: testMapLeaves(0, 1)},
+				&trillian.SetMapLeavesRequest{MapId: mapID + 1, Leaves: testMapLeaves(1, 1)},
+			),
+		},
+		{
+			desc: "shortIndex",
+			stream: streamOf(
+				&trillian.SetMapLeavesRequest{MapId: mapID, Leaves: []*trillian.MapLeaf{{Index: []byte("short")}}},
+			),
+		},
+	}
+	for _, test := range tests {
+		server := newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(1), 0)
+		err := server.SetLeavesStream(test.stream)
+		if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+			t.Errorf("%v: SetLeavesStream() returned err = %v, want code %v", test.desc, err, codes.InvalidArgument)
+		}
+		if test.stream.resp != nil {
+			t.Errorf("%v: SetLeavesStream() sent response %v, want none", test.desc, test.stream.resp)
+		}
+	}
+}
+
+// malformedValueValidator rejects leaves whose value starts with "malformed". It's registered
+// for all MAP trees, so it must accept the leaves used by the other tests.
+type malformedValueValidator struct{}
+
+func (malformedValueValidator) ValidateValue(ctx context.Context, tree *trillian.Tree, leaf *trillian.MapLeaf) error {
+	if bytes.HasPrefix(leaf.LeafValue, []byte("malformed")) {
+		return errors.New("malformed value")
+	}
+	return nil
+}
+
+var registerValueValidator sync.Once
+
+func TestSetLeavesInvalidValue(t *testing.T) {
+	registerValueValidator.Do(func() { maps.RegisterValueValidator(trillian.TreeType_MAP, malformedValueValidator{}) })
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	ctx := context.Background()
+	malformed := testMapLeaves(100, 1)[0]
+	malformed.LeafValue = []byte("malformed value")
+
+	// The valid leaves of a batch are set as usual.
+	server := newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(1), 0)
+	if _, err := server.SetLeaves(ctx, &trillian.SetMapLeavesRequest{MapId: mapID, Leaves: testMapLeaves(0, 5)}); err != nil {
+		t.Errorf("SetLeaves(valid leaves) returned err = %v, want nil", err)
+	}
+
+	// No storage expectations: invalid leaves must be rejected before storage is touched.
+	server = newStreamTestMapServer(ctrl, mapID, storage.NewMockMapStorage(ctrl), 0)
+	leaves := append(testMapLeaves(0, 2), malformed)
+	leaves = append(leaves, testMapLeaves(2, 2)...)
+	_, err := server.SetLeaves(ctx, &trillian.SetMapLeavesRequest{MapId: mapID, Leaves: leaves})
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument || !strings.Contains(s.Message(), "leaf 2 ") {
+		t.Errorf("SetLeaves() returned err = %v, want code %v naming leaf 2", err, codes.InvalidArgument)
+	}
+
+	// Leaves of a stream are numbered across its requests.
+	stream := streamOf(
+		&trillian.SetMapLeavesRequest{MapId: mapID, Leaves: testMapLeaves(0, 3)},
+		&trillian.SetMapLeavesRequest{Leaves: []*trillian.MapLeaf{testMapLeaves(3, 1)[0], malformed}},
+	)
+	server = newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(1), 0)
+	err = server.SetLeavesStream(stream)
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument || !strings.Contains(s.Message(), "leaf 4 ") {
+		t.Errorf("SetLeavesStream() returned err = %v, want code %v naming leaf 4", err, codes.InvalidArgument)
+	}
+	if stream.resp != nil {
+		t.Errorf("SetLeavesStream() sent response %v, want none", stream.resp)
+	}
+}
+
+// BenchmarkSetLeavesStream streams increasing numbers of leaves to a map
+// through SetLeavesStream, and reports the peak heap in use while writing
+// nodes, which should stay flat as the number of leaves grows.
+func TestQueueMapLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	const rev = int64(3)
+	ctx := context.Background()
+	metadata := &trillian.MapperMetadata{HighestFullyCompletedSeq: 42}
+
+	// The last of the leaves queued for an index is the one that's set.
+	leaves := testMapLeaves(0, 10)
+	overwrite := testMapLeaves(2, 1)[0]
+	overwrite.LeafValue = []byte("overwritten")
+	want := append(testMapLeaves(0, 2), testMapLeaves(3, 7)...)
+	want = append(want, overwrite)
+	resp, err := newStreamTestMapServer(ctrl, mapID, newFakeMapStorage(rev), 0).SetLeaves(ctx, &trillian.SetMapLeavesRequest{
+		MapId:  mapID,
+		Leaves: want,
+	})
+	if err != nil {
+		t.Fatalf("SetLeaves(): %v", err)
+	}
+
+	ms := newFakeMapStorage(rev)
+	ms.store.root = trillian.SignedMapRoot{MapRevision: rev - 1, Metadata: metadata}
+	for _, req := range []*trillian.QueueMapLeavesRequest{
+		{MapId: mapID, Leaves: leaves[:6]},
+		{MapId: mapID, Leaves: append(leaves[6:], overwrite)},
+	} {
+		if _, err := newStreamTestMapServer(ctrl, mapID, ms, 0).QueueLeaves(ctx, req); err != nil {
+			t.Fatalf("QueueLeaves(): %v", err)
+		}
+	}
+	if got, want := len(ms.store.queue), 11; got != want {
+		t.Fatalf("queued %v leaves, want %v", got, want)
+	}
+
+	n, err := newStreamTestMapServer(ctrl, mapID, ms, 0).SequenceQueuedLeaves(ctx, mapID, 100)
+	if err != nil {
+		t.Fatalf("SequenceQueuedLeaves(): %v", err)
+	}
+	if got, want := n, 11; got != want {
+		t.Errorf("SequenceQueuedLeaves() = %v, want %v", got, want)
+	}
+	got := ms.store.root
+	if !bytes.Equal(got.RootHash, resp.MapRoot.RootHash) {
+		t.Errorf("sequenced RootHash = %x, want %x", got.RootHash, resp.MapRoot.RootHash)
+	}
+	if got.MapRevision != rev || !proto.Equal(got.Metadata, metadata) {
+		t.Errorf("sequenced root = revision %v, metadata %v, want %v, %v", got.MapRevision, got.Metadata, rev, metadata)
+	}
+
+	// Nothing is written once the queue is empty.
+	n, err = newStreamTestMapServer(ctrl, mapID, ms, 0).SequenceQueuedLeaves(ctx, mapID, 100)
+	if err != nil || n != 0 {
+		t.Errorf("SequenceQueuedLeaves() = %v, %v, want 0, nil", n, err)
+	}
+	if !proto.Equal(&ms.store.root, &got) {
+		t.Errorf("SequenceQueuedLeaves() of an empty queue stored root %v", ms.store.root)
+	}
+}
+
+func TestQueueMapLeavesInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const mapID = int64(7)
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc   string
+		leaves []*trillian.MapLeaf
+	}{
+		{desc: "shortIndex", leaves: []*trillian.MapLeaf{{Index: []byte("short"), LeafValue: []byte("value")}}},
+		{desc: "wrongLeafHash", leaves: []*trillian.MapLeaf{{Index: testonly.HashKey("key"), LeafValue: []byte("value"), LeafHash: []byte("hash")}}},
+	} {
+		// Nothing is written, so map storage isn't used.
+		server := newStreamTestMapServer(ctrl, mapID, storage.NewMockMapStorage(ctrl), 0)
+		_, err := server.QueueLeaves(ctx, &trillian.QueueMapLeavesRequest{MapId: mapID, Leaves: test.leaves})
+		if got, want := status.Code(err), codes.InvalidArgument; got != want {
+			t.Errorf("%v: QueueLeaves() = %v, want code %v", test.desc, err, want)
+		}
+	}
+}
+
+func BenchmarkSetLeavesStream(b *testing.B) {
+	const mapID = int64(7)
+	const batchSize = 256
+	const perRequest = 64
+	for _, numLeaves := range []int{1024, 4096, 16384} {
+		b.Run(fmt.Sprintf("%d", numLeaves), func(b *testing.B) {
+			ctrl := gomock.NewController(b)
+			defer ctrl.Finish()
+
+			var peak uint64
+			var stats runtime.MemStats
+			ms := newFakeMapStorage(1)
+			ms.store.discard = true
+			ms.store.onWrite = func() {
+				runtime.ReadMemStats(&stats)
+				if stats.HeapAlloc > peak {
+					peak = stats.HeapAlloc
+				}
+			}
+
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				sent := 0
+				stream := &fakeSetLeavesStream{
+					ctx: context.Background(),
+					next: func() *trillian.SetMapLeavesRequest {
+						if sent >= numLeaves {
+							return nil
+						}
+						req := &trillian.SetMapLeavesRequest{MapId: mapID, Leaves: testMapLeaves(sent, perRequest)}
+						sent += perRequest
+						return req
+					},
+				}
+				server := newStreamTestMapServer(ctrl, mapID, ms, batchSize)
+				if err := server.SetLeavesStream(stream); err != nil {
+					b.Fatalf("SetLeavesStream(): %v", err)
+				}
+			}
+			b.Logf("peak heap: %v bytes", peak)
+		})
+	}
+}
@@ -15,6 +15,9 @@
 package server
 
 import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 
 	"google.golang.org/grpc/codes"
@@ -34,6 +37,10 @@ func validateGetInclusionProofRequest(req *trillian.GetInclusionProofRequest) er
 	return nil
 }
 
+func validateGetInclusionProofWithRootRequest(req *trillian.GetInclusionProofWithRootRequest) error {
+	return validateGetInclusionProofRequest(&trillian.GetInclusionProofRequest{LogId: req.LogId, LeafIndex: req.LeafIndex, TreeSize: req.TreeSize})
+}
+
 func validateGetInclusionProofByHashRequest(req *trillian.GetInclusionProofByHashRequest) error {
 	if req.TreeSize <= 0 {
 		return status.Errorf(codes.InvalidArgument, "GetInclusionProofByHashRequest.TreeSize: %v, want > 0", req.TreeSize)
@@ -57,6 +64,32 @@ func validateGetConsistencyProofRequest(req *trillian.GetConsistencyProofRequest
 	return nil
 }
 
+func validateCheckConsistencyProofRequest(req *trillian.CheckConsistencyProofRequest) error {
+	if req.FirstTreeSize <= 0 {
+		return status.Errorf(codes.InvalidArgument, "CheckConsistencyProofRequest.FirstTreeSize: %v, want > 0", req.FirstTreeSize)
+	}
+	if req.SecondTreeSize < req.FirstTreeSize {
+		return status.Errorf(codes.InvalidArgument, "CheckConsistencyProofRequest.SecondTreeSize: %v < CheckConsistencyProofRequest.FirstTreeSize: %v, want >= ", req.SecondTreeSize, req.FirstTreeSize)
+	}
+	if len(req.FirstRootHash) == 0 || len(req.SecondRootHash) == 0 {
+		return status.Errorf(codes.InvalidArgument, "CheckConsistencyProofRequest root hashes empty")
+	}
+	return nil
+}
+
+func validateGetProofByMerkleHashRequest(req *trillian.GetProofByMerkleHashRequest) error {
+	if len(req.LeafHash) == 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofByMerkleHashRequest.LeafHash empty")
+	}
+	if req.FirstTreeSize <= 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofByMerkleHashRequest.FirstTreeSize: %v, want > 0", req.FirstTreeSize)
+	}
+	if req.TreeSize <= req.FirstTreeSize {
+		return status.Errorf(codes.InvalidArgument, "GetProofByMerkleHashRequest.TreeSize: %v, want > FirstTreeSize (%v)", req.TreeSize, req.FirstTreeSize)
+	}
+	return nil
+}
+
 func validateGetEntryAndProofRequest(req *trillian.GetEntryAndProofRequest) error {
 	if req.TreeSize <= 0 {
 		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.TreeSize: %v, want > 0", req.TreeSize)
@@ -70,9 +103,55 @@ func validateGetEntryAndProofRequest(req *trillian.GetEntryAndProofRequest) erro
 	return nil
 }
 
+func validateGetEntriesRequest(req *trillian.GetEntriesRequest) error {
+	if req.StartIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetEntriesRequest.StartIndex: %v, want >= 0", req.StartIndex)
+	}
+	if req.EndIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetEntriesRequest.EndIndex: %v, want >= 0", req.EndIndex)
+	}
+	if req.EndIndex != 0 && req.EndIndex < req.StartIndex {
+		return status.Errorf(codes.InvalidArgument, "GetEntriesRequest.EndIndex: %v < StartIndex: %v, want >= ", req.EndIndex, req.StartIndex)
+	}
+	return nil
+}
+
+func validateGetSignedLogRootHistoryRequest(req *trillian.GetSignedLogRootHistoryRequest) error {
+	if req.StartRevision < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetSignedLogRootHistoryRequest.StartRevision: %v, want >= 0", req.StartRevision)
+	}
+	return nil
+}
+
 func validateQueueLeavesRequest(req *trillian.QueueLeavesRequest) error {
 	if len(req.Leaves) == 0 {
 		return status.Errorf(codes.InvalidArgument, "len(QueueLeavesRequest.Leaves)=0, want > 0")
 	}
 	return nil
 }
+
+// validateLeafQueueTimestamp checks that a client-supplied LogLeaf.QueueTimestamp, if present, is
+// accepted by the tree and lies within its skew window around now.
+func validateLeafQueueTimestamp(tree *trillian.Tree, leaf *trillian.LogLeaf, now time.Time) error {
+	if leaf.QueueTimestamp == nil {
+		return nil
+	}
+	var maxSkew time.Duration
+	if tree.MaxClientTimestampSkew != nil {
+		var err error
+		if maxSkew, err = ptypes.Duration(tree.MaxClientTimestampSkew); err != nil {
+			return status.Errorf(codes.Internal, "Tree.MaxClientTimestampSkew malformed: %v", err)
+		}
+	}
+	if maxSkew <= 0 {
+		return status.Errorf(codes.InvalidArgument, "LogLeaf.QueueTimestamp set, but tree %v does not accept client timestamps", tree.TreeId)
+	}
+	ts, err := ptypes.Timestamp(leaf.QueueTimestamp)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "LogLeaf.QueueTimestamp malformed: %v", err)
+	}
+	if skew := ts.Sub(now); skew > maxSkew || skew < -maxSkew {
+		return status.Errorf(codes.InvalidArgument, "LogLeaf.QueueTimestamp: %v is %v away from server time, want within %v", ts, skew, maxSkew)
+	}
+	return nil
+}
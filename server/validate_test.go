@@ -16,8 +16,13 @@ package server
 
 import (
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestGetConsistencyProofInvalidRequest(t *testing.T) {
@@ -136,3 +141,75 @@ func TestGetProofByHashInvalidRequests(t *testing.T) {
 		}
 	}
 }
+
+func TestGetProofByMerkleHashInvalidRequests(t *testing.T) {
+	for _, req := range []*trillian.GetProofByMerkleHashRequest{
+		{LogId: logID1, FirstTreeSize: 10, TreeSize: 50},
+		{LogId: logID1, LeafHash: []byte("data"), FirstTreeSize: 0, TreeSize: 50},
+		{LogId: logID1, LeafHash: []byte("data"), FirstTreeSize: 50, TreeSize: 50},
+		{LogId: logID1, LeafHash: []byte("data"), FirstTreeSize: 10, TreeSize: -50},
+	} {
+		if err := validateGetProofByMerkleHashRequest(req); err == nil {
+			t.Errorf("validateGetProofByMerkleHashRequest(%v): nil, want err", req)
+		}
+	}
+}
+
+func TestGetEntriesInvalidRequests(t *testing.T) {
+	for _, req := range []*trillian.GetEntriesRequest{
+		{LogId: logID1, StartIndex: -1},
+		{LogId: logID1, StartIndex: 0, EndIndex: -1},
+		{LogId: logID1, StartIndex: 10, EndIndex: 5},
+	} {
+		if err := validateGetEntriesRequest(req); err == nil {
+			t.Errorf("validateGetEntriesRequest(%v): nil, want err", req)
+		}
+	}
+}
+
+func TestGetSignedLogRootHistoryInvalidRequests(t *testing.T) {
+	req := &trillian.GetSignedLogRootHistoryRequest{LogId: logID1, StartRevision: -1}
+	if err := validateGetSignedLogRootHistoryRequest(req); err == nil {
+		t.Errorf("validateGetSignedLogRootHistoryRequest(%v): nil, want err", req)
+	}
+}
+
+func TestValidateLeafQueueTimestamp(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	tsProto := func(t time.Time) *timestamp.Timestamp {
+		ts, err := ptypes.TimestampProto(t)
+		if err != nil {
+			panic(err)
+		}
+		return ts
+	}
+	optIn := &trillian.Tree{TreeId: logID1, MaxClientTimestampSkew: ptypes.DurationProto(time.Hour)}
+	optOut := &trillian.Tree{TreeId: logID1}
+
+	for _, test := range []struct {
+		desc    string
+		tree    *trillian.Tree
+		leaf    *trillian.LogLeaf
+		wantErr bool
+	}{
+		{desc: "noTimestamp", tree: optOut, leaf: &trillian.LogLeaf{}},
+		{desc: "notAccepted", tree: optOut, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now)}, wantErr: true},
+		{desc: "now", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now)}},
+		{desc: "past", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now.Add(-59 * time.Minute))}},
+		{desc: "future", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now.Add(59 * time.Minute))}},
+		{desc: "tooOld", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now.Add(-61 * time.Minute))}, wantErr: true},
+		{desc: "tooNew", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: tsProto(now.Add(61 * time.Minute))}, wantErr: true},
+		{desc: "malformed", tree: optIn, leaf: &trillian.LogLeaf{QueueTimestamp: &timestamp.Timestamp{Nanos: -1}}, wantErr: true},
+	} {
+		err := validateLeafQueueTimestamp(test.tree, test.leaf, now)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: validateLeafQueueTimestamp() = %v, wantErr %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+				t.Errorf("%v: validateLeafQueueTimestamp() = %v, want code %v", test.desc, err, codes.InvalidArgument)
+			}
+		}
+	}
+}
@@ -85,10 +85,44 @@ type AdminReader interface {
 	// Note that there's no authorization restriction on the trees returned,
 	// so it should be used with caution in production code.
 	ListTrees(ctx context.Context) ([]*trillian.Tree, error)
+
+	// GetTreeFootprint returns an approximation of the storage used by
+	// treeID. Implementations may need to scan all of the tree's data, so
+	// it should be called sparingly.
+	GetTreeFootprint(ctx context.Context, treeID int64) (*TreeFootprint, error)
+
+	// ListPendingTrees returns the IDs of the trees that have queued leaves which aren't
+	// sequenced yet, mapped to the number of such leaves if countLeaves is true, or to zero
+	// otherwise. Implementations should answer from an aggregate query rather than by
+	// reading leaves, but it's still best called sparingly.
+	ListPendingTrees(ctx context.Context, countLeaves bool) (map[int64]int64, error)
+
+	// ListQuotaConfigs returns the configurations of all quota buckets.
+	ListQuotaConfigs(ctx context.Context) ([]*trillian.QuotaConfig, error)
+}
+
+// TreeFootprint approximates the storage used by a tree.
+type TreeFootprint struct {
+	// LeafCount is the number of sequenced leaves (logs) or leaf revisions
+	// (maps) stored.
+	LeafCount int64
+	// LeafDataBytes is the size of stored leaf values and extra data,
+	// including leaves that are yet to be sequenced.
+	LeafDataBytes int64
+	// SubtreeCount is the number of subtrees stored, across all revisions.
+	SubtreeCount int64
+	// SignedRootCount is the number of signed roots stored.
+	SignedRootCount int64
 }
 
 // AdminWriter provides a write-only interface for tree data.
 type AdminWriter interface {
+	// CountActiveTrees returns the number of trees that aren't deleted, so callers can
+	// limit the number of trees before calling CreateTree.
+	// The count must remain accurate until the transaction ends: implementations should
+	// lock as needed so that concurrent transactions can't create trees in the meantime.
+	CountActiveTrees(ctx context.Context) (int64, error)
+
 	// CreateTree inserts the specified tree in storage, returning a tree
 	// with all storage-generated fields set.
 	// Note that treeID and timestamps will be automatically generated by
@@ -105,4 +139,51 @@ type AdminWriter interface {
 	// Returns an error if the tree is invalid or the update cannot be
 	// performed.
 	UpdateTree(ctx context.Context, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, error)
+
+	// SoftDeleteTree marks the specified tree as SOFT_DELETED, setting its
+	// delete_time, and returns the deleted tree.
+	// Returns a FailedPrecondition error if the tree is deleted already.
+	SoftDeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error)
+
+	// UndeleteTree restores the specified SOFT_DELETED tree as FROZEN,
+	// clearing its delete_time, and returns the restored tree.
+	// Returns a FailedPrecondition error if the tree isn't SOFT_DELETED.
+	UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error)
+
+	// HardDeleteTree permanently removes the data of the specified
+	// SOFT_DELETED tree (leaves, nodes, roots, etc) and marks it as
+	// HARD_DELETED. The tree itself is kept, so its ID isn't reused.
+	// Returns a FailedPrecondition error if the tree isn't SOFT_DELETED.
+	HardDeleteTree(ctx context.Context, treeID int64) error
+
+	// RotateTreeKey sets the key_rotation of the specified LOG tree, replacing
+	// any rotation already scheduled, and returns the updated tree.
+	// Returns a FailedPrecondition error if the tree is deleted.
+	RotateTreeKey(ctx context.Context, treeID int64, rotation *trillian.KeyRotation) (*trillian.Tree, error)
+
+	// RetireTreeKey makes the key of the specified tree's key_rotation its
+	// private_key and public_key, appends the previous public_key to its
+	// key_history, and returns the updated tree. The previous key is recorded
+	// as having signed the roots below the rotation's activation_tree_size.
+	// Returns a FailedPrecondition error if the tree has no key_rotation or is
+	// deleted.
+	RetireTreeKey(ctx context.Context, treeID int64) (*trillian.Tree, error)
+
+	// CreateQuotaConfig inserts the specified quota bucket configuration in
+	// storage.
+	// Returns an error if cfg is invalid, or an AlreadyExists error if its
+	// bucket is configured already.
+	CreateQuotaConfig(ctx context.Context, cfg *trillian.QuotaConfig) (*trillian.QuotaConfig, error)
+
+	// UpdateQuotaConfig updates the configuration of bucket in storage,
+	// returning the updated configuration.
+	// updateFunc is called to perform the desired modifications, which
+	// mustn't change the bucket.
+	// Returns a NotFound error if bucket isn't configured, or an error if
+	// the updated configuration is invalid.
+	UpdateQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket, updateFunc func(*trillian.QuotaConfig)) (*trillian.QuotaConfig, error)
+
+	// DeleteQuotaConfig deletes the configuration of bucket from storage.
+	// Returns a NotFound error if bucket isn't configured.
+	DeleteQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket) error
 }
@@ -0,0 +1,312 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive exports logs to, and imports them from, a portable single-file archive.
+//
+// An archive holds everything needed to recreate a log on another server: the tree, the
+// latest signed root and every leaf covered by that root, in order. It's written and read as
+// a stream, so trees of any size can be archived without holding them in memory.
+//
+// The format is the magic string "TRILLIAN-ARCHIVE-1\n" followed by a sequence of protocol
+// buffers, each prefixed with its length as a uvarint: a trillian.Tree, a
+// trillian.SignedLogRoot, then one trillian.LogLeaf for each leaf in the root, by increasing
+// leaf index.
+//
+// The archived tree includes its private key configuration, which is needed to sign the roots
+// of the imported log. Archives must be protected accordingly.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+// magic identifies an archive and the version of its format.
+const magic = "TRILLIAN-ARCHIVE-1\n"
+
+// maxMessageSize bounds the size of a single message read from an archive, so that a corrupt
+// length prefix can't cause a huge allocation.
+const maxMessageSize = 64 << 20
+
+// chunkSize is the number of leaves read from storage, or queued and sequenced, at a time.
+var chunkSize = int64(1000)
+
+// Export returns a reader streaming an archive of the log treeID. Storage is read as the
+// archive is consumed, and any error reading it is returned by the reader.
+func Export(ctx context.Context, registry extension.Registry, treeID int64) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(export(ctx, registry, treeID, bufio.NewWriter(pw)))
+	}()
+	return pr
+}
+
+func export(ctx context.Context, registry extension.Registry, treeID int64, w *bufio.Writer) error {
+	tree, err := trees.GetTree(ctx, registry.AdminStorage, treeID, trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true})
+	if err != nil {
+		return err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	root, err := latestRoot(ctx, registry.LogStorage, treeID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.WriteString(magic); err != nil {
+		return err
+	}
+	if err := writeMessage(w, tree); err != nil {
+		return err
+	}
+	if err := writeMessage(w, &root); err != nil {
+		return err
+	}
+	for start := int64(0); start < root.TreeSize; start += chunkSize {
+		count := chunkSize
+		if start+count > root.TreeSize {
+			count = root.TreeSize - start
+		}
+		leaves, err := readLeaves(ctx, registry.LogStorage, treeID, start, count)
+		if err != nil {
+			return err
+		}
+		for _, leaf := range leaves {
+			if err := writeMessage(w, leaf); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// Import recreates the log archived in r as a new, active tree, and returns the tree. The
+// leaves are queued and sequenced in their archived order, and the import fails if the
+// resulting root doesn't match the archived root.
+func Import(ctx context.Context, registry extension.Registry, r io.Reader) (*trillian.Tree, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read archive header: %v", err)
+	}
+	if string(header) != magic {
+		return nil, errors.New("not a tree archive, or an unsupported version")
+	}
+
+	archivedTree := &trillian.Tree{}
+	if err := readMessage(br, archivedTree); err != nil {
+		return nil, fmt.Errorf("failed to read tree: %v", err)
+	}
+	if archivedTree.TreeType != trillian.TreeType_LOG {
+		return nil, fmt.Errorf("archived tree has type %v, only logs can be imported", archivedTree.TreeType)
+	}
+	archivedRoot := trillian.SignedLogRoot{}
+	if err := readMessage(br, &archivedRoot); err != nil {
+		return nil, fmt.Errorf("failed to read signed root: %v", err)
+	}
+	if err := trees.VerifySignature(archivedTree, crypto.HashLogRoot(archivedRoot), archivedRoot.Signature); err != nil {
+		return nil, fmt.Errorf("archived signed root doesn't verify: %v", err)
+	}
+
+	tree, err := createTree(ctx, registry.AdminStorage, archivedTree)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := trees.Signer(ctx, registry.SignerFactory, tree)
+	if err != nil {
+		return nil, err
+	}
+	qm := registry.QuotaManager
+	if qm == nil {
+		qm = quota.Noop()
+	}
+	seq := log.NewSequencer(hasher, util.SystemTimeSource{}, registry.LogStorage, signer, registry.MetricFactory, qm)
+	if err := seq.SignRoot(ctx, tree.TreeId); err != nil {
+		return nil, err
+	}
+
+	// Each leaf is queued at its own timestamp, ordered by leaf index and in the past, so
+	// that the sequencer dequeues all of them in their archived order.
+	base := time.Now().Add(-time.Duration(archivedRoot.TreeSize))
+	for start := int64(0); start < archivedRoot.TreeSize; start += chunkSize {
+		count := chunkSize
+		if start+count > archivedRoot.TreeSize {
+			count = archivedRoot.TreeSize - start
+		}
+		leaves := make([]*trillian.LogLeaf, count)
+		for i := range leaves {
+			leaf := &trillian.LogLeaf{}
+			if err := readMessage(br, leaf); err != nil {
+				return nil, fmt.Errorf("failed to read leaf %v: %v", start+int64(i), err)
+			}
+			if leaf.LeafIndex != start+int64(i) {
+				return nil, fmt.Errorf("got leaf %v at position %v of the archive", leaf.LeafIndex, start+int64(i))
+			}
+			leaves[i] = leaf
+		}
+		if err := queueLeaves(ctx, registry.LogStorage, tree.TreeId, leaves, base); err != nil {
+			return nil, err
+		}
+		n, err := seq.SequenceBatch(ctx, tree.TreeId, int(count), 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		if int64(n) != count {
+			return nil, fmt.Errorf("sequenced %v leaves from index %v, want %v", n, start, count)
+		}
+	}
+
+	root, err := latestRoot(ctx, registry.LogStorage, tree.TreeId)
+	if err != nil {
+		return nil, err
+	}
+	if root.TreeSize != archivedRoot.TreeSize || !bytes.Equal(root.RootHash, archivedRoot.RootHash) {
+		return nil, fmt.Errorf("imported tree %v has root hash %x at size %v, but archived root has hash %x at size %v", tree.TreeId, root.RootHash, root.TreeSize, archivedRoot.RootHash, archivedRoot.TreeSize)
+	}
+	return tree, nil
+}
+
+func createTree(ctx context.Context, as storage.AdminStorage, tree *trillian.Tree) (*trillian.Tree, error) {
+	tree = proto.Clone(tree).(*trillian.Tree)
+	tree.TreeId = 0
+	tree.TreeState = trillian.TreeState_ACTIVE
+	tree.CreateTime = nil
+	tree.UpdateTime = nil
+
+	tx, err := as.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	newTree, err := tx.CreateTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	return newTree, tx.Commit()
+}
+
+func latestRoot(ctx context.Context, ls storage.LogStorage, treeID int64) (trillian.SignedLogRoot, error) {
+	tx, err := ls.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		return trillian.SignedLogRoot{}, err
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return trillian.SignedLogRoot{}, err
+	}
+	return root, tx.Commit()
+}
+
+func readLeaves(ctx context.Context, ls storage.LogStorage, treeID, start, count int64) ([]*trillian.LogLeaf, error) {
+	indices := make([]int64, count)
+	for i := range indices {
+		indices[i] = start + int64(i)
+	}
+
+	tx, err := ls.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	leaves, err := tx.GetLeavesByIndex(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if got, want := len(leaves), len(indices); got != want {
+		return nil, fmt.Errorf("got %v leaves from index %v, want %v", got, start, want)
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].LeafIndex < leaves[j].LeafIndex })
+	return leaves, nil
+}
+
+func queueLeaves(ctx context.Context, ls storage.LogStorage, treeID int64, leaves []*trillian.LogLeaf, base time.Time) error {
+	tx, err := ls.BeginForTree(ctx, treeID)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	for _, leaf := range leaves {
+		queued := &trillian.LogLeaf{
+			MerkleLeafHash:   leaf.MerkleLeafHash,
+			LeafValue:        leaf.LeafValue,
+			ExtraData:        leaf.ExtraData,
+			LeafIdentityHash: leaf.LeafIdentityHash,
+		}
+		if _, err := tx.QueueLeaves(ctx, []*trillian.LogLeaf{queued}, base.Add(time.Duration(leaf.LeafIndex))); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func writeMessage(w io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(data)))
+	if _, err := w.Write(size[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func readMessage(r *bufio.Reader, msg proto.Message) error {
+	size, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if size > maxMessageSize {
+		return fmt.Errorf("message of %v bytes is larger than the maximum of %v", size, maxMessageSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,237 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+	"golang.org/x/net/context"
+)
+
+func newRegistry() extension.Registry {
+	ls := memory.NewLogStorage(nil)
+	return extension.Registry{
+		AdminStorage:  memory.NewAdminStorage(ls),
+		LogStorage:    ls,
+		SignerFactory: &keys.DefaultSignerFactory{},
+		QuotaManager:  quota.Noop(),
+	}
+}
+
+// createLog creates a log holding numLeaves leaves in registry.
+func createLog(ctx context.Context, t *testing.T, registry extension.Registry, numLeaves int) *trillian.Tree {
+	tree, err := createTree(ctx, registry.AdminStorage, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("createTree() = (_, %v), want (_, nil)", err)
+	}
+	signer, err := trees.Signer(ctx, registry.SignerFactory, tree)
+	if err != nil {
+		t.Fatalf("trees.Signer() = (_, %v), want (_, nil)", err)
+	}
+	seq := log.NewSequencer(rfc6962.DefaultHasher, util.SystemTimeSource{}, registry.LogStorage, signer, nil, quota.Noop())
+	if err := seq.SignRoot(ctx, tree.TreeId); err != nil {
+		t.Fatalf("SignRoot() = %v, want nil", err)
+	}
+
+	leaves := make([]*trillian.LogLeaf, numLeaves)
+	for i := range leaves {
+		value := []byte(fmt.Sprintf("leaf %d", i))
+		id := sha256.Sum256(value)
+		leaves[i] = &trillian.LogLeaf{
+			LeafValue:        value,
+			ExtraData:        []byte(fmt.Sprintf("extra %d", i)),
+			LeafIdentityHash: id[:],
+			MerkleLeafHash:   rfc6962.DefaultHasher.HashLeaf(value),
+			LeafIndex:        int64(i),
+		}
+	}
+	if err := queueLeaves(ctx, registry.LogStorage, tree.TreeId, leaves, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("queueLeaves() = %v, want nil", err)
+	}
+	if n, err := seq.SequenceBatch(ctx, tree.TreeId, numLeaves, 0, 0); err != nil || n != numLeaves {
+		t.Fatalf("SequenceBatch() = (%v, %v), want (%v, nil)", n, err, numLeaves)
+	}
+	return tree
+}
+
+func TestExportImport(t *testing.T) {
+	defer func(n int64) { chunkSize = n }(chunkSize)
+	chunkSize = 3
+
+	for _, numLeaves := range []int{0, 1, 3, 10} {
+		ctx := context.Background()
+		src := newRegistry()
+		srcTree := createLog(ctx, t, src, numLeaves)
+
+		archive, err := ioutil.ReadAll(Export(ctx, src, srcTree.TreeId))
+		if err != nil {
+			t.Fatalf("%v leaves: Export() failed: %v", numLeaves, err)
+		}
+
+		dst := newRegistry()
+		dstTree, err := Import(ctx, dst, bytes.NewReader(archive))
+		if err != nil {
+			t.Fatalf("%v leaves: Import() = (_, %v), want (_, nil)", numLeaves, err)
+		}
+		if got, want := dstTree.DisplayName, srcTree.DisplayName; got != want {
+			t.Errorf("%v leaves: imported tree has DisplayName %q, want %q", numLeaves, got, want)
+		}
+
+		srcRoot, err := latestRoot(ctx, src.LogStorage, srcTree.TreeId)
+		if err != nil {
+			t.Fatalf("%v leaves: latestRoot() = (_, %v), want (_, nil)", numLeaves, err)
+		}
+		dstRoot, err := latestRoot(ctx, dst.LogStorage, dstTree.TreeId)
+		if err != nil {
+			t.Fatalf("%v leaves: latestRoot() = (_, %v), want (_, nil)", numLeaves, err)
+		}
+		if dstRoot.TreeSize != srcRoot.TreeSize || !bytes.Equal(dstRoot.RootHash, srcRoot.RootHash) {
+			t.Errorf("%v leaves: imported root = %+v, want size and hash of %+v", numLeaves, dstRoot, srcRoot)
+		}
+		if numLeaves == 0 {
+			continue
+		}
+		srcLeaves, err := readLeaves(ctx, src.LogStorage, srcTree.TreeId, 0, int64(numLeaves))
+		if err != nil {
+			t.Fatalf("%v leaves: readLeaves() = (_, %v), want (_, nil)", numLeaves, err)
+		}
+		dstLeaves, err := readLeaves(ctx, dst.LogStorage, dstTree.TreeId, 0, int64(numLeaves))
+		if err != nil {
+			t.Fatalf("%v leaves: readLeaves() = (_, %v), want (_, nil)", numLeaves, err)
+		}
+		for i := range srcLeaves {
+			if !bytes.Equal(dstLeaves[i].LeafValue, srcLeaves[i].LeafValue) || !bytes.Equal(dstLeaves[i].ExtraData, srcLeaves[i].ExtraData) {
+				t.Errorf("%v leaves: imported leaf %v = %v, want %v", numLeaves, i, dstLeaves[i], srcLeaves[i])
+			}
+		}
+	}
+}
+
+func TestExportMissingTree(t *testing.T) {
+	if _, err := ioutil.ReadAll(Export(context.Background(), newRegistry(), 12345)); err == nil {
+		t.Error("Export() of a missing tree succeeded, want error")
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	ctx := context.Background()
+	src := newRegistry()
+	srcTree := createLog(ctx, t, src, 5)
+	archive, err := ioutil.ReadAll(Export(ctx, src, srcTree.TreeId))
+	if err != nil {
+		t.Fatalf("Export() failed: %v", err)
+	}
+
+	// modify decodes the archive, lets fn change its messages and encodes it again.
+	modify := func(fn func(tree *trillian.Tree, root *trillian.SignedLogRoot, leaves []*trillian.LogLeaf) []*trillian.LogLeaf) []byte {
+		r := bytes.NewReader(archive)
+		br := bufio.NewReader(r)
+		br.Discard(len(magic))
+		tree := &trillian.Tree{}
+		root := &trillian.SignedLogRoot{}
+		if err := readMessage(br, tree); err != nil {
+			t.Fatalf("readMessage() = %v", err)
+		}
+		if err := readMessage(br, root); err != nil {
+			t.Fatalf("readMessage() = %v", err)
+		}
+		leaves := make([]*trillian.LogLeaf, root.TreeSize)
+		for i := range leaves {
+			leaves[i] = &trillian.LogLeaf{}
+			if err := readMessage(br, leaves[i]); err != nil {
+				t.Fatalf("readMessage() = %v", err)
+			}
+		}
+		leaves = fn(tree, root, leaves)
+
+		var buf bytes.Buffer
+		buf.WriteString(magic)
+		msgs := []proto.Message{tree, root}
+		for _, leaf := range leaves {
+			msgs = append(msgs, leaf)
+		}
+		for _, msg := range msgs {
+			if err := writeMessage(&buf, msg); err != nil {
+				t.Fatalf("writeMessage() = %v", err)
+			}
+		}
+		return buf.Bytes()
+	}
+
+	for _, test := range []struct {
+		desc    string
+		archive []byte
+		wantErr string
+	}{
+		{desc: "empty", archive: nil, wantErr: "header"},
+		{desc: "badMagic", archive: append([]byte("TRILLIAN-ARCHIVE-9\n"), archive[len(magic):]...), wantErr: "not a tree archive"},
+		{desc: "truncated", archive: archive[:len(archive)-3], wantErr: "failed to read leaf 4"},
+		{
+			desc: "mapTree",
+			archive: modify(func(tree *trillian.Tree, _ *trillian.SignedLogRoot, leaves []*trillian.LogLeaf) []*trillian.LogLeaf {
+				tree.TreeType = trillian.TreeType_MAP
+				return leaves
+			}),
+			wantErr: "only logs",
+		},
+		{
+			desc: "tamperedRoot",
+			archive: modify(func(_ *trillian.Tree, root *trillian.SignedLogRoot, leaves []*trillian.LogLeaf) []*trillian.LogLeaf {
+				root.RootHash = []byte("not the root hash")
+				return leaves
+			}),
+			wantErr: "doesn't verify",
+		},
+		{
+			desc: "tamperedLeaf",
+			archive: modify(func(_ *trillian.Tree, _ *trillian.SignedLogRoot, leaves []*trillian.LogLeaf) []*trillian.LogLeaf {
+				leaves[2].MerkleLeafHash = rfc6962.DefaultHasher.HashLeaf([]byte("not leaf 2"))
+				return leaves
+			}),
+			wantErr: "archived root has hash",
+		},
+		{
+			desc: "reorderedLeaves",
+			archive: modify(func(_ *trillian.Tree, _ *trillian.SignedLogRoot, leaves []*trillian.LogLeaf) []*trillian.LogLeaf {
+				leaves[1], leaves[2] = leaves[2], leaves[1]
+				return leaves
+			}),
+			wantErr: "got leaf 2 at position 1",
+		},
+	} {
+		_, err := Import(ctx, newRegistry(), bytes.NewReader(test.archive))
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%v: Import() = (_, %v), want error containing %q", test.desc, err, test.wantErr)
+		}
+	}
+}
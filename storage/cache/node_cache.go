@@ -0,0 +1,136 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+)
+
+// nodeKey identifies a node of a tree as read at a tree revision.
+type nodeKey struct {
+	treeID       int64
+	treeRevision int64
+	nodeID       string
+}
+
+type nodeEntry struct {
+	key  nodeKey
+	node storage.Node
+}
+
+// NodeCache is a read-through cache of Merkle tree nodes, shared by concurrent readers of
+// any number of trees. It holds up to a fixed number of nodes, evicting the least recently
+// used ones first.
+//
+// Nodes are cached by tree, revision and node ID. The nodes read at a revision never change
+// once the revision is committed, so the cache must only be used for reads at committed
+// revisions, such as those of snapshot transactions.
+type NodeCache struct {
+	maxNodes int
+	hits     monitoring.Counter
+	misses   monitoring.Counter
+
+	mu      sync.Mutex
+	lru     *list.List // Of *nodeEntry, most recently used first.
+	entries map[nodeKey]*list.Element
+}
+
+// NewNodeCache returns a NodeCache holding up to maxNodes nodes, which must be positive.
+// Hits and misses are counted using mf, which may be nil.
+func NewNodeCache(maxNodes int, mf monitoring.MetricFactory) *NodeCache {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &NodeCache{
+		maxNodes: maxNodes,
+		hits:     mf.NewCounter("node_cache_hits", "Number of Merkle tree nodes read from the node cache"),
+		misses:   mf.NewCounter("node_cache_misses", "Number of Merkle tree nodes read from storage on node cache misses"),
+		lru:      list.New(),
+		entries:  make(map[nodeKey]*list.Element),
+	}
+}
+
+// GetMerkleNodes returns the nodes of tree treeID identified by ids at treeRevision, like
+// storage.NodeReader.GetMerkleNodes. Nodes missing from the cache are read from r, which
+// must read treeID, in a single call, and added to the cache.
+func (c *NodeCache) GetMerkleNodes(ctx context.Context, r storage.NodeReader, treeID, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	nodes := make([]storage.Node, len(ids))
+	var missIDs []storage.NodeID
+	var missPos []int
+
+	c.mu.Lock()
+	for i, id := range ids {
+		if e, ok := c.entries[nodeKey{treeID, treeRevision, id.String()}]; ok {
+			c.lru.MoveToFront(e)
+			nodes[i] = e.Value.(*nodeEntry).node
+			continue
+		}
+		missIDs = append(missIDs, id)
+		missPos = append(missPos, i)
+	}
+	c.mu.Unlock()
+	c.hits.Add(float64(len(ids) - len(missIDs)))
+	if len(missIDs) == 0 {
+		return nodes, nil
+	}
+	c.misses.Add(float64(len(missIDs)))
+
+	read, err := r.GetMerkleNodes(ctx, treeRevision, missIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(read) != len(missIDs) {
+		return nil, fmt.Errorf("expected %d nodes from storage but got %d", len(missIDs), len(read))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, node := range read {
+		nodes[missPos[i]] = node
+		// Callers check for storage returning the wrong nodes, but they mustn't be
+		// cached.
+		if node.NodeID.Equivalent(missIDs[i]) {
+			c.add(nodeKey{treeID, treeRevision, missIDs[i].String()}, node)
+		}
+	}
+	return nodes, nil
+}
+
+// add caches node under key, evicting the least recently used nodes if the cache is full.
+// c.mu must be held.
+func (c *NodeCache) add(key nodeKey, node storage.Node) {
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&nodeEntry{key: key, node: node})
+	for c.lru.Len() > c.maxNodes {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*nodeEntry).key)
+	}
+}
+
+// Len returns the number of cached nodes.
+func (c *NodeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
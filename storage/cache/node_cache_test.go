@@ -0,0 +1,130 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/trillian/storage"
+)
+
+// fakeNodeReader returns nodes whose hashes encode their revision and ID, and records the
+// IDs it reads.
+type fakeNodeReader struct {
+	reads []string
+	err   error
+}
+
+func (f *fakeNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	nodes := make([]storage.Node, 0, len(ids))
+	for _, id := range ids {
+		f.reads = append(f.reads, id.String())
+		nodes = append(nodes, storage.Node{NodeID: id, Hash: []byte(fmt.Sprintf("%d/%s", treeRevision, id.String()))})
+	}
+	return nodes, nil
+}
+
+func mustNodeID(t *testing.T, depth, index int64) storage.NodeID {
+	id, err := storage.NewNodeIDForTreeCoords(depth, index, 64)
+	if err != nil {
+		t.Fatalf("NewNodeIDForTreeCoords(%d, %d) = %v", depth, index, err)
+	}
+	return id
+}
+
+func TestNodeCache(t *testing.T) {
+	ctx := context.Background()
+	a, b, c := mustNodeID(t, 0, 1), mustNodeID(t, 1, 2), mustNodeID(t, 2, 3)
+	cache := NewNodeCache(2, nil)
+
+	for _, test := range []struct {
+		desc      string
+		treeID    int64
+		revision  int64
+		ids       []storage.NodeID
+		wantReads []storage.NodeID
+	}{
+		{desc: "empty", treeID: 1, revision: 5, ids: []storage.NodeID{a, b}, wantReads: []storage.NodeID{a, b}},
+		{desc: "hits", treeID: 1, revision: 5, ids: []storage.NodeID{b, a}},
+		{desc: "otherRevision", treeID: 1, revision: 6, ids: []storage.NodeID{a}, wantReads: []storage.NodeID{a}},
+		{desc: "otherTree", treeID: 2, revision: 5, ids: []storage.NodeID{b}, wantReads: []storage.NodeID{b}},
+		// a at revision 5 was the least recently used, so it was evicted.
+		{desc: "evicted", treeID: 1, revision: 5, ids: []storage.NodeID{c, a}, wantReads: []storage.NodeID{c, a}},
+		{desc: "partialHit", treeID: 1, revision: 5, ids: []storage.NodeID{b, a, c}, wantReads: []storage.NodeID{b}},
+	} {
+		r := &fakeNodeReader{}
+		nodes, err := cache.GetMerkleNodes(ctx, r, test.treeID, test.revision, test.ids)
+		if err != nil {
+			t.Fatalf("%v: GetMerkleNodes() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		for i, node := range nodes {
+			id := test.ids[i]
+			if want := fmt.Sprintf("%d/%s", test.revision, id.String()); string(node.Hash) != want || !node.NodeID.Equivalent(id) {
+				t.Errorf("%v: GetMerkleNodes()[%d] = %v with hash %q, want %v with hash %q", test.desc, i, node.NodeID, node.Hash, id, want)
+			}
+		}
+		var wantReads []string
+		for _, id := range test.wantReads {
+			wantReads = append(wantReads, id.String())
+		}
+		if got, want := fmt.Sprint(r.reads), fmt.Sprint(wantReads); got != want {
+			t.Errorf("%v: storage reads = %v, want %v", test.desc, got, want)
+		}
+		if got := cache.Len(); got > 2 {
+			t.Errorf("%v: Len() = %v, want <= 2", test.desc, got)
+		}
+	}
+}
+
+func TestNodeCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	id := mustNodeID(t, 0, 1)
+	cache := NewNodeCache(10, nil)
+
+	r := &fakeNodeReader{err: errors.New("read failed")}
+	if _, err := cache.GetMerkleNodes(ctx, r, 1, 1, []storage.NodeID{id}); err != r.err {
+		t.Errorf("GetMerkleNodes() = (_, %v), want (_, %v)", err, r.err)
+	}
+	if got := cache.Len(); got != 0 {
+		t.Errorf("Len() after failed read = %v, want 0", got)
+	}
+
+	// Nodes other than the ones requested aren't cached.
+	wrong := wrongNodeReader{mustNodeID(t, 3, 0)}
+	if _, err := cache.GetMerkleNodes(ctx, wrong, 1, 1, []storage.NodeID{id}); err != nil {
+		t.Errorf("GetMerkleNodes() = (_, %v), want (_, nil)", err)
+	}
+	if got := cache.Len(); got != 0 {
+		t.Errorf("Len() after reading the wrong node = %v, want 0", got)
+	}
+}
+
+// wrongNodeReader returns the same node, whatever is asked for.
+type wrongNodeReader struct {
+	id storage.NodeID
+}
+
+func (w wrongNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	nodes := make([]storage.Node, len(ids))
+	for i := range nodes {
+		nodes[i] = storage.Node{NodeID: w.id}
+	}
+	return nodes, nil
+}
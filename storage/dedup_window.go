@@ -0,0 +1,57 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+)
+
+// DedupWindowLimits converts w into the bounds of the window at now, for a log
+// with treeSize sequenced leaves. A sequenced leaf is within the window if its
+// index is at least minIndex and it was queued at or after minQueueNanos;
+// unsequenced leaves are always within it. ok is false if w is nil, meaning
+// leaves are deduplicated forever.
+func DedupWindowLimits(w *trillian.DedupWindow, treeSize int64, now time.Time) (minIndex, minQueueNanos int64, ok bool, err error) {
+	if w == nil {
+		return 0, 0, false, nil
+	}
+	// An unset limit mustn't exclude any leaves by itself, as leaves are only
+	// within the window if they're within both limits.
+	minQueueNanos = math.MinInt64
+	if w.MaxAge != nil {
+		maxAge, err := ptypes.Duration(w.MaxAge)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if maxAge > 0 {
+			minQueueNanos = now.Add(-maxAge).UnixNano()
+		}
+	}
+	if w.MaxLeaves > 0 && treeSize > w.MaxLeaves {
+		minIndex = treeSize - w.MaxLeaves
+	}
+	return minIndex, minQueueNanos, true, nil
+}
+
+// InDedupWindow returns true if a leaf sequenced at index, and queued at
+// queueNanos, is within the window bounded by minIndex and minQueueNanos, as
+// returned by DedupWindowLimits.
+func InDedupWindow(index, queueNanos, minIndex, minQueueNanos int64) bool {
+	return index >= minIndex && queueNanos >= minQueueNanos
+}
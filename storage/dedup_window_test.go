@@ -0,0 +1,85 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+)
+
+func TestDedupWindowLimits(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	hour := ptypes.DurationProto(time.Hour)
+	hourAgo := now.Add(-time.Hour).UnixNano()
+
+	tests := []struct {
+		desc              string
+		window            *trillian.DedupWindow
+		treeSize          int64
+		wantMinIndex      int64
+		wantMinQueueNanos int64
+		wantOK            bool
+	}{
+		{desc: "unset", treeSize: 100},
+		{desc: "leaves", window: &trillian.DedupWindow{MaxLeaves: 10}, treeSize: 100, wantMinIndex: 90, wantMinQueueNanos: math.MinInt64, wantOK: true},
+		{desc: "leavesExactlyFull", window: &trillian.DedupWindow{MaxLeaves: 100}, treeSize: 100, wantMinIndex: 0, wantMinQueueNanos: math.MinInt64, wantOK: true},
+		{desc: "leavesNotFull", window: &trillian.DedupWindow{MaxLeaves: 1000}, treeSize: 100, wantMinIndex: 0, wantMinQueueNanos: math.MinInt64, wantOK: true},
+		{desc: "age", window: &trillian.DedupWindow{MaxAge: hour}, treeSize: 100, wantMinIndex: 0, wantMinQueueNanos: hourAgo, wantOK: true},
+		{desc: "both", window: &trillian.DedupWindow{MaxLeaves: 10, MaxAge: hour}, treeSize: 100, wantMinIndex: 90, wantMinQueueNanos: hourAgo, wantOK: true},
+	}
+	for _, test := range tests {
+		minIndex, minQueueNanos, ok, err := DedupWindowLimits(test.window, test.treeSize, now)
+		if err != nil {
+			t.Errorf("%v: DedupWindowLimits() = %v", test.desc, err)
+			continue
+		}
+		if minIndex != test.wantMinIndex || minQueueNanos != test.wantMinQueueNanos || ok != test.wantOK {
+			t.Errorf("%v: DedupWindowLimits() = (%v, %v, %v), want (%v, %v, %v)", test.desc, minIndex, minQueueNanos, ok, test.wantMinIndex, test.wantMinQueueNanos, test.wantOK)
+		}
+	}
+}
+
+func TestInDedupWindow(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	window := &trillian.DedupWindow{MaxLeaves: 10, MaxAge: ptypes.DurationProto(time.Hour)}
+	minIndex, minQueueNanos, _, err := DedupWindowLimits(window, 100, now)
+	if err != nil {
+		t.Fatalf("DedupWindowLimits() = %v", err)
+	}
+	recent := now.Add(-time.Minute).UnixNano()
+
+	tests := []struct {
+		desc       string
+		index      int64
+		queueNanos int64
+		want       bool
+	}{
+		{desc: "latest", index: 99, queueNanos: recent, want: true},
+		{desc: "oldestIndex", index: 90, queueNanos: recent, want: true},
+		{desc: "beforeOldestIndex", index: 89, queueNanos: recent, want: false},
+		{desc: "oldestQueueTime", index: 99, queueNanos: now.Add(-time.Hour).UnixNano(), want: true},
+		{desc: "beforeOldestQueueTime", index: 99, queueNanos: now.Add(-time.Hour).UnixNano() - 1, want: false},
+		{desc: "outsideBoth", index: 0, queueNanos: 0, want: false},
+	}
+	for _, test := range tests {
+		if got := InDedupWindow(test.index, test.queueNanos, minIndex, minQueueNanos); got != test.want {
+			t.Errorf("%v: InDedupWindow(%v, %v) = %v, want %v", test.desc, test.index, test.queueNanos, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package factory creates storage for the storage system selected at runtime. Storage
+// systems register themselves with the factory, usually from an init function, so that
+// servers only need to import them to make them available:
+//
+//	import _ "github.com/google/trillian/storage/mysql" // Load MySQL storage
+//
+// Features that depend on the storage system, such as quota backed by the storage, are
+// offered through optional interfaces that Providers may implement, like QuotaProvider, so
+// that servers needn't know which storage system they run with.
+package factory
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util"
+)
+
+// Provider gives access to the storage of a single storage system.
+type Provider interface {
+	// AdminStorage returns the AdminStorage of the storage system.
+	AdminStorage() storage.AdminStorage
+	// LogStorage returns the LogStorage of the storage system.
+	LogStorage() storage.LogStorage
+	// MapStorage returns the MapStorage of the storage system.
+	MapStorage() storage.MapStorage
+	// Close releases any resources, such as database connections, held by the Provider.
+	Close() error
+}
+
+// QuotaProvider is implemented by Providers whose storage system backs a quota.Manager.
+type QuotaProvider interface {
+	// QuotaManager returns the quota.Manager of the storage system.
+	QuotaManager() quota.Manager
+}
+
+// TimeSourceProvider is implemented by Providers whose storage system has a clock of its
+// own, for timestamps that must agree with the timestamps set by the storage.
+type TimeSourceProvider interface {
+	// TimeSource returns a util.TimeSource reading the clock of the storage system, or
+	// fallback if the clock can't be read.
+	TimeSource(fallback util.TimeSource) util.TimeSource
+}
+
+// QuotaManager returns the quota.Manager of p if it's a QuotaProvider, or quota.Noop()
+// otherwise.
+func QuotaManager(p Provider) quota.Manager {
+	if qp, ok := p.(QuotaProvider); ok {
+		return qp.QuotaManager()
+	}
+	return quota.Noop()
+}
+
+// NewProviderFunc creates a Provider connected to uri. An empty uri selects the storage
+// system's default.
+type NewProviderFunc func(uri string, mf monitoring.MetricFactory) (Provider, error)
+
+var providers = make(map[string]NewProviderFunc)
+
+// Register makes a storage system available under name.
+// It panics if name is empty or already registered.
+func Register(name string, f NewProviderFunc) {
+	if name == "" {
+		panic("Register() of storage system with empty name")
+	}
+	if providers[name] != nil {
+		panic(fmt.Sprintf("storage system %q already registered", name))
+	}
+	providers[name] = f
+}
+
+// NewProvider creates a Provider for the storage system registered under name, connected
+// to uri.
+func NewProvider(name, uri string, mf monitoring.MetricFactory) (Provider, error) {
+	f := providers[name]
+	if f == nil {
+		return nil, fmt.Errorf("unknown storage system %q, registered systems are %v", name, Systems())
+	}
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return f(uri, mf)
+}
+
+// Systems returns the names of the registered storage systems, in sorted order.
+func Systems() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package factory
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+)
+
+type fakeProvider struct {
+	uri string
+}
+
+func (p *fakeProvider) AdminStorage() storage.AdminStorage { return nil }
+func (p *fakeProvider) LogStorage() storage.LogStorage     { return nil }
+func (p *fakeProvider) MapStorage() storage.MapStorage     { return nil }
+func (p *fakeProvider) Close() error                       { return nil }
+
+func TestNewProvider(t *testing.T) {
+	errFailing := errors.New("failed to connect")
+	Register("fake", func(uri string, mf monitoring.MetricFactory) (Provider, error) {
+		if mf == nil {
+			return nil, errors.New("nil MetricFactory")
+		}
+		return &fakeProvider{uri: uri}, nil
+	})
+	Register("failing", func(uri string, mf monitoring.MetricFactory) (Provider, error) {
+		return nil, errFailing
+	})
+	defer delete(providers, "fake")
+	defer delete(providers, "failing")
+
+	p, err := NewProvider("fake", "fake://uri", nil)
+	if err != nil {
+		t.Fatalf("NewProvider(fake) returned err = %v", err)
+	}
+	if got, want := p.(*fakeProvider).uri, "fake://uri"; got != want {
+		t.Errorf("NewProvider(fake) connected to %q, want %q", got, want)
+	}
+
+	if _, err := NewProvider("failing", "", monitoring.InertMetricFactory{}); err != errFailing {
+		t.Errorf("NewProvider(failing) returned err = %v, want %v", err, errFailing)
+	}
+
+	if _, err := NewProvider("unknown", "", nil); err == nil || !strings.Contains(err.Error(), "unknown storage system") {
+		t.Errorf("NewProvider(unknown) returned err = %v, want unknown storage system error", err)
+	}
+
+	if got, want := Systems(), []string{"failing", "fake"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Systems() = %v, want %v", got, want)
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	newFake := func(string, monitoring.MetricFactory) (Provider, error) { return &fakeProvider{}, nil }
+	Register("duplicate", newFake)
+	defer delete(providers, "duplicate")
+
+	for _, name := range []string{"", "duplicate"} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Register(%q) didn't panic", name)
+				}
+			}()
+			Register(name, newFake)
+		}()
+	}
+}
+
+type fakeQuotaProvider struct {
+	fakeProvider
+	qm quota.Manager
+}
+
+func (p *fakeQuotaProvider) QuotaManager() quota.Manager { return p.qm }
+
+func TestQuotaManager(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	qm := quota.NewMockManager(ctrl)
+
+	if got, want := QuotaManager(&fakeQuotaProvider{qm: qm}), quota.Manager(qm); got != want {
+		t.Errorf("QuotaManager(QuotaProvider) = %v, want %v", got, want)
+	}
+	if got, want := QuotaManager(&fakeProvider{}), quota.Noop(); !reflect.DeepEqual(got, want) {
+		t.Errorf("QuotaManager(Provider) = %v, want %v", got, want)
+	}
+}
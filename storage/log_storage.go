@@ -43,6 +43,9 @@ type ReadOnlyLogTreeTX interface {
 	ReadOnlyTreeTX
 	LeafReader
 	LogRootReader
+	CosignatureReader
+	TimestampTokenReader
+	DeadLetterReader
 }
 
 // LogTreeTX is the transactional interface for reading/updating a Log.
@@ -54,6 +57,12 @@ type LogTreeTX interface {
 	TreeTX
 	LogRootReader
 	LogRootWriter
+	CosignatureReader
+	CosignatureWriter
+	TimestampTokenReader
+	TimestampTokenWriter
+	DeadLetterReader
+	DeadLetterWriter
 	LeafReader
 	LeafQueuer
 	LeafDequeuer
@@ -93,8 +102,15 @@ type LeafQueuer interface {
 	//  - the existing leaf entry if a duplicate has been submitted
 	//  - nil otherwise.
 	// Duplicates are only reported if the underlying tree does not permit duplicates, and are
-	// considered duplicate if their leaf.LeafIdentityHash matches.
+	// considered duplicate if their leaf.LeafIdentityHash matches, and the matching leaf
+	// is within the tree's DedupWindow if it has one.
+	// Leaves are queued at queueTimestamp, unless leaf.QueueTimestamp is already set.
 	QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error)
+	// PruneDedupIndex forgets the identity hashes of the sequenced leaves of a tree with a
+	// DedupWindow that are outside the window, as bounded by minIndex and minQueueNanos
+	// (see DedupWindowLimits), returning the number of identity hashes forgotten. Leaves
+	// outside the window are never duplicates, so this only reclaims space.
+	PruneDedupIndex(ctx context.Context, minIndex, minQueueNanos int64) (int64, error)
 }
 
 // LeafDequeuer provides an interface for reading previously queued leaves for integration into the tree.
@@ -119,22 +135,119 @@ type LeafReader interface {
 	// same hash but different sequence numbers. If orderBySequence is true then the returned data
 	// will be in ascending sequence number order.
 	GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error)
+	// HasLeaves reports, for each of the given leaf identity hashes, whether a leaf with that
+	// identity hash has been sequenced or, if includeUnsequenced is true, queued. The result is
+	// in the same order as leafIdentityHashes.
+	HasLeaves(ctx context.Context, leafIdentityHashes [][]byte, includeUnsequenced bool) ([]bool, error)
+	// GetLatestLeafByIdentityHashPrefix returns the sequenced leaf with the highest index whose
+	// identity hash starts with prefix, or nil if there's no such leaf.
+	GetLatestLeafByIdentityHashPrefix(ctx context.Context, prefix []byte) (*trillian.LogLeaf, error)
+	// CountLeaves returns the number of leaves matching filter. Counts including leaves that
+	// are queued but not yet sequenced are best-effort, as they change while leaves are queued
+	// and sequenced.
+	CountLeaves(ctx context.Context, filter LeafFilter) (int64, error)
+}
+
+// LeafFilter selects the leaves counted by CountLeaves. Zero-valued fields don't restrict the
+// leaves counted.
+type LeafFilter struct {
+	// MinQueueTimestampNanos is the earliest time, inclusive, that counted leaves were queued.
+	MinQueueTimestampNanos int64
+	// MaxQueueTimestampNanos is the latest time, exclusive, that counted leaves were queued.
+	MaxQueueTimestampNanos int64
+	// LeafIdentityHashPrefix is the prefix of the identity hash of counted leaves.
+	LeafIdentityHashPrefix []byte
+	// SequencedOnly restricts the count to leaves that have been sequenced.
+	SequencedOnly bool
+	// UnsequencedOnly restricts the count to leaves that are queued but not yet sequenced.
+	// It can't be combined with SequencedOnly.
+	UnsequencedOnly bool
 }
 
 // LogRootReader provides an interface for reading SignedLogRoots.
 type LogRootReader interface {
 	// LatestSignedLogRoot returns the most recent SignedLogRoot, if any.
 	LatestSignedLogRoot(ctx context.Context) (trillian.SignedLogRoot, error)
+	// SignedLogRootAtTime returns the SignedLogRoot with the latest timestamp not after
+	// timestampNanos, or an empty root if there's no such root.
+	SignedLogRootAtTime(ctx context.Context, timestampNanos int64) (trillian.SignedLogRoot, error)
+	// SignedLogRootAtSize returns the latest SignedLogRoot with exactly treeSize leaves, or
+	// an empty root if there's no such root.
+	SignedLogRootAtSize(ctx context.Context, treeSize int64) (trillian.SignedLogRoot, error)
+	// SignedLogRoots returns up to limit SignedLogRoots with a tree revision greater than
+	// afterRevision, by increasing tree revision. Tree sizes never decrease with the revision,
+	// so the roots are also in size order.
+	SignedLogRoots(ctx context.Context, afterRevision int64, limit int) ([]trillian.SignedLogRoot, error)
 }
 
 // LogRootWriter provides an interface for storing new SignedLogRoots.
 type LogRootWriter interface {
 	// StoreSignedLogRoot stores a freshly created SignedLogRoot.
 	StoreSignedLogRoot(ctx context.Context, root trillian.SignedLogRoot) error
+	// PruneSignedLogRoots deletes the SignedLogRoots that are neither among the keepCount
+	// most recent roots nor timestamped at or after keepAfterNanos, returning the number of
+	// roots deleted. The latest root and roots that have been cosigned are never deleted.
+	PruneSignedLogRoots(ctx context.Context, keepCount, keepAfterNanos int64) (int64, error)
+}
+
+// CosignatureReader provides an interface for reading witness cosignatures of SignedLogRoots.
+type CosignatureReader interface {
+	// LatestCosignedLogRoot returns the most recent SignedLogRoot that has been cosigned by
+	// at least one witness, along with its cosignatures. If nothing has been cosigned an empty
+	// root and no cosignatures are returned.
+	LatestCosignedLogRoot(ctx context.Context) (trillian.SignedLogRoot, []*trillian.Cosignature, error)
+}
+
+// CosignatureWriter provides an interface for storing witness cosignatures of SignedLogRoots.
+type CosignatureWriter interface {
+	// StoreCosignature stores a cosignature of the SignedLogRoot at treeRevision, replacing
+	// any earlier cosignature of that root by the same witness.
+	StoreCosignature(ctx context.Context, treeRevision int64, cosig *trillian.Cosignature) error
+}
+
+// TimestampTokenReader provides an interface for reading the timestamp authority tokens of
+// SignedLogRoots.
+type TimestampTokenReader interface {
+	// GetTimestampToken returns the timestamp token of the SignedLogRoot at treeRevision, or
+	// nil if it has none.
+	GetTimestampToken(ctx context.Context, treeRevision int64) ([]byte, error)
+	// LatestTimestampedRevision returns the highest tree revision whose SignedLogRoot has a
+	// timestamp token, or -1 if no root has one.
+	LatestTimestampedRevision(ctx context.Context) (int64, error)
+}
+
+// TimestampTokenWriter provides an interface for storing the timestamp authority tokens of
+// SignedLogRoots.
+type TimestampTokenWriter interface {
+	// StoreTimestampToken stores the timestamp token of the SignedLogRoot at treeRevision,
+	// replacing any earlier token of that root. Tokens are deleted along with their roots.
+	StoreTimestampToken(ctx context.Context, treeRevision int64, token []byte) error
+}
+
+// DeadLetterReader provides an interface for reading the dead-lettered leaves of a log.
+type DeadLetterReader interface {
+	// GetDeadLetteredLeaves returns the leaves that have been dead-lettered, ordered by the
+	// time they were dead-lettered.
+	GetDeadLetteredLeaves(ctx context.Context) ([]*trillian.DeadLetteredLeaf, error)
+}
+
+// DeadLetterWriter provides an interface for moving leaves between the queue and the
+// dead-lettered leaves of a log.
+type DeadLetterWriter interface {
+	// DeadLetterLeaves sets aside leaves that were dequeued but can't be sequenced. They
+	// won't be dequeued again unless requeued.
+	DeadLetterLeaves(ctx context.Context, leaves []*trillian.DeadLetteredLeaf) error
+	// RequeueDeadLetteredLeaves queues the dead-lettered leaves with the given identity hashes
+	// again at queueTimestamp, returning the number of leaves requeued. Hashes that don't
+	// belong to a dead-lettered leaf are ignored.
+	RequeueDeadLetteredLeaves(ctx context.Context, leafIdentityHashes [][]byte, queueTimestamp time.Time) (int, error)
 }
 
 // LogMetadata provides access to information about the logs in storage
 type LogMetadata interface {
 	// GetActiveLogs returns a list of the IDs of all the logs that are configured in storage
 	GetActiveLogIDs(ctx context.Context) ([]int64, error)
+	// GetActiveLogIDsWithPendingWork returns a list of the IDs of all the logs that have
+	// unsequenced leaves queued.
+	GetActiveLogIDsWithPendingWork(ctx context.Context) ([]int64, error)
 }
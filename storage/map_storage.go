@@ -16,6 +16,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/trillian"
 )
@@ -53,6 +54,13 @@ type MapTreeTX interface {
 	MapRootWriter
 	Getter
 	Setter
+	MapLeafQueuer
+
+	// PruneRevisions deletes the leaf and subtree versions that are only needed to read
+	// revisions before minRevision, i.e. those superseded by a newer version at or before
+	// minRevision, and returns the number of versions deleted. Reads at minRevision and later
+	// are unaffected.
+	PruneRevisions(ctx context.Context, minRevision int64) (int64, error)
 }
 
 // ReadOnlyMapStorage provides a narrow read-only view into a MapStorage.
@@ -85,6 +93,16 @@ type Setter interface {
 	Set(ctx context.Context, keyHash []byte, value trillian.MapLeaf) error
 }
 
+// MapLeafQueuer queues leaves to be set in a later revision of the map.
+type MapLeafQueuer interface {
+	// QueueMapLeaves queues leaves, in order, to be set in a later revision of the map.
+	QueueMapLeaves(ctx context.Context, leaves []*trillian.MapLeaf, queueTimestamp time.Time) error
+	// DequeueMapLeaves removes up to limit of the oldest queued leaves from the queue, and
+	// returns them in queue order. Like all changes, the removal only takes effect if the
+	// transaction commits.
+	DequeueMapLeaves(ctx context.Context, limit int) ([]*trillian.MapLeaf, error)
+}
+
 // Getter allows access to the values stored in the map.
 type Getter interface {
 	// Get retrieves the values associates with the keyHashes, if any, at the
@@ -109,4 +127,8 @@ type MapRootReader interface {
 type MapRootWriter interface {
 	// StoreSignedMapRoot stores root.
 	StoreSignedMapRoot(ctx context.Context, root trillian.SignedMapRoot) error
+	// PruneSignedMapRoots deletes the SignedMapRoots that are neither among the keepCount
+	// most recent roots nor timestamped at or after keepAfterNanos, returning the number of
+	// roots deleted. The latest root is never deleted.
+	PruneSignedMapRoots(ctx context.Context, keepCount, keepAfterNanos int64) (int64, error)
 }
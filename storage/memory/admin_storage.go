@@ -15,14 +15,19 @@
 package memory
 
 import (
+	"container/list"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/google/btree"
 	"github.com/google/trillian"
+	"github.com/google/trillian/errors"
 	"github.com/google/trillian/storage"
 )
 
@@ -100,12 +105,11 @@ func (t *adminTX) Close() error {
 
 func (t *adminTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
 	tree := t.ms.getTree(treeID)
-	tree.RLock()
-	defer tree.RUnlock()
-
 	if tree == nil {
-		return nil, fmt.Errorf("no such treeID %d", treeID)
+		return nil, errors.Errorf(errors.NotFound, "tree %v not found", treeID)
 	}
+	tree.RLock()
+	defer tree.RUnlock()
 	return tree.meta, nil
 }
 
@@ -131,6 +135,83 @@ func (t *adminTX) ListTrees(ctx context.Context) ([]*trillian.Tree, error) {
 	return ret, nil
 }
 
+func (t *adminTX) GetTreeFootprint(ctx context.Context, treeID int64) (*storage.TreeFootprint, error) {
+	tree := t.ms.getTree(treeID)
+	if tree == nil {
+		return nil, errors.Errorf(errors.NotFound, "tree %v not found", treeID)
+	}
+	tree.RLock()
+	defer tree.RUnlock()
+
+	var (
+		fp          storage.TreeFootprint
+		seqPrefix   = fmt.Sprintf("/%d/seq/", treeID)
+		stPrefix    = fmt.Sprintf("/%d/subtree/", treeID)
+		sthPrefix   = fmt.Sprintf("/%d/sth/", treeID)
+		leafDataLen = func(l *trillian.LogLeaf) int64 {
+			return int64(len(l.LeafValue) + len(l.ExtraData))
+		}
+	)
+	tree.store.Ascend(func(i btree.Item) bool {
+		item := i.(*kv)
+		switch {
+		case strings.HasPrefix(item.k, seqPrefix):
+			fp.LeafCount++
+			fp.LeafDataBytes += leafDataLen(item.v.(*trillian.LogLeaf))
+		case strings.HasPrefix(item.k, stPrefix):
+			fp.SubtreeCount++
+		case strings.HasPrefix(item.k, sthPrefix):
+			fp.SignedRootCount++
+		}
+		return true
+	})
+	if q := tree.store.Get(unseqKey(treeID)); q != nil {
+		for e := q.(*kv).v.(*list.List).Front(); e != nil; e = e.Next() {
+			fp.LeafDataBytes += leafDataLen(e.Value.(*trillian.LogLeaf))
+		}
+	}
+	return &fp, nil
+}
+
+func (t *adminTX) ListPendingTrees(ctx context.Context, countLeaves bool) (map[int64]int64, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	pending := make(map[int64]int64)
+	for id, tree := range t.ms.trees {
+		tree.RLock()
+		q := tree.store.Get(unseqKey(id))
+		tree.RUnlock()
+		if q == nil {
+			continue
+		}
+		n := int64(q.(*kv).v.(*list.List).Len())
+		if n == 0 {
+			continue
+		}
+		if !countLeaves {
+			n = 0
+		}
+		pending[id] = n
+	}
+	return pending, nil
+}
+
+// CountActiveTrees counts the trees that aren't deleted. As memory storage has no real
+// transactions, the count isn't protected from concurrent CreateTree calls.
+func (t *adminTX) CountActiveTrees(ctx context.Context) (int64, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	var count int64
+	for _, v := range t.ms.trees {
+		if !storage.IsDeleted(v.meta.TreeState) {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (t *adminTX) CreateTree(ctx context.Context, tr *trillian.Tree) (*trillian.Tree, error) {
 	if err := storage.ValidateTreeForCreation(tr); err != nil {
 		return nil, err
@@ -159,6 +240,13 @@ func (t *adminTX) CreateTree(ctx context.Context, tr *trillian.Tree) (*trillian.
 
 	t.ms.mu.Lock()
 	defer t.ms.mu.Unlock()
+	if origin := meta.CheckpointOrigin; origin != "" {
+		for _, other := range t.ms.trees {
+			if other.meta.CheckpointOrigin == origin {
+				return nil, errors.Errorf(errors.AlreadyExists, "checkpoint_origin already in use: %q", origin)
+			}
+		}
+	}
 	t.ms.trees[id] = newTree(meta)
 
 	glog.Infof("trees: %v", t.ms.trees)
@@ -168,6 +256,9 @@ func (t *adminTX) CreateTree(ctx context.Context, tr *trillian.Tree) (*trillian.
 
 func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, error) {
 	mTree := t.ms.getTree(treeID)
+	if mTree == nil {
+		return nil, errors.Errorf(errors.NotFound, "tree %v not found", treeID)
+	}
 	mTree.mu.Lock()
 	defer mTree.mu.Unlock()
 
@@ -189,6 +280,184 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	return tree, nil
 }
 
+func (t *adminTX) SoftDeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	return t.changeTree(treeID, func(tree *trillian.Tree) error {
+		if storage.IsDeleted(tree.TreeState) {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v is already %s", treeID, tree.TreeState)
+		}
+		deleteTime, err := ptypes.TimestampProto(time.Now())
+		if err != nil {
+			return err
+		}
+		tree.TreeState = trillian.TreeState_SOFT_DELETED
+		tree.DeleteTime = deleteTime
+		tree.DrainDeadline = nil
+		return nil
+	})
+}
+
+func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	return t.changeTree(treeID, func(tree *trillian.Tree) error {
+		if tree.TreeState != trillian.TreeState_SOFT_DELETED {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v is %s, only SOFT_DELETED trees can be undeleted", treeID, tree.TreeState)
+		}
+		tree.TreeState = trillian.TreeState_FROZEN
+		tree.DeleteTime = nil
+		return nil
+	})
+}
+
+func (t *adminTX) HardDeleteTree(ctx context.Context, treeID int64) error {
+	mTree := t.ms.getTree(treeID)
+	if mTree == nil {
+		return errors.Errorf(errors.NotFound, "tree %v not found", treeID)
+	}
+	if _, err := t.changeTree(treeID, func(tree *trillian.Tree) error {
+		if tree.TreeState != trillian.TreeState_SOFT_DELETED {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v is %s, only SOFT_DELETED trees can be hard-deleted", treeID, tree.TreeState)
+		}
+		// Replace the tree's data with that of a new, empty tree.
+		empty := newTree(*tree)
+		mTree.store = empty.store
+		mTree.currentSTH = 0
+		tree.TreeState = trillian.TreeState_HARD_DELETED
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+	for key := range t.ms.quotaConfigs {
+		if key.treeID == treeID {
+			delete(t.ms.quotaConfigs, key)
+		}
+	}
+	return nil
+}
+
+func (t *adminTX) RotateTreeKey(ctx context.Context, treeID int64, rotation *trillian.KeyRotation) (*trillian.Tree, error) {
+	return t.changeTree(treeID, func(tree *trillian.Tree) error {
+		if storage.IsDeleted(tree.TreeState) {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v is %s", treeID, tree.TreeState)
+		}
+		tree.KeyRotation = rotation
+		return storage.ValidateKeyRotation(tree)
+	})
+}
+
+func (t *adminTX) RetireTreeKey(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	return t.changeTree(treeID, func(tree *trillian.Tree) error {
+		if storage.IsDeleted(tree.TreeState) {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v is %s", treeID, tree.TreeState)
+		}
+		if tree.KeyRotation == nil {
+			return errors.Errorf(errors.FailedPrecondition, "tree %v has no key rotation", treeID)
+		}
+		tree.KeyHistory = storage.RetireKey(tree)
+		tree.PrivateKey = tree.KeyRotation.PrivateKey
+		tree.PublicKey = tree.KeyRotation.PublicKey
+		tree.KeyRotation = nil
+		return nil
+	})
+}
+
+// changeTree changes treeID with updateFunc, which returns an error if the tree's current
+// state doesn't allow the change. Unlike UpdateTree, it may change readonly fields.
+func (t *adminTX) changeTree(treeID int64, updateFunc func(*trillian.Tree) error) (*trillian.Tree, error) {
+	mTree := t.ms.getTree(treeID)
+	if mTree == nil {
+		return nil, errors.Errorf(errors.NotFound, "tree %v not found", treeID)
+	}
+	mTree.mu.Lock()
+	defer mTree.mu.Unlock()
+
+	tree := *mTree.meta
+	if err := updateFunc(&tree); err != nil {
+		return nil, err
+	}
+	var err error
+	tree.UpdateTime, err = ptypes.TimestampProto(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	*mTree.meta = tree
+	return &tree, nil
+}
+
+// quotaBucketKey identifies a quota bucket in memoryTreeStorage.quotaConfigs.
+type quotaBucketKey struct {
+	group, kind string
+	treeID      int64
+	user        string
+}
+
+func bucketKey(bucket *trillian.QuotaBucket) quotaBucketKey {
+	return quotaBucketKey{group: bucket.Group, kind: bucket.Kind, treeID: bucket.TreeId, user: bucket.User}
+}
+
+func (t *adminTX) ListQuotaConfigs(ctx context.Context) ([]*trillian.QuotaConfig, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	var ret []*trillian.QuotaConfig
+	for _, cfg := range t.ms.quotaConfigs {
+		ret = append(ret, proto.Clone(cfg).(*trillian.QuotaConfig))
+	}
+	return ret, nil
+}
+
+func (t *adminTX) CreateQuotaConfig(ctx context.Context, cfg *trillian.QuotaConfig) (*trillian.QuotaConfig, error) {
+	if err := storage.ValidateQuotaConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+	key := bucketKey(cfg.Bucket)
+	if _, ok := t.ms.quotaConfigs[key]; ok {
+		return nil, errors.Errorf(errors.AlreadyExists, "quota bucket already configured: %v", cfg.Bucket)
+	}
+	t.ms.quotaConfigs[key] = proto.Clone(cfg).(*trillian.QuotaConfig)
+	return proto.Clone(cfg).(*trillian.QuotaConfig), nil
+}
+
+func (t *adminTX) UpdateQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket, updateFunc func(*trillian.QuotaConfig)) (*trillian.QuotaConfig, error) {
+	if err := storage.ValidateQuotaBucket(bucket); err != nil {
+		return nil, err
+	}
+
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+	key := bucketKey(bucket)
+	cfg, ok := t.ms.quotaConfigs[key]
+	if !ok {
+		return nil, errors.Errorf(errors.NotFound, "quota bucket not configured: %v", bucket)
+	}
+	updated := proto.Clone(cfg).(*trillian.QuotaConfig)
+	updateFunc(updated)
+	if err := storage.ValidateQuotaConfigForUpdate(cfg, updated); err != nil {
+		return nil, err
+	}
+	t.ms.quotaConfigs[key] = updated
+	return proto.Clone(updated).(*trillian.QuotaConfig), nil
+}
+
+func (t *adminTX) DeleteQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket) error {
+	if err := storage.ValidateQuotaBucket(bucket); err != nil {
+		return err
+	}
+
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+	key := bucketKey(bucket)
+	if _, ok := t.ms.quotaConfigs[key]; !ok {
+		return errors.Errorf(errors.NotFound, "quota bucket not configured: %v", bucket)
+	}
+	delete(t.ms.quotaConfigs, key)
+	return nil
+}
+
 func validateStorageSettings(tree *trillian.Tree) error {
 	if tree.StorageSettings != nil {
 		return fmt.Errorf("storage_settings not supported, but got %v", tree.StorageSettings)
@@ -0,0 +1,100 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+)
+
+func TestMemoryAdminStorage(t *testing.T) {
+	tester := &testonly.AdminStorageTester{NewAdminStorage: func() storage.AdminStorage {
+		return NewAdminStorage(NewLogStorage(nil))
+	}}
+	// Not TestAdminTXClose: memory storage doesn't roll back transactions.
+	t.Run("TestCreateTree", tester.TestCreateTree)
+	t.Run("TestCreateTreeDuplicateOrigin", tester.TestCreateTreeDuplicateOrigin)
+	t.Run("TestCountActiveTrees", tester.TestCountActiveTrees)
+	t.Run("TestUpdateTree", tester.TestUpdateTree)
+	t.Run("TestListTrees", tester.TestListTrees)
+	t.Run("TestQuotaConfigs", tester.TestQuotaConfigs)
+	t.Run("TestDeleteTree", tester.TestDeleteTree)
+	t.Run("TestKeyRotation", tester.TestKeyRotation)
+}
+
+func TestListPendingTrees(t *testing.T) {
+	ctx := context.Background()
+	ls := NewLogStorage(nil)
+	as := NewAdminStorage(ls)
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		atx, err := as.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Begin() returned err = %v", err)
+		}
+		tree, err := atx.CreateTree(ctx, testonly.LogTree)
+		if err != nil {
+			t.Fatalf("CreateTree() returned err = %v", err)
+		}
+		if err := atx.Commit(); err != nil {
+			t.Fatalf("Commit() returned err = %v", err)
+		}
+		ids = append(ids, tree.TreeId)
+	}
+
+	// Only the first tree gets queued leaves.
+	tx, err := ls.BeginForTree(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("BeginForTree() returned err = %v", err)
+	}
+	leaves := []*trillian.LogLeaf{
+		{LeafIdentityHash: make([]byte, 32), LeafValue: []byte("a")},
+		{LeafIdentityHash: make([]byte, 32), LeafValue: []byte("b")},
+	}
+	if _, err := tx.QueueLeaves(ctx, leaves, time.Now()); err != nil {
+		t.Fatalf("QueueLeaves() returned err = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() returned err = %v", err)
+	}
+
+	for _, countLeaves := range []bool{false, true} {
+		want := map[int64]int64{ids[0]: 0}
+		if countLeaves {
+			want[ids[0]] = int64(len(leaves))
+		}
+		atx, err := as.Snapshot(ctx)
+		if err != nil {
+			t.Fatalf("Snapshot() returned err = %v", err)
+		}
+		got, err := atx.ListPendingTrees(ctx, countLeaves)
+		if err != nil {
+			t.Fatalf("ListPendingTrees(%v) returned err = %v", countLeaves, err)
+		}
+		if err := atx.Commit(); err != nil {
+			t.Fatalf("Commit() returned err = %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ListPendingTrees(%v) = %v, want %v", countLeaves, got, want)
+		}
+	}
+}
@@ -21,10 +21,13 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/btree"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle/hashers"
@@ -69,6 +72,18 @@ func sthKey(treeID, timestamp int64) btree.Item {
 	return &kv{k: fmt.Sprintf("/%d/sth/%020d", treeID, timestamp)}
 }
 
+func cosigKey(treeID, treeRevision int64, witness string) btree.Item {
+	return &kv{k: fmt.Sprintf("/%d/cosig/%020d/%s", treeID, treeRevision, witness)}
+}
+
+func tokenKey(treeID, treeRevision int64) btree.Item {
+	return &kv{k: fmt.Sprintf("/%d/tst/%020d", treeID, treeRevision)}
+}
+
+func deadLetterKey(treeID int64, leafIdentityHash []byte) btree.Item {
+	return &kv{k: fmt.Sprintf("/%d/dead/%x", treeID, leafIdentityHash)}
+}
+
 type memoryLogStorage struct {
 	*memoryTreeStorage
 	admin         storage.AdminStorage
@@ -123,6 +138,28 @@ func (t *readOnlyLogTX) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
 	return ret, nil
 }
 
+func (t *readOnlyLogTX) GetActiveLogIDsWithPendingWork(ctx context.Context) ([]int64, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	var ret []int64
+	for k, tree := range t.ms.trees {
+		tree.RLock()
+		pending := hasUnsequenced(tree.store, k)
+		tree.RUnlock()
+		if pending {
+			ret = append(ret, k)
+		}
+	}
+	return ret, nil
+}
+
+// hasUnsequenced returns whether store holds queued leaves for treeID.
+func hasUnsequenced(store *btree.BTree, treeID int64) bool {
+	item := store.Get(unseqKey(treeID))
+	return item != nil && item.(*kv).v.(*list.List).Len() > 0
+}
+
 func (m *memoryLogStorage) beginInternal(ctx context.Context, treeID int64, readonly bool) (storage.LogTreeTX, error) {
 	once.Do(func() {
 		createMetrics(m.metricFactory)
@@ -214,6 +251,13 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 	k := unseqKey(t.treeID)
 	q := t.tx.Get(k).(*kv).v.(*list.List)
 	for _, l := range leaves {
+		if l.QueueTimestamp == nil {
+			ts, err := ptypes.TimestampProto(queueTimestamp)
+			if err != nil {
+				return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+			}
+			l.QueueTimestamp = ts
+		}
 		q.PushBack(l)
 	}
 	return []*trillian.LogLeaf{}, nil
@@ -260,10 +304,130 @@ func (t *logTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, or
 	return ret, nil
 }
 
+func (t *logTreeTX) HasLeaves(ctx context.Context, leafIdentityHashes [][]byte, includeUnsequenced bool) ([]bool, error) {
+	found := make(map[string]bool)
+	t.tx.AscendRange(seqLeafKey(t.treeID, 0), seqLeafKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		found[string(i.(*kv).v.(*trillian.LogLeaf).LeafIdentityHash)] = true
+		return true
+	})
+	if includeUnsequenced {
+		q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+		for e := q.Front(); e != nil; e = e.Next() {
+			found[string(e.Value.(*trillian.LogLeaf).LeafIdentityHash)] = true
+		}
+	}
+
+	present := make([]bool, len(leafIdentityHashes))
+	for i, hash := range leafIdentityHashes {
+		present[i] = found[string(hash)]
+	}
+	return present, nil
+}
+
+func (t *logTreeTX) CountLeaves(ctx context.Context, filter storage.LeafFilter) (int64, error) {
+	if filter.SequencedOnly && filter.UnsequencedOnly {
+		return 0, errors.New("can't count leaves that are both sequenced and unsequenced")
+	}
+	var count int64
+	var err error
+	match := func(leaf *trillian.LogLeaf) bool {
+		if !bytes.HasPrefix(leaf.LeafIdentityHash, filter.LeafIdentityHashPrefix) {
+			return false
+		}
+		var queued int64
+		if leaf.QueueTimestamp != nil {
+			ts, tsErr := ptypes.Timestamp(leaf.QueueTimestamp)
+			if tsErr != nil {
+				err = tsErr
+				return false
+			}
+			queued = ts.UnixNano()
+		}
+		if filter.MinQueueTimestampNanos != 0 && queued < filter.MinQueueTimestampNanos {
+			return false
+		}
+		if filter.MaxQueueTimestampNanos != 0 && queued >= filter.MaxQueueTimestampNanos {
+			return false
+		}
+		return true
+	}
+
+	if !filter.UnsequencedOnly {
+		t.tx.AscendRange(seqLeafKey(t.treeID, 0), seqLeafKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+			if match(i.(*kv).v.(*trillian.LogLeaf)) {
+				count++
+			}
+			return err == nil
+		})
+	}
+	if !filter.SequencedOnly {
+		q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+		for e := q.Front(); e != nil && err == nil; e = e.Next() {
+			if match(e.Value.(*trillian.LogLeaf)) {
+				count++
+			}
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (t *logTreeTX) GetLatestLeafByIdentityHashPrefix(ctx context.Context, prefix []byte) (*trillian.LogLeaf, error) {
+	var latest *trillian.LogLeaf
+	seqPrefix := fmt.Sprintf("/%d/seq/", t.treeID)
+	t.tx.DescendLessOrEqual(seqLeafKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		if !strings.HasPrefix(i.(*kv).k, seqPrefix) {
+			return false
+		}
+		leaf := i.(*kv).v.(*trillian.LogLeaf)
+		if bytes.HasPrefix(leaf.LeafIdentityHash, prefix) {
+			latest = leaf
+			return false
+		}
+		return true
+	})
+	return latest, nil
+}
+
 func (t *logTreeTX) LatestSignedLogRoot(ctx context.Context) (trillian.SignedLogRoot, error) {
 	return t.root, nil
 }
 
+func (t *logTreeTX) SignedLogRootAtTime(ctx context.Context, timestampNanos int64) (trillian.SignedLogRoot, error) {
+	var root trillian.SignedLogRoot
+	t.tx.DescendRange(sthKey(t.treeID, timestampNanos), sthKey(t.treeID, -1), func(i btree.Item) bool {
+		root = i.(*kv).v.(trillian.SignedLogRoot)
+		return false
+	})
+	return root, nil
+}
+
+func (t *logTreeTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (trillian.SignedLogRoot, error) {
+	var root trillian.SignedLogRoot
+	t.tx.DescendRange(sthKey(t.treeID, math.MaxInt64), sthKey(t.treeID, -1), func(i btree.Item) bool {
+		if r := i.(*kv).v.(trillian.SignedLogRoot); r.TreeSize == treeSize {
+			root = r
+			return false
+		}
+		return true
+	})
+	return root, nil
+}
+
+func (t *logTreeTX) SignedLogRoots(ctx context.Context, afterRevision int64, limit int) ([]trillian.SignedLogRoot, error) {
+	// Roots are keyed by timestamp, which never decreases with the revision.
+	var roots []trillian.SignedLogRoot
+	t.tx.AscendRange(sthKey(t.treeID, 0), sthKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		if root := i.(*kv).v.(trillian.SignedLogRoot); root.TreeRevision > afterRevision {
+			roots = append(roots, root)
+		}
+		return len(roots) < limit
+	})
+	return roots, nil
+}
+
 // fetchLatestRoot reads the latest SignedLogRoot from the DB and returns it.
 func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (trillian.SignedLogRoot, error) {
 	r := t.tx.Get(sthKey(t.treeID, t.tree.currentSTH))
@@ -285,6 +449,171 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root trillian.Signed
 	return nil
 }
 
+// PruneDedupIndex does nothing, as this storage doesn't deduplicate leaves.
+func (t *logTreeTX) PruneDedupIndex(ctx context.Context, minIndex, minQueueNanos int64) (int64, error) {
+	return 0, nil
+}
+
+func (t *logTreeTX) PruneSignedLogRoots(ctx context.Context, keepCount, keepAfterNanos int64) (int64, error) {
+	if keepCount < 1 {
+		keepCount = 1 // The latest root is always kept.
+	}
+	cosigned := make(map[int64]bool)
+	t.tx.AscendRange(cosigKey(t.treeID, 0, ""), cosigKey(t.treeID, math.MaxInt64, ""), func(i btree.Item) bool {
+		cosigned[i.(*kv).v.(cosignature).treeRevision] = true
+		return true
+	})
+
+	// Roots are keyed by timestamp, so they're visited oldest first.
+	var roots []trillian.SignedLogRoot
+	t.tx.AscendRange(sthKey(t.treeID, 0), sthKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		roots = append(roots, i.(*kv).v.(trillian.SignedLogRoot))
+		return true
+	})
+	if int64(len(roots)) <= keepCount {
+		return 0, nil
+	}
+
+	var pruned int64
+	for _, root := range roots[:int64(len(roots))-keepCount] {
+		if root.TimestampNanos >= keepAfterNanos || cosigned[root.TreeRevision] {
+			continue
+		}
+		t.tx.Delete(sthKey(t.treeID, root.TimestampNanos))
+		t.tx.Delete(tokenKey(t.treeID, root.TreeRevision))
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (t *logTreeTX) LatestCosignedLogRoot(ctx context.Context) (trillian.SignedLogRoot, []*trillian.Cosignature, error) {
+	// The highest cosignature key belongs to the latest cosigned revision.
+	rev := int64(-1)
+	t.tx.DescendRange(cosigKey(t.treeID, math.MaxInt64, ""), cosigKey(t.treeID, 0, ""), func(i btree.Item) bool {
+		rev = i.(*kv).v.(cosignature).treeRevision
+		return false
+	})
+	if rev < 0 {
+		return trillian.SignedLogRoot{}, nil, nil
+	}
+
+	var cosigs []*trillian.Cosignature
+	t.tx.AscendRange(cosigKey(t.treeID, rev, ""), cosigKey(t.treeID, rev+1, ""), func(i btree.Item) bool {
+		cosigs = append(cosigs, i.(*kv).v.(cosignature).cosig)
+		return true
+	})
+
+	var root trillian.SignedLogRoot
+	found := false
+	t.tx.AscendRange(sthKey(t.treeID, 0), sthKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		r := i.(*kv).v.(trillian.SignedLogRoot)
+		if r.TreeRevision == rev {
+			root, found = r, true
+			return false
+		}
+		return true
+	})
+	if !found {
+		return trillian.SignedLogRoot{}, nil, fmt.Errorf("no root at cosigned revision %d", rev)
+	}
+	return root, cosigs, nil
+}
+
+// cosignature is the value stored under a cosigKey.
+type cosignature struct {
+	treeRevision int64
+	cosig        *trillian.Cosignature
+}
+
+func (t *logTreeTX) StoreCosignature(ctx context.Context, treeRevision int64, cosig *trillian.Cosignature) error {
+	k := cosigKey(t.treeID, treeRevision, cosig.WitnessName)
+	k.(*kv).v = cosignature{treeRevision: treeRevision, cosig: cosig}
+	t.tx.ReplaceOrInsert(k)
+	return nil
+}
+
+// timestampToken is the value stored under a tokenKey.
+type timestampToken struct {
+	treeRevision int64
+	token        []byte
+}
+
+func (t *logTreeTX) GetTimestampToken(ctx context.Context, treeRevision int64) ([]byte, error) {
+	if i := t.tx.Get(tokenKey(t.treeID, treeRevision)); i != nil {
+		return i.(*kv).v.(timestampToken).token, nil
+	}
+	return nil, nil
+}
+
+func (t *logTreeTX) LatestTimestampedRevision(ctx context.Context) (int64, error) {
+	rev := int64(-1)
+	t.tx.DescendRange(tokenKey(t.treeID, math.MaxInt64), tokenKey(t.treeID, 0), func(i btree.Item) bool {
+		rev = i.(*kv).v.(timestampToken).treeRevision
+		return false
+	})
+	return rev, nil
+}
+
+func (t *logTreeTX) StoreTimestampToken(ctx context.Context, treeRevision int64, token []byte) error {
+	k := tokenKey(t.treeID, treeRevision)
+	k.(*kv).v = timestampToken{treeRevision: treeRevision, token: token}
+	t.tx.ReplaceOrInsert(k)
+	return nil
+}
+
+func (t *logTreeTX) GetDeadLetteredLeaves(ctx context.Context) ([]*trillian.DeadLetteredLeaf, error) {
+	var leaves []*trillian.DeadLetteredLeaf
+	prefix := deadLetterKey(t.treeID, nil).(*kv).k
+	t.tx.AscendGreaterOrEqual(deadLetterKey(t.treeID, nil), func(i btree.Item) bool {
+		if !strings.HasPrefix(i.(*kv).k, prefix) {
+			return false
+		}
+		leaves = append(leaves, i.(*kv).v.(*trillian.DeadLetteredLeaf))
+		return true
+	})
+	sort.SliceStable(leaves, func(i, j int) bool {
+		ti, tj := leaves[i].DeadLetterTime, leaves[j].DeadLetterTime
+		return ti.Seconds < tj.Seconds || (ti.Seconds == tj.Seconds && ti.Nanos < tj.Nanos)
+	})
+	return leaves, nil
+}
+
+func (t *logTreeTX) DeadLetterLeaves(ctx context.Context, leaves []*trillian.DeadLetteredLeaf) error {
+	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+	for _, dl := range leaves {
+		for e := q.Front(); e != nil; e = e.Next() {
+			if bytes.Equal(e.Value.(*trillian.LogLeaf).LeafIdentityHash, dl.Leaf.LeafIdentityHash) {
+				q.Remove(e)
+				break
+			}
+		}
+		k := deadLetterKey(t.treeID, dl.Leaf.LeafIdentityHash)
+		k.(*kv).v = dl
+		t.tx.ReplaceOrInsert(k)
+	}
+	return nil
+}
+
+func (t *logTreeTX) RequeueDeadLetteredLeaves(ctx context.Context, leafIdentityHashes [][]byte, queueTimestamp time.Time) (int, error) {
+	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+	requeued := 0
+	for _, h := range leafIdentityHashes {
+		i := t.tx.Delete(deadLetterKey(t.treeID, h))
+		if i == nil {
+			continue
+		}
+		leaf := *i.(*kv).v.(*trillian.DeadLetteredLeaf).Leaf
+		ts, err := ptypes.TimestampProto(queueTimestamp)
+		if err != nil {
+			return 0, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		leaf.QueueTimestamp = ts
+		q.PushBack(&leaf)
+		requeued++
+	}
+	return requeued, nil
+}
+
 func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf) error {
 	countByMerkleHash := make(map[string]int)
 	for _, leaf := range leaves {
@@ -342,6 +671,30 @@ func (t *logTreeTX) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
 	return t.getActiveLogIDs(ctx)
 }
 
+// GetActiveLogIDsWithPendingWork returns a list of the IDs of all configured logs
+// that have queued unsequenced leaves
+func (t *logTreeTX) GetActiveLogIDsWithPendingWork(ctx context.Context) ([]int64, error) {
+	t.ts.mu.RLock()
+	defer t.ts.mu.RUnlock()
+
+	var ret []int64
+	for k, tree := range t.ts.trees {
+		var pending bool
+		if k == t.treeID {
+			// This TX already holds the tree's lock, and sees its own changes.
+			pending = hasUnsequenced(t.tx, k)
+		} else {
+			tree.RLock()
+			pending = hasUnsequenced(tree.store, k)
+			tree.RUnlock()
+		}
+		if pending {
+			ret = append(ret, k)
+		}
+	}
+	return ret, nil
+}
+
 // byLeafIdentityHash allows sorting of leaves by their identity hash, so DB
 // operations always happen in a consistent order.
 type byLeafIdentityHash []*trillian.LogLeaf
@@ -71,13 +71,15 @@ func Dump(t *btree.BTree) {
 // memoryTreeStorage is shared between the memoryLog and (forthcoming) memoryMap-
 // Storage implementations, and contains functionality which is common to both,
 type memoryTreeStorage struct {
-	mu    sync.RWMutex
-	trees map[int64]*tree
+	mu           sync.RWMutex
+	trees        map[int64]*tree
+	quotaConfigs map[quotaBucketKey]*trillian.QuotaConfig
 }
 
 func newTreeStorage() *memoryTreeStorage {
 	return &memoryTreeStorage{
-		trees: make(map[int64]*tree),
+		trees:        make(map[int64]*tree),
+		quotaConfigs: make(map[quotaBucketKey]*trillian.QuotaConfig),
 	}
 }
 
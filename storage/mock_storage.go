@@ -118,6 +118,32 @@ func (_mr *MockAdminTXMockRecorder) Commit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Commit")
 }
 
+// CountActiveTrees mocks base method
+func (_m *MockAdminTX) CountActiveTrees(_param0 context.Context) (int64, error) {
+	ret := _m.ctrl.Call(_m, "CountActiveTrees", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountActiveTrees indicates an expected call of CountActiveTrees
+func (_mr *MockAdminTXMockRecorder) CountActiveTrees(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CountActiveTrees", arg0)
+}
+
+// CreateQuotaConfig mocks base method
+func (_m *MockAdminTX) CreateQuotaConfig(_param0 context.Context, _param1 *trillian.QuotaConfig) (*trillian.QuotaConfig, error) {
+	ret := _m.ctrl.Call(_m, "CreateQuotaConfig", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.QuotaConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateQuotaConfig indicates an expected call of CreateQuotaConfig
+func (_mr *MockAdminTXMockRecorder) CreateQuotaConfig(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateQuotaConfig", arg0, arg1)
+}
+
 // CreateTree mocks base method
 func (_m *MockAdminTX) CreateTree(_param0 context.Context, _param1 *trillian.Tree) (*trillian.Tree, error) {
 	ret := _m.ctrl.Call(_m, "CreateTree", _param0, _param1)
@@ -131,6 +157,18 @@ func (_mr *MockAdminTXMockRecorder) CreateTree(arg0, arg1 interface{}) *gomock.C
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "CreateTree", arg0, arg1)
 }
 
+// DeleteQuotaConfig mocks base method
+func (_m *MockAdminTX) DeleteQuotaConfig(_param0 context.Context, _param1 *trillian.QuotaBucket) error {
+	ret := _m.ctrl.Call(_m, "DeleteQuotaConfig", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQuotaConfig indicates an expected call of DeleteQuotaConfig
+func (_mr *MockAdminTXMockRecorder) DeleteQuotaConfig(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeleteQuotaConfig", arg0, arg1)
+}
+
 // GetTree mocks base method
 func (_m *MockAdminTX) GetTree(_param0 context.Context, _param1 int64) (*trillian.Tree, error) {
 	ret := _m.ctrl.Call(_m, "GetTree", _param0, _param1)
@@ -144,6 +182,31 @@ func (_mr *MockAdminTXMockRecorder) GetTree(arg0, arg1 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTree", arg0, arg1)
 }
 
+// GetTreeFootprint mocks base method
+func (_m *MockAdminTX) GetTreeFootprint(_param0 context.Context, _param1 int64) (*TreeFootprint, error) {
+	ret := _m.ctrl.Call(_m, "GetTreeFootprint", _param0, _param1)
+	ret0, _ := ret[0].(*TreeFootprint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeFootprint indicates an expected call of GetTreeFootprint
+func (_mr *MockAdminTXMockRecorder) GetTreeFootprint(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTreeFootprint", arg0, arg1)
+}
+
+// HardDeleteTree mocks base method
+func (_m *MockAdminTX) HardDeleteTree(_param0 context.Context, _param1 int64) error {
+	ret := _m.ctrl.Call(_m, "HardDeleteTree", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HardDeleteTree indicates an expected call of HardDeleteTree
+func (_mr *MockAdminTXMockRecorder) HardDeleteTree(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "HardDeleteTree", arg0, arg1)
+}
+
 // IsClosed mocks base method
 func (_m *MockAdminTX) IsClosed() bool {
 	ret := _m.ctrl.Call(_m, "IsClosed")
@@ -156,6 +219,32 @@ func (_mr *MockAdminTXMockRecorder) IsClosed() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsClosed")
 }
 
+// ListPendingTrees mocks base method
+func (_m *MockAdminTX) ListPendingTrees(_param0 context.Context, _param1 bool) (map[int64]int64, error) {
+	ret := _m.ctrl.Call(_m, "ListPendingTrees", _param0, _param1)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingTrees indicates an expected call of ListPendingTrees
+func (_mr *MockAdminTXMockRecorder) ListPendingTrees(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListPendingTrees", arg0, arg1)
+}
+
+// ListQuotaConfigs mocks base method
+func (_m *MockAdminTX) ListQuotaConfigs(_param0 context.Context) ([]*trillian.QuotaConfig, error) {
+	ret := _m.ctrl.Call(_m, "ListQuotaConfigs", _param0)
+	ret0, _ := ret[0].([]*trillian.QuotaConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuotaConfigs indicates an expected call of ListQuotaConfigs
+func (_mr *MockAdminTXMockRecorder) ListQuotaConfigs(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListQuotaConfigs", arg0)
+}
+
 // ListTreeIDs mocks base method
 func (_m *MockAdminTX) ListTreeIDs(_param0 context.Context) ([]int64, error) {
 	ret := _m.ctrl.Call(_m, "ListTreeIDs", _param0)
@@ -182,6 +271,19 @@ func (_mr *MockAdminTXMockRecorder) ListTrees(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListTrees", arg0)
 }
 
+// RetireTreeKey mocks base method
+func (_m *MockAdminTX) RetireTreeKey(_param0 context.Context, _param1 int64) (*trillian.Tree, error) {
+	ret := _m.ctrl.Call(_m, "RetireTreeKey", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetireTreeKey indicates an expected call of RetireTreeKey
+func (_mr *MockAdminTXMockRecorder) RetireTreeKey(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RetireTreeKey", arg0, arg1)
+}
+
 // Rollback mocks base method
 func (_m *MockAdminTX) Rollback() error {
 	ret := _m.ctrl.Call(_m, "Rollback")
@@ -194,6 +296,58 @@ func (_mr *MockAdminTXMockRecorder) Rollback() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Rollback")
 }
 
+// RotateTreeKey mocks base method
+func (_m *MockAdminTX) RotateTreeKey(_param0 context.Context, _param1 int64, _param2 *trillian.KeyRotation) (*trillian.Tree, error) {
+	ret := _m.ctrl.Call(_m, "RotateTreeKey", _param0, _param1, _param2)
+	ret0, _ := ret[0].(*trillian.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateTreeKey indicates an expected call of RotateTreeKey
+func (_mr *MockAdminTXMockRecorder) RotateTreeKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RotateTreeKey", arg0, arg1, arg2)
+}
+
+// SoftDeleteTree mocks base method
+func (_m *MockAdminTX) SoftDeleteTree(_param0 context.Context, _param1 int64) (*trillian.Tree, error) {
+	ret := _m.ctrl.Call(_m, "SoftDeleteTree", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteTree indicates an expected call of SoftDeleteTree
+func (_mr *MockAdminTXMockRecorder) SoftDeleteTree(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SoftDeleteTree", arg0, arg1)
+}
+
+// UndeleteTree mocks base method
+func (_m *MockAdminTX) UndeleteTree(_param0 context.Context, _param1 int64) (*trillian.Tree, error) {
+	ret := _m.ctrl.Call(_m, "UndeleteTree", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UndeleteTree indicates an expected call of UndeleteTree
+func (_mr *MockAdminTXMockRecorder) UndeleteTree(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UndeleteTree", arg0, arg1)
+}
+
+// UpdateQuotaConfig mocks base method
+func (_m *MockAdminTX) UpdateQuotaConfig(_param0 context.Context, _param1 *trillian.QuotaBucket, _param2 func(*trillian.QuotaConfig)) (*trillian.QuotaConfig, error) {
+	ret := _m.ctrl.Call(_m, "UpdateQuotaConfig", _param0, _param1, _param2)
+	ret0, _ := ret[0].(*trillian.QuotaConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateQuotaConfig indicates an expected call of UpdateQuotaConfig
+func (_mr *MockAdminTXMockRecorder) UpdateQuotaConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "UpdateQuotaConfig", arg0, arg1, arg2)
+}
+
 // UpdateTree mocks base method
 func (_m *MockAdminTX) UpdateTree(_param0 context.Context, _param1 int64, _param2 func(*trillian.Tree)) (*trillian.Tree, error) {
 	ret := _m.ctrl.Call(_m, "UpdateTree", _param0, _param1, _param2)
@@ -328,6 +482,31 @@ func (_mr *MockLogTreeTXMockRecorder) Commit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Commit")
 }
 
+// CountLeaves mocks base method
+func (_m *MockLogTreeTX) CountLeaves(_param0 context.Context, _param1 LeafFilter) (int64, error) {
+	ret := _m.ctrl.Call(_m, "CountLeaves", _param0, _param1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountLeaves indicates an expected call of CountLeaves
+func (_mr *MockLogTreeTXMockRecorder) CountLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CountLeaves", arg0, arg1)
+}
+
+// DeadLetterLeaves mocks base method
+func (_m *MockLogTreeTX) DeadLetterLeaves(_param0 context.Context, _param1 []*trillian.DeadLetteredLeaf) error {
+	ret := _m.ctrl.Call(_m, "DeadLetterLeaves", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadLetterLeaves indicates an expected call of DeadLetterLeaves
+func (_mr *MockLogTreeTXMockRecorder) DeadLetterLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DeadLetterLeaves", arg0, arg1)
+}
+
 // DequeueLeaves mocks base method
 func (_m *MockLogTreeTX) DequeueLeaves(_param0 context.Context, _param1 int, _param2 time.Time) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "DequeueLeaves", _param0, _param1, _param2)
@@ -354,6 +533,45 @@ func (_mr *MockLogTreeTXMockRecorder) GetActiveLogIDs(arg0 interface{}) *gomock.
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetActiveLogIDs", arg0)
 }
 
+// GetActiveLogIDsWithPendingWork mocks base method
+func (_m *MockLogTreeTX) GetActiveLogIDsWithPendingWork(_param0 context.Context) ([]int64, error) {
+	ret := _m.ctrl.Call(_m, "GetActiveLogIDsWithPendingWork", _param0)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveLogIDsWithPendingWork indicates an expected call of GetActiveLogIDsWithPendingWork
+func (_mr *MockLogTreeTXMockRecorder) GetActiveLogIDsWithPendingWork(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetActiveLogIDsWithPendingWork", arg0)
+}
+
+// GetDeadLetteredLeaves mocks base method
+func (_m *MockLogTreeTX) GetDeadLetteredLeaves(_param0 context.Context) ([]*trillian.DeadLetteredLeaf, error) {
+	ret := _m.ctrl.Call(_m, "GetDeadLetteredLeaves", _param0)
+	ret0, _ := ret[0].([]*trillian.DeadLetteredLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetteredLeaves indicates an expected call of GetDeadLetteredLeaves
+func (_mr *MockLogTreeTXMockRecorder) GetDeadLetteredLeaves(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDeadLetteredLeaves", arg0)
+}
+
+// GetLatestLeafByIdentityHashPrefix mocks base method
+func (_m *MockLogTreeTX) GetLatestLeafByIdentityHashPrefix(_param0 context.Context, _param1 []byte) (*trillian.LogLeaf, error) {
+	ret := _m.ctrl.Call(_m, "GetLatestLeafByIdentityHashPrefix", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.LogLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestLeafByIdentityHashPrefix indicates an expected call of GetLatestLeafByIdentityHashPrefix
+func (_mr *MockLogTreeTXMockRecorder) GetLatestLeafByIdentityHashPrefix(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLatestLeafByIdentityHashPrefix", arg0, arg1)
+}
+
 // GetLeavesByHash mocks base method
 func (_m *MockLogTreeTX) GetLeavesByHash(_param0 context.Context, _param1 [][]byte, _param2 bool) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "GetLeavesByHash", _param0, _param1, _param2)
@@ -406,6 +624,32 @@ func (_mr *MockLogTreeTXMockRecorder) GetSequencedLeafCount(arg0 interface{}) *g
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSequencedLeafCount", arg0)
 }
 
+// GetTimestampToken mocks base method
+func (_m *MockLogTreeTX) GetTimestampToken(_param0 context.Context, _param1 int64) ([]byte, error) {
+	ret := _m.ctrl.Call(_m, "GetTimestampToken", _param0, _param1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimestampToken indicates an expected call of GetTimestampToken
+func (_mr *MockLogTreeTXMockRecorder) GetTimestampToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTimestampToken", arg0, arg1)
+}
+
+// HasLeaves mocks base method
+func (_m *MockLogTreeTX) HasLeaves(_param0 context.Context, _param1 [][]byte, _param2 bool) ([]bool, error) {
+	ret := _m.ctrl.Call(_m, "HasLeaves", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasLeaves indicates an expected call of HasLeaves
+func (_mr *MockLogTreeTXMockRecorder) HasLeaves(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "HasLeaves", arg0, arg1, arg2)
+}
+
 // IsOpen mocks base method
 func (_m *MockLogTreeTX) IsOpen() bool {
 	ret := _m.ctrl.Call(_m, "IsOpen")
@@ -418,6 +662,20 @@ func (_mr *MockLogTreeTXMockRecorder) IsOpen() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsOpen")
 }
 
+// LatestCosignedLogRoot mocks base method
+func (_m *MockLogTreeTX) LatestCosignedLogRoot(_param0 context.Context) (trillian.SignedLogRoot, []*trillian.Cosignature, error) {
+	ret := _m.ctrl.Call(_m, "LatestCosignedLogRoot", _param0)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].([]*trillian.Cosignature)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LatestCosignedLogRoot indicates an expected call of LatestCosignedLogRoot
+func (_mr *MockLogTreeTXMockRecorder) LatestCosignedLogRoot(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestCosignedLogRoot", arg0)
+}
+
 // LatestSignedLogRoot mocks base method
 func (_m *MockLogTreeTX) LatestSignedLogRoot(_param0 context.Context) (trillian.SignedLogRoot, error) {
 	ret := _m.ctrl.Call(_m, "LatestSignedLogRoot", _param0)
@@ -431,6 +689,45 @@ func (_mr *MockLogTreeTXMockRecorder) LatestSignedLogRoot(arg0 interface{}) *gom
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedLogRoot", arg0)
 }
 
+// LatestTimestampedRevision mocks base method
+func (_m *MockLogTreeTX) LatestTimestampedRevision(_param0 context.Context) (int64, error) {
+	ret := _m.ctrl.Call(_m, "LatestTimestampedRevision", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestTimestampedRevision indicates an expected call of LatestTimestampedRevision
+func (_mr *MockLogTreeTXMockRecorder) LatestTimestampedRevision(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestTimestampedRevision", arg0)
+}
+
+// PruneDedupIndex mocks base method
+func (_m *MockLogTreeTX) PruneDedupIndex(_param0 context.Context, _param1 int64, _param2 int64) (int64, error) {
+	ret := _m.ctrl.Call(_m, "PruneDedupIndex", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneDedupIndex indicates an expected call of PruneDedupIndex
+func (_mr *MockLogTreeTXMockRecorder) PruneDedupIndex(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PruneDedupIndex", arg0, arg1, arg2)
+}
+
+// PruneSignedLogRoots mocks base method
+func (_m *MockLogTreeTX) PruneSignedLogRoots(_param0 context.Context, _param1 int64, _param2 int64) (int64, error) {
+	ret := _m.ctrl.Call(_m, "PruneSignedLogRoots", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneSignedLogRoots indicates an expected call of PruneSignedLogRoots
+func (_mr *MockLogTreeTXMockRecorder) PruneSignedLogRoots(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PruneSignedLogRoots", arg0, arg1, arg2)
+}
+
 // QueueLeaves mocks base method
 func (_m *MockLogTreeTX) QueueLeaves(_param0 context.Context, _param1 []*trillian.LogLeaf, _param2 time.Time) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "QueueLeaves", _param0, _param1, _param2)
@@ -456,6 +753,19 @@ func (_mr *MockLogTreeTXMockRecorder) ReadRevision() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ReadRevision")
 }
 
+// RequeueDeadLetteredLeaves mocks base method
+func (_m *MockLogTreeTX) RequeueDeadLetteredLeaves(_param0 context.Context, _param1 [][]byte, _param2 time.Time) (int, error) {
+	ret := _m.ctrl.Call(_m, "RequeueDeadLetteredLeaves", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueDeadLetteredLeaves indicates an expected call of RequeueDeadLetteredLeaves
+func (_mr *MockLogTreeTXMockRecorder) RequeueDeadLetteredLeaves(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RequeueDeadLetteredLeaves", arg0, arg1, arg2)
+}
+
 // Rollback mocks base method
 func (_m *MockLogTreeTX) Rollback() error {
 	ret := _m.ctrl.Call(_m, "Rollback")
@@ -480,6 +790,57 @@ func (_mr *MockLogTreeTXMockRecorder) SetMerkleNodes(arg0, arg1 interface{}) *go
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetMerkleNodes", arg0, arg1)
 }
 
+// SignedLogRootAtSize mocks base method
+func (_m *MockLogTreeTX) SignedLogRootAtSize(_param0 context.Context, _param1 int64) (trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRootAtSize", _param0, _param1)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtSize indicates an expected call of SignedLogRootAtSize
+func (_mr *MockLogTreeTXMockRecorder) SignedLogRootAtSize(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRootAtSize", arg0, arg1)
+}
+
+// SignedLogRootAtTime mocks base method
+func (_m *MockLogTreeTX) SignedLogRootAtTime(_param0 context.Context, _param1 int64) (trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRootAtTime", _param0, _param1)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtTime indicates an expected call of SignedLogRootAtTime
+func (_mr *MockLogTreeTXMockRecorder) SignedLogRootAtTime(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRootAtTime", arg0, arg1)
+}
+
+// SignedLogRoots mocks base method
+func (_m *MockLogTreeTX) SignedLogRoots(_param0 context.Context, _param1 int64, _param2 int) ([]trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRoots", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRoots indicates an expected call of SignedLogRoots
+func (_mr *MockLogTreeTXMockRecorder) SignedLogRoots(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRoots", arg0, arg1, arg2)
+}
+
+// StoreCosignature mocks base method
+func (_m *MockLogTreeTX) StoreCosignature(_param0 context.Context, _param1 int64, _param2 *trillian.Cosignature) error {
+	ret := _m.ctrl.Call(_m, "StoreCosignature", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreCosignature indicates an expected call of StoreCosignature
+func (_mr *MockLogTreeTXMockRecorder) StoreCosignature(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StoreCosignature", arg0, arg1, arg2)
+}
+
 // StoreSignedLogRoot mocks base method
 func (_m *MockLogTreeTX) StoreSignedLogRoot(_param0 context.Context, _param1 trillian.SignedLogRoot) error {
 	ret := _m.ctrl.Call(_m, "StoreSignedLogRoot", _param0, _param1)
@@ -492,6 +853,18 @@ func (_mr *MockLogTreeTXMockRecorder) StoreSignedLogRoot(arg0, arg1 interface{})
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "StoreSignedLogRoot", arg0, arg1)
 }
 
+// StoreTimestampToken mocks base method
+func (_m *MockLogTreeTX) StoreTimestampToken(_param0 context.Context, _param1 int64, _param2 []byte) error {
+	ret := _m.ctrl.Call(_m, "StoreTimestampToken", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreTimestampToken indicates an expected call of StoreTimestampToken
+func (_mr *MockLogTreeTXMockRecorder) StoreTimestampToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "StoreTimestampToken", arg0, arg1, arg2)
+}
+
 // UpdateSequencedLeaves mocks base method
 func (_m *MockLogTreeTX) UpdateSequencedLeaves(_param0 context.Context, _param1 []*trillian.LogLeaf) error {
 	ret := _m.ctrl.Call(_m, "UpdateSequencedLeaves", _param0, _param1)
@@ -637,6 +1010,19 @@ func (_mr *MockMapTreeTXMockRecorder) Commit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Commit")
 }
 
+// DequeueMapLeaves mocks base method
+func (_m *MockMapTreeTX) DequeueMapLeaves(_param0 context.Context, _param1 int) ([]*trillian.MapLeaf, error) {
+	ret := _m.ctrl.Call(_m, "DequeueMapLeaves", _param0, _param1)
+	ret0, _ := ret[0].([]*trillian.MapLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DequeueMapLeaves indicates an expected call of DequeueMapLeaves
+func (_mr *MockMapTreeTXMockRecorder) DequeueMapLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "DequeueMapLeaves", arg0, arg1)
+}
+
 // Get mocks base method
 func (_m *MockMapTreeTX) Get(_param0 context.Context, _param1 int64, _param2 [][]byte) ([]trillian.MapLeaf, error) {
 	ret := _m.ctrl.Call(_m, "Get", _param0, _param1, _param2)
@@ -701,6 +1087,44 @@ func (_mr *MockMapTreeTXMockRecorder) LatestSignedMapRoot(arg0 interface{}) *gom
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedMapRoot", arg0)
 }
 
+// PruneSignedMapRoots mocks base method
+func (_m *MockMapTreeTX) PruneSignedMapRoots(_param0 context.Context, _param1 int64, _param2 int64) (int64, error) {
+	ret := _m.ctrl.Call(_m, "PruneSignedMapRoots", _param0, _param1, _param2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneSignedMapRoots indicates an expected call of PruneSignedMapRoots
+func (_mr *MockMapTreeTXMockRecorder) PruneSignedMapRoots(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PruneSignedMapRoots", arg0, arg1, arg2)
+}
+
+// PruneRevisions mocks base method
+func (_m *MockMapTreeTX) PruneRevisions(_param0 context.Context, _param1 int64) (int64, error) {
+	ret := _m.ctrl.Call(_m, "PruneRevisions", _param0, _param1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneRevisions indicates an expected call of PruneRevisions
+func (_mr *MockMapTreeTXMockRecorder) PruneRevisions(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "PruneRevisions", arg0, arg1)
+}
+
+// QueueMapLeaves mocks base method
+func (_m *MockMapTreeTX) QueueMapLeaves(_param0 context.Context, _param1 []*trillian.MapLeaf, _param2 time.Time) error {
+	ret := _m.ctrl.Call(_m, "QueueMapLeaves", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QueueMapLeaves indicates an expected call of QueueMapLeaves
+func (_mr *MockMapTreeTXMockRecorder) QueueMapLeaves(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "QueueMapLeaves", arg0, arg1, arg2)
+}
+
 // ReadRevision mocks base method
 func (_m *MockMapTreeTX) ReadRevision() int64 {
 	ret := _m.ctrl.Call(_m, "ReadRevision")
@@ -833,6 +1257,19 @@ func (_mr *MockReadOnlyAdminTXMockRecorder) GetTree(arg0, arg1 interface{}) *gom
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTree", arg0, arg1)
 }
 
+// GetTreeFootprint mocks base method
+func (_m *MockReadOnlyAdminTX) GetTreeFootprint(_param0 context.Context, _param1 int64) (*TreeFootprint, error) {
+	ret := _m.ctrl.Call(_m, "GetTreeFootprint", _param0, _param1)
+	ret0, _ := ret[0].(*TreeFootprint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeFootprint indicates an expected call of GetTreeFootprint
+func (_mr *MockReadOnlyAdminTXMockRecorder) GetTreeFootprint(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTreeFootprint", arg0, arg1)
+}
+
 // IsClosed mocks base method
 func (_m *MockReadOnlyAdminTX) IsClosed() bool {
 	ret := _m.ctrl.Call(_m, "IsClosed")
@@ -845,6 +1282,32 @@ func (_mr *MockReadOnlyAdminTXMockRecorder) IsClosed() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsClosed")
 }
 
+// ListPendingTrees mocks base method
+func (_m *MockReadOnlyAdminTX) ListPendingTrees(_param0 context.Context, _param1 bool) (map[int64]int64, error) {
+	ret := _m.ctrl.Call(_m, "ListPendingTrees", _param0, _param1)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingTrees indicates an expected call of ListPendingTrees
+func (_mr *MockReadOnlyAdminTXMockRecorder) ListPendingTrees(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListPendingTrees", arg0, arg1)
+}
+
+// ListQuotaConfigs mocks base method
+func (_m *MockReadOnlyAdminTX) ListQuotaConfigs(_param0 context.Context) ([]*trillian.QuotaConfig, error) {
+	ret := _m.ctrl.Call(_m, "ListQuotaConfigs", _param0)
+	ret0, _ := ret[0].([]*trillian.QuotaConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuotaConfigs indicates an expected call of ListQuotaConfigs
+func (_mr *MockReadOnlyAdminTXMockRecorder) ListQuotaConfigs(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListQuotaConfigs", arg0)
+}
+
 // ListTreeIDs mocks base method
 func (_m *MockReadOnlyAdminTX) ListTreeIDs(_param0 context.Context) ([]int64, error) {
 	ret := _m.ctrl.Call(_m, "ListTreeIDs", _param0)
@@ -943,6 +1406,19 @@ func (_mr *MockReadOnlyLogTXMockRecorder) GetActiveLogIDs(arg0 interface{}) *gom
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetActiveLogIDs", arg0)
 }
 
+// GetActiveLogIDsWithPendingWork mocks base method
+func (_m *MockReadOnlyLogTX) GetActiveLogIDsWithPendingWork(_param0 context.Context) ([]int64, error) {
+	ret := _m.ctrl.Call(_m, "GetActiveLogIDsWithPendingWork", _param0)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveLogIDsWithPendingWork indicates an expected call of GetActiveLogIDsWithPendingWork
+func (_mr *MockReadOnlyLogTXMockRecorder) GetActiveLogIDsWithPendingWork(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetActiveLogIDsWithPendingWork", arg0)
+}
+
 // Rollback mocks base method
 func (_m *MockReadOnlyLogTX) Rollback() error {
 	ret := _m.ctrl.Call(_m, "Rollback")
@@ -1002,6 +1478,45 @@ func (_mr *MockReadOnlyLogTreeTXMockRecorder) Commit() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Commit")
 }
 
+// CountLeaves mocks base method
+func (_m *MockReadOnlyLogTreeTX) CountLeaves(_param0 context.Context, _param1 LeafFilter) (int64, error) {
+	ret := _m.ctrl.Call(_m, "CountLeaves", _param0, _param1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountLeaves indicates an expected call of CountLeaves
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) CountLeaves(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "CountLeaves", arg0, arg1)
+}
+
+// GetDeadLetteredLeaves mocks base method
+func (_m *MockReadOnlyLogTreeTX) GetDeadLetteredLeaves(_param0 context.Context) ([]*trillian.DeadLetteredLeaf, error) {
+	ret := _m.ctrl.Call(_m, "GetDeadLetteredLeaves", _param0)
+	ret0, _ := ret[0].([]*trillian.DeadLetteredLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetteredLeaves indicates an expected call of GetDeadLetteredLeaves
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) GetDeadLetteredLeaves(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetDeadLetteredLeaves", arg0)
+}
+
+// GetLatestLeafByIdentityHashPrefix mocks base method
+func (_m *MockReadOnlyLogTreeTX) GetLatestLeafByIdentityHashPrefix(_param0 context.Context, _param1 []byte) (*trillian.LogLeaf, error) {
+	ret := _m.ctrl.Call(_m, "GetLatestLeafByIdentityHashPrefix", _param0, _param1)
+	ret0, _ := ret[0].(*trillian.LogLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestLeafByIdentityHashPrefix indicates an expected call of GetLatestLeafByIdentityHashPrefix
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) GetLatestLeafByIdentityHashPrefix(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetLatestLeafByIdentityHashPrefix", arg0, arg1)
+}
+
 // GetLeavesByHash mocks base method
 func (_m *MockReadOnlyLogTreeTX) GetLeavesByHash(_param0 context.Context, _param1 [][]byte, _param2 bool) ([]*trillian.LogLeaf, error) {
 	ret := _m.ctrl.Call(_m, "GetLeavesByHash", _param0, _param1, _param2)
@@ -1054,6 +1569,32 @@ func (_mr *MockReadOnlyLogTreeTXMockRecorder) GetSequencedLeafCount(arg0 interfa
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetSequencedLeafCount", arg0)
 }
 
+// GetTimestampToken mocks base method
+func (_m *MockReadOnlyLogTreeTX) GetTimestampToken(_param0 context.Context, _param1 int64) ([]byte, error) {
+	ret := _m.ctrl.Call(_m, "GetTimestampToken", _param0, _param1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTimestampToken indicates an expected call of GetTimestampToken
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) GetTimestampToken(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetTimestampToken", arg0, arg1)
+}
+
+// HasLeaves mocks base method
+func (_m *MockReadOnlyLogTreeTX) HasLeaves(_param0 context.Context, _param1 [][]byte, _param2 bool) ([]bool, error) {
+	ret := _m.ctrl.Call(_m, "HasLeaves", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasLeaves indicates an expected call of HasLeaves
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) HasLeaves(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "HasLeaves", arg0, arg1, arg2)
+}
+
 // IsOpen mocks base method
 func (_m *MockReadOnlyLogTreeTX) IsOpen() bool {
 	ret := _m.ctrl.Call(_m, "IsOpen")
@@ -1066,6 +1607,20 @@ func (_mr *MockReadOnlyLogTreeTXMockRecorder) IsOpen() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsOpen")
 }
 
+// LatestCosignedLogRoot mocks base method
+func (_m *MockReadOnlyLogTreeTX) LatestCosignedLogRoot(_param0 context.Context) (trillian.SignedLogRoot, []*trillian.Cosignature, error) {
+	ret := _m.ctrl.Call(_m, "LatestCosignedLogRoot", _param0)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].([]*trillian.Cosignature)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LatestCosignedLogRoot indicates an expected call of LatestCosignedLogRoot
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) LatestCosignedLogRoot(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestCosignedLogRoot", arg0)
+}
+
 // LatestSignedLogRoot mocks base method
 func (_m *MockReadOnlyLogTreeTX) LatestSignedLogRoot(_param0 context.Context) (trillian.SignedLogRoot, error) {
 	ret := _m.ctrl.Call(_m, "LatestSignedLogRoot", _param0)
@@ -1079,6 +1634,19 @@ func (_mr *MockReadOnlyLogTreeTXMockRecorder) LatestSignedLogRoot(arg0 interface
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestSignedLogRoot", arg0)
 }
 
+// LatestTimestampedRevision mocks base method
+func (_m *MockReadOnlyLogTreeTX) LatestTimestampedRevision(_param0 context.Context) (int64, error) {
+	ret := _m.ctrl.Call(_m, "LatestTimestampedRevision", _param0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestTimestampedRevision indicates an expected call of LatestTimestampedRevision
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) LatestTimestampedRevision(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "LatestTimestampedRevision", arg0)
+}
+
 // ReadRevision mocks base method
 func (_m *MockReadOnlyLogTreeTX) ReadRevision() int64 {
 	ret := _m.ctrl.Call(_m, "ReadRevision")
@@ -1103,6 +1671,45 @@ func (_mr *MockReadOnlyLogTreeTXMockRecorder) Rollback() *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Rollback")
 }
 
+// SignedLogRootAtSize mocks base method
+func (_m *MockReadOnlyLogTreeTX) SignedLogRootAtSize(_param0 context.Context, _param1 int64) (trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRootAtSize", _param0, _param1)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtSize indicates an expected call of SignedLogRootAtSize
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) SignedLogRootAtSize(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRootAtSize", arg0, arg1)
+}
+
+// SignedLogRootAtTime mocks base method
+func (_m *MockReadOnlyLogTreeTX) SignedLogRootAtTime(_param0 context.Context, _param1 int64) (trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRootAtTime", _param0, _param1)
+	ret0, _ := ret[0].(trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtTime indicates an expected call of SignedLogRootAtTime
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) SignedLogRootAtTime(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRootAtTime", arg0, arg1)
+}
+
+// SignedLogRoots mocks base method
+func (_m *MockReadOnlyLogTreeTX) SignedLogRoots(_param0 context.Context, _param1 int64, _param2 int) ([]trillian.SignedLogRoot, error) {
+	ret := _m.ctrl.Call(_m, "SignedLogRoots", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRoots indicates an expected call of SignedLogRoots
+func (_mr *MockReadOnlyLogTreeTXMockRecorder) SignedLogRoots(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SignedLogRoots", arg0, arg1, arg2)
+}
+
 // MockReadOnlyMapTreeTX is a mock of ReadOnlyMapTreeTX interface
 type MockReadOnlyMapTreeTX struct {
 	ctrl     *gomock.Controller
@@ -30,6 +30,7 @@ import (
 	spb "github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/errors"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
 )
 
 const (
@@ -48,11 +49,92 @@ const (
 			UpdateTimeMillis,
 			PrivateKey,
 			PublicKey,
-			MaxRootDurationMillis
+			MaxRootDurationMillis,
+			MaxClientTimestampSkewMillis,
+			Witnesses,
+			RootRetention,
+			MapLeafHashing,
+			RootMetadataHook,
+			DeadLetterPolicy,
+			MaxRevisionLookback,
+			AdditionalPublicKeys,
+			CheckpointOrigin,
+			DrainGracePeriodMillis,
+			DrainDeadlineMillis,
+			SecondarySigner,
+			VerifyLeafIdentityHash,
+			DedupWindow,
+			UnsequencedBuckets,
+			AccessPolicy,
+			DeleteTimeMillis,
+			KeyRotation,
+			KeyHistory
 		FROM Trees`
 	selectTreeByID = selectTrees + " WHERE TreeId = ?"
+	// FOR UPDATE locks all of Trees, so concurrent transactions can't insert trees until
+	// the transaction ends.
+	countActiveTreesSQL = `SELECT COUNT(*) FROM Trees
+			WHERE TreeState NOT IN ('SOFT_DELETED', 'HARD_DELETED') FOR UPDATE`
+	updateTreeDeletionSQL = `UPDATE Trees
+			SET TreeState = ?, DeleteTimeMillis = ?, DrainDeadlineMillis = 0, UpdateTimeMillis = ?
+			WHERE TreeId = ?`
+	updateTreeKeysSQL = `UPDATE Trees
+			SET PrivateKey = ?, PublicKey = ?, KeyRotation = ?, KeyHistory = ?, UpdateTimeMillis = ?
+			WHERE TreeId = ?`
+
+	// Footprint queries return a single row with a single value. Log and map
+	// tables are both queried, as trees only populate one set of them.
+	selectLeafCountSQL = `
+		SELECT
+			(SELECT COUNT(*) FROM SequencedLeafData WHERE TreeId = ?) +
+			(SELECT COUNT(*) FROM MapLeaf WHERE TreeId = ?)`
+	selectLeafDataBytesSQL = `
+		SELECT
+			(SELECT COALESCE(SUM(LENGTH(LeafValue) + COALESCE(LENGTH(ExtraData), 0)), 0)
+				FROM LeafData WHERE TreeId = ?) +
+			(SELECT COALESCE(SUM(LENGTH(LeafValue)), 0) FROM MapLeaf WHERE TreeId = ?)`
+	selectSubtreeCountSQL    = "SELECT COUNT(*) FROM Subtree WHERE TreeId = ?"
+	selectSignedRootCountSQL = `
+		SELECT
+			(SELECT COUNT(*) FROM TreeHead WHERE TreeId = ?) +
+			(SELECT COUNT(*) FROM MapHead WHERE TreeId = ?)`
+
+	// TreeId prefixes the primary key of Unsequenced, so both of these are answered from
+	// the index.
+	selectPendingTreesSQL      = "SELECT DISTINCT TreeId, 0 FROM Unsequenced"
+	selectPendingTreeCountsSQL = "SELECT TreeId, COUNT(*) FROM Unsequenced GROUP BY TreeId"
+
+	selectQuotaConfigsSQL = `
+		SELECT QuotaGroup, QuotaKind, TreeId, QuotaUser, MaxTokens, TokensToReplenish, ReplenishIntervalMillis
+		FROM QuotaConfigs`
+	selectQuotaConfigForUpdateSQL = selectQuotaConfigsSQL + `
+		WHERE QuotaGroup = ? AND QuotaKind = ? AND TreeId = ? AND QuotaUser = ? FOR UPDATE`
+	insertQuotaConfigSQL = `
+		INSERT INTO QuotaConfigs(QuotaGroup, QuotaKind, TreeId, QuotaUser, MaxTokens, TokensToReplenish, ReplenishIntervalMillis)
+		VALUES(?, ?, ?, ?, ?, ?, ?)`
+	updateQuotaConfigSQL = `
+		UPDATE QuotaConfigs SET MaxTokens = ?, TokensToReplenish = ?, ReplenishIntervalMillis = ?
+		WHERE QuotaGroup = ? AND QuotaKind = ? AND TreeId = ? AND QuotaUser = ?`
+	deleteQuotaConfigSQL = `
+		DELETE FROM QuotaConfigs
+		WHERE QuotaGroup = ? AND QuotaKind = ? AND TreeId = ? AND QuotaUser = ?`
 )
 
+// hardDeleteTreeSQL are the statements that remove the data of a hard-deleted tree, in an order
+// that respects foreign keys. Rows that reference LeafData or TreeHead are removed by cascade.
+var hardDeleteTreeSQL = []string{
+	"DELETE FROM Unsequenced WHERE TreeId = ?",
+	"DELETE FROM LeafIdentityDedup WHERE TreeId = ?",
+	"DELETE FROM LeafData WHERE TreeId = ?",
+	"DELETE FROM Subtree WHERE TreeId = ?",
+	"DELETE FROM TreeHead WHERE TreeId = ?",
+	"DELETE FROM MapLeaf WHERE TreeId = ?",
+	"DELETE FROM MapQueuedLeaf WHERE TreeId = ?",
+	"DELETE FROM MapHead WHERE TreeId = ?",
+	"DELETE FROM TreeControl WHERE TreeId = ?",
+	"DELETE FROM QuotaConfigs WHERE TreeId = ?",
+}
+
 // NewAdminStorage returns a MySQL storage.AdminStorage implementation backed by DB.
 func NewAdminStorage(db *sql.DB) storage.AdminStorage {
 	return &mysqlAdminStorage{db}
@@ -157,10 +239,12 @@ func readTree(row row) (*trillian.Tree, error) {
 	tree := &trillian.Tree{}
 
 	// Enums and Datetimes need an extra conversion step
-	var treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm string
-	var createMillis, updateMillis, maxRootDurationMillis int64
-	var displayName, description sql.NullString
-	var privateKey, publicKey []byte
+	var treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm, mapLeafHashing string
+	var createMillis, updateMillis, maxRootDurationMillis, maxClientTimestampSkewMillis int64
+	var drainGracePeriodMillis, drainDeadlineMillis, deleteTimeMillis int64
+	var displayName, description, checkpointOrigin sql.NullString
+	var privateKey, publicKey, witnesses, rootRetention, deadLetterPolicy, additionalPublicKeys, secondarySigner, dedupWindow, accessPolicy []byte
+	var keyRotation, keyHistory []byte
 	err := row.Scan(
 		&tree.TreeId,
 		&treeState,
@@ -175,6 +259,25 @@ func readTree(row row) (*trillian.Tree, error) {
 		&privateKey,
 		&publicKey,
 		&maxRootDurationMillis,
+		&maxClientTimestampSkewMillis,
+		&witnesses,
+		&rootRetention,
+		&mapLeafHashing,
+		&tree.RootMetadataHook,
+		&deadLetterPolicy,
+		&tree.MaxRevisionLookback,
+		&additionalPublicKeys,
+		&checkpointOrigin,
+		&drainGracePeriodMillis,
+		&drainDeadlineMillis,
+		&secondarySigner,
+		&tree.VerifyLeafIdentityHash,
+		&dedupWindow,
+		&tree.UnsequencedBuckets,
+		&accessPolicy,
+		&deleteTimeMillis,
+		&keyRotation,
+		&keyHistory,
 	)
 	if err != nil {
 		return nil, err
@@ -182,6 +285,7 @@ func readTree(row row) (*trillian.Tree, error) {
 
 	setNullStringIfValid(displayName, &tree.DisplayName)
 	setNullStringIfValid(description, &tree.Description)
+	setNullStringIfValid(checkpointOrigin, &tree.CheckpointOrigin)
 
 	// Convert all things!
 	if ts, ok := trillian.TreeState_value[treeState]; ok {
@@ -209,6 +313,11 @@ func readTree(row row) (*trillian.Tree, error) {
 	} else {
 		return nil, fmt.Errorf("unknown SignatureAlgorithm: %v", signatureAlgorithm)
 	}
+	if mlh, ok := trillian.MapLeafHashing_value[mapLeafHashing]; ok {
+		tree.MapLeafHashing = trillian.MapLeafHashing(mlh)
+	} else {
+		return nil, fmt.Errorf("unknown MapLeafHashing: %v", mapLeafHashing)
+	}
 
 	// Let's make sure we didn't mismatch any of the casts above
 	ok := tree.TreeState.String() == treeState
@@ -216,11 +325,12 @@ func readTree(row row) (*trillian.Tree, error) {
 	ok = ok && tree.HashStrategy.String() == hashStrategy
 	ok = ok && tree.HashAlgorithm.String() == hashAlgorithm
 	ok = ok && tree.SignatureAlgorithm.String() == signatureAlgorithm
+	ok = ok && tree.MapLeafHashing.String() == mapLeafHashing
 	if !ok {
 		return nil, fmt.Errorf(
-			"mismatched enum: tree = %v, enums = [%v, %v, %v, %v, %v]",
+			"mismatched enum: tree = %v, enums = [%v, %v, %v, %v, %v, %v]",
 			tree,
-			treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm)
+			treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm, mapLeafHashing)
 	}
 
 	tree.CreateTime, err = ptypes.TimestampProto(fromMillisSinceEpoch(createMillis))
@@ -232,6 +342,24 @@ func readTree(row row) (*trillian.Tree, error) {
 		return nil, fmt.Errorf("failed to parse update time: %v", err)
 	}
 	tree.MaxRootDuration = ptypes.DurationProto(time.Duration(maxRootDurationMillis * int64(time.Millisecond)))
+	if maxClientTimestampSkewMillis != 0 {
+		tree.MaxClientTimestampSkew = ptypes.DurationProto(time.Duration(maxClientTimestampSkewMillis * int64(time.Millisecond)))
+	}
+	if drainGracePeriodMillis != 0 {
+		tree.DrainGracePeriod = ptypes.DurationProto(time.Duration(drainGracePeriodMillis * int64(time.Millisecond)))
+	}
+	if drainDeadlineMillis != 0 {
+		tree.DrainDeadline, err = ptypes.TimestampProto(fromMillisSinceEpoch(drainDeadlineMillis))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse drain deadline: %v", err)
+		}
+	}
+	if deleteTimeMillis != 0 {
+		tree.DeleteTime, err = ptypes.TimestampProto(fromMillisSinceEpoch(deleteTimeMillis))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse delete time: %v", err)
+		}
+	}
 
 	tree.PrivateKey = &any.Any{}
 	if err := proto.Unmarshal(privateKey, tree.PrivateKey); err != nil {
@@ -239,9 +367,75 @@ func readTree(row row) (*trillian.Tree, error) {
 	}
 	tree.PublicKey = &keyspb.PublicKey{Der: publicKey}
 
+	if len(witnesses) > 0 {
+		var tw storagepb.TreeWitnesses
+		if err := proto.Unmarshal(witnesses, &tw); err != nil {
+			return nil, fmt.Errorf("could not unmarshal Witnesses: %v", err)
+		}
+		tree.Witnesses = tw.Witnesses
+	}
+	if len(rootRetention) > 0 {
+		tree.RootRetention = &trillian.RootRetention{}
+		if err := proto.Unmarshal(rootRetention, tree.RootRetention); err != nil {
+			return nil, fmt.Errorf("could not unmarshal RootRetention: %v", err)
+		}
+	}
+	if len(deadLetterPolicy) > 0 {
+		tree.DeadLetterPolicy = &trillian.DeadLetterPolicy{}
+		if err := proto.Unmarshal(deadLetterPolicy, tree.DeadLetterPolicy); err != nil {
+			return nil, fmt.Errorf("could not unmarshal DeadLetterPolicy: %v", err)
+		}
+	}
+	if len(additionalPublicKeys) > 0 {
+		var keys storagepb.TreePublicKeys
+		if err := proto.Unmarshal(additionalPublicKeys, &keys); err != nil {
+			return nil, fmt.Errorf("could not unmarshal AdditionalPublicKeys: %v", err)
+		}
+		tree.AdditionalPublicKeys = keys.PublicKeys
+	}
+	if len(secondarySigner) > 0 {
+		tree.SecondarySigner = &trillian.SecondarySigner{}
+		if err := proto.Unmarshal(secondarySigner, tree.SecondarySigner); err != nil {
+			return nil, fmt.Errorf("could not unmarshal SecondarySigner: %v", err)
+		}
+	}
+	if len(dedupWindow) > 0 {
+		tree.DedupWindow = &trillian.DedupWindow{}
+		if err := proto.Unmarshal(dedupWindow, tree.DedupWindow); err != nil {
+			return nil, fmt.Errorf("could not unmarshal DedupWindow: %v", err)
+		}
+	}
+	if len(accessPolicy) > 0 {
+		tree.AccessPolicy = &trillian.AccessPolicy{}
+		if err := proto.Unmarshal(accessPolicy, tree.AccessPolicy); err != nil {
+			return nil, fmt.Errorf("could not unmarshal AccessPolicy: %v", err)
+		}
+	}
+	if len(keyRotation) > 0 {
+		tree.KeyRotation = &trillian.KeyRotation{}
+		if err := proto.Unmarshal(keyRotation, tree.KeyRotation); err != nil {
+			return nil, fmt.Errorf("could not unmarshal KeyRotation: %v", err)
+		}
+	}
+	if len(keyHistory) > 0 {
+		var history storagepb.TreeKeyHistory
+		if err := proto.Unmarshal(keyHistory, &history); err != nil {
+			return nil, fmt.Errorf("could not unmarshal KeyHistory: %v", err)
+		}
+		tree.KeyHistory = history.Keys
+	}
+
 	return tree, nil
 }
 
+// nullIfEmpty returns s, or nil (NULL) if s is empty.
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // setNullStringIfValid assigns src to dest if src is Valid.
 func setNullStringIfValid(src sql.NullString, dest *string) {
 	if src.Valid {
@@ -295,6 +489,59 @@ func (t *adminTX) ListTrees(ctx context.Context) ([]*trillian.Tree, error) {
 	return trees, nil
 }
 
+func (t *adminTX) GetTreeFootprint(ctx context.Context, treeID int64) (*storage.TreeFootprint, error) {
+	if _, err := t.GetTree(ctx, treeID); err != nil {
+		return nil, err
+	}
+
+	fp := &storage.TreeFootprint{}
+	for _, q := range []struct {
+		sql  string
+		args []interface{}
+		dest *int64
+	}{
+		{selectLeafCountSQL, []interface{}{treeID, treeID}, &fp.LeafCount},
+		{selectLeafDataBytesSQL, []interface{}{treeID, treeID}, &fp.LeafDataBytes},
+		{selectSubtreeCountSQL, []interface{}{treeID}, &fp.SubtreeCount},
+		{selectSignedRootCountSQL, []interface{}{treeID, treeID}, &fp.SignedRootCount},
+	} {
+		if err := t.tx.QueryRowContext(ctx, q.sql, q.args...).Scan(q.dest); err != nil {
+			return nil, fmt.Errorf("error reading footprint of tree %v: %v", treeID, err)
+		}
+	}
+	return fp, nil
+}
+
+func (t *adminTX) ListPendingTrees(ctx context.Context, countLeaves bool) (map[int64]int64, error) {
+	query := selectPendingTreesSQL
+	if countLeaves {
+		query = selectPendingTreeCountsSQL
+	}
+	rows, err := t.tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := make(map[int64]int64)
+	for rows.Next() {
+		var treeID, count int64
+		if err := rows.Scan(&treeID, &count); err != nil {
+			return nil, err
+		}
+		pending[treeID] = count
+	}
+	return pending, rows.Err()
+}
+
+func (t *adminTX) CountActiveTrees(ctx context.Context) (int64, error) {
+	var count int64
+	if err := t.tx.QueryRowContext(ctx, countActiveTreesSQL).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
 	if err := storage.ValidateTreeForCreation(tree); err != nil {
 		return nil, err
@@ -326,6 +573,42 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	clientTimestampSkew, err := maxClientTimestampSkew(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	witnesses, err := marshalWitnesses(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	rootRetention, err := marshalRootRetention(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	deadLetterPolicy, err := marshalDeadLetterPolicy(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	additionalPublicKeys, err := marshalAdditionalPublicKeys(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	drainGracePeriod, drainDeadlineMillis, err := drainSettings(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	secondarySigner, err := marshalSecondarySigner(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	dedupWindow, err := marshalDedupWindow(&newTree)
+	if err != nil {
+		return nil, err
+	}
+	accessPolicy, err := marshalAccessPolicy(&newTree)
+	if err != nil {
+		return nil, err
+	}
 
 	insertTreeStmt, err := t.tx.PrepareContext(
 		ctx,
@@ -342,8 +625,24 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 			UpdateTimeMillis,
 			PrivateKey,
 			PublicKey,
-			MaxRootDurationMillis)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+			MaxRootDurationMillis,
+			MaxClientTimestampSkewMillis,
+			Witnesses,
+			RootRetention,
+			MapLeafHashing,
+			RootMetadataHook,
+			DeadLetterPolicy,
+			MaxRevisionLookback,
+			AdditionalPublicKeys,
+			CheckpointOrigin,
+			DrainGracePeriodMillis,
+			DrainDeadlineMillis,
+			SecondarySigner,
+			VerifyLeafIdentityHash,
+			DedupWindow,
+			UnsequencedBuckets,
+			AccessPolicy)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return nil, err
 	}
@@ -369,7 +668,26 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 		privateKey,
 		newTree.PublicKey.GetDer(),
 		rootDuration/time.Millisecond,
+		clientTimestampSkew/time.Millisecond,
+		witnesses,
+		rootRetention,
+		newTree.MapLeafHashing.String(),
+		newTree.RootMetadataHook,
+		deadLetterPolicy,
+		newTree.MaxRevisionLookback,
+		additionalPublicKeys,
+		nullIfEmpty(newTree.CheckpointOrigin),
+		drainGracePeriod/time.Millisecond,
+		drainDeadlineMillis,
+		secondarySigner,
+		newTree.VerifyLeafIdentityHash,
+		dedupWindow,
+		newTree.UnsequencedBuckets,
+		accessPolicy,
 	)
+	if isDuplicateErr(err) && newTree.CheckpointOrigin != "" {
+		return nil, errors.Errorf(errors.AlreadyExists, "checkpoint_origin already in use: %q", newTree.CheckpointOrigin)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -436,11 +754,47 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	clientTimestampSkew, err := maxClientTimestampSkew(tree)
+	if err != nil {
+		return nil, err
+	}
+	witnesses, err := marshalWitnesses(tree)
+	if err != nil {
+		return nil, err
+	}
+	rootRetention, err := marshalRootRetention(tree)
+	if err != nil {
+		return nil, err
+	}
+	deadLetterPolicy, err := marshalDeadLetterPolicy(tree)
+	if err != nil {
+		return nil, err
+	}
+	additionalPublicKeys, err := marshalAdditionalPublicKeys(tree)
+	if err != nil {
+		return nil, err
+	}
+	drainGracePeriod, drainDeadlineMillis, err := drainSettings(tree)
+	if err != nil {
+		return nil, err
+	}
+	secondarySigner, err := marshalSecondarySigner(tree)
+	if err != nil {
+		return nil, err
+	}
+	accessPolicy, err := marshalAccessPolicy(tree)
+	if err != nil {
+		return nil, err
+	}
 
 	stmt, err := t.tx.PrepareContext(
 		ctx,
 		`UPDATE Trees
-		SET TreeState = ?, DisplayName = ?, Description = ?, UpdateTimeMillis = ?, MaxRootDurationMillis = ?
+		SET TreeState = ?, DisplayName = ?, Description = ?, UpdateTimeMillis = ?, MaxRootDurationMillis = ?,
+			MaxClientTimestampSkewMillis = ?, Witnesses = ?, RootRetention = ?, RootMetadataHook = ?,
+			DeadLetterPolicy = ?, MaxRevisionLookback = ?, AdditionalPublicKeys = ?,
+			DrainGracePeriodMillis = ?, DrainDeadlineMillis = ?, SecondarySigner = ?,
+			VerifyLeafIdentityHash = ?, AccessPolicy = ?
 		WHERE TreeId = ?`)
 	if err != nil {
 		return nil, err
@@ -454,6 +808,18 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 		tree.Description,
 		nowMillis,
 		rootDuration/time.Millisecond,
+		clientTimestampSkew/time.Millisecond,
+		witnesses,
+		rootRetention,
+		tree.RootMetadataHook,
+		deadLetterPolicy,
+		tree.MaxRevisionLookback,
+		additionalPublicKeys,
+		drainGracePeriod/time.Millisecond,
+		drainDeadlineMillis,
+		secondarySigner,
+		tree.VerifyLeafIdentityHash,
+		accessPolicy,
 		tree.TreeId); err != nil {
 		return nil, err
 	}
@@ -461,6 +827,368 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	return tree, nil
 }
 
+func (t *adminTX) SoftDeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tree, err := t.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if storage.IsDeleted(tree.TreeState) {
+		return nil, errors.Errorf(errors.FailedPrecondition, "tree %v is already %s", treeID, tree.TreeState)
+	}
+	nowMillis := toMillisSinceEpoch(time.Now())
+	if err := t.updateTreeDeletion(ctx, treeID, trillian.TreeState_SOFT_DELETED, nowMillis, nowMillis); err != nil {
+		return nil, err
+	}
+	return t.GetTree(ctx, treeID)
+}
+
+func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tree, err := t.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if tree.TreeState != trillian.TreeState_SOFT_DELETED {
+		return nil, errors.Errorf(errors.FailedPrecondition, "tree %v is %s, only SOFT_DELETED trees can be undeleted", treeID, tree.TreeState)
+	}
+	if err := t.updateTreeDeletion(ctx, treeID, trillian.TreeState_FROZEN, 0, toMillisSinceEpoch(time.Now())); err != nil {
+		return nil, err
+	}
+	return t.GetTree(ctx, treeID)
+}
+
+func (t *adminTX) HardDeleteTree(ctx context.Context, treeID int64) error {
+	tree, err := t.GetTree(ctx, treeID)
+	if err != nil {
+		return err
+	}
+	if tree.TreeState != trillian.TreeState_SOFT_DELETED {
+		return errors.Errorf(errors.FailedPrecondition, "tree %v is %s, only SOFT_DELETED trees can be hard-deleted", treeID, tree.TreeState)
+	}
+	for _, query := range hardDeleteTreeSQL {
+		if _, err := t.tx.ExecContext(ctx, query, treeID); err != nil {
+			return err
+		}
+	}
+	deleteMillis := toMillisSinceEpoch(time.Now())
+	if tree.DeleteTime != nil {
+		deleteTime, err := ptypes.Timestamp(tree.DeleteTime)
+		if err != nil {
+			return fmt.Errorf("could not parse DeleteTime: %v", err)
+		}
+		deleteMillis = toMillisSinceEpoch(deleteTime)
+	}
+	return t.updateTreeDeletion(ctx, treeID, trillian.TreeState_HARD_DELETED, deleteMillis, toMillisSinceEpoch(time.Now()))
+}
+
+func (t *adminTX) RotateTreeKey(ctx context.Context, treeID int64, rotation *trillian.KeyRotation) (*trillian.Tree, error) {
+	tree, err := t.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if storage.IsDeleted(tree.TreeState) {
+		return nil, errors.Errorf(errors.FailedPrecondition, "tree %v is %s", treeID, tree.TreeState)
+	}
+	tree.KeyRotation = rotation
+	if err := storage.ValidateKeyRotation(tree); err != nil {
+		return nil, err
+	}
+	if err := t.updateTreeKeys(ctx, tree); err != nil {
+		return nil, err
+	}
+	return t.GetTree(ctx, treeID)
+}
+
+func (t *adminTX) RetireTreeKey(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tree, err := t.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case storage.IsDeleted(tree.TreeState):
+		return nil, errors.Errorf(errors.FailedPrecondition, "tree %v is %s", treeID, tree.TreeState)
+	case tree.KeyRotation == nil:
+		return nil, errors.Errorf(errors.FailedPrecondition, "tree %v has no key rotation", treeID)
+	}
+	tree.KeyHistory = storage.RetireKey(tree)
+	tree.PrivateKey = tree.KeyRotation.PrivateKey
+	tree.PublicKey = tree.KeyRotation.PublicKey
+	tree.KeyRotation = nil
+	if err := t.updateTreeKeys(ctx, tree); err != nil {
+		return nil, err
+	}
+	return t.GetTree(ctx, treeID)
+}
+
+// updateTreeKeys writes the private and public keys, key rotation and key history of tree.
+func (t *adminTX) updateTreeKeys(ctx context.Context, tree *trillian.Tree) error {
+	privateKey, err := proto.Marshal(tree.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("could not marshal PrivateKey: %v", err)
+	}
+	var keyRotation []byte
+	if tree.KeyRotation != nil {
+		if keyRotation, err = proto.Marshal(tree.KeyRotation); err != nil {
+			return fmt.Errorf("could not marshal KeyRotation: %v", err)
+		}
+	}
+	var keyHistory []byte
+	if len(tree.KeyHistory) > 0 {
+		if keyHistory, err = proto.Marshal(&storagepb.TreeKeyHistory{Keys: tree.KeyHistory}); err != nil {
+			return fmt.Errorf("could not marshal KeyHistory: %v", err)
+		}
+	}
+	_, err = t.tx.ExecContext(ctx, updateTreeKeysSQL, privateKey, tree.PublicKey.GetDer(), keyRotation, keyHistory, toMillisSinceEpoch(time.Now()), tree.TreeId)
+	return err
+}
+
+// updateTreeDeletion sets the state and delete time of treeID, clearing its drain deadline.
+// A deleteMillis of zero clears the delete time.
+func (t *adminTX) updateTreeDeletion(ctx context.Context, treeID int64, state trillian.TreeState, deleteMillis, nowMillis int64) error {
+	_, err := t.tx.ExecContext(ctx, updateTreeDeletionSQL, state.String(), deleteMillis, nowMillis, treeID)
+	return err
+}
+
+func (t *adminTX) ListQuotaConfigs(ctx context.Context) ([]*trillian.QuotaConfig, error) {
+	rows, err := t.tx.QueryContext(ctx, selectQuotaConfigsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cfgs := []*trillian.QuotaConfig{}
+	for rows.Next() {
+		cfg, err := readQuotaConfig(rows)
+		if err != nil {
+			return nil, err
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, rows.Err()
+}
+
+func readQuotaConfig(row row) (*trillian.QuotaConfig, error) {
+	cfg := &trillian.QuotaConfig{Bucket: &trillian.QuotaBucket{}}
+	var intervalMillis int64
+	if err := row.Scan(
+		&cfg.Bucket.Group,
+		&cfg.Bucket.Kind,
+		&cfg.Bucket.TreeId,
+		&cfg.Bucket.User,
+		&cfg.MaxTokens,
+		&cfg.TokensToReplenish,
+		&intervalMillis); err != nil {
+		return nil, err
+	}
+	cfg.ReplenishInterval = ptypes.DurationProto(time.Duration(intervalMillis) * time.Millisecond)
+	return cfg, nil
+}
+
+func (t *adminTX) CreateQuotaConfig(ctx context.Context, cfg *trillian.QuotaConfig) (*trillian.QuotaConfig, error) {
+	if err := storage.ValidateQuotaConfig(cfg); err != nil {
+		return nil, err
+	}
+	interval, err := ptypes.Duration(cfg.ReplenishInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse ReplenishInterval: %v", err)
+	}
+
+	b := cfg.Bucket
+	_, err = t.tx.ExecContext(
+		ctx,
+		insertQuotaConfigSQL,
+		b.Group,
+		b.Kind,
+		b.TreeId,
+		b.User,
+		cfg.MaxTokens,
+		cfg.TokensToReplenish,
+		interval/time.Millisecond)
+	switch {
+	case isDuplicateErr(err):
+		return nil, errors.Errorf(errors.AlreadyExists, "quota bucket already configured: %v", b)
+	case err != nil:
+		return nil, fmt.Errorf("failed to insert quota config: %v", err)
+	}
+
+	// Return the config as it's stored, with the interval truncated to millis.
+	newCfg := *cfg
+	newCfg.ReplenishInterval = ptypes.DurationProto(interval / time.Millisecond * time.Millisecond)
+	return &newCfg, nil
+}
+
+func (t *adminTX) UpdateQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket, updateFunc func(*trillian.QuotaConfig)) (*trillian.QuotaConfig, error) {
+	if err := storage.ValidateQuotaBucket(bucket); err != nil {
+		return nil, err
+	}
+	cfg, err := readQuotaConfig(t.tx.QueryRowContext(ctx, selectQuotaConfigForUpdateSQL, bucket.Group, bucket.Kind, bucket.TreeId, bucket.User))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, errors.Errorf(errors.NotFound, "quota bucket not configured: %v", bucket)
+	case err != nil:
+		return nil, fmt.Errorf("error reading quota config: %v", err)
+	}
+
+	updated := proto.Clone(cfg).(*trillian.QuotaConfig)
+	updateFunc(updated)
+	if err := storage.ValidateQuotaConfigForUpdate(cfg, updated); err != nil {
+		return nil, err
+	}
+	interval, err := ptypes.Duration(updated.ReplenishInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse ReplenishInterval: %v", err)
+	}
+
+	if _, err := t.tx.ExecContext(
+		ctx,
+		updateQuotaConfigSQL,
+		updated.MaxTokens,
+		updated.TokensToReplenish,
+		interval/time.Millisecond,
+		bucket.Group,
+		bucket.Kind,
+		bucket.TreeId,
+		bucket.User); err != nil {
+		return nil, err
+	}
+	updated.ReplenishInterval = ptypes.DurationProto(interval / time.Millisecond * time.Millisecond)
+	return updated, nil
+}
+
+func (t *adminTX) DeleteQuotaConfig(ctx context.Context, bucket *trillian.QuotaBucket) error {
+	if err := storage.ValidateQuotaBucket(bucket); err != nil {
+		return err
+	}
+	res, err := t.tx.ExecContext(ctx, deleteQuotaConfigSQL, bucket.Group, bucket.Kind, bucket.TreeId, bucket.User)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.Errorf(errors.NotFound, "quota bucket not configured: %v", bucket)
+	}
+	return nil
+}
+
+// maxClientTimestampSkew returns tree.MaxClientTimestampSkew, treating unset as zero.
+func maxClientTimestampSkew(tree *trillian.Tree) (time.Duration, error) {
+	if tree.MaxClientTimestampSkew == nil {
+		return 0, nil
+	}
+	skew, err := ptypes.Duration(tree.MaxClientTimestampSkew)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse MaxClientTimestampSkew: %v", err)
+	}
+	return skew, nil
+}
+
+// drainSettings returns tree.DrainGracePeriod, treating unset as zero, and tree.DrainDeadline
+// in millis since epoch, treating unset as zero.
+func drainSettings(tree *trillian.Tree) (time.Duration, int64, error) {
+	var gracePeriod time.Duration
+	if tree.DrainGracePeriod != nil {
+		var err error
+		if gracePeriod, err = ptypes.Duration(tree.DrainGracePeriod); err != nil {
+			return 0, 0, fmt.Errorf("could not parse DrainGracePeriod: %v", err)
+		}
+	}
+	if tree.DrainDeadline == nil {
+		return gracePeriod, 0, nil
+	}
+	deadline, err := ptypes.Timestamp(tree.DrainDeadline)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not parse DrainDeadline: %v", err)
+	}
+	return gracePeriod, toMillisSinceEpoch(deadline), nil
+}
+
+// marshalWitnesses returns the serialized tree.Witnesses, or nil if the tree has no witnesses.
+func marshalWitnesses(tree *trillian.Tree) ([]byte, error) {
+	if len(tree.Witnesses) == 0 {
+		return nil, nil
+	}
+	witnesses, err := proto.Marshal(&storagepb.TreeWitnesses{Witnesses: tree.Witnesses})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal Witnesses: %v", err)
+	}
+	return witnesses, nil
+}
+
+// marshalAdditionalPublicKeys returns the serialized tree.AdditionalPublicKeys, or nil if the
+// tree has no additional public keys.
+func marshalAdditionalPublicKeys(tree *trillian.Tree) ([]byte, error) {
+	if len(tree.AdditionalPublicKeys) == 0 {
+		return nil, nil
+	}
+	keys, err := proto.Marshal(&storagepb.TreePublicKeys{PublicKeys: tree.AdditionalPublicKeys})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal AdditionalPublicKeys: %v", err)
+	}
+	return keys, nil
+}
+
+// marshalSecondarySigner returns the serialized tree.SecondarySigner, or nil if it's unset.
+func marshalSecondarySigner(tree *trillian.Tree) ([]byte, error) {
+	if tree.SecondarySigner == nil {
+		return nil, nil
+	}
+	secondarySigner, err := proto.Marshal(tree.SecondarySigner)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal SecondarySigner: %v", err)
+	}
+	return secondarySigner, nil
+}
+
+// marshalRootRetention returns the serialized tree.RootRetention, or nil if it's unset.
+func marshalRootRetention(tree *trillian.Tree) ([]byte, error) {
+	if tree.RootRetention == nil {
+		return nil, nil
+	}
+	rootRetention, err := proto.Marshal(tree.RootRetention)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal RootRetention: %v", err)
+	}
+	return rootRetention, nil
+}
+
+// marshalDeadLetterPolicy returns the serialized tree.DeadLetterPolicy, or nil if it's unset.
+func marshalDeadLetterPolicy(tree *trillian.Tree) ([]byte, error) {
+	if tree.DeadLetterPolicy == nil {
+		return nil, nil
+	}
+	deadLetterPolicy, err := proto.Marshal(tree.DeadLetterPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal DeadLetterPolicy: %v", err)
+	}
+	return deadLetterPolicy, nil
+}
+
+// marshalDedupWindow returns the serialized tree.DedupWindow, or nil if it's unset.
+func marshalDedupWindow(tree *trillian.Tree) ([]byte, error) {
+	if tree.DedupWindow == nil {
+		return nil, nil
+	}
+	dedupWindow, err := proto.Marshal(tree.DedupWindow)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal DedupWindow: %v", err)
+	}
+	return dedupWindow, nil
+}
+
+// marshalAccessPolicy returns the serialized tree.AccessPolicy, or nil if it's unset.
+func marshalAccessPolicy(tree *trillian.Tree) ([]byte, error) {
+	if tree.AccessPolicy == nil {
+		return nil, nil
+	}
+	accessPolicy, err := proto.Marshal(tree.AccessPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal AccessPolicy: %v", err)
+	}
+	return accessPolicy, nil
+}
+
 func toMillisSinceEpoch(t time.Time) int64 {
 	return t.UnixNano() / 1000000
 }
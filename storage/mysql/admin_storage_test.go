@@ -18,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/golang/protobuf/ptypes"
@@ -181,6 +182,87 @@ func TestAdminTX_StorageSettingsNotSupported(t *testing.T) {
 	}
 }
 
+func TestAdminTX_GetTreeFootprint(t *testing.T) {
+	cleanTestDB(DB)
+	s := NewAdminStorage(DB)
+	ctx := context.Background()
+
+	tree, err := createTreeInternal(ctx, s, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("createTree() failed: %v", err)
+	}
+	createFakeLeaf(ctx, DB, tree.TreeId, []byte("id1"), []byte("hash1"), []byte("value"), []byte("extra"), 0, t)
+	createFakeLeaf(ctx, DB, tree.TreeId, []byte("id2"), []byte("hash2"), []byte("value"), nil, 1, t)
+
+	tx, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+	defer tx.Close()
+	fp, err := tx.GetTreeFootprint(ctx, tree.TreeId)
+	if err != nil {
+		t.Fatalf("GetTreeFootprint() failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	want := storage.TreeFootprint{LeafCount: 2, LeafDataBytes: 15}
+	if *fp != want {
+		t.Errorf("GetTreeFootprint() = %+v, want %+v", *fp, want)
+	}
+
+	tx2, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+	defer tx2.Close()
+	if _, err := tx2.GetTreeFootprint(ctx, tree.TreeId+1); err == nil {
+		t.Error("GetTreeFootprint() of unknown tree returned err = nil, want non-nil")
+	}
+}
+
+func TestAdminTX_ListPendingTrees(t *testing.T) {
+	cleanTestDB(DB)
+	s := NewAdminStorage(DB)
+	ctx := context.Background()
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		tree, err := createTreeInternal(ctx, s, testonly.LogTree)
+		if err != nil {
+			t.Fatalf("createTree() failed: %v", err)
+		}
+		ids = append(ids, tree.TreeId)
+	}
+	// Only the first tree gets queued leaves.
+	for i, id := range []string{"id1", "id2"} {
+		if _, err := DB.ExecContext(ctx, "INSERT INTO Unsequenced(TreeId, Bucket, LeafIdentityHash, MerkleLeafHash, QueueTimestampNanos) VALUES(?,0,?,?,?)", ids[0], []byte(id), []byte("hash"), i); err != nil {
+			t.Fatalf("Failed to queue leaf: %v", err)
+		}
+	}
+
+	for _, countLeaves := range []bool{false, true} {
+		want := map[int64]int64{ids[0]: 0}
+		if countLeaves {
+			want[ids[0]] = 2
+		}
+		tx, err := s.Snapshot(ctx)
+		if err != nil {
+			t.Fatalf("Snapshot() failed: %v", err)
+		}
+		got, err := tx.ListPendingTrees(ctx, countLeaves)
+		if err != nil {
+			t.Fatalf("ListPendingTrees(%v) failed: %v", countLeaves, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit() failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ListPendingTrees(%v) = %v, want %v", countLeaves, got, want)
+		}
+	}
+}
+
 func TestCheckDatabaseAccessible_Fails(t *testing.T) {
 	// Pass in a closed database to provoke a failure.
 	db := openTestDBOrDie()
@@ -0,0 +1,140 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// bucketDepthSampler periodically sets the depth gauges of the Unsequenced buckets of the
+// trees with several buckets that have been dequeued from. Counting the rows of a bucket
+// scans it, so it's done outside the sequencing transactions, and only as often as the
+// gauges need to be fresh.
+type bucketDepthSampler struct {
+	db       *sql.DB
+	interval time.Duration
+
+	mu sync.Mutex
+	// trees maps the IDs of the sampled trees to their number of buckets.
+	trees   map[int64]int
+	started bool
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newBucketDepthSampler(db *sql.DB, interval time.Duration) *bucketDepthSampler {
+	return &bucketDepthSampler{
+		db:       db,
+		interval: interval,
+		trees:    make(map[int64]int),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// watch adds a tree to the sampled trees, starting the sampling if it's the first.
+func (s *bucketDepthSampler) watch(treeID int64, buckets int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trees[treeID] = buckets
+	if !s.started {
+		s.started = true
+		go s.run()
+	}
+}
+
+// stop stops the sampling and waits for a sample in progress to finish.
+func (s *bucketDepthSampler) stop() {
+	s.mu.Lock()
+	started := s.started
+	s.started = true
+	s.mu.Unlock()
+	close(s.done)
+	if started {
+		<-s.stopped
+	}
+}
+
+func (s *bucketDepthSampler) run() {
+	defer close(s.stopped)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancel a sample in progress when stopped.
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.sample(ctx)
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *bucketDepthSampler) sample(ctx context.Context) {
+	s.mu.Lock()
+	trees := make(map[int64]int, len(s.trees))
+	for treeID, buckets := range s.trees {
+		trees[treeID] = buckets
+	}
+	s.mu.Unlock()
+
+	for treeID, buckets := range trees {
+		depths, err := readBucketDepths(ctx, s.db, treeID, buckets)
+		if err != nil {
+			// The depths are only informational.
+			glog.Warningf("%v: failed to read unsequenced bucket depths: %v", treeID, err)
+			continue
+		}
+		label := strconv.FormatInt(treeID, 10)
+		for bucket, depth := range depths {
+			unsequencedBucketDepth.Set(float64(depth), label, strconv.Itoa(bucket))
+		}
+	}
+}
+
+// readBucketDepths returns the number of leaves in each Unsequenced bucket of a tree.
+func readBucketDepths(ctx context.Context, db *sql.DB, treeID int64, buckets int) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, selectUnsequencedBucketDepthsSQL, treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depths := make([]int64, buckets)
+	for rows.Next() {
+		var bucket int
+		var depth int64
+		if err := rows.Scan(&bucket, &depth); err != nil {
+			return nil, err
+		}
+		if bucket >= 0 && bucket < len(depths) {
+			depths[bucket] = depth
+		}
+	}
+	return depths, rows.Err()
+}
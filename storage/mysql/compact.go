@@ -0,0 +1,102 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+// DefaultCompactTables are the tables compacted when CompactOptions.Tables is empty. These
+// are the tables whose rows are deleted in bulk by retention and revision pruning.
+var DefaultCompactTables = []string{"Subtree", "MapLeaf", "LeafData", "SequencedLeafData", "TreeHead"}
+
+// compactableTables is the set of tables Compact accepts. Table names can't be passed as
+// query parameters, so only these are ever interpolated into a statement.
+var compactableTables = map[string]bool{
+	"Subtree":             true,
+	"MapLeaf":             true,
+	"MapHead":             true,
+	"LeafData":            true,
+	"SequencedLeafData":   true,
+	"TreeHead":            true,
+	"Unsequenced":         true,
+	"DeadLetteredLeaves":  true,
+	"Cosignatures":        true,
+	"RootTimestampTokens": true,
+}
+
+const selectDataFreeSQL = `SELECT DATA_FREE FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`
+
+// CompactOptions configures Compact.
+type CompactOptions struct {
+	// Tables to compact, in order. Empty means DefaultCompactTables.
+	Tables []string
+	// MinFreeBytes is the amount of reclaimable space a table must have to be rebuilt.
+	// Tables with less are skipped.
+	MinFreeBytes int64
+	// Progress, if set, is called before each table is rebuilt with the space it is
+	// expected to reclaim.
+	Progress func(table string, freeBytes int64)
+}
+
+// Compact reclaims the space left behind by deleted rows, such as the subtrees and leaves of
+// pruned map revisions, by rebuilding tables one at a time. Each rebuild runs online
+// (ALGORITHM=INPLACE, LOCK=NONE), so trees stay readable and writable while it runs, and
+// only reorganizes storage: no rows are added, changed or removed, so the subtrees of a
+// tree's latest revision are never touched.
+//
+// The context is checked between tables, so cancelling it stops Compact once the table
+// being rebuilt is done. The tables that were rebuilt are returned, including when an error
+// stops the compaction part way through.
+func Compact(ctx context.Context, db *sql.DB, opts CompactOptions) ([]string, error) {
+	tables := opts.Tables
+	if len(tables) == 0 {
+		tables = DefaultCompactTables
+	}
+	for _, table := range tables {
+		if !compactableTables[table] {
+			return nil, fmt.Errorf("table %q can't be compacted", table)
+		}
+	}
+
+	var done []string
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		var free sql.NullInt64
+		if err := db.QueryRowContext(ctx, selectDataFreeSQL, table).Scan(&free); err != nil {
+			return done, fmt.Errorf("failed to read free space of %v: %v", table, err)
+		}
+		if free.Int64 < opts.MinFreeBytes {
+			glog.V(1).Infof("Skipping compaction of %v: %d bytes free", table, free.Int64)
+			continue
+		}
+		if opts.Progress != nil {
+			opts.Progress(table, free.Int64)
+		}
+		// The rebuild isn't passed ctx: interrupting an ALTER part way through only throws
+		// its work away.
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s FORCE, ALGORITHM=INPLACE, LOCK=NONE", table)); err != nil {
+			return done, fmt.Errorf("failed to rebuild %v: %v", table, err)
+		}
+		done = append(done, table)
+	}
+	return done, nil
+}
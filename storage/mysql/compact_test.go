@@ -0,0 +1,63 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+
+	var progress []string
+	done, err := Compact(ctx, DB, CompactOptions{
+		Tables:   []string{"Subtree", "MapLeaf"},
+		Progress: func(table string, _ int64) { progress = append(progress, table) },
+	})
+	if err != nil {
+		t.Fatalf("Compact() = (_, %v), want (_, nil)", err)
+	}
+	if want := []string{"Subtree", "MapLeaf"}; !reflect.DeepEqual(done, want) || !reflect.DeepEqual(progress, want) {
+		t.Errorf("Compact() compacted %v with progress %v, want %v", done, progress, want)
+	}
+
+	// Nothing has that much free space, so everything is skipped.
+	done, err = Compact(ctx, DB, CompactOptions{MinFreeBytes: 1 << 50})
+	if err != nil || len(done) != 0 {
+		t.Errorf("Compact(MinFreeBytes) = (%v, %v), want (nil, nil)", done, err)
+	}
+}
+
+func TestCompactErrors(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		desc string
+		ctx  context.Context
+		opts CompactOptions
+	}{
+		{desc: "unknownTable", ctx: context.Background(), opts: CompactOptions{Tables: []string{"Trees"}}},
+		{desc: "injection", ctx: context.Background(), opts: CompactOptions{Tables: []string{"Subtree; DROP TABLE Trees"}}},
+		{desc: "cancelled", ctx: cancelled},
+	}
+	for _, test := range tests {
+		if done, err := Compact(test.ctx, DB, test.opts); err == nil || len(done) != 0 {
+			t.Errorf("%v: Compact() = (%v, %v), want (nil, error)", test.desc, done, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Codec identifies how a leaf's ExtraData is encoded at rest. The codec used is
+// recorded alongside each LeafData row, so rows written with different codecs can
+// coexist and the default can be changed at any time without rewriting old data.
+//
+// The values are stored in the database and must not be renumbered.
+type Codec int8
+
+const (
+	// NoCompression stores ExtraData as supplied.
+	NoCompression Codec = 0
+	// GzipCompression stores ExtraData compressed with gzip.
+	GzipCompression Codec = 1
+)
+
+var codecNames = map[string]Codec{
+	"":     NoCompression,
+	"none": NoCompression,
+	"gzip": GzipCompression,
+}
+
+// ParseCodec converts a codec name ("none" or "gzip") into a Codec. The empty
+// string selects NoCompression.
+func ParseCodec(name string) (Codec, error) {
+	c, ok := codecNames[strings.ToLower(name)]
+	if !ok {
+		return NoCompression, fmt.Errorf("unknown MySQL ExtraData codec: %q", name)
+	}
+	return c, nil
+}
+
+// encode returns data encoded with c. Empty data is never compressed, as the
+// codec overhead would only make it larger.
+func (c Codec) encode(data []byte) ([]byte, Codec, error) {
+	if len(data) == 0 || c == NoCompression {
+		return data, NoCompression, nil
+	}
+	switch c {
+	case GzipCompression:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, c, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, c, err
+		}
+		return buf.Bytes(), c, nil
+	}
+	return nil, c, fmt.Errorf("unknown MySQL ExtraData codec: %d", c)
+}
+
+// decode reverses encode for data that was stored with c.
+func (c Codec) decode(data []byte) ([]byte, error) {
+	switch c {
+	case NoCompression:
+		return data, nil
+	case GzipCompression:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return ioutil.ReadAll(r)
+	}
+	return nil, fmt.Errorf("unknown MySQL ExtraData codec: %d", c)
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseCodec(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Codec
+		wantErr bool
+	}{
+		{name: "", want: NoCompression},
+		{name: "none", want: NoCompression},
+		{name: "gzip", want: GzipCompression},
+		{name: "GZIP", want: GzipCompression},
+		{name: "lz4", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseCodec(test.name)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("ParseCodec(%q) = (_, %v), wantErr = %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseCodec(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	json := bytes.Repeat([]byte(`{"issuer":"CN=Example CA","chain":[]}`), 50)
+	tests := []struct {
+		codec     Codec
+		data      []byte
+		wantCodec Codec
+	}{
+		{codec: NoCompression, data: json, wantCodec: NoCompression},
+		{codec: GzipCompression, data: json, wantCodec: GzipCompression},
+		{codec: GzipCompression, data: nil, wantCodec: NoCompression},
+		{codec: GzipCompression, data: []byte{}, wantCodec: NoCompression},
+	}
+	for _, test := range tests {
+		stored, codec, err := test.codec.encode(test.data)
+		if err != nil {
+			t.Errorf("%v.encode(%d bytes) = (_, _, %v), want nil", test.codec, len(test.data), err)
+			continue
+		}
+		if codec != test.wantCodec {
+			t.Errorf("%v.encode(%d bytes) stored with codec %v, want %v", test.codec, len(test.data), codec, test.wantCodec)
+		}
+		if codec == GzipCompression && len(stored) >= len(test.data) {
+			t.Errorf("%v.encode(%d bytes) = %d bytes, want fewer", test.codec, len(test.data), len(stored))
+		}
+		got, err := codec.decode(stored)
+		if err != nil {
+			t.Errorf("%v.decode() = (_, %v), want nil", codec, err)
+			continue
+		}
+		if !bytes.Equal(got, test.data) {
+			t.Errorf("%v round trip = %q, want %q", test.codec, got, test.data)
+		}
+	}
+}
+
+func TestCodecDecodeCorrupt(t *testing.T) {
+	if _, err := GzipCompression.decode([]byte("not gzip")); err == nil {
+		t.Error("GzipCompression.decode(corrupt) = (_, nil), want error")
+	}
+	if _, err := Codec(99).decode([]byte("data")); err == nil {
+		t.Error("Codec(99).decode() = (_, nil), want error")
+	}
+}
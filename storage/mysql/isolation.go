@@ -0,0 +1,78 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// IsolationLevels holds the transaction isolation levels used by MySQL storage.
+//
+// ReadWrite is used for transactions that may modify a tree, most importantly the
+// sequencing transaction that dequeues leaves, integrates them and writes a new root.
+// ReadOnly is used for snapshots serving reads such as proof and leaf queries.
+//
+// InnoDB defaults to REPEATABLE READ, which gives each transaction a consistent
+// snapshot but lets plain SELECTs run without taking locks. That is sufficient as long
+// as only one signer sequences a given tree at a time, as the TreeHead primary key
+// rejects a second root at the same revision. SERIALIZABLE makes InnoDB take shared
+// locks on every row read, so concurrent sequencing transactions on the same tree
+// conflict instead of both seeing the same queued leaves. The price is more lock
+// contention and deadlock retries, which is why read-only transactions are normally
+// left at a weaker level.
+type IsolationLevels struct {
+	ReadWrite sql.IsolationLevel
+	ReadOnly  sql.IsolationLevel
+}
+
+// DefaultIsolationLevels leaves the choice of isolation level to the database server.
+var DefaultIsolationLevels = IsolationLevels{
+	ReadWrite: sql.LevelDefault,
+	ReadOnly:  sql.LevelDefault,
+}
+
+var isolationLevelNames = map[string]sql.IsolationLevel{
+	"":                 sql.LevelDefault,
+	"default":          sql.LevelDefault,
+	"read-uncommitted": sql.LevelReadUncommitted,
+	"read-committed":   sql.LevelReadCommitted,
+	"repeatable-read":  sql.LevelRepeatableRead,
+	"serializable":     sql.LevelSerializable,
+}
+
+// ParseIsolationLevel converts a level name such as "repeatable-read" or
+// "serializable" into a sql.IsolationLevel. The empty string and "default" both
+// select the server's default level.
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	level, ok := isolationLevelNames[strings.ToLower(strings.Replace(name, "_", "-", -1))]
+	if !ok {
+		return sql.LevelDefault, fmt.Errorf("unknown MySQL isolation level: %q", name)
+	}
+	return level, nil
+}
+
+// txOptions returns the options for beginning a read-write or read-only transaction.
+func (l IsolationLevels) txOptions(readonly bool) *sql.TxOptions {
+	level := l.ReadWrite
+	if readonly {
+		level = l.ReadOnly
+	}
+	if level == sql.LevelDefault {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: level}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	spb "github.com/google/trillian/crypto/sigpb"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    sql.IsolationLevel
+		wantErr bool
+	}{
+		{name: "", want: sql.LevelDefault},
+		{name: "default", want: sql.LevelDefault},
+		{name: "read-committed", want: sql.LevelReadCommitted},
+		{name: "REPEATABLE_READ", want: sql.LevelRepeatableRead},
+		{name: "serializable", want: sql.LevelSerializable},
+		{name: "snapshot", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := ParseIsolationLevel(test.name)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("ParseIsolationLevel(%q) = (_, %v), wantErr = %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseIsolationLevel(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIsolationLevelsTxOptions(t *testing.T) {
+	l := IsolationLevels{ReadWrite: sql.LevelSerializable, ReadOnly: sql.LevelDefault}
+	if got := l.txOptions(false /* readonly */); got == nil || got.Isolation != sql.LevelSerializable {
+		t.Errorf("txOptions(false) = %+v, want Isolation = %v", got, sql.LevelSerializable)
+	}
+	if got := l.txOptions(true /* readonly */); got != nil {
+		t.Errorf("txOptions(true) = %+v, want nil", got)
+	}
+}
+
+// TestSequencingIsolationLevels runs a sequencing transaction at each supported
+// isolation level and checks that leaves are integrated exactly once, while a
+// concurrent read-only snapshot keeps seeing the root it started with.
+func TestSequencingIsolationLevels(t *testing.T) {
+	ctx := context.Background()
+
+	for _, level := range []sql.IsolationLevel{sql.LevelDefault, sql.LevelRepeatableRead, sql.LevelSerializable} {
+		cleanTestDB(DB)
+		logID := createLogForTests(DB)
+		s := newLogStorage(DB, nil, IsolationLevels{ReadWrite: level, ReadOnly: sql.LevelRepeatableRead})
+		desc := fmt.Sprintf("level %v", level)
+
+		{
+			tx := beginLogTx(s, logID, t)
+			if _, err := tx.QueueLeaves(ctx, createTestLeaves(leavesToInsert, 0), fakeQueueTime); err != nil {
+				t.Fatalf("%v: QueueLeaves() = %v", desc, err)
+			}
+			commit(tx, t)
+		}
+
+		snapshot, err := s.SnapshotForTree(ctx, logID)
+		if err != nil {
+			t.Fatalf("%v: SnapshotForTree() = %v", desc, err)
+		}
+		oldRoot, err := snapshot.LatestSignedLogRoot(ctx)
+		if err != nil {
+			t.Fatalf("%v: LatestSignedLogRoot() = %v", desc, err)
+		}
+
+		newRoot := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: 98765,
+			TreeSize:       leavesToInsert,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		{
+			tx := beginLogTx(s, logID, t)
+			leaves, err := tx.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime)
+			if err != nil {
+				t.Fatalf("%v: DequeueLeaves() = %v", desc, err)
+			}
+			if got, want := len(leaves), leavesToInsert; got != want {
+				t.Fatalf("%v: DequeueLeaves() returned %d leaves, want %d", desc, got, want)
+			}
+			for i, leaf := range leaves {
+				leaf.LeafIndex = int64(i)
+			}
+			if err := tx.UpdateSequencedLeaves(ctx, leaves); err != nil {
+				t.Fatalf("%v: UpdateSequencedLeaves() = %v", desc, err)
+			}
+			newRoot.TreeRevision = tx.WriteRevision()
+			if err := tx.StoreSignedLogRoot(ctx, newRoot); err != nil {
+				t.Fatalf("%v: StoreSignedLogRoot() = %v", desc, err)
+			}
+			commit(tx, t)
+		}
+
+		// The snapshot started before sequencing committed, so must not observe it.
+		root, err := snapshot.LatestSignedLogRoot(ctx)
+		if err != nil {
+			t.Fatalf("%v: LatestSignedLogRoot() = %v", desc, err)
+		}
+		if !proto.Equal(&root, &oldRoot) {
+			t.Errorf("%v: snapshot root changed from %v to %v", desc, oldRoot, root)
+		}
+		if err := snapshot.Commit(); err != nil {
+			t.Errorf("%v: snapshot Commit() = %v", desc, err)
+		}
+
+		{
+			tx := beginLogTx(s, logID, t)
+			leaves, err := tx.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime)
+			if err != nil {
+				t.Fatalf("%v: DequeueLeaves() = %v", desc, err)
+			}
+			if len(leaves) != 0 {
+				t.Errorf("%v: DequeueLeaves() returned %d leaves after sequencing, want 0", desc, len(leaves))
+			}
+			root, err := tx.LatestSignedLogRoot(ctx)
+			if err != nil {
+				t.Fatalf("%v: LatestSignedLogRoot() = %v", desc, err)
+			}
+			if !proto.Equal(&root, &newRoot) {
+				t.Errorf("%v: LatestSignedLogRoot() = %v, want %v", desc, root, newRoot)
+			}
+			commit(tx, t)
+		}
+	}
+}
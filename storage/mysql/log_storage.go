@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"sort"
 	"strconv"
 	"sync"
@@ -28,12 +29,14 @@ import (
 	"github.com/go-sql-driver/mysql"
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	spb "github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/trees"
 )
 
@@ -42,38 +45,107 @@ const (
 	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos
 			FROM Unsequenced
 			WHERE TreeID=?
-			AND Bucket=0
+			AND Bucket=?
 			AND QueueTimestampNanos<=?
 			ORDER BY QueueTimestampNanos,LeafIdentityHash ASC LIMIT ?`
-	insertUnsequencedLeafSQL = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,ExtraData)
-			VALUES(?,?,?,?)`
+	insertUnsequencedLeafSQL = `INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,ExtraData,ExtraDataCodec,QueueTimestampNanos,Generation)
+			VALUES(?,?,?,?,?,?,?)`
 	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,Bucket,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos)
-			VALUES(?,0,?,?,?)`
-	insertSequencedLeafSQL = `INSERT INTO SequencedLeafData(TreeId,LeafIdentityHash,MerkleLeafHash,SequenceNumber)
+			VALUES(?,?,?,?,?)`
+	// A leaf being sequenced is always the latest generation of its identity hash, as
+	// the identity hash can't be queued again until the leaf is in the log.
+	insertSequencedLeafSQL = `INSERT INTO SequencedLeafData(TreeId,LeafIdentityHash,MerkleLeafHash,SequenceNumber,Generation)
+			SELECT ?,?,?,?,COALESCE(MAX(Generation),0) FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?`
+	selectSequencedLeafCountSQL = "SELECT COUNT(*) FROM SequencedLeafData WHERE TreeId=?"
+	insertDeadLetteredLeafSQL   = `INSERT INTO DeadLetteredLeaves(TreeId,LeafIdentityHash,MerkleLeafHash,DeadLetterTimestampNanos,Attempts,Reason)
+			VALUES(?,?,?,?,?,?)
+			ON DUPLICATE KEY UPDATE DeadLetterTimestampNanos=VALUES(DeadLetterTimestampNanos),Attempts=VALUES(Attempts),Reason=VALUES(Reason)`
+	selectDeadLetteredLeavesSQL = `SELECT d.LeafIdentityHash,d.MerkleLeafHash,l.LeafValue,l.ExtraData,l.ExtraDataCodec,l.QueueTimestampNanos,d.Reason,d.Attempts,d.DeadLetterTimestampNanos
+			FROM DeadLetteredLeaves d,LeafData l
+			WHERE d.TreeId=? AND l.TreeId=d.TreeId AND l.LeafIdentityHash=d.LeafIdentityHash
+			AND l.Generation=(SELECT MAX(g.Generation) FROM LeafData g WHERE g.TreeId=d.TreeId AND g.LeafIdentityHash=d.LeafIdentityHash)
+			ORDER BY d.DeadLetterTimestampNanos,d.LeafIdentityHash`
+	selectDedupEntrySQL = `SELECT Generation,QueueTimestampNanos FROM LeafIdentityDedup
+			WHERE TreeId=? AND LeafIdentityHash=? FOR UPDATE`
+	insertDedupEntrySQL = `INSERT INTO LeafIdentityDedup(TreeId,LeafIdentityHash,Generation,QueueTimestampNanos)
 			VALUES(?,?,?,?)`
-	selectSequencedLeafCountSQL  = "SELECT COUNT(*) FROM SequencedLeafData WHERE TreeId=?"
-	selectLatestSignedLogRootSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature
+	updateDedupEntrySQL = `UPDATE LeafIdentityDedup SET Generation=?,QueueTimestampNanos=?
+			WHERE TreeId=? AND LeafIdentityHash=?`
+	selectNextGenerationSQL           = "SELECT COALESCE(MAX(Generation)+1,0) FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?"
+	selectGenerationSequenceNumberSQL = "SELECT SequenceNumber FROM SequencedLeafData WHERE TreeId=? AND LeafIdentityHash=? AND Generation=?"
+	// Entries of leaves that haven't been sequenced are always within the window, and
+	// are kept by the join.
+	deleteDedupEntriesOutsideWindowSQL = `DELETE d FROM LeafIdentityDedup d
+			INNER JOIN SequencedLeafData s
+			ON s.TreeId=d.TreeId AND s.LeafIdentityHash=d.LeafIdentityHash AND s.Generation=d.Generation
+			WHERE d.TreeId=? AND (s.SequenceNumber<? OR d.QueueTimestampNanos<?)`
+	selectDeadLetteredMerkleHashSQL = "SELECT MerkleLeafHash FROM DeadLetteredLeaves WHERE TreeId=? AND LeafIdentityHash=?"
+	deleteDeadLetteredLeafSQL       = "DELETE FROM DeadLetteredLeaves WHERE TreeId=? AND LeafIdentityHash=?"
+	// These statements are extended with the conditions of a LeafFilter.
+	countLeavesSQL          = "SELECT COUNT(*) FROM LeafData l WHERE l.TreeId=?"
+	countSequencedLeavesSQL = `SELECT COUNT(*) FROM LeafData l,SequencedLeafData s
+			WHERE l.TreeId=? AND s.TreeId=l.TreeId AND s.LeafIdentityHash=l.LeafIdentityHash AND s.Generation=l.Generation`
+	countUnsequencedLeavesSQL        = "SELECT COUNT(*) FROM Unsequenced l WHERE l.TreeId=?"
+	selectUnsequencedBucketDepthsSQL = "SELECT Bucket,COUNT(*) FROM Unsequenced WHERE TreeId=? GROUP BY Bucket"
+	selectLatestSignedLogRootSQL     = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures
 			FROM TreeHead WHERE TreeId=?
 			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
-	deleteUnsequencedSQL = "DELETE FROM Unsequenced WHERE TreeId=? AND Bucket=0 AND QueueTimestampNanos=? AND LeafIdentityHash=?"
+	selectSignedLogRootAtTimeSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures
+			FROM TreeHead WHERE TreeId=? AND TreeHeadTimestamp<=?
+			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
+	selectSignedLogRootAtSizeSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures
+			FROM TreeHead WHERE TreeId=? AND TreeSize=?
+			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
+	selectSignedLogRootsSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures
+			FROM TreeHead WHERE TreeId=? AND TreeRevision>?
+			ORDER BY TreeRevision LIMIT ?`
+	deleteUnsequencedSQL           = "DELETE FROM Unsequenced WHERE TreeId=? AND Bucket=? AND QueueTimestampNanos=? AND LeafIdentityHash=?"
+	selectLatestCosignedLogRootSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures
+			FROM TreeHead WHERE TreeId=?
+			AND TreeRevision=(SELECT MAX(TreeRevision) FROM Cosignatures WHERE TreeId=?)`
+	selectCosignaturesSQL = `SELECT WitnessName,Signature FROM Cosignatures
+			WHERE TreeId=? AND TreeRevision=?
+			ORDER BY WitnessName`
+	insertCosignatureSQL = `INSERT INTO Cosignatures(TreeId,TreeRevision,WitnessName,Signature)
+			VALUES(?,?,?,?)
+			ON DUPLICATE KEY UPDATE Signature=VALUES(Signature)`
+	selectTimestampTokenSQL            = "SELECT Token FROM RootTimestampTokens WHERE TreeId=? AND TreeRevision=?"
+	selectLatestTimestampedRevisionSQL = "SELECT COALESCE(MAX(TreeRevision), -1) FROM RootTimestampTokens WHERE TreeId=?"
+	insertTimestampTokenSQL            = `INSERT INTO RootTimestampTokens(TreeId,TreeRevision,Token)
+			VALUES(?,?,?)
+			ON DUPLICATE KEY UPDATE Token=VALUES(Token)`
+	selectNthLatestTreeRevisionSQL = `SELECT TreeRevision FROM TreeHead WHERE TreeId=?
+			ORDER BY TreeRevision DESC LIMIT 1 OFFSET ?`
+	deleteOldTreeHeadsSQL = `DELETE FROM TreeHead
+			WHERE TreeId=? AND TreeRevision<? AND TreeHeadTimestamp<?
+			AND TreeRevision NOT IN (SELECT TreeRevision FROM Cosignatures WHERE TreeId=?)`
 
 	// These statements need to be expanded to provide the correct number of parameter placeholders.
-	selectLeavesByIndexSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData
+	selectLeavesByIndexSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.ExtraDataCodec
 			FROM LeafData l,SequencedLeafData s
-			WHERE l.LeafIdentityHash = s.LeafIdentityHash
+			WHERE l.LeafIdentityHash = s.LeafIdentityHash AND l.Generation = s.Generation
 			AND s.SequenceNumber IN (` + placeholderSQL + `) AND l.TreeId = ? AND s.TreeId = l.TreeId`
-	selectLeavesByMerkleHashSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData
+	selectLeavesByMerkleHashSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.ExtraDataCodec
 			FROM LeafData l,SequencedLeafData s
-			WHERE l.LeafIdentityHash = s.LeafIdentityHash
+			WHERE l.LeafIdentityHash = s.LeafIdentityHash AND l.Generation = s.Generation
 			AND s.MerkleLeafHash IN (` + placeholderSQL + `) AND l.TreeId = ? AND s.TreeId = l.TreeId`
+	selectLatestLeafByIdentityHashPrefixSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.ExtraDataCodec
+			FROM LeafData l,SequencedLeafData s
+			WHERE l.LeafIdentityHash = s.LeafIdentityHash AND l.Generation = s.Generation
+			AND l.TreeId = ? AND s.TreeId = l.TreeId AND l.LeafIdentityHash >= ?`
 	// TODO(drysdale): rework the code so the dummy hash isn't needed (e.g. this assumes hash size is 32)
 	dummyMerkleLeafHash = "00000000000000000000000000000000"
 	// This statement returns a dummy Merkle leaf hash value (which must be
 	// of the right size) so that its signature matches that of the other
 	// leaf-selection statements.
-	selectLeavesByLeafIdentityHashSQL = `SELECT '` + dummyMerkleLeafHash + `',l.LeafIdentityHash,l.LeafValue,-1,l.ExtraData
+	selectLeavesByLeafIdentityHashSQL = `SELECT '` + dummyMerkleLeafHash + `',l.LeafIdentityHash,l.LeafValue,-1,l.ExtraData,l.ExtraDataCodec
 			FROM LeafData l
-			WHERE l.LeafIdentityHash IN (` + placeholderSQL + `) AND l.TreeId = ?`
+			WHERE l.LeafIdentityHash IN (` + placeholderSQL + `) AND l.TreeId = ?
+			AND l.Generation = (SELECT MAX(g.Generation) FROM LeafData g WHERE g.TreeId = l.TreeId AND g.LeafIdentityHash = l.LeafIdentityHash)`
+	selectLeafIdentityHashesSQL = `SELECT LeafIdentityHash FROM LeafData
+			WHERE LeafIdentityHash IN (` + placeholderSQL + `) AND TreeId = ?`
+	selectSequencedLeafIdentityHashesSQL = `SELECT DISTINCT LeafIdentityHash FROM SequencedLeafData
+			WHERE LeafIdentityHash IN (` + placeholderSQL + `) AND TreeId = ?`
 
 	// Same as above except with leaves ordered by sequence so we only incur this cost when necessary
 	orderBySequenceNumberSQL                     = " ORDER BY s.SequenceNumber"
@@ -82,7 +154,8 @@ const (
 	// Error code returned by driver when inserting a duplicate row
 	errNumDuplicate = 1062
 
-	logIDLabel = "logid"
+	logIDLabel  = "logid"
+	bucketLabel = "bucket"
 )
 
 var (
@@ -101,6 +174,9 @@ var (
 	dequeueLatency          monitoring.Histogram
 	dequeueSelectLatency    monitoring.Histogram
 	dequeueRemoveLatency    monitoring.Histogram
+
+	extraDataCompressionRatio monitoring.Gauge
+	unsequencedBucketDepth    monitoring.Gauge
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -117,6 +193,9 @@ func createMetrics(mf monitoring.MetricFactory) {
 	dequeueLatency = mf.NewHistogram("mysql_dequeue_leaves_latency", "Latency of dequeue leaves operation in seconds", logIDLabel)
 	dequeueSelectLatency = mf.NewHistogram("mysql_dequeue_leaves_latency_select", "Latency of selection part of dequeue leaves operation in seconds", logIDLabel)
 	dequeueRemoveLatency = mf.NewHistogram("mysql_dequeue_leaves_latency_remove", "Latency of removal part of dequeue leaves operation in seconds", logIDLabel)
+
+	extraDataCompressionRatio = mf.NewGauge("mysql_extra_data_compression_ratio", "Ratio of stored to supplied ExtraData size for the most recent batch of queued leaves", logIDLabel)
+	unsequencedBucketDepth = mf.NewGauge("mysql_unsequenced_bucket_depth", "Number of queued leaves in each Unsequenced bucket of trees with several buckets, sampled every --mysql_bucket_depth_interval", logIDLabel, bucketLabel)
 }
 
 func labelForTX(t *logTreeTX) string {
@@ -131,17 +210,26 @@ type mySQLLogStorage struct {
 	*mySQLTreeStorage
 	admin         storage.AdminStorage
 	metricFactory monitoring.MetricFactory
+	// extraDataCodec is used to encode the ExtraData of newly queued leaves.
+	extraDataCodec Codec
+	// bucketDepths samples the depths of the Unsequenced buckets of dequeued trees, if
+	// not nil.
+	bucketDepths *bucketDepthSampler
 }
 
 // NewLogStorage creates a storage.LogStorage instance for the specified MySQL URL.
 // It assumes storage.AdminStorage is backed by the same MySQL database as well.
 func NewLogStorage(db *sql.DB, mf monitoring.MetricFactory) storage.LogStorage {
+	return newLogStorage(db, mf, DefaultIsolationLevels)
+}
+
+func newLogStorage(db *sql.DB, mf monitoring.MetricFactory, isolation IsolationLevels) *mySQLLogStorage {
 	if mf == nil {
 		mf = monitoring.InertMetricFactory{}
 	}
 	return &mySQLLogStorage{
 		admin:            NewAdminStorage(db),
-		mySQLTreeStorage: newTreeStorage(db),
+		mySQLTreeStorage: newTreeStorage(db, isolation),
 		metricFactory:    mf,
 	}
 }
@@ -166,6 +254,15 @@ func (m *mySQLLogStorage) getLeavesByLeafIdentityHashStmt(ctx context.Context, n
 	return m.getStmt(ctx, selectLeavesByLeafIdentityHashSQL, num, "?", "?")
 }
 
+func (m *mySQLLogStorage) getLeafIdentityHashesStmt(ctx context.Context, num int, includeUnsequenced bool) (*sql.Stmt, error) {
+	// Queued leaves are written to LeafData straight away, but only appear in
+	// SequencedLeafData once they're integrated.
+	if includeUnsequenced {
+		return m.getStmt(ctx, selectLeafIdentityHashesSQL, num, "?", "?")
+	}
+	return m.getStmt(ctx, selectSequencedLeafIdentityHashesSQL, num, "?", "?")
+}
+
 func getActiveLogIDsInternal(ctx context.Context, tx *sql.Tx, sql string) ([]int64, error) {
 	rows, err := tx.QueryContext(ctx, sql)
 	if err != nil {
@@ -193,13 +290,17 @@ func getActiveLogIDs(ctx context.Context, tx *sql.Tx) ([]int64, error) {
 	return getActiveLogIDsInternal(ctx, tx, selectActiveLogsSQL)
 }
 
+func getActiveLogIDsWithPendingWork(ctx context.Context, tx *sql.Tx) ([]int64, error) {
+	return getActiveLogIDsInternal(ctx, tx, selectActiveLogsWithUnsequencedSQL)
+}
+
 // readOnlyLogTX implements storage.ReadOnlyLogTX
 type readOnlyLogTX struct {
 	tx *sql.Tx
 }
 
 func (m *mySQLLogStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
-	tx, err := m.db.BeginTx(ctx, nil /* opts */)
+	tx, err := m.db.BeginTx(ctx, m.isolation.txOptions(true /* readonly */))
 	if err != nil {
 		glog.Warningf("Could not start ReadOnlyLogTX: %s", err)
 		return nil, err
@@ -227,6 +328,10 @@ func (t *readOnlyLogTX) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
 	return getActiveLogIDs(ctx, t.tx)
 }
 
+func (t *readOnlyLogTX) GetActiveLogIDsWithPendingWork(ctx context.Context) ([]int64, error) {
+	return getActiveLogIDsWithPendingWork(ctx, t.tx)
+}
+
 func (m *mySQLLogStorage) beginInternal(ctx context.Context, treeID int64, readonly bool) (storage.LogTreeTX, error) {
 	once.Do(func() {
 		createMetrics(m.metricFactory)
@@ -245,14 +350,20 @@ func (m *mySQLLogStorage) beginInternal(ctx context.Context, treeID int64, reado
 	}
 
 	stCache := cache.NewSubtreeCache(defaultLogStrata, cache.PopulateLogSubtreeNodes(hasher), cache.PrepareLogSubtreeWrite())
-	ttx, err := m.beginTreeTx(ctx, treeID, hasher.Size(), stCache)
+	ttx, err := m.beginTreeTx(ctx, treeID, hasher.Size(), stCache, readonly)
 	if err != nil {
 		return nil, err
 	}
 
 	ltx := &logTreeTX{
-		treeTX: ttx,
-		ls:     m,
+		treeTX:      ttx,
+		ls:          m,
+		dedupWindow: tree.DedupWindow,
+		// The bucket count can't change, so it needn't be read in the transaction.
+		unsequencedBuckets: 1,
+	}
+	if tree.UnsequencedBuckets > 1 {
+		ltx.unsequencedBuckets = int(tree.UnsequencedBuckets)
 	}
 
 	ltx.root, err = ltx.fetchLatestRoot(ctx)
@@ -279,8 +390,11 @@ func (m *mySQLLogStorage) SnapshotForTree(ctx context.Context, treeID int64) (st
 
 type logTreeTX struct {
 	treeTX
-	ls   *mySQLLogStorage
-	root trillian.SignedLogRoot
+	ls          *mySQLLogStorage
+	root        trillian.SignedLogRoot
+	dedupWindow *trillian.DedupWindow
+	// unsequencedBuckets is the number of Unsequenced buckets of the tree, at least 1.
+	unsequencedBuckets int
 }
 
 func (t *logTreeTX) ReadRevision() int64 {
@@ -293,8 +407,22 @@ func (t *logTreeTX) WriteRevision() int64 {
 
 // dequeuedLeaf is used internally and contains some data that is not part of the client API.
 type dequeuedLeaf struct {
+	bucket              int
 	queueTimestampNanos int64
 	leafIdentityHash    []byte
+	merkleLeafHash      []byte
+}
+
+// byQueueOrder sorts dequeued leaves in the order of selectQueuedLeavesSQL.
+type byQueueOrder []*dequeuedLeaf
+
+func (b byQueueOrder) Len() int      { return len(b) }
+func (b byQueueOrder) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byQueueOrder) Less(i, j int) bool {
+	if b[i].queueTimestampNanos != b[j].queueTimestampNanos {
+		return b[i].queueTimestampNanos < b[j].queueTimestampNanos
+	}
+	return bytes.Compare(b[i].leafIdentityHash, b[j].leafIdentityHash) < 0
 }
 
 func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime time.Time) ([]*trillian.LogLeaf, error) {
@@ -305,48 +433,37 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 		glog.Warningf("Failed to prepare dequeue select: %s", err)
 		return nil, err
 	}
+	defer stx.Close()
 
-	leaves := make([]*trillian.LogLeaf, 0, limit)
+	// Each bucket is in queue order, so the first limit leaves of the tree are among the
+	// first limit leaves of each bucket. Merging them keeps the order the same as it would
+	// be with a single bucket.
 	dql := make([]*dequeuedLeaf, 0, limit)
-	rows, err := stx.QueryContext(ctx, t.treeID, cutoffTime.UnixNano(), limit)
-
-	if err != nil {
-		glog.Warningf("Failed to select rows for work: %s", err)
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	for rows.Next() {
-		var leafIDHash []byte
-		var merkleHash []byte
-		var queueTimeNanos int64
-
-		err := rows.Scan(&leafIDHash, &merkleHash, &queueTimeNanos)
-
+	for bucket := 0; bucket < t.unsequencedBuckets; bucket++ {
+		bucketLeaves, err := t.dequeueBucket(ctx, stx, bucket, limit, cutoffTime)
 		if err != nil {
-			glog.Warningf("Error scanning work rows: %s", err)
 			return nil, err
 		}
-
-		if len(leafIDHash) != t.hashSizeBytes {
-			return nil, errors.New("Dequeued a leaf with incorrect hash size")
+		dql = append(dql, bucketLeaves...)
+	}
+	if t.unsequencedBuckets > 1 {
+		sort.Sort(byQueueOrder(dql))
+		if len(dql) > limit {
+			dql = dql[:limit]
 		}
+	}
 
+	leaves := make([]*trillian.LogLeaf, 0, len(dql))
+	for _, d := range dql {
 		// Note: the LeafData and ExtraData being nil here is OK as this is only used by the
 		// sequencer. The sequencer only writes to the SequencedLeafData table and the client
 		// supplied data was already written to LeafData as part of queueing the leaf.
-		leaf := &trillian.LogLeaf{
-			LeafIdentityHash: leafIDHash,
-			MerkleLeafHash:   merkleHash,
-		}
-		leaves = append(leaves, leaf)
-		dql = append(dql, &dequeuedLeaf{queueTimestampNanos: queueTimeNanos, leafIdentityHash: leafIDHash})
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIdentityHash: d.leafIdentityHash,
+			MerkleLeafHash:   d.merkleLeafHash,
+		})
 	}
 
-	if rows.Err() != nil {
-		return nil, rows.Err()
-	}
 	label := labelForTX(t)
 	selectDuration := time.Now().Sub(start)
 	observe(dequeueSelectLatency, selectDuration, label)
@@ -367,9 +484,53 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 	observe(dequeueLatency, totalDuration, label)
 	dequeuedCounter.Add(float64(len(leaves)), label)
 
+	if t.unsequencedBuckets > 1 && t.ls.bucketDepths != nil {
+		t.ls.bucketDepths.watch(t.treeID, t.unsequencedBuckets)
+	}
+
 	return leaves, nil
 }
 
+// dequeueBucket returns up to limit leaves of a bucket queued no later than cutoffTime, in
+// queue order.
+func (t *logTreeTX) dequeueBucket(ctx context.Context, stx *sql.Stmt, bucket, limit int, cutoffTime time.Time) ([]*dequeuedLeaf, error) {
+	rows, err := stx.QueryContext(ctx, t.treeID, bucket, cutoffTime.UnixNano(), limit)
+
+	if err != nil {
+		glog.Warningf("Failed to select rows for work: %s", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var dql []*dequeuedLeaf
+	for rows.Next() {
+		d := &dequeuedLeaf{bucket: bucket}
+		err := rows.Scan(&d.leafIdentityHash, &d.merkleLeafHash, &d.queueTimestampNanos)
+
+		if err != nil {
+			glog.Warningf("Error scanning work rows: %s", err)
+			return nil, err
+		}
+
+		if len(d.leafIdentityHash) != t.hashSizeBytes {
+			return nil, errors.New("Dequeued a leaf with incorrect hash size")
+		}
+		dql = append(dql, d)
+	}
+	return dql, rows.Err()
+}
+
+// unsequencedBucket returns the Unsequenced bucket of a leaf of the tree.
+func (t *logTreeTX) unsequencedBucket(leafIdentityHash []byte) int {
+	if t.unsequencedBuckets <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write(leafIdentityHash)
+	return int(h.Sum32() % uint32(t.unsequencedBuckets))
+}
+
 func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error) {
 	// Don't accept batches if any of the leaves are invalid.
 	for _, leaf := range leaves {
@@ -390,11 +551,41 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 	sort.Sort(byLeafIdentityHashWithPosition(orderedLeaves))
 	existingCount := 0
 	existingLeaves := make([]*trillian.LogLeaf, len(leaves))
+	rawExtraBytes, storedExtraBytes := 0, 0
 
 	for i, leafPos := range orderedLeaves {
 		leafStart := time.Now()
 		leaf := leafPos.leaf
-		_, err := t.tx.ExecContext(ctx, insertUnsequencedLeafSQL, t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData)
+
+		// Honour any queue timestamp already assigned to the leaf.
+		leafQueueTimestamp := queueTimestamp
+		if leaf.QueueTimestamp != nil {
+			var err error
+			leafQueueTimestamp, err = ptypes.Timestamp(leaf.QueueTimestamp)
+			if err != nil {
+				return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+			}
+		}
+		var generation int64
+		if t.dedupWindow != nil {
+			gen, dup, err := t.dedupGeneration(ctx, leaf.LeafIdentityHash, leafQueueTimestamp.UnixNano(), queueTimestamp)
+			if err != nil {
+				glog.Warningf("Error deduplicating %d: %s", i, err)
+				return nil, err
+			}
+			if dup {
+				existingLeaves[leafPos.idx] = leaf
+				existingCount++
+				queuedDupCounter.Inc(label)
+				continue
+			}
+			generation = gen
+		}
+		extraData, codec, err := t.ls.extraDataCodec.encode(leaf.ExtraData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode extra data: %v", err)
+		}
+		_, err = t.tx.ExecContext(ctx, insertUnsequencedLeafSQL, t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, extraData, codec, leafQueueTimestamp.UnixNano(), generation)
 		insertDuration := time.Now().Sub(leafStart)
 		observe(queueInsertLeafLatency, insertDuration, label)
 		if isDuplicateErr(err) {
@@ -408,15 +599,18 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 			glog.Warningf("Error inserting %d into LeafData: %s", i, err)
 			return nil, err
 		}
+		rawExtraBytes += len(leaf.ExtraData)
+		storedExtraBytes += len(extraData)
 
-		// Create the work queue entry
+		// Create the work queue entry.
 		_, err = t.tx.ExecContext(
 			ctx,
 			insertUnsequencedEntrySQL,
 			t.treeID,
+			t.unsequencedBucket(leaf.LeafIdentityHash),
 			leaf.LeafIdentityHash,
 			leaf.MerkleLeafHash,
-			queueTimestamp.UnixNano())
+			leafQueueTimestamp.UnixNano())
 		if err != nil {
 			glog.Warningf("Error inserting into Unsequenced: %s", err)
 			return nil, fmt.Errorf("Unsequenced: %v", err)
@@ -427,6 +621,9 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 	insertDuration := time.Now().Sub(start)
 	observe(queueInsertLatency, insertDuration, label)
 	queuedCounter.Add(float64(len(leaves)), label)
+	if rawExtraBytes > 0 {
+		extraDataCompressionRatio.Set(float64(storedExtraBytes)/float64(rawExtraBytes), label)
+	}
 
 	if existingCount == 0 {
 		return existingLeaves, nil
@@ -483,6 +680,90 @@ func (t *logTreeTX) GetSequencedLeafCount(ctx context.Context) (int64, error) {
 	return sequencedLeafCount, err
 }
 
+func (t *logTreeTX) CountLeaves(ctx context.Context, filter storage.LeafFilter) (int64, error) {
+	query := countLeavesSQL
+	switch {
+	case filter.SequencedOnly && filter.UnsequencedOnly:
+		return 0, errors.New("can't count leaves that are both sequenced and unsequenced")
+	case filter.SequencedOnly:
+		query = countSequencedLeavesSQL
+	case filter.UnsequencedOnly:
+		query = countUnsequencedLeavesSQL
+	}
+	args := []interface{}{t.treeID}
+	if filter.MinQueueTimestampNanos != 0 {
+		query += " AND l.QueueTimestampNanos>=?"
+		args = append(args, filter.MinQueueTimestampNanos)
+	}
+	if filter.MaxQueueTimestampNanos != 0 {
+		query += " AND l.QueueTimestampNanos<?"
+		args = append(args, filter.MaxQueueTimestampNanos)
+	}
+	// Identity hashes with a given prefix sort between the prefix itself and the smallest value
+	// greater than all of them, so the primary key index can be used.
+	if prefix := filter.LeafIdentityHashPrefix; len(prefix) > 0 {
+		query += " AND l.LeafIdentityHash>=?"
+		args = append(args, prefix)
+		if end := prefixEnd(prefix); end != nil {
+			query += " AND l.LeafIdentityHash<?"
+			args = append(args, end)
+		}
+	}
+
+	var count int64
+	if err := t.tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		glog.Warningf("Failed to count leaves: %s", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (t *logTreeTX) GetLatestLeafByIdentityHashPrefix(ctx context.Context, prefix []byte) (*trillian.LogLeaf, error) {
+	// As in CountLeaves, the prefix is turned into a range of the primary key index.
+	query := selectLatestLeafByIdentityHashPrefixSQL
+	args := []interface{}{t.treeID, prefix}
+	if end := prefixEnd(prefix); end != nil {
+		query += " AND l.LeafIdentityHash < ?"
+		args = append(args, end)
+	}
+	query += " ORDER BY s.SequenceNumber DESC LIMIT 1"
+
+	leaf := &trillian.LogLeaf{}
+	var codec Codec
+	err := t.tx.QueryRowContext(ctx, query, args...).Scan(
+		&leaf.MerkleLeafHash,
+		&leaf.LeafIdentityHash,
+		&leaf.LeafValue,
+		&leaf.LeafIndex,
+		&leaf.ExtraData,
+		&codec)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		glog.Warningf("Failed to get latest leaf by identity hash prefix: %s", err)
+		return nil, err
+	}
+	if leaf.ExtraData, err = codec.decode(leaf.ExtraData); err != nil {
+		return nil, fmt.Errorf("failed to decode extra data: %v", err)
+	}
+	return leaf, nil
+}
+
+// prefixEnd returns the smallest value greater than all values starting with prefix, or nil if
+// there isn't one.
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
 func (t *logTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*trillian.LogLeaf, error) {
 	tmpl, err := t.ls.getLeavesByIndexStmt(ctx, len(leaves))
 	if err != nil {
@@ -504,15 +785,20 @@ func (t *logTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*tr
 	defer rows.Close()
 	for rows.Next() {
 		leaf := &trillian.LogLeaf{}
+		var codec Codec
 		if err := rows.Scan(
 			&leaf.MerkleLeafHash,
 			&leaf.LeafIdentityHash,
 			&leaf.LeafValue,
 			&leaf.LeafIndex,
-			&leaf.ExtraData); err != nil {
+			&leaf.ExtraData,
+			&codec); err != nil {
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if leaf.ExtraData, err = codec.decode(leaf.ExtraData); err != nil {
+			return nil, fmt.Errorf("failed to decode extra data: %v", err)
+		}
 		ret = append(ret, leaf)
 	}
 
@@ -531,6 +817,59 @@ func (t *logTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, or
 	return t.getLeavesByHashInternal(ctx, leafHashes, tmpl, "merkle")
 }
 
+// dedupGeneration looks up the leaf identity hash id in the dedup index of a tree with a
+// DedupWindow, for a leaf queued at queueNanos. If the latest leaf with the identity hash
+// is still within the window at now, dup is true. Otherwise the index is updated for the
+// new leaf, and the generation it must be stored at is returned.
+func (t *logTreeTX) dedupGeneration(ctx context.Context, id []byte, queueNanos int64, now time.Time) (generation int64, dup bool, err error) {
+	var queuedNanos int64
+	err = t.tx.QueryRowContext(ctx, selectDedupEntrySQL, t.treeID, id).Scan(&generation, &queuedNanos)
+	switch {
+	case err == sql.ErrNoRows:
+		// The identity hash is new, or its entry was pruned as it was outside the window.
+		if err := t.tx.QueryRowContext(ctx, selectNextGenerationSQL, t.treeID, id).Scan(&generation); err != nil {
+			return 0, false, err
+		}
+		_, err := t.tx.ExecContext(ctx, insertDedupEntrySQL, t.treeID, id, generation, queueNanos)
+		if isDuplicateErr(err) {
+			// Queued concurrently, so it's certainly within the window.
+			return 0, true, nil
+		}
+		return generation, false, err
+	case err != nil:
+		return 0, false, err
+	}
+
+	inWindow, err := t.inDedupWindow(ctx, id, generation, queuedNanos, now)
+	if err != nil || inWindow {
+		return 0, inWindow, err
+	}
+	generation++
+	if _, err := t.tx.ExecContext(ctx, updateDedupEntrySQL, generation, queueNanos, t.treeID, id); err != nil {
+		return 0, false, err
+	}
+	return generation, false, nil
+}
+
+// inDedupWindow returns true if the leaf with identity hash id at generation, queued at
+// queueNanos, is within the tree's DedupWindow at now.
+func (t *logTreeTX) inDedupWindow(ctx context.Context, id []byte, generation, queueNanos int64, now time.Time) (bool, error) {
+	var index int64
+	err := t.tx.QueryRowContext(ctx, selectGenerationSequenceNumberSQL, t.treeID, id, generation).Scan(&index)
+	switch {
+	case err == sql.ErrNoRows:
+		// Leaves that haven't been sequenced yet are always within the window.
+		return true, nil
+	case err != nil:
+		return false, err
+	}
+	minIndex, minQueueNanos, _, err := storage.DedupWindowLimits(t.dedupWindow, t.root.TreeSize, now)
+	if err != nil {
+		return false, err
+	}
+	return storage.InDedupWindow(index, queueNanos, minIndex, minQueueNanos), nil
+}
+
 // getLeafDataByIdentityHash retrieves leaf data by LeafIdentityHash, returned
 // as a slice of LogLeaf objects for convenience.  However, note that the
 // returned LogLeaf objects will not have a valid MerkleLeafHash or LeafIndex.
@@ -546,38 +885,81 @@ func (t *logTreeTX) LatestSignedLogRoot(ctx context.Context) (trillian.SignedLog
 	return t.root, nil
 }
 
+func (t *logTreeTX) SignedLogRootAtTime(ctx context.Context, timestampNanos int64) (trillian.SignedLogRoot, error) {
+	return t.fetchRoot(ctx, selectSignedLogRootAtTimeSQL, t.treeID, timestampNanos)
+}
+
+func (t *logTreeTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (trillian.SignedLogRoot, error) {
+	return t.fetchRoot(ctx, selectSignedLogRootAtSizeSQL, t.treeID, treeSize)
+}
+
 // fetchLatestRoot reads the latest SignedLogRoot from the DB and returns it.
 func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (trillian.SignedLogRoot, error) {
-	var timestamp, treeSize, treeRevision int64
-	var rootHash, rootSignatureBytes []byte
-	var rootSignature spb.DigitallySigned
+	return t.fetchRoot(ctx, selectLatestSignedLogRootSQL, t.treeID)
+}
 
-	err := t.tx.QueryRowContext(
-		ctx, selectLatestSignedLogRootSQL, t.treeID).Scan(
-		&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes)
+// fetchRoot reads the SignedLogRoot selected by query from the DB and returns it, or an
+// empty root if there's no such root.
+func (t *logTreeTX) fetchRoot(ctx context.Context, query string, args ...interface{}) (trillian.SignedLogRoot, error) {
+	root, err := t.readRoot(t.tx.QueryRowContext(ctx, query, args...))
 
 	// It's possible there are no roots for this tree yet
 	if err == sql.ErrNoRows {
 		return trillian.SignedLogRoot{}, nil
 	}
+	return root, err
+}
+
+// readRoot scans a SignedLogRoot from a row of one of the TreeHead selects.
+func (t *logTreeTX) readRoot(r row) (trillian.SignedLogRoot, error) {
+	var timestamp, treeSize, treeRevision int64
+	var rootHash, rootSignatureBytes, rootMetadata, additionalSignatures []byte
+	var rootSignature spb.DigitallySigned
 
-	err = proto.Unmarshal(rootSignatureBytes, &rootSignature)
+	if err := r.Scan(&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes, &rootMetadata, &additionalSignatures); err != nil {
+		return trillian.SignedLogRoot{}, err
+	}
 
-	if err != nil {
+	if err := proto.Unmarshal(rootSignatureBytes, &rootSignature); err != nil {
 		glog.Warningf("Failed to unmarshall root signature: %v", err)
 		return trillian.SignedLogRoot{}, err
 	}
+	additional, err := unmarshalRootSignatures(additionalSignatures)
+	if err != nil {
+		glog.Warningf("Failed to unmarshal additional root signatures: %v", err)
+		return trillian.SignedLogRoot{}, err
+	}
 
 	return trillian.SignedLogRoot{
-		RootHash:       rootHash,
-		TimestampNanos: timestamp,
-		TreeRevision:   treeRevision,
-		Signature:      &rootSignature,
-		LogId:          t.treeID,
-		TreeSize:       treeSize,
+		RootHash:             rootHash,
+		TimestampNanos:       timestamp,
+		TreeRevision:         treeRevision,
+		Signature:            &rootSignature,
+		LogId:                t.treeID,
+		TreeSize:             treeSize,
+		Metadata:             rootMetadata,
+		AdditionalSignatures: additional,
 	}, nil
 }
 
+func (t *logTreeTX) SignedLogRoots(ctx context.Context, afterRevision int64, limit int) ([]trillian.SignedLogRoot, error) {
+	rows, err := t.tx.QueryContext(ctx, selectSignedLogRootsSQL, t.treeID, afterRevision, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roots []trillian.SignedLogRoot
+	for rows.Next() {
+		root, err := t.readRoot(rows)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, rows.Err()
+}
+
 func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root trillian.SignedLogRoot) error {
 	signatureBytes, err := proto.Marshal(root.Signature)
 
@@ -585,6 +967,11 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root trillian.Signed
 		glog.Warningf("Failed to marshal root signature: %v %v", root.Signature, err)
 		return err
 	}
+	additional, err := marshalRootSignatures(root.AdditionalSignatures)
+	if err != nil {
+		glog.Warningf("Failed to marshal additional root signatures: %v", err)
+		return err
+	}
 
 	res, err := t.tx.ExecContext(
 		ctx,
@@ -594,7 +981,9 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root trillian.Signed
 		root.TreeSize,
 		root.RootHash,
 		root.TreeRevision,
-		signatureBytes)
+		signatureBytes,
+		rootMetadata(root),
+		additional)
 	if err != nil {
 		glog.Warningf("Failed to store signed root: %s", err)
 	}
@@ -602,6 +991,256 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root trillian.Signed
 	return checkResultOkAndRowCountIs(res, err, 1)
 }
 
+// rootMetadata returns the metadata of root to store, nil (NULL) if it has none.
+func rootMetadata(root trillian.SignedLogRoot) []byte {
+	if len(root.Metadata) == 0 {
+		return nil
+	}
+	return root.Metadata
+}
+
+// marshalRootSignatures serializes the additional signatures of a root to store, nil (NULL)
+// if there are none.
+func marshalRootSignatures(sigs []*spb.DigitallySigned) ([]byte, error) {
+	if len(sigs) == 0 {
+		return nil, nil
+	}
+	return proto.Marshal(&storagepb.RootSignatures{Signatures: sigs})
+}
+
+// unmarshalRootSignatures is the inverse of marshalRootSignatures.
+func unmarshalRootSignatures(b []byte) ([]*spb.DigitallySigned, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var sigs storagepb.RootSignatures
+	if err := proto.Unmarshal(b, &sigs); err != nil {
+		return nil, err
+	}
+	return sigs.Signatures, nil
+}
+
+func (t *logTreeTX) PruneSignedLogRoots(ctx context.Context, keepCount, keepAfterNanos int64) (int64, error) {
+	if keepCount < 1 {
+		keepCount = 1 // The latest root is always kept.
+	}
+	var minRevision int64
+	err := t.tx.QueryRowContext(ctx, selectNthLatestTreeRevisionSQL, t.treeID, keepCount-1).Scan(&minRevision)
+	switch {
+	case err == sql.ErrNoRows:
+		// No more than keepCount roots, nothing to prune.
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	res, err := t.tx.ExecContext(ctx, deleteOldTreeHeadsSQL, t.treeID, minRevision, keepAfterNanos, t.treeID)
+	if err != nil {
+		glog.Warningf("Failed to prune signed roots: %s", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (t *logTreeTX) PruneDedupIndex(ctx context.Context, minIndex, minQueueNanos int64) (int64, error) {
+	res, err := t.tx.ExecContext(ctx, deleteDedupEntriesOutsideWindowSQL, t.treeID, minIndex, minQueueNanos)
+	if err != nil {
+		glog.Warningf("Failed to prune dedup index: %s", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (t *logTreeTX) LatestCosignedLogRoot(ctx context.Context) (trillian.SignedLogRoot, []*trillian.Cosignature, error) {
+	var timestamp, treeSize, treeRevision int64
+	var rootHash, rootSignatureBytes, rootMetadata, additionalSignatures []byte
+	err := t.tx.QueryRowContext(ctx, selectLatestCosignedLogRootSQL, t.treeID, t.treeID).Scan(
+		&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes, &rootMetadata, &additionalSignatures)
+	if err == sql.ErrNoRows {
+		// Nothing has been cosigned yet
+		return trillian.SignedLogRoot{}, nil, nil
+	} else if err != nil {
+		return trillian.SignedLogRoot{}, nil, err
+	}
+	var rootSignature spb.DigitallySigned
+	if err := proto.Unmarshal(rootSignatureBytes, &rootSignature); err != nil {
+		glog.Warningf("Failed to unmarshal root signature: %v", err)
+		return trillian.SignedLogRoot{}, nil, err
+	}
+	additional, err := unmarshalRootSignatures(additionalSignatures)
+	if err != nil {
+		glog.Warningf("Failed to unmarshal additional root signatures: %v", err)
+		return trillian.SignedLogRoot{}, nil, err
+	}
+	root := trillian.SignedLogRoot{
+		RootHash:             rootHash,
+		TimestampNanos:       timestamp,
+		TreeRevision:         treeRevision,
+		Signature:            &rootSignature,
+		LogId:                t.treeID,
+		TreeSize:             treeSize,
+		Metadata:             rootMetadata,
+		AdditionalSignatures: additional,
+	}
+
+	rows, err := t.tx.QueryContext(ctx, selectCosignaturesSQL, t.treeID, treeRevision)
+	if err != nil {
+		return trillian.SignedLogRoot{}, nil, err
+	}
+	defer rows.Close()
+	var cosigs []*trillian.Cosignature
+	for rows.Next() {
+		var witnessName string
+		var signatureBytes []byte
+		if err := rows.Scan(&witnessName, &signatureBytes); err != nil {
+			return trillian.SignedLogRoot{}, nil, err
+		}
+		var signature spb.DigitallySigned
+		if err := proto.Unmarshal(signatureBytes, &signature); err != nil {
+			glog.Warningf("Failed to unmarshal cosignature: %v", err)
+			return trillian.SignedLogRoot{}, nil, err
+		}
+		cosigs = append(cosigs, &trillian.Cosignature{WitnessName: witnessName, Signature: &signature})
+	}
+	if err := rows.Err(); err != nil {
+		return trillian.SignedLogRoot{}, nil, err
+	}
+	return root, cosigs, nil
+}
+
+func (t *logTreeTX) StoreCosignature(ctx context.Context, treeRevision int64, cosig *trillian.Cosignature) error {
+	signatureBytes, err := proto.Marshal(cosig.Signature)
+	if err != nil {
+		glog.Warningf("Failed to marshal cosignature: %v %v", cosig.Signature, err)
+		return err
+	}
+	if _, err := t.tx.ExecContext(ctx, insertCosignatureSQL, t.treeID, treeRevision, cosig.WitnessName, signatureBytes); err != nil {
+		glog.Warningf("Failed to store cosignature: %s", err)
+		return err
+	}
+	return nil
+}
+
+func (t *logTreeTX) GetTimestampToken(ctx context.Context, treeRevision int64) ([]byte, error) {
+	var token []byte
+	err := t.tx.QueryRowContext(ctx, selectTimestampTokenSQL, t.treeID, treeRevision).Scan(&token)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		glog.Warningf("Failed to select timestamp token: %s", err)
+		return nil, err
+	}
+	return token, nil
+}
+
+func (t *logTreeTX) LatestTimestampedRevision(ctx context.Context) (int64, error) {
+	var rev int64
+	if err := t.tx.QueryRowContext(ctx, selectLatestTimestampedRevisionSQL, t.treeID).Scan(&rev); err != nil {
+		glog.Warningf("Failed to select latest timestamped revision: %s", err)
+		return 0, err
+	}
+	return rev, nil
+}
+
+func (t *logTreeTX) StoreTimestampToken(ctx context.Context, treeRevision int64, token []byte) error {
+	if _, err := t.tx.ExecContext(ctx, insertTimestampTokenSQL, t.treeID, treeRevision, token); err != nil {
+		glog.Warningf("Failed to store timestamp token: %s", err)
+		return err
+	}
+	return nil
+}
+
+func (t *logTreeTX) GetDeadLetteredLeaves(ctx context.Context) ([]*trillian.DeadLetteredLeaf, error) {
+	rows, err := t.tx.QueryContext(ctx, selectDeadLetteredLeavesSQL, t.treeID)
+	if err != nil {
+		glog.Warningf("Failed to select dead-lettered leaves: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaves []*trillian.DeadLetteredLeaf
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		var queueTimestampNanos, deadLetterTimestampNanos int64
+		var reason string
+		var attempts int32
+		var codec Codec
+		if err := rows.Scan(&leaf.LeafIdentityHash, &leaf.MerkleLeafHash, &leaf.LeafValue, &leaf.ExtraData, &codec, &queueTimestampNanos, &reason, &attempts, &deadLetterTimestampNanos); err != nil {
+			glog.Warningf("Failed to scan dead-lettered leaf: %s", err)
+			return nil, err
+		}
+		if leaf.ExtraData, err = codec.decode(leaf.ExtraData); err != nil {
+			return nil, fmt.Errorf("failed to decode extra data: %v", err)
+		}
+		if leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, queueTimestampNanos)); err != nil {
+			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		deadLetterTime, err := ptypes.TimestampProto(time.Unix(0, deadLetterTimestampNanos))
+		if err != nil {
+			return nil, fmt.Errorf("got invalid dead-letter timestamp: %v", err)
+		}
+		leaves = append(leaves, &trillian.DeadLetteredLeaf{
+			Leaf:           leaf,
+			Reason:         reason,
+			Attempts:       attempts,
+			DeadLetterTime: deadLetterTime,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+func (t *logTreeTX) DeadLetterLeaves(ctx context.Context, leaves []*trillian.DeadLetteredLeaf) error {
+	// The leaves were removed from Unsequenced when they were dequeued, so they only
+	// need recording here.
+	for _, dl := range leaves {
+		deadLetterTime, err := ptypes.Timestamp(dl.DeadLetterTime)
+		if err != nil {
+			return fmt.Errorf("got invalid dead-letter timestamp: %v", err)
+		}
+		if _, err := t.tx.ExecContext(
+			ctx,
+			insertDeadLetteredLeafSQL,
+			t.treeID,
+			dl.Leaf.LeafIdentityHash,
+			dl.Leaf.MerkleLeafHash,
+			deadLetterTime.UnixNano(),
+			dl.Attempts,
+			dl.Reason); err != nil {
+			glog.Warningf("Failed to dead-letter leaf: %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *logTreeTX) RequeueDeadLetteredLeaves(ctx context.Context, leafIdentityHashes [][]byte, queueTimestamp time.Time) (int, error) {
+	requeued := 0
+	for _, leafIdentityHash := range leafIdentityHashes {
+		var merkleHash []byte
+		err := t.tx.QueryRowContext(ctx, selectDeadLetteredMerkleHashSQL, t.treeID, leafIdentityHash).Scan(&merkleHash)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			glog.Warningf("Failed to select dead-lettered leaf: %s", err)
+			return 0, err
+		}
+		if _, err := t.tx.ExecContext(ctx, insertUnsequencedEntrySQL, t.treeID, t.unsequencedBucket(leafIdentityHash), leafIdentityHash, merkleHash, queueTimestamp.UnixNano()); err != nil {
+			glog.Warningf("Failed to requeue dead-lettered leaf: %s", err)
+			return 0, err
+		}
+		result, err := t.tx.ExecContext(ctx, deleteDeadLetteredLeafSQL, t.treeID, leafIdentityHash)
+		if err := checkResultOkAndRowCountIs(result, err, 1); err != nil {
+			return 0, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
 func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf) error {
 	// TODO: In theory we can do this with CASE / WHEN in one SQL statement but it's more fiddly
 	// and can be implemented later if necessary
@@ -617,7 +1256,9 @@ func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillia
 			t.treeID,
 			leaf.LeafIdentityHash,
 			leaf.MerkleLeafHash,
-			leaf.LeafIndex)
+			leaf.LeafIndex,
+			t.treeID,
+			leaf.LeafIdentityHash)
 		if err != nil {
 			glog.Warningf("Failed to update sequenced leaves: %s", err)
 			return err
@@ -639,7 +1280,7 @@ func (t *logTreeTX) removeSequencedLeaves(ctx context.Context, leaves []*dequeue
 		return err
 	}
 	for _, dql := range leaves {
-		result, err := stx.ExecContext(ctx, t.treeID, dql.queueTimestampNanos, dql.leafIdentityHash)
+		result, err := stx.ExecContext(ctx, t.treeID, dql.bucket, dql.queueTimestampNanos, dql.leafIdentityHash)
 		err = checkResultOkAndRowCountIs(result, err, int64(1))
 		if err != nil {
 			return err
@@ -649,6 +1290,46 @@ func (t *logTreeTX) removeSequencedLeaves(ctx context.Context, leaves []*dequeue
 	return nil
 }
 
+func (t *logTreeTX) HasLeaves(ctx context.Context, leafIdentityHashes [][]byte, includeUnsequenced bool) ([]bool, error) {
+	present := make([]bool, len(leafIdentityHashes))
+	if len(leafIdentityHashes) == 0 {
+		return present, nil
+	}
+	tmpl, err := t.ls.getLeafIdentityHashesStmt(ctx, len(leafIdentityHashes), includeUnsequenced)
+	if err != nil {
+		return nil, err
+	}
+	stx := t.tx.StmtContext(ctx, tmpl)
+	var args []interface{}
+	for _, hash := range leafIdentityHashes {
+		args = append(args, interface{}([]byte(hash)))
+	}
+	args = append(args, interface{}(t.treeID))
+	rows, err := stx.QueryContext(ctx, args...)
+	if err != nil {
+		glog.Warningf("Query() identity hashes = %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]bool)
+	for rows.Next() {
+		var hash []byte
+		if err := rows.Scan(&hash); err != nil {
+			glog.Warningf("LogID: %d Scan() identity hash = %s", t.treeID, err)
+			return nil, err
+		}
+		found[string(hash)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, hash := range leafIdentityHashes {
+		present[i] = found[string(hash)]
+	}
+	return present, nil
+}
+
 func (t *logTreeTX) getLeavesByHashInternal(ctx context.Context, leafHashes [][]byte, tmpl *sql.Stmt, desc string) ([]*trillian.LogLeaf, error) {
 	stx := t.tx.StmtContext(ctx, tmpl)
 	var args []interface{}
@@ -669,10 +1350,14 @@ func (t *logTreeTX) getLeavesByHashInternal(ctx context.Context, leafHashes [][]
 	for rows.Next() {
 		leaf := &trillian.LogLeaf{}
 
-		if err := rows.Scan(&leaf.MerkleLeafHash, &leaf.LeafIdentityHash, &leaf.LeafValue, &leaf.LeafIndex, &leaf.ExtraData); err != nil {
+		var codec Codec
+		if err := rows.Scan(&leaf.MerkleLeafHash, &leaf.LeafIdentityHash, &leaf.LeafValue, &leaf.LeafIndex, &leaf.ExtraData, &codec); err != nil {
 			glog.Warningf("LogID: %d Scan() %s = %s", t.treeID, desc, err)
 			return nil, err
 		}
+		if leaf.ExtraData, err = codec.decode(leaf.ExtraData); err != nil {
+			return nil, fmt.Errorf("LogID: %d failed to decode extra data: %v", t.treeID, err)
+		}
 
 		if got, want := len(leaf.MerkleLeafHash), t.hashSizeBytes; got != want {
 			return nil, fmt.Errorf("LogID: %d Scanned leaf %s does not have hash length %d, got %d", t.treeID, desc, want, got)
@@ -689,6 +1374,12 @@ func (t *logTreeTX) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
 	return getActiveLogIDs(ctx, t.tx)
 }
 
+// GetActiveLogIDsWithPendingWork returns a list of the IDs of all configured logs
+// that have queued unsequenced leaves
+func (t *logTreeTX) GetActiveLogIDsWithPendingWork(ctx context.Context) ([]int64, error) {
+	return getActiveLogIDsWithPendingWork(ctx, t.tx)
+}
+
 // byLeafIdentityHash allows sorting of leaves by their identity hash, so DB
 // operations always happen in a consistent order.
 type byLeafIdentityHash []*trillian.LogLeaf
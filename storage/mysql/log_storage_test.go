@@ -20,19 +20,22 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	spb "github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/storage"
+	storageto "github.com/google/trillian/storage/testonly"
 	"github.com/kylelemons/godebug/pretty"
 )
 
-var allTables = []string{"Unsequenced", "TreeHead", "SequencedLeafData", "LeafData", "Subtree", "TreeControl", "Trees", "MapLeaf", "MapHead"}
+var allTables = []string{"Unsequenced", "TreeHead", "SequencedLeafData", "LeafData", "LeafIdentityDedup", "Subtree", "TreeControl", "Trees", "MapLeaf", "MapHead", "MapQueuedLeaf", "QuotaConfigs"}
 
 // Must be 32 bytes to match sha256 length if it was a real hash
 var dummyHash = []byte("hashxxxxhashxxxxhashxxxxhashxxxx")
@@ -311,6 +314,49 @@ func TestQueueDuplicateLeaf(t *testing.T) {
 	}
 }
 
+func TestQueueLeavesCompressedExtraData(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	gz := newLogStorage(DB, nil, DefaultIsolationLevels)
+	gz.extraDataCodec = GzipCompression
+	leaves := createTestLeaves(3, 0)
+
+	tx := beginLogTx(gz, logID, t)
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(ctx, leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	var codec Codec
+	if err := DB.QueryRowContext(ctx, "SELECT ExtraDataCodec FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?", logID, leaves[0].LeafIdentityHash).Scan(&codec); err != nil {
+		t.Fatalf("Failed to read ExtraDataCodec: %v", err)
+	}
+	if codec != GzipCompression {
+		t.Errorf("ExtraDataCodec=%v, want %v", codec, GzipCompression)
+	}
+
+	// Storage using a different codec must still read back the compressed rows.
+	tx2 := beginLogTx(NewLogStorage(DB, nil), logID, t)
+	defer tx2.Close()
+	existing, err := tx2.QueueLeaves(ctx, leaves, fakeQueueTime)
+	if err != nil {
+		t.Fatalf("Failed to queue duplicate leaves: %v", err)
+	}
+	commit(tx2, t)
+	for i, leaf := range existing {
+		if leaf == nil {
+			t.Errorf("QueueLeaves()[%d]=nil; want duplicate", i)
+			continue
+		}
+		if got, want := leaf.ExtraData, leaves[i].ExtraData; !bytes.Equal(got, want) {
+			t.Errorf("QueueLeaves()[%d].ExtraData=%q; want %q", i, got, want)
+		}
+	}
+}
+
 func TestQueueLeaves(t *testing.T) {
 	ctx := context.Background()
 
@@ -413,6 +459,85 @@ func TestDequeueLeaves(t *testing.T) {
 	}
 }
 
+func TestDeadLetterLeaves(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	{
+		tx := beginLogTx(s, logID, t)
+		defer tx.Close()
+		if _, err := tx.QueueLeaves(ctx, createTestLeaves(leavesToInsert, 20), fakeDequeueCutoffTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		commit(tx, t)
+	}
+
+	var dead *trillian.LogLeaf
+	{
+		// Dequeue the leaves and dead-letter one of them
+		tx2 := beginLogTx(s, logID, t)
+		defer tx2.Close()
+		leaves, err := tx2.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("Failed to dequeue leaves: %v", err)
+		}
+		dead = leaves[0]
+		dl := &trillian.DeadLetteredLeaf{Leaf: dead, Reason: "bad leaf", Attempts: 3, DeadLetterTime: ptypes.TimestampNow()}
+		if err := tx2.DeadLetterLeaves(ctx, []*trillian.DeadLetteredLeaf{dl}); err != nil {
+			t.Fatalf("Failed to dead-letter leaves: %v", err)
+		}
+		commit(tx2, t)
+	}
+
+	{
+		tx3 := beginLogTx(s, logID, t)
+		defer tx3.Close()
+		got, err := tx3.GetDeadLetteredLeaves(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get dead-lettered leaves: %v", err)
+		}
+		if len(got) != 1 || !bytes.Equal(got[0].Leaf.LeafIdentityHash, dead.LeafIdentityHash) || got[0].Reason != "bad leaf" || got[0].Attempts != 3 {
+			t.Fatalf("GetDeadLetteredLeaves() = %v, want leaf %x", got, dead.LeafIdentityHash)
+		}
+		if len(got[0].Leaf.LeafValue) == 0 {
+			t.Errorf("GetDeadLetteredLeaves() returned leaf without value")
+		}
+		// Requeueing ignores leaves that aren't dead-lettered
+		n, err := tx3.RequeueDeadLetteredLeaves(ctx, [][]byte{dead.LeafIdentityHash, []byte("not dead-lettered")}, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("Failed to requeue dead-lettered leaves: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("RequeueDeadLetteredLeaves() = %v, want 1", n)
+		}
+		commit(tx3, t)
+	}
+
+	{
+		// The requeued leaf is dequeued again, and no longer dead-lettered
+		tx4 := beginLogTx(s, logID, t)
+		defer tx4.Close()
+		leaves, err := tx4.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("Failed to dequeue leaves: %v", err)
+		}
+		if len(leaves) != 1 || !bytes.Equal(leaves[0].LeafIdentityHash, dead.LeafIdentityHash) {
+			t.Errorf("Dequeued %v, want requeued leaf %x", leaves, dead.LeafIdentityHash)
+		}
+		got, err := tx4.GetDeadLetteredLeaves(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get dead-lettered leaves: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetDeadLetteredLeaves() = %v, want none", got)
+		}
+		commit(tx4, t)
+	}
+}
+
 func TestDequeueLeavesTwoBatches(t *testing.T) {
 	ctx := context.Background()
 
@@ -592,6 +717,85 @@ func TestDequeueLeavesTimeOrdering(t *testing.T) {
 	}
 }
 
+func TestDequeueLeavesBuckets(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	tree := proto.Clone(storageto.LogTree).(*trillian.Tree)
+	tree.UnsequencedBuckets = 4
+	tree, err := createTree(DB, tree)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	logID := tree.TreeId
+	s := newLogStorage(DB, nil, DefaultIsolationLevels)
+	s.bucketDepths = newBucketDepthSampler(DB, time.Hour)
+	defer s.bucketDepths.stop()
+
+	// Leaves are queued in the opposite order of their timestamps, and must be dequeued in
+	// timestamp order whichever bucket they're in.
+	leaves := createTestLeaves(20, 0)
+	for i, leaf := range leaves {
+		ts, err := ptypes.TimestampProto(fakeQueueTime.Add(-time.Duration(i) * time.Second))
+		if err != nil {
+			t.Fatalf("TimestampProto() = (_, %v)", err)
+		}
+		leaf.QueueTimestamp = ts
+	}
+	tx := beginLogTx(s, logID, t)
+	if _, err := tx.QueueLeaves(ctx, leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	var buckets int
+	if err := DB.QueryRow("SELECT COUNT(DISTINCT Bucket) FROM Unsequenced WHERE TreeId=?", logID).Scan(&buckets); err != nil {
+		t.Fatalf("Failed to count buckets: %v", err)
+	}
+	if buckets < 2 {
+		t.Errorf("Leaves queued in %d buckets, want several", buckets)
+	}
+	depths, err := readBucketDepths(ctx, DB, logID, 4)
+	if err != nil {
+		t.Fatalf("readBucketDepths() = (_, %v), want (_, nil)", err)
+	}
+	var total int64
+	for _, depth := range depths {
+		total += depth
+	}
+	if got, want := total, int64(len(leaves)); got != want {
+		t.Errorf("readBucketDepths() = %v, total %d, want total %d", depths, got, want)
+	}
+
+	var dequeued []*trillian.LogLeaf
+	for {
+		tx := beginLogTx(s, logID, t)
+		batch, err := tx.DequeueLeaves(ctx, 3, fakeQueueTime)
+		if err != nil {
+			t.Fatalf("DequeueLeaves() = (_, %v), want (_, nil)", err)
+		}
+		commit(tx, t)
+		if len(batch) == 0 {
+			break
+		}
+		dequeued = append(dequeued, batch...)
+	}
+	if got, want := len(dequeued), len(leaves); got != want {
+		t.Fatalf("Dequeued %d leaves, want %d", got, want)
+	}
+	s.bucketDepths.mu.Lock()
+	sampled := s.bucketDepths.trees[logID]
+	s.bucketDepths.mu.Unlock()
+	if got, want := sampled, 4; got != want {
+		t.Errorf("Sampled buckets after dequeue = %d, want %d", got, want)
+	}
+	for i, leaf := range dequeued {
+		if want := leaves[len(leaves)-1-i]; !bytes.Equal(leaf.LeafIdentityHash, want.LeafIdentityHash) {
+			t.Errorf("Dequeued leaf %d = %x, want %x", i, leaf.LeafIdentityHash, want.LeafIdentityHash)
+		}
+	}
+}
+
 func TestGetLeavesByHashNotPresent(t *testing.T) {
 	ctx := context.Background()
 
@@ -717,6 +921,163 @@ func TestGetLeafDataByIdentityHash(t *testing.T) {
 	}
 }
 
+func TestHasLeaves(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	createFakeLeaf(ctx, DB, logID, dummyRawHash, dummyHash, []byte("some data"), someExtraData, sequenceNumber, t)
+	queued := createTestLeaves(1, 20)[0]
+	tx := beginLogTx(s, logID, t)
+	if _, err := tx.QueueLeaves(ctx, []*trillian.LogLeaf{queued}, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	hashes := [][]byte{dummyRawHash, queued.LeafIdentityHash, {0x01, 0x02}, dummyRawHash}
+	tests := []struct {
+		includeUnsequenced bool
+		want               []bool
+	}{
+		{includeUnsequenced: false, want: []bool{true, false, false, true}},
+		{includeUnsequenced: true, want: []bool{true, true, false, true}},
+	}
+	for _, test := range tests {
+		func() {
+			tx := beginLogTx(s, logID, t)
+			defer tx.Close()
+
+			got, err := tx.HasLeaves(ctx, hashes, test.includeUnsequenced)
+			if err != nil {
+				t.Errorf("HasLeaves(_, %v) = (_, %v), want (_, nil)", test.includeUnsequenced, err)
+				return
+			}
+			commit(tx, t)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("HasLeaves(_, %v) = %v, want %v", test.includeUnsequenced, got, test.want)
+			}
+		}()
+	}
+}
+
+func TestCountLeaves(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	// The sequenced leaf has no queue timestamp.
+	createFakeLeaf(ctx, DB, logID, dummyRawHash, dummyHash, []byte("some data"), someExtraData, sequenceNumber, t)
+	queued := createTestLeaves(3, 20)
+	later := fakeQueueTime.Add(time.Hour)
+	tx := beginLogTx(s, logID, t)
+	if _, err := tx.QueueLeaves(ctx, queued[:2], fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	if _, err := tx.QueueLeaves(ctx, queued[2:], later); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	commit(tx, t)
+
+	tests := []struct {
+		desc   string
+		filter storage.LeafFilter
+		want   int64
+	}{
+		{desc: "all", want: 4},
+		{desc: "sequenced", filter: storage.LeafFilter{SequencedOnly: true}, want: 1},
+		{desc: "unsequenced", filter: storage.LeafFilter{UnsequencedOnly: true}, want: 3},
+		{desc: "unsequencedRange", filter: storage.LeafFilter{MinQueueTimestampNanos: later.UnixNano(), UnsequencedOnly: true}, want: 1},
+		{desc: "min", filter: storage.LeafFilter{MinQueueTimestampNanos: fakeQueueTime.UnixNano()}, want: 3},
+		{desc: "max", filter: storage.LeafFilter{MaxQueueTimestampNanos: later.UnixNano()}, want: 3},
+		{desc: "range", filter: storage.LeafFilter{MinQueueTimestampNanos: fakeQueueTime.UnixNano(), MaxQueueTimestampNanos: later.UnixNano()}, want: 2},
+		{desc: "sequencedRange", filter: storage.LeafFilter{MinQueueTimestampNanos: fakeQueueTime.UnixNano(), SequencedOnly: true}, want: 0},
+		{desc: "prefix", filter: storage.LeafFilter{LeafIdentityHashPrefix: dummyRawHash[:4]}, want: 1},
+		{desc: "fullHash", filter: storage.LeafFilter{LeafIdentityHashPrefix: queued[1].LeafIdentityHash}, want: 1},
+		{desc: "noMatch", filter: storage.LeafFilter{LeafIdentityHashPrefix: []byte("yyyy")}, want: 0},
+	}
+	for _, test := range tests {
+		func() {
+			tx := beginLogTx(s, logID, t)
+			defer tx.Close()
+
+			got, err := tx.CountLeaves(ctx, test.filter)
+			if err != nil {
+				t.Errorf("%v: CountLeaves() = (_, %v), want (_, nil)", test.desc, err)
+				return
+			}
+			commit(tx, t)
+			if got != test.want {
+				t.Errorf("%v: CountLeaves() = %v, want %v", test.desc, got, test.want)
+			}
+		}()
+	}
+}
+
+func TestGetLatestLeafByIdentityHashPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	createFakeLeaf(ctx, DB, logID, []byte{0x01, 0x02, 0x03}, []byte("hash1"), []byte("data1"), someExtraData, 1, t)
+	createFakeLeaf(ctx, DB, logID, []byte{0x01, 0x02, 0x04}, []byte("hash2"), []byte("data2"), someExtraData, 2, t)
+	createFakeLeaf(ctx, DB, logID, []byte{0x01, 0x03, 0x01}, []byte("hash3"), []byte("data3"), someExtraData, 3, t)
+
+	tests := []struct {
+		desc      string
+		prefix    []byte
+		wantIndex int64 // -1 means no leaf
+	}{
+		{desc: "latestOfTwo", prefix: []byte{0x01, 0x02}, wantIndex: 2},
+		{desc: "single", prefix: []byte{0x01, 0x03}, wantIndex: 3},
+		{desc: "all", prefix: []byte{0x01}, wantIndex: 3},
+		{desc: "fullHash", prefix: []byte{0x01, 0x02, 0x03}, wantIndex: 1},
+		{desc: "noMatch", prefix: []byte{0x02}, wantIndex: -1},
+	}
+	for _, test := range tests {
+		func() {
+			tx := beginLogTx(s, logID, t)
+			defer tx.Close()
+
+			leaf, err := tx.GetLatestLeafByIdentityHashPrefix(ctx, test.prefix)
+			if err != nil {
+				t.Errorf("%v: GetLatestLeafByIdentityHashPrefix() = (_, %v), want (_, nil)", test.desc, err)
+				return
+			}
+			commit(tx, t)
+			switch {
+			case test.wantIndex < 0 && leaf != nil:
+				t.Errorf("%v: GetLatestLeafByIdentityHashPrefix() = %v, want nil", test.desc, leaf)
+			case test.wantIndex >= 0 && leaf == nil:
+				t.Errorf("%v: GetLatestLeafByIdentityHashPrefix() = nil, want leaf %v", test.desc, test.wantIndex)
+			case leaf != nil && leaf.LeafIndex != test.wantIndex:
+				t.Errorf("%v: GetLatestLeafByIdentityHashPrefix().LeafIndex = %v, want %v", test.desc, leaf.LeafIndex, test.wantIndex)
+			}
+		}()
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix, want []byte
+	}{
+		{prefix: []byte{0x00}, want: []byte{0x01}},
+		{prefix: []byte{0x12, 0x34}, want: []byte{0x12, 0x35}},
+		{prefix: []byte{0x12, 0xff}, want: []byte{0x13}},
+		{prefix: []byte{0xff, 0xff}, want: nil},
+	}
+	for _, test := range tests {
+		if got := prefixEnd(test.prefix); !bytes.Equal(got, test.want) {
+			t.Errorf("prefixEnd(%x) = %x, want %x", test.prefix, got, test.want)
+		}
+	}
+}
+
 func TestGetLeavesByIndex(t *testing.T) {
 	ctx := context.Background()
 
@@ -799,6 +1160,236 @@ func TestLatestSignedLogRoot(t *testing.T) {
 	}
 }
 
+func TestSignedLogRootAtTime(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	var roots []trillian.SignedLogRoot
+	for i := int64(1); i <= 3; i++ {
+		root := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: 1000 * i,
+			TreeSize:       16 * i,
+			TreeRevision:   i,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("Failed to store signed root: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	commit(tx, t)
+
+	for _, test := range []struct {
+		ts   int64
+		want *trillian.SignedLogRoot
+	}{
+		{ts: 999, want: &trillian.SignedLogRoot{}},
+		{ts: 1000, want: &roots[0]},
+		{ts: 2500, want: &roots[1]},
+		{ts: 5000, want: &roots[2]},
+	} {
+		tx := beginLogTx(s, logID, t)
+		got, err := tx.SignedLogRootAtTime(ctx, test.ts)
+		if err != nil {
+			t.Fatalf("SignedLogRootAtTime(%v)=_,%v, want: nil", test.ts, err)
+		}
+		if !proto.Equal(&got, test.want) {
+			t.Errorf("SignedLogRootAtTime(%v)=<%v>, want: <%v>", test.ts, got, test.want)
+		}
+		commit(tx, t)
+	}
+}
+
+func TestSignedLogRootAtSize(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	// The last two roots have the same size, as when a log is resigned without new leaves.
+	var roots []trillian.SignedLogRoot
+	for i, size := range []int64{16, 32, 32} {
+		root := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: 1000 * int64(i+1),
+			TreeSize:       size,
+			TreeRevision:   int64(i + 1),
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("Failed to store signed root: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	commit(tx, t)
+
+	for _, test := range []struct {
+		size int64
+		want *trillian.SignedLogRoot
+	}{
+		{size: 8, want: &trillian.SignedLogRoot{}},
+		{size: 16, want: &roots[0]},
+		{size: 32, want: &roots[2]},
+		{size: 48, want: &trillian.SignedLogRoot{}},
+	} {
+		tx := beginLogTx(s, logID, t)
+		got, err := tx.SignedLogRootAtSize(ctx, test.size)
+		if err != nil {
+			t.Fatalf("SignedLogRootAtSize(%v)=_,%v, want: nil", test.size, err)
+		}
+		if !proto.Equal(&got, test.want) {
+			t.Errorf("SignedLogRootAtSize(%v)=<%v>, want: <%v>", test.size, got, test.want)
+		}
+		commit(tx, t)
+	}
+}
+
+func TestSignedLogRoots(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	var roots []trillian.SignedLogRoot
+	for i := int64(0); i < 5; i++ {
+		root := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: 1000 * (i + 1),
+			TreeSize:       16 * i,
+			TreeRevision:   i,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("Failed to store signed root: %v", err)
+		}
+		roots = append(roots, root)
+	}
+	commit(tx, t)
+
+	for _, test := range []struct {
+		afterRevision int64
+		limit         int
+		want          []trillian.SignedLogRoot
+	}{
+		{afterRevision: -1, limit: 10, want: roots},
+		{afterRevision: -1, limit: 2, want: roots[:2]},
+		{afterRevision: 1, limit: 2, want: roots[2:4]},
+		{afterRevision: 3, limit: 10, want: roots[4:]},
+		{afterRevision: 4, limit: 10},
+	} {
+		tx := beginLogTx(s, logID, t)
+		got, err := tx.SignedLogRoots(ctx, test.afterRevision, test.limit)
+		if err != nil {
+			t.Fatalf("SignedLogRoots(%v, %v)=_,%v, want: nil", test.afterRevision, test.limit, err)
+		}
+		if len(got) != len(test.want) {
+			t.Fatalf("SignedLogRoots(%v, %v) returned %v roots, want: %v", test.afterRevision, test.limit, len(got), len(test.want))
+		}
+		for i := range got {
+			if !proto.Equal(&got[i], &test.want[i]) {
+				t.Errorf("SignedLogRoots(%v, %v)[%v]=<%v>, want: <%v>", test.afterRevision, test.limit, i, got[i], test.want[i])
+			}
+		}
+		commit(tx, t)
+	}
+}
+
+func TestLatestSignedLogRootMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	root := trillian.SignedLogRoot{
+		LogId:          logID,
+		TimestampNanos: 98765,
+		TreeSize:       16,
+		TreeRevision:   5,
+		RootHash:       []byte(dummyHash),
+		Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		Metadata:       []byte("epoch 3"),
+	}
+	if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+		t.Fatalf("Failed to store signed root: %v", err)
+	}
+	commit(tx, t)
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	root2, err := tx2.LatestSignedLogRoot(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read back new log root: %v", err)
+	}
+	if !proto.Equal(&root, &root2) {
+		t.Fatalf("Root round trip failed: <%v> and: <%v>", root, root2)
+	}
+	commit(tx2, t)
+}
+
+func TestLatestSignedLogRootAdditionalSignatures(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+
+	root := trillian.SignedLogRoot{
+		LogId:          logID,
+		TimestampNanos: 98765,
+		TreeSize:       16,
+		TreeRevision:   5,
+		RootHash:       []byte(dummyHash),
+		Signature: &spb.DigitallySigned{
+			SignatureAlgorithm: spb.DigitallySigned_ECDSA,
+			Signature:          []byte("notempty"),
+		},
+		AdditionalSignatures: []*spb.DigitallySigned{{
+			SignatureAlgorithm: spb.DigitallySigned_RSA,
+			Signature:          []byte("alsonotempty"),
+		}},
+	}
+	if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+		t.Fatalf("Failed to store signed root: %v", err)
+	}
+	commit(tx, t)
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	root2, err := tx2.LatestSignedLogRoot(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read back new log root: %v", err)
+	}
+	if !proto.Equal(&root, &root2) {
+		t.Fatalf("Root round trip failed: <%v> and: <%v>", root, root2)
+	}
+	commit(tx2, t)
+}
+
 func TestDuplicateSignedLogRoot(t *testing.T) {
 	ctx := context.Background()
 
@@ -873,6 +1464,241 @@ func TestLogRootUpdate(t *testing.T) {
 	commit(tx2, t)
 }
 
+func TestTimestampTokens(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	if rev, err := tx.LatestTimestampedRevision(ctx); err != nil || rev != -1 {
+		t.Errorf("LatestTimestampedRevision() = %v, %v, want -1, nil", rev, err)
+	}
+	for rev := int64(1); rev <= 3; rev++ {
+		root := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: rev * 1000,
+			TreeSize:       rev,
+			TreeRevision:   rev,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("Failed to store signed root: %v", err)
+		}
+	}
+	for _, rev := range []int64{1, 2} {
+		if err := tx.StoreTimestampToken(ctx, rev, []byte("old")); err != nil {
+			t.Fatalf("StoreTimestampToken(%v) returned err = %v", rev, err)
+		}
+	}
+	// Storing a token again replaces it.
+	if err := tx.StoreTimestampToken(ctx, 2, []byte("token2")); err != nil {
+		t.Fatalf("StoreTimestampToken(2) returned err = %v", err)
+	}
+	commit(tx, t)
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	if rev, err := tx2.LatestTimestampedRevision(ctx); err != nil || rev != 2 {
+		t.Errorf("LatestTimestampedRevision() = %v, %v, want 2, nil", rev, err)
+	}
+	for _, test := range []struct {
+		rev  int64
+		want []byte
+	}{
+		{rev: 1, want: []byte("old")},
+		{rev: 2, want: []byte("token2")},
+		{rev: 3},
+	} {
+		got, err := tx2.GetTimestampToken(ctx, test.rev)
+		if err != nil {
+			t.Errorf("GetTimestampToken(%v) returned err = %v", test.rev, err)
+		} else if !bytes.Equal(got, test.want) {
+			t.Errorf("GetTimestampToken(%v) = %q, want %q", test.rev, got, test.want)
+		}
+	}
+	commit(tx2, t)
+}
+
+func TestPruneSignedLogRoots(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID := createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	for rev := int64(1); rev <= 5; rev++ {
+		root := trillian.SignedLogRoot{
+			LogId:          logID,
+			TimestampNanos: rev * 1000,
+			TreeSize:       rev,
+			TreeRevision:   rev,
+			RootHash:       []byte(dummyHash),
+			Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+		}
+		if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+			t.Fatalf("Failed to store signed root: %v", err)
+		}
+	}
+	cosig := &trillian.Cosignature{WitnessName: "witness", Signature: &spb.DigitallySigned{Signature: []byte("cosig")}}
+	if err := tx.StoreCosignature(ctx, 2, cosig); err != nil {
+		t.Fatalf("Failed to store cosignature: %v", err)
+	}
+	commit(tx, t)
+
+	// Revisions 4 and 5 are kept by count, 2 because it's cosigned.
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	pruned, err := tx2.PruneSignedLogRoots(ctx, 2, 4000)
+	if err != nil {
+		t.Fatalf("PruneSignedLogRoots() returned err = %v", err)
+	}
+	commit(tx2, t)
+	if got, want := pruned, int64(2); got != want {
+		t.Errorf("PruneSignedLogRoots() = %v, want %v", got, want)
+	}
+
+	rows, err := DB.QueryContext(ctx, "SELECT TreeRevision FROM TreeHead WHERE TreeId=? ORDER BY TreeRevision", logID)
+	if err != nil {
+		t.Fatalf("Failed to read signed roots: %v", err)
+	}
+	defer rows.Close()
+	var revs []int64
+	for rows.Next() {
+		var rev int64
+		if err := rows.Scan(&rev); err != nil {
+			t.Fatalf("Failed to scan revision: %v", err)
+		}
+		revs = append(revs, rev)
+	}
+	if want := []int64{2, 4, 5}; !reflect.DeepEqual(revs, want) {
+		t.Errorf("remaining revisions = %v, want %v", revs, want)
+	}
+}
+
+func TestQueueLeavesDedupWindow(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	tree := proto.Clone(storageto.LogTree).(*trillian.Tree)
+	tree.DedupWindow = &trillian.DedupWindow{MaxLeaves: 2}
+	tree, err := createTree(DB, tree)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	logID := tree.TreeId
+	s := NewLogStorage(DB, nil)
+	leaf := createTestLeaves(1, 0)[0]
+
+	// Each step queues the same leaf again, optionally after sequencing it at index 0 and
+	// growing the tree to treeSize.
+	tests := []struct {
+		desc     string
+		sequence bool
+		treeSize int64
+		wantDup  bool
+	}{
+		{desc: "new"},
+		{desc: "unsequenced", wantDup: true},
+		{desc: "sequenced", sequence: true, treeSize: 1, wantDup: true},
+		{desc: "lastInWindow", treeSize: 2, wantDup: true},
+		{desc: "outsideWindow", treeSize: 3},
+		{desc: "requeued", wantDup: true},
+	}
+	for i, test := range tests {
+		if test.sequence || test.treeSize > 0 {
+			tx := beginLogTx(s, logID, t)
+			if test.sequence {
+				seqLeaf := *leaf
+				seqLeaf.LeafIndex = 0
+				if err := tx.UpdateSequencedLeaves(ctx, []*trillian.LogLeaf{&seqLeaf}); err != nil {
+					t.Fatalf("%v: UpdateSequencedLeaves() returned err = %v", test.desc, err)
+				}
+			}
+			root := trillian.SignedLogRoot{
+				LogId:          logID,
+				TimestampNanos: int64(i + 1),
+				TreeSize:       test.treeSize,
+				TreeRevision:   int64(i + 1),
+				RootHash:       []byte(dummyHash),
+				Signature:      &spb.DigitallySigned{Signature: []byte("notempty")},
+			}
+			if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+				t.Fatalf("%v: StoreSignedLogRoot() returned err = %v", test.desc, err)
+			}
+			commit(tx, t)
+		}
+
+		tx := beginLogTx(s, logID, t)
+		existing, err := tx.QueueLeaves(ctx, []*trillian.LogLeaf{leaf}, fakeQueueTime.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("%v: QueueLeaves() returned err = %v", test.desc, err)
+		}
+		commit(tx, t)
+		if got := existing[0] != nil; got != test.wantDup {
+			t.Errorf("%v: QueueLeaves() dup = %v, want %v", test.desc, got, test.wantDup)
+		}
+	}
+
+	var generations int
+	if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM LeafData WHERE TreeId=?", logID).Scan(&generations); err != nil {
+		t.Fatalf("Failed to count leaf data: %v", err)
+	}
+	if got, want := generations, 2; got != want {
+		t.Errorf("stored %v generations of the leaf, want %v", got, want)
+	}
+}
+
+func TestPruneDedupIndex(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	tree := proto.Clone(storageto.LogTree).(*trillian.Tree)
+	tree.DedupWindow = &trillian.DedupWindow{MaxLeaves: 2}
+	tree, err := createTree(DB, tree)
+	if err != nil {
+		t.Fatalf("Failed to create tree: %v", err)
+	}
+	logID := tree.TreeId
+	s := NewLogStorage(DB, nil)
+
+	// Sequence 3 of 5 queued leaves, the remaining 2 stay in the window.
+	leaves := createTestLeaves(5, 0)
+	tx := beginLogTx(s, logID, t)
+	defer tx.Close()
+	if _, err := tx.QueueLeaves(ctx, leaves, fakeQueueTime); err != nil {
+		t.Fatalf("Failed to queue leaves: %v", err)
+	}
+	if err := tx.UpdateSequencedLeaves(ctx, leaves[:3]); err != nil {
+		t.Fatalf("Failed to update sequenced leaves: %v", err)
+	}
+	commit(tx, t)
+
+	tx2 := beginLogTx(s, logID, t)
+	defer tx2.Close()
+	pruned, err := tx2.PruneDedupIndex(ctx, 2, fakeQueueTime.UnixNano())
+	if err != nil {
+		t.Fatalf("PruneDedupIndex() returned err = %v", err)
+	}
+	commit(tx2, t)
+	if got, want := pruned, int64(2); got != want {
+		t.Errorf("PruneDedupIndex() = %v, want %v", got, want)
+	}
+
+	var entries int
+	if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM LeafIdentityDedup WHERE TreeId=?", logID).Scan(&entries); err != nil {
+		t.Fatalf("Failed to count dedup entries: %v", err)
+	}
+	if got, want := entries, 3; got != want {
+		t.Errorf("dedup entries = %v, want %v", got, want)
+	}
+}
+
 // getActiveLogIDsFn creates a TX, calls the appropriate GetActiveLogIDs* function, commits the TX
 // and returns the results.
 type getActiveLogIDsFn func(context.Context, storage.LogStorage, int64) ([]int64, error)
@@ -963,6 +1789,77 @@ func TestGetActiveLogIDs(t *testing.T) {
 	}
 }
 
+func TestGetActiveLogIDsWithPendingWork(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	logID1 := createLogForTests(DB)
+	logID2 := createLogForTests(DB)
+	createLogForTests(DB)
+	s := NewLogStorage(DB, nil)
+
+	for i, logID := range []int64{logID1, logID2} {
+		tx := beginLogTx(s, logID, t)
+		if _, err := tx.QueueLeaves(ctx, createTestLeaves(leavesToInsert, int64(i)*leavesToInsert), fakeQueueTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		commit(tx, t)
+	}
+	// Sequence everything queued for logID2, leaving it clean again.
+	{
+		tx := beginLogTx(s, logID2, t)
+		leaves, err := tx.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime)
+		if err != nil {
+			t.Fatalf("Failed to dequeue leaves: %v", err)
+		}
+		for i, leaf := range leaves {
+			leaf.LeafIndex = int64(i)
+		}
+		if err := tx.UpdateSequencedLeaves(ctx, leaves); err != nil {
+			t.Fatalf("Failed to update sequenced leaves: %v", err)
+		}
+		commit(tx, t)
+	}
+
+	getPendingBegin := func(ctx context.Context, s storage.LogStorage, logID int64) ([]int64, error) {
+		tx, err := s.BeginForTree(ctx, logID)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Close()
+		ids, err := tx.GetActiveLogIDsWithPendingWork(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+	getPendingSnapshot := func(ctx context.Context, s storage.LogStorage, logID int64) ([]int64, error) {
+		tx, err := s.Snapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Close()
+		ids, err := tx.GetActiveLogIDsWithPendingWork(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
+	for _, test := range []getActiveIDsTest{
+		{name: "getPendingBegin", fn: getPendingBegin},
+		{name: "getPendingSnapshot", fn: getPendingSnapshot},
+	} {
+		runTestGetActiveLogIDsInternal(ctx, t, test, logID1, []int64{logID1})
+	}
+}
+
 func TestGetActiveLogIDsEmpty(t *testing.T) {
 	ctx := context.Background()
 
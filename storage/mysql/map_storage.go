@@ -17,6 +17,8 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
@@ -36,6 +38,10 @@ const (
 		 ORDER BY MapHeadTimestamp DESC LIMIT 1`
 	selectGetSignedMapRootSQL = `SELECT MapHeadTimestamp, RootHash, MapRevision, RootSignature, MapperData
 		 FROM MapHead WHERE TreeId=? AND MapRevision=?`
+	selectNthLatestMapRevisionSQL = `SELECT MapRevision FROM MapHead WHERE TreeId=?
+		 ORDER BY MapRevision DESC LIMIT 1 OFFSET ?`
+	deleteOldMapHeadsSQL = `DELETE FROM MapHead
+		 WHERE TreeId=? AND MapRevision<? AND MapHeadTimestamp<?`
 	insertMapLeafSQL = `INSERT INTO MapLeaf(TreeId, KeyHash, MapRevision, LeafValue) VALUES (?, ?, ?, ?)`
 	selectMapLeafSQL = `
  SELECT t1.KeyHash, t1.MapRevision, t1.LeafValue
@@ -51,6 +57,35 @@ const (
  ON t1.TreeId=t2.TreeId
  AND t1.KeyHash=t2.KeyHash
  AND t1.MapRevision=t2.maxrev`
+	insertMapQueuedLeafSQL = `INSERT INTO MapQueuedLeaf(TreeId, KeyHash, LeafValue, QueueTimestampNanos)
+		 VALUES (?, ?, ?, ?)`
+	selectMapQueuedLeavesSQL = `SELECT QueueId, LeafValue FROM MapQueuedLeaf
+		 WHERE TreeId=? ORDER BY QueueId LIMIT ? FOR UPDATE`
+	deleteMapQueuedLeavesSQL = `DELETE FROM MapQueuedLeaf WHERE TreeId=? AND QueueId<=?`
+	// The newest version of each leaf and subtree at or before a revision is kept, older ones
+	// are deleted. The derived tables are grouped, so MySQL materializes them before deleting.
+	deleteSupersededMapLeavesSQL = `
+ DELETE t1 FROM MapLeaf t1
+ INNER JOIN
+ (
+	SELECT KeyHash, MAX(MapRevision) AS keeprev
+	FROM MapLeaf
+	WHERE TreeId = ? AND MapRevision <= ?
+	GROUP BY KeyHash
+ ) t2
+ ON t1.KeyHash=t2.KeyHash
+ WHERE t1.TreeId = ? AND t1.MapRevision < t2.keeprev`
+	deleteSupersededSubtreesSQL = `
+ DELETE t1 FROM Subtree t1
+ INNER JOIN
+ (
+	SELECT SubtreeId, MAX(SubtreeRevision) AS keeprev
+	FROM Subtree
+	WHERE TreeId = ? AND SubtreeRevision <= ?
+	GROUP BY SubtreeId
+ ) t2
+ ON t1.SubtreeId=t2.SubtreeId
+ WHERE t1.TreeId = ? AND t1.SubtreeRevision < t2.keeprev`
 )
 
 var defaultMapStrata = []int{8, 8, 8, 8, 8, 8, 8, 8, 8, 8, 176}
@@ -63,9 +98,13 @@ type mySQLMapStorage struct {
 // NewMapStorage creates a storage.MapStorage instance for the specified MySQL URL.
 // It assumes storage.AdminStorage is backed by the same MySQL database as well.
 func NewMapStorage(db *sql.DB) storage.MapStorage {
+	return newMapStorage(db, DefaultIsolationLevels)
+}
+
+func newMapStorage(db *sql.DB, isolation IsolationLevels) *mySQLMapStorage {
 	return &mySQLMapStorage{
 		admin:            NewAdminStorage(db),
-		mySQLTreeStorage: newTreeStorage(db),
+		mySQLTreeStorage: newTreeStorage(db, isolation),
 	}
 }
 
@@ -78,7 +117,7 @@ type readOnlyMapTX struct {
 }
 
 func (m *mySQLMapStorage) Snapshot(ctx context.Context) (storage.ReadOnlyMapTX, error) {
-	tx, err := m.db.BeginTx(ctx, nil /* opts */)
+	tx, err := m.db.BeginTx(ctx, m.isolation.txOptions(true /* readonly */))
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +147,7 @@ func (m *mySQLMapStorage) begin(ctx context.Context, treeID int64, readonly bool
 	}
 
 	stCache := cache.NewSubtreeCache(defaultMapStrata, cache.PopulateMapSubtreeNodes(treeID, hasher), cache.PrepareMapSubtreeWrite())
-	ttx, err := m.beginTreeTx(ctx, treeID, hasher.Size(), stCache)
+	ttx, err := m.beginTreeTx(ctx, treeID, hasher.Size(), stCache, readonly)
 	if err != nil {
 		return nil, err
 	}
@@ -328,3 +367,97 @@ func (m *mapTreeTX) StoreSignedMapRoot(ctx context.Context, root trillian.Signed
 
 	return checkResultOkAndRowCountIs(res, err, 1)
 }
+
+func (m *mapTreeTX) PruneSignedMapRoots(ctx context.Context, keepCount, keepAfterNanos int64) (int64, error) {
+	if keepCount < 1 {
+		keepCount = 1 // The latest root is always kept.
+	}
+	var minRevision int64
+	err := m.tx.QueryRowContext(ctx, selectNthLatestMapRevisionSQL, m.treeID, keepCount-1).Scan(&minRevision)
+	switch {
+	case err == sql.ErrNoRows:
+		// No more than keepCount roots, nothing to prune.
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	res, err := m.tx.ExecContext(ctx, deleteOldMapHeadsSQL, m.treeID, minRevision, keepAfterNanos)
+	if err != nil {
+		glog.Warningf("Failed to prune signed map roots: %s", err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (m *mapTreeTX) PruneRevisions(ctx context.Context, minRevision int64) (int64, error) {
+	var pruned int64
+	for _, query := range []string{deleteSupersededMapLeavesSQL, deleteSupersededSubtreesSQL} {
+		res, err := m.tx.ExecContext(ctx, query, m.treeID, minRevision, m.treeID)
+		if err != nil {
+			glog.Warningf("Failed to prune map revisions: %s", err)
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		pruned += n
+	}
+	return pruned, nil
+}
+
+func (m *mapTreeTX) QueueMapLeaves(ctx context.Context, leaves []*trillian.MapLeaf, queueTimestamp time.Time) error {
+	stmt, err := m.tx.PrepareContext(ctx, insertMapQueuedLeafSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, leaf := range leaves {
+		flatValue, err := proto.Marshal(leaf)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, m.treeID, leaf.Index, flatValue, queueTimestamp.UnixNano()); err != nil {
+			glog.Warningf("Failed to queue map leaf: %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mapTreeTX) DequeueMapLeaves(ctx context.Context, limit int) ([]*trillian.MapLeaf, error) {
+	rows, err := m.tx.QueryContext(ctx, selectMapQueuedLeavesSQL, m.treeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leaves []*trillian.MapLeaf
+	var lastQueueID int64
+	for rows.Next() {
+		var flatValue []byte
+		if err := rows.Scan(&lastQueueID, &flatValue); err != nil {
+			return nil, err
+		}
+		var leaf trillian.MapLeaf
+		if err := proto.Unmarshal(flatValue, &leaf); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal queued map leaf %v: %v", lastQueueID, err)
+		}
+		leaves = append(leaves, &leaf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(leaves) == 0 {
+		return nil, nil
+	}
+
+	// The leaves are the oldest in the queue, so they're exactly those up to the last one.
+	if _, err := m.tx.ExecContext(ctx, deleteMapQueuedLeavesSQL, m.treeID, lastQueueID); err != nil {
+		glog.Warningf("Failed to dequeue map leaves: %s", err)
+		return nil, err
+	}
+	return leaves, nil
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
@@ -340,6 +341,95 @@ func TestMapSetGetMultipleRevisions(t *testing.T) {
 	}
 }
 
+func TestMapPruneRevisions(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	s := NewMapStorage(DB)
+	ctx := context.Background()
+
+	leaves := make([]trillian.MapLeaf, 4)
+	for rev := range leaves {
+		leaves[rev] = trillian.MapLeaf{Index: keyHash, LeafHash: []byte{byte(rev)}, LeafValue: []byte{byte(rev)}, ExtraData: []byte{byte(rev)}}
+		tx := beginMapTx(ctx, s, mapID, t)
+		tx.(*mapTreeTX).treeTX.writeRevision = int64(rev)
+		if err := tx.Set(ctx, keyHash, leaves[rev]); err != nil {
+			t.Fatalf("Failed to set %v to %v: %v", keyHash, leaves[rev], err)
+		}
+		commit(tx, t)
+	}
+
+	tx := beginMapTx(ctx, s, mapID, t)
+	n, err := tx.PruneRevisions(ctx, 2)
+	if err != nil {
+		t.Fatalf("PruneRevisions() returned err = %v", err)
+	}
+	commit(tx, t)
+	// The versions at revisions 0 and 1 are superseded by the one at revision 2.
+	if got, want := n, int64(2); got != want {
+		t.Errorf("PruneRevisions() = %v, want %v", got, want)
+	}
+
+	for rev := int64(2); rev < int64(len(leaves)); rev++ {
+		tx := beginMapTx(ctx, s, mapID, t)
+		got, err := tx.Get(ctx, rev, [][]byte{keyHash})
+		if err != nil {
+			t.Fatalf("Get(%v) returned err = %v", rev, err)
+		}
+		commit(tx, t)
+		if len(got) != 1 || !proto.Equal(&got[0], &leaves[rev]) {
+			t.Errorf("Get(%v) = %v, want %v", rev, got, leaves[rev])
+		}
+	}
+}
+
+func TestMapLeafQueue(t *testing.T) {
+	cleanTestDB(DB)
+	mapID := createMapForTests(DB)
+	s := NewMapStorage(DB)
+	ctx := context.Background()
+
+	var leaves []*trillian.MapLeaf
+	for i := 0; i < 5; i++ {
+		leaves = append(leaves, &trillian.MapLeaf{Index: keyHash, LeafValue: []byte{byte(i)}})
+	}
+	tx := beginMapTx(ctx, s, mapID, t)
+	if err := tx.QueueMapLeaves(ctx, leaves[:3], time.Now()); err != nil {
+		t.Fatalf("QueueMapLeaves() returned err = %v", err)
+	}
+	commit(tx, t)
+	tx = beginMapTx(ctx, s, mapID, t)
+	if err := tx.QueueMapLeaves(ctx, leaves[3:], time.Now()); err != nil {
+		t.Fatalf("QueueMapLeaves() returned err = %v", err)
+	}
+	commit(tx, t)
+
+	// Leaves dequeued by a transaction that isn't committed stay queued.
+	tx = beginMapTx(ctx, s, mapID, t)
+	if _, err := tx.DequeueMapLeaves(ctx, 2); err != nil {
+		t.Fatalf("DequeueMapLeaves() returned err = %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() returned err = %v", err)
+	}
+
+	for _, want := range [][]*trillian.MapLeaf{leaves[:3], leaves[3:], nil} {
+		tx := beginMapTx(ctx, s, mapID, t)
+		got, err := tx.DequeueMapLeaves(ctx, 3)
+		if err != nil {
+			t.Fatalf("DequeueMapLeaves() returned err = %v", err)
+		}
+		commit(tx, t)
+		if len(got) != len(want) {
+			t.Fatalf("DequeueMapLeaves() = %v, want %v", got, want)
+		}
+		for i := range got {
+			if !proto.Equal(got[i], want[i]) {
+				t.Errorf("DequeueMapLeaves()[%v] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
 func TestGetSignedMapRootNotExist(t *testing.T) {
 	cleanTestDB(DB)
 	mapID := createMapForTests(DB)
@@ -0,0 +1,130 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"flag"
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	mysqlq "github.com/google/trillian/quota/mysql"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/factory"
+	"github.com/google/trillian/util"
+)
+
+// DefaultURI is the MySQL connection URI used when none is given to the storage factory.
+const DefaultURI = "test:zaphod@tcp(127.0.0.1:3306)/test"
+
+var (
+	isolationLevel         = flag.String("mysql_isolation_level", "default", "Isolation level for MySQL transactions that modify trees, including sequencing: default, read-committed, repeatable-read or serializable. See IsolationLevels for the tradeoffs")
+	readOnlyIsolationLevel = flag.String("mysql_readonly_isolation_level", "default", "Isolation level for read-only MySQL transactions such as proof queries, using the same names as --mysql_isolation_level")
+	maxUnsequencedRows     = flag.Int("max_unsequenced_rows", mysqlq.DefaultMaxUnsequenced, "Max number of unsequenced rows before rate limiting kicks in")
+	extraDataCodec         = flag.String("mysql_extra_data_codec", "none", "Codec used to compress the ExtraData of newly queued log leaves: none or gzip. Existing leaves remain readable whatever codec they were stored with")
+	bucketDepthInterval    = flag.Duration("mysql_bucket_depth_interval", time.Minute, "Time between each sample of the Unsequenced bucket depths of dequeued logs with several buckets, zero means disabled")
+)
+
+func init() {
+	factory.Register("mysql", func(uri string, mf monitoring.MetricFactory) (factory.Provider, error) {
+		return NewStorageProvider(uri, mf)
+	})
+}
+
+// StorageProvider is a factory.Provider for MySQL storage. All of its storage shares
+// a single database.
+type StorageProvider struct {
+	db           *sql.DB
+	mf           monitoring.MetricFactory
+	isolation    IsolationLevels
+	codec        Codec
+	bucketDepths *bucketDepthSampler
+}
+
+// NewStorageProvider opens the MySQL database at uri, or DefaultURI if uri is empty, and
+// returns a StorageProvider for it. Transaction isolation levels are taken from the
+// --mysql_isolation_level and --mysql_readonly_isolation_level flags, and the ExtraData
+// codec from --mysql_extra_data_codec. Unsequenced bucket depths are sampled every
+// --mysql_bucket_depth_interval until the provider is closed.
+func NewStorageProvider(uri string, mf monitoring.MetricFactory) (*StorageProvider, error) {
+	rw, err := ParseIsolationLevel(*isolationLevel)
+	if err != nil {
+		return nil, err
+	}
+	ro, err := ParseIsolationLevel(*readOnlyIsolationLevel)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := ParseCodec(*extraDataCodec)
+	if err != nil {
+		return nil, err
+	}
+	if uri == "" {
+		uri = DefaultURI
+	}
+	db, err := OpenDB(uri)
+	if err != nil {
+		return nil, err
+	}
+	p := &StorageProvider{db: db, mf: mf, isolation: IsolationLevels{ReadWrite: rw, ReadOnly: ro}, codec: codec}
+	if *bucketDepthInterval > 0 {
+		p.bucketDepths = newBucketDepthSampler(db, *bucketDepthInterval)
+	}
+	return p, nil
+}
+
+// DB returns the database used by the provider, for MySQL specific components such as the
+// MySQL quota manager.
+func (p *StorageProvider) DB() *sql.DB {
+	return p.db
+}
+
+// AdminStorage implements factory.Provider.
+func (p *StorageProvider) AdminStorage() storage.AdminStorage {
+	return NewAdminStorage(p.db)
+}
+
+// LogStorage implements factory.Provider.
+func (p *StorageProvider) LogStorage() storage.LogStorage {
+	ls := newLogStorage(p.db, p.mf, p.isolation)
+	ls.extraDataCodec = p.codec
+	ls.bucketDepths = p.bucketDepths
+	return ls
+}
+
+// MapStorage implements factory.Provider.
+func (p *StorageProvider) MapStorage() storage.MapStorage {
+	return newMapStorage(p.db, p.isolation)
+}
+
+// QuotaManager implements factory.QuotaProvider. The quota manager rate limits writes by the
+// number of unsequenced rows, up to --max_unsequenced_rows.
+func (p *StorageProvider) QuotaManager() quota.Manager {
+	return &mysqlq.QuotaManager{DB: p.db, MaxUnsequencedRows: *maxUnsequencedRows, MetricFactory: p.mf}
+}
+
+// TimeSource implements factory.TimeSourceProvider, see DBTimeSource.
+func (p *StorageProvider) TimeSource(fallback util.TimeSource) util.TimeSource {
+	return NewDBTimeSource(p.db, fallback)
+}
+
+// Close implements factory.Provider.
+func (p *StorageProvider) Close() error {
+	if p.bucketDepths != nil {
+		p.bucketDepths.stop()
+	}
+	return p.db.Close()
+}
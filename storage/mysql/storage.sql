@@ -13,7 +13,7 @@ SET GLOBAL sql_mode = 'STRICT_ALL_TABLES';
 -- render the data in the tree unusable or inconsistent.
 CREATE TABLE IF NOT EXISTS Trees(
   TreeId                BIGINT NOT NULL,
-  TreeState             ENUM('ACTIVE', 'FROZEN', 'SOFT_DELETED', 'HARD_DELETED') NOT NULL,
+  TreeState             ENUM('ACTIVE', 'FROZEN', 'SOFT_DELETED', 'HARD_DELETED', 'DRAINING') NOT NULL,
   TreeType              ENUM('LOG', 'MAP') NOT NULL,
   HashStrategy          ENUM('RFC6962_SHA256', 'TEST_MAP_HASHER', 'OBJECT_RFC6962_SHA256', 'CONIKS_SHA512_256') NOT NULL,
   HashAlgorithm         ENUM('SHA256') NOT NULL,
@@ -25,7 +25,40 @@ CREATE TABLE IF NOT EXISTS Trees(
   MaxRootDurationMillis BIGINT NOT NULL,
   PrivateKey            MEDIUMBLOB NOT NULL,
   PublicKey             MEDIUMBLOB NOT NULL,
-  PRIMARY KEY(TreeId)
+  MaxClientTimestampSkewMillis BIGINT NOT NULL DEFAULT 0,
+  -- Serialized storagepb.TreeWitnesses, NULL if the tree has no witnesses.
+  Witnesses             MEDIUMBLOB,
+  -- Serialized trillian.RootRetention, NULL if all signed roots are kept.
+  RootRetention         MEDIUMBLOB,
+  MapLeafHashing        ENUM('SERVER_HASHED_LEAVES', 'CLIENT_HASHED_LEAVES') NOT NULL DEFAULT 'SERVER_HASHED_LEAVES',
+  RootMetadataHook      VARCHAR(50) NOT NULL DEFAULT '',
+  -- Serialized trillian.DeadLetterPolicy, NULL if leaves are never dead-lettered.
+  DeadLetterPolicy      MEDIUMBLOB,
+  MaxRevisionLookback   BIGINT NOT NULL DEFAULT 0,
+  -- Serialized storagepb.TreePublicKeys, NULL if the tree has no additional public keys.
+  AdditionalPublicKeys  MEDIUMBLOB,
+  -- NULL if the tree has no origin, so that only set origins must be unique.
+  CheckpointOrigin      VARCHAR(255),
+  DrainGracePeriodMillis BIGINT NOT NULL DEFAULT 0,
+  -- Zero unless the tree is DRAINING.
+  DrainDeadlineMillis   BIGINT NOT NULL DEFAULT 0,
+  -- Serialized trillian.SecondarySigner, NULL if roots are signed by PrivateKey only.
+  SecondarySigner       MEDIUMBLOB,
+  VerifyLeafIdentityHash BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Serialized trillian.DedupWindow, NULL if leaves are deduplicated forever.
+  DedupWindow           MEDIUMBLOB,
+  -- Number of Unsequenced buckets of the tree, zero meaning one.
+  UnsequencedBuckets    INTEGER NOT NULL DEFAULT 0,
+  -- Serialized trillian.AccessPolicy, NULL if any authenticated principal may use the tree.
+  AccessPolicy          MEDIUMBLOB,
+  -- Zero unless the tree is SOFT_DELETED or HARD_DELETED.
+  DeleteTimeMillis      BIGINT NOT NULL DEFAULT 0,
+  -- Serialized trillian.KeyRotation, NULL unless the tree's key is being rotated.
+  KeyRotation           MEDIUMBLOB,
+  -- Serialized storagepb.TreeKeyHistory, NULL if the tree's key was never rotated.
+  KeyHistory            MEDIUMBLOB,
+  PRIMARY KEY(TreeId),
+  UNIQUE INDEX CheckpointOriginIdx(CheckpointOrigin)
 );
 
 -- This table contains tree parameters that can be changed at runtime such as for
@@ -39,6 +72,20 @@ CREATE TABLE IF NOT EXISTS TreeControl(
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId)
 );
 
+-- Configurations of quota buckets, set through the admin API. Buckets that
+-- aren't listed are unlimited. TreeId is zero and QuotaUser empty for buckets
+-- that don't apply to a tree or user respectively.
+CREATE TABLE IF NOT EXISTS QuotaConfigs(
+  QuotaGroup              ENUM('Global', 'Tree', 'User') NOT NULL,
+  QuotaKind               ENUM('Read', 'Write', 'Admin') NOT NULL,
+  TreeId                  BIGINT NOT NULL,
+  QuotaUser               VARCHAR(255) NOT NULL,
+  MaxTokens               BIGINT NOT NULL,
+  TokensToReplenish       BIGINT NOT NULL,
+  ReplenishIntervalMillis BIGINT NOT NULL,
+  PRIMARY KEY(QuotaGroup, QuotaKind, TreeId, QuotaUser)
+);
+
 CREATE TABLE IF NOT EXISTS Subtree(
   TreeId               BIGINT NOT NULL,
   SubtreeId            VARBINARY(255) NOT NULL,
@@ -57,8 +104,14 @@ CREATE TABLE IF NOT EXISTS TreeHead(
   RootHash             VARBINARY(255) NOT NULL,
   RootSignature        VARBINARY(1024) NOT NULL,
   TreeRevision         BIGINT,
+  -- SignedLogRoot.metadata, NULL if the root has none.
+  RootMetadata         MEDIUMBLOB,
+  -- SignedLogRoot.additional_signatures as a serialized
+  -- storagepb.RootSignatures, NULL if the root has none.
+  AdditionalSignatures MEDIUMBLOB,
   PRIMARY KEY(TreeId, TreeHeadTimestamp),
   UNIQUE INDEX TreeRevisionIdx(TreeId, TreeRevision),
+  INDEX TreeSizeIdx(TreeId, TreeSize),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
@@ -85,7 +138,17 @@ CREATE TABLE IF NOT EXISTS LeafData(
   -- This is extra data that the application can associate with the leaf should it wish to.
   -- This data is not included in signing and hashing.
   ExtraData            LONGBLOB,
-  PRIMARY KEY(TreeId, LeafIdentityHash),
+  -- How ExtraData is encoded at rest, see the Codec type. Zero means uncompressed.
+  ExtraDataCodec       TINYINT NOT NULL DEFAULT 0,
+  -- The time the leaf was first queued, so leaves can be counted by when they were queued.
+  -- Leaves queued before this column was added have a timestamp of zero.
+  QueueTimestampNanos  BIGINT NOT NULL DEFAULT 0,
+  -- In trees with a DedupWindow, a leaf can be queued again with the same
+  -- LeafIdentityHash once the earlier leaf is outside the window; each such
+  -- leaf gets the next generation. Always zero in other trees.
+  Generation           INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(TreeId, LeafIdentityHash, Generation),
+  INDEX LeafDataQueueTimestampIdx(TreeId, QueueTimestampNanos),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
@@ -105,15 +168,31 @@ CREATE TABLE IF NOT EXISTS SequencedLeafData(
   -- This is a MerkleLeafHash as defined by the treehasher that the log uses. For example for
   -- CT this hash will include the leaf prefix byte as well as the leaf data.
   MerkleLeafHash       VARBINARY(255) NOT NULL,
+  -- The generation of the LeafData row of the leaf.
+  Generation           INTEGER NOT NULL DEFAULT 0,
   PRIMARY KEY(TreeId, SequenceNumber),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE,
-  FOREIGN KEY(TreeId, LeafIdentityHash) REFERENCES LeafData(TreeId, LeafIdentityHash) ON DELETE CASCADE
+  FOREIGN KEY(TreeId, LeafIdentityHash, Generation) REFERENCES LeafData(TreeId, LeafIdentityHash, Generation) ON DELETE CASCADE
+);
+
+-- Index of the identity hashes of the leaves of trees with a DedupWindow,
+-- which QueueLeaves deduplicates against. Rows of leaves outside the window
+-- are pruned, after which the identity hash can be queued as a new leaf.
+CREATE TABLE IF NOT EXISTS LeafIdentityDedup(
+  TreeId               BIGINT NOT NULL,
+  LeafIdentityHash     VARBINARY(255) NOT NULL,
+  -- The generation of the latest leaf queued with this identity hash.
+  Generation           INTEGER NOT NULL,
+  QueueTimestampNanos  BIGINT NOT NULL,
+  PRIMARY KEY(TreeId, LeafIdentityHash),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
 CREATE TABLE IF NOT EXISTS Unsequenced(
   TreeId               BIGINT NOT NULL,
-  -- The bucket field is to allow the use of time based ring bucketed schemes if desired. If
-  -- unused this should be set to zero for all entries.
+  -- Leaves of trees with several Trees.UnsequencedBuckets are spread across buckets by a
+  -- hash of their LeafIdentityHash, so that concurrent inserts don't contend on the same
+  -- part of the primary key. Zero for all entries of other trees.
   Bucket               INTEGER NOT NULL,
   -- This is a personality specific hash of some subset of the leaf data.
   -- It's only purpose is to allow Trillian to identify duplicate entries in
@@ -126,6 +205,43 @@ CREATE TABLE IF NOT EXISTS Unsequenced(
   PRIMARY KEY (TreeId, Bucket, QueueTimestampNanos, LeafIdentityHash)
 );
 
+-- Leaves that were dequeued but repeatedly failed to be sequenced, see
+-- Tree.dead_letter_policy. A dead-lettered leaf has no row in Unsequenced until
+-- it's requeued.
+CREATE TABLE IF NOT EXISTS DeadLetteredLeaves(
+  TreeId                   BIGINT NOT NULL,
+  LeafIdentityHash         VARBINARY(255) NOT NULL,
+  MerkleLeafHash           VARBINARY(255) NOT NULL,
+  DeadLetterTimestampNanos BIGINT NOT NULL,
+  -- The number of sequencing passes the leaf failed.
+  Attempts                 INTEGER NOT NULL,
+  -- Why the leaf couldn't be sequenced.
+  Reason                   TEXT NOT NULL,
+  PRIMARY KEY(TreeId, LeafIdentityHash),
+  FOREIGN KEY(TreeId, LeafIdentityHash) REFERENCES LeafData(TreeId, LeafIdentityHash) ON DELETE CASCADE
+);
+
+-- Witness cosignatures of the signed roots in TreeHead. Signature is a
+-- serialized DigitallySigned.
+CREATE TABLE IF NOT EXISTS Cosignatures(
+  TreeId               BIGINT NOT NULL,
+  TreeRevision         BIGINT NOT NULL,
+  WitnessName          VARCHAR(50) NOT NULL,
+  Signature            VARBINARY(1024) NOT NULL,
+  PRIMARY KEY(TreeId, TreeRevision, WitnessName),
+  FOREIGN KEY(TreeId, TreeRevision) REFERENCES TreeHead(TreeId, TreeRevision) ON DELETE CASCADE
+);
+
+-- RFC 3161 timestamp tokens of the signed roots in TreeHead. Token is a
+-- DER-encoded TimeStampToken over the root's hash.
+CREATE TABLE IF NOT EXISTS RootTimestampTokens(
+  TreeId               BIGINT NOT NULL,
+  TreeRevision         BIGINT NOT NULL,
+  Token                MEDIUMBLOB NOT NULL,
+  PRIMARY KEY(TreeId, TreeRevision),
+  FOREIGN KEY(TreeId, TreeRevision) REFERENCES TreeHead(TreeId, TreeRevision) ON DELETE CASCADE
+);
+
 
 -- ---------------------------------------------
 -- Map specific stuff here
@@ -142,6 +258,18 @@ CREATE TABLE IF NOT EXISTS MapLeaf(
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
 
+-- Leaves queued by QueueLeaves, to be set in a later revision of the map by
+-- the map sequencer. QueueId orders the queue; LeafValue is a marshaled MapLeaf.
+CREATE TABLE IF NOT EXISTS MapQueuedLeaf(
+  TreeId                BIGINT NOT NULL,
+  QueueId               BIGINT NOT NULL AUTO_INCREMENT,
+  KeyHash               VARBINARY(255) NOT NULL,
+  LeafValue             LONGBLOB NOT NULL,
+  QueueTimestampNanos   BIGINT NOT NULL,
+  PRIMARY KEY(TreeId, QueueId),
+  INDEX QueueIdIdx(QueueId),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
 
 CREATE TABLE IF NOT EXISTS MapHead(
   TreeId               BIGINT NOT NULL,
@@ -0,0 +1,51 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/util"
+)
+
+// selectNowSQL reads the database clock in microseconds since the epoch, the finest
+// precision NOW() offers.
+const selectNowSQL = "SELECT CAST(UNIX_TIMESTAMP(NOW(6)) * 1000000 AS SIGNED)"
+
+// DBTimeSource is a util.TimeSource reading the clock of a MySQL database. Using it for
+// timestamps that are compared against timestamps set by the database keeps them consistent
+// when the clocks of the application server and the database disagree.
+type DBTimeSource struct {
+	db       *sql.DB
+	fallback util.TimeSource
+}
+
+// NewDBTimeSource returns a DBTimeSource reading the clock of db. If the database can't be
+// queried, the time is taken from fallback instead.
+func NewDBTimeSource(db *sql.DB, fallback util.TimeSource) *DBTimeSource {
+	return &DBTimeSource{db: db, fallback: fallback}
+}
+
+// Now returns the current time according to the database.
+func (s *DBTimeSource) Now() time.Time {
+	var micros int64
+	if err := s.db.QueryRow(selectNowSQL).Scan(&micros); err != nil {
+		glog.Warningf("Failed to read database clock, using fallback: %v", err)
+		return s.fallback.Now()
+	}
+	return time.Unix(0, micros*int64(time.Microsecond))
+}
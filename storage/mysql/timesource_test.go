@@ -0,0 +1,45 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/util"
+)
+
+func TestDBTimeSource(t *testing.T) {
+	now := time.Now()
+	got := NewDBTimeSource(DB, util.NewFakeTimeSource(time.Unix(0, 0))).Now()
+	// The test database runs on the same host, so its clock should be close to ours.
+	if diff := got.Sub(now); diff < -time.Minute || diff > time.Minute {
+		t.Errorf("DBTimeSource.Now() = %v, want close to %v", got, now)
+	}
+}
+
+func TestDBTimeSourceFallback(t *testing.T) {
+	db, err := sql.Open("mysql", DefaultURI)
+	if err != nil {
+		t.Fatalf("sql.Open() = %v", err)
+	}
+	db.Close()
+
+	want := time.Unix(1234, 0)
+	if got := NewDBTimeSource(db, util.NewFakeTimeSource(want)).Now(); !got.Equal(want) {
+		t.Errorf("DBTimeSource.Now() = %v, want fallback time %v", got, want)
+	}
+}
@@ -24,19 +24,25 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/cache"
 	"github.com/google/trillian/storage/storagepb"
 )
 
+// instrumentation identifies the storage instrumentation to the tracer.
+const instrumentation = "github.com/google/trillian/storage/mysql"
+
 // These statements are fixed
 const (
-	insertSubtreeMultiSQL = `INSERT INTO Subtree(TreeId, SubtreeId, Nodes, SubtreeRevision) ` + placeholderSQL
-	insertTreeHeadSQL     = `INSERT INTO TreeHead(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature)
-		 VALUES(?,?,?,?,?,?)`
+	// Subtrees are upserted, as a map revision written in several batches
+	// (see SetLeavesStream) may write the same subtree more than once.
+	insertSubtreeMultiSQL = `INSERT INTO Subtree(TreeId, SubtreeId, Nodes, SubtreeRevision) ` + placeholderSQL + ` ON DUPLICATE KEY UPDATE Nodes = VALUES(Nodes)`
+	insertTreeHeadSQL     = `INSERT INTO TreeHead(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature,RootMetadata,AdditionalSignatures)
+		 VALUES(?,?,?,?,?,?,?,?)`
 	selectTreeRevisionAtSizeOrLargerSQL = "SELECT TreeRevision,TreeSize FROM TreeHead WHERE TreeId=? AND TreeSize>=? ORDER BY TreeRevision LIMIT 1"
-	selectActiveLogsSQL                 = "SELECT TreeId from Trees where TreeType='LOG'"
-	selectActiveLogsWithUnsequencedSQL  = "SELECT DISTINCT t.TreeId from Trees t INNER JOIN Unsequenced u WHERE TreeType='LOG' AND t.TreeId=u.TreeId"
+	selectActiveLogsSQL                 = "SELECT TreeId from Trees where TreeType='LOG' AND TreeState NOT IN ('SOFT_DELETED', 'HARD_DELETED')"
+	selectActiveLogsWithUnsequencedSQL  = "SELECT DISTINCT t.TreeId from Trees t INNER JOIN Unsequenced u WHERE TreeType='LOG' AND TreeState NOT IN ('SOFT_DELETED', 'HARD_DELETED') AND t.TreeId=u.TreeId"
 
 	selectSubtreeSQL = `
  SELECT x.SubtreeId, x.MaxRevision, Subtree.Nodes
@@ -57,7 +63,8 @@ const (
 // mySQLTreeStorage is shared between the mySQLLog- and (forthcoming) mySQLMap-
 // Storage implementations, and contains functionality which is common to both,
 type mySQLTreeStorage struct {
-	db *sql.DB
+	db        *sql.DB
+	isolation IsolationLevels
 
 	// Must hold the mutex before manipulating the statement map. Sharing a lock because
 	// it only needs to be held while the statements are built, not while they execute and
@@ -84,9 +91,10 @@ func OpenDB(dbURL string) (*sql.DB, error) {
 	return db, nil
 }
 
-func newTreeStorage(db *sql.DB) *mySQLTreeStorage {
+func newTreeStorage(db *sql.DB, isolation IsolationLevels) *mySQLTreeStorage {
 	return &mySQLTreeStorage{
 		db:         db,
+		isolation:  isolation,
 		statements: make(map[string]map[int]*sql.Stmt),
 	}
 }
@@ -141,14 +149,21 @@ func (m *mySQLTreeStorage) setSubtreeStmt(ctx context.Context, num int) (*sql.St
 	return m.getStmt(ctx, insertSubtreeMultiSQL, num, "VALUES(?, ?, ?, ?)", "(?, ?, ?, ?)")
 }
 
-func (m *mySQLTreeStorage) beginTreeTx(ctx context.Context, treeID int64, hashSizeBytes int, subtreeCache cache.SubtreeCache) (treeTX, error) {
-	t, err := m.db.BeginTx(ctx, nil /* opts */)
+func (m *mySQLTreeStorage) beginTreeTx(ctx context.Context, treeID int64, hashSizeBytes int, subtreeCache cache.SubtreeCache, readonly bool) (treeTX, error) {
+	// The span covers the transaction, from here until it's committed or rolled back.
+	_, span := monitoring.StartSpan(ctx, instrumentation, "mysql.TreeTX")
+	span.SetAttribute("trillian.tree_id", treeID)
+	span.SetAttribute("trillian.readonly", readonly)
+	t, err := m.db.BeginTx(ctx, m.isolation.txOptions(readonly))
 	if err != nil {
 		glog.Warningf("Could not start tree TX: %s", err)
+		span.SetError(err.Error())
+		span.End()
 		return treeTX{}, err
 	}
 	return treeTX{
 		tx:            t,
+		span:          span,
 		ts:            m,
 		treeID:        treeID,
 		hashSizeBytes: hashSizeBytes,
@@ -165,6 +180,7 @@ type treeTX struct {
 	hashSizeBytes int
 	subtreeCache  cache.SubtreeCache
 	writeRevision int64
+	span          monitoring.Span
 }
 
 func (t *treeTX) getSubtree(ctx context.Context, treeRevision int64, nodeID storage.NodeID) (*storagepb.SubtreeProto, error) {
@@ -364,8 +380,10 @@ func (t *treeTX) Commit() error {
 		}
 	}
 	t.closed = true
+	defer t.span.End()
 	if err := t.tx.Commit(); err != nil {
 		glog.Warningf("TX commit error: %s", err)
+		t.span.SetError(err.Error())
 		return err
 	}
 	return nil
@@ -373,6 +391,8 @@ func (t *treeTX) Commit() error {
 
 func (t *treeTX) Rollback() error {
 	t.closed = true
+	t.span.SetAttribute("trillian.rolled_back", true)
+	defer t.span.End()
 	if err := t.tx.Rollback(); err != nil {
 		glog.Warningf("TX rollback error: %s", err)
 		return err
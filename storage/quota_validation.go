@@ -0,0 +1,92 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package storage
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/errors"
+	"github.com/google/trillian/quota"
+)
+
+const maxQuotaUserLength = 255
+
+// ValidateQuotaBucket returns nil if bucket identifies a valid quota bucket,
+// error otherwise.
+// See the documentation on trillian.QuotaBucket for reference on which values
+// are valid.
+func ValidateQuotaBucket(bucket *trillian.QuotaBucket) error {
+	if bucket == nil {
+		return errors.New(errors.InvalidArgument, "a quota bucket is required")
+	}
+	group, err := quota.ParseGroup(bucket.Group)
+	if err != nil {
+		return errors.Errorf(errors.InvalidArgument, "invalid group: %v", err)
+	}
+	if _, err := quota.ParseKind(bucket.Kind); err != nil {
+		return errors.Errorf(errors.InvalidArgument, "invalid kind: %v", err)
+	}
+	switch {
+	case group == quota.Tree && bucket.TreeId <= 0:
+		return errors.Errorf(errors.InvalidArgument, "invalid tree_id for %v bucket: %v", group, bucket.TreeId)
+	case group != quota.Tree && bucket.TreeId != 0:
+		return errors.Errorf(errors.InvalidArgument, "tree_id not allowed for %v buckets", group)
+	case group == quota.User && bucket.User == "":
+		return errors.Errorf(errors.InvalidArgument, "a user is required for %v buckets", group)
+	case group != quota.User && bucket.User != "":
+		return errors.Errorf(errors.InvalidArgument, "user not allowed for %v buckets", group)
+	case len(bucket.User) > maxQuotaUserLength:
+		return errors.Errorf(errors.InvalidArgument, "user too big, max length is %v: %q", maxQuotaUserLength, bucket.User)
+	}
+	return nil
+}
+
+// ValidateQuotaConfig returns nil if cfg is valid for insertion or update,
+// error otherwise.
+// See the documentation on trillian.QuotaConfig for reference on which values
+// are valid.
+func ValidateQuotaConfig(cfg *trillian.QuotaConfig) error {
+	if cfg == nil {
+		return errors.New(errors.InvalidArgument, "a quota config is required")
+	}
+	if err := ValidateQuotaBucket(cfg.Bucket); err != nil {
+		return err
+	}
+	switch {
+	case cfg.MaxTokens <= 0:
+		return errors.Errorf(errors.InvalidArgument, "invalid max_tokens: %v", cfg.MaxTokens)
+	case cfg.TokensToReplenish <= 0:
+		return errors.Errorf(errors.InvalidArgument, "invalid tokens_to_replenish: %v", cfg.TokensToReplenish)
+	}
+	interval, err := ptypes.Duration(cfg.ReplenishInterval)
+	if err != nil {
+		return errors.Errorf(errors.InvalidArgument, "invalid replenish_interval: %v", err)
+	}
+	if interval < time.Millisecond {
+		return errors.Errorf(errors.InvalidArgument, "replenish_interval too small, min is %v: %v", time.Millisecond, interval)
+	}
+	return nil
+}
+
+// ValidateQuotaConfigForUpdate returns nil if updated is a valid update of
+// cfg, error otherwise. The bucket of a config is readonly.
+func ValidateQuotaConfigForUpdate(cfg, updated *trillian.QuotaConfig) error {
+	if !proto.Equal(cfg.GetBucket(), updated.GetBucket()) {
+		return errors.New(errors.InvalidArgument, "readonly field changed: bucket")
+	}
+	return ValidateQuotaConfig(updated)
+}
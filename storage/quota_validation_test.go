@@ -0,0 +1,173 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/errors"
+)
+
+func TestValidateQuotaConfig(t *testing.T) {
+	valid := &trillian.QuotaConfig{
+		Bucket:            &trillian.QuotaBucket{Group: "Tree", Kind: "Write", TreeId: 12345},
+		MaxTokens:         100,
+		TokensToReplenish: 10,
+		ReplenishInterval: ptypes.DurationProto(time.Second),
+	}
+	modify := func(f func(*trillian.QuotaConfig)) *trillian.QuotaConfig {
+		cfg := proto.Clone(valid).(*trillian.QuotaConfig)
+		f(cfg)
+		return cfg
+	}
+
+	tests := []struct {
+		desc    string
+		cfg     *trillian.QuotaConfig
+		wantErr bool
+	}{
+		{desc: "valid", cfg: valid},
+		{
+			desc: "global",
+			cfg:  modify(func(c *trillian.QuotaConfig) { c.Bucket = &trillian.QuotaBucket{Group: "Global", Kind: "Read"} }),
+		},
+		{
+			desc: "user",
+			cfg: modify(func(c *trillian.QuotaConfig) {
+				c.Bucket = &trillian.QuotaBucket{Group: "User", Kind: "Admin", User: "alice"}
+			}),
+		},
+		{desc: "nilConfig", wantErr: true},
+		{
+			desc:    "nilBucket",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket = nil }),
+			wantErr: true,
+		},
+		{
+			desc:    "unknownGroup",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket.Group = "Intermediate" }),
+			wantErr: true,
+		},
+		{
+			desc:    "unknownKind",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket.Kind = "write" }),
+			wantErr: true,
+		},
+		{
+			desc:    "treeWithoutTreeID",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket.TreeId = 0 }),
+			wantErr: true,
+		},
+		{
+			desc:    "treeWithUser",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket.User = "alice" }),
+			wantErr: true,
+		},
+		{
+			desc:    "globalWithTreeID",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket.Group = "Global" }),
+			wantErr: true,
+		},
+		{
+			desc:    "userWithoutUser",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.Bucket = &trillian.QuotaBucket{Group: "User", Kind: "Read"} }),
+			wantErr: true,
+		},
+		{
+			desc: "userTooLong",
+			cfg: modify(func(c *trillian.QuotaConfig) {
+				c.Bucket = &trillian.QuotaBucket{Group: "User", Kind: "Read", User: strings.Repeat("a", maxQuotaUserLength+1)}
+			}),
+			wantErr: true,
+		},
+		{
+			desc:    "zeroMaxTokens",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.MaxTokens = 0 }),
+			wantErr: true,
+		},
+		{
+			desc:    "negativeTokensToReplenish",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.TokensToReplenish = -1 }),
+			wantErr: true,
+		},
+		{
+			desc:    "nilReplenishInterval",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.ReplenishInterval = nil }),
+			wantErr: true,
+		},
+		{
+			desc:    "shortReplenishInterval",
+			cfg:     modify(func(c *trillian.QuotaConfig) { c.ReplenishInterval = ptypes.DurationProto(time.Microsecond) }),
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		err := ValidateQuotaConfig(test.cfg)
+		switch hasErr := err != nil; {
+		case hasErr != test.wantErr:
+			t.Errorf("%v: ValidateQuotaConfig() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		case hasErr && errors.ErrorCode(err) != errors.InvalidArgument:
+			t.Errorf("%v: ValidateQuotaConfig() = %v, wantCode = %v", test.desc, err, errors.InvalidArgument)
+		}
+	}
+}
+
+func TestValidateQuotaConfigForUpdate(t *testing.T) {
+	cfg := &trillian.QuotaConfig{
+		Bucket:            &trillian.QuotaBucket{Group: "Global", Kind: "Write"},
+		MaxTokens:         100,
+		TokensToReplenish: 10,
+		ReplenishInterval: ptypes.DurationProto(time.Second),
+	}
+
+	tests := []struct {
+		desc    string
+		update  func(*trillian.QuotaConfig)
+		wantErr bool
+	}{
+		{
+			desc: "validUpdate",
+			update: func(c *trillian.QuotaConfig) {
+				c.MaxTokens = 200
+				c.ReplenishInterval = ptypes.DurationProto(time.Minute)
+			},
+		},
+		{
+			desc:    "invalidUpdate",
+			update:  func(c *trillian.QuotaConfig) { c.TokensToReplenish = 0 },
+			wantErr: true,
+		},
+		{
+			desc:    "bucketChanged",
+			update:  func(c *trillian.QuotaConfig) { c.Bucket.Kind = "Read" },
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		updated := proto.Clone(cfg).(*trillian.QuotaConfig)
+		test.update(updated)
+		err := ValidateQuotaConfigForUpdate(cfg, updated)
+		switch hasErr := err != nil; {
+		case hasErr != test.wantErr:
+			t.Errorf("%v: ValidateQuotaConfigForUpdate() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		case hasErr && errors.ErrorCode(err) != errors.InvalidArgument:
+			t.Errorf("%v: ValidateQuotaConfigForUpdate() = %v, wantCode = %v", test.desc, err, errors.InvalidArgument)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package roothistory verifies that a log has never forked: that each of its stored signed
+// roots is consistent with the root before it.
+//
+// Roots are read from storage in revision (and thus size) order and a consistency proof
+// between each consecutive pair is built from the stored Merkle nodes and checked with the
+// same code clients use. Verification can be resumed from a checkpoint, the last root found
+// to be consistent.
+package roothistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+)
+
+const (
+	// defaultBatchSize is the number of roots read from storage at a time if Options.BatchSize
+	// is unset.
+	defaultBatchSize = 1000
+	// proofMaxBitLen is the maximum depth of the trees proofs are built for.
+	proofMaxBitLen = 64
+)
+
+// Options configures Verify.
+type Options struct {
+	// BatchSize is the number of roots read from storage at a time, zero means a default.
+	BatchSize int
+	// MaxChecksPerSecond limits the rate of consistency checks, zero means no limit.
+	MaxChecksPerSecond float64
+	// Checkpoint, if set, is called with the last root found to be consistent after each batch
+	// of roots. Passing that root to a later Verify resumes verification after it.
+	Checkpoint func(root trillian.SignedLogRoot) error
+}
+
+// InconsistencyError is returned by Verify if a root isn't consistent with the root before it.
+type InconsistencyError struct {
+	Prev, Root trillian.SignedLogRoot
+	Err        error
+}
+
+func (e *InconsistencyError) Error() string {
+	return fmt.Sprintf("root at revision %v (size %v) is not consistent with root at revision %v (size %v): %v",
+		e.Root.TreeRevision, e.Root.TreeSize, e.Prev.TreeRevision, e.Prev.TreeSize, e.Err)
+}
+
+// Verify checks that each signed root of the log treeID is consistent with the root before it,
+// starting after the checkpoint start, or with the first root if start is empty. It returns the
+// last root found to be consistent, and an *InconsistencyError for the first root that isn't.
+func Verify(ctx context.Context, registry extension.Registry, treeID int64, start trillian.SignedLogRoot, opts Options) (trillian.SignedLogRoot, error) {
+	tree, err := trees.GetTree(ctx, registry.AdminStorage, treeID, trees.GetOpts{TreeType: trillian.TreeType_LOG, Readonly: true})
+	if err != nil {
+		return start, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	if err != nil {
+		return start, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	var throttle <-chan time.Time
+	if opts.MaxChecksPerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.MaxChecksPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	v := &verifier{ls: registry.LogStorage, treeID: treeID, hasher: hasher}
+	prev := start
+	afterRevision := start.TreeRevision
+	if start.RootHash == nil {
+		// Nothing verified yet, the first root (at revision zero) has no predecessor.
+		afterRevision = -1
+	}
+	for {
+		roots, err := v.roots(ctx, afterRevision, batchSize)
+		if err != nil {
+			return prev, err
+		}
+		if len(roots) == 0 {
+			return prev, nil
+		}
+		for _, root := range roots {
+			if prev.RootHash != nil {
+				if throttle != nil {
+					select {
+					case <-throttle:
+					case <-ctx.Done():
+						return prev, ctx.Err()
+					}
+				}
+				if err := v.checkConsistency(ctx, prev, root); err != nil {
+					return prev, err
+				}
+			}
+			prev = root
+		}
+		afterRevision = prev.TreeRevision
+		glog.V(1).Infof("%v: roots up to revision %v (size %v) are consistent", treeID, prev.TreeRevision, prev.TreeSize)
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint(prev); err != nil {
+				return prev, err
+			}
+		}
+	}
+}
+
+type verifier struct {
+	ls     storage.LogStorage
+	treeID int64
+	hasher hashers.LogHasher
+}
+
+// roots reads up to limit roots after the given revision.
+func (v *verifier) roots(ctx context.Context, afterRevision int64, limit int) ([]trillian.SignedLogRoot, error) {
+	tx, err := v.ls.SnapshotForTree(ctx, v.treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	roots, err := tx.SignedLogRoots(ctx, afterRevision, limit)
+	if err != nil {
+		return nil, err
+	}
+	return roots, tx.Commit()
+}
+
+// checkConsistency checks that root is consistent with prev, the root before it. A storage
+// error is returned as is, an inconsistency as an *InconsistencyError.
+func (v *verifier) checkConsistency(ctx context.Context, prev, root trillian.SignedLogRoot) error {
+	if root.TreeSize < prev.TreeSize {
+		return &InconsistencyError{Prev: prev, Root: root, Err: fmt.Errorf("tree size decreased")}
+	}
+	if prev.TreeSize == 0 {
+		// Everything is consistent with the empty tree.
+		return nil
+	}
+
+	proof, err := v.consistencyProof(ctx, prev.TreeSize, root)
+	if err != nil {
+		return err
+	}
+	if err := merkle.NewLogVerifier(v.hasher).VerifyConsistencyProof(prev.TreeSize, root.TreeSize, prev.RootHash, root.RootHash, proof); err != nil {
+		return &InconsistencyError{Prev: prev, Root: root, Err: err}
+	}
+	return nil
+}
+
+// consistencyProof builds the proof that the tree of the given size is a prefix of the tree of
+// root, from the nodes stored at the revision of root.
+func (v *verifier) consistencyProof(ctx context.Context, size int64, root trillian.SignedLogRoot) ([][]byte, error) {
+	if size == root.TreeSize {
+		// Trees of the same size are consistent if their hashes match, no proof needed.
+		return nil, nil
+	}
+	fetches, err := merkle.CalcConsistencyProofNodeAddresses(size, root.TreeSize, root.TreeSize, proofMaxBitLen)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]storage.NodeID, 0, len(fetches))
+	for _, fetch := range fetches {
+		ids = append(ids, fetch.NodeID)
+	}
+
+	tx, err := v.ls.SnapshotForTree(ctx, v.treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	nodes, err := tx.GetMerkleNodes(ctx, root.TreeRevision, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if got, want := len(nodes), len(ids); got != want {
+		return nil, fmt.Errorf("got %d nodes from storage, want %d", got, want)
+	}
+
+	// Nodes marked for rehashing are combined into the single proof node they make up.
+	var proof [][]byte
+	var rehashed []byte
+	for i, fetch := range fetches {
+		if !nodes[i].NodeID.Equivalent(ids[i]) {
+			return nil, fmt.Errorf("got node %v from storage, want %v", nodes[i].NodeID, ids[i])
+		}
+		switch {
+		case fetch.Rehash && rehashed == nil:
+			rehashed = nodes[i].Hash
+		case fetch.Rehash:
+			rehashed = v.hasher.HashChildren(nodes[i].Hash, rehashed)
+		default:
+			if rehashed != nil {
+				proof = append(proof, rehashed)
+				rehashed = nil
+			}
+			proof = append(proof, nodes[i].Hash)
+		}
+	}
+	if rehashed != nil {
+		proof = append(proof, rehashed)
+	}
+	return proof, nil
+}
@@ -0,0 +1,232 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package roothistory
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util"
+)
+
+// batchSizes are the numbers of leaves sequenced into each root after the empty one. An empty
+// batch still produces a new root, of the same size as the one before.
+var batchSizes = []int{1, 4, 0, 7, 3}
+
+// createLog creates a log in registry with one root for the empty tree and one for each of
+// batchSizes.
+func createLog(ctx context.Context, t *testing.T, registry extension.Registry) *trillian.Tree {
+	tx, err := registry.AdminStorage.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin() = (_, %v), want (_, nil)", err)
+	}
+	defer tx.Close()
+	tree, err := tx.CreateTree(ctx, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() = (_, %v), want (_, nil)", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+
+	signer, err := trees.Signer(ctx, registry.SignerFactory, tree)
+	if err != nil {
+		t.Fatalf("trees.Signer() = (_, %v), want (_, nil)", err)
+	}
+	now := time.Unix(1500000000, 0)
+	ts := util.NewFakeTimeSource(now)
+	seq := log.NewSequencer(rfc6962.DefaultHasher, ts, registry.LogStorage, signer, nil, quota.Noop())
+	if err := seq.SignRoot(ctx, tree.TreeId); err != nil {
+		t.Fatalf("SignRoot() = %v, want nil", err)
+	}
+
+	index := 0
+	for _, size := range batchSizes {
+		ltx, err := registry.LogStorage.BeginForTree(ctx, tree.TreeId)
+		if err != nil {
+			t.Fatalf("BeginForTree() = (_, %v), want (_, nil)", err)
+		}
+		for i := 0; i < size; i++ {
+			value := []byte(fmt.Sprintf("leaf %d", index))
+			id := sha256.Sum256(value)
+			leaf := &trillian.LogLeaf{
+				LeafValue:        value,
+				LeafIdentityHash: id[:],
+				MerkleLeafHash:   rfc6962.DefaultHasher.HashLeaf(value),
+			}
+			if _, err := ltx.QueueLeaves(ctx, []*trillian.LogLeaf{leaf}, now.Add(time.Duration(index))); err != nil {
+				t.Fatalf("QueueLeaves() = (_, %v), want (_, nil)", err)
+			}
+			index++
+		}
+		if err := ltx.Commit(); err != nil {
+			t.Fatalf("Commit() = %v, want nil", err)
+		}
+
+		now = now.Add(time.Minute)
+		ts.Set(now)
+		if size == 0 {
+			if err := seq.SignRoot(ctx, tree.TreeId); err != nil {
+				t.Fatalf("SignRoot() = %v, want nil", err)
+			}
+			continue
+		}
+		if n, err := seq.SequenceBatch(ctx, tree.TreeId, size, 0, 0); err != nil || n != size {
+			t.Fatalf("SequenceBatch() = (%v, %v), want (%v, nil)", n, err, size)
+		}
+	}
+	return tree
+}
+
+func newRegistry() extension.Registry {
+	ls := memory.NewLogStorage(nil)
+	return extension.Registry{
+		AdminStorage:  memory.NewAdminStorage(ls),
+		LogStorage:    ls,
+		SignerFactory: &keys.DefaultSignerFactory{},
+		QuotaManager:  quota.Noop(),
+	}
+}
+
+func latestRoot(ctx context.Context, t *testing.T, registry extension.Registry, treeID int64) trillian.SignedLogRoot {
+	tx, err := registry.LogStorage.SnapshotForTree(ctx, treeID)
+	if err != nil {
+		t.Fatalf("SnapshotForTree() = (_, %v), want (_, nil)", err)
+	}
+	defer tx.Close()
+	root, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		t.Fatalf("LatestSignedLogRoot() = (_, %v), want (_, nil)", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want nil", err)
+	}
+	return root
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	registry := newRegistry()
+	tree := createLog(ctx, t, registry)
+	latest := latestRoot(ctx, t, registry, tree.TreeId)
+
+	var checkpoints []trillian.SignedLogRoot
+	opts := Options{
+		BatchSize:  2,
+		Checkpoint: func(root trillian.SignedLogRoot) error { checkpoints = append(checkpoints, root); return nil },
+	}
+	last, err := Verify(ctx, registry, tree.TreeId, trillian.SignedLogRoot{}, opts)
+	if err != nil {
+		t.Fatalf("Verify() = (_, %v), want (_, nil)", err)
+	}
+	if !proto.Equal(&last, &latest) {
+		t.Errorf("Verify() = (%v, nil), want (%v, nil)", last, latest)
+	}
+	// One root for the empty tree and one per batch, checkpointed two at a time.
+	if got, want := len(checkpoints), (len(batchSizes)+2)/2; got != want {
+		t.Fatalf("Verify() made %v checkpoints, want %v", got, want)
+	}
+
+	// Resuming from a checkpoint verifies the remaining roots.
+	resumed, err := Verify(ctx, registry, tree.TreeId, checkpoints[0], Options{})
+	if err != nil {
+		t.Fatalf("Verify(checkpoint) = (_, %v), want (_, nil)", err)
+	}
+	if !proto.Equal(&resumed, &latest) {
+		t.Errorf("Verify(checkpoint) = (%v, nil), want (%v, nil)", resumed, latest)
+	}
+
+	// Resuming from the last root has nothing left to verify.
+	resumed, err = Verify(ctx, registry, tree.TreeId, latest, Options{MaxChecksPerSecond: 1})
+	if err != nil || !proto.Equal(&resumed, &latest) {
+		t.Errorf("Verify(latest) = (%v, %v), want (%v, nil)", resumed, err, latest)
+	}
+}
+
+func TestVerifyRateLimited(t *testing.T) {
+	ctx := context.Background()
+	registry := newRegistry()
+	tree := createLog(ctx, t, registry)
+
+	start := time.Now()
+	if _, err := Verify(ctx, registry, tree.TreeId, trillian.SignedLogRoot{}, Options{MaxChecksPerSecond: 100}); err != nil {
+		t.Fatalf("Verify() = (_, %v), want (_, nil)", err)
+	}
+	// There's one check per root after the first, at most 100 per second.
+	if got, want := time.Since(start), time.Duration(len(batchSizes))*10*time.Millisecond; got < want {
+		t.Errorf("Verify() took %v, want at least %v", got, want)
+	}
+}
+
+func TestVerifyInconsistent(t *testing.T) {
+	for _, test := range []struct {
+		desc   string
+		modify func(root *trillian.SignedLogRoot)
+	}{
+		{
+			desc:   "forkedHash",
+			modify: func(root *trillian.SignedLogRoot) { root.RootHash = []byte("forked") },
+		},
+		{
+			desc:   "smallerSize",
+			modify: func(root *trillian.SignedLogRoot) { root.TreeSize-- },
+		},
+	} {
+		ctx := context.Background()
+		registry := newRegistry()
+		tree := createLog(ctx, t, registry)
+		latest := latestRoot(ctx, t, registry, tree.TreeId)
+
+		tx, err := registry.LogStorage.BeginForTree(ctx, tree.TreeId)
+		if err != nil {
+			t.Fatalf("%v: BeginForTree() = (_, %v), want (_, nil)", test.desc, err)
+		}
+		bad := latest
+		bad.TreeRevision = tx.WriteRevision()
+		bad.TimestampNanos += int64(time.Minute)
+		test.modify(&bad)
+		if err := tx.StoreSignedLogRoot(ctx, bad); err != nil {
+			t.Fatalf("%v: StoreSignedLogRoot() = %v, want nil", test.desc, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("%v: Commit() = %v, want nil", test.desc, err)
+		}
+
+		last, err := Verify(ctx, registry, tree.TreeId, trillian.SignedLogRoot{}, Options{})
+		ierr, ok := err.(*InconsistencyError)
+		if !ok {
+			t.Fatalf("%v: Verify() = (_, %v), want InconsistencyError", test.desc, err)
+		}
+		if !proto.Equal(&ierr.Prev, &latest) || !proto.Equal(&ierr.Root, &bad) {
+			t.Errorf("%v: Verify() reported %v", test.desc, err)
+		}
+		if !proto.Equal(&last, &latest) {
+			t.Errorf("%v: Verify() = (%v, _), want last consistent root %v", test.desc, last, latest)
+		}
+	}
+}
@@ -10,12 +10,19 @@ It is generated from these files:
 It has these top-level messages:
 	NodeIDProto
 	SubtreeProto
+	TreeWitnesses
+	TreePublicKeys
+	TreeKeyHistory
+	RootSignatures
 */
 package storagepb
 
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import keyspb "github.com/google/trillian/crypto/keyspb"
+import sigpb "github.com/google/trillian/crypto/sigpb"
+import trillian "github.com/google/trillian"
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
@@ -120,33 +127,118 @@ func (m *SubtreeProto) GetInternalNodeCount() uint32 {
 	return 0
 }
 
+// TreeWitnesses holds the witnesses of a tree, for storage implementations that
+// keep them in a single column.
+type TreeWitnesses struct {
+	Witnesses []*trillian.Witness `protobuf:"bytes,1,rep,name=witnesses" json:"witnesses,omitempty"`
+}
+
+func (m *TreeWitnesses) Reset()                    { *m = TreeWitnesses{} }
+func (m *TreeWitnesses) String() string            { return proto.CompactTextString(m) }
+func (*TreeWitnesses) ProtoMessage()               {}
+func (*TreeWitnesses) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *TreeWitnesses) GetWitnesses() []*trillian.Witness {
+	if m != nil {
+		return m.Witnesses
+	}
+	return nil
+}
+
+// TreePublicKeys holds the additional public keys of a tree, for storage
+// implementations that keep them in a single column.
+type TreePublicKeys struct {
+	PublicKeys []*keyspb.PublicKey `protobuf:"bytes,1,rep,name=public_keys,json=publicKeys" json:"public_keys,omitempty"`
+}
+
+func (m *TreePublicKeys) Reset()                    { *m = TreePublicKeys{} }
+func (m *TreePublicKeys) String() string            { return proto.CompactTextString(m) }
+func (*TreePublicKeys) ProtoMessage()               {}
+func (*TreePublicKeys) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *TreePublicKeys) GetPublicKeys() []*keyspb.PublicKey {
+	if m != nil {
+		return m.PublicKeys
+	}
+	return nil
+}
+
+// TreeKeyHistory holds the retired keys of a tree, for storage implementations
+// that keep them in a single column.
+type TreeKeyHistory struct {
+	Keys []*trillian.RetiredKey `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *TreeKeyHistory) Reset()                    { *m = TreeKeyHistory{} }
+func (m *TreeKeyHistory) String() string            { return proto.CompactTextString(m) }
+func (*TreeKeyHistory) ProtoMessage()               {}
+func (*TreeKeyHistory) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *TreeKeyHistory) GetKeys() []*trillian.RetiredKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type RootSignatures struct {
+	Signatures []*sigpb.DigitallySigned `protobuf:"bytes,1,rep,name=signatures" json:"signatures,omitempty"`
+}
+
+func (m *RootSignatures) Reset()                    { *m = RootSignatures{} }
+func (m *RootSignatures) String() string            { return proto.CompactTextString(m) }
+func (*RootSignatures) ProtoMessage()               {}
+func (*RootSignatures) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *RootSignatures) GetSignatures() []*sigpb.DigitallySigned {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*NodeIDProto)(nil), "storagepb.NodeIDProto")
 	proto.RegisterType((*SubtreeProto)(nil), "storagepb.SubtreeProto")
+	proto.RegisterType((*TreeWitnesses)(nil), "storagepb.TreeWitnesses")
+	proto.RegisterType((*TreePublicKeys)(nil), "storagepb.TreePublicKeys")
+	proto.RegisterType((*TreeKeyHistory)(nil), "storagepb.TreeKeyHistory")
+	proto.RegisterType((*RootSignatures)(nil), "storagepb.RootSignatures")
 }
 
 func init() { proto.RegisterFile("storage.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 308 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x4d, 0x4b, 0xc3, 0x40,
-	0x10, 0x86, 0x49, 0xd3, 0x06, 0x3b, 0x6d, 0xfc, 0x58, 0x45, 0x96, 0x7a, 0x09, 0x15, 0x24, 0x78,
-	0xc8, 0x41, 0x2f, 0x7e, 0x5c, 0xc4, 0x0f, 0xb0, 0x50, 0x44, 0xd7, 0x1f, 0x10, 0x36, 0x66, 0x34,
-	0xc1, 0xb0, 0x1b, 0x76, 0x37, 0xc5, 0xfe, 0x11, 0x7f, 0xaf, 0x64, 0xb3, 0x48, 0x44, 0x3c, 0x78,
-	0x9b, 0x77, 0xe6, 0x9d, 0x27, 0x93, 0x77, 0x21, 0xd4, 0x46, 0x2a, 0xfe, 0x86, 0x49, 0xad, 0xa4,
-	0x91, 0x64, 0xec, 0x64, 0x9d, 0xcd, 0x17, 0x30, 0x79, 0x90, 0x39, 0x2e, 0x6e, 0x1f, 0xed, 0x84,
-	0xc0, 0xb0, 0xe6, 0xa6, 0xa0, 0x5e, 0xe4, 0xc5, 0x53, 0x66, 0x6b, 0x72, 0x04, 0x5b, 0xb5, 0xc2,
-	0xd7, 0xf2, 0x23, 0xad, 0x50, 0xa4, 0x59, 0x69, 0x34, 0x1d, 0x44, 0x5e, 0x3c, 0x62, 0x61, 0xd7,
-	0x5e, 0xa2, 0xb8, 0x2e, 0x8d, 0x9e, 0x7f, 0xfa, 0x30, 0x7d, 0x6e, 0x32, 0xa3, 0x10, 0x3b, 0xd8,
-	0x3e, 0x04, 0x9d, 0xc3, 0xe1, 0x9c, 0x22, 0x7b, 0x30, 0xca, 0xb1, 0x36, 0x85, 0xc3, 0x74, 0x82,
-	0x1c, 0xc0, 0x58, 0x49, 0x69, 0xd2, 0x82, 0xeb, 0x82, 0xfa, 0x76, 0x61, 0xa3, 0x6d, 0xdc, 0x73,
-	0x5d, 0x90, 0x4b, 0x08, 0x2a, 0xe4, 0x2b, 0xd4, 0x74, 0x18, 0xf9, 0xf1, 0xe4, 0xe4, 0x30, 0xf9,
-	0xfe, 0x85, 0xa4, 0xff, 0xcd, 0x64, 0x69, 0x5d, 0x77, 0xc2, 0xa8, 0x35, 0x73, 0x2b, 0xe4, 0x09,
-	0x36, 0x4b, 0x61, 0x50, 0x09, 0x5e, 0xa5, 0x42, 0xe6, 0xa8, 0xe9, 0xc8, 0x42, 0x8e, 0xff, 0x82,
-	0x2c, 0x9c, 0xbb, 0x4d, 0xc6, 0xb1, 0xc2, 0xb2, 0xdf, 0x23, 0x09, 0xec, 0xfe, 0x40, 0xa6, 0x2f,
-	0xb2, 0x11, 0x86, 0x06, 0x91, 0x17, 0x87, 0x6c, 0xa7, 0xef, 0xbd, 0x69, 0x07, 0xb3, 0x73, 0x98,
-	0xf4, 0x2e, 0x23, 0xdb, 0xe0, 0xbf, 0xe3, 0xda, 0xc6, 0x32, 0x66, 0x6d, 0xd9, 0x66, 0xb2, 0xe2,
-	0x55, 0x83, 0x36, 0x93, 0x29, 0xeb, 0xc4, 0xc5, 0xe0, 0xcc, 0x9b, 0x5d, 0x01, 0xf9, 0x7d, 0xcf,
-	0x7f, 0x08, 0x59, 0x60, 0x5f, 0xfd, 0xf4, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xf4, 0xae, 0x38, 0x20,
-	0x06, 0x02, 0x00, 0x00,
+	// 487 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x93, 0x5d, 0x6b, 0xd4, 0x4e,
+	0x14, 0xc6, 0x49, 0xf7, 0x85, 0xff, 0x9e, 0x7d, 0xf9, 0xdb, 0xb1, 0x94, 0xb0, 0xde, 0x2c, 0x11,
+	0x64, 0xf5, 0x22, 0x0b, 0x2d, 0x8a, 0xd6, 0x9b, 0xa2, 0x2b, 0xec, 0xd2, 0x22, 0x75, 0x2a, 0x78,
+	0x19, 0x92, 0xdd, 0x63, 0x32, 0x34, 0x66, 0x86, 0x99, 0x49, 0x35, 0x5f, 0xc4, 0xcf, 0x2b, 0xf3,
+	0xd2, 0x34, 0x22, 0x2a, 0xde, 0xec, 0xce, 0x33, 0x79, 0x9e, 0x5f, 0x0e, 0x0f, 0x27, 0x30, 0x55,
+	0x9a, 0xcb, 0x34, 0xc7, 0x58, 0x48, 0xae, 0x39, 0x19, 0x79, 0x29, 0xb2, 0xf9, 0xf3, 0x9c, 0xe9,
+	0xa2, 0xce, 0xe2, 0x1d, 0xff, 0xb2, 0xca, 0x39, 0xcf, 0x4b, 0x5c, 0x69, 0xc9, 0xca, 0x92, 0xa5,
+	0xd5, 0x6a, 0x27, 0x1b, 0xa1, 0xf9, 0xea, 0x06, 0x1b, 0x25, 0x32, 0xff, 0xe7, 0x08, 0xf3, 0xd3,
+	0xbf, 0xc7, 0x14, 0xcb, 0x45, 0xe6, 0x7e, 0x7d, 0xe8, 0xe9, 0x1f, 0x42, 0x77, 0x07, 0x67, 0x8d,
+	0xb6, 0x30, 0x7e, 0xcf, 0xf7, 0xb8, 0x5d, 0x5f, 0xd9, 0x81, 0x09, 0xf4, 0x45, 0xaa, 0x8b, 0x30,
+	0x58, 0x04, 0xcb, 0x09, 0xb5, 0x67, 0xf2, 0x04, 0xfe, 0x17, 0x12, 0x3f, 0xb3, 0x6f, 0x49, 0x89,
+	0x55, 0x92, 0x31, 0xad, 0xc2, 0x83, 0x45, 0xb0, 0x1c, 0xd0, 0xa9, 0xbb, 0xbe, 0xc4, 0xea, 0x0d,
+	0xd3, 0x2a, 0xfa, 0xde, 0x83, 0xc9, 0x75, 0x9d, 0x69, 0x89, 0xe8, 0x60, 0xc7, 0x30, 0x74, 0x0e,
+	0x8f, 0xf3, 0x8a, 0x1c, 0xc1, 0x60, 0x8f, 0x42, 0x17, 0x1e, 0xe3, 0x04, 0x79, 0x04, 0x23, 0xc9,
+	0xb9, 0x4e, 0x8a, 0x54, 0x15, 0x61, 0xcf, 0x06, 0xfe, 0x33, 0x17, 0x9b, 0x54, 0x15, 0xe4, 0x35,
+	0x0c, 0x4b, 0x4c, 0x6f, 0x51, 0x85, 0xfd, 0x45, 0x6f, 0x39, 0x3e, 0x79, 0x1c, 0xb7, 0xcd, 0xc6,
+	0xdd, 0x77, 0xc6, 0x97, 0xd6, 0xf5, 0xae, 0xd2, 0xb2, 0xa1, 0x3e, 0x42, 0x3e, 0xc0, 0x8c, 0x55,
+	0x1a, 0x65, 0x95, 0x96, 0x49, 0xc5, 0xf7, 0xa8, 0xc2, 0x81, 0x85, 0x3c, 0xfb, 0x1d, 0x64, 0xeb,
+	0xdd, 0xa6, 0x19, 0xcf, 0x9a, 0xb2, 0xee, 0x1d, 0x89, 0xe1, 0xe1, 0x4f, 0xc8, 0x64, 0xc7, 0xeb,
+	0x4a, 0x87, 0xc3, 0x45, 0xb0, 0x9c, 0xd2, 0xc3, 0xae, 0xf7, 0xad, 0x79, 0x30, 0x7f, 0x05, 0xe3,
+	0xce, 0x64, 0xe4, 0x01, 0xf4, 0x6e, 0xb0, 0xb1, 0xb5, 0x8c, 0xa8, 0x39, 0x9a, 0x4e, 0x6e, 0xd3,
+	0xb2, 0x46, 0xdb, 0xc9, 0x84, 0x3a, 0x71, 0x76, 0xf0, 0x32, 0x98, 0x9f, 0x03, 0xf9, 0x75, 0x9e,
+	0x7f, 0x21, 0x44, 0xe7, 0x30, 0xfd, 0x28, 0x11, 0x3f, 0x31, 0x5d, 0xa1, 0x52, 0xa8, 0xc8, 0x0a,
+	0x46, 0x5f, 0xef, 0x44, 0x18, 0xd8, 0x2e, 0x0e, 0xe3, 0x76, 0x31, 0xbc, 0x8f, 0xde, 0x7b, 0xa2,
+	0x35, 0xcc, 0x0c, 0xe1, 0xaa, 0xce, 0x4a, 0xb6, 0xbb, 0xc0, 0x46, 0x91, 0x13, 0x18, 0x0b, 0xab,
+	0x12, 0xb3, 0xae, 0x2d, 0xc4, 0xef, 0x6e, 0x6b, 0xa4, 0x20, 0xda, 0x4c, 0x74, 0xe6, 0x28, 0x17,
+	0xd8, 0x6c, 0x98, 0x69, 0xbe, 0x21, 0x4b, 0xe8, 0x77, 0xe2, 0x47, 0xf7, 0x33, 0x50, 0xd4, 0x4c,
+	0xe2, 0xde, 0x10, 0xac, 0x23, 0xda, 0xc0, 0x8c, 0x72, 0xae, 0xaf, 0x59, 0x5e, 0xa5, 0xba, 0x96,
+	0xa8, 0xc8, 0x0b, 0x00, 0xd5, 0x2a, 0x4f, 0x38, 0x8e, 0xdd, 0x67, 0xb0, 0x66, 0x39, 0xd3, 0x69,
+	0x59, 0x36, 0xc6, 0x8f, 0x7b, 0xda, 0x71, 0x66, 0x43, 0xbb, 0xf8, 0xa7, 0x3f, 0x06, 0x00, 0x3e,
+	0xee, 0xd3, 0x4d, 0xab, 0x03, 0x00, 0x00,
 }
@@ -28,6 +28,7 @@ import (
 	ktestonly "github.com/google/trillian/crypto/keys/testonly"
 	"github.com/google/trillian/crypto/keyspb"
 	spb "github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/errors"
 	_ "github.com/google/trillian/merkle/maphasher" // TEST_MAP_HASHER
 	"github.com/google/trillian/storage"
 	ttestonly "github.com/google/trillian/testonly"
@@ -91,8 +92,13 @@ type AdminStorageTester struct {
 // RunAllTests runs all AdminStorage tests.
 func (tester *AdminStorageTester) RunAllTests(t *testing.T) {
 	t.Run("TestCreateTree", tester.TestCreateTree)
+	t.Run("TestCreateTreeDuplicateOrigin", tester.TestCreateTreeDuplicateOrigin)
+	t.Run("TestCountActiveTrees", tester.TestCountActiveTrees)
 	t.Run("TestUpdateTree", tester.TestUpdateTree)
 	t.Run("TestListTrees", tester.TestListTrees)
+	t.Run("TestQuotaConfigs", tester.TestQuotaConfigs)
+	t.Run("TestDeleteTree", tester.TestDeleteTree)
+	t.Run("TestKeyRotation", tester.TestKeyRotation)
 	t.Run("TestAdminTXClose", tester.TestAdminTXClose)
 }
 
@@ -110,6 +116,9 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 	validTreeWithoutOptionals.DisplayName = ""
 	validTreeWithoutOptionals.Description = ""
 
+	validTreeWithOrigin := *LogTree
+	validTreeWithOrigin.CheckpointOrigin = "example.com/log/create"
+
 	tests := []struct {
 		desc    string
 		tree    *trillian.Tree
@@ -132,6 +141,10 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 			desc: "validTreeWithoutOptionals",
 			tree: &validTreeWithoutOptionals,
 		},
+		{
+			desc: "validTreeWithOrigin",
+			tree: &validTreeWithOrigin,
+		},
 	}
 
 	ctx := context.Background()
@@ -197,6 +210,48 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 	}
 }
 
+// TestCreateTreeDuplicateOrigin tests that trees can't share a checkpoint origin.
+func (tester *AdminStorageTester) TestCreateTreeDuplicateOrigin(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	tree := *LogTree
+	tree.CheckpointOrigin = "example.com/log/unique"
+	if _, err := createTree(ctx, s, &tree); err != nil {
+		t.Fatalf("createTree() = (_, %v), want = (_, nil)", err)
+	}
+	_, err := createTree(ctx, s, &tree)
+	if got, want := errors.ErrorCode(err), errors.AlreadyExists; got != want {
+		t.Errorf("createTree(duplicate origin) = (_, %v), want code %v", err, want)
+	}
+
+	// Trees without an origin don't conflict.
+	for i := 0; i < 2; i++ {
+		if _, err := createTree(ctx, s, LogTree); err != nil {
+			t.Errorf("createTree(no origin) = (_, %v), want = (_, nil)", err)
+		}
+	}
+}
+
+// TestCountActiveTrees tests that created trees are counted.
+func (tester *AdminStorageTester) TestCountActiveTrees(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	before, err := countActiveTrees(ctx, s)
+	if err != nil {
+		t.Fatalf("countActiveTrees() = (_, %v), want = (_, nil)", err)
+	}
+	for _, tree := range []*trillian.Tree{LogTree, MapTree} {
+		if _, err := createTree(ctx, s, tree); err != nil {
+			t.Fatalf("createTree() = (_, %v), want = (_, nil)", err)
+		}
+	}
+	if got, err := countActiveTrees(ctx, s); err != nil || got != before+2 {
+		t.Errorf("countActiveTrees() = (%v, %v), want = (%v, nil)", got, err, before+2)
+	}
+}
+
 // TestUpdateTree tests AdminStorage Tree updates.
 func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 	ctx := context.Background()
@@ -225,6 +280,44 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 	validLogWithoutOptionals := referenceLog
 	validLogWithoutOptionalsFunc(&validLogWithoutOptionals)
 
+	// Millisecond-precision values, so they survive storage round trips.
+	drainDeadline, err := ptypes.TimestampProto(time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatalf("TimestampProto() returned err = %v", err)
+	}
+	drainingLog := referenceLog
+	drainingLog.TreeState = trillian.TreeState_DRAINING
+	drainingLog.DrainGracePeriod = ptypes.DurationProto(time.Hour)
+	drainingLog.DrainDeadline = drainDeadline
+	drainingLogFunc := func(t *trillian.Tree) {
+		t.TreeState = drainingLog.TreeState
+		t.DrainGracePeriod = drainingLog.DrainGracePeriod
+		t.DrainDeadline = drainingLog.DrainDeadline
+	}
+
+	// Storage doesn't check that keys match the algorithm, so the log's own keys will do.
+	secondarySignerLog := referenceLog
+	secondarySignerLog.SecondarySigner = &trillian.SecondarySigner{
+		SignatureAlgorithm: spb.DigitallySigned_RSA,
+		PrivateKey:         referenceLog.PrivateKey,
+		PublicKey:          referenceLog.PublicKey,
+	}
+	secondarySignerLogFunc := func(t *trillian.Tree) {
+		t.SecondarySigner = secondarySignerLog.SecondarySigner
+	}
+
+	verifyingLog := referenceLog
+	verifyingLog.VerifyLeafIdentityHash = true
+	verifyingLogFunc := func(t *trillian.Tree) {
+		t.VerifyLeafIdentityHash = true
+	}
+
+	policyLog := referenceLog
+	policyLog.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"alice"}, Readers: []string{"bob", "carol"}}
+	policyLogFunc := func(t *trillian.Tree) {
+		t.AccessPolicy = policyLog.AccessPolicy
+	}
+
 	invalidLogFunc := func(t *trillian.Tree) {
 		t.TreeState = trillian.TreeState_UNKNOWN_TREE_STATE
 	}
@@ -233,6 +326,10 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 		t.TreeType = trillian.TreeType_MAP
 	}
 
+	originChangedFunc := func(t *trillian.Tree) {
+		t.CheckpointOrigin = "example.com/log/changed"
+	}
+
 	referenceMap := *MapTree
 	validMap := referenceMap
 	validMap.DisplayName = "Updated Map"
@@ -263,6 +360,30 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 			updateFunc: validLogWithoutOptionalsFunc,
 			want:       &validLogWithoutOptionals,
 		},
+		{
+			desc:       "drainingLog",
+			create:     &referenceLog,
+			updateFunc: drainingLogFunc,
+			want:       &drainingLog,
+		},
+		{
+			desc:       "secondarySignerLog",
+			create:     &referenceLog,
+			updateFunc: secondarySignerLogFunc,
+			want:       &secondarySignerLog,
+		},
+		{
+			desc:       "verifyingLog",
+			create:     &referenceLog,
+			updateFunc: verifyingLogFunc,
+			want:       &verifyingLog,
+		},
+		{
+			desc:       "policyLog",
+			create:     &referenceLog,
+			updateFunc: policyLogFunc,
+			want:       &policyLog,
+		},
 		{
 			desc:       "invalidLog",
 			create:     &referenceLog,
@@ -275,6 +396,12 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 			updateFunc: readonlyChangedFunc,
 			wantErr:    true,
 		},
+		{
+			desc:       "originChanged",
+			create:     &referenceLog,
+			updateFunc: originChangedFunc,
+			wantErr:    true,
+		},
 		{
 			desc:       "validMap",
 			create:     &referenceMap,
@@ -363,6 +490,20 @@ func createTree(ctx context.Context, s storage.AdminStorage, tree *trillian.Tree
 	return newTree, nil
 }
 
+// countActiveTrees counts the active trees in a transaction of its own.
+func countActiveTrees(ctx context.Context, s storage.AdminStorage) (int64, error) {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+	count, err := tx.CountActiveTrees(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
 // updateTree updates the specified tree.
 // The bool return signifies whether the error was returned by the UpdateTree() call.
 func updateTree(ctx context.Context, s storage.AdminStorage, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, bool, error) {
@@ -515,6 +656,256 @@ func toTreeMap(values []*trillian.Tree) map[int64]*trillian.Tree {
 	return m
 }
 
+// TestQuotaConfigs tests creating, updating, listing and deleting quota
+// configurations.
+func (tester *AdminStorageTester) TestQuotaConfigs(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	globalCfg := &trillian.QuotaConfig{
+		Bucket:            &trillian.QuotaBucket{Group: "Global", Kind: "Write"},
+		MaxTokens:         1000,
+		TokensToReplenish: 100,
+		ReplenishInterval: ptypes.DurationProto(time.Second),
+	}
+	treeCfg := &trillian.QuotaConfig{
+		Bucket:            &trillian.QuotaBucket{Group: "Tree", Kind: "Read", TreeId: 12345},
+		MaxTokens:         50,
+		TokensToReplenish: 5,
+		ReplenishInterval: ptypes.DurationProto(time.Minute),
+	}
+	for _, cfg := range []*trillian.QuotaConfig{globalCfg, treeCfg} {
+		err := inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+			got, err := tx.CreateQuotaConfig(ctx, cfg)
+			if err == nil && !proto.Equal(got, cfg) {
+				t.Errorf("CreateQuotaConfig() = %v, want %v", got, cfg)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("CreateQuotaConfig() = (_, %v), want = (_, nil)", err)
+		}
+	}
+	err := inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		_, err := tx.CreateQuotaConfig(ctx, globalCfg)
+		return err
+	})
+	if got, want := errors.ErrorCode(err), errors.AlreadyExists; got != want {
+		t.Errorf("CreateQuotaConfig(duplicate) = (_, %v), want code %v", err, want)
+	}
+	err = inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		_, err := tx.CreateQuotaConfig(ctx, &trillian.QuotaConfig{Bucket: globalCfg.Bucket})
+		return err
+	})
+	if got, want := errors.ErrorCode(err), errors.InvalidArgument; got != want {
+		t.Errorf("CreateQuotaConfig(invalid) = (_, %v), want code %v", err, want)
+	}
+
+	updatedCfg := proto.Clone(globalCfg).(*trillian.QuotaConfig)
+	updatedCfg.MaxTokens = 2000
+	err = inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		got, err := tx.UpdateQuotaConfig(ctx, globalCfg.Bucket, func(cfg *trillian.QuotaConfig) { cfg.MaxTokens = 2000 })
+		if err == nil && !proto.Equal(got, updatedCfg) {
+			t.Errorf("UpdateQuotaConfig() = %v, want %v", got, updatedCfg)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("UpdateQuotaConfig() = (_, %v), want = (_, nil)", err)
+	}
+	err = inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		_, err := tx.UpdateQuotaConfig(ctx, globalCfg.Bucket, func(cfg *trillian.QuotaConfig) { cfg.Bucket.Kind = "Read" })
+		return err
+	})
+	if got, want := errors.ErrorCode(err), errors.InvalidArgument; got != want {
+		t.Errorf("UpdateQuotaConfig(bucket changed) = (_, %v), want code %v", err, want)
+	}
+	unknownBucket := &trillian.QuotaBucket{Group: "User", Kind: "Read", User: "unknown"}
+	err = inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		_, err := tx.UpdateQuotaConfig(ctx, unknownBucket, func(cfg *trillian.QuotaConfig) {})
+		return err
+	})
+	if got, want := errors.ErrorCode(err), errors.NotFound; got != want {
+		t.Errorf("UpdateQuotaConfig(unknown bucket) = (_, %v), want code %v", err, want)
+	}
+
+	if err := inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		return tx.DeleteQuotaConfig(ctx, treeCfg.Bucket)
+	}); err != nil {
+		t.Fatalf("DeleteQuotaConfig() = %v, want = nil", err)
+	}
+	err = inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		return tx.DeleteQuotaConfig(ctx, treeCfg.Bucket)
+	})
+	if got, want := errors.ErrorCode(err), errors.NotFound; got != want {
+		t.Errorf("DeleteQuotaConfig(deleted bucket) = %v, want code %v", err, want)
+	}
+
+	var cfgs []*trillian.QuotaConfig
+	if err := inAdminTX(ctx, s, func(tx storage.AdminTX) error {
+		var err error
+		cfgs, err = tx.ListQuotaConfigs(ctx)
+		return err
+	}); err != nil {
+		t.Fatalf("ListQuotaConfigs() = (_, %v), want = (_, nil)", err)
+	}
+	if len(cfgs) != 1 || !proto.Equal(cfgs[0], updatedCfg) {
+		t.Errorf("ListQuotaConfigs() = %v, want [%v]", cfgs, updatedCfg)
+	}
+}
+
+// inAdminTX runs f in a transaction, committing it if f succeeds.
+// TestDeleteTree tests soft-deleting, undeleting and hard-deleting trees.
+func (tester *AdminStorageTester) TestDeleteTree(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	tree, err := createTree(ctx, s, LogTree)
+	if err != nil {
+		t.Fatalf("createTree() = (_, %v), want = (_, nil)", err)
+	}
+	id := tree.TreeId
+
+	steps := []struct {
+		desc      string
+		fn        func(storage.AdminTX) error
+		wantErr   bool
+		wantState trillian.TreeState
+	}{
+		{
+			desc:      "softDelete",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.SoftDeleteTree(ctx, id); return err },
+			wantState: trillian.TreeState_SOFT_DELETED,
+		},
+		{
+			desc:      "softDeleteAgain",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.SoftDeleteTree(ctx, id); return err },
+			wantErr:   true,
+			wantState: trillian.TreeState_SOFT_DELETED,
+		},
+		{
+			desc:      "undelete",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.UndeleteTree(ctx, id); return err },
+			wantState: trillian.TreeState_FROZEN,
+		},
+		{
+			desc:      "undeleteAgain",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.UndeleteTree(ctx, id); return err },
+			wantErr:   true,
+			wantState: trillian.TreeState_FROZEN,
+		},
+		{
+			desc:      "hardDeleteUndeleted",
+			fn:        func(tx storage.AdminTX) error { return tx.HardDeleteTree(ctx, id) },
+			wantErr:   true,
+			wantState: trillian.TreeState_FROZEN,
+		},
+		{
+			desc:      "softDeleteFrozen",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.SoftDeleteTree(ctx, id); return err },
+			wantState: trillian.TreeState_SOFT_DELETED,
+		},
+		{
+			desc:      "hardDelete",
+			fn:        func(tx storage.AdminTX) error { return tx.HardDeleteTree(ctx, id) },
+			wantState: trillian.TreeState_HARD_DELETED,
+		},
+		{
+			desc:      "undeleteHardDeleted",
+			fn:        func(tx storage.AdminTX) error { _, err := tx.UndeleteTree(ctx, id); return err },
+			wantErr:   true,
+			wantState: trillian.TreeState_HARD_DELETED,
+		},
+	}
+	for _, step := range steps {
+		err := inAdminTX(ctx, s, step.fn)
+		if gotErr := err != nil; gotErr != step.wantErr {
+			t.Fatalf("%v: got err = %v, wantErr = %v", step.desc, err, step.wantErr)
+		}
+		got, err := getTree(ctx, s, id)
+		if err != nil {
+			t.Fatalf("%v: getTree() = (_, %v), want = (_, nil)", step.desc, err)
+		}
+		if got.TreeState != step.wantState {
+			t.Errorf("%v: TreeState = %s, want = %s", step.desc, got.TreeState, step.wantState)
+		}
+		if wantDeleteTime := storage.IsDeleted(step.wantState); (got.DeleteTime != nil) != wantDeleteTime {
+			t.Errorf("%v: DeleteTime = %v, want set = %v", step.desc, got.DeleteTime, wantDeleteTime)
+		}
+	}
+}
+
+// TestKeyRotation tests rotating and retiring the key of a tree.
+func (tester *AdminStorageTester) TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	tree, err := createTree(ctx, s, LogTree)
+	if err != nil {
+		t.Fatalf("createTree() = (_, %v), want = (_, nil)", err)
+	}
+	id := tree.TreeId
+	rotation := func(size int64) *trillian.KeyRotation {
+		return &trillian.KeyRotation{PrivateKey: LogTree.PrivateKey, PublicKey: LogTree.PublicKey, ActivationTreeSize: size}
+	}
+	rotate := func(kr *trillian.KeyRotation) func(storage.AdminTX) error {
+		return func(tx storage.AdminTX) error { _, err := tx.RotateTreeKey(ctx, id, kr); return err }
+	}
+	retire := func(tx storage.AdminTX) error { _, err := tx.RetireTreeKey(ctx, id); return err }
+
+	steps := []struct {
+		desc            string
+		fn              func(storage.AdminTX) error
+		wantErr         bool
+		wantRotation    *trillian.KeyRotation
+		wantHistorySize []int64
+	}{
+		{desc: "retireWithoutRotation", fn: retire, wantErr: true},
+		{desc: "rotateWithoutKey", fn: rotate(&trillian.KeyRotation{ActivationTreeSize: 10}), wantErr: true},
+		{desc: "rotateAtZero", fn: rotate(rotation(0)), wantErr: true},
+		{desc: "rotate", fn: rotate(rotation(10)), wantRotation: rotation(10)},
+		{desc: "reschedule", fn: rotate(rotation(15)), wantRotation: rotation(15)},
+		{desc: "retire", fn: retire, wantHistorySize: []int64{0, 15}},
+		{desc: "rotateAgain", fn: rotate(rotation(30)), wantRotation: rotation(30), wantHistorySize: []int64{0, 15}},
+		{desc: "retireAgain", fn: retire, wantHistorySize: []int64{0, 15, 15, 30}},
+	}
+	for _, step := range steps {
+		err := inAdminTX(ctx, s, step.fn)
+		if gotErr := err != nil; gotErr != step.wantErr {
+			t.Fatalf("%v: got err = %v, wantErr = %v", step.desc, err, step.wantErr)
+		}
+		got, err := getTree(ctx, s, id)
+		if err != nil {
+			t.Fatalf("%v: getTree() = (_, %v), want = (_, nil)", step.desc, err)
+		}
+		if !proto.Equal(got.KeyRotation, step.wantRotation) {
+			t.Errorf("%v: KeyRotation = %v, want = %v", step.desc, got.KeyRotation, step.wantRotation)
+		}
+		var gotHistorySize []int64
+		for _, rk := range got.KeyHistory {
+			if !proto.Equal(rk.PublicKey, LogTree.PublicKey) {
+				t.Errorf("%v: KeyHistory has public key %v, want %v", step.desc, rk.PublicKey, LogTree.PublicKey)
+			}
+			gotHistorySize = append(gotHistorySize, rk.StartTreeSize, rk.EndTreeSize)
+		}
+		if !reflect.DeepEqual(gotHistorySize, step.wantHistorySize) {
+			t.Errorf("%v: KeyHistory tree sizes = %v, want = %v", step.desc, gotHistorySize, step.wantHistorySize)
+		}
+	}
+}
+
+func inAdminTX(ctx context.Context, s storage.AdminStorage, f func(storage.AdminTX) error) error {
+	tx, err := s.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // TestAdminTXClose verifies the behavior of Close() with and without explicit Commit() / Rollback() calls.
 func (tester *AdminStorageTester) TestAdminTXClose(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,87 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The archive_tree binary exports a log to an archive file, or imports an archive file as a
+// new log. See the storage/archive package for the archive format.
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/extension"
+	_ "github.com/google/trillian/merkle/rfc6962" // Load hashers
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage/archive"
+	"github.com/google/trillian/storage/factory"
+	_ "github.com/google/trillian/storage/mysql" // Load MySQL storage
+)
+
+var (
+	storageSystem = flag.String("storage_system", "mysql", "Storage system to use, one of: "+strings.Join(factory.Systems(), ", "))
+	storageURI    = flag.String("storage_uri", "", "Connection URI for the storage system, empty means the storage system's default")
+	treeIDFlag    = flag.Int64("treeid", 0, "The tree id to export, zero means import instead")
+	fileFlag      = flag.String("file", "", "The archive file to write when exporting, or read when importing")
+)
+
+func main() {
+	flag.Parse()
+	if *fileFlag == "" {
+		log.Exit("--file is required")
+	}
+
+	sp, err := factory.NewProvider(*storageSystem, *storageURI, nil)
+	if err != nil {
+		log.Exitf("Failed to open %v storage: %v", *storageSystem, err)
+	}
+	defer sp.Close()
+	registry := extension.Registry{
+		AdminStorage:  sp.AdminStorage(),
+		LogStorage:    sp.LogStorage(),
+		SignerFactory: &keys.DefaultSignerFactory{},
+		QuotaManager:  quota.Noop(),
+	}
+	ctx := context.Background()
+
+	if *treeIDFlag != 0 {
+		f, err := os.Create(*fileFlag)
+		if err != nil {
+			log.Exitf("Failed to create archive: %v", err)
+		}
+		if _, err := io.Copy(f, archive.Export(ctx, registry, *treeIDFlag)); err != nil {
+			log.Exitf("Failed to export tree %v: %v", *treeIDFlag, err)
+		}
+		if err := f.Close(); err != nil {
+			log.Exitf("Failed to write archive: %v", err)
+		}
+		log.Infof("Exported tree %v to %v", *treeIDFlag, *fileFlag)
+		return
+	}
+
+	f, err := os.Open(*fileFlag)
+	if err != nil {
+		log.Exitf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	tree, err := archive.Import(ctx, registry, f)
+	if err != nil {
+		log.Exitf("Failed to import archive: %v", err)
+	}
+	log.Infof("Imported %v as tree %v", *fileFlag, tree.TreeId)
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// The compact_mysql binary reclaims the disk space left behind in MySQL storage by deleted
+// rows, such as those of pruned map revisions. Tables are rebuilt online one at a time, so
+// trees stay online. Interrupting it stops the compaction after the current table.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/google/trillian/storage/mysql"
+)
+
+var (
+	mySQLURI     = flag.String("mysql_uri", mysql.DefaultURI, "Connection URI for MySQL database")
+	tablesFlag   = flag.String("tables", strings.Join(mysql.DefaultCompactTables, ","), "Comma separated list of the tables to compact, in order")
+	minFreeBytes = flag.Int64("min_free_bytes", 64<<20, "Tables with less reclaimable space than this are skipped")
+)
+
+func main() {
+	flag.Parse()
+
+	db, err := mysql.OpenDB(*mySQLURI)
+	if err != nil {
+		log.Exitf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		log.Info("Interrupted, stopping after the current table")
+		cancel()
+	}()
+
+	opts := mysql.CompactOptions{
+		Tables:       strings.Split(*tablesFlag, ","),
+		MinFreeBytes: *minFreeBytes,
+		Progress: func(table string, freeBytes int64) {
+			log.Infof("Compacting %v, %d bytes reclaimable", table, freeBytes)
+		},
+	}
+	done, err := mysql.Compact(ctx, db, opts)
+	log.Infof("Compacted %d tables: %v", len(done), done)
+	if err != nil {
+		log.Exitf("Compaction stopped: %v", err)
+	}
+}
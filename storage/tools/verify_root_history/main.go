@@ -0,0 +1,108 @@
+// Copyright 2017 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The verify_root_history binary checks that every stored signed root of a log is consistent
+// with the root before it, i.e. that the log has never forked. See the storage/roothistory
+// package.
+//
+// If --checkpoint_file is set, the last root found to be consistent is saved there as it goes,
+// and a later run resumes after it.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	_ "github.com/google/trillian/merkle/rfc6962" // Load hashers
+	"github.com/google/trillian/storage/factory"
+	_ "github.com/google/trillian/storage/mysql" // Load MySQL storage
+	"github.com/google/trillian/storage/roothistory"
+)
+
+var (
+	storageSystem      = flag.String("storage_system", "mysql", "Storage system to use, one of: "+strings.Join(factory.Systems(), ", "))
+	storageURI         = flag.String("storage_uri", "", "Connection URI for the storage system, empty means the storage system's default")
+	treeIDFlag         = flag.Int64("treeid", 0, "The tree id of the log to verify")
+	checkpointFile     = flag.String("checkpoint_file", "", "File to resume from and save progress to, empty means the whole history is verified every time")
+	batchSize          = flag.Int("batch_size", 1000, "Number of roots read from storage at a time")
+	maxChecksPerSecond = flag.Float64("max_checks_per_second", 0, "Maximum number of consistency checks per second, zero means no limit")
+)
+
+func main() {
+	flag.Parse()
+	if *treeIDFlag == 0 {
+		log.Exit("--treeid is required")
+	}
+
+	sp, err := factory.NewProvider(*storageSystem, *storageURI, nil)
+	if err != nil {
+		log.Exitf("Failed to open %v storage: %v", *storageSystem, err)
+	}
+	defer sp.Close()
+	registry := extension.Registry{
+		AdminStorage: sp.AdminStorage(),
+		LogStorage:   sp.LogStorage(),
+	}
+
+	var start trillian.SignedLogRoot
+	opts := roothistory.Options{
+		BatchSize:          *batchSize,
+		MaxChecksPerSecond: *maxChecksPerSecond,
+	}
+	if *checkpointFile != "" {
+		if start, err = readCheckpoint(*checkpointFile); err != nil {
+			log.Exitf("Failed to read checkpoint: %v", err)
+		}
+		opts.Checkpoint = func(root trillian.SignedLogRoot) error {
+			return writeCheckpoint(*checkpointFile, root)
+		}
+	}
+
+	last, err := roothistory.Verify(context.Background(), registry, *treeIDFlag, start, opts)
+	if err != nil {
+		log.Exitf("Failed to verify tree %v: %v", *treeIDFlag, err)
+	}
+	log.Infof("Roots of tree %v up to revision %v (size %v) are consistent", *treeIDFlag, last.TreeRevision, last.TreeSize)
+}
+
+// readCheckpoint reads the root saved by writeCheckpoint, or returns an empty root if there's
+// no checkpoint yet.
+func readCheckpoint(path string) (trillian.SignedLogRoot, error) {
+	var root trillian.SignedLogRoot
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return root, nil
+	} else if err != nil {
+		return root, err
+	}
+	err = proto.UnmarshalText(string(data), &root)
+	return root, err
+}
+
+// writeCheckpoint saves root in text format. The file is replaced atomically, so an
+// interrupted run never leaves a corrupt checkpoint behind.
+func writeCheckpoint(path string, root trillian.SignedLogRoot) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(proto.MarshalTextString(&root)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
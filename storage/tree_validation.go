@@ -16,6 +16,8 @@ package storage
 
 import (
 	"crypto/x509"
+	"strings"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
@@ -24,8 +26,13 @@ import (
 )
 
 const (
-	maxDisplayNameLength = 20
-	maxDescriptionLength = 200
+	maxDisplayNameLength      = 20
+	maxDescriptionLength      = 200
+	maxWitnessNameLength      = 50
+	maxRootMetadataHookLength = 50
+	maxCheckpointOriginLength = 255
+	maxUnsequencedBuckets     = 256
+	maxPrincipalLength        = 255
 )
 
 // ValidateTreeForCreation returns nil if tree is valid for insertion, error
@@ -50,6 +57,12 @@ func ValidateTreeForCreation(tree *trillian.Tree) error {
 		return errors.New(errors.InvalidArgument, "a private_key is required")
 	case tree.PublicKey == nil:
 		return errors.New(errors.InvalidArgument, "a public_key is required")
+	case tree.MapLeafHashing != trillian.MapLeafHashing_SERVER_HASHED_LEAVES && tree.TreeType != trillian.TreeType_MAP:
+		return errors.Errorf(errors.InvalidArgument, "map_leaf_hashing not allowed for %s trees: %s", tree.TreeType, tree.MapLeafHashing)
+	case tree.KeyRotation != nil:
+		return errors.New(errors.InvalidArgument, "key_rotation not allowed for new trees")
+	case len(tree.KeyHistory) != 0:
+		return errors.New(errors.InvalidArgument, "key_history not allowed for new trees")
 	}
 
 	// Check that the private_key proto contains a valid serialized proto.
@@ -65,6 +78,47 @@ func ValidateTreeForCreation(tree *trillian.Tree) error {
 		return errors.Errorf(errors.InvalidArgument, "invalid public_key: %v", err)
 	}
 
+	if origin := tree.CheckpointOrigin; origin != "" {
+		switch {
+		case tree.TreeType != trillian.TreeType_LOG:
+			return errors.Errorf(errors.InvalidArgument, "checkpoint_origin not allowed for %s trees", tree.TreeType)
+		case len(origin) > maxCheckpointOriginLength:
+			return errors.Errorf(errors.InvalidArgument, "checkpoint_origin too big, max length is %v: %v", maxCheckpointOriginLength, origin)
+		case strings.Contains(origin, "\n"):
+			return errors.Errorf(errors.InvalidArgument, "checkpoint_origin must be a single line: %q", origin)
+		}
+	}
+
+	if w := tree.DedupWindow; w != nil {
+		if tree.TreeType != trillian.TreeType_LOG {
+			return errors.Errorf(errors.InvalidArgument, "dedup_window not allowed for %s trees", tree.TreeType)
+		}
+		if w.MaxLeaves < 0 {
+			return errors.Errorf(errors.InvalidArgument, "dedup_window.max_leaves negative: %v", w.MaxLeaves)
+		}
+		var maxAge time.Duration
+		if w.MaxAge != nil {
+			var err error
+			if maxAge, err = ptypes.Duration(w.MaxAge); err != nil {
+				return errors.Errorf(errors.InvalidArgument, "dedup_window.max_age malformed: %v", w.MaxAge)
+			} else if maxAge < 0 {
+				return errors.Errorf(errors.InvalidArgument, "dedup_window.max_age negative: %v", w.MaxAge)
+			}
+		}
+		if w.MaxLeaves == 0 && maxAge == 0 {
+			return errors.New(errors.InvalidArgument, "dedup_window must set max_leaves or max_age")
+		}
+	}
+
+	if b := tree.UnsequencedBuckets; b != 0 {
+		switch {
+		case tree.TreeType != trillian.TreeType_LOG:
+			return errors.Errorf(errors.InvalidArgument, "unsequenced_buckets not allowed for %s trees", tree.TreeType)
+		case b < 0 || b > maxUnsequencedBuckets:
+			return errors.Errorf(errors.InvalidArgument, "unsequenced_buckets out of range, must be between 0 and %v: %v", maxUnsequencedBuckets, b)
+		}
+	}
+
 	return validateMutableTreeFields(tree)
 }
 
@@ -96,6 +150,26 @@ func ValidateTreeForUpdate(storedTree, newTree *trillian.Tree) error {
 		return errors.New(errors.InvalidArgument, "readonly field changed: private_key")
 	case storedTree.PublicKey != newTree.PublicKey:
 		return errors.New(errors.InvalidArgument, "readonly field changed: public_key")
+	case storedTree.MapLeafHashing != newTree.MapLeafHashing:
+		return errors.New(errors.InvalidArgument, "readonly field changed: map_leaf_hashing")
+	case storedTree.CheckpointOrigin != newTree.CheckpointOrigin:
+		// The origin is part of the signed checkpoints of the log.
+		return errors.New(errors.InvalidArgument, "readonly field changed: checkpoint_origin")
+	case storedTree.DedupWindow != newTree.DedupWindow:
+		// Identity hashes are only indexed for deduplication in trees with a window.
+		return errors.New(errors.InvalidArgument, "readonly field changed: dedup_window")
+	case storedTree.UnsequencedBuckets != newTree.UnsequencedBuckets:
+		// Queued leaves are stored in the bucket they were hashed to.
+		return errors.New(errors.InvalidArgument, "readonly field changed: unsequenced_buckets")
+	case storedTree.DeleteTime != newTree.DeleteTime:
+		return errors.New(errors.InvalidArgument, "readonly field changed: delete_time")
+	case storedTree.KeyRotation != newTree.KeyRotation:
+		return errors.New(errors.InvalidArgument, "readonly field changed: key_rotation")
+	case !sameKeyHistory(storedTree.KeyHistory, newTree.KeyHistory):
+		return errors.New(errors.InvalidArgument, "readonly field changed: key_history")
+	case IsDeleted(storedTree.TreeState) != IsDeleted(newTree.TreeState):
+		// Trees are deleted and undeleted through their own methods, which manage delete_time.
+		return errors.Errorf(errors.InvalidArgument, "tree_state can't be changed from %s to %s by an update", storedTree.TreeState, newTree.TreeState)
 	}
 	return validateMutableTreeFields(newTree)
 }
@@ -114,6 +188,138 @@ func validateMutableTreeFields(tree *trillian.Tree) error {
 	} else if duration < 0 {
 		return errors.Errorf(errors.InvalidArgument, "max_root_duration negative: %v", tree.MaxRootDuration)
 	}
+	if tree.MaxClientTimestampSkew != nil {
+		if skew, err := ptypes.Duration(tree.MaxClientTimestampSkew); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "max_client_timestamp_skew malformed: %v", tree.MaxClientTimestampSkew)
+		} else if skew < 0 {
+			return errors.Errorf(errors.InvalidArgument, "max_client_timestamp_skew negative: %v", tree.MaxClientTimestampSkew)
+		}
+	}
+
+	if tree.DrainGracePeriod != nil {
+		if d, err := ptypes.Duration(tree.DrainGracePeriod); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "drain_grace_period malformed: %v", tree.DrainGracePeriod)
+		} else if d < 0 {
+			return errors.Errorf(errors.InvalidArgument, "drain_grace_period negative: %v", tree.DrainGracePeriod)
+		}
+	}
+	switch {
+	case tree.TreeState == trillian.TreeState_DRAINING && tree.DrainDeadline == nil:
+		return errors.Errorf(errors.InvalidArgument, "drain_deadline required for %s trees", tree.TreeState)
+	case tree.TreeState != trillian.TreeState_DRAINING && tree.DrainDeadline != nil:
+		return errors.Errorf(errors.InvalidArgument, "drain_deadline not allowed for %s trees", tree.TreeState)
+	}
+	if tree.DrainDeadline != nil {
+		if _, err := ptypes.Timestamp(tree.DrainDeadline); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "drain_deadline malformed: %v", tree.DrainDeadline)
+		}
+	}
+	switch {
+	case IsDeleted(tree.TreeState) && tree.DeleteTime == nil:
+		return errors.Errorf(errors.InvalidArgument, "delete_time required for %s trees", tree.TreeState)
+	case !IsDeleted(tree.TreeState) && tree.DeleteTime != nil:
+		return errors.Errorf(errors.InvalidArgument, "delete_time not allowed for %s trees", tree.TreeState)
+	}
+
+	if rr := tree.RootRetention; rr != nil {
+		if rr.KeepCount < 0 {
+			return errors.Errorf(errors.InvalidArgument, "root_retention.keep_count negative: %v", rr.KeepCount)
+		}
+		if rr.KeepDuration != nil {
+			if d, err := ptypes.Duration(rr.KeepDuration); err != nil {
+				return errors.Errorf(errors.InvalidArgument, "root_retention.keep_duration malformed: %v", rr.KeepDuration)
+			} else if d < 0 {
+				return errors.Errorf(errors.InvalidArgument, "root_retention.keep_duration negative: %v", rr.KeepDuration)
+			}
+		}
+	}
+
+	if hook := tree.RootMetadataHook; hook != "" {
+		switch {
+		case tree.TreeType != trillian.TreeType_LOG:
+			return errors.Errorf(errors.InvalidArgument, "root_metadata_hook not allowed for %s trees: %v", tree.TreeType, hook)
+		case len(hook) > maxRootMetadataHookLength:
+			return errors.Errorf(errors.InvalidArgument, "root_metadata_hook too big, max length is %v: %v", maxRootMetadataHookLength, hook)
+		}
+	}
+
+	if p := tree.DeadLetterPolicy; p != nil {
+		switch {
+		case tree.TreeType != trillian.TreeType_LOG:
+			return errors.Errorf(errors.InvalidArgument, "dead_letter_policy not allowed for %s trees", tree.TreeType)
+		case p.MaxAttempts <= 0:
+			return errors.Errorf(errors.InvalidArgument, "dead_letter_policy.max_attempts must be positive: %v", p.MaxAttempts)
+		}
+	}
+
+	if lookback := tree.MaxRevisionLookback; lookback != 0 {
+		switch {
+		case tree.TreeType != trillian.TreeType_MAP:
+			return errors.Errorf(errors.InvalidArgument, "max_revision_lookback not allowed for %s trees", tree.TreeType)
+		case lookback < 0:
+			return errors.Errorf(errors.InvalidArgument, "max_revision_lookback must not be negative: %v", lookback)
+		}
+	}
+
+	for _, key := range tree.AdditionalPublicKeys {
+		if _, err := x509.ParsePKIXPublicKey(key.GetDer()); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "invalid additional_public_keys: %v", err)
+		}
+	}
+
+	if tree.VerifyLeafIdentityHash && tree.TreeType != trillian.TreeType_LOG {
+		return errors.Errorf(errors.InvalidArgument, "verify_leaf_identity_hash not allowed for %s trees", tree.TreeType)
+	}
+
+	if ss := tree.SecondarySigner; ss != nil {
+		switch {
+		case tree.TreeType != trillian.TreeType_LOG:
+			return errors.Errorf(errors.InvalidArgument, "secondary_signer not allowed for %s trees", tree.TreeType)
+		case ss.SignatureAlgorithm == sigpb.DigitallySigned_ANONYMOUS:
+			return errors.Errorf(errors.InvalidArgument, "invalid secondary_signer.signature_algorithm: %s", ss.SignatureAlgorithm)
+		case ss.SignatureAlgorithm == tree.SignatureAlgorithm:
+			return errors.Errorf(errors.InvalidArgument, "secondary_signer.signature_algorithm must differ from signature_algorithm: %s", ss.SignatureAlgorithm)
+		case ss.PrivateKey == nil:
+			return errors.New(errors.InvalidArgument, "a secondary_signer.private_key is required")
+		}
+		var privateKey ptypes.DynamicAny
+		if err := ptypes.UnmarshalAny(ss.PrivateKey, &privateKey); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "invalid secondary_signer.private_key: %v", err)
+		}
+		if _, err := x509.ParsePKIXPublicKey(ss.PublicKey.GetDer()); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "invalid secondary_signer.public_key: %v", err)
+		}
+	}
+
+	if err := ValidateKeyRotation(tree); err != nil {
+		return err
+	}
+
+	witnessNames := make(map[string]bool)
+	for _, witness := range tree.Witnesses {
+		name := witness.GetName()
+		switch {
+		case name == "":
+			return errors.New(errors.InvalidArgument, "witnesses must have a name")
+		case len(name) > maxWitnessNameLength:
+			return errors.Errorf(errors.InvalidArgument, "witness name too big, max length is %v: %v", maxWitnessNameLength, name)
+		case witnessNames[name]:
+			return errors.Errorf(errors.InvalidArgument, "duplicate witness: %v", name)
+		}
+		if _, err := x509.ParsePKIXPublicKey(witness.GetPublicKey().GetDer()); err != nil {
+			return errors.Errorf(errors.InvalidArgument, "invalid public_key for witness %v: %v", name, err)
+		}
+		witnessNames[name] = true
+	}
+
+	if p := tree.AccessPolicy; p != nil {
+		if err := validatePrincipals("access_policy.owners", p.Owners); err != nil {
+			return err
+		}
+		if err := validatePrincipals("access_policy.readers", p.Readers); err != nil {
+			return err
+		}
+	}
 
 	// Implementations may vary, so let's assume storage_settings is mutable.
 	// Other than checking that it's a valid Any there isn't much to do at this layer, though.
@@ -126,3 +332,80 @@ func validateMutableTreeFields(tree *trillian.Tree) error {
 
 	return nil
 }
+
+// validatePrincipals returns an error if any of principals, which is the named field of an
+// AccessPolicy, is empty or too big.
+func validatePrincipals(field string, principals []string) error {
+	for _, p := range principals {
+		switch {
+		case p == "":
+			return errors.Errorf(errors.InvalidArgument, "%v must not contain empty principals", field)
+		case len(p) > maxPrincipalLength:
+			return errors.Errorf(errors.InvalidArgument, "%v principal too big, max length is %v: %v", field, maxPrincipalLength, p)
+		}
+	}
+	return nil
+}
+
+// ValidateKeyRotation returns nil if the key_rotation of tree, if any, is valid, error
+// otherwise.
+func ValidateKeyRotation(tree *trillian.Tree) error {
+	r := tree.KeyRotation
+	if r == nil {
+		return nil
+	}
+	switch {
+	case tree.TreeType != trillian.TreeType_LOG:
+		return errors.Errorf(errors.InvalidArgument, "key_rotation not allowed for %s trees", tree.TreeType)
+	case r.PrivateKey == nil:
+		return errors.New(errors.InvalidArgument, "a key_rotation.private_key is required")
+	case r.ActivationTreeSize <= 0:
+		return errors.Errorf(errors.InvalidArgument, "key_rotation.activation_tree_size must be positive: %v", r.ActivationTreeSize)
+	}
+	var privateKey ptypes.DynamicAny
+	if err := ptypes.UnmarshalAny(r.PrivateKey, &privateKey); err != nil {
+		return errors.Errorf(errors.InvalidArgument, "invalid key_rotation.private_key: %v", err)
+	}
+	if _, err := x509.ParsePKIXPublicKey(r.PublicKey.GetDer()); err != nil {
+		return errors.Errorf(errors.InvalidArgument, "invalid key_rotation.public_key: %v", err)
+	}
+	return nil
+}
+
+// RetireKey returns the key_history of tree after its current key is retired in favor of the
+// key of its key_rotation, which must be set. The current key is recorded as having signed the
+// roots since the previous key in the history was retired, up to the rotation's activation
+// size.
+func RetireKey(tree *trillian.Tree) []*trillian.RetiredKey {
+	var start int64
+	if n := len(tree.KeyHistory); n > 0 {
+		start = tree.KeyHistory[n-1].EndTreeSize
+	}
+	history := make([]*trillian.RetiredKey, 0, len(tree.KeyHistory)+1)
+	history = append(history, tree.KeyHistory...)
+	return append(history, &trillian.RetiredKey{
+		PublicKey:     tree.PublicKey,
+		StartTreeSize: start,
+		EndTreeSize:   tree.KeyRotation.ActivationTreeSize,
+	})
+}
+
+// sameKeyHistory returns true if a and b are the same key history, rather than equal copies.
+// Like the other readonly fields, an unchanged key history is expected to be shared by the
+// stored and the updated tree.
+func sameKeyHistory(a, b []*trillian.RetiredKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDeleted returns true if state is one of the states of deleted trees.
+func IsDeleted(state trillian.TreeState) bool {
+	return state == trillian.TreeState_SOFT_DELETED || state == trillian.TreeState_HARD_DELETED
+}
@@ -16,6 +16,7 @@ package storage
 
 import (
 	"encoding/pem"
+	"strings"
 	"testing"
 	"time"
 
@@ -96,6 +97,97 @@ func TestValidateTreeForCreation(t *testing.T) {
 	invalidRootDuration := newTree()
 	invalidRootDuration.MaxRootDuration = ptypes.DurationProto(-1 * time.Second)
 
+	clientHashedMap := newTree()
+	clientHashedMap.TreeType = trillian.TreeType_MAP
+	clientHashedMap.MapLeafHashing = trillian.MapLeafHashing_CLIENT_HASHED_LEAVES
+
+	clientHashedLog := newTree()
+	clientHashedLog.MapLeafHashing = trillian.MapLeafHashing_CLIENT_HASHED_LEAVES
+
+	logMetadataHook := newTree()
+	logMetadataHook.RootMetadataHook = "epoch"
+
+	mapMetadataHook := newTree()
+	mapMetadataHook.TreeType = trillian.TreeType_MAP
+	mapMetadataHook.RootMetadataHook = "epoch"
+
+	mapDeadLetterPolicy := newTree()
+	mapDeadLetterPolicy.TreeType = trillian.TreeType_MAP
+	mapDeadLetterPolicy.DeadLetterPolicy = &trillian.DeadLetterPolicy{MaxAttempts: 3}
+
+	mapRevisionLookback := newTree()
+	mapRevisionLookback.TreeType = trillian.TreeType_MAP
+	mapRevisionLookback.MaxRevisionLookback = 10
+
+	negativeRevisionLookback := newTree()
+	negativeRevisionLookback.TreeType = trillian.TreeType_MAP
+	negativeRevisionLookback.MaxRevisionLookback = -1
+
+	logRevisionLookback := newTree()
+	logRevisionLookback.MaxRevisionLookback = 10
+
+	checkpointOrigin := newTree()
+	checkpointOrigin.CheckpointOrigin = "example.com/log"
+
+	mapCheckpointOrigin := newTree()
+	mapCheckpointOrigin.TreeType = trillian.TreeType_MAP
+	mapCheckpointOrigin.CheckpointOrigin = "example.com/map"
+
+	multilineCheckpointOrigin := newTree()
+	multilineCheckpointOrigin.CheckpointOrigin = "example.com/log\n42"
+
+	longCheckpointOrigin := newTree()
+	longCheckpointOrigin.CheckpointOrigin = strings.Repeat("o", maxCheckpointOriginLength+1)
+
+	mapSecondarySigner := newTree()
+	mapSecondarySigner.TreeType = trillian.TreeType_MAP
+	mapSecondarySigner.SecondarySigner = newSecondarySigner(mapSecondarySigner)
+
+	mapVerifyLeafIdentityHash := newTree()
+	mapVerifyLeafIdentityHash.TreeType = trillian.TreeType_MAP
+	mapVerifyLeafIdentityHash.VerifyLeafIdentityHash = true
+
+	dedupWindow := newTree()
+	dedupWindow.DedupWindow = &trillian.DedupWindow{MaxLeaves: 1000, MaxAge: ptypes.DurationProto(time.Hour)}
+
+	emptyDedupWindow := newTree()
+	emptyDedupWindow.DedupWindow = &trillian.DedupWindow{}
+
+	negativeDedupWindow := newTree()
+	negativeDedupWindow.DedupWindow = &trillian.DedupWindow{MaxLeaves: -1, MaxAge: ptypes.DurationProto(time.Hour)}
+
+	mapDedupWindow := newTree()
+	mapDedupWindow.TreeType = trillian.TreeType_MAP
+	mapDedupWindow.DedupWindow = &trillian.DedupWindow{MaxLeaves: 1000}
+
+	unsequencedBuckets := newTree()
+	unsequencedBuckets.UnsequencedBuckets = 16
+
+	tooManyUnsequencedBuckets := newTree()
+	tooManyUnsequencedBuckets.UnsequencedBuckets = maxUnsequencedBuckets + 1
+
+	negativeUnsequencedBuckets := newTree()
+	negativeUnsequencedBuckets.UnsequencedBuckets = -1
+
+	mapUnsequencedBuckets := newTree()
+	mapUnsequencedBuckets.TreeType = trillian.TreeType_MAP
+	mapUnsequencedBuckets.UnsequencedBuckets = 16
+
+	accessPolicy := newTree()
+	accessPolicy.AccessPolicy = &trillian.AccessPolicy{Owners: []string{"alice"}, Readers: []string{"bob"}, PublicRead: true}
+
+	emptyOwner := newTree()
+	emptyOwner.AccessPolicy = &trillian.AccessPolicy{Owners: []string{""}}
+
+	longReader := newTree()
+	longReader.AccessPolicy = &trillian.AccessPolicy{Readers: []string{strings.Repeat("a", maxPrincipalLength+1)}}
+
+	keyRotation := newTree()
+	keyRotation.KeyRotation = &trillian.KeyRotation{PrivateKey: keyRotation.PrivateKey, PublicKey: keyRotation.PublicKey, ActivationTreeSize: 10}
+
+	keyHistory := newTree()
+	keyHistory.KeyHistory = []*trillian.RetiredKey{{PublicKey: keyHistory.PublicKey, EndTreeSize: 10}}
+
 	tests := []struct {
 		desc    string
 		tree    *trillian.Tree
@@ -198,6 +290,134 @@ func TestValidateTreeForCreation(t *testing.T) {
 			desc: "validSettings",
 			tree: validSettings,
 		},
+		{
+			desc: "clientHashedMap",
+			tree: clientHashedMap,
+		},
+		{
+			desc:    "clientHashedLog",
+			tree:    clientHashedLog,
+			wantErr: true,
+		},
+		{
+			desc: "logMetadataHook",
+			tree: logMetadataHook,
+		},
+		{
+			desc:    "mapMetadataHook",
+			tree:    mapMetadataHook,
+			wantErr: true,
+		},
+		{
+			desc:    "mapDeadLetterPolicy",
+			tree:    mapDeadLetterPolicy,
+			wantErr: true,
+		},
+		{
+			desc: "mapRevisionLookback",
+			tree: mapRevisionLookback,
+		},
+		{
+			desc:    "negativeRevisionLookback",
+			tree:    negativeRevisionLookback,
+			wantErr: true,
+		},
+		{
+			desc:    "logRevisionLookback",
+			tree:    logRevisionLookback,
+			wantErr: true,
+		},
+		{
+			desc: "checkpointOrigin",
+			tree: checkpointOrigin,
+		},
+		{
+			desc:    "mapCheckpointOrigin",
+			tree:    mapCheckpointOrigin,
+			wantErr: true,
+		},
+		{
+			desc:    "multilineCheckpointOrigin",
+			tree:    multilineCheckpointOrigin,
+			wantErr: true,
+		},
+		{
+			desc:    "longCheckpointOrigin",
+			tree:    longCheckpointOrigin,
+			wantErr: true,
+		},
+		{
+			desc:    "mapSecondarySigner",
+			tree:    mapSecondarySigner,
+			wantErr: true,
+		},
+		{
+			desc:    "mapVerifyLeafIdentityHash",
+			tree:    mapVerifyLeafIdentityHash,
+			wantErr: true,
+		},
+		{
+			desc: "dedupWindow",
+			tree: dedupWindow,
+		},
+		{
+			desc:    "emptyDedupWindow",
+			tree:    emptyDedupWindow,
+			wantErr: true,
+		},
+		{
+			desc:    "negativeDedupWindow",
+			tree:    negativeDedupWindow,
+			wantErr: true,
+		},
+		{
+			desc:    "mapDedupWindow",
+			tree:    mapDedupWindow,
+			wantErr: true,
+		},
+		{
+			desc: "unsequencedBuckets",
+			tree: unsequencedBuckets,
+		},
+		{
+			desc:    "tooManyUnsequencedBuckets",
+			tree:    tooManyUnsequencedBuckets,
+			wantErr: true,
+		},
+		{
+			desc:    "negativeUnsequencedBuckets",
+			tree:    negativeUnsequencedBuckets,
+			wantErr: true,
+		},
+		{
+			desc:    "mapUnsequencedBuckets",
+			tree:    mapUnsequencedBuckets,
+			wantErr: true,
+		},
+		{
+			desc: "accessPolicy",
+			tree: accessPolicy,
+		},
+		{
+			desc:    "emptyOwner",
+			tree:    emptyOwner,
+			wantErr: true,
+		},
+		{
+			desc:    "longReader",
+			tree:    longReader,
+			wantErr: true,
+		},
+		{
+			desc:    "keyRotation",
+			tree:    keyRotation,
+			wantErr: true,
+		},
+		{
+			desc:    "keyHistory",
+			tree:    keyHistory,
+			wantErr: true,
+		},
 		{
 			desc:    "nilRootDuration",
 			tree:    nilRootDuration,
@@ -269,7 +489,225 @@ func TestValidateTreeForUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			desc: "validClientTimestampSkew",
+			updatefn: func(tree *trillian.Tree) {
+				tree.MaxClientTimestampSkew = ptypes.DurationProto(5 * time.Minute)
+			},
+		},
+		{
+			desc: "invalidClientTimestampSkew",
+			updatefn: func(tree *trillian.Tree) {
+				tree.MaxClientTimestampSkew = ptypes.DurationProto(-5 * time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validWitnesses",
+			updatefn: func(tree *trillian.Tree) {
+				tree.Witnesses = []*trillian.Witness{
+					{Name: "witness1", PublicKey: tree.PublicKey},
+					{Name: "witness2", PublicKey: tree.PublicKey},
+				}
+			},
+		},
+		{
+			desc: "unnamedWitness",
+			updatefn: func(tree *trillian.Tree) {
+				tree.Witnesses = []*trillian.Witness{{PublicKey: tree.PublicKey}}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "duplicateWitness",
+			updatefn: func(tree *trillian.Tree) {
+				tree.Witnesses = []*trillian.Witness{
+					{Name: "witness", PublicKey: tree.PublicKey},
+					{Name: "witness", PublicKey: tree.PublicKey},
+				}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "invalidWitnessPublicKey",
+			updatefn: func(tree *trillian.Tree) {
+				tree.Witnesses = []*trillian.Witness{{Name: "witness", PublicKey: &keyspb.PublicKey{Der: []byte("foobar")}}}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validDrainGracePeriod",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DrainGracePeriod = ptypes.DurationProto(time.Hour)
+			},
+		},
+		{
+			desc: "invalidDrainGracePeriod",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DrainGracePeriod = ptypes.DurationProto(-time.Hour)
+			},
+			wantErr: true,
+		},
+		{
+			desc: "draining",
+			updatefn: func(tree *trillian.Tree) {
+				tree.TreeState = trillian.TreeState_DRAINING
+				tree.DrainDeadline = ptypes.TimestampNow()
+			},
+		},
+		{
+			desc: "drainingWithoutDeadline",
+			updatefn: func(tree *trillian.Tree) {
+				tree.TreeState = trillian.TreeState_DRAINING
+			},
+			wantErr: true,
+		},
+		{
+			desc: "deadlineWithoutDraining",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DrainDeadline = ptypes.TimestampNow()
+			},
+			wantErr: true,
+		},
+		{
+			desc: "softDeleted",
+			updatefn: func(tree *trillian.Tree) {
+				tree.TreeState = trillian.TreeState_SOFT_DELETED
+			},
+			wantErr: true,
+		},
+		{
+			desc: "deleteTimeChanged",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DeleteTime = ptypes.TimestampNow()
+			},
+			wantErr: true,
+		},
+		{
+			desc: "keyRotationChanged",
+			updatefn: func(tree *trillian.Tree) {
+				tree.KeyRotation = &trillian.KeyRotation{PrivateKey: tree.PrivateKey, PublicKey: tree.PublicKey, ActivationTreeSize: 10}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "keyHistoryChanged",
+			updatefn: func(tree *trillian.Tree) {
+				tree.KeyHistory = []*trillian.RetiredKey{{PublicKey: tree.PublicKey, EndTreeSize: 10}}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "checkpointOriginChanged",
+			updatefn: func(tree *trillian.Tree) {
+				tree.CheckpointOrigin = "example.com/log"
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validAdditionalPublicKeys",
+			updatefn: func(tree *trillian.Tree) {
+				tree.AdditionalPublicKeys = []*keyspb.PublicKey{tree.PublicKey}
+			},
+		},
+		{
+			desc: "invalidAdditionalPublicKey",
+			updatefn: func(tree *trillian.Tree) {
+				tree.AdditionalPublicKeys = []*keyspb.PublicKey{tree.PublicKey, {Der: []byte("foobar")}}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validSecondarySigner",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SecondarySigner = newSecondarySigner(tree)
+			},
+		},
+		{
+			desc: "secondarySignerSameAlgorithm",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SecondarySigner = newSecondarySigner(tree)
+				tree.SecondarySigner.SignatureAlgorithm = tree.SignatureAlgorithm
+			},
+			wantErr: true,
+		},
+		{
+			desc: "secondarySignerWithoutPrivateKey",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SecondarySigner = newSecondarySigner(tree)
+				tree.SecondarySigner.PrivateKey = nil
+			},
+			wantErr: true,
+		},
+		{
+			desc: "secondarySignerInvalidPublicKey",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SecondarySigner = newSecondarySigner(tree)
+				tree.SecondarySigner.PublicKey = &keyspb.PublicKey{Der: []byte("foobar")}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validRootRetention",
+			updatefn: func(tree *trillian.Tree) {
+				tree.RootRetention = &trillian.RootRetention{KeepCount: 100, KeepDuration: ptypes.DurationProto(24 * time.Hour)}
+			},
+		},
+		{
+			desc: "negativeRootRetentionCount",
+			updatefn: func(tree *trillian.Tree) {
+				tree.RootRetention = &trillian.RootRetention{KeepCount: -1}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "negativeRootRetentionDuration",
+			updatefn: func(tree *trillian.Tree) {
+				tree.RootRetention = &trillian.RootRetention{KeepDuration: ptypes.DurationProto(-time.Hour)}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validRootMetadataHook",
+			updatefn: func(tree *trillian.Tree) {
+				tree.RootMetadataHook = "epoch"
+			},
+		},
+		{
+			desc: "longRootMetadataHook",
+			updatefn: func(tree *trillian.Tree) {
+				tree.RootMetadataHook = "a-root-metadata-hook-with-a-name-that-is-much-too-long"
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validDeadLetterPolicy",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DeadLetterPolicy = &trillian.DeadLetterPolicy{MaxAttempts: 3}
+			},
+		},
+		{
+			desc: "zeroDeadLetterAttempts",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DeadLetterPolicy = &trillian.DeadLetterPolicy{}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "logRevisionLookback",
+			updatefn: func(tree *trillian.Tree) {
+				tree.MaxRevisionLookback = 10
+			},
+			wantErr: true,
+		},
 		// Changes on readonly fields
+		{
+			desc: "MapLeafHashing",
+			updatefn: func(tree *trillian.Tree) {
+				tree.MapLeafHashing = trillian.MapLeafHashing_CLIENT_HASHED_LEAVES
+			},
+			wantErr: true,
+		},
 		{
 			desc: "TreeId",
 			updatefn: func(tree *trillian.Tree) {
@@ -332,6 +770,20 @@ func TestValidateTreeForUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			desc: "DedupWindow",
+			updatefn: func(tree *trillian.Tree) {
+				tree.DedupWindow = &trillian.DedupWindow{MaxLeaves: 1000}
+			},
+			wantErr: true,
+		},
+		{
+			desc: "UnsequencedBuckets",
+			updatefn: func(tree *trillian.Tree) {
+				tree.UnsequencedBuckets = 16
+			},
+			wantErr: true,
+		},
 	}
 	for _, test := range tests {
 		tree := newTree()
@@ -376,3 +828,13 @@ func newTree() *trillian.Tree {
 		MaxRootDuration:    ptypes.DurationProto(1000 * time.Millisecond),
 	}
 }
+
+// newSecondarySigner returns a valid secondary signer for tree.
+// The key material is reused from tree, as validation doesn't check that keys match algorithms.
+func newSecondarySigner(tree *trillian.Tree) *trillian.SecondarySigner {
+	return &trillian.SecondarySigner{
+		SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+		PrivateKey:         tree.PrivateKey,
+		PublicKey:          tree.PublicKey,
+	}
+}
@@ -127,11 +127,12 @@ func NewLogEnvWithRegistry(ctx context.Context, numSequencers int, testID string
 	var sequencerTask *server.LogOperationManager
 	ctx, cancel := context.WithCancel(ctx)
 	info := server.LogOperationInfo{
-		Registry:    registry,
-		BatchSize:   batchSize,
-		NumWorkers:  numSequencers,
-		RunInterval: SequencerInterval,
-		TimeSource:  timeSource,
+		Registry:         registry,
+		BatchSize:        batchSize,
+		NumWorkers:       numSequencers,
+		RunInterval:      SequencerInterval,
+		TimeSource:       timeSource,
+		CheckConsistency: true,
 	}
 	// Start a live sequencer in a goroutine.
 	sequencerTask = server.NewLogOperationManager(info, sequencerManager)
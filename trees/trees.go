@@ -21,11 +21,14 @@ import (
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
 	"github.com/google/trillian"
 	tcrypto "github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/errors"
 	"github.com/google/trillian/storage"
@@ -57,7 +60,8 @@ type GetOpts struct {
 
 // GetTree returns the specified tree, either from the ctx (if present) or read from storage.
 // The tree will be validated according to GetOpts before returned. Tree state is also considered
-// (for example, deleted tree will return NotFound errors).
+// (for example, deleted trees will return FailedPrecondition errors, as opposed to the NotFound
+// errors returned by storage for unknown trees).
 func GetTree(ctx context.Context, s storage.AdminStorage, treeID int64, opts GetOpts) (*trillian.Tree, error) {
 	// TODO(codingllama): Record stats of ctx hits/misses, so we can assess whether RPCs work
 	// as intended.
@@ -76,7 +80,7 @@ func GetTree(ctx context.Context, s storage.AdminStorage, treeID int64, opts Get
 	case tree.TreeState == trillian.TreeState_FROZEN && !opts.Readonly:
 		return nil, errors.Errorf(errors.FailedPrecondition, "operation not allowed on %s trees", tree.TreeState)
 	case tree.TreeState == trillian.TreeState_SOFT_DELETED || tree.TreeState == trillian.TreeState_HARD_DELETED:
-		return nil, errors.Errorf(errors.NotFound, "deleted tree: %v", tree.TreeId)
+		return nil, errors.Errorf(errors.FailedPrecondition, "deleted tree: %v", tree.TreeId)
 	}
 
 	return tree, nil
@@ -98,6 +102,19 @@ func getTreeFromStorage(ctx context.Context, s storage.AdminStorage, treeID int6
 	return tree, nil
 }
 
+// DrainDeadlinePassed returns whether tree is DRAINING and its drain deadline isn't after now,
+// meaning it should no longer accept write requests.
+func DrainDeadlinePassed(tree *trillian.Tree, now time.Time) (bool, error) {
+	if tree.TreeState != trillian.TreeState_DRAINING {
+		return false, nil
+	}
+	deadline, err := ptypes.Timestamp(tree.DrainDeadline)
+	if err != nil {
+		return false, fmt.Errorf("invalid drain_deadline for tree %v: %v", tree.TreeId, err)
+	}
+	return !now.Before(deadline), nil
+}
+
 // Hash returns the crypto.Hash configured by the tree.
 func Hash(tree *trillian.Tree) (crypto.Hash, error) {
 	switch tree.HashAlgorithm {
@@ -110,8 +127,34 @@ func Hash(tree *trillian.Tree) (crypto.Hash, error) {
 
 // Signer returns a Trillian crypto.Signer configured by the tree.
 func Signer(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree) (*tcrypto.Signer, error) {
-	if tree.SignatureAlgorithm == sigpb.DigitallySigned_ANONYMOUS {
-		return nil, fmt.Errorf("signature algorithm not supported: %s", tree.SignatureAlgorithm)
+	return newSigner(ctx, sf, tree, tree.SignatureAlgorithm, tree.PrivateKey, "tree.PrivateKey")
+}
+
+// SecondarySigner returns a Trillian crypto.Signer configured by the tree's secondary signer, or
+// nil if the tree has none.
+func SecondarySigner(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree) (*tcrypto.Signer, error) {
+	ss := tree.SecondarySigner
+	if ss == nil {
+		return nil, nil
+	}
+	return newSigner(ctx, sf, tree, ss.SignatureAlgorithm, ss.PrivateKey, "tree.SecondarySigner.PrivateKey")
+}
+
+// RotationSigner returns a Trillian crypto.Signer configured by the tree's pending key rotation,
+// or nil if the tree has none. The rotation key uses the tree's signature algorithm.
+func RotationSigner(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree) (*tcrypto.Signer, error) {
+	kr := tree.KeyRotation
+	if kr == nil {
+		return nil, nil
+	}
+	return newSigner(ctx, sf, tree, tree.SignatureAlgorithm, kr.PrivateKey, "tree.KeyRotation.PrivateKey")
+}
+
+// newSigner returns a Trillian crypto.Signer for privateKey, using the tree's hash algorithm.
+// keyName identifies privateKey in errors.
+func newSigner(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree, sigAlgorithm sigpb.DigitallySigned_SignatureAlgorithm, privateKey *any.Any, keyName string) (*tcrypto.Signer, error) {
+	if sigAlgorithm == sigpb.DigitallySigned_ANONYMOUS {
+		return nil, fmt.Errorf("signature algorithm not supported: %s", sigAlgorithm)
 	}
 
 	hash, err := Hash(tree)
@@ -120,8 +163,8 @@ func Signer(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree) (*t
 	}
 
 	var keyProto ptypes.DynamicAny
-	if err := ptypes.UnmarshalAny(tree.PrivateKey, &keyProto); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal tree.PrivateKey: %v", err)
+	if err := ptypes.UnmarshalAny(privateKey, &keyProto); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %v: %v", keyName, err)
 	}
 
 	signer, err := sf.NewSigner(ctx, keyProto.Message)
@@ -132,16 +175,40 @@ func Signer(ctx context.Context, sf keys.SignerFactory, tree *trillian.Tree) (*t
 	var ok bool
 	switch signer.(type) {
 	case *ecdsa.PrivateKey:
-		ok = tree.SignatureAlgorithm == sigpb.DigitallySigned_ECDSA
+		ok = sigAlgorithm == sigpb.DigitallySigned_ECDSA
 	case *rsa.PrivateKey:
-		ok = tree.SignatureAlgorithm == sigpb.DigitallySigned_RSA
+		ok = sigAlgorithm == sigpb.DigitallySigned_RSA
 	default:
 		// TODO(codingllama): Make SignatureAlgorithm / key matching part of the SignerFactory contract?
 		// We don't know about custom signers, so let it pass
 		ok = true
 	}
 	if !ok {
-		return nil, fmt.Errorf("%s signature not supported by key of type %T", tree.SignatureAlgorithm, signer)
+		return nil, fmt.Errorf("%s signature not supported by key of type %T", sigAlgorithm, signer)
 	}
 	return &tcrypto.Signer{Hash: hash, Signer: signer}, nil
 }
+
+// VerifySignature verifies sig over data, which the tree is meant to have signed, against the
+// tree's public key and, failing that, against each of its additional public keys, the key of
+// its pending key rotation and the keys it retired. This lets signatures made by a previous key
+// keep verifying while the tree's key is rotated.
+func VerifySignature(tree *trillian.Tree, data []byte, sig *sigpb.DigitallySigned) error {
+	pubKeys := append([]*keyspb.PublicKey{tree.PublicKey}, tree.AdditionalPublicKeys...)
+	if kr := tree.KeyRotation; kr != nil {
+		pubKeys = append(pubKeys, kr.PublicKey)
+	}
+	for _, rk := range tree.KeyHistory {
+		pubKeys = append(pubKeys, rk.PublicKey)
+	}
+	for _, pubKey := range pubKeys {
+		pub, err := keys.NewFromPublicDER(pubKey.GetDer())
+		if err != nil {
+			return fmt.Errorf("failed to parse public key: %v", err)
+		}
+		if err := tcrypto.Verify(pub, data, sig); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature not verified by any of the %v public keys of tree %v", len(pubKeys), tree.TreeId)
+}
@@ -21,8 +21,10 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
@@ -30,6 +32,7 @@ import (
 	"github.com/google/trillian"
 	tcrypto "github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
+	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
@@ -209,6 +212,61 @@ func TestGetTree(t *testing.T) {
 	}
 }
 
+func TestDrainDeadlinePassed(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	deadline, err := ptypes.TimestampProto(now)
+	if err != nil {
+		t.Fatalf("TimestampProto() returned err = %v", err)
+	}
+
+	tests := []struct {
+		desc    string
+		tree    *trillian.Tree
+		now     time.Time
+		want    bool
+		wantErr bool
+	}{
+		{
+			desc: "active",
+			tree: &trillian.Tree{TreeState: trillian.TreeState_ACTIVE},
+			now:  now,
+		},
+		{
+			desc: "beforeDeadline",
+			tree: &trillian.Tree{TreeState: trillian.TreeState_DRAINING, DrainDeadline: deadline},
+			now:  now.Add(-time.Millisecond),
+		},
+		{
+			desc: "atDeadline",
+			tree: &trillian.Tree{TreeState: trillian.TreeState_DRAINING, DrainDeadline: deadline},
+			now:  now,
+			want: true,
+		},
+		{
+			desc: "afterDeadline",
+			tree: &trillian.Tree{TreeState: trillian.TreeState_DRAINING, DrainDeadline: deadline},
+			now:  now.Add(time.Hour),
+			want: true,
+		},
+		{
+			desc:    "noDeadline",
+			tree:    &trillian.Tree{TreeState: trillian.TreeState_DRAINING},
+			now:     now,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		got, err := DrainDeadlinePassed(test.tree, test.now)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: DrainDeadlinePassed() returned err = %v, wantErr = %v", test.desc, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%v: DrainDeadlinePassed() = %v, want = %v", test.desc, got, test.want)
+		}
+	}
+}
+
 func TestHash(t *testing.T) {
 	tests := []struct {
 		hashAlgo sigpb.DigitallySigned_HashAlgorithm
@@ -322,3 +380,167 @@ func TestSigner(t *testing.T) {
 		}
 	}
 }
+
+func TestSecondarySigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Error generating test RSA key: %v", err)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	// Trees without a secondary signer have no secondary signer, but no error either.
+	tree := *testonly.LogTree
+	if signer, err := SecondarySigner(ctx, keys.NewMockSignerFactory(ctrl), &tree); signer != nil || err != nil {
+		t.Errorf("SecondarySigner() = (%v, %v), want = (nil, nil)", signer, err)
+	}
+
+	keyProto := &keyspb.PrivateKey{Der: []byte("secondary key")}
+	secondaryKey, err := ptypes.MarshalAny(keyProto)
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+	tree.SecondarySigner = &trillian.SecondarySigner{
+		SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+		PrivateKey:         secondaryKey,
+	}
+	sf := keys.NewMockSignerFactory(ctrl)
+	sf.EXPECT().NewSigner(ctx, matchers.ProtoEqual(keyProto)).Times(2).Return(rsaKey, nil)
+
+	signer, err := SecondarySigner(ctx, sf, &tree)
+	if err != nil {
+		t.Fatalf("SecondarySigner() returned err = %v", err)
+	}
+	want := &tcrypto.Signer{Hash: crypto.SHA256, Signer: rsaKey}
+	if diff := pretty.Compare(signer, want); diff != "" {
+		t.Errorf("post-SecondarySigner() diff:\n%v", diff)
+	}
+
+	// The secondary signer's algorithm must match its key, regardless of the tree's algorithm.
+	tree.SecondarySigner.SignatureAlgorithm = sigpb.DigitallySigned_ECDSA
+	if _, err := SecondarySigner(ctx, sf, &tree); err == nil {
+		t.Error("SecondarySigner() with mismatched key returned err = nil, want error")
+	}
+}
+
+func TestRotationSigner(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test ECDSA key: %v", err)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	// Trees without a key rotation have no rotation signer, but no error either.
+	tree := *testonly.LogTree
+	if signer, err := RotationSigner(ctx, keys.NewMockSignerFactory(ctrl), &tree); signer != nil || err != nil {
+		t.Errorf("RotationSigner() = (%v, %v), want = (nil, nil)", signer, err)
+	}
+
+	keyProto := &keyspb.PrivateKey{Der: []byte("rotation key")}
+	rotationKey, err := ptypes.MarshalAny(keyProto)
+	if err != nil {
+		t.Fatalf("MarshalAny() returned err = %v", err)
+	}
+	tree.KeyRotation = &trillian.KeyRotation{PrivateKey: rotationKey, ActivationTreeSize: 10}
+	sf := keys.NewMockSignerFactory(ctrl)
+	sf.EXPECT().NewSigner(ctx, matchers.ProtoEqual(keyProto)).Return(ecdsaKey, nil)
+
+	signer, err := RotationSigner(ctx, sf, &tree)
+	if err != nil {
+		t.Fatalf("RotationSigner() returned err = %v", err)
+	}
+	want := &tcrypto.Signer{Hash: crypto.SHA256, Signer: ecdsaKey}
+	if diff := pretty.Compare(signer, want); diff != "" {
+		t.Errorf("post-RotationSigner() diff:\n%v", diff)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test ECDSA key: %v", err)
+	}
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating test ECDSA key: %v", err)
+	}
+	publicKey := func(key *ecdsa.PrivateKey) *keyspb.PublicKey {
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatalf("MarshalPKIXPublicKey() returned err = %v", err)
+		}
+		return &keyspb.PublicKey{Der: der}
+	}
+
+	data := []byte("signed root")
+	oldSig, err := tcrypto.NewSHA256Signer(oldKey).Sign(data)
+	if err != nil {
+		t.Fatalf("Sign() returned err = %v", err)
+	}
+	newSig, err := tcrypto.NewSHA256Signer(newKey).Sign(data)
+	if err != nil {
+		t.Fatalf("Sign() returned err = %v", err)
+	}
+
+	// Keys are rotated from oldKey to newKey, keeping oldKey valid until all clients have
+	// newKey.
+	tests := []struct {
+		desc                 string
+		publicKey            *keyspb.PublicKey
+		additionalPublicKeys []*keyspb.PublicKey
+		keyRotation          *trillian.KeyRotation
+		keyHistory           []*trillian.RetiredKey
+		wantOldOK, wantNewOK bool
+	}{
+		{
+			desc:      "beforeRotation",
+			publicKey: publicKey(oldKey),
+			wantOldOK: true,
+		},
+		{
+			desc:                 "duringRotation",
+			publicKey:            publicKey(newKey),
+			additionalPublicKeys: []*keyspb.PublicKey{publicKey(oldKey)},
+			wantOldOK:            true,
+			wantNewOK:            true,
+		},
+		{
+			desc:        "pendingKeyRotation",
+			publicKey:   publicKey(oldKey),
+			keyRotation: &trillian.KeyRotation{PublicKey: publicKey(newKey), ActivationTreeSize: 10},
+			wantOldOK:   true,
+			wantNewOK:   true,
+		},
+		{
+			desc:       "retiredKey",
+			publicKey:  publicKey(newKey),
+			keyHistory: []*trillian.RetiredKey{{PublicKey: publicKey(oldKey), EndTreeSize: 10}},
+			wantOldOK:  true,
+			wantNewOK:  true,
+		},
+		{
+			desc:      "afterRotation",
+			publicKey: publicKey(newKey),
+			wantNewOK: true,
+		},
+	}
+	for _, test := range tests {
+		tree := *testonly.LogTree
+		tree.PublicKey = test.publicKey
+		tree.AdditionalPublicKeys = test.additionalPublicKeys
+		tree.KeyRotation = test.keyRotation
+		tree.KeyHistory = test.keyHistory
+
+		if err := VerifySignature(&tree, data, oldSig); (err == nil) != test.wantOldOK {
+			t.Errorf("%v: VerifySignature(oldSig) = %v, wantOK = %v", test.desc, err, test.wantOldOK)
+		}
+		if err := VerifySignature(&tree, data, newSig); (err == nil) != test.wantNewOK {
+			t.Errorf("%v: VerifySignature(newSig) = %v, wantOK = %v", test.desc, err, test.wantNewOK)
+		}
+	}
+}
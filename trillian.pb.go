@@ -80,6 +80,10 @@ const (
 	// Acts an a non-existing tree for all read and write requests, but blocks the
 	// tree ID from ever being reused.
 	TreeState_HARD_DELETED TreeState = 4
+	// Draining trees respond to read requests, but only accept write requests
+	// until their drain_deadline. Once the deadline has passed and all queued
+	// leaves have been sequenced, the log signer freezes the tree.
+	TreeState_DRAINING TreeState = 5
 )
 
 var TreeState_name = map[int32]string{
@@ -88,6 +92,7 @@ var TreeState_name = map[int32]string{
 	2: "FROZEN",
 	3: "SOFT_DELETED",
 	4: "HARD_DELETED",
+	5: "DRAINING",
 }
 var TreeState_value = map[string]int32{
 	"UNKNOWN_TREE_STATE": 0,
@@ -95,6 +100,7 @@ var TreeState_value = map[string]int32{
 	"FROZEN":             2,
 	"SOFT_DELETED":       3,
 	"HARD_DELETED":       4,
+	"DRAINING":           5,
 }
 
 func (x TreeState) String() string {
@@ -131,6 +137,33 @@ func (x TreeType) String() string {
 }
 func (TreeType) EnumDescriptor() ([]byte, []int) { return fileDescriptor3, []int{2} }
 
+// Defines who computes the leaf hashes of a map.
+type MapLeafHashing int32
+
+const (
+	// The server computes leaf hashes from MapLeaf.leaf_value, according to the
+	// tree's hash_strategy. Clients may leave MapLeaf.leaf_hash unset; if set, it
+	// must match the hash computed by the server.
+	MapLeafHashing_SERVER_HASHED_LEAVES MapLeafHashing = 0
+	// Clients compute leaf hashes, which are stored in the tree verbatim.
+	// MapLeaf.leaf_hash is required and MapLeaf.leaf_value is optional.
+	MapLeafHashing_CLIENT_HASHED_LEAVES MapLeafHashing = 1
+)
+
+var MapLeafHashing_name = map[int32]string{
+	0: "SERVER_HASHED_LEAVES",
+	1: "CLIENT_HASHED_LEAVES",
+}
+var MapLeafHashing_value = map[string]int32{
+	"SERVER_HASHED_LEAVES": 0,
+	"CLIENT_HASHED_LEAVES": 1,
+}
+
+func (x MapLeafHashing) String() string {
+	return proto.EnumName(MapLeafHashing_name, int32(x))
+}
+func (MapLeafHashing) EnumDescriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+
 // Represents a tree, which may be either a verifiable log or map.
 // Readonly attributes are assigned at tree creation, after which they may not
 // be modified.
@@ -190,6 +223,100 @@ type Tree struct {
 	// Time of last tree update.
 	// Readonly (automatically assigned on updates).
 	UpdateTime *google_protobuf2.Timestamp `protobuf:"bytes,17,opt,name=update_time,json=updateTime" json:"update_time,omitempty"`
+	// Maximum allowed difference between a client-supplied leaf queue timestamp
+	// (LogLeaf.queue_timestamp) and the server's clock, in either direction.
+	// If zero, client-supplied timestamps are not accepted.
+	// Only applicable to LOG trees.
+	MaxClientTimestampSkew *google_protobuf1.Duration `protobuf:"bytes,19,opt,name=max_client_timestamp_skew,json=maxClientTimestampSkew" json:"max_client_timestamp_skew,omitempty"`
+	// Witnesses whose cosignatures of the tree's signed roots are accepted.
+	// Only applicable to LOG trees.
+	Witnesses []*Witness `protobuf:"bytes,20,rep,name=witnesses" json:"witnesses,omitempty"`
+	// Limits the number of historical signed roots kept for the tree.
+	// If unset, all signed roots are kept.
+	RootRetention *RootRetention `protobuf:"bytes,21,opt,name=root_retention,json=rootRetention" json:"root_retention,omitempty"`
+	// Defines who computes the leaf hashes of the tree.
+	// Readonly.
+	// Only applicable to MAP trees.
+	MapLeafHashing MapLeafHashing `protobuf:"varint,22,opt,name=map_leaf_hashing,json=mapLeafHashing,enum=trillian.MapLeafHashing" json:"map_leaf_hashing,omitempty"`
+	// Name of the hook that supplies metadata to be bound into each signed root
+	// of the tree, see SignedLogRoot.metadata. Hooks are registered with the log
+	// signer. If empty, signed roots have no metadata.
+	// Only applicable to LOG trees.
+	RootMetadataHook string `protobuf:"bytes,23,opt,name=root_metadata_hook,json=rootMetadataHook" json:"root_metadata_hook,omitempty"`
+	// If set, queued leaves that repeatedly fail to be sequenced are moved aside
+	// as dead-lettered leaves, rather than halting sequencing of the tree.
+	// If unset, such leaves fail every sequencing pass until fixed.
+	// Only applicable to LOG trees.
+	DeadLetterPolicy *DeadLetterPolicy `protobuf:"bytes,24,opt,name=dead_letter_policy,json=deadLetterPolicy" json:"dead_letter_policy,omitempty"`
+	// Number of revisions before the latest one that remain readable. Reads of
+	// older revisions fail with OUT_OF_RANGE, so their subtrees may be pruned.
+	// The latest revision is always readable. Zero means all revisions are
+	// readable.
+	// Only applicable to MAP trees.
+	MaxRevisionLookback int64 `protobuf:"varint,25,opt,name=max_revision_lookback,json=maxRevisionLookback" json:"max_revision_lookback,omitempty"`
+	// Public keys, other than public_key, whose signatures of the tree's roots
+	// are still valid, e.g. the previous key while the tree's key is rotated.
+	// New roots are only ever signed with private_key, so keys should be
+	// removed from here once clients no longer rely on them.
+	AdditionalPublicKeys []*keyspb.PublicKey `protobuf:"bytes,26,rep,name=additional_public_keys,json=additionalPublicKeys" json:"additional_public_keys,omitempty"`
+	// Origin of the log: a stable, human-meaningful name that identifies the log
+	// to checkpoint-based tooling such as witnesses, and in metrics. It's the
+	// origin line of the log's checkpoints and the name of their signature.
+	// Empty means the tree ID in decimal.
+	// Origins are unique among the trees of a server. They can only be set when
+	// the tree is created, as changing them would change the signed checkpoints.
+	// Only applicable to LOG trees.
+	// Readonly.
+	CheckpointOrigin string `protobuf:"bytes,27,opt,name=checkpoint_origin,json=checkpointOrigin" json:"checkpoint_origin,omitempty"`
+	// Period, starting when the tree enters the DRAINING state, during which
+	// the tree keeps accepting write requests. Zero means writes are rejected as
+	// soon as the tree is draining.
+	DrainGracePeriod *google_protobuf1.Duration `protobuf:"bytes,28,opt,name=drain_grace_period,json=drainGracePeriod" json:"drain_grace_period,omitempty"`
+	// Time after which a DRAINING tree no longer accepts write requests.
+	// Readonly (automatically assigned when the tree enters the DRAINING state,
+	// cleared when it leaves it).
+	DrainDeadline *google_protobuf2.Timestamp `protobuf:"bytes,29,opt,name=drain_deadline,json=drainDeadline" json:"drain_deadline,omitempty"`
+	// If set, signed roots also carry a signature by this signer, see
+	// SignedLogRoot.additional_signatures. Used to migrate verifiers to a new
+	// signature algorithm. The private key is never returned by the API.
+	// Only applicable to LOG trees.
+	SecondarySigner *SecondarySigner `protobuf:"bytes,30,opt,name=secondary_signer,json=secondarySigner" json:"secondary_signer,omitempty"`
+	// If true, QueueLeaves rejects leaves whose leaf_identity_hash isn't the
+	// tree's leaf hash of their leaf_value, as computed by its hash_strategy.
+	// Trees whose clients use opaque identity hashes must leave it unset.
+	// Only applicable to LOG trees.
+	VerifyLeafIdentityHash bool `protobuf:"varint,31,opt,name=verify_leaf_identity_hash,json=verifyLeafIdentityHash" json:"verify_leaf_identity_hash,omitempty"`
+	// Window within which QueueLeaves deduplicates leaves by
+	// leaf_identity_hash, see DedupWindow. If unset, a leaf is a duplicate of
+	// any earlier leaf with the same identity hash, however old.
+	// Only applicable to LOG trees.
+	// Readonly (can only be set when the tree is created).
+	DedupWindow *DedupWindow `protobuf:"bytes,32,opt,name=dedup_window,json=dedupWindow" json:"dedup_window,omitempty"`
+	// Number of buckets that storage spreads the tree's queued leaves across,
+	// to reduce contention between concurrent QueueLeaves requests. Leaves are
+	// still sequenced in the order they were queued. Zero means one bucket.
+	// Storage implementations without buckets ignore it.
+	// Only applicable to LOG trees.
+	// Readonly (can only be set when the tree is created).
+	UnsequencedBuckets int32 `protobuf:"varint,33,opt,name=unsequenced_buckets,json=unsequencedBuckets" json:"unsequenced_buckets,omitempty"`
+	// Principals allowed to use the tree, see AccessPolicy. If unset, only
+	// admins may make log or map requests to the tree, unless the server is
+	// configured to let any authenticated principal use it.
+	// Only enforced by servers that authenticate their callers.
+	AccessPolicy *AccessPolicy `protobuf:"bytes,34,opt,name=access_policy,json=accessPolicy" json:"access_policy,omitempty"`
+	// Time the tree was deleted. SOFT_DELETED trees are permanently deleted
+	// once it's older than the retention period of the server.
+	// Readonly (automatically assigned by DeleteTree, cleared by UndeleteTree).
+	DeleteTime *google_protobuf2.Timestamp `protobuf:"bytes,35,opt,name=delete_time,json=deleteTime" json:"delete_time,omitempty"`
+	// Scheduled change of the tree's signing key, see KeyRotation. While it's
+	// set, its public key is published alongside public_key.
+	// Only applicable to LOG trees.
+	// Readonly (set by RotateTreeKey, cleared by RetireTreeKey).
+	KeyRotation *KeyRotation `protobuf:"bytes,36,opt,name=key_rotation,json=keyRotation" json:"key_rotation,omitempty"`
+	// Keys that signed the tree's roots before public_key, oldest first, so
+	// that clients can verify the signatures of historical roots.
+	// Readonly (appended to by RetireTreeKey).
+	KeyHistory []*RetiredKey `protobuf:"bytes,37,rep,name=key_history,json=keyHistory" json:"key_history,omitempty"`
 }
 
 func (m *Tree) Reset()                    { *m = Tree{} }
@@ -302,6 +429,439 @@ func (m *Tree) GetUpdateTime() *google_protobuf2.Timestamp {
 	return nil
 }
 
+func (m *Tree) GetMaxClientTimestampSkew() *google_protobuf1.Duration {
+	if m != nil {
+		return m.MaxClientTimestampSkew
+	}
+	return nil
+}
+
+func (m *Tree) GetWitnesses() []*Witness {
+	if m != nil {
+		return m.Witnesses
+	}
+	return nil
+}
+
+func (m *Tree) GetRootRetention() *RootRetention {
+	if m != nil {
+		return m.RootRetention
+	}
+	return nil
+}
+
+func (m *Tree) GetMapLeafHashing() MapLeafHashing {
+	if m != nil {
+		return m.MapLeafHashing
+	}
+	return MapLeafHashing_SERVER_HASHED_LEAVES
+}
+
+func (m *Tree) GetRootMetadataHook() string {
+	if m != nil {
+		return m.RootMetadataHook
+	}
+	return ""
+}
+
+func (m *Tree) GetDeadLetterPolicy() *DeadLetterPolicy {
+	if m != nil {
+		return m.DeadLetterPolicy
+	}
+	return nil
+}
+
+func (m *Tree) GetMaxRevisionLookback() int64 {
+	if m != nil {
+		return m.MaxRevisionLookback
+	}
+	return 0
+}
+
+func (m *Tree) GetAdditionalPublicKeys() []*keyspb.PublicKey {
+	if m != nil {
+		return m.AdditionalPublicKeys
+	}
+	return nil
+}
+
+func (m *Tree) GetCheckpointOrigin() string {
+	if m != nil {
+		return m.CheckpointOrigin
+	}
+	return ""
+}
+
+func (m *Tree) GetDrainGracePeriod() *google_protobuf1.Duration {
+	if m != nil {
+		return m.DrainGracePeriod
+	}
+	return nil
+}
+
+func (m *Tree) GetDrainDeadline() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.DrainDeadline
+	}
+	return nil
+}
+
+func (m *Tree) GetSecondarySigner() *SecondarySigner {
+	if m != nil {
+		return m.SecondarySigner
+	}
+	return nil
+}
+
+func (m *Tree) GetVerifyLeafIdentityHash() bool {
+	if m != nil {
+		return m.VerifyLeafIdentityHash
+	}
+	return false
+}
+
+func (m *Tree) GetDedupWindow() *DedupWindow {
+	if m != nil {
+		return m.DedupWindow
+	}
+	return nil
+}
+
+func (m *Tree) GetUnsequencedBuckets() int32 {
+	if m != nil {
+		return m.UnsequencedBuckets
+	}
+	return 0
+}
+
+func (m *Tree) GetAccessPolicy() *AccessPolicy {
+	if m != nil {
+		return m.AccessPolicy
+	}
+	return nil
+}
+
+func (m *Tree) GetDeleteTime() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.DeleteTime
+	}
+	return nil
+}
+
+func (m *Tree) GetKeyRotation() *KeyRotation {
+	if m != nil {
+		return m.KeyRotation
+	}
+	return nil
+}
+
+func (m *Tree) GetKeyHistory() []*RetiredKey {
+	if m != nil {
+		return m.KeyHistory
+	}
+	return nil
+}
+
+// KeyRotation is a scheduled change of a log's signing key. Roots of at least
+// activation_tree_size leaves are signed by the new key; smaller roots are
+// still signed by the tree's private_key. Once the log's roots are signed by
+// the new key, RetireTreeKey makes it the tree's private_key.
+type KeyRotation struct {
+	// Identifies the new private key, like Tree.private_key. Never returned by
+	// the API.
+	PrivateKey *google_protobuf.Any `protobuf:"bytes,1,opt,name=private_key,json=privateKey" json:"private_key,omitempty"`
+	// The public key of private_key.
+	PublicKey *keyspb.PublicKey `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	// Smallest tree size whose roots are signed by the new key.
+	ActivationTreeSize int64 `protobuf:"varint,3,opt,name=activation_tree_size,json=activationTreeSize" json:"activation_tree_size,omitempty"`
+}
+
+func (m *KeyRotation) Reset()                    { *m = KeyRotation{} }
+func (m *KeyRotation) String() string            { return proto.CompactTextString(m) }
+func (*KeyRotation) ProtoMessage()               {}
+func (*KeyRotation) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{1} }
+
+func (m *KeyRotation) GetPrivateKey() *google_protobuf.Any {
+	if m != nil {
+		return m.PrivateKey
+	}
+	return nil
+}
+
+func (m *KeyRotation) GetPublicKey() *keyspb.PublicKey {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *KeyRotation) GetActivationTreeSize() int64 {
+	if m != nil {
+		return m.ActivationTreeSize
+	}
+	return 0
+}
+
+// RetiredKey is a key that no longer signs a log's roots.
+type RetiredKey struct {
+	// The public key verifying the key's signatures.
+	PublicKey *keyspb.PublicKey `protobuf:"bytes,1,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+	// The key signed the roots whose tree sizes are at least start_tree_size and
+	// less than end_tree_size.
+	StartTreeSize int64 `protobuf:"varint,2,opt,name=start_tree_size,json=startTreeSize" json:"start_tree_size,omitempty"`
+	EndTreeSize   int64 `protobuf:"varint,3,opt,name=end_tree_size,json=endTreeSize" json:"end_tree_size,omitempty"`
+}
+
+func (m *RetiredKey) Reset()                    { *m = RetiredKey{} }
+func (m *RetiredKey) String() string            { return proto.CompactTextString(m) }
+func (*RetiredKey) ProtoMessage()               {}
+func (*RetiredKey) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{2} }
+
+func (m *RetiredKey) GetPublicKey() *keyspb.PublicKey {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *RetiredKey) GetStartTreeSize() int64 {
+	if m != nil {
+		return m.StartTreeSize
+	}
+	return 0
+}
+
+func (m *RetiredKey) GetEndTreeSize() int64 {
+	if m != nil {
+		return m.EndTreeSize
+	}
+	return 0
+}
+
+// AccessPolicy lists the principals allowed to make log or map requests to a
+// tree. Principals are named by the server's authenticators, e.g. after the
+// subject of a client certificate. Server admins may make any request
+// regardless of the policy, and are the only principals allowed to make admin
+// requests.
+type AccessPolicy struct {
+	// Principals that may make any log or map request to the tree, including
+	// writes such as QueueLeaves and SetMapLeaves.
+	Owners []string `protobuf:"bytes,1,rep,name=owners" json:"owners,omitempty"`
+	// Principals that may make readonly log or map requests to the tree.
+	Readers []string `protobuf:"bytes,2,rep,name=readers" json:"readers,omitempty"`
+	// If true, anyone may make readonly log or map requests to the tree,
+	// including unauthenticated callers.
+	PublicRead bool `protobuf:"varint,3,opt,name=public_read,json=publicRead" json:"public_read,omitempty"`
+}
+
+func (m *AccessPolicy) Reset()                    { *m = AccessPolicy{} }
+func (m *AccessPolicy) String() string            { return proto.CompactTextString(m) }
+func (*AccessPolicy) ProtoMessage()               {}
+func (*AccessPolicy) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+
+func (m *AccessPolicy) GetOwners() []string {
+	if m != nil {
+		return m.Owners
+	}
+	return nil
+}
+
+func (m *AccessPolicy) GetReaders() []string {
+	if m != nil {
+		return m.Readers
+	}
+	return nil
+}
+
+func (m *AccessPolicy) GetPublicRead() bool {
+	if m != nil {
+		return m.PublicRead
+	}
+	return false
+}
+
+// SecondarySigner is a signer of a tree's roots other than the tree's own key.
+type SecondarySigner struct {
+	// Signature algorithm of the signer, which must match its keys.
+	SignatureAlgorithm sigpb.DigitallySigned_SignatureAlgorithm `protobuf:"varint,1,opt,name=signature_algorithm,json=signatureAlgorithm,enum=sigpb.DigitallySigned_SignatureAlgorithm" json:"signature_algorithm,omitempty"`
+	// Identifies the private key used for signing, like Tree.private_key.
+	PrivateKey *google_protobuf.Any `protobuf:"bytes,2,opt,name=private_key,json=privateKey" json:"private_key,omitempty"`
+	// The public key verifying the signer's signatures.
+	PublicKey *keyspb.PublicKey `protobuf:"bytes,3,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+}
+
+func (m *SecondarySigner) Reset()                    { *m = SecondarySigner{} }
+func (m *SecondarySigner) String() string            { return proto.CompactTextString(m) }
+func (*SecondarySigner) ProtoMessage()               {}
+func (*SecondarySigner) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+
+func (m *SecondarySigner) GetSignatureAlgorithm() sigpb.DigitallySigned_SignatureAlgorithm {
+	if m != nil {
+		return m.SignatureAlgorithm
+	}
+	return sigpb.DigitallySigned_ANONYMOUS
+}
+
+func (m *SecondarySigner) GetPrivateKey() *google_protobuf.Any {
+	if m != nil {
+		return m.PrivateKey
+	}
+	return nil
+}
+
+func (m *SecondarySigner) GetPublicKey() *keyspb.PublicKey {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+// DeadLetterPolicy describes when queued leaves that can't be sequenced are
+// dead-lettered. Dead-lettered leaves are no longer sequenced, but can be
+// inspected and requeued through the admin API.
+type DeadLetterPolicy struct {
+	// Number of sequencing passes a leaf must fail before it's dead-lettered.
+	// Passes fail as a whole until then. Must be positive.
+	MaxAttempts int32 `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts" json:"max_attempts,omitempty"`
+}
+
+func (m *DeadLetterPolicy) Reset()                    { *m = DeadLetterPolicy{} }
+func (m *DeadLetterPolicy) String() string            { return proto.CompactTextString(m) }
+func (*DeadLetterPolicy) ProtoMessage()               {}
+func (*DeadLetterPolicy) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+
+func (m *DeadLetterPolicy) GetMaxAttempts() int32 {
+	if m != nil {
+		return m.MaxAttempts
+	}
+	return 0
+}
+
+// RootRetention describes which historical signed roots of a tree are kept.
+// A root is kept if it's one of the keep_count most recent roots or if it was
+// created within keep_duration; other roots are eventually deleted. If neither
+// is set all roots are kept.
+// The latest root and, for logs, cosigned roots are always kept. Leaves and
+// subtrees are never deleted, so proofs against pruned log roots can still be
+// served.
+type RootRetention struct {
+	// Number of most recent signed roots to keep.
+	KeepCount int64 `protobuf:"varint,1,opt,name=keep_count,json=keepCount" json:"keep_count,omitempty"`
+	// Keep signed roots created within this duration.
+	KeepDuration *google_protobuf1.Duration `protobuf:"bytes,2,opt,name=keep_duration,json=keepDuration" json:"keep_duration,omitempty"`
+}
+
+func (m *RootRetention) Reset()                    { *m = RootRetention{} }
+func (m *RootRetention) String() string            { return proto.CompactTextString(m) }
+func (*RootRetention) ProtoMessage()               {}
+func (*RootRetention) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
+
+func (m *RootRetention) GetKeepCount() int64 {
+	if m != nil {
+		return m.KeepCount
+	}
+	return 0
+}
+
+func (m *RootRetention) GetKeepDuration() *google_protobuf1.Duration {
+	if m != nil {
+		return m.KeepDuration
+	}
+	return nil
+}
+
+// DedupWindow limits which earlier leaves a queued leaf is deduplicated
+// against. A leaf queued with the same leaf_identity_hash as an earlier leaf
+// is a duplicate, and QueueLeaves returns the earlier leaf, only while the
+// earlier leaf is within the window. Once it's outside the window, the new
+// leaf is queued and sequenced as a new leaf of the log.
+// A leaf that hasn't been sequenced yet is always within the window. A
+// sequenced leaf is within the window while it's both among the max_leaves
+// last leaves of the log and was queued no more than max_age ago; unset
+// limits don't apply. At least one limit must be set.
+// Storage may forget the identity hashes of leaves outside the window, so the
+// space they take can be reclaimed.
+type DedupWindow struct {
+	// Number of trailing leaves of the log that are within the window.
+	MaxLeaves int64 `protobuf:"varint,1,opt,name=max_leaves,json=maxLeaves" json:"max_leaves,omitempty"`
+	// Age, since they were queued, up to which leaves are within the window.
+	MaxAge *google_protobuf1.Duration `protobuf:"bytes,2,opt,name=max_age,json=maxAge" json:"max_age,omitempty"`
+}
+
+func (m *DedupWindow) Reset()                    { *m = DedupWindow{} }
+func (m *DedupWindow) String() string            { return proto.CompactTextString(m) }
+func (*DedupWindow) ProtoMessage()               {}
+func (*DedupWindow) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
+
+func (m *DedupWindow) GetMaxLeaves() int64 {
+	if m != nil {
+		return m.MaxLeaves
+	}
+	return 0
+}
+
+func (m *DedupWindow) GetMaxAge() *google_protobuf1.Duration {
+	if m != nil {
+		return m.MaxAge
+	}
+	return nil
+}
+
+// Witness is a third party that cosigns the signed roots of a log, vouching
+// that it has seen them.
+type Witness struct {
+	// Name of the witness, unique within a tree.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The public key used for verifying the witness's cosignatures.
+	PublicKey *keyspb.PublicKey `protobuf:"bytes,2,opt,name=public_key,json=publicKey" json:"public_key,omitempty"`
+}
+
+func (m *Witness) Reset()                    { *m = Witness{} }
+func (m *Witness) String() string            { return proto.CompactTextString(m) }
+func (*Witness) ProtoMessage()               {}
+func (*Witness) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{8} }
+
+func (m *Witness) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Witness) GetPublicKey() *keyspb.PublicKey {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+// Cosignature is a witness's signature of a SignedLogRoot. Witnesses sign the
+// same data as the log does.
+type Cosignature struct {
+	// Name of the witness that made the cosignature.
+	WitnessName string                 `protobuf:"bytes,1,opt,name=witness_name,json=witnessName" json:"witness_name,omitempty"`
+	Signature   *sigpb.DigitallySigned `protobuf:"bytes,2,opt,name=signature" json:"signature,omitempty"`
+}
+
+func (m *Cosignature) Reset()                    { *m = Cosignature{} }
+func (m *Cosignature) String() string            { return proto.CompactTextString(m) }
+func (*Cosignature) ProtoMessage()               {}
+func (*Cosignature) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
+
+func (m *Cosignature) GetWitnessName() string {
+	if m != nil {
+		return m.WitnessName
+	}
+	return ""
+}
+
+func (m *Cosignature) GetSignature() *sigpb.DigitallySigned {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
 type SignedEntryTimestamp struct {
 	TimestampNanos int64                  `protobuf:"varint,1,opt,name=timestamp_nanos,json=timestampNanos" json:"timestamp_nanos,omitempty"`
 	LogId          int64                  `protobuf:"varint,2,opt,name=log_id,json=logId" json:"log_id,omitempty"`
@@ -311,7 +871,7 @@ type SignedEntryTimestamp struct {
 func (m *SignedEntryTimestamp) Reset()                    { *m = SignedEntryTimestamp{} }
 func (m *SignedEntryTimestamp) String() string            { return proto.CompactTextString(m) }
 func (*SignedEntryTimestamp) ProtoMessage()               {}
-func (*SignedEntryTimestamp) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{1} }
+func (*SignedEntryTimestamp) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
 
 func (m *SignedEntryTimestamp) GetTimestampNanos() int64 {
 	if m != nil {
@@ -344,12 +904,20 @@ type SignedLogRoot struct {
 	Signature    *sigpb.DigitallySigned `protobuf:"bytes,4,opt,name=signature" json:"signature,omitempty"`
 	LogId        int64                  `protobuf:"varint,5,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	TreeRevision int64                  `protobuf:"varint,6,opt,name=tree_revision,json=treeRevision" json:"tree_revision,omitempty"`
+	// Opaque data supplied by the tree's root metadata hook when the root was
+	// signed. It's covered by the signature; roots without metadata are signed
+	// exactly as if the field didn't exist.
+	Metadata []byte `protobuf:"bytes,7,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Signatures of the root by the tree's secondary signer, if any. They cover
+	// the same data as signature, so verifiers may check whichever signature
+	// matches the algorithm they support.
+	AdditionalSignatures []*sigpb.DigitallySigned `protobuf:"bytes,8,rep,name=additional_signatures,json=additionalSignatures" json:"additional_signatures,omitempty"`
 }
 
 func (m *SignedLogRoot) Reset()                    { *m = SignedLogRoot{} }
 func (m *SignedLogRoot) String() string            { return proto.CompactTextString(m) }
 func (*SignedLogRoot) ProtoMessage()               {}
-func (*SignedLogRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{2} }
+func (*SignedLogRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{11} }
 
 func (m *SignedLogRoot) GetTimestampNanos() int64 {
 	if m != nil {
@@ -393,6 +961,20 @@ func (m *SignedLogRoot) GetTreeRevision() int64 {
 	return 0
 }
 
+func (m *SignedLogRoot) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *SignedLogRoot) GetAdditionalSignatures() []*sigpb.DigitallySigned {
+	if m != nil {
+		return m.AdditionalSignatures
+	}
+	return nil
+}
+
 type MapperMetadata struct {
 	SourceLogId                  []byte `protobuf:"bytes,1,opt,name=source_log_id,json=sourceLogId,proto3" json:"source_log_id,omitempty"`
 	HighestFullyCompletedSeq     int64  `protobuf:"varint,2,opt,name=highest_fully_completed_seq,json=highestFullyCompletedSeq" json:"highest_fully_completed_seq,omitempty"`
@@ -402,7 +984,7 @@ type MapperMetadata struct {
 func (m *MapperMetadata) Reset()                    { *m = MapperMetadata{} }
 func (m *MapperMetadata) String() string            { return proto.CompactTextString(m) }
 func (*MapperMetadata) ProtoMessage()               {}
-func (*MapperMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{3} }
+func (*MapperMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{12} }
 
 func (m *MapperMetadata) GetSourceLogId() []byte {
 	if m != nil {
@@ -439,7 +1021,7 @@ type SignedMapRoot struct {
 func (m *SignedMapRoot) Reset()                    { *m = SignedMapRoot{} }
 func (m *SignedMapRoot) String() string            { return proto.CompactTextString(m) }
 func (*SignedMapRoot) ProtoMessage()               {}
-func (*SignedMapRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+func (*SignedMapRoot) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{13} }
 
 func (m *SignedMapRoot) GetTimestampNanos() int64 {
 	if m != nil {
@@ -485,6 +1067,15 @@ func (m *SignedMapRoot) GetMapRevision() int64 {
 
 func init() {
 	proto.RegisterType((*Tree)(nil), "trillian.Tree")
+	proto.RegisterType((*KeyRotation)(nil), "trillian.KeyRotation")
+	proto.RegisterType((*RetiredKey)(nil), "trillian.RetiredKey")
+	proto.RegisterType((*AccessPolicy)(nil), "trillian.AccessPolicy")
+	proto.RegisterType((*SecondarySigner)(nil), "trillian.SecondarySigner")
+	proto.RegisterType((*DeadLetterPolicy)(nil), "trillian.DeadLetterPolicy")
+	proto.RegisterType((*RootRetention)(nil), "trillian.RootRetention")
+	proto.RegisterType((*DedupWindow)(nil), "trillian.DedupWindow")
+	proto.RegisterType((*Witness)(nil), "trillian.Witness")
+	proto.RegisterType((*Cosignature)(nil), "trillian.Cosignature")
 	proto.RegisterType((*SignedEntryTimestamp)(nil), "trillian.SignedEntryTimestamp")
 	proto.RegisterType((*SignedLogRoot)(nil), "trillian.SignedLogRoot")
 	proto.RegisterType((*MapperMetadata)(nil), "trillian.MapperMetadata")
@@ -492,76 +1083,132 @@ func init() {
 	proto.RegisterEnum("trillian.HashStrategy", HashStrategy_name, HashStrategy_value)
 	proto.RegisterEnum("trillian.TreeState", TreeState_name, TreeState_value)
 	proto.RegisterEnum("trillian.TreeType", TreeType_name, TreeType_value)
+	proto.RegisterEnum("trillian.MapLeafHashing", MapLeafHashing_name, MapLeafHashing_value)
 }
 
 func init() { proto.RegisterFile("trillian.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 1056 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x5f, 0x6f, 0xdb, 0xb6,
-	0x17, 0xad, 0x62, 0xd7, 0xb1, 0xaf, 0xff, 0x44, 0x61, 0x93, 0xfc, 0x94, 0xf4, 0x87, 0x35, 0xf3,
-	0x06, 0x2c, 0xcb, 0x06, 0x7b, 0x73, 0x9a, 0x00, 0x43, 0x31, 0x0c, 0x8e, 0xa3, 0x34, 0x7f, 0x6d,
-	0x43, 0xd2, 0x36, 0xb4, 0x2f, 0x04, 0x6d, 0xb3, 0x32, 0x51, 0xc9, 0x52, 0x25, 0xba, 0xa8, 0xfa,
-	0xbc, 0xc7, 0x01, 0xfb, 0x3e, 0xfb, 0x3c, 0xfb, 0x16, 0x7b, 0x19, 0x48, 0x51, 0xb2, 0x93, 0x74,
-	0x4b, 0x31, 0xec, 0x25, 0x21, 0xcf, 0x3d, 0xe7, 0xe8, 0x8a, 0xf7, 0x5e, 0xca, 0xd0, 0xe0, 0x11,
-	0xf3, 0x3c, 0x46, 0x66, 0xad, 0x30, 0x0a, 0x78, 0x80, 0xca, 0xd9, 0x7e, 0xe7, 0xd0, 0x65, 0x7c,
-	0x3a, 0x1f, 0xb5, 0xc6, 0x81, 0xdf, 0x76, 0x83, 0xc0, 0xf5, 0x68, 0x3b, 0x8b, 0xb5, 0xc7, 0x51,
-	0x12, 0xf2, 0xa0, 0xfd, 0x9a, 0x26, 0x71, 0x38, 0x52, 0xff, 0x52, 0x83, 0x9d, 0x83, 0xfb, 0x65,
-	0x31, 0x73, 0xc3, 0x51, 0xfa, 0x57, 0x89, 0xb6, 0x15, 0x53, 0xee, 0x46, 0xf3, 0x57, 0x6d, 0x32,
-	0x4b, 0x54, 0xe8, 0x93, 0xdb, 0xa1, 0xc9, 0x3c, 0x22, 0x9c, 0x05, 0x2a, 0xe1, 0x9d, 0x27, 0xb7,
-	0xe3, 0x9c, 0xf9, 0x34, 0xe6, 0xc4, 0x0f, 0x53, 0x42, 0xf3, 0xb7, 0x55, 0x28, 0x3a, 0x11, 0xa5,
-	0xe8, 0x7f, 0xb0, 0xca, 0x23, 0x4a, 0x31, 0x9b, 0x18, 0xda, 0xae, 0xb6, 0x57, 0xb0, 0x4a, 0x62,
-	0x7b, 0x3e, 0x41, 0x1d, 0x00, 0x19, 0x88, 0x39, 0xe1, 0xd4, 0x58, 0xd9, 0xd5, 0xf6, 0x1a, 0x9d,
-	0x47, 0xad, 0xfc, 0x60, 0x84, 0xd8, 0x16, 0x21, 0xab, 0xc2, 0xb3, 0x25, 0x6a, 0x83, 0xdc, 0x60,
-	0x9e, 0x84, 0xd4, 0x28, 0x48, 0x09, 0xba, 0x29, 0x71, 0x92, 0x90, 0x5a, 0x65, 0xae, 0x56, 0xe8,
-	0x19, 0xd4, 0xa7, 0x24, 0x9e, 0xe2, 0x98, 0x47, 0x84, 0x53, 0x37, 0x31, 0x8a, 0x52, 0xb4, 0xb5,
-	0x10, 0x9d, 0x91, 0x78, 0x6a, 0xab, 0xa8, 0x55, 0x9b, 0x2e, 0xed, 0xd0, 0x25, 0x34, 0xa4, 0x98,
-	0x78, 0x6e, 0x10, 0x31, 0x3e, 0xf5, 0x8d, 0x87, 0x52, 0xfd, 0x79, 0x2b, 0x3d, 0xc5, 0x13, 0xe6,
-	0x32, 0x4e, 0x3c, 0x2f, 0xb1, 0x99, 0x3b, 0xa3, 0x13, 0x69, 0xd5, 0xcd, 0xb8, 0x96, 0x7c, 0x70,
-	0xbe, 0x45, 0x2f, 0xe1, 0x51, 0xcc, 0xdc, 0x19, 0xe1, 0xf3, 0x88, 0x2e, 0x39, 0x96, 0xa4, 0xe3,
-	0x97, 0x7f, 0xe3, 0x68, 0x67, 0x8a, 0x85, 0x2d, 0x8a, 0xef, 0x60, 0x88, 0xc0, 0xd6, 0xc2, 0x7b,
-	0xcc, 0xc2, 0x29, 0x8d, 0x70, 0x3c, 0x67, 0x9c, 0x1a, 0x48, 0xda, 0x7f, 0x75, 0x9f, 0x7d, 0x4f,
-	0x6a, 0x6c, 0x21, 0xb1, 0x36, 0xe2, 0x0f, 0xa0, 0xe8, 0x53, 0xa8, 0x4d, 0x58, 0x1c, 0x7a, 0x24,
-	0xc1, 0x33, 0xe2, 0x53, 0xa3, 0xbc, 0xab, 0xed, 0x55, 0xac, 0xaa, 0xc2, 0xfa, 0xc4, 0xa7, 0x68,
-	0x17, 0xaa, 0x13, 0x1a, 0x8f, 0x23, 0x16, 0x8a, 0x46, 0x31, 0x2a, 0x8a, 0xb1, 0x80, 0xd0, 0x21,
-	0x54, 0xc3, 0x88, 0xbd, 0x25, 0x9c, 0xe2, 0xd7, 0x34, 0x31, 0x6a, 0xbb, 0xda, 0x5e, 0xb5, 0xb3,
-	0xd1, 0x4a, 0x7b, 0xa9, 0x95, 0xf5, 0x52, 0xab, 0x3b, 0x4b, 0x2c, 0x50, 0xc4, 0x4b, 0x9a, 0xa0,
-	0x1f, 0x40, 0x8f, 0x79, 0x10, 0x11, 0x97, 0xe2, 0x98, 0x72, 0xce, 0x66, 0x6e, 0x6c, 0xd4, 0xff,
-	0x41, 0xbb, 0xa6, 0xd8, 0xb6, 0x22, 0xa3, 0x6f, 0x00, 0xc2, 0xf9, 0xc8, 0x63, 0x63, 0xf9, 0xd8,
-	0x86, 0x94, 0xae, 0xb7, 0xd4, 0x00, 0x0d, 0x65, 0xe4, 0x92, 0x26, 0x56, 0x25, 0xcc, 0x96, 0xc8,
-	0x84, 0x75, 0x9f, 0xbc, 0xc3, 0x51, 0x10, 0x70, 0x9c, 0xb5, 0xbe, 0xb1, 0x26, 0x85, 0xdb, 0x77,
-	0x9e, 0x79, 0xa2, 0x08, 0xd6, 0x9a, 0x4f, 0xde, 0x59, 0x41, 0xc0, 0x33, 0x00, 0x3d, 0x83, 0xea,
-	0x38, 0xa2, 0xe2, 0x7d, 0xc5, 0x7c, 0x18, 0xba, 0x34, 0xd8, 0xb9, 0x63, 0xe0, 0x64, 0xc3, 0x63,
-	0x41, 0x4a, 0x17, 0x80, 0x10, 0xcf, 0xc3, 0x49, 0x2e, 0x5e, 0xbf, 0x5f, 0x9c, 0xd2, 0x05, 0x70,
-	0x51, 0x2c, 0xaf, 0xea, 0xe5, 0x8b, 0x62, 0x19, 0xf4, 0xea, 0x45, 0xb1, 0x5c, 0xd5, 0x6b, 0xcd,
-	0x5f, 0x35, 0xd8, 0x48, 0xeb, 0x6e, 0xce, 0x78, 0x94, 0xe4, 0x32, 0xf4, 0x05, 0xac, 0xe5, 0xd3,
-	0x8b, 0x67, 0x64, 0x16, 0xc4, 0x6a, 0x52, 0x1b, 0x39, 0xdc, 0x17, 0x28, 0xda, 0x84, 0x92, 0x17,
-	0xb8, 0x62, 0x92, 0x57, 0x64, 0xfc, 0xa1, 0x17, 0xb8, 0xe7, 0x13, 0xf4, 0x14, 0x2a, 0x79, 0xcb,
-	0xc8, 0xa1, 0xac, 0x76, 0xb6, 0x3e, 0xdc, 0x70, 0xd6, 0x82, 0xd8, 0xfc, 0x43, 0x83, 0x7a, 0x8a,
-	0x5e, 0x05, 0xae, 0x38, 0xb4, 0x8f, 0xcf, 0xe3, 0x31, 0x54, 0x64, 0x61, 0xc4, 0x80, 0xc9, 0x54,
-	0x6a, 0x56, 0x59, 0x00, 0x62, 0xfe, 0x44, 0x30, 0xbd, 0x56, 0xd8, 0xfb, 0x34, 0x9b, 0x42, 0x7a,
-	0x1d, 0xd8, 0xec, 0x3d, 0xbd, 0x99, 0x6a, 0xf1, 0x23, 0x53, 0x5d, 0x7a, 0xef, 0x87, 0xcb, 0xef,
-	0xfd, 0x19, 0xd4, 0xe5, 0x93, 0x22, 0xfa, 0x96, 0xc5, 0xa2, 0x3f, 0x4a, 0x32, 0x5a, 0x13, 0xa0,
-	0xa5, 0xb0, 0xe6, 0xef, 0x1a, 0x34, 0xae, 0x49, 0x18, 0xd2, 0xe8, 0x9a, 0x72, 0x32, 0x21, 0x9c,
-	0xa0, 0x26, 0xd4, 0xe3, 0x60, 0x1e, 0x8d, 0x29, 0x56, 0xae, 0x9a, 0x7c, 0x85, 0x6a, 0x0a, 0x5e,
-	0x49, 0xef, 0xef, 0xe1, 0xf1, 0x94, 0xb9, 0x53, 0x1a, 0x73, 0xfc, 0x6a, 0xee, 0x79, 0x09, 0x1e,
-	0x07, 0x7e, 0xe8, 0x51, 0x4e, 0x27, 0x38, 0xa6, 0x6f, 0xd4, 0xf9, 0x1b, 0x8a, 0x72, 0x2a, 0x18,
-	0xbd, 0x8c, 0x60, 0xd3, 0x37, 0xc8, 0x84, 0x27, 0x99, 0x3c, 0x24, 0x11, 0x67, 0xe4, 0xae, 0x45,
-	0x7a, 0x34, 0xff, 0x57, 0xb4, 0x61, 0xc6, 0x5a, 0xb6, 0x69, 0xfe, 0x99, 0xd7, 0xe8, 0x9a, 0x84,
-	0xff, 0x61, 0x8d, 0x9e, 0x42, 0xd9, 0x57, 0xa7, 0xa1, 0x1a, 0xc6, 0x58, 0x5c, 0xc8, 0x37, 0x4f,
-	0xcb, 0xca, 0x99, 0xff, 0xbe, 0x78, 0x3e, 0x09, 0x97, 0x8a, 0xe7, 0x93, 0xf0, 0x7c, 0x22, 0xee,
-	0x33, 0x01, 0xdf, 0xaa, 0x5d, 0xd5, 0x27, 0x61, 0x56, 0xba, 0xfd, 0x5f, 0x34, 0xa8, 0x2d, 0x7f,
-	0x1d, 0xd0, 0x36, 0x6c, 0xfe, 0xd8, 0xbf, 0xec, 0x0f, 0x7e, 0xee, 0xe3, 0xb3, 0xae, 0x7d, 0x86,
-	0x6d, 0xc7, 0xea, 0x3a, 0xe6, 0xf3, 0x17, 0xfa, 0x03, 0x84, 0xa0, 0x61, 0x9d, 0xf6, 0x8e, 0xbe,
-	0x3b, 0xea, 0x60, 0xfb, 0xac, 0xdb, 0x39, 0x3c, 0xd2, 0x35, 0xf4, 0x08, 0xd6, 0x1c, 0xd3, 0x76,
-	0xf0, 0x75, 0x77, 0x28, 0xf9, 0xa6, 0xa5, 0xaf, 0x08, 0x8f, 0xc1, 0xf1, 0x85, 0xd9, 0x73, 0xf0,
-	0x2d, 0x7e, 0x01, 0x6d, 0xc2, 0x7a, 0x6f, 0xd0, 0x3f, 0xbf, 0xb4, 0x05, 0x74, 0xf8, 0x6d, 0x07,
-	0x0b, 0xb8, 0xb8, 0x8f, 0xa1, 0x92, 0x7f, 0x0b, 0xd1, 0x16, 0xa0, 0x2c, 0x05, 0xc7, 0x32, 0x4d,
-	0x6c, 0x3b, 0x5d, 0xc7, 0xd4, 0x1f, 0x20, 0x80, 0x52, 0xb7, 0xe7, 0x9c, 0xff, 0x64, 0xea, 0x9a,
-	0x58, 0x9f, 0x5a, 0x83, 0x97, 0x66, 0x5f, 0x5f, 0x41, 0x3a, 0xd4, 0xec, 0xc1, 0xa9, 0x83, 0x4f,
-	0xcc, 0x2b, 0xd3, 0x31, 0x4f, 0xf4, 0x82, 0x40, 0xce, 0xba, 0xd6, 0x49, 0x8e, 0x14, 0xf7, 0x0f,
-	0xa0, 0x9c, 0x7d, 0x39, 0x45, 0x0e, 0x37, 0xfc, 0x9d, 0x17, 0x43, 0x61, 0xbf, 0x0a, 0x85, 0xab,
-	0xc1, 0x73, 0x5d, 0x13, 0x8b, 0xeb, 0xee, 0x50, 0x5f, 0x39, 0xfe, 0x1a, 0xb6, 0xc7, 0x81, 0x9f,
-	0x5d, 0x46, 0x37, 0x7f, 0xce, 0x1c, 0xd7, 0x1d, 0xb5, 0x1f, 0x8a, 0xed, 0x50, 0x1b, 0x95, 0x24,
-	0x7e, 0xf0, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0x17, 0x75, 0x85, 0xca, 0xf8, 0x08, 0x00, 0x00,
+	// 1926 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x5b, 0x73, 0xdb, 0xc6,
+	0x15, 0x36, 0x44, 0x5d, 0xa8, 0xc3, 0x8b, 0xe0, 0xd5, 0xc5, 0x90, 0x9c, 0xc4, 0x0c, 0x93, 0xb6,
+	0xaa, 0x93, 0x91, 0x52, 0x39, 0xf6, 0x34, 0x93, 0x69, 0x3a, 0x34, 0x09, 0x4b, 0xb2, 0x28, 0x4a,
+	0x03, 0xb2, 0xf6, 0x24, 0x2f, 0xdb, 0x15, 0xb0, 0x02, 0x77, 0x88, 0x9b, 0x81, 0xa5, 0x25, 0xe4,
+	0xb9, 0x4f, 0x9d, 0xfe, 0x87, 0xbe, 0xf6, 0x37, 0xf4, 0x3f, 0xf4, 0xb5, 0x7f, 0xa6, 0x2f, 0x9d,
+	0x5d, 0x2c, 0x48, 0x90, 0x4a, 0x42, 0x25, 0x93, 0x17, 0x09, 0xe7, 0xf2, 0x9d, 0x73, 0x76, 0xf1,
+	0xed, 0xd9, 0x03, 0x42, 0x9d, 0xc7, 0xcc, 0xf3, 0x18, 0x09, 0x0e, 0xa2, 0x38, 0xe4, 0x21, 0x2a,
+	0xe7, 0xf2, 0xde, 0x73, 0x97, 0xf1, 0xe1, 0xf8, 0xea, 0xc0, 0x0e, 0xfd, 0x43, 0x37, 0x0c, 0x5d,
+	0x8f, 0x1e, 0xe6, 0xb6, 0x43, 0x3b, 0x4e, 0x23, 0x1e, 0x1e, 0x8e, 0x68, 0x9a, 0x44, 0x57, 0xea,
+	0x5f, 0x16, 0x60, 0xef, 0xd9, 0x62, 0x58, 0xc2, 0xdc, 0xe8, 0x2a, 0xfb, 0xab, 0x40, 0xbb, 0xca,
+	0x53, 0x4a, 0x57, 0xe3, 0xeb, 0x43, 0x12, 0xa4, 0xca, 0xf4, 0xd1, 0xbc, 0xc9, 0x19, 0xc7, 0x84,
+	0xb3, 0x50, 0x15, 0xbc, 0xf7, 0x64, 0xde, 0xce, 0x99, 0x4f, 0x13, 0x4e, 0xfc, 0x28, 0x73, 0x68,
+	0xfe, 0x53, 0x87, 0xe5, 0x41, 0x4c, 0x29, 0x7a, 0x04, 0x6b, 0x3c, 0xa6, 0x14, 0x33, 0xc7, 0xd0,
+	0x1a, 0xda, 0x7e, 0xc9, 0x5a, 0x15, 0xe2, 0xa9, 0x83, 0x8e, 0x00, 0xa4, 0x21, 0xe1, 0x84, 0x53,
+	0x63, 0xa9, 0xa1, 0xed, 0xd7, 0x8f, 0x36, 0x0f, 0x26, 0x1b, 0x23, 0xc0, 0x7d, 0x61, 0xb2, 0xd6,
+	0x79, 0xfe, 0x88, 0x0e, 0x41, 0x0a, 0x98, 0xa7, 0x11, 0x35, 0x4a, 0x12, 0x82, 0x66, 0x21, 0x83,
+	0x34, 0xa2, 0x56, 0x99, 0xab, 0x27, 0xf4, 0x35, 0xd4, 0x86, 0x24, 0x19, 0xe2, 0x84, 0xc7, 0x84,
+	0x53, 0x37, 0x35, 0x96, 0x25, 0x68, 0x67, 0x0a, 0x3a, 0x21, 0xc9, 0xb0, 0xaf, 0xac, 0x56, 0x75,
+	0x58, 0x90, 0xd0, 0x19, 0xd4, 0x25, 0x98, 0x78, 0x6e, 0x18, 0x33, 0x3e, 0xf4, 0x8d, 0x15, 0x89,
+	0xfe, 0xf4, 0x20, 0xdb, 0xc5, 0x0e, 0x73, 0x19, 0x27, 0x9e, 0x97, 0xf6, 0x99, 0x1b, 0x50, 0x47,
+	0x86, 0x6a, 0xe5, 0xbe, 0x56, 0x6d, 0x58, 0x14, 0xd1, 0x77, 0xb0, 0x99, 0x30, 0x37, 0x20, 0x7c,
+	0x1c, 0xd3, 0x42, 0xc4, 0x55, 0x19, 0xf1, 0xf7, 0x3f, 0x12, 0xb1, 0x9f, 0x23, 0xa6, 0x61, 0x51,
+	0x72, 0x47, 0x87, 0x08, 0xec, 0x4c, 0x63, 0xdb, 0x2c, 0x1a, 0xd2, 0x18, 0x27, 0x63, 0xc6, 0xa9,
+	0x81, 0x64, 0xf8, 0xcf, 0x16, 0x85, 0x6f, 0x4b, 0x4c, 0x5f, 0x40, 0xac, 0xad, 0xe4, 0x07, 0xb4,
+	0xe8, 0x63, 0xa8, 0x3a, 0x2c, 0x89, 0x3c, 0x92, 0xe2, 0x80, 0xf8, 0xd4, 0x28, 0x37, 0xb4, 0xfd,
+	0x75, 0xab, 0xa2, 0x74, 0x3d, 0xe2, 0x53, 0xd4, 0x80, 0x8a, 0x43, 0x13, 0x3b, 0x66, 0x91, 0x20,
+	0x8a, 0xb1, 0xae, 0x3c, 0xa6, 0x2a, 0xf4, 0x1c, 0x2a, 0x51, 0xcc, 0xde, 0x13, 0x4e, 0xf1, 0x88,
+	0xa6, 0x46, 0xb5, 0xa1, 0xed, 0x57, 0x8e, 0xb6, 0x0e, 0x32, 0x2e, 0x1d, 0xe4, 0x5c, 0x3a, 0x68,
+	0x05, 0xa9, 0x05, 0xca, 0xf1, 0x8c, 0xa6, 0xe8, 0xcf, 0xa0, 0x27, 0x3c, 0x8c, 0x89, 0x4b, 0x71,
+	0x42, 0x39, 0x67, 0x81, 0x9b, 0x18, 0xb5, 0x9f, 0xc0, 0x6e, 0x28, 0xef, 0xbe, 0x72, 0x46, 0x5f,
+	0x00, 0x44, 0xe3, 0x2b, 0x8f, 0xd9, 0x32, 0x6d, 0x5d, 0x42, 0x1f, 0x1e, 0xa8, 0x03, 0x74, 0x29,
+	0x2d, 0x67, 0x34, 0xb5, 0xd6, 0xa3, 0xfc, 0x11, 0x99, 0xf0, 0xd0, 0x27, 0xb7, 0x38, 0x0e, 0x43,
+	0x8e, 0x73, 0xea, 0x1b, 0x1b, 0x12, 0xb8, 0x7b, 0x27, 0x67, 0x47, 0x39, 0x58, 0x1b, 0x3e, 0xb9,
+	0xb5, 0xc2, 0x90, 0xe7, 0x0a, 0xf4, 0x35, 0x54, 0xec, 0x98, 0x8a, 0xf5, 0x8a, 0xf3, 0x61, 0xe8,
+	0x32, 0xc0, 0xde, 0x9d, 0x00, 0x83, 0xfc, 0xf0, 0x58, 0x90, 0xb9, 0x0b, 0x85, 0x00, 0x8f, 0x23,
+	0x67, 0x02, 0x7e, 0xb8, 0x18, 0x9c, 0xb9, 0x4b, 0xf0, 0x00, 0x76, 0xc5, 0x02, 0x6c, 0x8f, 0xd1,
+	0x80, 0xe3, 0xc9, 0xe9, 0xc4, 0xc9, 0x88, 0xde, 0x18, 0x9b, 0x8b, 0x16, 0xb2, 0xe3, 0x93, 0xdb,
+	0xb6, 0x84, 0x4e, 0xa2, 0xf7, 0x47, 0xf4, 0x46, 0x9c, 0xbf, 0x1b, 0xc6, 0x03, 0x9a, 0x24, 0x34,
+	0x31, 0xb6, 0x1a, 0x25, 0xb9, 0x8f, 0x93, 0xa3, 0xf4, 0x36, 0x33, 0x59, 0x53, 0x1f, 0xf4, 0x0d,
+	0xd4, 0xe5, 0x1e, 0xc6, 0x94, 0xd3, 0x40, 0x6e, 0xe2, 0xb6, 0xcc, 0xfd, 0x68, 0x8a, 0x12, 0x1b,
+	0x66, 0xe5, 0x66, 0xab, 0x16, 0x17, 0x45, 0xf4, 0x12, 0x74, 0x9f, 0x44, 0xd8, 0xa3, 0xe4, 0x1a,
+	0x8b, 0xf3, 0xc4, 0x02, 0xd7, 0xd8, 0x91, 0x9c, 0x36, 0xa6, 0x11, 0xce, 0x49, 0xd4, 0xa5, 0xe4,
+	0xfa, 0x24, 0xb3, 0x5b, 0x75, 0x7f, 0x46, 0x46, 0x9f, 0x03, 0x92, 0x35, 0xf8, 0x94, 0x13, 0x87,
+	0x70, 0x82, 0x87, 0x61, 0x38, 0x32, 0x1e, 0x49, 0x7a, 0xea, 0xc2, 0x72, 0xae, 0x0c, 0x27, 0x61,
+	0x38, 0x42, 0x27, 0x80, 0x1c, 0x4a, 0x1c, 0xec, 0x51, 0xce, 0x69, 0x8c, 0xa3, 0xd0, 0x63, 0x76,
+	0x6a, 0x18, 0x6a, 0xf3, 0x27, 0x39, 0x3b, 0x94, 0x38, 0x5d, 0xe9, 0x72, 0x29, 0x3d, 0x2c, 0xdd,
+	0x99, 0xd3, 0xa0, 0x23, 0xd8, 0x96, 0x1c, 0xa2, 0xef, 0x59, 0xc2, 0xc2, 0x00, 0x7b, 0x61, 0x38,
+	0xba, 0x22, 0xf6, 0xc8, 0xd8, 0x95, 0x7d, 0x70, 0x53, 0x90, 0x45, 0xd9, 0xba, 0xca, 0x84, 0x8e,
+	0x61, 0x87, 0x38, 0x0e, 0x13, 0x6b, 0x27, 0x1e, 0x9e, 0x92, 0x36, 0x31, 0xf6, 0xd4, 0x6e, 0xdf,
+	0x61, 0xed, 0xd6, 0x14, 0x30, 0x51, 0x26, 0xe8, 0x33, 0x78, 0x68, 0x0f, 0xa9, 0x3d, 0x8a, 0x42,
+	0x16, 0x70, 0x1c, 0xc6, 0xcc, 0x65, 0x81, 0xf1, 0x38, 0x5b, 0xf3, 0xd4, 0x70, 0x21, 0xf5, 0xe8,
+	0x18, 0x90, 0x13, 0x13, 0x16, 0x60, 0x37, 0x26, 0x36, 0xc5, 0x11, 0x8d, 0x59, 0xe8, 0x18, 0x1f,
+	0x2c, 0x62, 0x89, 0x2e, 0x41, 0xc7, 0x02, 0x73, 0x29, 0x21, 0xa8, 0x05, 0xf5, 0x2c, 0x90, 0xd8,
+	0x0c, 0x8f, 0x05, 0xd4, 0xf8, 0x70, 0x21, 0x6b, 0x6b, 0x12, 0xd1, 0x51, 0x00, 0xd4, 0x01, 0x3d,
+	0xa1, 0x76, 0x18, 0x38, 0x24, 0x4e, 0xb1, 0x68, 0x45, 0x34, 0x36, 0x3e, 0x52, 0x95, 0x4c, 0x76,
+	0xbf, 0x9f, 0x7b, 0xc8, 0x46, 0x16, 0x5b, 0x1b, 0xc9, 0xac, 0x02, 0x7d, 0x05, 0xbb, 0xef, 0x69,
+	0xcc, 0xae, 0xd3, 0x8c, 0x3a, 0xcc, 0x11, 0x7c, 0xe2, 0xa9, 0xe4, 0x90, 0xf1, 0xa4, 0xa1, 0xed,
+	0x97, 0xad, 0x9d, 0xcc, 0x41, 0x30, 0xe5, 0x54, 0x99, 0x05, 0x63, 0xd0, 0x1f, 0xa1, 0xea, 0x50,
+	0x67, 0x1c, 0xe1, 0x1b, 0x16, 0x38, 0xe1, 0x8d, 0xd1, 0x90, 0xc9, 0xb7, 0x8b, 0xaf, 0xde, 0x19,
+	0x47, 0x6f, 0xa5, 0x51, 0xb4, 0xb7, 0x89, 0x80, 0x0e, 0x61, 0x73, 0x1c, 0x24, 0xf4, 0xdd, 0x98,
+	0x06, 0x36, 0x75, 0xf0, 0xd5, 0xd8, 0x1e, 0x51, 0x9e, 0x18, 0x1f, 0x37, 0xb4, 0xfd, 0x15, 0x0b,
+	0x15, 0x4c, 0x2f, 0x33, 0x8b, 0xb8, 0x9d, 0x88, 0x6d, 0xd3, 0x24, 0xc9, 0x69, 0xd6, 0x94, 0xb9,
+	0x0a, 0xb7, 0x53, 0x4b, 0x9a, 0x15, 0xc5, 0xaa, 0xa4, 0x20, 0x89, 0xf6, 0xe0, 0x50, 0x8f, 0xe6,
+	0xed, 0xe1, 0x93, 0xc5, 0xed, 0x21, 0x73, 0x17, 0x0a, 0xb1, 0xc8, 0x11, 0x4d, 0x71, 0x1c, 0xf2,
+	0xac, 0xb5, 0x7d, 0x3a, 0xbf, 0x48, 0xc1, 0x2c, 0x65, 0xb4, 0x2a, 0xa3, 0xa9, 0x20, 0x7a, 0xb8,
+	0x40, 0x0e, 0x99, 0x68, 0xb2, 0xa9, 0xf1, 0x1b, 0x49, 0xcb, 0xad, 0xc2, 0x71, 0xa6, 0x9c, 0xc5,
+	0xd4, 0x11, 0x78, 0x18, 0xd1, 0xf4, 0x24, 0xf3, 0x7b, 0xbd, 0x5c, 0x5e, 0xd3, 0xcb, 0xaf, 0x97,
+	0xcb, 0xa0, 0x57, 0x5e, 0x2f, 0x97, 0x2b, 0x7a, 0xb5, 0xf9, 0x2f, 0x0d, 0x2a, 0x67, 0xb3, 0x81,
+	0x8b, 0x97, 0x83, 0x76, 0xcf, 0xcb, 0x61, 0xb6, 0xb7, 0x2f, 0xdd, 0xa3, 0xb7, 0x7f, 0x01, 0x5b,
+	0xc4, 0xe6, 0x22, 0x80, 0x38, 0x95, 0xd9, 0x0c, 0xc2, 0xbe, 0xcf, 0xe6, 0x89, 0x92, 0x85, 0xa6,
+	0x36, 0x39, 0x82, 0xb0, 0xef, 0x69, 0xf3, 0xef, 0x1a, 0xc0, 0x74, 0x5d, 0x73, 0x29, 0xb5, 0x7b,
+	0xa4, 0xfc, 0x2d, 0x6c, 0x24, 0x9c, 0xc4, 0xbc, 0x90, 0x6d, 0x49, 0x66, 0xab, 0x49, 0x75, 0x9e,
+	0x08, 0x35, 0xa1, 0x46, 0x03, 0xe7, 0x4e, 0x4d, 0x15, 0x1a, 0x38, 0x93, 0x62, 0x08, 0x54, 0x8b,
+	0xac, 0x40, 0x3b, 0xb0, 0x1a, 0xde, 0x04, 0x34, 0x4e, 0x0c, 0xad, 0x51, 0xda, 0x5f, 0xb7, 0x94,
+	0x84, 0x0c, 0x58, 0x8b, 0x29, 0x71, 0x84, 0x61, 0x49, 0x1a, 0x72, 0x11, 0x3d, 0x81, 0x8a, 0xaa,
+	0x5f, 0x68, 0x64, 0x8e, 0xb2, 0xa5, 0x96, 0x64, 0x51, 0xe2, 0x34, 0xff, 0xab, 0xc1, 0xc6, 0xdc,
+	0x11, 0xfb, 0xb1, 0xf9, 0x45, 0xfb, 0x35, 0xe6, 0x97, 0xb9, 0x57, 0xbf, 0xf4, 0x8b, 0x5e, 0x7d,
+	0x69, 0xf1, 0x7b, 0x68, 0x3e, 0x07, 0x7d, 0xbe, 0x71, 0x8b, 0xc9, 0x46, 0xb4, 0x69, 0xc2, 0x39,
+	0xf5, 0x23, 0x9e, 0xc8, 0x15, 0xad, 0x58, 0x15, 0x9f, 0xdc, 0xb6, 0x94, 0xaa, 0x19, 0x40, 0x6d,
+	0xe6, 0x96, 0x42, 0x1f, 0x02, 0x8c, 0x28, 0x8d, 0xb0, 0x1d, 0x8e, 0x03, 0xae, 0xe6, 0xda, 0x75,
+	0xa1, 0x69, 0x0b, 0x05, 0xfa, 0x06, 0x6a, 0xd2, 0x3c, 0x99, 0x1c, 0x96, 0x16, 0xb5, 0xd2, 0xaa,
+	0xf0, 0xcf, 0xa5, 0xe6, 0x5f, 0xa1, 0x52, 0x68, 0x32, 0x22, 0x9b, 0xa8, 0xd0, 0xa3, 0xe4, 0x3d,
+	0x4d, 0xf2, 0x6c, 0x3e, 0xb9, 0xed, 0x4a, 0x05, 0x3a, 0x82, 0x35, 0xb9, 0x00, 0x97, 0x2e, 0xce,
+	0xb3, 0x2a, 0x96, 0xe5, 0xd2, 0xe6, 0x05, 0xac, 0xa9, 0xdb, 0x1a, 0x21, 0x58, 0x96, 0x13, 0x9d,
+	0x26, 0x2f, 0x07, 0xf9, 0xfc, 0xf3, 0x0f, 0x55, 0xf3, 0x1a, 0x2a, 0xed, 0x70, 0xf2, 0x6a, 0xc5,
+	0xa6, 0xaa, 0x21, 0x00, 0x17, 0x82, 0x57, 0x94, 0x4e, 0x8e, 0x8b, 0x5f, 0xc2, 0xfa, 0xc4, 0x5f,
+	0xa5, 0xd8, 0xf9, 0x61, 0x1a, 0x59, 0x53, 0xc7, 0xe6, 0x3f, 0x34, 0xd8, 0xca, 0xb4, 0x66, 0xc0,
+	0xe3, 0x74, 0xd2, 0xdd, 0xd0, 0xef, 0x60, 0x63, 0x3a, 0xe5, 0x04, 0x24, 0x08, 0xf3, 0x9d, 0xaa,
+	0x4f, 0xd4, 0x3d, 0xa1, 0x45, 0xdb, 0xb0, 0xea, 0x85, 0xae, 0xf8, 0x1e, 0xc9, 0x8e, 0xe0, 0x8a,
+	0x17, 0xba, 0xa7, 0xce, 0x6c, 0x39, 0xa5, 0xfb, 0x96, 0xf3, 0x9f, 0x25, 0xa8, 0x65, 0xda, 0x6e,
+	0xe8, 0x0a, 0x8e, 0xdc, 0xbf, 0x8e, 0xc7, 0xb0, 0x2e, 0xc7, 0x12, 0x79, 0x25, 0x89, 0x52, 0xaa,
+	0x56, 0x59, 0x28, 0xe4, 0x25, 0xf4, 0x18, 0xd6, 0xe7, 0x9b, 0x40, 0x99, 0xab, 0x0e, 0x30, 0x5b,
+	0xea, 0xf2, 0x3d, 0x4b, 0x2d, 0xac, 0x7b, 0xa5, 0xb8, 0xee, 0x4f, 0xa0, 0x26, 0x33, 0xe5, 0x63,
+	0x8a, 0xfc, 0x22, 0x29, 0x59, 0x55, 0xa1, 0xcc, 0xc7, 0x13, 0xb4, 0x07, 0xe5, 0x7c, 0x7a, 0x32,
+	0xd6, 0xb2, 0x52, 0x73, 0x19, 0x9d, 0xc1, 0x76, 0x61, 0x64, 0x99, 0xe4, 0x4b, 0x8c, 0x72, 0xa3,
+	0xf4, 0x13, 0x95, 0x15, 0xc6, 0x96, 0x49, 0x97, 0x48, 0x9a, 0xff, 0xd6, 0xa0, 0x7e, 0x4e, 0xa2,
+	0x88, 0xc6, 0xf9, 0x50, 0x26, 0x7a, 0x62, 0x12, 0x8e, 0x63, 0x9b, 0x62, 0x55, 0xbe, 0x26, 0x0b,
+	0xa8, 0x64, 0xca, 0xae, 0x5c, 0xc4, 0x9f, 0xe0, 0xf1, 0x90, 0xb9, 0x43, 0x9a, 0x70, 0x7c, 0x3d,
+	0xf6, 0xbc, 0x14, 0xdb, 0xa1, 0x1f, 0x89, 0xcb, 0xce, 0xc1, 0x09, 0x7d, 0xa7, 0x5e, 0xb4, 0xa1,
+	0x5c, 0x5e, 0x09, 0x8f, 0x76, 0xee, 0xd0, 0xa7, 0xef, 0x90, 0x09, 0x4f, 0x72, 0x78, 0x44, 0x62,
+	0xce, 0xc8, 0xdd, 0x10, 0xd9, 0x3b, 0xf8, 0x40, 0xb9, 0x5d, 0xe6, 0x5e, 0xc5, 0x30, 0xcd, 0xff,
+	0x69, 0x39, 0x19, 0xce, 0x49, 0xf4, 0x2b, 0x92, 0xe1, 0xcb, 0xc2, 0xee, 0x67, 0xcc, 0x9c, 0x1d,
+	0x7e, 0x0b, 0xbb, 0x55, 0x78, 0x2f, 0xbf, 0x98, 0x25, 0x62, 0xe0, 0x9e, 0xb2, 0xc4, 0x27, 0xd1,
+	0xa9, 0x93, 0x35, 0xc9, 0x68, 0x9e, 0x24, 0x15, 0x9f, 0x44, 0x39, 0x47, 0x9e, 0xfe, 0x4d, 0x83,
+	0x6a, 0xf1, 0x63, 0x1a, 0xed, 0xc2, 0xf6, 0x5f, 0x7a, 0x67, 0xbd, 0x8b, 0xb7, 0x3d, 0x7c, 0xd2,
+	0xea, 0x9f, 0xe0, 0xfe, 0xc0, 0x6a, 0x0d, 0xcc, 0xe3, 0x6f, 0xf5, 0x07, 0x08, 0x41, 0xdd, 0x7a,
+	0xd5, 0x7e, 0xf1, 0xd5, 0x8b, 0x23, 0xdc, 0x3f, 0x69, 0x1d, 0x3d, 0x7f, 0xa1, 0x6b, 0x68, 0x13,
+	0x36, 0x06, 0x66, 0x7f, 0x80, 0xcf, 0x5b, 0x97, 0xd2, 0xdf, 0xb4, 0xf4, 0x25, 0x11, 0xe3, 0xe2,
+	0xe5, 0x6b, 0xb3, 0x3d, 0xc0, 0x73, 0xfe, 0x25, 0xb4, 0x0d, 0x0f, 0xdb, 0x17, 0xbd, 0xd3, 0xb3,
+	0xbe, 0x50, 0x3d, 0xff, 0xc3, 0x11, 0x16, 0xea, 0xe5, 0xa7, 0x3e, 0xac, 0x4f, 0x7e, 0x3a, 0x40,
+	0x3b, 0x80, 0xf2, 0x12, 0x06, 0x96, 0x69, 0xe2, 0xfe, 0xa0, 0x35, 0x30, 0xf5, 0x07, 0x08, 0x60,
+	0xb5, 0xd5, 0x1e, 0x9c, 0xbe, 0x31, 0x75, 0x4d, 0x3c, 0xbf, 0xb2, 0x2e, 0xbe, 0x33, 0x7b, 0xfa,
+	0x12, 0xd2, 0xa1, 0xda, 0xbf, 0x78, 0x35, 0xc0, 0x1d, 0xb3, 0x6b, 0x0e, 0xcc, 0x8e, 0x5e, 0x12,
+	0x9a, 0x93, 0x96, 0xd5, 0x99, 0x68, 0x96, 0x51, 0x15, 0xca, 0x1d, 0xab, 0x75, 0xda, 0x3b, 0xed,
+	0x1d, 0xeb, 0x2b, 0x4f, 0x9f, 0x41, 0x39, 0xff, 0xd9, 0x41, 0x54, 0x34, 0x93, 0x6d, 0xf0, 0xed,
+	0xa5, 0x48, 0xb6, 0x06, 0xa5, 0xee, 0xc5, 0xb1, 0xae, 0x89, 0x87, 0xf3, 0xd6, 0xa5, 0xbe, 0xf4,
+	0xb4, 0x23, 0x49, 0x5e, 0xfc, 0x46, 0x31, 0x60, 0xab, 0x6f, 0x5a, 0x6f, 0x4c, 0x2b, 0x5b, 0x7a,
+	0x07, 0x77, 0xcd, 0xd6, 0x1b, 0xb3, 0xaf, 0x3f, 0x10, 0x96, 0x76, 0xf7, 0xd4, 0xec, 0x0d, 0xe6,
+	0x2c, 0xda, 0xcb, 0xcf, 0x61, 0xd7, 0x0e, 0xfd, 0xbc, 0xd7, 0xcf, 0xfe, 0xa2, 0xf4, 0xb2, 0x36,
+	0x50, 0xf2, 0xa5, 0x10, 0x2f, 0xb5, 0xab, 0x55, 0xa9, 0x7f, 0xf6, 0xff, 0x01, 0x00, 0x9e, 0x4b,
+	0x7b, 0xf2, 0x7b, 0x12, 0x00, 0x00,
 }
@@ -8,8 +8,11 @@ import fmt "fmt"
 import math "math"
 import keyspb "github.com/google/trillian/crypto/keyspb"
 import _ "google.golang.org/genproto/googleapis/api/annotations"
+import google_protobuf "github.com/golang/protobuf/ptypes/any"
+import google_protobuf1 "github.com/golang/protobuf/ptypes/duration"
 import google_protobuf4 "google.golang.org/genproto/protobuf/field_mask"
 import google_protobuf5 "github.com/golang/protobuf/ptypes/empty"
+import google_rpc "google.golang.org/genproto/googleapis/rpc/status"
 
 import (
 	context "golang.org/x/net/context"
@@ -22,8 +25,10 @@ var _ = fmt.Errorf
 var _ = math.Inf
 
 // ListTrees request.
-// No filters or pagination options are provided.
+// No pagination options are provided.
 type ListTreesRequest struct {
+	// If true, deleted trees are listed too.
+	ShowDeleted bool `protobuf:"varint,1,opt,name=show_deleted,json=showDeleted" json:"show_deleted,omitempty"`
 }
 
 func (m *ListTreesRequest) Reset()                    { *m = ListTreesRequest{} }
@@ -31,6 +36,13 @@ func (m *ListTreesRequest) String() string            { return proto.CompactText
 func (*ListTreesRequest) ProtoMessage()               {}
 func (*ListTreesRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{0} }
 
+func (m *ListTreesRequest) GetShowDeleted() bool {
+	if m != nil {
+		return m.ShowDeleted
+	}
+	return false
+}
+
 // ListTrees response.
 // No pagination is provided, all trees the requester has access to are
 // returned.
@@ -125,6 +137,128 @@ func (m *UpdateTreeRequest) GetUpdateMask() *google_protobuf4.FieldMask {
 	return nil
 }
 
+// BatchUpdateTrees request.
+type BatchUpdateTreesRequest struct {
+	// IDs of the trees to update.
+	// If empty, all trees matching tree_type and tree_state are updated.
+	TreeIds []int64 `protobuf:"varint,1,rep,packed,name=tree_ids,json=treeIds" json:"tree_ids,omitempty"`
+	// Restricts the update to trees of this type, if tree_ids is empty.
+	// UNKNOWN_TREE_TYPE matches all types.
+	TreeType TreeType `protobuf:"varint,2,opt,name=tree_type,json=treeType,enum=trillian.TreeType" json:"tree_type,omitempty"`
+	// Restricts the update to trees in this state, if tree_ids is empty.
+	// UNKNOWN_TREE_STATE matches all states.
+	TreeState TreeState `protobuf:"varint,3,opt,name=tree_state,json=treeState,enum=trillian.TreeState" json:"tree_state,omitempty"`
+	// Template holding the new values of the fields in update_mask.
+	// Its tree_id is ignored.
+	Tree *Tree `protobuf:"bytes,4,opt,name=tree" json:"tree,omitempty"`
+	// Fields modified by the update request, as in UpdateTreeRequest.
+	UpdateMask *google_protobuf4.FieldMask `protobuf:"bytes,5,opt,name=update_mask,json=updateMask" json:"update_mask,omitempty"`
+	// Maximum number of trees updated per storage transaction.
+	// Zero means a server-chosen default.
+	BatchSize int32 `protobuf:"varint,6,opt,name=batch_size,json=batchSize" json:"batch_size,omitempty"`
+}
+
+func (m *BatchUpdateTreesRequest) Reset()                    { *m = BatchUpdateTreesRequest{} }
+func (m *BatchUpdateTreesRequest) String() string            { return proto.CompactTextString(m) }
+func (*BatchUpdateTreesRequest) ProtoMessage()               {}
+func (*BatchUpdateTreesRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{5} }
+
+func (m *BatchUpdateTreesRequest) GetTreeIds() []int64 {
+	if m != nil {
+		return m.TreeIds
+	}
+	return nil
+}
+
+func (m *BatchUpdateTreesRequest) GetTreeType() TreeType {
+	if m != nil {
+		return m.TreeType
+	}
+	return TreeType_UNKNOWN_TREE_TYPE
+}
+
+func (m *BatchUpdateTreesRequest) GetTreeState() TreeState {
+	if m != nil {
+		return m.TreeState
+	}
+	return TreeState_UNKNOWN_TREE_STATE
+}
+
+func (m *BatchUpdateTreesRequest) GetTree() *Tree {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
+func (m *BatchUpdateTreesRequest) GetUpdateMask() *google_protobuf4.FieldMask {
+	if m != nil {
+		return m.UpdateMask
+	}
+	return nil
+}
+
+func (m *BatchUpdateTreesRequest) GetBatchSize() int32 {
+	if m != nil {
+		return m.BatchSize
+	}
+	return 0
+}
+
+// BatchUpdateTrees response.
+type BatchUpdateTreesResponse struct {
+	// Outcome of each tree update, in the order the trees were updated.
+	Results []*TreeUpdateResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *BatchUpdateTreesResponse) Reset()                    { *m = BatchUpdateTreesResponse{} }
+func (m *BatchUpdateTreesResponse) String() string            { return proto.CompactTextString(m) }
+func (*BatchUpdateTreesResponse) ProtoMessage()               {}
+func (*BatchUpdateTreesResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{6} }
+
+func (m *BatchUpdateTreesResponse) GetResults() []*TreeUpdateResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// Outcome of updating a single tree within a BatchUpdateTrees request.
+type TreeUpdateResult struct {
+	// ID of the tree.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Updated tree. Only set if the update succeeded.
+	Tree *Tree `protobuf:"bytes,2,opt,name=tree" json:"tree,omitempty"`
+	// Reason for the update failure. Not set if the update succeeded.
+	Status *google_rpc.Status `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *TreeUpdateResult) Reset()                    { *m = TreeUpdateResult{} }
+func (m *TreeUpdateResult) String() string            { return proto.CompactTextString(m) }
+func (*TreeUpdateResult) ProtoMessage()               {}
+func (*TreeUpdateResult) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{7} }
+
+func (m *TreeUpdateResult) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *TreeUpdateResult) GetTree() *Tree {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
+func (m *TreeUpdateResult) GetStatus() *google_rpc.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
 // DeleteTree request.
 type DeleteTreeRequest struct {
 	// ID of the tree to delete.
@@ -134,7 +268,7 @@ type DeleteTreeRequest struct {
 func (m *DeleteTreeRequest) Reset()                    { *m = DeleteTreeRequest{} }
 func (m *DeleteTreeRequest) String() string            { return proto.CompactTextString(m) }
 func (*DeleteTreeRequest) ProtoMessage()               {}
-func (*DeleteTreeRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{5} }
+func (*DeleteTreeRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{8} }
 
 func (m *DeleteTreeRequest) GetTreeId() int64 {
 	if m != nil {
@@ -143,13 +277,681 @@ func (m *DeleteTreeRequest) GetTreeId() int64 {
 	return 0
 }
 
+// UndeleteTree request.
+type UndeleteTreeRequest struct {
+	// ID of the tree to undelete.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+}
+
+func (m *UndeleteTreeRequest) Reset()                    { *m = UndeleteTreeRequest{} }
+func (m *UndeleteTreeRequest) String() string            { return proto.CompactTextString(m) }
+func (*UndeleteTreeRequest) ProtoMessage()               {}
+func (*UndeleteTreeRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{9} }
+
+func (m *UndeleteTreeRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// RotateTreeKey request.
+type RotateTreeKeyRequest struct {
+	// ID of the log whose key is rotated.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// The new private key, like Tree.private_key.
+	// Mutually exclusive with key_spec.
+	PrivateKey *google_protobuf.Any `protobuf:"bytes,2,opt,name=private_key,json=privateKey" json:"private_key,omitempty"`
+	// Specification of a new private key, generated by the server.
+	// Mutually exclusive with private_key.
+	KeySpec *keyspb.Specification `protobuf:"bytes,3,opt,name=key_spec,json=keySpec" json:"key_spec,omitempty"`
+	// Smallest tree size whose roots are signed by the new key. Must be larger
+	// than the size of the log's latest signed root.
+	ActivationTreeSize int64 `protobuf:"varint,4,opt,name=activation_tree_size,json=activationTreeSize" json:"activation_tree_size,omitempty"`
+}
+
+func (m *RotateTreeKeyRequest) Reset()                    { *m = RotateTreeKeyRequest{} }
+func (m *RotateTreeKeyRequest) String() string            { return proto.CompactTextString(m) }
+func (*RotateTreeKeyRequest) ProtoMessage()               {}
+func (*RotateTreeKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{10} }
+
+func (m *RotateTreeKeyRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *RotateTreeKeyRequest) GetPrivateKey() *google_protobuf.Any {
+	if m != nil {
+		return m.PrivateKey
+	}
+	return nil
+}
+
+func (m *RotateTreeKeyRequest) GetKeySpec() *keyspb.Specification {
+	if m != nil {
+		return m.KeySpec
+	}
+	return nil
+}
+
+func (m *RotateTreeKeyRequest) GetActivationTreeSize() int64 {
+	if m != nil {
+		return m.ActivationTreeSize
+	}
+	return 0
+}
+
+// RetireTreeKey request.
+type RetireTreeKeyRequest struct {
+	// ID of the log whose previous key is retired.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+}
+
+func (m *RetireTreeKeyRequest) Reset()                    { *m = RetireTreeKeyRequest{} }
+func (m *RetireTreeKeyRequest) String() string            { return proto.CompactTextString(m) }
+func (*RetireTreeKeyRequest) ProtoMessage()               {}
+func (*RetireTreeKeyRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{11} }
+
+func (m *RetireTreeKeyRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// RepairTreeRoot request.
+type RepairTreeRootRequest struct {
+	// ID of the log tree to repair.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Allows the repair of trees that aren't FROZEN, e.g. ACTIVE trees.
+	// By default only FROZEN trees may be repaired, as a mismatching root on an
+	// ACTIVE tree is more likely to be a sign of genuine corruption.
+	Force bool `protobuf:"varint,2,opt,name=force" json:"force,omitempty"`
+}
+
+func (m *RepairTreeRootRequest) Reset()                    { *m = RepairTreeRootRequest{} }
+func (m *RepairTreeRootRequest) String() string            { return proto.CompactTextString(m) }
+func (*RepairTreeRootRequest) ProtoMessage()               {}
+func (*RepairTreeRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{12} }
+
+func (m *RepairTreeRootRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *RepairTreeRootRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+// RepairTreeRoot response.
+type RepairTreeRootResponse struct {
+	// Whether the stored signed root differed from the recomputed one, and thus
+	// a corrected root was written.
+	Repaired bool `protobuf:"varint,1,opt,name=repaired" json:"repaired,omitempty"`
+	// Latest signed root, as stored before the repair.
+	PreviousRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=previous_root,json=previousRoot" json:"previous_root,omitempty"`
+	// Corrected signed root. Only set if repaired is true.
+	RepairedRoot *SignedLogRoot `protobuf:"bytes,3,opt,name=repaired_root,json=repairedRoot" json:"repaired_root,omitempty"`
+}
+
+func (m *RepairTreeRootResponse) Reset()                    { *m = RepairTreeRootResponse{} }
+func (m *RepairTreeRootResponse) String() string            { return proto.CompactTextString(m) }
+func (*RepairTreeRootResponse) ProtoMessage()               {}
+func (*RepairTreeRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{13} }
+
+func (m *RepairTreeRootResponse) GetRepaired() bool {
+	if m != nil {
+		return m.Repaired
+	}
+	return false
+}
+
+func (m *RepairTreeRootResponse) GetPreviousRoot() *SignedLogRoot {
+	if m != nil {
+		return m.PreviousRoot
+	}
+	return nil
+}
+
+func (m *RepairTreeRootResponse) GetRepairedRoot() *SignedLogRoot {
+	if m != nil {
+		return m.RepairedRoot
+	}
+	return nil
+}
+
+// GetTreeFootprint request.
+type GetTreeFootprintRequest struct {
+	// ID of the tree to inspect.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+}
+
+func (m *GetTreeFootprintRequest) Reset()                    { *m = GetTreeFootprintRequest{} }
+func (m *GetTreeFootprintRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetTreeFootprintRequest) ProtoMessage()               {}
+func (*GetTreeFootprintRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{14} }
+
+func (m *GetTreeFootprintRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// GetTreeFootprint response.
+// Figures are read without locking the tree, so they're approximate if the
+// tree is being written to.
+type GetTreeFootprintResponse struct {
+	// Number of leaves: sequenced leaves for logs, leaf revisions for maps.
+	LeafCount int64 `protobuf:"varint,1,opt,name=leaf_count,json=leafCount" json:"leaf_count,omitempty"`
+	// Approximate number of bytes of leaf data, including extra data and leaves
+	// that are queued but not yet sequenced.
+	LeafDataBytes int64 `protobuf:"varint,2,opt,name=leaf_data_bytes,json=leafDataBytes" json:"leaf_data_bytes,omitempty"`
+	// Number of stored subtrees, across all revisions.
+	SubtreeCount int64 `protobuf:"varint,3,opt,name=subtree_count,json=subtreeCount" json:"subtree_count,omitempty"`
+	// Number of signed roots retained in storage.
+	SignedRootCount int64 `protobuf:"varint,4,opt,name=signed_root_count,json=signedRootCount" json:"signed_root_count,omitempty"`
+}
+
+func (m *GetTreeFootprintResponse) Reset()                    { *m = GetTreeFootprintResponse{} }
+func (m *GetTreeFootprintResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetTreeFootprintResponse) ProtoMessage()               {}
+func (*GetTreeFootprintResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{15} }
+
+func (m *GetTreeFootprintResponse) GetLeafCount() int64 {
+	if m != nil {
+		return m.LeafCount
+	}
+	return 0
+}
+
+func (m *GetTreeFootprintResponse) GetLeafDataBytes() int64 {
+	if m != nil {
+		return m.LeafDataBytes
+	}
+	return 0
+}
+
+func (m *GetTreeFootprintResponse) GetSubtreeCount() int64 {
+	if m != nil {
+		return m.SubtreeCount
+	}
+	return 0
+}
+
+func (m *GetTreeFootprintResponse) GetSignedRootCount() int64 {
+	if m != nil {
+		return m.SignedRootCount
+	}
+	return 0
+}
+
+// ListDeadLetteredLeaves request.
+type ListDeadLetteredLeavesRequest struct {
+	// ID of the log tree.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+}
+
+func (m *ListDeadLetteredLeavesRequest) Reset()                    { *m = ListDeadLetteredLeavesRequest{} }
+func (m *ListDeadLetteredLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListDeadLetteredLeavesRequest) ProtoMessage()               {}
+func (*ListDeadLetteredLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{16} }
+
+func (m *ListDeadLetteredLeavesRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// ListDeadLetteredLeaves response.
+type ListDeadLetteredLeavesResponse struct {
+	Leaves []*DeadLetteredLeaf `protobuf:"bytes,1,rep,name=leaves" json:"leaves,omitempty"`
+}
+
+func (m *ListDeadLetteredLeavesResponse) Reset()                    { *m = ListDeadLetteredLeavesResponse{} }
+func (m *ListDeadLetteredLeavesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListDeadLetteredLeavesResponse) ProtoMessage()               {}
+func (*ListDeadLetteredLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{17} }
+
+func (m *ListDeadLetteredLeavesResponse) GetLeaves() []*DeadLetteredLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+// RequeueDeadLetteredLeaves request.
+type RequeueDeadLetteredLeavesRequest struct {
+	// ID of the log tree.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Identity hashes of the dead-lettered leaves to requeue.
+	LeafIdentityHashes [][]byte `protobuf:"bytes,2,rep,name=leaf_identity_hashes,json=leafIdentityHashes,proto3" json:"leaf_identity_hashes,omitempty"`
+}
+
+func (m *RequeueDeadLetteredLeavesRequest) Reset()         { *m = RequeueDeadLetteredLeavesRequest{} }
+func (m *RequeueDeadLetteredLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*RequeueDeadLetteredLeavesRequest) ProtoMessage()    {}
+func (*RequeueDeadLetteredLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor2, []int{18}
+}
+
+func (m *RequeueDeadLetteredLeavesRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *RequeueDeadLetteredLeavesRequest) GetLeafIdentityHashes() [][]byte {
+	if m != nil {
+		return m.LeafIdentityHashes
+	}
+	return nil
+}
+
+// RequeueDeadLetteredLeaves response.
+type RequeueDeadLetteredLeavesResponse struct {
+	// Number of leaves requeued. Hashes of leaves that aren't dead-lettered are
+	// ignored.
+	RequeuedCount int32 `protobuf:"varint,1,opt,name=requeued_count,json=requeuedCount" json:"requeued_count,omitempty"`
+}
+
+func (m *RequeueDeadLetteredLeavesResponse) Reset()         { *m = RequeueDeadLetteredLeavesResponse{} }
+func (m *RequeueDeadLetteredLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*RequeueDeadLetteredLeavesResponse) ProtoMessage()    {}
+func (*RequeueDeadLetteredLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor2, []int{19}
+}
+
+func (m *RequeueDeadLetteredLeavesResponse) GetRequeuedCount() int32 {
+	if m != nil {
+		return m.RequeuedCount
+	}
+	return 0
+}
+
+// GetQuotaTokens request.
+// Global buckets are always returned. Tree and User buckets are returned if
+// tree_id and user are set respectively.
+type GetQuotaTokensRequest struct {
+	// ID of the tree whose buckets to return.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Quota user whose buckets to return.
+	User string `protobuf:"bytes,2,opt,name=user" json:"user,omitempty"`
+}
+
+func (m *GetQuotaTokensRequest) Reset()                    { *m = GetQuotaTokensRequest{} }
+func (m *GetQuotaTokensRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetQuotaTokensRequest) ProtoMessage()               {}
+func (*GetQuotaTokensRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{20} }
+
+func (m *GetQuotaTokensRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *GetQuotaTokensRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+// Tokens available in a quota bucket.
+type QuotaTokens struct {
+	// Group of the bucket: "Global", "Tree" or "User".
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	// Kind of the bucket: "Read" or "Write".
+	Kind string `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
+	// Number of tokens available, unset if unlimited is true. May be negative
+	// if the bucket is overdrawn.
+	Tokens int64 `protobuf:"varint,3,opt,name=tokens" json:"tokens,omitempty"`
+	// Whether the bucket never runs out of tokens.
+	Unlimited bool `protobuf:"varint,4,opt,name=unlimited" json:"unlimited,omitempty"`
+}
+
+func (m *QuotaTokens) Reset()                    { *m = QuotaTokens{} }
+func (m *QuotaTokens) String() string            { return proto.CompactTextString(m) }
+func (*QuotaTokens) ProtoMessage()               {}
+func (*QuotaTokens) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{21} }
+
+func (m *QuotaTokens) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *QuotaTokens) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *QuotaTokens) GetTokens() int64 {
+	if m != nil {
+		return m.Tokens
+	}
+	return 0
+}
+
+func (m *QuotaTokens) GetUnlimited() bool {
+	if m != nil {
+		return m.Unlimited
+	}
+	return false
+}
+
+// GetQuotaTokens response.
+type GetQuotaTokensResponse struct {
+	Buckets []*QuotaTokens `protobuf:"bytes,1,rep,name=buckets" json:"buckets,omitempty"`
+}
+
+func (m *GetQuotaTokensResponse) Reset()                    { *m = GetQuotaTokensResponse{} }
+func (m *GetQuotaTokensResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetQuotaTokensResponse) ProtoMessage()               {}
+func (*GetQuotaTokensResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{22} }
+
+func (m *GetQuotaTokensResponse) GetBuckets() []*QuotaTokens {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+// ListPendingTrees request.
+type ListPendingTreesRequest struct {
+	// Whether to count the unsequenced leaves of each tree. Counting is more
+	// expensive than checking whether any leaves are queued.
+	IncludeCounts bool `protobuf:"varint,1,opt,name=include_counts,json=includeCounts" json:"include_counts,omitempty"`
+}
+
+func (m *ListPendingTreesRequest) Reset()                    { *m = ListPendingTreesRequest{} }
+func (m *ListPendingTreesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListPendingTreesRequest) ProtoMessage()               {}
+func (*ListPendingTreesRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{23} }
+
+func (m *ListPendingTreesRequest) GetIncludeCounts() bool {
+	if m != nil {
+		return m.IncludeCounts
+	}
+	return false
+}
+
+// A tree with leaves waiting to be sequenced.
+type PendingTree struct {
+	// ID of the tree.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Number of unsequenced leaves, only set if include_counts was requested.
+	UnsequencedCount int64 `protobuf:"varint,2,opt,name=unsequenced_count,json=unsequencedCount" json:"unsequenced_count,omitempty"`
+}
+
+func (m *PendingTree) Reset()                    { *m = PendingTree{} }
+func (m *PendingTree) String() string            { return proto.CompactTextString(m) }
+func (*PendingTree) ProtoMessage()               {}
+func (*PendingTree) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{24} }
+
+func (m *PendingTree) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *PendingTree) GetUnsequencedCount() int64 {
+	if m != nil {
+		return m.UnsequencedCount
+	}
+	return 0
+}
+
+// ListPendingTrees response.
+type ListPendingTreesResponse struct {
+	// Trees with unsequenced leaves, ordered by ID.
+	Trees []*PendingTree `protobuf:"bytes,1,rep,name=trees" json:"trees,omitempty"`
+}
+
+func (m *ListPendingTreesResponse) Reset()                    { *m = ListPendingTreesResponse{} }
+func (m *ListPendingTreesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListPendingTreesResponse) ProtoMessage()               {}
+func (*ListPendingTreesResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{25} }
+
+func (m *ListPendingTreesResponse) GetTrees() []*PendingTree {
+	if m != nil {
+		return m.Trees
+	}
+	return nil
+}
+
+// Identifies a quota bucket.
+type QuotaBucket struct {
+	// Group of the bucket: "Global", "Tree" or "User".
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	// Kind of the bucket: "Read", "Write" or "Admin".
+	Kind string `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
+	// ID of the tree, required for Tree buckets and not allowed otherwise.
+	TreeId int64 `protobuf:"varint,3,opt,name=tree_id,json=treeId" json:"tree_id,omitempty"`
+	// Quota user, required for User buckets and not allowed otherwise.
+	User string `protobuf:"bytes,4,opt,name=user" json:"user,omitempty"`
+}
+
+func (m *QuotaBucket) Reset()                    { *m = QuotaBucket{} }
+func (m *QuotaBucket) String() string            { return proto.CompactTextString(m) }
+func (*QuotaBucket) ProtoMessage()               {}
+func (*QuotaBucket) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{26} }
+
+func (m *QuotaBucket) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *QuotaBucket) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *QuotaBucket) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *QuotaBucket) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+// Configuration of a quota bucket, which works as a token bucket: it starts
+// with max_tokens, and tokens_to_replenish tokens are added back every
+// replenish_interval, up to max_tokens.
+type QuotaConfig struct {
+	// Bucket the configuration applies to.
+	Bucket *QuotaBucket `protobuf:"bytes,1,opt,name=bucket" json:"bucket,omitempty"`
+	// Maximum number of tokens in the bucket. Must be > 0.
+	MaxTokens int64 `protobuf:"varint,2,opt,name=max_tokens,json=maxTokens" json:"max_tokens,omitempty"`
+	// Number of tokens added to the bucket every replenish_interval. Must be
+	// > 0.
+	TokensToReplenish int64 `protobuf:"varint,3,opt,name=tokens_to_replenish,json=tokensToReplenish" json:"tokens_to_replenish,omitempty"`
+	// Interval between replenishments. Must be at least a millisecond.
+	ReplenishInterval *google_protobuf1.Duration `protobuf:"bytes,4,opt,name=replenish_interval,json=replenishInterval" json:"replenish_interval,omitempty"`
+}
+
+func (m *QuotaConfig) Reset()                    { *m = QuotaConfig{} }
+func (m *QuotaConfig) String() string            { return proto.CompactTextString(m) }
+func (*QuotaConfig) ProtoMessage()               {}
+func (*QuotaConfig) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{27} }
+
+func (m *QuotaConfig) GetBucket() *QuotaBucket {
+	if m != nil {
+		return m.Bucket
+	}
+	return nil
+}
+
+func (m *QuotaConfig) GetMaxTokens() int64 {
+	if m != nil {
+		return m.MaxTokens
+	}
+	return 0
+}
+
+func (m *QuotaConfig) GetTokensToReplenish() int64 {
+	if m != nil {
+		return m.TokensToReplenish
+	}
+	return 0
+}
+
+func (m *QuotaConfig) GetReplenishInterval() *google_protobuf1.Duration {
+	if m != nil {
+		return m.ReplenishInterval
+	}
+	return nil
+}
+
+// CreateQuotaConfig request.
+type CreateQuotaConfigRequest struct {
+	// Configuration to create. Fails with ALREADY_EXISTS if its bucket is
+	// configured already.
+	Config *QuotaConfig `protobuf:"bytes,1,opt,name=config" json:"config,omitempty"`
+}
+
+func (m *CreateQuotaConfigRequest) Reset()                    { *m = CreateQuotaConfigRequest{} }
+func (m *CreateQuotaConfigRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateQuotaConfigRequest) ProtoMessage()               {}
+func (*CreateQuotaConfigRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{28} }
+
+func (m *CreateQuotaConfigRequest) GetConfig() *QuotaConfig {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+// UpdateQuotaConfig request.
+type UpdateQuotaConfigRequest struct {
+	// Configuration to update, identified by config.bucket.
+	Config *QuotaConfig `protobuf:"bytes,1,opt,name=config" json:"config,omitempty"`
+	// Fields to update: max_tokens, tokens_to_replenish and/or
+	// replenish_interval.
+	UpdateMask *google_protobuf4.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask" json:"update_mask,omitempty"`
+}
+
+func (m *UpdateQuotaConfigRequest) Reset()                    { *m = UpdateQuotaConfigRequest{} }
+func (m *UpdateQuotaConfigRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateQuotaConfigRequest) ProtoMessage()               {}
+func (*UpdateQuotaConfigRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{29} }
+
+func (m *UpdateQuotaConfigRequest) GetConfig() *QuotaConfig {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *UpdateQuotaConfigRequest) GetUpdateMask() *google_protobuf4.FieldMask {
+	if m != nil {
+		return m.UpdateMask
+	}
+	return nil
+}
+
+// DeleteQuotaConfig request.
+type DeleteQuotaConfigRequest struct {
+	// Bucket whose configuration to delete.
+	Bucket *QuotaBucket `protobuf:"bytes,1,opt,name=bucket" json:"bucket,omitempty"`
+}
+
+func (m *DeleteQuotaConfigRequest) Reset()                    { *m = DeleteQuotaConfigRequest{} }
+func (m *DeleteQuotaConfigRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteQuotaConfigRequest) ProtoMessage()               {}
+func (*DeleteQuotaConfigRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{30} }
+
+func (m *DeleteQuotaConfigRequest) GetBucket() *QuotaBucket {
+	if m != nil {
+		return m.Bucket
+	}
+	return nil
+}
+
+// ListQuotaConfigs request.
+type ListQuotaConfigsRequest struct {
+}
+
+func (m *ListQuotaConfigsRequest) Reset()                    { *m = ListQuotaConfigsRequest{} }
+func (m *ListQuotaConfigsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListQuotaConfigsRequest) ProtoMessage()               {}
+func (*ListQuotaConfigsRequest) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{31} }
+
+// ListQuotaConfigs response.
+type ListQuotaConfigsResponse struct {
+	Configs []*QuotaConfig `protobuf:"bytes,1,rep,name=configs" json:"configs,omitempty"`
+}
+
+func (m *ListQuotaConfigsResponse) Reset()                    { *m = ListQuotaConfigsResponse{} }
+func (m *ListQuotaConfigsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListQuotaConfigsResponse) ProtoMessage()               {}
+func (*ListQuotaConfigsResponse) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{32} }
+
+func (m *ListQuotaConfigsResponse) GetConfigs() []*QuotaConfig {
+	if m != nil {
+		return m.Configs
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ListTreesRequest)(nil), "trillian.ListTreesRequest")
 	proto.RegisterType((*ListTreesResponse)(nil), "trillian.ListTreesResponse")
 	proto.RegisterType((*GetTreeRequest)(nil), "trillian.GetTreeRequest")
 	proto.RegisterType((*CreateTreeRequest)(nil), "trillian.CreateTreeRequest")
 	proto.RegisterType((*UpdateTreeRequest)(nil), "trillian.UpdateTreeRequest")
+	proto.RegisterType((*BatchUpdateTreesRequest)(nil), "trillian.BatchUpdateTreesRequest")
+	proto.RegisterType((*BatchUpdateTreesResponse)(nil), "trillian.BatchUpdateTreesResponse")
+	proto.RegisterType((*TreeUpdateResult)(nil), "trillian.TreeUpdateResult")
 	proto.RegisterType((*DeleteTreeRequest)(nil), "trillian.DeleteTreeRequest")
+	proto.RegisterType((*UndeleteTreeRequest)(nil), "trillian.UndeleteTreeRequest")
+	proto.RegisterType((*RotateTreeKeyRequest)(nil), "trillian.RotateTreeKeyRequest")
+	proto.RegisterType((*RetireTreeKeyRequest)(nil), "trillian.RetireTreeKeyRequest")
+	proto.RegisterType((*RepairTreeRootRequest)(nil), "trillian.RepairTreeRootRequest")
+	proto.RegisterType((*RepairTreeRootResponse)(nil), "trillian.RepairTreeRootResponse")
+	proto.RegisterType((*GetTreeFootprintRequest)(nil), "trillian.GetTreeFootprintRequest")
+	proto.RegisterType((*GetTreeFootprintResponse)(nil), "trillian.GetTreeFootprintResponse")
+	proto.RegisterType((*ListDeadLetteredLeavesRequest)(nil), "trillian.ListDeadLetteredLeavesRequest")
+	proto.RegisterType((*ListDeadLetteredLeavesResponse)(nil), "trillian.ListDeadLetteredLeavesResponse")
+	proto.RegisterType((*RequeueDeadLetteredLeavesRequest)(nil), "trillian.RequeueDeadLetteredLeavesRequest")
+	proto.RegisterType((*RequeueDeadLetteredLeavesResponse)(nil), "trillian.RequeueDeadLetteredLeavesResponse")
+	proto.RegisterType((*GetQuotaTokensRequest)(nil), "trillian.GetQuotaTokensRequest")
+	proto.RegisterType((*QuotaTokens)(nil), "trillian.QuotaTokens")
+	proto.RegisterType((*GetQuotaTokensResponse)(nil), "trillian.GetQuotaTokensResponse")
+	proto.RegisterType((*ListPendingTreesRequest)(nil), "trillian.ListPendingTreesRequest")
+	proto.RegisterType((*PendingTree)(nil), "trillian.PendingTree")
+	proto.RegisterType((*ListPendingTreesResponse)(nil), "trillian.ListPendingTreesResponse")
+	proto.RegisterType((*QuotaBucket)(nil), "trillian.QuotaBucket")
+	proto.RegisterType((*QuotaConfig)(nil), "trillian.QuotaConfig")
+	proto.RegisterType((*CreateQuotaConfigRequest)(nil), "trillian.CreateQuotaConfigRequest")
+	proto.RegisterType((*UpdateQuotaConfigRequest)(nil), "trillian.UpdateQuotaConfigRequest")
+	proto.RegisterType((*DeleteQuotaConfigRequest)(nil), "trillian.DeleteQuotaConfigRequest")
+	proto.RegisterType((*ListQuotaConfigsRequest)(nil), "trillian.ListQuotaConfigsRequest")
+	proto.RegisterType((*ListQuotaConfigsResponse)(nil), "trillian.ListQuotaConfigsResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -175,60 +977,234 @@ type TrillianAdminClient interface {
 	// Updates a tree.
 	// See Tree for details. Readonly fields cannot be updated.
 	UpdateTree(ctx context.Context, in *UpdateTreeRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Applies the same update to many trees, in batches of bounded
+	// transactions. Each tree is validated as in UpdateTree and failures are
+	// reported per tree; a failed tree doesn't prevent others from updating.
+	BatchUpdateTrees(ctx context.Context, in *BatchUpdateTreesRequest, opts ...grpc.CallOption) (*BatchUpdateTreesResponse, error)
 	// Soft-deletes a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
-	// it'll be permanently deleted.
-	// TODO(codingllama): Provide an undelete RPC.
+	// it'll be permanently deleted. Until then it's hidden from all other
+	// requests, as if it didn't exist.
 	DeleteTree(ctx context.Context, in *DeleteTreeRequest, opts ...grpc.CallOption) (*google_protobuf5.Empty, error)
+	// Undeletes a soft-deleted tree, which is restored as FROZEN. Use
+	// UpdateTree to make it ACTIVE again.
+	UndeleteTree(ctx context.Context, in *UndeleteTreeRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Recomputes the root hash of a log tree from its stored Merkle nodes, at
+	// the size of its latest signed root. If the recomputed hash doesn't match
+	// the stored root, a corrected signed root is written.
+	// Trees that aren't FROZEN are refused unless force is set.
+	RepairTreeRoot(ctx context.Context, in *RepairTreeRootRequest, opts ...grpc.CallOption) (*RepairTreeRootResponse, error)
+	// Returns an approximation of the storage used by a tree.
+	// The aggregate queries involved may be expensive, so servers run at most
+	// one at a time and reject concurrent requests with RESOURCE_EXHAUSTED.
+	GetTreeFootprint(ctx context.Context, in *GetTreeFootprintRequest, opts ...grpc.CallOption) (*GetTreeFootprintResponse, error)
+	// Lists the dead-lettered leaves of a log tree, oldest first.
+	ListDeadLetteredLeaves(ctx context.Context, in *ListDeadLetteredLeavesRequest, opts ...grpc.CallOption) (*ListDeadLetteredLeavesResponse, error)
+	// Puts dead-lettered leaves of a log tree back in its queue, so they are
+	// sequenced again.
+	RequeueDeadLetteredLeaves(ctx context.Context, in *RequeueDeadLetteredLeavesRequest, opts ...grpc.CallOption) (*RequeueDeadLetteredLeavesResponse, error)
+	// Returns the tokens available in the quota buckets that apply to a request,
+	// without acquiring any.
+	GetQuotaTokens(ctx context.Context, in *GetQuotaTokensRequest, opts ...grpc.CallOption) (*GetQuotaTokensResponse, error)
+	// Lists the trees that have leaves queued but not yet sequenced.
+	// The result is a point-in-time snapshot: leaves may be queued or sequenced
+	// as soon as it's read. Servers run at most one such query at a time and
+	// reject concurrent requests with RESOURCE_EXHAUSTED.
+	ListPendingTrees(ctx context.Context, in *ListPendingTreesRequest, opts ...grpc.CallOption) (*ListPendingTreesResponse, error)
+	// Creates the configuration of a quota bucket.
+	// Buckets without a configuration are unlimited. Servers pick up created,
+	// updated and deleted configurations periodically, without restarting.
+	CreateQuotaConfig(ctx context.Context, in *CreateQuotaConfigRequest, opts ...grpc.CallOption) (*QuotaConfig, error)
+	// Updates the configuration of a quota bucket.
+	UpdateQuotaConfig(ctx context.Context, in *UpdateQuotaConfigRequest, opts ...grpc.CallOption) (*QuotaConfig, error)
+	// Deletes the configuration of a quota bucket, making the bucket unlimited.
+	DeleteQuotaConfig(ctx context.Context, in *DeleteQuotaConfigRequest, opts ...grpc.CallOption) (*google_protobuf5.Empty, error)
+	// Lists the configurations of all quota buckets.
+	ListQuotaConfigs(ctx context.Context, in *ListQuotaConfigsRequest, opts ...grpc.CallOption) (*ListQuotaConfigsResponse, error)
+	// Schedules a change of a log's signing key: roots of at least
+	// activation_tree_size leaves are signed by the new key, whose public key
+	// is published in the tree's key_rotation meanwhile. Replaces any rotation
+	// already scheduled.
+	RotateTreeKey(ctx context.Context, in *RotateTreeKeyRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Completes the key rotation of a log, once its latest root is signed by
+	// the new key: the new key becomes the tree's private_key, and the previous
+	// public key is moved to the tree's key_history.
+	RetireTreeKey(ctx context.Context, in *RetireTreeKeyRequest, opts ...grpc.CallOption) (*Tree, error)
+}
+
+type trillianAdminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTrillianAdminClient(cc *grpc.ClientConn) TrillianAdminClient {
+	return &trillianAdminClient{cc}
+}
+
+func (c *trillianAdminClient) ListTrees(ctx context.Context, in *ListTreesRequest, opts ...grpc.CallOption) (*ListTreesResponse, error) {
+	out := new(ListTreesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/ListTrees", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) GetTree(ctx context.Context, in *GetTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/GetTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) CreateTree(ctx context.Context, in *CreateTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/CreateTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) UpdateTree(ctx context.Context, in *UpdateTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/UpdateTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) BatchUpdateTrees(ctx context.Context, in *BatchUpdateTreesRequest, opts ...grpc.CallOption) (*BatchUpdateTreesResponse, error) {
+	out := new(BatchUpdateTreesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/BatchUpdateTrees", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) DeleteTree(ctx context.Context, in *DeleteTreeRequest, opts ...grpc.CallOption) (*google_protobuf5.Empty, error) {
+	out := new(google_protobuf5.Empty)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/DeleteTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) UndeleteTree(ctx context.Context, in *UndeleteTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/UndeleteTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) RepairTreeRoot(ctx context.Context, in *RepairTreeRootRequest, opts ...grpc.CallOption) (*RepairTreeRootResponse, error) {
+	out := new(RepairTreeRootResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/RepairTreeRoot", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) GetTreeFootprint(ctx context.Context, in *GetTreeFootprintRequest, opts ...grpc.CallOption) (*GetTreeFootprintResponse, error) {
+	out := new(GetTreeFootprintResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/GetTreeFootprint", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-type trillianAdminClient struct {
-	cc *grpc.ClientConn
+func (c *trillianAdminClient) ListDeadLetteredLeaves(ctx context.Context, in *ListDeadLetteredLeavesRequest, opts ...grpc.CallOption) (*ListDeadLetteredLeavesResponse, error) {
+	out := new(ListDeadLetteredLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/ListDeadLetteredLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func NewTrillianAdminClient(cc *grpc.ClientConn) TrillianAdminClient {
-	return &trillianAdminClient{cc}
+func (c *trillianAdminClient) RequeueDeadLetteredLeaves(ctx context.Context, in *RequeueDeadLetteredLeavesRequest, opts ...grpc.CallOption) (*RequeueDeadLetteredLeavesResponse, error) {
+	out := new(RequeueDeadLetteredLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/RequeueDeadLetteredLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (c *trillianAdminClient) ListTrees(ctx context.Context, in *ListTreesRequest, opts ...grpc.CallOption) (*ListTreesResponse, error) {
-	out := new(ListTreesResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/ListTrees", in, out, c.cc, opts...)
+func (c *trillianAdminClient) GetQuotaTokens(ctx context.Context, in *GetQuotaTokensRequest, opts ...grpc.CallOption) (*GetQuotaTokensResponse, error) {
+	out := new(GetQuotaTokensResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/GetQuotaTokens", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianAdminClient) GetTree(ctx context.Context, in *GetTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
-	out := new(Tree)
-	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/GetTree", in, out, c.cc, opts...)
+func (c *trillianAdminClient) ListPendingTrees(ctx context.Context, in *ListPendingTreesRequest, opts ...grpc.CallOption) (*ListPendingTreesResponse, error) {
+	out := new(ListPendingTreesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/ListPendingTrees", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianAdminClient) CreateTree(ctx context.Context, in *CreateTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
-	out := new(Tree)
-	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/CreateTree", in, out, c.cc, opts...)
+func (c *trillianAdminClient) CreateQuotaConfig(ctx context.Context, in *CreateQuotaConfigRequest, opts ...grpc.CallOption) (*QuotaConfig, error) {
+	out := new(QuotaConfig)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/CreateQuotaConfig", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianAdminClient) UpdateTree(ctx context.Context, in *UpdateTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
-	out := new(Tree)
-	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/UpdateTree", in, out, c.cc, opts...)
+func (c *trillianAdminClient) UpdateQuotaConfig(ctx context.Context, in *UpdateQuotaConfigRequest, opts ...grpc.CallOption) (*QuotaConfig, error) {
+	out := new(QuotaConfig)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/UpdateQuotaConfig", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianAdminClient) DeleteTree(ctx context.Context, in *DeleteTreeRequest, opts ...grpc.CallOption) (*google_protobuf5.Empty, error) {
+func (c *trillianAdminClient) DeleteQuotaConfig(ctx context.Context, in *DeleteQuotaConfigRequest, opts ...grpc.CallOption) (*google_protobuf5.Empty, error) {
 	out := new(google_protobuf5.Empty)
-	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/DeleteTree", in, out, c.cc, opts...)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/DeleteQuotaConfig", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) ListQuotaConfigs(ctx context.Context, in *ListQuotaConfigsRequest, opts ...grpc.CallOption) (*ListQuotaConfigsResponse, error) {
+	out := new(ListQuotaConfigsResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/ListQuotaConfigs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) RotateTreeKey(ctx context.Context, in *RotateTreeKeyRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/RotateTreeKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) RetireTreeKey(ctx context.Context, in *RetireTreeKeyRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := grpc.Invoke(ctx, "/trillian.TrillianAdmin/RetireTreeKey", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -250,11 +1226,59 @@ type TrillianAdminServer interface {
 	// Updates a tree.
 	// See Tree for details. Readonly fields cannot be updated.
 	UpdateTree(context.Context, *UpdateTreeRequest) (*Tree, error)
+	// Applies the same update to many trees, in batches of bounded
+	// transactions. Each tree is validated as in UpdateTree and failures are
+	// reported per tree; a failed tree doesn't prevent others from updating.
+	BatchUpdateTrees(context.Context, *BatchUpdateTreesRequest) (*BatchUpdateTreesResponse, error)
 	// Soft-deletes a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
-	// it'll be permanently deleted.
-	// TODO(codingllama): Provide an undelete RPC.
+	// it'll be permanently deleted. Until then it's hidden from all other
+	// requests, as if it didn't exist.
 	DeleteTree(context.Context, *DeleteTreeRequest) (*google_protobuf5.Empty, error)
+	// Undeletes a soft-deleted tree, which is restored as FROZEN. Use
+	// UpdateTree to make it ACTIVE again.
+	UndeleteTree(context.Context, *UndeleteTreeRequest) (*Tree, error)
+	// Recomputes the root hash of a log tree from its stored Merkle nodes, at
+	// the size of its latest signed root. If the recomputed hash doesn't match
+	// the stored root, a corrected signed root is written.
+	// Trees that aren't FROZEN are refused unless force is set.
+	RepairTreeRoot(context.Context, *RepairTreeRootRequest) (*RepairTreeRootResponse, error)
+	// Returns an approximation of the storage used by a tree.
+	// The aggregate queries involved may be expensive, so servers run at most
+	// one at a time and reject concurrent requests with RESOURCE_EXHAUSTED.
+	GetTreeFootprint(context.Context, *GetTreeFootprintRequest) (*GetTreeFootprintResponse, error)
+	// Lists the dead-lettered leaves of a log tree, oldest first.
+	ListDeadLetteredLeaves(context.Context, *ListDeadLetteredLeavesRequest) (*ListDeadLetteredLeavesResponse, error)
+	// Puts dead-lettered leaves of a log tree back in its queue, so they are
+	// sequenced again.
+	RequeueDeadLetteredLeaves(context.Context, *RequeueDeadLetteredLeavesRequest) (*RequeueDeadLetteredLeavesResponse, error)
+	// Returns the tokens available in the quota buckets that apply to a request,
+	// without acquiring any.
+	GetQuotaTokens(context.Context, *GetQuotaTokensRequest) (*GetQuotaTokensResponse, error)
+	// Lists the trees that have leaves queued but not yet sequenced.
+	// The result is a point-in-time snapshot: leaves may be queued or sequenced
+	// as soon as it's read. Servers run at most one such query at a time and
+	// reject concurrent requests with RESOURCE_EXHAUSTED.
+	ListPendingTrees(context.Context, *ListPendingTreesRequest) (*ListPendingTreesResponse, error)
+	// Creates the configuration of a quota bucket.
+	// Buckets without a configuration are unlimited. Servers pick up created,
+	// updated and deleted configurations periodically, without restarting.
+	CreateQuotaConfig(context.Context, *CreateQuotaConfigRequest) (*QuotaConfig, error)
+	// Updates the configuration of a quota bucket.
+	UpdateQuotaConfig(context.Context, *UpdateQuotaConfigRequest) (*QuotaConfig, error)
+	// Deletes the configuration of a quota bucket, making the bucket unlimited.
+	DeleteQuotaConfig(context.Context, *DeleteQuotaConfigRequest) (*google_protobuf5.Empty, error)
+	// Lists the configurations of all quota buckets.
+	ListQuotaConfigs(context.Context, *ListQuotaConfigsRequest) (*ListQuotaConfigsResponse, error)
+	// Schedules a change of a log's signing key: roots of at least
+	// activation_tree_size leaves are signed by the new key, whose public key
+	// is published in the tree's key_rotation meanwhile. Replaces any rotation
+	// already scheduled.
+	RotateTreeKey(context.Context, *RotateTreeKeyRequest) (*Tree, error)
+	// Completes the key rotation of a log, once its latest root is signed by
+	// the new key: the new key becomes the tree's private_key, and the previous
+	// public key is moved to the tree's key_history.
+	RetireTreeKey(context.Context, *RetireTreeKeyRequest) (*Tree, error)
 }
 
 func RegisterTrillianAdminServer(s *grpc.Server, srv TrillianAdminServer) {
@@ -333,6 +1357,24 @@ func _TrillianAdmin_UpdateTree_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianAdmin_BatchUpdateTrees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateTreesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).BatchUpdateTrees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/BatchUpdateTrees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).BatchUpdateTrees(ctx, req.(*BatchUpdateTreesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianAdmin_DeleteTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteTreeRequest)
 	if err := dec(in); err != nil {
@@ -351,6 +1393,240 @@ func _TrillianAdmin_DeleteTree_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianAdmin_UndeleteTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).UndeleteTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/UndeleteTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).UndeleteTree(ctx, req.(*UndeleteTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_RepairTreeRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepairTreeRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).RepairTreeRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/RepairTreeRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).RepairTreeRoot(ctx, req.(*RepairTreeRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_GetTreeFootprint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTreeFootprintRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).GetTreeFootprint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/GetTreeFootprint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).GetTreeFootprint(ctx, req.(*GetTreeFootprintRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_ListDeadLetteredLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLetteredLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).ListDeadLetteredLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/ListDeadLetteredLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).ListDeadLetteredLeaves(ctx, req.(*ListDeadLetteredLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_RequeueDeadLetteredLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequeueDeadLetteredLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).RequeueDeadLetteredLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/RequeueDeadLetteredLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).RequeueDeadLetteredLeaves(ctx, req.(*RequeueDeadLetteredLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_GetQuotaTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuotaTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).GetQuotaTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/GetQuotaTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).GetQuotaTokens(ctx, req.(*GetQuotaTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_ListPendingTrees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingTreesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).ListPendingTrees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/ListPendingTrees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).ListPendingTrees(ctx, req.(*ListPendingTreesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_CreateQuotaConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateQuotaConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).CreateQuotaConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/CreateQuotaConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).CreateQuotaConfig(ctx, req.(*CreateQuotaConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_UpdateQuotaConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateQuotaConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).UpdateQuotaConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/UpdateQuotaConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).UpdateQuotaConfig(ctx, req.(*UpdateQuotaConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_DeleteQuotaConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteQuotaConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).DeleteQuotaConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/DeleteQuotaConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).DeleteQuotaConfig(ctx, req.(*DeleteQuotaConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_ListQuotaConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuotaConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).ListQuotaConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/ListQuotaConfigs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).ListQuotaConfigs(ctx, req.(*ListQuotaConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_RotateTreeKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateTreeKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).RotateTreeKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/RotateTreeKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).RotateTreeKey(ctx, req.(*RotateTreeKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_RetireTreeKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetireTreeKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).RetireTreeKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/RetireTreeKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).RetireTreeKey(ctx, req.(*RetireTreeKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianAdmin",
 	HandlerType: (*TrillianAdminServer)(nil),
@@ -371,10 +1647,66 @@ var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateTree",
 			Handler:    _TrillianAdmin_UpdateTree_Handler,
 		},
+		{
+			MethodName: "BatchUpdateTrees",
+			Handler:    _TrillianAdmin_BatchUpdateTrees_Handler,
+		},
 		{
 			MethodName: "DeleteTree",
 			Handler:    _TrillianAdmin_DeleteTree_Handler,
 		},
+		{
+			MethodName: "UndeleteTree",
+			Handler:    _TrillianAdmin_UndeleteTree_Handler,
+		},
+		{
+			MethodName: "RepairTreeRoot",
+			Handler:    _TrillianAdmin_RepairTreeRoot_Handler,
+		},
+		{
+			MethodName: "GetTreeFootprint",
+			Handler:    _TrillianAdmin_GetTreeFootprint_Handler,
+		},
+		{
+			MethodName: "ListDeadLetteredLeaves",
+			Handler:    _TrillianAdmin_ListDeadLetteredLeaves_Handler,
+		},
+		{
+			MethodName: "RequeueDeadLetteredLeaves",
+			Handler:    _TrillianAdmin_RequeueDeadLetteredLeaves_Handler,
+		},
+		{
+			MethodName: "GetQuotaTokens",
+			Handler:    _TrillianAdmin_GetQuotaTokens_Handler,
+		},
+		{
+			MethodName: "ListPendingTrees",
+			Handler:    _TrillianAdmin_ListPendingTrees_Handler,
+		},
+		{
+			MethodName: "CreateQuotaConfig",
+			Handler:    _TrillianAdmin_CreateQuotaConfig_Handler,
+		},
+		{
+			MethodName: "UpdateQuotaConfig",
+			Handler:    _TrillianAdmin_UpdateQuotaConfig_Handler,
+		},
+		{
+			MethodName: "DeleteQuotaConfig",
+			Handler:    _TrillianAdmin_DeleteQuotaConfig_Handler,
+		},
+		{
+			MethodName: "ListQuotaConfigs",
+			Handler:    _TrillianAdmin_ListQuotaConfigs_Handler,
+		},
+		{
+			MethodName: "RotateTreeKey",
+			Handler:    _TrillianAdmin_RotateTreeKey_Handler,
+		},
+		{
+			MethodName: "RetireTreeKey",
+			Handler:    _TrillianAdmin_RetireTreeKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_admin_api.proto",
@@ -383,37 +1715,109 @@ var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("trillian_admin_api.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 504 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xd1, 0x6e, 0xd3, 0x3c,
-	0x18, 0xfd, 0xb3, 0xfe, 0x5a, 0xc7, 0x37, 0x51, 0x51, 0xa3, 0x41, 0x97, 0x4d, 0xa2, 0xb2, 0xb8,
-	0x18, 0x15, 0x4a, 0x58, 0x01, 0x21, 0x81, 0xb8, 0xd8, 0x80, 0x21, 0x24, 0x90, 0xa6, 0x52, 0xc4,
-	0x65, 0xe5, 0xa6, 0x5f, 0x8b, 0xd5, 0x26, 0x36, 0xb1, 0x8b, 0x14, 0x21, 0x6e, 0x78, 0x05, 0x1e,
-	0x0d, 0x1e, 0x81, 0x07, 0x41, 0x76, 0x9c, 0x25, 0x69, 0x36, 0x2e, 0xb8, 0xaa, 0xed, 0x73, 0x7c,
-	0xbe, 0xe3, 0x73, 0xda, 0x42, 0x4f, 0xa7, 0x7c, 0xb5, 0xe2, 0x2c, 0x99, 0xb0, 0x59, 0xcc, 0x93,
-	0x09, 0x93, 0x3c, 0x90, 0xa9, 0xd0, 0x82, 0xec, 0x14, 0x88, 0xdf, 0x29, 0x56, 0x39, 0xe2, 0x3f,
-	0x5e, 0x70, 0xfd, 0x69, 0x3d, 0x0d, 0x22, 0x11, 0x87, 0x0b, 0x21, 0x16, 0x2b, 0x0c, 0x0b, 0x46,
-	0x18, 0xa5, 0x99, 0xd4, 0x22, 0x5c, 0x62, 0xa6, 0xe4, 0xd4, 0x7d, 0xb8, 0x6b, 0x87, 0x8e, 0xcb,
-	0x24, 0x0f, 0x59, 0x92, 0x08, 0xcd, 0x34, 0x17, 0x89, 0x72, 0x68, 0xdf, 0xa1, 0x76, 0x37, 0x5d,
-	0xcf, 0xc3, 0x39, 0xc7, 0xd5, 0x6c, 0x12, 0x33, 0xb5, 0x74, 0x8c, 0x83, 0x4d, 0x06, 0xc6, 0x52,
-	0x67, 0x39, 0x48, 0x09, 0xdc, 0x78, 0xcb, 0x95, 0x1e, 0xa7, 0x88, 0x6a, 0x84, 0x9f, 0xd7, 0xa8,
-	0x34, 0x7d, 0x02, 0xdd, 0xca, 0x99, 0x92, 0x22, 0x51, 0x48, 0x28, 0xfc, 0xaf, 0x53, 0xc4, 0x9e,
-	0xd7, 0x6f, 0x1d, 0xed, 0x0e, 0x3b, 0xc1, 0xc5, 0xdb, 0x0c, 0x6d, 0x64, 0x31, 0x7a, 0x0f, 0x3a,
-	0xaf, 0xd1, 0xde, 0x73, 0x52, 0xe4, 0x36, 0xb4, 0x0d, 0x32, 0xe1, 0xb3, 0x9e, 0xd7, 0xf7, 0x8e,
-	0x5a, 0xa3, 0x6d, 0xb3, 0x7d, 0x33, 0xa3, 0x1c, 0xba, 0x2f, 0x52, 0x64, 0x1a, 0xab, 0xec, 0x72,
-	0x86, 0x77, 0xd5, 0x0c, 0xf2, 0x00, 0x76, 0x96, 0x98, 0x4d, 0x94, 0xc4, 0xa8, 0xb7, 0x65, 0x79,
-	0x7b, 0x81, 0x8b, 0xeb, 0xbd, 0xc4, 0x88, 0xcf, 0x79, 0x64, 0xf3, 0x19, 0xb5, 0x97, 0x98, 0x99,
-	0x13, 0xaa, 0xa1, 0xfb, 0x41, 0xce, 0xfe, 0x61, 0xd4, 0x33, 0xd8, 0x5d, 0xdb, 0x8b, 0x36, 0x4d,
-	0x37, 0xcd, 0x0f, 0xf2, 0x38, 0x83, 0x22, 0xce, 0xe0, 0xcc, 0x04, 0xfe, 0x8e, 0xa9, 0xe5, 0x08,
-	0x72, 0xba, 0x59, 0xd3, 0xfb, 0xd0, 0x7d, 0x89, 0x2b, 0xac, 0x4f, 0xbd, 0x2a, 0x8e, 0xe1, 0xaf,
-	0x16, 0x5c, 0x1f, 0x3b, 0x0b, 0x27, 0xe6, 0x0b, 0x45, 0xce, 0xe0, 0xda, 0x45, 0x09, 0xc4, 0x2f,
-	0xfd, 0x6d, 0xb6, 0xe5, 0x1f, 0x5c, 0x8a, 0xe5, 0xad, 0xd1, 0xff, 0xc8, 0x47, 0x68, 0xbb, 0x4e,
-	0x48, 0xaf, 0x64, 0xd6, 0x6b, 0xf2, 0x37, 0xde, 0x4f, 0xe9, 0xf7, 0x9f, 0xbf, 0x7f, 0x6c, 0x1d,
-	0x12, 0x3f, 0xfc, 0x72, 0x3c, 0x45, 0xcd, 0x8e, 0x43, 0xe3, 0x53, 0x85, 0x5f, 0x9d, 0xfb, 0xe7,
-	0x83, 0x6f, 0x64, 0x0c, 0x50, 0x36, 0x48, 0x2a, 0x2e, 0x1a, 0xbd, 0x36, 0xe4, 0xf7, 0xad, 0xfc,
-	0x4d, 0xda, 0xa9, 0xcb, 0x3f, 0xf5, 0x06, 0x04, 0x01, 0xca, 0xb2, 0xaa, 0xaa, 0x8d, 0x0a, 0x1b,
-	0xaa, 0x03, 0xab, 0x7a, 0x77, 0x78, 0xe7, 0x32, 0xd3, 0x41, 0xe9, 0xdc, 0x8d, 0x29, 0xdb, 0xa9,
-	0x8e, 0x69, 0x74, 0xe6, 0xdf, 0x6a, 0x14, 0xfe, 0xca, 0xfc, 0x7e, 0x8a, 0x8c, 0x06, 0x7f, 0xc9,
-	0xe8, 0xf4, 0x11, 0xec, 0x47, 0x22, 0x2e, 0x04, 0xea, 0x7f, 0x07, 0xa7, 0x7b, 0xb5, 0xc2, 0x4f,
-	0x24, 0x3f, 0x37, 0xc7, 0xe7, 0xde, 0x74, 0xdb, 0xe2, 0x0f, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff,
-	0xcc, 0x22, 0x2c, 0x19, 0x64, 0x04, 0x00, 0x00,
+	// 1657 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xcd, 0x52, 0x1b, 0xc7,
+	0x16, 0xb6, 0x10, 0x08, 0x74, 0x40, 0x32, 0x6a, 0xfe, 0xc4, 0xd8, 0xd8, 0xb8, 0xef, 0xf5, 0xbd,
+	0x5c, 0x7c, 0x2d, 0xd9, 0x8a, 0x5d, 0x49, 0xe5, 0xa7, 0x12, 0x30, 0xc1, 0x26, 0x26, 0x55, 0x78,
+	0x80, 0xca, 0xc2, 0x8b, 0xa9, 0xd1, 0x4c, 0x23, 0xba, 0x24, 0xcd, 0x4c, 0x66, 0x7a, 0x88, 0x65,
+	0x57, 0x36, 0x59, 0xe4, 0x05, 0xb2, 0x4a, 0xe5, 0x25, 0x52, 0x79, 0x8c, 0x6c, 0x53, 0x79, 0x83,
+	0x3c, 0x48, 0xaa, 0x7f, 0xe6, 0x47, 0x1a, 0x8d, 0x80, 0x78, 0xa5, 0xe9, 0x3e, 0xdf, 0xf9, 0x3f,
+	0xa7, 0xfb, 0xb4, 0xa0, 0xce, 0x7c, 0xda, 0xeb, 0x51, 0xd3, 0x31, 0x4c, 0xbb, 0x4f, 0x1d, 0xc3,
+	0xf4, 0x68, 0xc3, 0xf3, 0x5d, 0xe6, 0xa2, 0xb9, 0x88, 0xa2, 0x55, 0xa3, 0x2f, 0x49, 0xd1, 0x56,
+	0x63, 0x9e, 0x9e, 0xdb, 0x49, 0x38, 0xb4, 0xa7, 0x1d, 0xca, 0xce, 0xc3, 0x76, 0xc3, 0x72, 0xfb,
+	0xcd, 0x8e, 0xeb, 0x76, 0x7a, 0xa4, 0x19, 0x21, 0x9b, 0x96, 0x3f, 0xf0, 0x98, 0xdb, 0xec, 0x92,
+	0x41, 0xe0, 0xb5, 0xd5, 0x8f, 0x62, 0xbb, 0xad, 0xb0, 0xa6, 0x47, 0x9b, 0xa6, 0xe3, 0xb8, 0xcc,
+	0x64, 0xd4, 0x75, 0x02, 0x45, 0x5d, 0x57, 0x54, 0xb1, 0x6a, 0x87, 0x67, 0x4d, 0xd3, 0x19, 0x28,
+	0xd2, 0x9d, 0x51, 0x92, 0x1d, 0xfa, 0x82, 0x57, 0xd1, 0x37, 0x47, 0xe9, 0x67, 0x94, 0xf4, 0x6c,
+	0xa3, 0x6f, 0x06, 0x5d, 0x85, 0xb8, 0x35, 0x8a, 0x20, 0x7d, 0x8f, 0x45, 0xe2, 0xd7, 0x14, 0xd1,
+	0xf7, 0xac, 0x66, 0xc0, 0x4c, 0x16, 0x2a, 0x93, 0xf0, 0x53, 0x58, 0x3c, 0xa4, 0x01, 0x3b, 0xf1,
+	0x09, 0x09, 0x74, 0xf2, 0x6d, 0x48, 0x02, 0x86, 0xee, 0xc1, 0x42, 0x70, 0xee, 0x7e, 0x67, 0xd8,
+	0xa4, 0x47, 0x18, 0xb1, 0xeb, 0x85, 0xcd, 0xc2, 0xd6, 0x9c, 0x3e, 0xcf, 0xf7, 0xf6, 0xe4, 0x16,
+	0xfe, 0x10, 0x6a, 0x29, 0xb6, 0xc0, 0x73, 0x9d, 0x80, 0x20, 0x0c, 0xd3, 0xcc, 0x27, 0xa4, 0x5e,
+	0xd8, 0x2c, 0x6e, 0xcd, 0xb7, 0xaa, 0x8d, 0x38, 0xd4, 0x1c, 0xa6, 0x0b, 0x1a, 0xfe, 0x1f, 0x54,
+	0x9f, 0x13, 0xc1, 0x17, 0x69, 0x5b, 0x83, 0x59, 0x4e, 0x31, 0xa8, 0x54, 0x54, 0xd4, 0x4b, 0x7c,
+	0x79, 0x60, 0x63, 0x0a, 0xb5, 0x67, 0x3e, 0x31, 0x19, 0x49, 0xa3, 0x13, 0x1d, 0x85, 0x3c, 0x1d,
+	0xe8, 0x11, 0xcc, 0x75, 0xc9, 0xc0, 0x08, 0x3c, 0x62, 0xd5, 0xa7, 0x04, 0x6e, 0xa5, 0xa1, 0xb2,
+	0x74, 0xec, 0x11, 0x8b, 0x9e, 0x51, 0x4b, 0x84, 0x56, 0x9f, 0xed, 0x92, 0x01, 0xdf, 0xc1, 0x0c,
+	0x6a, 0xa7, 0x9e, 0xfd, 0x0f, 0x54, 0x7d, 0x02, 0xf3, 0xa1, 0x60, 0x14, 0x99, 0x50, 0xda, 0xb4,
+	0x86, 0x8c, 0x76, 0x23, 0x4a, 0x45, 0x63, 0x9f, 0x27, 0xeb, 0x6b, 0x33, 0xe8, 0xea, 0x20, 0xe1,
+	0xfc, 0x1b, 0xff, 0x3c, 0x05, 0x6b, 0xbb, 0x26, 0xb3, 0xce, 0x13, 0xdd, 0x71, 0x0e, 0xd6, 0x61,
+	0x4e, 0x45, 0x25, 0x10, 0xf1, 0x2c, 0xea, 0xb3, 0x32, 0x2c, 0x01, 0x6a, 0x42, 0x59, 0x90, 0xd8,
+	0xc0, 0x23, 0x42, 0x63, 0xb5, 0x85, 0x86, 0x8d, 0x3b, 0x19, 0x78, 0x44, 0x9f, 0x63, 0xea, 0x0b,
+	0xb5, 0x00, 0x04, 0x03, 0x4f, 0x3c, 0xa9, 0x17, 0x05, 0xc7, 0xd2, 0x30, 0xc7, 0x31, 0x27, 0xe9,
+	0x65, 0x16, 0x7d, 0xc6, 0xce, 0x4f, 0x5f, 0xdd, 0xf9, 0x99, 0xeb, 0x38, 0x8f, 0x36, 0x00, 0xda,
+	0xdc, 0x77, 0x23, 0xa0, 0x6f, 0x49, 0xbd, 0xb4, 0x59, 0xd8, 0x9a, 0xd1, 0xcb, 0x62, 0xe7, 0x98,
+	0xbe, 0x25, 0xf8, 0x08, 0xea, 0xd9, 0xd0, 0xa8, 0x3a, 0x7b, 0x02, 0xb3, 0x3e, 0x09, 0xc2, 0x1e,
+	0x0b, 0x54, 0xa9, 0x69, 0xc3, 0xe6, 0x49, 0x1e, 0x5d, 0x40, 0xf4, 0x08, 0x8a, 0xdf, 0xc1, 0xe2,
+	0x28, 0x31, 0xb7, 0xf6, 0x62, 0xf7, 0xa7, 0x26, 0xb8, 0xbf, 0x0d, 0x25, 0xd9, 0x4a, 0x22, 0xa4,
+	0xf3, 0x2d, 0x14, 0x79, 0xee, 0x7b, 0x56, 0xe3, 0x58, 0x50, 0x74, 0x85, 0xc0, 0xff, 0x87, 0x9a,
+	0x6c, 0x9d, 0x2b, 0x55, 0x7e, 0x03, 0x96, 0x4e, 0x1d, 0xfb, 0xea, 0xf8, 0xdf, 0x0b, 0xb0, 0xac,
+	0xf3, 0xb3, 0x46, 0xc0, 0x5f, 0x92, 0xc1, 0x65, 0x1c, 0xe8, 0x29, 0xcc, 0x7b, 0x3e, 0xbd, 0xe0,
+	0xb9, 0xeb, 0x92, 0x81, 0x72, 0x73, 0x39, 0x93, 0xba, 0x1d, 0x67, 0xa0, 0x83, 0x02, 0xbe, 0x24,
+	0x83, 0xa1, 0xce, 0x2a, 0x5e, 0xa5, 0xb3, 0xd0, 0x23, 0x58, 0x36, 0x2d, 0xc6, 0x05, 0x50, 0xd7,
+	0x31, 0x64, 0x19, 0xd2, 0xb7, 0xb2, 0xae, 0x8a, 0x3a, 0x4a, 0x68, 0xa2, 0x0a, 0x79, 0xe6, 0x9b,
+	0xb0, 0xac, 0x13, 0x46, 0xfd, 0xab, 0xfa, 0x82, 0xf7, 0x61, 0x45, 0x27, 0x9e, 0x49, 0x7d, 0x11,
+	0x2b, 0xd7, 0x65, 0x97, 0x7a, 0xbf, 0x0c, 0x33, 0x67, 0xae, 0x6f, 0xc9, 0xf4, 0xce, 0xe9, 0x72,
+	0x81, 0x7f, 0x2d, 0xc0, 0xea, 0xa8, 0x20, 0x55, 0x71, 0x1a, 0xcc, 0xf9, 0x82, 0x12, 0x9f, 0x86,
+	0xf1, 0x1a, 0x7d, 0x0a, 0x15, 0xcf, 0x27, 0x17, 0xd4, 0x0d, 0x03, 0xc3, 0x77, 0x5d, 0xa6, 0x82,
+	0xb9, 0x96, 0xd4, 0xcc, 0x31, 0xed, 0x38, 0xc4, 0x3e, 0x74, 0x3b, 0x42, 0xe6, 0x42, 0x84, 0xe6,
+	0x2b, 0xce, 0x1d, 0x49, 0x92, 0xdc, 0xc5, 0x4b, 0xb8, 0x23, 0x34, 0x5f, 0xe1, 0x16, 0xac, 0xa9,
+	0xd3, 0x74, 0xdf, 0x75, 0x99, 0xe7, 0x53, 0xe7, 0x52, 0xe7, 0xf1, 0x6f, 0x05, 0xa8, 0x67, 0x99,
+	0x94, 0xa3, 0x1b, 0x00, 0x3d, 0x62, 0x9e, 0x19, 0x96, 0x1b, 0x3a, 0x4c, 0x31, 0x96, 0xf9, 0xce,
+	0x33, 0xbe, 0x81, 0xfe, 0x03, 0x37, 0x05, 0xd9, 0x36, 0x99, 0x69, 0xb4, 0x07, 0x8c, 0x04, 0xc2,
+	0xdb, 0xa2, 0x5e, 0xe1, 0xdb, 0x7b, 0x26, 0x33, 0x77, 0xf9, 0x26, 0xfa, 0x17, 0x54, 0x82, 0xb0,
+	0x2d, 0xf4, 0x4b, 0x49, 0x45, 0x81, 0x5a, 0x50, 0x9b, 0x52, 0xd8, 0x36, 0xd4, 0x02, 0xe1, 0x9b,
+	0x70, 0x5c, 0x01, 0x65, 0x5d, 0xdc, 0x94, 0x04, 0xee, 0xa3, 0xc0, 0xe2, 0x8f, 0x60, 0x83, 0xdf,
+	0x37, 0x7b, 0xc4, 0xb4, 0x0f, 0x09, 0x63, 0xc4, 0x27, 0xf6, 0x21, 0x31, 0x2f, 0x48, 0x70, 0xa9,
+	0xbb, 0x27, 0x70, 0x27, 0x8f, 0x53, 0xf9, 0xdc, 0x82, 0x52, 0x4f, 0xec, 0x64, 0x4f, 0x93, 0x11,
+	0xae, 0x33, 0x5d, 0x21, 0x71, 0x1f, 0x36, 0x85, 0xe6, 0x90, 0x5c, 0xdf, 0x24, 0xde, 0x13, 0x22,
+	0x8a, 0xd4, 0x26, 0x0e, 0xa3, 0x6c, 0x60, 0x9c, 0x9b, 0xc1, 0xb9, 0x08, 0x65, 0x71, 0x6b, 0x41,
+	0x47, 0x9c, 0x76, 0xa0, 0x48, 0x2f, 0x04, 0x05, 0x7f, 0x05, 0xf7, 0x26, 0xa8, 0x53, 0x7e, 0xdc,
+	0x87, 0xaa, 0x2f, 0x41, 0x76, 0x2a, 0x7f, 0x33, 0x7a, 0x25, 0xda, 0x95, 0xa1, 0xdc, 0x83, 0x95,
+	0xe7, 0x84, 0xbd, 0x0a, 0x5d, 0x66, 0x9e, 0xb8, 0x5d, 0xe2, 0x5c, 0x6e, 0x2f, 0x82, 0xe9, 0x30,
+	0x20, 0xbe, 0x48, 0x75, 0x59, 0x17, 0xdf, 0xb8, 0x0f, 0xf3, 0x29, 0x11, 0xbc, 0xa3, 0x3a, 0xbe,
+	0x1b, 0x7a, 0x82, 0xb3, 0xac, 0xcb, 0x05, 0x67, 0xec, 0x52, 0xc7, 0x8e, 0x18, 0xf9, 0x37, 0x5a,
+	0x85, 0x12, 0x13, 0x3c, 0xaa, 0x26, 0xd4, 0x0a, 0xdd, 0x86, 0x72, 0xe8, 0xf4, 0x68, 0x9f, 0xf2,
+	0x89, 0x63, 0x5a, 0xf4, 0x58, 0xb2, 0x81, 0x0f, 0x60, 0x75, 0xd4, 0x68, 0xe5, 0x75, 0x13, 0x66,
+	0xdb, 0xa1, 0xd5, 0x25, 0xf1, 0x65, 0xb0, 0x92, 0xa4, 0x2f, 0x8d, 0x8f, 0x50, 0xf8, 0x0b, 0x58,
+	0xe3, 0x05, 0x71, 0x44, 0x1c, 0x9b, 0x3a, 0x9d, 0xa1, 0x4b, 0xf7, 0x3e, 0x54, 0xa9, 0x63, 0xf5,
+	0x42, 0x5b, 0x95, 0x6d, 0xa0, 0x9a, 0xbd, 0xa2, 0x76, 0x45, 0x00, 0x03, 0x7c, 0x0c, 0xf3, 0x29,
+	0xee, 0xfc, 0xb8, 0x3d, 0x80, 0x5a, 0xe8, 0x04, 0x5c, 0xb6, 0x63, 0xc5, 0x39, 0x91, 0xfd, 0xb2,
+	0x98, 0x22, 0xc8, 0xb4, 0x3c, 0x87, 0x7a, 0xd6, 0x2c, 0xe5, 0xe3, 0x03, 0x98, 0xe1, 0x22, 0xc7,
+	0x78, 0x98, 0x82, 0xeb, 0x12, 0x83, 0x6d, 0x95, 0x99, 0x5d, 0xe1, 0xef, 0x35, 0x32, 0x93, 0xf2,
+	0xa3, 0x38, 0x36, 0xff, 0xd3, 0xa9, 0xfc, 0xff, 0x59, 0x50, 0x6a, 0x9e, 0xb9, 0xce, 0x19, 0xed,
+	0xa0, 0x87, 0x50, 0x92, 0x01, 0x56, 0xe3, 0xd2, 0x68, 0x16, 0xa4, 0x35, 0xba, 0x02, 0xf1, 0x73,
+	0xa6, 0x6f, 0xbe, 0x31, 0x54, 0x25, 0xc8, 0x98, 0x94, 0xfb, 0xe6, 0x1b, 0x55, 0x4e, 0x0d, 0x58,
+	0x92, 0x24, 0x83, 0xb9, 0x86, 0x4f, 0xbc, 0x1e, 0x71, 0x68, 0x70, 0xae, 0xcc, 0xaa, 0x49, 0xd2,
+	0x89, 0xab, 0x47, 0x04, 0xf4, 0x02, 0x50, 0x8c, 0x32, 0xa8, 0xc3, 0x88, 0x7f, 0x61, 0xf6, 0xd4,
+	0xec, 0xb2, 0x9e, 0xb9, 0xd5, 0xf6, 0xd4, 0x68, 0xad, 0xd7, 0x62, 0xa6, 0x03, 0xc5, 0x83, 0x0f,
+	0xa0, 0x2e, 0x87, 0xce, 0x94, 0x73, 0x51, 0x79, 0x3c, 0x84, 0x92, 0x25, 0x36, 0x72, 0x7c, 0x54,
+	0x68, 0x05, 0xc2, 0x3f, 0x16, 0xa0, 0x2e, 0xa7, 0x8d, 0xf7, 0x96, 0xf5, 0x7e, 0x73, 0xe6, 0x01,
+	0xd4, 0xe5, 0xf0, 0x31, 0xde, 0x8e, 0x6b, 0xe4, 0x0d, 0xaf, 0xcb, 0xe6, 0x49, 0x09, 0x8a, 0x9a,
+	0x07, 0xbf, 0x84, 0x7a, 0x96, 0x94, 0x34, 0xa9, 0x74, 0x24, 0xaf, 0x49, 0x95, 0x51, 0x11, 0xaa,
+	0xf5, 0x4b, 0x05, 0x2a, 0x27, 0x0a, 0xb1, 0xc3, 0x1f, 0x73, 0x68, 0x1f, 0xca, 0xf1, 0x8b, 0x03,
+	0xa5, 0x8e, 0xe8, 0xd1, 0xd7, 0x8b, 0x76, 0x6b, 0x2c, 0x4d, 0x1a, 0x82, 0x6f, 0xa0, 0x6f, 0x60,
+	0x56, 0xdd, 0x7e, 0xa8, 0x9e, 0x20, 0x87, 0xdf, 0x24, 0xda, 0xc8, 0xc0, 0x87, 0xf1, 0x0f, 0x7f,
+	0xfc, 0xf5, 0xd3, 0xd4, 0x6d, 0xa4, 0x35, 0x2f, 0x1e, 0xb7, 0x09, 0x33, 0x1f, 0x37, 0x45, 0xaf,
+	0x35, 0xdf, 0xa9, 0x86, 0xf9, 0x6c, 0xfb, 0x7b, 0x74, 0x02, 0x90, 0x3c, 0x57, 0x50, 0xca, 0x8a,
+	0xcc, 0x23, 0x26, 0x23, 0x7e, 0x5d, 0x88, 0x5f, 0xc2, 0xd5, 0x61, 0xf1, 0x1f, 0x17, 0xb6, 0x11,
+	0x01, 0x48, 0x46, 0xe0, 0xb4, 0xd4, 0xcc, 0x7b, 0x25, 0x23, 0x75, 0x5b, 0x48, 0xfd, 0x77, 0xeb,
+	0xee, 0x38, 0xa3, 0x1b, 0x89, 0xe5, 0x5c, 0xcd, 0x6b, 0x58, 0x1c, 0x1d, 0xb7, 0xd1, 0xbd, 0x44,
+	0x5e, 0xce, 0x2b, 0x45, 0xc3, 0x93, 0x20, 0x71, 0xc8, 0x09, 0x40, 0x32, 0xfc, 0xa6, 0x7d, 0xc8,
+	0x8c, 0xc4, 0xda, 0x6a, 0xa6, 0xa4, 0xbf, 0xe4, 0xaf, 0xd8, 0x28, 0x01, 0xdb, 0x93, 0x12, 0xf0,
+	0x39, 0x2c, 0xa4, 0xa7, 0x66, 0xb4, 0x91, 0x0a, 0x56, 0x76, 0x9a, 0xce, 0x84, 0xeb, 0x06, 0x3a,
+	0x85, 0xea, 0xf0, 0xfc, 0x87, 0xee, 0x26, 0x98, 0xb1, 0x23, 0xa6, 0xb6, 0x99, 0x0f, 0x88, 0xdd,
+	0x7f, 0x0d, 0x8b, 0xa3, 0xf3, 0x56, 0x3a, 0xb6, 0x39, 0x03, 0x9c, 0x86, 0x27, 0x41, 0x62, 0xe1,
+	0x7d, 0x58, 0x1d, 0x3f, 0xde, 0xa0, 0xff, 0x0e, 0xf7, 0x41, 0xee, 0x9c, 0xa2, 0x6d, 0x5d, 0x0e,
+	0x8c, 0xd5, 0x5d, 0xc0, 0x7a, 0xee, 0x20, 0x82, 0xb6, 0xd3, 0xc1, 0x98, 0x3c, 0x1c, 0x69, 0x0f,
+	0xae, 0x84, 0x8d, 0xf5, 0x9e, 0x8a, 0xbf, 0x0d, 0xd2, 0x13, 0xc7, 0xdd, 0xa1, 0xf0, 0x64, 0xc7,
+	0x19, 0x6d, 0x33, 0x1f, 0x90, 0x4e, 0xcd, 0xe8, 0xa5, 0x9b, 0x4e, 0x4d, 0xce, 0x9c, 0xa0, 0xe1,
+	0x49, 0x90, 0x58, 0xf8, 0x51, 0xf4, 0xff, 0x45, 0xfa, 0x9e, 0xc4, 0xa3, 0xe7, 0x42, 0xf6, 0x4c,
+	0xd6, 0xc6, 0x1f, 0x8e, 0x52, 0x62, 0xe6, 0x42, 0x49, 0x4b, 0xcc, 0xbb, 0x6d, 0xf2, 0x25, 0xbe,
+	0x8a, 0xde, 0xa5, 0x39, 0x12, 0xf3, 0xee, 0x8d, 0xdc, 0x46, 0x8d, 0x63, 0x9a, 0xe2, 0xc9, 0xc4,
+	0x74, 0xcc, 0xf5, 0xa1, 0xe1, 0x49, 0x90, 0x38, 0xa6, 0x3b, 0x50, 0x19, 0x7a, 0xe8, 0xa2, 0x3b,
+	0x09, 0xdb, 0xb8, 0x17, 0xf0, 0x98, 0x2e, 0xe7, 0x22, 0xd2, 0xef, 0xcb, 0x21, 0x11, 0x63, 0x1e,
+	0x9e, 0x59, 0x11, 0xbb, 0x4f, 0x60, 0xdd, 0x72, 0xfb, 0x51, 0x04, 0x86, 0xff, 0x51, 0xdc, 0x5d,
+	0x19, 0xba, 0xb7, 0x76, 0x3c, 0x7a, 0xc4, 0xb7, 0x8f, 0x0a, 0xed, 0x92, 0xa0, 0x7f, 0xf0, 0xf7,
+	0x00, 0xa0, 0x19, 0xea, 0x17, 0xa7, 0x14, 0x00, 0x00,
 }
@@ -12,6 +12,7 @@ It is generated from these files:
 
 It has these top-level messages:
 	LogLeaf
+	DeadLetteredLeaf
 	Proof
 	QueuedLogLeaf
 	QueueLeavesRequest
@@ -20,26 +21,56 @@ It has these top-level messages:
 	QueueLeavesResponse
 	GetInclusionProofRequest
 	GetInclusionProofResponse
+	GetInclusionProofWithRootRequest
+	GetInclusionProofWithRootResponse
 	GetInclusionProofByHashRequest
 	GetInclusionProofByHashResponse
 	GetConsistencyProofRequest
 	GetConsistencyProofResponse
+	CheckConsistencyProofRequest
+	CheckConsistencyProofResponse
+	GetProofByMerkleHashRequest
+	GetProofByMerkleHashResponse
 	GetLeavesByHashRequest
 	GetLeavesByHashResponse
+	HasLeavesRequest
+	HasLeavesResponse
+	CountLeavesRequest
+	CountLeavesResponse
 	GetLeavesByIndexRequest
 	GetLeavesByIndexResponse
+	GetEntriesRequest
+	GetEntriesResponse
+	GetSignedLogRootHistoryRequest
+	GetSignedLogRootHistoryResponse
 	GetSequencedLeafCountRequest
 	GetSequencedLeafCountResponse
 	GetLatestSignedLogRootRequest
 	GetLatestSignedLogRootResponse
+	GetLatestCheckpointRequest
+	GetLatestCheckpointResponse
+	GetSignedLogRootAtTimeRequest
+	GetSignedLogRootAtTimeResponse
 	GetEntryAndProofRequest
 	GetEntryAndProofResponse
+	GetLatestLeafByIdentityHashPrefixRequest
+	GetLatestLeafByIdentityHashPrefixResponse
+	AddCosignatureRequest
+	AddCosignatureResponse
+	GetLatestCosignedLogRootRequest
+	GetLatestCosignedLogRootResponse
 	MapLeaf
 	MapLeafInclusion
 	GetMapLeavesRequest
 	GetMapLeavesResponse
+	MapLeafAtRevision
+	GetMapLeavesByRevisionsRequest
+	MapLeafRevisionInclusion
+	GetMapLeavesByRevisionsResponse
 	SetMapLeavesRequest
 	SetMapLeavesResponse
+	QueueMapLeavesRequest
+	QueueMapLeavesResponse
 	GetSignedMapRootRequest
 	GetSignedMapRootByRevisionRequest
 	GetSignedMapRootResponse
@@ -48,8 +79,44 @@ It has these top-level messages:
 	GetTreeRequest
 	CreateTreeRequest
 	UpdateTreeRequest
+	BatchUpdateTreesRequest
+	BatchUpdateTreesResponse
+	TreeUpdateResult
 	DeleteTreeRequest
+	UndeleteTreeRequest
+	RotateTreeKeyRequest
+	RetireTreeKeyRequest
+	RepairTreeRootRequest
+	RepairTreeRootResponse
+	GetTreeFootprintRequest
+	GetTreeFootprintResponse
+	ListDeadLetteredLeavesRequest
+	ListDeadLetteredLeavesResponse
+	RequeueDeadLetteredLeavesRequest
+	RequeueDeadLetteredLeavesResponse
+	GetQuotaTokensRequest
+	QuotaTokens
+	GetQuotaTokensResponse
+	ListPendingTreesRequest
+	PendingTree
+	ListPendingTreesResponse
+	QuotaBucket
+	QuotaConfig
+	CreateQuotaConfigRequest
+	UpdateQuotaConfigRequest
+	DeleteQuotaConfigRequest
+	ListQuotaConfigsRequest
+	ListQuotaConfigsResponse
 	Tree
+	KeyRotation
+	RetiredKey
+	AccessPolicy
+	SecondarySigner
+	DeadLetterPolicy
+	RootRetention
+	DedupWindow
+	Witness
+	Cosignature
 	SignedEntryTimestamp
 	SignedLogRoot
 	MapperMetadata
@@ -62,6 +129,7 @@ import fmt "fmt"
 import math "math"
 import google_rpc "google.golang.org/genproto/googleapis/rpc/status"
 import _ "google.golang.org/genproto/googleapis/api/annotations"
+import google_protobuf2 "github.com/golang/protobuf/ptypes/timestamp"
 
 import (
 	context "golang.org/x/net/context"
@@ -79,6 +147,72 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// ConsistencyProofCheck is the outcome of checking a client-held consistency
+// proof.
+type ConsistencyProofCheck int32
+
+const (
+	ConsistencyProofCheck_UNKNOWN_CONSISTENCY_PROOF_CHECK ConsistencyProofCheck = 0
+	// Both root hashes are the log's roots at their tree sizes, and the proof
+	// verifies between them.
+	ConsistencyProofCheck_CONSISTENCY_PROOF_VALID ConsistencyProofCheck = 1
+	// Both root hashes are the log's roots at their tree sizes, but the proof
+	// doesn't verify between them.
+	ConsistencyProofCheck_CONSISTENCY_PROOF_INVALID ConsistencyProofCheck = 2
+	// At least one of the root hashes isn't the log's root at its tree size,
+	// whether or not the proof verifies between them.
+	ConsistencyProofCheck_CONSISTENCY_PROOF_ROOT_MISMATCH ConsistencyProofCheck = 3
+)
+
+var ConsistencyProofCheck_name = map[int32]string{
+	0: "UNKNOWN_CONSISTENCY_PROOF_CHECK",
+	1: "CONSISTENCY_PROOF_VALID",
+	2: "CONSISTENCY_PROOF_INVALID",
+	3: "CONSISTENCY_PROOF_ROOT_MISMATCH",
+}
+var ConsistencyProofCheck_value = map[string]int32{
+	"UNKNOWN_CONSISTENCY_PROOF_CHECK": 0,
+	"CONSISTENCY_PROOF_VALID":         1,
+	"CONSISTENCY_PROOF_INVALID":       2,
+	"CONSISTENCY_PROOF_ROOT_MISMATCH": 3,
+}
+
+func (x ConsistencyProofCheck) String() string {
+	return proto.EnumName(ConsistencyProofCheck_name, int32(x))
+}
+func (ConsistencyProofCheck) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+// LeafIndexStatus is the outcome of fetching a single leaf by index.
+type LeafIndexStatus int32
+
+const (
+	LeafIndexStatus_UNKNOWN_LEAF_INDEX_STATUS LeafIndexStatus = 0
+	// The leaf was found and is included in the response.
+	LeafIndexStatus_LEAF_FOUND LeafIndexStatus = 1
+	// The index is negative or beyond the size of the latest signed root.
+	LeafIndexStatus_LEAF_INDEX_OUT_OF_RANGE LeafIndexStatus = 2
+	// The index is within the tree, but the leaf could not be found.
+	LeafIndexStatus_LEAF_NOT_FOUND LeafIndexStatus = 3
+)
+
+var LeafIndexStatus_name = map[int32]string{
+	0: "UNKNOWN_LEAF_INDEX_STATUS",
+	1: "LEAF_FOUND",
+	2: "LEAF_INDEX_OUT_OF_RANGE",
+	3: "LEAF_NOT_FOUND",
+}
+var LeafIndexStatus_value = map[string]int32{
+	"UNKNOWN_LEAF_INDEX_STATUS": 0,
+	"LEAF_FOUND":                1,
+	"LEAF_INDEX_OUT_OF_RANGE":   2,
+	"LEAF_NOT_FOUND":            3,
+}
+
+func (x LeafIndexStatus) String() string {
+	return proto.EnumName(LeafIndexStatus_name, int32(x))
+}
+func (LeafIndexStatus) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
 type LogLeaf struct {
 	// merkle_leaf_hash is over leaf data and optional extra_data.
 	MerkleLeafHash []byte `protobuf:"bytes,1,opt,name=merkle_leaf_hash,json=merkleLeafHash,proto3" json:"merkle_leaf_hash,omitempty"`
@@ -106,6 +240,12 @@ type LogLeaf struct {
 	// personality which fetches and submits the entries might set
 	// leaf_identity_hash to H(seq||certdata).
 	LeafIdentityHash []byte `protobuf:"bytes,5,opt,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
+	// queue_timestamp is the time at which the leaf was queued.
+	// It's assigned by Trillian on QueueLeaves, unless supplied by the client
+	// for trees that accept client timestamps (see
+	// Tree.max_client_timestamp_skew), in which case it must be within the
+	// tree's skew window relative to the server's clock.
+	QueueTimestamp *google_protobuf2.Timestamp `protobuf:"bytes,6,opt,name=queue_timestamp,json=queueTimestamp" json:"queue_timestamp,omitempty"`
 }
 
 func (m *LogLeaf) Reset()                    { *m = LogLeaf{} }
@@ -148,6 +288,59 @@ func (m *LogLeaf) GetLeafIdentityHash() []byte {
 	return nil
 }
 
+func (m *LogLeaf) GetQueueTimestamp() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.QueueTimestamp
+	}
+	return nil
+}
+
+// DeadLetteredLeaf is a queued leaf that was set aside because it repeatedly
+// failed to be sequenced. See Tree.dead_letter_policy.
+type DeadLetteredLeaf struct {
+	// The leaf, as queued. Its leaf_index is not set.
+	Leaf *LogLeaf `protobuf:"bytes,1,opt,name=leaf" json:"leaf,omitempty"`
+	// Why the leaf couldn't be sequenced.
+	Reason string `protobuf:"bytes,2,opt,name=reason" json:"reason,omitempty"`
+	// Number of sequencing passes the leaf failed.
+	Attempts int32 `protobuf:"varint,3,opt,name=attempts" json:"attempts,omitempty"`
+	// When the leaf was dead-lettered.
+	DeadLetterTime *google_protobuf2.Timestamp `protobuf:"bytes,4,opt,name=dead_letter_time,json=deadLetterTime" json:"dead_letter_time,omitempty"`
+}
+
+func (m *DeadLetteredLeaf) Reset()                    { *m = DeadLetteredLeaf{} }
+func (m *DeadLetteredLeaf) String() string            { return proto.CompactTextString(m) }
+func (*DeadLetteredLeaf) ProtoMessage()               {}
+func (*DeadLetteredLeaf) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *DeadLetteredLeaf) GetLeaf() *LogLeaf {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *DeadLetteredLeaf) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *DeadLetteredLeaf) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *DeadLetteredLeaf) GetDeadLetterTime() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.DeadLetterTime
+	}
+	return nil
+}
+
 type Proof struct {
 	LeafIndex int64    `protobuf:"varint,1,opt,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
 	Hashes    [][]byte `protobuf:"bytes,3,rep,name=hashes,proto3" json:"hashes,omitempty"`
@@ -156,7 +349,7 @@ type Proof struct {
 func (m *Proof) Reset()                    { *m = Proof{} }
 func (m *Proof) String() string            { return proto.CompactTextString(m) }
 func (*Proof) ProtoMessage()               {}
-func (*Proof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Proof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *Proof) GetLeafIndex() int64 {
 	if m != nil {
@@ -180,12 +373,18 @@ type QueuedLogLeaf struct {
 	//  - google.rpc.ALREADY_EXISTS : the leaf is the one already present in the log.
 	Leaf   *LogLeaf           `protobuf:"bytes,1,opt,name=leaf" json:"leaf,omitempty"`
 	Status *google_rpc.Status `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// The number of leaves that were queued but not yet sequenced when the leaf was
+	// queued, including earlier new leaves of the same request; i.e. the leaves
+	// ahead of it in the queue. Only set for new leaves, and only if
+	// return_queue_depth was set in the request. This is best-effort: leaves queued
+	// concurrently may not be counted.
+	QueueDepth int64 `protobuf:"varint,3,opt,name=queue_depth,json=queueDepth" json:"queue_depth,omitempty"`
 }
 
 func (m *QueuedLogLeaf) Reset()                    { *m = QueuedLogLeaf{} }
 func (m *QueuedLogLeaf) String() string            { return proto.CompactTextString(m) }
 func (*QueuedLogLeaf) ProtoMessage()               {}
-func (*QueuedLogLeaf) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*QueuedLogLeaf) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *QueuedLogLeaf) GetLeaf() *LogLeaf {
 	if m != nil {
@@ -201,15 +400,25 @@ func (m *QueuedLogLeaf) GetStatus() *google_rpc.Status {
 	return nil
 }
 
+func (m *QueuedLogLeaf) GetQueueDepth() int64 {
+	if m != nil {
+		return m.QueueDepth
+	}
+	return 0
+}
+
 type QueueLeavesRequest struct {
 	LogId  int64      `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+	// If true, queue_depth is set in the response for each new leaf, at the cost
+	// of counting the queued leaves of the log.
+	ReturnQueueDepth bool `protobuf:"varint,3,opt,name=return_queue_depth,json=returnQueueDepth" json:"return_queue_depth,omitempty"`
 }
 
 func (m *QueueLeavesRequest) Reset()                    { *m = QueueLeavesRequest{} }
 func (m *QueueLeavesRequest) String() string            { return proto.CompactTextString(m) }
 func (*QueueLeavesRequest) ProtoMessage()               {}
-func (*QueueLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*QueueLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 func (m *QueueLeavesRequest) GetLogId() int64 {
 	if m != nil {
@@ -225,6 +434,13 @@ func (m *QueueLeavesRequest) GetLeaves() []*LogLeaf {
 	return nil
 }
 
+func (m *QueueLeavesRequest) GetReturnQueueDepth() bool {
+	if m != nil {
+		return m.ReturnQueueDepth
+	}
+	return false
+}
+
 type QueueLeafRequest struct {
 	LogId int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	Leaf  *LogLeaf `protobuf:"bytes,2,opt,name=leaf" json:"leaf,omitempty"`
@@ -233,7 +449,7 @@ type QueueLeafRequest struct {
 func (m *QueueLeafRequest) Reset()                    { *m = QueueLeafRequest{} }
 func (m *QueueLeafRequest) String() string            { return proto.CompactTextString(m) }
 func (*QueueLeafRequest) ProtoMessage()               {}
-func (*QueueLeafRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*QueueLeafRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
 func (m *QueueLeafRequest) GetLogId() int64 {
 	if m != nil {
@@ -256,7 +472,7 @@ type QueueLeafResponse struct {
 func (m *QueueLeafResponse) Reset()                    { *m = QueueLeafResponse{} }
 func (m *QueueLeafResponse) String() string            { return proto.CompactTextString(m) }
 func (*QueueLeafResponse) ProtoMessage()               {}
-func (*QueueLeafResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*QueueLeafResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *QueueLeafResponse) GetQueuedLeaf() *QueuedLogLeaf {
 	if m != nil {
@@ -273,7 +489,7 @@ type QueueLeavesResponse struct {
 func (m *QueueLeavesResponse) Reset()                    { *m = QueueLeavesResponse{} }
 func (m *QueueLeavesResponse) String() string            { return proto.CompactTextString(m) }
 func (*QueueLeavesResponse) ProtoMessage()               {}
-func (*QueueLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*QueueLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *QueueLeavesResponse) GetQueuedLeaves() []*QueuedLogLeaf {
 	if m != nil {
@@ -291,7 +507,7 @@ type GetInclusionProofRequest struct {
 func (m *GetInclusionProofRequest) Reset()                    { *m = GetInclusionProofRequest{} }
 func (m *GetInclusionProofRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetInclusionProofRequest) ProtoMessage()               {}
-func (*GetInclusionProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*GetInclusionProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *GetInclusionProofRequest) GetLogId() int64 {
 	if m != nil {
@@ -316,12 +532,16 @@ func (m *GetInclusionProofRequest) GetTreeSize() int64 {
 
 type GetInclusionProofResponse struct {
 	Proof *Proof `protobuf:"bytes,2,opt,name=proof" json:"proof,omitempty"`
+	// Whether the proof was served from memory while the log's storage was
+	// unavailable. Stale proofs are against the latest root the server had
+	// seen, which may not be the log's latest root.
+	Stale bool `protobuf:"varint,3,opt,name=stale" json:"stale,omitempty"`
 }
 
 func (m *GetInclusionProofResponse) Reset()                    { *m = GetInclusionProofResponse{} }
 func (m *GetInclusionProofResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetInclusionProofResponse) ProtoMessage()               {}
-func (*GetInclusionProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*GetInclusionProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
 func (m *GetInclusionProofResponse) GetProof() *Proof {
 	if m != nil {
@@ -330,6 +550,77 @@ func (m *GetInclusionProofResponse) GetProof() *Proof {
 	return nil
 }
 
+func (m *GetInclusionProofResponse) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+type GetInclusionProofWithRootRequest struct {
+	LogId     int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIndex int64 `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
+	// The size of the signed root the proof is for. A root must have been
+	// signed at exactly this size.
+	TreeSize int64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize" json:"tree_size,omitempty"`
+}
+
+func (m *GetInclusionProofWithRootRequest) Reset()         { *m = GetInclusionProofWithRootRequest{} }
+func (m *GetInclusionProofWithRootRequest) String() string { return proto.CompactTextString(m) }
+func (*GetInclusionProofWithRootRequest) ProtoMessage()    {}
+func (*GetInclusionProofWithRootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{10}
+}
+
+func (m *GetInclusionProofWithRootRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetInclusionProofWithRootRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *GetInclusionProofWithRootRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+type GetInclusionProofWithRootResponse struct {
+	// Inclusion proof of the leaf in the tree of size tree_size.
+	Proof *Proof `protobuf:"bytes,1,opt,name=proof" json:"proof,omitempty"`
+	// The root the log signed at tree_size, which the proof terminates in.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+}
+
+func (m *GetInclusionProofWithRootResponse) Reset()         { *m = GetInclusionProofWithRootResponse{} }
+func (m *GetInclusionProofWithRootResponse) String() string { return proto.CompactTextString(m) }
+func (*GetInclusionProofWithRootResponse) ProtoMessage()    {}
+func (*GetInclusionProofWithRootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{11}
+}
+
+func (m *GetInclusionProofWithRootResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *GetInclusionProofWithRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
 type GetInclusionProofByHashRequest struct {
 	LogId           int64  `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
 	LeafHash        []byte `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
@@ -340,7 +631,7 @@ type GetInclusionProofByHashRequest struct {
 func (m *GetInclusionProofByHashRequest) Reset()                    { *m = GetInclusionProofByHashRequest{} }
 func (m *GetInclusionProofByHashRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetInclusionProofByHashRequest) ProtoMessage()               {}
-func (*GetInclusionProofByHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (*GetInclusionProofByHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
 
 func (m *GetInclusionProofByHashRequest) GetLogId() int64 {
 	if m != nil {
@@ -381,7 +672,7 @@ func (m *GetInclusionProofByHashResponse) Reset()         { *m = GetInclusionPro
 func (m *GetInclusionProofByHashResponse) String() string { return proto.CompactTextString(m) }
 func (*GetInclusionProofByHashResponse) ProtoMessage()    {}
 func (*GetInclusionProofByHashResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{10}
+	return fileDescriptor0, []int{13}
 }
 
 func (m *GetInclusionProofByHashResponse) GetProof() []*Proof {
@@ -400,7 +691,7 @@ type GetConsistencyProofRequest struct {
 func (m *GetConsistencyProofRequest) Reset()                    { *m = GetConsistencyProofRequest{} }
 func (m *GetConsistencyProofRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetConsistencyProofRequest) ProtoMessage()               {}
-func (*GetConsistencyProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*GetConsistencyProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *GetConsistencyProofRequest) GetLogId() int64 {
 	if m != nil {
@@ -430,7 +721,7 @@ type GetConsistencyProofResponse struct {
 func (m *GetConsistencyProofResponse) Reset()                    { *m = GetConsistencyProofResponse{} }
 func (m *GetConsistencyProofResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetConsistencyProofResponse) ProtoMessage()               {}
-func (*GetConsistencyProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*GetConsistencyProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *GetConsistencyProofResponse) GetProof() *Proof {
 	if m != nil {
@@ -439,218 +730,905 @@ func (m *GetConsistencyProofResponse) GetProof() *Proof {
 	return nil
 }
 
-type GetLeavesByHashRequest struct {
-	LogId           int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
-	LeafHash        [][]byte `protobuf:"bytes,2,rep,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
-	OrderBySequence bool     `protobuf:"varint,3,opt,name=order_by_sequence,json=orderBySequence" json:"order_by_sequence,omitempty"`
+type CheckConsistencyProofRequest struct {
+	LogId          int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	FirstTreeSize  int64 `protobuf:"varint,2,opt,name=first_tree_size,json=firstTreeSize" json:"first_tree_size,omitempty"`
+	SecondTreeSize int64 `protobuf:"varint,3,opt,name=second_tree_size,json=secondTreeSize" json:"second_tree_size,omitempty"`
+	// The client's root hashes of the log at first_tree_size and
+	// second_tree_size.
+	FirstRootHash  []byte `protobuf:"bytes,4,opt,name=first_root_hash,json=firstRootHash,proto3" json:"first_root_hash,omitempty"`
+	SecondRootHash []byte `protobuf:"bytes,5,opt,name=second_root_hash,json=secondRootHash,proto3" json:"second_root_hash,omitempty"`
+	// The consistency proof held by the client, from first_tree_size to
+	// second_tree_size.
+	Proof *Proof `protobuf:"bytes,6,opt,name=proof" json:"proof,omitempty"`
 }
 
-func (m *GetLeavesByHashRequest) Reset()                    { *m = GetLeavesByHashRequest{} }
-func (m *GetLeavesByHashRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetLeavesByHashRequest) ProtoMessage()               {}
-func (*GetLeavesByHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (m *CheckConsistencyProofRequest) Reset()                    { *m = CheckConsistencyProofRequest{} }
+func (m *CheckConsistencyProofRequest) String() string            { return proto.CompactTextString(m) }
+func (*CheckConsistencyProofRequest) ProtoMessage()               {}
+func (*CheckConsistencyProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
-func (m *GetLeavesByHashRequest) GetLogId() int64 {
+func (m *CheckConsistencyProofRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetLeavesByHashRequest) GetLeafHash() [][]byte {
+func (m *CheckConsistencyProofRequest) GetFirstTreeSize() int64 {
 	if m != nil {
-		return m.LeafHash
+		return m.FirstTreeSize
 	}
-	return nil
+	return 0
 }
 
-func (m *GetLeavesByHashRequest) GetOrderBySequence() bool {
+func (m *CheckConsistencyProofRequest) GetSecondTreeSize() int64 {
 	if m != nil {
-		return m.OrderBySequence
+		return m.SecondTreeSize
 	}
-	return false
-}
-
-type GetLeavesByHashResponse struct {
-	// TODO(gbelvin) reply with error codes.
-	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+	return 0
 }
 
-func (m *GetLeavesByHashResponse) Reset()                    { *m = GetLeavesByHashResponse{} }
-func (m *GetLeavesByHashResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetLeavesByHashResponse) ProtoMessage()               {}
-func (*GetLeavesByHashResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
-
-func (m *GetLeavesByHashResponse) GetLeaves() []*LogLeaf {
+func (m *CheckConsistencyProofRequest) GetFirstRootHash() []byte {
 	if m != nil {
-		return m.Leaves
+		return m.FirstRootHash
 	}
 	return nil
 }
 
-type GetLeavesByIndexRequest struct {
-	LogId     int64   `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
-	LeafIndex []int64 `protobuf:"varint,2,rep,packed,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
-}
-
-func (m *GetLeavesByIndexRequest) Reset()                    { *m = GetLeavesByIndexRequest{} }
-func (m *GetLeavesByIndexRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetLeavesByIndexRequest) ProtoMessage()               {}
-func (*GetLeavesByIndexRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
-
-func (m *GetLeavesByIndexRequest) GetLogId() int64 {
+func (m *CheckConsistencyProofRequest) GetSecondRootHash() []byte {
 	if m != nil {
-		return m.LogId
+		return m.SecondRootHash
 	}
-	return 0
+	return nil
 }
 
-func (m *GetLeavesByIndexRequest) GetLeafIndex() []int64 {
+func (m *CheckConsistencyProofRequest) GetProof() *Proof {
 	if m != nil {
-		return m.LeafIndex
+		return m.Proof
 	}
 	return nil
 }
 
-type GetLeavesByIndexResponse struct {
-	// TODO(gbelvin) reply with error codes.
-	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+type CheckConsistencyProofResponse struct {
+	Result ConsistencyProofCheck `protobuf:"varint,1,opt,name=result,enum=trillian.ConsistencyProofCheck" json:"result,omitempty"`
+	// Why the check failed, for debugging. Empty if the proof is valid.
+	Detail string `protobuf:"bytes,2,opt,name=detail" json:"detail,omitempty"`
 }
 
-func (m *GetLeavesByIndexResponse) Reset()                    { *m = GetLeavesByIndexResponse{} }
-func (m *GetLeavesByIndexResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetLeavesByIndexResponse) ProtoMessage()               {}
-func (*GetLeavesByIndexResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (m *CheckConsistencyProofResponse) Reset()                    { *m = CheckConsistencyProofResponse{} }
+func (m *CheckConsistencyProofResponse) String() string            { return proto.CompactTextString(m) }
+func (*CheckConsistencyProofResponse) ProtoMessage()               {}
+func (*CheckConsistencyProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
-func (m *GetLeavesByIndexResponse) GetLeaves() []*LogLeaf {
+func (m *CheckConsistencyProofResponse) GetResult() ConsistencyProofCheck {
 	if m != nil {
-		return m.Leaves
+		return m.Result
 	}
-	return nil
+	return ConsistencyProofCheck_UNKNOWN_CONSISTENCY_PROOF_CHECK
 }
 
-type GetSequencedLeafCountRequest struct {
-	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+func (m *CheckConsistencyProofResponse) GetDetail() string {
+	if m != nil {
+		return m.Detail
+	}
+	return ""
 }
 
-func (m *GetSequencedLeafCountRequest) Reset()                    { *m = GetSequencedLeafCountRequest{} }
-func (m *GetSequencedLeafCountRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetSequencedLeafCountRequest) ProtoMessage()               {}
-func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+type GetProofByMerkleHashRequest struct {
+	LogId    int64  `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafHash []byte `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	// The tree size the client last saw. The consistency proof is from this size to tree_size.
+	FirstTreeSize int64 `protobuf:"varint,3,opt,name=first_tree_size,json=firstTreeSize" json:"first_tree_size,omitempty"`
+	// Must not exceed the size of the latest signed log root.
+	TreeSize        int64 `protobuf:"varint,4,opt,name=tree_size,json=treeSize" json:"tree_size,omitempty"`
+	OrderBySequence bool  `protobuf:"varint,5,opt,name=order_by_sequence,json=orderBySequence" json:"order_by_sequence,omitempty"`
+}
 
-func (m *GetSequencedLeafCountRequest) GetLogId() int64 {
+func (m *GetProofByMerkleHashRequest) Reset()                    { *m = GetProofByMerkleHashRequest{} }
+func (m *GetProofByMerkleHashRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetProofByMerkleHashRequest) ProtoMessage()               {}
+func (*GetProofByMerkleHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *GetProofByMerkleHashRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-type GetSequencedLeafCountResponse struct {
-	LeafCount int64 `protobuf:"varint,2,opt,name=leaf_count,json=leafCount" json:"leaf_count,omitempty"`
+func (m *GetProofByMerkleHashRequest) GetLeafHash() []byte {
+	if m != nil {
+		return m.LeafHash
+	}
+	return nil
 }
 
-func (m *GetSequencedLeafCountResponse) Reset()                    { *m = GetSequencedLeafCountResponse{} }
-func (m *GetSequencedLeafCountResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetSequencedLeafCountResponse) ProtoMessage()               {}
-func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
-
-func (m *GetSequencedLeafCountResponse) GetLeafCount() int64 {
+func (m *GetProofByMerkleHashRequest) GetFirstTreeSize() int64 {
 	if m != nil {
-		return m.LeafCount
+		return m.FirstTreeSize
 	}
 	return 0
 }
 
-type GetLatestSignedLogRootRequest struct {
-	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+func (m *GetProofByMerkleHashRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
 }
 
-func (m *GetLatestSignedLogRootRequest) Reset()                    { *m = GetLatestSignedLogRootRequest{} }
-func (m *GetLatestSignedLogRootRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetLatestSignedLogRootRequest) ProtoMessage()               {}
-func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
-
-func (m *GetLatestSignedLogRootRequest) GetLogId() int64 {
+func (m *GetProofByMerkleHashRequest) GetOrderBySequence() bool {
 	if m != nil {
-		return m.LogId
+		return m.OrderBySequence
 	}
-	return 0
+	return false
 }
 
-type GetLatestSignedLogRootResponse struct {
-	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+type GetProofByMerkleHashResponse struct {
+	// Inclusion proof at tree_size for the first leaf with the requested hash
+	// that is included at tree_size.
+	InclusionProof *Proof `protobuf:"bytes,1,opt,name=inclusion_proof,json=inclusionProof" json:"inclusion_proof,omitempty"`
+	// Consistency proof from first_tree_size to tree_size.
+	ConsistencyProof *Proof `protobuf:"bytes,2,opt,name=consistency_proof,json=consistencyProof" json:"consistency_proof,omitempty"`
 }
 
-func (m *GetLatestSignedLogRootResponse) Reset()                    { *m = GetLatestSignedLogRootResponse{} }
-func (m *GetLatestSignedLogRootResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetLatestSignedLogRootResponse) ProtoMessage()               {}
-func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+func (m *GetProofByMerkleHashResponse) Reset()                    { *m = GetProofByMerkleHashResponse{} }
+func (m *GetProofByMerkleHashResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetProofByMerkleHashResponse) ProtoMessage()               {}
+func (*GetProofByMerkleHashResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
-func (m *GetLatestSignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+func (m *GetProofByMerkleHashResponse) GetInclusionProof() *Proof {
 	if m != nil {
-		return m.SignedLogRoot
+		return m.InclusionProof
 	}
 	return nil
 }
 
-type GetEntryAndProofRequest struct {
-	LogId     int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
-	LeafIndex int64 `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
-	TreeSize  int64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize" json:"tree_size,omitempty"`
+func (m *GetProofByMerkleHashResponse) GetConsistencyProof() *Proof {
+	if m != nil {
+		return m.ConsistencyProof
+	}
+	return nil
 }
 
-func (m *GetEntryAndProofRequest) Reset()                    { *m = GetEntryAndProofRequest{} }
-func (m *GetEntryAndProofRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetEntryAndProofRequest) ProtoMessage()               {}
-func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+type GetLeavesByHashRequest struct {
+	LogId           int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafHash        [][]byte `protobuf:"bytes,2,rep,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	OrderBySequence bool     `protobuf:"varint,3,opt,name=order_by_sequence,json=orderBySequence" json:"order_by_sequence,omitempty"`
+}
 
-func (m *GetEntryAndProofRequest) GetLogId() int64 {
+func (m *GetLeavesByHashRequest) Reset()                    { *m = GetLeavesByHashRequest{} }
+func (m *GetLeavesByHashRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLeavesByHashRequest) ProtoMessage()               {}
+func (*GetLeavesByHashRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *GetLeavesByHashRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetEntryAndProofRequest) GetLeafIndex() int64 {
+func (m *GetLeavesByHashRequest) GetLeafHash() [][]byte {
 	if m != nil {
-		return m.LeafIndex
+		return m.LeafHash
 	}
-	return 0
+	return nil
 }
 
-func (m *GetEntryAndProofRequest) GetTreeSize() int64 {
+func (m *GetLeavesByHashRequest) GetOrderBySequence() bool {
 	if m != nil {
-		return m.TreeSize
+		return m.OrderBySequence
 	}
-	return 0
+	return false
 }
 
-type GetEntryAndProofResponse struct {
-	Proof *Proof   `protobuf:"bytes,2,opt,name=proof" json:"proof,omitempty"`
-	Leaf  *LogLeaf `protobuf:"bytes,3,opt,name=leaf" json:"leaf,omitempty"`
+type GetLeavesByHashResponse struct {
+	// TODO(gbelvin) reply with error codes.
+	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
 }
 
-func (m *GetEntryAndProofResponse) Reset()                    { *m = GetEntryAndProofResponse{} }
-func (m *GetEntryAndProofResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetEntryAndProofResponse) ProtoMessage()               {}
-func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+func (m *GetLeavesByHashResponse) Reset()                    { *m = GetLeavesByHashResponse{} }
+func (m *GetLeavesByHashResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLeavesByHashResponse) ProtoMessage()               {}
+func (*GetLeavesByHashResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
-func (m *GetEntryAndProofResponse) GetProof() *Proof {
+func (m *GetLeavesByHashResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
-		return m.Proof
+		return m.Leaves
 	}
 	return nil
 }
 
-func (m *GetEntryAndProofResponse) GetLeaf() *LogLeaf {
+type HasLeavesRequest struct {
+	LogId            int64    `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIdentityHash [][]byte `protobuf:"bytes,2,rep,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
+	// If true, leaves that are queued but not yet sequenced count as present.
+	IncludeUnsequenced bool `protobuf:"varint,3,opt,name=include_unsequenced,json=includeUnsequenced" json:"include_unsequenced,omitempty"`
+}
+
+func (m *HasLeavesRequest) Reset()                    { *m = HasLeavesRequest{} }
+func (m *HasLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*HasLeavesRequest) ProtoMessage()               {}
+func (*HasLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *HasLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *HasLeavesRequest) GetLeafIdentityHash() [][]byte {
+	if m != nil {
+		return m.LeafIdentityHash
+	}
+	return nil
+}
+
+func (m *HasLeavesRequest) GetIncludeUnsequenced() bool {
+	if m != nil {
+		return m.IncludeUnsequenced
+	}
+	return false
+}
+
+type HasLeavesResponse struct {
+	// Whether each of the requested leaf identity hashes is present, in the
+	// same order as the request.
+	Present []bool `protobuf:"varint,1,rep,packed,name=present" json:"present,omitempty"`
+}
+
+func (m *HasLeavesResponse) Reset()                    { *m = HasLeavesResponse{} }
+func (m *HasLeavesResponse) String() string            { return proto.CompactTextString(m) }
+func (*HasLeavesResponse) ProtoMessage()               {}
+func (*HasLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *HasLeavesResponse) GetPresent() []bool {
+	if m != nil {
+		return m.Present
+	}
+	return nil
+}
+
+type CountLeavesRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// If set, only leaves queued at or after this time are counted.
+	MinQueueTimestamp *google_protobuf2.Timestamp `protobuf:"bytes,2,opt,name=min_queue_timestamp,json=minQueueTimestamp" json:"min_queue_timestamp,omitempty"`
+	// If set, only leaves queued before this time are counted.
+	MaxQueueTimestamp *google_protobuf2.Timestamp `protobuf:"bytes,3,opt,name=max_queue_timestamp,json=maxQueueTimestamp" json:"max_queue_timestamp,omitempty"`
+	// If set, only leaves whose identity hash starts with this prefix are
+	// counted.
+	LeafIdentityHashPrefix []byte `protobuf:"bytes,4,opt,name=leaf_identity_hash_prefix,json=leafIdentityHashPrefix,proto3" json:"leaf_identity_hash_prefix,omitempty"`
+	// If true, only leaves that have been sequenced are counted. Otherwise
+	// queued leaves are counted too.
+	SequencedOnly bool `protobuf:"varint,5,opt,name=sequenced_only,json=sequencedOnly" json:"sequenced_only,omitempty"`
+}
+
+func (m *CountLeavesRequest) Reset()                    { *m = CountLeavesRequest{} }
+func (m *CountLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*CountLeavesRequest) ProtoMessage()               {}
+func (*CountLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *CountLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *CountLeavesRequest) GetMinQueueTimestamp() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.MinQueueTimestamp
+	}
+	return nil
+}
+
+func (m *CountLeavesRequest) GetMaxQueueTimestamp() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.MaxQueueTimestamp
+	}
+	return nil
+}
+
+func (m *CountLeavesRequest) GetLeafIdentityHashPrefix() []byte {
+	if m != nil {
+		return m.LeafIdentityHashPrefix
+	}
+	return nil
+}
+
+func (m *CountLeavesRequest) GetSequencedOnly() bool {
+	if m != nil {
+		return m.SequencedOnly
+	}
+	return false
+}
+
+type CountLeavesResponse struct {
+	LeafCount int64 `protobuf:"varint,1,opt,name=leaf_count,json=leafCount" json:"leaf_count,omitempty"`
+}
+
+func (m *CountLeavesResponse) Reset()                    { *m = CountLeavesResponse{} }
+func (m *CountLeavesResponse) String() string            { return proto.CompactTextString(m) }
+func (*CountLeavesResponse) ProtoMessage()               {}
+func (*CountLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *CountLeavesResponse) GetLeafCount() int64 {
+	if m != nil {
+		return m.LeafCount
+	}
+	return 0
+}
+
+type GetLeavesByIndexRequest struct {
+	LogId     int64   `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIndex []int64 `protobuf:"varint,2,rep,packed,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
+	// If true, indices that are out of range or not found don't fail the
+	// request; the leaves that were found are returned along with the status
+	// of each requested index.
+	AllowPartial bool `protobuf:"varint,3,opt,name=allow_partial,json=allowPartial" json:"allow_partial,omitempty"`
+}
+
+func (m *GetLeavesByIndexRequest) Reset()                    { *m = GetLeavesByIndexRequest{} }
+func (m *GetLeavesByIndexRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLeavesByIndexRequest) ProtoMessage()               {}
+func (*GetLeavesByIndexRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *GetLeavesByIndexRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetLeavesByIndexRequest) GetLeafIndex() []int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return nil
+}
+
+func (m *GetLeavesByIndexRequest) GetAllowPartial() bool {
+	if m != nil {
+		return m.AllowPartial
+	}
+	return false
+}
+
+type GetLeavesByIndexResponse struct {
+	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+	// The status of each requested index, in the same order as the request.
+	// Only populated if allow_partial was set.
+	LeafStatus []LeafIndexStatus `protobuf:"varint,3,rep,packed,name=leaf_status,json=leafStatus,enum=trillian.LeafIndexStatus" json:"leaf_status,omitempty"`
+}
+
+func (m *GetLeavesByIndexResponse) Reset()                    { *m = GetLeavesByIndexResponse{} }
+func (m *GetLeavesByIndexResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLeavesByIndexResponse) ProtoMessage()               {}
+func (*GetLeavesByIndexResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *GetLeavesByIndexResponse) GetLeaves() []*LogLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+func (m *GetLeavesByIndexResponse) GetLeafStatus() []LeafIndexStatus {
+	if m != nil {
+		return m.LeafStatus
+	}
+	return nil
+}
+
+type GetEntriesRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// The index of the first leaf to return.
+	StartIndex int64 `protobuf:"varint,2,opt,name=start_index,json=startIndex" json:"start_index,omitempty"`
+	// The index after the last leaf to return. Zero means the size of the
+	// latest signed root, which also caps any larger value.
+	EndIndex int64 `protobuf:"varint,3,opt,name=end_index,json=endIndex" json:"end_index,omitempty"`
+}
+
+func (m *GetEntriesRequest) Reset()                    { *m = GetEntriesRequest{} }
+func (m *GetEntriesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetEntriesRequest) ProtoMessage()               {}
+func (*GetEntriesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *GetEntriesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetEntriesRequest) GetStartIndex() int64 {
+	if m != nil {
+		return m.StartIndex
+	}
+	return 0
+}
+
+func (m *GetEntriesRequest) GetEndIndex() int64 {
+	if m != nil {
+		return m.EndIndex
+	}
+	return 0
+}
+
+type GetEntriesResponse struct {
+	// Consecutive leaves, in index order, following those of the previous
+	// response in the stream.
+	Leaves []*LogLeaf `protobuf:"bytes,1,rep,name=leaves" json:"leaves,omitempty"`
+}
+
+func (m *GetEntriesResponse) Reset()                    { *m = GetEntriesResponse{} }
+func (m *GetEntriesResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetEntriesResponse) ProtoMessage()               {}
+func (*GetEntriesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *GetEntriesResponse) GetLeaves() []*LogLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+type GetSignedLogRootHistoryRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// The tree revision of the first root to return. An interrupted stream
+	// can be resumed from the revision after that of the last root received.
+	StartRevision int64 `protobuf:"varint,2,opt,name=start_revision,json=startRevision" json:"start_revision,omitempty"`
+}
+
+func (m *GetSignedLogRootHistoryRequest) Reset()                    { *m = GetSignedLogRootHistoryRequest{} }
+func (m *GetSignedLogRootHistoryRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetSignedLogRootHistoryRequest) ProtoMessage()               {}
+func (*GetSignedLogRootHistoryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *GetSignedLogRootHistoryRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetSignedLogRootHistoryRequest) GetStartRevision() int64 {
+	if m != nil {
+		return m.StartRevision
+	}
+	return 0
+}
+
+type GetSignedLogRootHistoryResponse struct {
+	// Consecutive retained roots, in revision and thus tree size order,
+	// following those of the previous response in the stream.
+	SignedLogRoots []*SignedLogRoot `protobuf:"bytes,1,rep,name=signed_log_roots,json=signedLogRoots" json:"signed_log_roots,omitempty"`
+}
+
+func (m *GetSignedLogRootHistoryResponse) Reset()         { *m = GetSignedLogRootHistoryResponse{} }
+func (m *GetSignedLogRootHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSignedLogRootHistoryResponse) ProtoMessage()    {}
+func (*GetSignedLogRootHistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{31}
+}
+
+func (m *GetSignedLogRootHistoryResponse) GetSignedLogRoots() []*SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoots
+	}
+	return nil
+}
+
+type GetSequencedLeafCountRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+}
+
+func (m *GetSequencedLeafCountRequest) Reset()                    { *m = GetSequencedLeafCountRequest{} }
+func (m *GetSequencedLeafCountRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetSequencedLeafCountRequest) ProtoMessage()               {}
+func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *GetSequencedLeafCountRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+type GetSequencedLeafCountResponse struct {
+	LeafCount int64 `protobuf:"varint,2,opt,name=leaf_count,json=leafCount" json:"leaf_count,omitempty"`
+}
+
+func (m *GetSequencedLeafCountResponse) Reset()                    { *m = GetSequencedLeafCountResponse{} }
+func (m *GetSequencedLeafCountResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetSequencedLeafCountResponse) ProtoMessage()               {}
+func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *GetSequencedLeafCountResponse) GetLeafCount() int64 {
+	if m != nil {
+		return m.LeafCount
+	}
+	return 0
+}
+
+type GetLatestSignedLogRootRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// If true, the response includes the root's timestamp token, if any.
+	IncludeTimestampToken bool `protobuf:"varint,2,opt,name=include_timestamp_token,json=includeTimestampToken" json:"include_timestamp_token,omitempty"`
+}
+
+func (m *GetLatestSignedLogRootRequest) Reset()                    { *m = GetLatestSignedLogRootRequest{} }
+func (m *GetLatestSignedLogRootRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLatestSignedLogRootRequest) ProtoMessage()               {}
+func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *GetLatestSignedLogRootRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetLatestSignedLogRootRequest) GetIncludeTimestampToken() bool {
+	if m != nil {
+		return m.IncludeTimestampToken
+	}
+	return false
+}
+
+type GetLatestSignedLogRootResponse struct {
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+	// DER-encoded RFC 3161 TimeStampToken over the root's hash, as returned
+	// by crypto.HashLogRoot. Only set if requested and the root has been
+	// timestamped: roots are timestamped asynchronously after being signed,
+	// so the latest root may not have a token yet.
+	TimestampToken []byte `protobuf:"bytes,3,opt,name=timestamp_token,json=timestampToken,proto3" json:"timestamp_token,omitempty"`
+	// Whether the root was served from memory while the log's storage was
+	// unavailable, in which case it may not be the log's latest root.
+	Stale bool `protobuf:"varint,4,opt,name=stale" json:"stale,omitempty"`
+}
+
+func (m *GetLatestSignedLogRootResponse) Reset()                    { *m = GetLatestSignedLogRootResponse{} }
+func (m *GetLatestSignedLogRootResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLatestSignedLogRootResponse) ProtoMessage()               {}
+func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *GetLatestSignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *GetLatestSignedLogRootResponse) GetTimestampToken() []byte {
+	if m != nil {
+		return m.TimestampToken
+	}
+	return nil
+}
+
+func (m *GetLatestSignedLogRootResponse) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
+type GetLatestCheckpointRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+}
+
+func (m *GetLatestCheckpointRequest) Reset()                    { *m = GetLatestCheckpointRequest{} }
+func (m *GetLatestCheckpointRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetLatestCheckpointRequest) ProtoMessage()               {}
+func (*GetLatestCheckpointRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *GetLatestCheckpointRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+type GetLatestCheckpointResponse struct {
+	// The latest signed log root rendered as a signed checkpoint: the origin
+	// line, the tree size, the base64 root hash, a blank line, and a signature
+	// line with the tree's key over the lines before it.
+	Checkpoint []byte `protobuf:"bytes,1,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	// The signed log root the checkpoint was rendered from.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+}
+
+func (m *GetLatestCheckpointResponse) Reset()                    { *m = GetLatestCheckpointResponse{} }
+func (m *GetLatestCheckpointResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetLatestCheckpointResponse) ProtoMessage()               {}
+func (*GetLatestCheckpointResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *GetLatestCheckpointResponse) GetCheckpoint() []byte {
+	if m != nil {
+		return m.Checkpoint
+	}
+	return nil
+}
+
+func (m *GetLatestCheckpointResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+type GetSignedLogRootAtTimeRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// The time at which the returned root was in effect.
+	Timestamp *google_protobuf2.Timestamp `protobuf:"bytes,2,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *GetSignedLogRootAtTimeRequest) Reset()                    { *m = GetSignedLogRootAtTimeRequest{} }
+func (m *GetSignedLogRootAtTimeRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetSignedLogRootAtTimeRequest) ProtoMessage()               {}
+func (*GetSignedLogRootAtTimeRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *GetSignedLogRootAtTimeRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetSignedLogRootAtTimeRequest) GetTimestamp() *google_protobuf2.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+type GetSignedLogRootAtTimeResponse struct {
+	// The root with the latest timestamp not after the requested timestamp.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,1,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+}
+
+func (m *GetSignedLogRootAtTimeResponse) Reset()                    { *m = GetSignedLogRootAtTimeResponse{} }
+func (m *GetSignedLogRootAtTimeResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetSignedLogRootAtTimeResponse) ProtoMessage()               {}
+func (*GetSignedLogRootAtTimeResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *GetSignedLogRootAtTimeResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+type GetEntryAndProofRequest struct {
+	LogId     int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	LeafIndex int64 `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex" json:"leaf_index,omitempty"`
+	TreeSize  int64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize" json:"tree_size,omitempty"`
+}
+
+func (m *GetEntryAndProofRequest) Reset()                    { *m = GetEntryAndProofRequest{} }
+func (m *GetEntryAndProofRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetEntryAndProofRequest) ProtoMessage()               {}
+func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *GetEntryAndProofRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetEntryAndProofRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *GetEntryAndProofRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+type GetEntryAndProofResponse struct {
+	Proof *Proof   `protobuf:"bytes,2,opt,name=proof" json:"proof,omitempty"`
+	Leaf  *LogLeaf `protobuf:"bytes,3,opt,name=leaf" json:"leaf,omitempty"`
+}
+
+func (m *GetEntryAndProofResponse) Reset()                    { *m = GetEntryAndProofResponse{} }
+func (m *GetEntryAndProofResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetEntryAndProofResponse) ProtoMessage()               {}
+func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *GetEntryAndProofResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *GetEntryAndProofResponse) GetLeaf() *LogLeaf {
 	if m != nil {
 		return m.Leaf
 	}
 	return nil
 }
 
+type GetLatestLeafByIdentityHashPrefixRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// Prefix of the identity hash of the leaf, e.g. a fingerprint of its value.
+	// Must not be empty.
+	LeafIdentityHashPrefix []byte `protobuf:"bytes,2,opt,name=leaf_identity_hash_prefix,json=leafIdentityHashPrefix,proto3" json:"leaf_identity_hash_prefix,omitempty"`
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixRequest) Reset() {
+	*m = GetLatestLeafByIdentityHashPrefixRequest{}
+}
+func (m *GetLatestLeafByIdentityHashPrefixRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLatestLeafByIdentityHashPrefixRequest) ProtoMessage()    {}
+func (*GetLatestLeafByIdentityHashPrefixRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{42}
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixRequest) GetLeafIdentityHashPrefix() []byte {
+	if m != nil {
+		return m.LeafIdentityHashPrefix
+	}
+	return nil
+}
+
+type GetLatestLeafByIdentityHashPrefixResponse struct {
+	// The sequenced leaf with the highest index whose identity hash starts
+	// with the requested prefix.
+	Leaf *LogLeaf `protobuf:"bytes,1,opt,name=leaf" json:"leaf,omitempty"`
+	// Inclusion proof of leaf in the tree of signed_log_root.
+	Proof         *Proof         `protobuf:"bytes,2,opt,name=proof" json:"proof,omitempty"`
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+	// Number of sequenced leaves whose identity hash starts with the requested
+	// prefix. If it's more than one the prefix is ambiguous, and only the
+	// latest matching leaf is returned.
+	MatchCount int64 `protobuf:"varint,4,opt,name=match_count,json=matchCount" json:"match_count,omitempty"`
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixResponse) Reset() {
+	*m = GetLatestLeafByIdentityHashPrefixResponse{}
+}
+func (m *GetLatestLeafByIdentityHashPrefixResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLatestLeafByIdentityHashPrefixResponse) ProtoMessage()    {}
+func (*GetLatestLeafByIdentityHashPrefixResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{43}
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixResponse) GetLeaf() *LogLeaf {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *GetLatestLeafByIdentityHashPrefixResponse) GetMatchCount() int64 {
+	if m != nil {
+		return m.MatchCount
+	}
+	return 0
+}
+
+type AddCosignatureRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+	// The root that was cosigned, as returned by GetLatestSignedLogRoot.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+	Cosignature   *Cosignature   `protobuf:"bytes,3,opt,name=cosignature" json:"cosignature,omitempty"`
+}
+
+func (m *AddCosignatureRequest) Reset()                    { *m = AddCosignatureRequest{} }
+func (m *AddCosignatureRequest) String() string            { return proto.CompactTextString(m) }
+func (*AddCosignatureRequest) ProtoMessage()               {}
+func (*AddCosignatureRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *AddCosignatureRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *AddCosignatureRequest) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *AddCosignatureRequest) GetCosignature() *Cosignature {
+	if m != nil {
+		return m.Cosignature
+	}
+	return nil
+}
+
+type AddCosignatureResponse struct {
+}
+
+func (m *AddCosignatureResponse) Reset()                    { *m = AddCosignatureResponse{} }
+func (m *AddCosignatureResponse) String() string            { return proto.CompactTextString(m) }
+func (*AddCosignatureResponse) ProtoMessage()               {}
+func (*AddCosignatureResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+type GetLatestCosignedLogRootRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId" json:"log_id,omitempty"`
+}
+
+func (m *GetLatestCosignedLogRootRequest) Reset()         { *m = GetLatestCosignedLogRootRequest{} }
+func (m *GetLatestCosignedLogRootRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLatestCosignedLogRootRequest) ProtoMessage()    {}
+func (*GetLatestCosignedLogRootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{46}
+}
+
+func (m *GetLatestCosignedLogRootRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+type GetLatestCosignedLogRootResponse struct {
+	// The most recent root cosigned by at least one witness, unset if there's
+	// no such root.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,1,opt,name=signed_log_root,json=signedLogRoot" json:"signed_log_root,omitempty"`
+	Cosignatures  []*Cosignature `protobuf:"bytes,2,rep,name=cosignatures" json:"cosignatures,omitempty"`
+}
+
+func (m *GetLatestCosignedLogRootResponse) Reset()         { *m = GetLatestCosignedLogRootResponse{} }
+func (m *GetLatestCosignedLogRootResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLatestCosignedLogRootResponse) ProtoMessage()    {}
+func (*GetLatestCosignedLogRootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{47}
+}
+
+func (m *GetLatestCosignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *GetLatestCosignedLogRootResponse) GetCosignatures() []*Cosignature {
+	if m != nil {
+		return m.Cosignatures
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*LogLeaf)(nil), "trillian.LogLeaf")
+	proto.RegisterType((*DeadLetteredLeaf)(nil), "trillian.DeadLetteredLeaf")
 	proto.RegisterType((*Proof)(nil), "trillian.Proof")
 	proto.RegisterType((*QueuedLogLeaf)(nil), "trillian.QueuedLogLeaf")
 	proto.RegisterType((*QueueLeavesRequest)(nil), "trillian.QueueLeavesRequest")
@@ -659,20 +1637,46 @@ func init() {
 	proto.RegisterType((*QueueLeavesResponse)(nil), "trillian.QueueLeavesResponse")
 	proto.RegisterType((*GetInclusionProofRequest)(nil), "trillian.GetInclusionProofRequest")
 	proto.RegisterType((*GetInclusionProofResponse)(nil), "trillian.GetInclusionProofResponse")
+	proto.RegisterType((*GetInclusionProofWithRootRequest)(nil), "trillian.GetInclusionProofWithRootRequest")
+	proto.RegisterType((*GetInclusionProofWithRootResponse)(nil), "trillian.GetInclusionProofWithRootResponse")
 	proto.RegisterType((*GetInclusionProofByHashRequest)(nil), "trillian.GetInclusionProofByHashRequest")
 	proto.RegisterType((*GetInclusionProofByHashResponse)(nil), "trillian.GetInclusionProofByHashResponse")
 	proto.RegisterType((*GetConsistencyProofRequest)(nil), "trillian.GetConsistencyProofRequest")
 	proto.RegisterType((*GetConsistencyProofResponse)(nil), "trillian.GetConsistencyProofResponse")
+	proto.RegisterType((*CheckConsistencyProofRequest)(nil), "trillian.CheckConsistencyProofRequest")
+	proto.RegisterType((*CheckConsistencyProofResponse)(nil), "trillian.CheckConsistencyProofResponse")
+	proto.RegisterType((*GetProofByMerkleHashRequest)(nil), "trillian.GetProofByMerkleHashRequest")
+	proto.RegisterType((*GetProofByMerkleHashResponse)(nil), "trillian.GetProofByMerkleHashResponse")
 	proto.RegisterType((*GetLeavesByHashRequest)(nil), "trillian.GetLeavesByHashRequest")
 	proto.RegisterType((*GetLeavesByHashResponse)(nil), "trillian.GetLeavesByHashResponse")
+	proto.RegisterType((*HasLeavesRequest)(nil), "trillian.HasLeavesRequest")
+	proto.RegisterType((*HasLeavesResponse)(nil), "trillian.HasLeavesResponse")
+	proto.RegisterType((*CountLeavesRequest)(nil), "trillian.CountLeavesRequest")
+	proto.RegisterType((*CountLeavesResponse)(nil), "trillian.CountLeavesResponse")
 	proto.RegisterType((*GetLeavesByIndexRequest)(nil), "trillian.GetLeavesByIndexRequest")
 	proto.RegisterType((*GetLeavesByIndexResponse)(nil), "trillian.GetLeavesByIndexResponse")
+	proto.RegisterType((*GetEntriesRequest)(nil), "trillian.GetEntriesRequest")
+	proto.RegisterType((*GetEntriesResponse)(nil), "trillian.GetEntriesResponse")
+	proto.RegisterType((*GetSignedLogRootHistoryRequest)(nil), "trillian.GetSignedLogRootHistoryRequest")
+	proto.RegisterType((*GetSignedLogRootHistoryResponse)(nil), "trillian.GetSignedLogRootHistoryResponse")
 	proto.RegisterType((*GetSequencedLeafCountRequest)(nil), "trillian.GetSequencedLeafCountRequest")
 	proto.RegisterType((*GetSequencedLeafCountResponse)(nil), "trillian.GetSequencedLeafCountResponse")
 	proto.RegisterType((*GetLatestSignedLogRootRequest)(nil), "trillian.GetLatestSignedLogRootRequest")
 	proto.RegisterType((*GetLatestSignedLogRootResponse)(nil), "trillian.GetLatestSignedLogRootResponse")
+	proto.RegisterType((*GetLatestCheckpointRequest)(nil), "trillian.GetLatestCheckpointRequest")
+	proto.RegisterType((*GetLatestCheckpointResponse)(nil), "trillian.GetLatestCheckpointResponse")
+	proto.RegisterType((*GetSignedLogRootAtTimeRequest)(nil), "trillian.GetSignedLogRootAtTimeRequest")
+	proto.RegisterType((*GetSignedLogRootAtTimeResponse)(nil), "trillian.GetSignedLogRootAtTimeResponse")
 	proto.RegisterType((*GetEntryAndProofRequest)(nil), "trillian.GetEntryAndProofRequest")
 	proto.RegisterType((*GetEntryAndProofResponse)(nil), "trillian.GetEntryAndProofResponse")
+	proto.RegisterType((*GetLatestLeafByIdentityHashPrefixRequest)(nil), "trillian.GetLatestLeafByIdentityHashPrefixRequest")
+	proto.RegisterType((*GetLatestLeafByIdentityHashPrefixResponse)(nil), "trillian.GetLatestLeafByIdentityHashPrefixResponse")
+	proto.RegisterType((*AddCosignatureRequest)(nil), "trillian.AddCosignatureRequest")
+	proto.RegisterType((*AddCosignatureResponse)(nil), "trillian.AddCosignatureResponse")
+	proto.RegisterType((*GetLatestCosignedLogRootRequest)(nil), "trillian.GetLatestCosignedLogRootRequest")
+	proto.RegisterType((*GetLatestCosignedLogRootResponse)(nil), "trillian.GetLatestCosignedLogRootResponse")
+	proto.RegisterEnum("trillian.ConsistencyProofCheck", ConsistencyProofCheck_name, ConsistencyProofCheck_value)
+	proto.RegisterEnum("trillian.LeafIndexStatus", LeafIndexStatus_name, LeafIndexStatus_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -690,66 +1694,182 @@ type TrillianLogClient interface {
 	QueueLeaf(ctx context.Context, in *QueueLeafRequest, opts ...grpc.CallOption) (*QueueLeafResponse, error)
 	// No direct equivalent at the storage level
 	GetInclusionProof(ctx context.Context, in *GetInclusionProofRequest, opts ...grpc.CallOption) (*GetInclusionProofResponse, error)
+	// GetInclusionProofWithRoot returns an inclusion proof at a tree size along
+	// with the root the log signed at that size, so clients can check that the
+	// proof terminates in a signed root. Returns NotFound if no retained root
+	// was signed at exactly that size.
+	GetInclusionProofWithRoot(ctx context.Context, in *GetInclusionProofWithRootRequest, opts ...grpc.CallOption) (*GetInclusionProofWithRootResponse, error)
 	GetInclusionProofByHash(ctx context.Context, in *GetInclusionProofByHashRequest, opts ...grpc.CallOption) (*GetInclusionProofByHashResponse, error)
 	GetConsistencyProof(ctx context.Context, in *GetConsistencyProofRequest, opts ...grpc.CallOption) (*GetConsistencyProofResponse, error)
+	// CheckConsistencyProof checks a consistency proof held by the client
+	// against the log's history, for clients that want to double-check their
+	// own verification. It shifts trust to the server, so it's a debugging
+	// aid only, and is disabled unless the server enables it.
+	CheckConsistencyProof(ctx context.Context, in *CheckConsistencyProofRequest, opts ...grpc.CallOption) (*CheckConsistencyProofResponse, error)
+	// GetProofByMerkleHash returns both an inclusion proof for a leaf and a consistency
+	// proof from an earlier tree size, read in a single storage transaction.
+	GetProofByMerkleHash(ctx context.Context, in *GetProofByMerkleHashRequest, opts ...grpc.CallOption) (*GetProofByMerkleHashResponse, error)
 	// Corresponds to the LogRootReader API
 	GetLatestSignedLogRoot(ctx context.Context, in *GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestSignedLogRootResponse, error)
+	// GetLatestCheckpoint returns the latest signed log root in the text
+	// checkpoint format used by checkpoint-based tooling such as witnesses.
+	// The checkpoint of a given root is byte-for-byte stable across calls to
+	// the same server.
+	GetLatestCheckpoint(ctx context.Context, in *GetLatestCheckpointRequest, opts ...grpc.CallOption) (*GetLatestCheckpointResponse, error)
+	// GetSignedLogRootAtTime returns the root that was in effect at a given
+	// time, i.e. the retained root with the latest timestamp not after it.
+	// Returns NotFound if the time predates all retained roots.
+	GetSignedLogRootAtTime(ctx context.Context, in *GetSignedLogRootAtTimeRequest, opts ...grpc.CallOption) (*GetSignedLogRootAtTimeResponse, error)
+	// AddCosignature stores a witness's cosignature of a root signed by the log.
+	// Only cosignatures of the log's latest signed root are accepted, and they
+	// must verify against one of the tree's witnesses.
+	AddCosignature(ctx context.Context, in *AddCosignatureRequest, opts ...grpc.CallOption) (*AddCosignatureResponse, error)
+	// GetLatestCosignedLogRoot returns the most recent root that has been
+	// cosigned, along with its cosignatures.
+	GetLatestCosignedLogRoot(ctx context.Context, in *GetLatestCosignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestCosignedLogRootResponse, error)
 	// Corresponds to the LeafReader API
 	GetSequencedLeafCount(ctx context.Context, in *GetSequencedLeafCountRequest, opts ...grpc.CallOption) (*GetSequencedLeafCountResponse, error)
 	GetEntryAndProof(ctx context.Context, in *GetEntryAndProofRequest, opts ...grpc.CallOption) (*GetEntryAndProofResponse, error)
+	// GetLatestLeafByIdentityHashPrefix returns the highest-indexed sequenced
+	// leaf whose identity hash starts with a prefix, with its inclusion proof
+	// against the latest signed root. Returns NotFound if no leaf matches.
+	GetLatestLeafByIdentityHashPrefix(ctx context.Context, in *GetLatestLeafByIdentityHashPrefixRequest, opts ...grpc.CallOption) (*GetLatestLeafByIdentityHashPrefixResponse, error)
 	// Corresponds to the LeafQueuer API
 	QueueLeaves(ctx context.Context, in *QueueLeavesRequest, opts ...grpc.CallOption) (*QueueLeavesResponse, error)
 	GetLeavesByIndex(ctx context.Context, in *GetLeavesByIndexRequest, opts ...grpc.CallOption) (*GetLeavesByIndexResponse, error)
 	GetLeavesByHash(ctx context.Context, in *GetLeavesByHashRequest, opts ...grpc.CallOption) (*GetLeavesByHashResponse, error)
+	// GetEntries streams the leaves in [start_index, end_index) in index
+	// order, in chunks chosen by the server. The stream ends at the size of
+	// the latest signed root when the call started; an interrupted stream can
+	// be resumed by calling again from the index after the last leaf received.
+	GetEntries(ctx context.Context, in *GetEntriesRequest, opts ...grpc.CallOption) (TrillianLog_GetEntriesClient, error)
+	// GetSignedLogRootHistory streams the retained signed roots of the log
+	// from start_revision in revision order, which is also tree size order,
+	// in chunks chosen by the server. The stream ends at the latest signed
+	// root when the call started. Roots may have been pruned from storage,
+	// so the history can start later than the log's first root and skip
+	// revisions; each consecutive pair can still be checked for consistency.
+	GetSignedLogRootHistory(ctx context.Context, in *GetSignedLogRootHistoryRequest, opts ...grpc.CallOption) (TrillianLog_GetSignedLogRootHistoryClient, error)
+	// HasLeaves reports whether leaves with the given identity hashes are in
+	// the log, without returning their data or proofs.
+	HasLeaves(ctx context.Context, in *HasLeavesRequest, opts ...grpc.CallOption) (*HasLeavesResponse, error)
+	// CountLeaves returns the number of leaves matching the request, without
+	// returning their data. Counts including leaves that are queued but not
+	// yet sequenced are best-effort: they change as leaves are queued and
+	// sequenced, and don't correspond to any signed root.
+	CountLeaves(ctx context.Context, in *CountLeavesRequest, opts ...grpc.CallOption) (*CountLeavesResponse, error)
+}
+
+type trillianLogClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTrillianLogClient(cc *grpc.ClientConn) TrillianLogClient {
+	return &trillianLogClient{cc}
+}
+
+func (c *trillianLogClient) QueueLeaf(ctx context.Context, in *QueueLeafRequest, opts ...grpc.CallOption) (*QueueLeafResponse, error) {
+	out := new(QueueLeafResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/QueueLeaf", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) GetInclusionProof(ctx context.Context, in *GetInclusionProofRequest, opts ...grpc.CallOption) (*GetInclusionProofResponse, error) {
+	out := new(GetInclusionProofResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProof", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) GetInclusionProofWithRoot(ctx context.Context, in *GetInclusionProofWithRootRequest, opts ...grpc.CallOption) (*GetInclusionProofWithRootResponse, error) {
+	out := new(GetInclusionProofWithRootResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProofWithRoot", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) GetInclusionProofByHash(ctx context.Context, in *GetInclusionProofByHashRequest, opts ...grpc.CallOption) (*GetInclusionProofByHashResponse, error) {
+	out := new(GetInclusionProofByHashResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProofByHash", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) GetConsistencyProof(ctx context.Context, in *GetConsistencyProofRequest, opts ...grpc.CallOption) (*GetConsistencyProofResponse, error) {
+	out := new(GetConsistencyProofResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetConsistencyProof", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-type trillianLogClient struct {
-	cc *grpc.ClientConn
+func (c *trillianLogClient) CheckConsistencyProof(ctx context.Context, in *CheckConsistencyProofRequest, opts ...grpc.CallOption) (*CheckConsistencyProofResponse, error) {
+	out := new(CheckConsistencyProofResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/CheckConsistencyProof", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func NewTrillianLogClient(cc *grpc.ClientConn) TrillianLogClient {
-	return &trillianLogClient{cc}
+func (c *trillianLogClient) GetProofByMerkleHash(ctx context.Context, in *GetProofByMerkleHashRequest, opts ...grpc.CallOption) (*GetProofByMerkleHashResponse, error) {
+	out := new(GetProofByMerkleHashResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetProofByMerkleHash", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (c *trillianLogClient) QueueLeaf(ctx context.Context, in *QueueLeafRequest, opts ...grpc.CallOption) (*QueueLeafResponse, error) {
-	out := new(QueueLeafResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianLog/QueueLeaf", in, out, c.cc, opts...)
+func (c *trillianLogClient) GetLatestSignedLogRoot(ctx context.Context, in *GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestSignedLogRootResponse, error) {
+	out := new(GetLatestSignedLogRootResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLatestSignedLogRoot", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianLogClient) GetInclusionProof(ctx context.Context, in *GetInclusionProofRequest, opts ...grpc.CallOption) (*GetInclusionProofResponse, error) {
-	out := new(GetInclusionProofResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProof", in, out, c.cc, opts...)
+func (c *trillianLogClient) GetLatestCheckpoint(ctx context.Context, in *GetLatestCheckpointRequest, opts ...grpc.CallOption) (*GetLatestCheckpointResponse, error) {
+	out := new(GetLatestCheckpointResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLatestCheckpoint", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianLogClient) GetInclusionProofByHash(ctx context.Context, in *GetInclusionProofByHashRequest, opts ...grpc.CallOption) (*GetInclusionProofByHashResponse, error) {
-	out := new(GetInclusionProofByHashResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProofByHash", in, out, c.cc, opts...)
+func (c *trillianLogClient) GetSignedLogRootAtTime(ctx context.Context, in *GetSignedLogRootAtTimeRequest, opts ...grpc.CallOption) (*GetSignedLogRootAtTimeResponse, error) {
+	out := new(GetSignedLogRootAtTimeResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetSignedLogRootAtTime", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianLogClient) GetConsistencyProof(ctx context.Context, in *GetConsistencyProofRequest, opts ...grpc.CallOption) (*GetConsistencyProofResponse, error) {
-	out := new(GetConsistencyProofResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetConsistencyProof", in, out, c.cc, opts...)
+func (c *trillianLogClient) AddCosignature(ctx context.Context, in *AddCosignatureRequest, opts ...grpc.CallOption) (*AddCosignatureResponse, error) {
+	out := new(AddCosignatureResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/AddCosignature", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *trillianLogClient) GetLatestSignedLogRoot(ctx context.Context, in *GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestSignedLogRootResponse, error) {
-	out := new(GetLatestSignedLogRootResponse)
-	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLatestSignedLogRoot", in, out, c.cc, opts...)
+func (c *trillianLogClient) GetLatestCosignedLogRoot(ctx context.Context, in *GetLatestCosignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestCosignedLogRootResponse, error) {
+	out := new(GetLatestCosignedLogRootResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLatestCosignedLogRoot", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -774,6 +1894,15 @@ func (c *trillianLogClient) GetEntryAndProof(ctx context.Context, in *GetEntryAn
 	return out, nil
 }
 
+func (c *trillianLogClient) GetLatestLeafByIdentityHashPrefix(ctx context.Context, in *GetLatestLeafByIdentityHashPrefixRequest, opts ...grpc.CallOption) (*GetLatestLeafByIdentityHashPrefixResponse, error) {
+	out := new(GetLatestLeafByIdentityHashPrefixResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/GetLatestLeafByIdentityHashPrefix", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianLogClient) QueueLeaves(ctx context.Context, in *QueueLeavesRequest, opts ...grpc.CallOption) (*QueueLeavesResponse, error) {
 	out := new(QueueLeavesResponse)
 	err := grpc.Invoke(ctx, "/trillian.TrillianLog/QueueLeaves", in, out, c.cc, opts...)
@@ -801,6 +1930,88 @@ func (c *trillianLogClient) GetLeavesByHash(ctx context.Context, in *GetLeavesBy
 	return out, nil
 }
 
+func (c *trillianLogClient) GetEntries(ctx context.Context, in *GetEntriesRequest, opts ...grpc.CallOption) (TrillianLog_GetEntriesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_TrillianLog_serviceDesc.Streams[0], c.cc, "/trillian.TrillianLog/GetEntries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trillianLogGetEntriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrillianLog_GetEntriesClient interface {
+	Recv() (*GetEntriesResponse, error)
+	grpc.ClientStream
+}
+
+type trillianLogGetEntriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *trillianLogGetEntriesClient) Recv() (*GetEntriesResponse, error) {
+	m := new(GetEntriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *trillianLogClient) GetSignedLogRootHistory(ctx context.Context, in *GetSignedLogRootHistoryRequest, opts ...grpc.CallOption) (TrillianLog_GetSignedLogRootHistoryClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_TrillianLog_serviceDesc.Streams[1], c.cc, "/trillian.TrillianLog/GetSignedLogRootHistory", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trillianLogGetSignedLogRootHistoryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrillianLog_GetSignedLogRootHistoryClient interface {
+	Recv() (*GetSignedLogRootHistoryResponse, error)
+	grpc.ClientStream
+}
+
+type trillianLogGetSignedLogRootHistoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *trillianLogGetSignedLogRootHistoryClient) Recv() (*GetSignedLogRootHistoryResponse, error) {
+	m := new(GetSignedLogRootHistoryResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *trillianLogClient) HasLeaves(ctx context.Context, in *HasLeavesRequest, opts ...grpc.CallOption) (*HasLeavesResponse, error) {
+	out := new(HasLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/HasLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) CountLeaves(ctx context.Context, in *CountLeavesRequest, opts ...grpc.CallOption) (*CountLeavesResponse, error) {
+	out := new(CountLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianLog/CountLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for TrillianLog service
 
 type TrillianLogServer interface {
@@ -808,17 +2019,70 @@ type TrillianLogServer interface {
 	QueueLeaf(context.Context, *QueueLeafRequest) (*QueueLeafResponse, error)
 	// No direct equivalent at the storage level
 	GetInclusionProof(context.Context, *GetInclusionProofRequest) (*GetInclusionProofResponse, error)
+	// GetInclusionProofWithRoot returns an inclusion proof at a tree size along
+	// with the root the log signed at that size, so clients can check that the
+	// proof terminates in a signed root. Returns NotFound if no retained root
+	// was signed at exactly that size.
+	GetInclusionProofWithRoot(context.Context, *GetInclusionProofWithRootRequest) (*GetInclusionProofWithRootResponse, error)
 	GetInclusionProofByHash(context.Context, *GetInclusionProofByHashRequest) (*GetInclusionProofByHashResponse, error)
 	GetConsistencyProof(context.Context, *GetConsistencyProofRequest) (*GetConsistencyProofResponse, error)
+	// CheckConsistencyProof checks a consistency proof held by the client
+	// against the log's history, for clients that want to double-check their
+	// own verification. It shifts trust to the server, so it's a debugging
+	// aid only, and is disabled unless the server enables it.
+	CheckConsistencyProof(context.Context, *CheckConsistencyProofRequest) (*CheckConsistencyProofResponse, error)
+	// GetProofByMerkleHash returns both an inclusion proof for a leaf and a consistency
+	// proof from an earlier tree size, read in a single storage transaction.
+	GetProofByMerkleHash(context.Context, *GetProofByMerkleHashRequest) (*GetProofByMerkleHashResponse, error)
 	// Corresponds to the LogRootReader API
 	GetLatestSignedLogRoot(context.Context, *GetLatestSignedLogRootRequest) (*GetLatestSignedLogRootResponse, error)
+	// GetLatestCheckpoint returns the latest signed log root in the text
+	// checkpoint format used by checkpoint-based tooling such as witnesses.
+	// The checkpoint of a given root is byte-for-byte stable across calls to
+	// the same server.
+	GetLatestCheckpoint(context.Context, *GetLatestCheckpointRequest) (*GetLatestCheckpointResponse, error)
+	// GetSignedLogRootAtTime returns the root that was in effect at a given
+	// time, i.e. the retained root with the latest timestamp not after it.
+	// Returns NotFound if the time predates all retained roots.
+	GetSignedLogRootAtTime(context.Context, *GetSignedLogRootAtTimeRequest) (*GetSignedLogRootAtTimeResponse, error)
+	// AddCosignature stores a witness's cosignature of a root signed by the log.
+	// Only cosignatures of the log's latest signed root are accepted, and they
+	// must verify against one of the tree's witnesses.
+	AddCosignature(context.Context, *AddCosignatureRequest) (*AddCosignatureResponse, error)
+	// GetLatestCosignedLogRoot returns the most recent root that has been
+	// cosigned, along with its cosignatures.
+	GetLatestCosignedLogRoot(context.Context, *GetLatestCosignedLogRootRequest) (*GetLatestCosignedLogRootResponse, error)
 	// Corresponds to the LeafReader API
 	GetSequencedLeafCount(context.Context, *GetSequencedLeafCountRequest) (*GetSequencedLeafCountResponse, error)
 	GetEntryAndProof(context.Context, *GetEntryAndProofRequest) (*GetEntryAndProofResponse, error)
+	// GetLatestLeafByIdentityHashPrefix returns the highest-indexed sequenced
+	// leaf whose identity hash starts with a prefix, with its inclusion proof
+	// against the latest signed root. Returns NotFound if no leaf matches.
+	GetLatestLeafByIdentityHashPrefix(context.Context, *GetLatestLeafByIdentityHashPrefixRequest) (*GetLatestLeafByIdentityHashPrefixResponse, error)
 	// Corresponds to the LeafQueuer API
 	QueueLeaves(context.Context, *QueueLeavesRequest) (*QueueLeavesResponse, error)
 	GetLeavesByIndex(context.Context, *GetLeavesByIndexRequest) (*GetLeavesByIndexResponse, error)
 	GetLeavesByHash(context.Context, *GetLeavesByHashRequest) (*GetLeavesByHashResponse, error)
+	// GetEntries streams the leaves in [start_index, end_index) in index
+	// order, in chunks chosen by the server. The stream ends at the size of
+	// the latest signed root when the call started; an interrupted stream can
+	// be resumed by calling again from the index after the last leaf received.
+	GetEntries(*GetEntriesRequest, TrillianLog_GetEntriesServer) error
+	// GetSignedLogRootHistory streams the retained signed roots of the log
+	// from start_revision in revision order, which is also tree size order,
+	// in chunks chosen by the server. The stream ends at the latest signed
+	// root when the call started. Roots may have been pruned from storage,
+	// so the history can start later than the log's first root and skip
+	// revisions; each consecutive pair can still be checked for consistency.
+	GetSignedLogRootHistory(*GetSignedLogRootHistoryRequest, TrillianLog_GetSignedLogRootHistoryServer) error
+	// HasLeaves reports whether leaves with the given identity hashes are in
+	// the log, without returning their data or proofs.
+	HasLeaves(context.Context, *HasLeavesRequest) (*HasLeavesResponse, error)
+	// CountLeaves returns the number of leaves matching the request, without
+	// returning their data. Counts including leaves that are queued but not
+	// yet sequenced are best-effort: they change as leaves are queued and
+	// sequenced, and don't correspond to any signed root.
+	CountLeaves(context.Context, *CountLeavesRequest) (*CountLeavesResponse, error)
 }
 
 func RegisterTrillianLogServer(s *grpc.Server, srv TrillianLogServer) {
@@ -861,6 +2125,24 @@ func _TrillianLog_GetInclusionProof_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetInclusionProofWithRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInclusionProofWithRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetInclusionProofWithRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetInclusionProofWithRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetInclusionProofWithRoot(ctx, req.(*GetInclusionProofWithRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetInclusionProofByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetInclusionProofByHashRequest)
 	if err := dec(in); err != nil {
@@ -897,6 +2179,42 @@ func _TrillianLog_GetConsistencyProof_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_CheckConsistencyProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckConsistencyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).CheckConsistencyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/CheckConsistencyProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).CheckConsistencyProof(ctx, req.(*CheckConsistencyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_GetProofByMerkleHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProofByMerkleHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetProofByMerkleHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetProofByMerkleHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetProofByMerkleHash(ctx, req.(*GetProofByMerkleHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetLatestSignedLogRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetLatestSignedLogRootRequest)
 	if err := dec(in); err != nil {
@@ -915,6 +2233,78 @@ func _TrillianLog_GetLatestSignedLogRoot_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetLatestCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestCheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetLatestCheckpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetLatestCheckpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetLatestCheckpoint(ctx, req.(*GetLatestCheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_GetSignedLogRootAtTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSignedLogRootAtTimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetSignedLogRootAtTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetSignedLogRootAtTime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetSignedLogRootAtTime(ctx, req.(*GetSignedLogRootAtTimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_AddCosignature_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCosignatureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).AddCosignature(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/AddCosignature",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).AddCosignature(ctx, req.(*AddCosignatureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_GetLatestCosignedLogRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestCosignedLogRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetLatestCosignedLogRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetLatestCosignedLogRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetLatestCosignedLogRoot(ctx, req.(*GetLatestCosignedLogRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetSequencedLeafCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetSequencedLeafCountRequest)
 	if err := dec(in); err != nil {
@@ -951,6 +2341,24 @@ func _TrillianLog_GetEntryAndProof_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetLatestLeafByIdentityHashPrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestLeafByIdentityHashPrefixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetLatestLeafByIdentityHashPrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetLatestLeafByIdentityHashPrefix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetLatestLeafByIdentityHashPrefix(ctx, req.(*GetLatestLeafByIdentityHashPrefixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_QueueLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(QueueLeavesRequest)
 	if err := dec(in); err != nil {
@@ -1005,6 +2413,84 @@ func _TrillianLog_GetLeavesByHash_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetEntriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrillianLogServer).GetEntries(m, &trillianLogGetEntriesServer{stream})
+}
+
+type TrillianLog_GetEntriesServer interface {
+	Send(*GetEntriesResponse) error
+	grpc.ServerStream
+}
+
+type trillianLogGetEntriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *trillianLogGetEntriesServer) Send(m *GetEntriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TrillianLog_GetSignedLogRootHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSignedLogRootHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrillianLogServer).GetSignedLogRootHistory(m, &trillianLogGetSignedLogRootHistoryServer{stream})
+}
+
+type TrillianLog_GetSignedLogRootHistoryServer interface {
+	Send(*GetSignedLogRootHistoryResponse) error
+	grpc.ServerStream
+}
+
+type trillianLogGetSignedLogRootHistoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *trillianLogGetSignedLogRootHistoryServer) Send(m *GetSignedLogRootHistoryResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TrillianLog_HasLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HasLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).HasLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/HasLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).HasLeaves(ctx, req.(*HasLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_CountLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).CountLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/CountLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).CountLeaves(ctx, req.(*CountLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianLog",
 	HandlerType: (*TrillianLogServer)(nil),
@@ -1017,6 +2503,10 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetInclusionProof",
 			Handler:    _TrillianLog_GetInclusionProof_Handler,
 		},
+		{
+			MethodName: "GetInclusionProofWithRoot",
+			Handler:    _TrillianLog_GetInclusionProofWithRoot_Handler,
+		},
 		{
 			MethodName: "GetInclusionProofByHash",
 			Handler:    _TrillianLog_GetInclusionProofByHash_Handler,
@@ -1025,10 +2515,34 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetConsistencyProof",
 			Handler:    _TrillianLog_GetConsistencyProof_Handler,
 		},
+		{
+			MethodName: "CheckConsistencyProof",
+			Handler:    _TrillianLog_CheckConsistencyProof_Handler,
+		},
+		{
+			MethodName: "GetProofByMerkleHash",
+			Handler:    _TrillianLog_GetProofByMerkleHash_Handler,
+		},
 		{
 			MethodName: "GetLatestSignedLogRoot",
 			Handler:    _TrillianLog_GetLatestSignedLogRoot_Handler,
 		},
+		{
+			MethodName: "GetLatestCheckpoint",
+			Handler:    _TrillianLog_GetLatestCheckpoint_Handler,
+		},
+		{
+			MethodName: "GetSignedLogRootAtTime",
+			Handler:    _TrillianLog_GetSignedLogRootAtTime_Handler,
+		},
+		{
+			MethodName: "AddCosignature",
+			Handler:    _TrillianLog_AddCosignature_Handler,
+		},
+		{
+			MethodName: "GetLatestCosignedLogRoot",
+			Handler:    _TrillianLog_GetLatestCosignedLogRoot_Handler,
+		},
 		{
 			MethodName: "GetSequencedLeafCount",
 			Handler:    _TrillianLog_GetSequencedLeafCount_Handler,
@@ -1037,6 +2551,10 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetEntryAndProof",
 			Handler:    _TrillianLog_GetEntryAndProof_Handler,
 		},
+		{
+			MethodName: "GetLatestLeafByIdentityHashPrefix",
+			Handler:    _TrillianLog_GetLatestLeafByIdentityHashPrefix_Handler,
+		},
 		{
 			MethodName: "QueueLeaves",
 			Handler:    _TrillianLog_QueueLeaves_Handler,
@@ -1049,83 +2567,179 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetLeavesByHash",
 			Handler:    _TrillianLog_GetLeavesByHash_Handler,
 		},
+		{
+			MethodName: "HasLeaves",
+			Handler:    _TrillianLog_HasLeaves_Handler,
+		},
+		{
+			MethodName: "CountLeaves",
+			Handler:    _TrillianLog_CountLeaves_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetEntries",
+			Handler:       _TrillianLog_GetEntries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetSignedLogRootHistory",
+			Handler:       _TrillianLog_GetSignedLogRootHistory_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_log_api.proto",
 }
 
 func init() { proto.RegisterFile("trillian_log_api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1118 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4f, 0x6f, 0x1b, 0x45,
-	0x14, 0xef, 0x7a, 0x93, 0x90, 0xbc, 0xfc, 0x73, 0x26, 0x6a, 0xe2, 0x6e, 0x12, 0x48, 0xa7, 0xa4,
-	0x75, 0x43, 0xc9, 0x92, 0xa0, 0x02, 0x8a, 0x2a, 0x50, 0xdd, 0x54, 0x69, 0x90, 0x11, 0xc1, 0xa9,
-	0x2a, 0x24, 0x0e, 0xab, 0x89, 0x77, 0xe2, 0xac, 0xd8, 0xec, 0xb8, 0x3b, 0xe3, 0x28, 0x6e, 0xd5,
-	0x0b, 0x12, 0x47, 0x4e, 0x70, 0xe0, 0x82, 0xe0, 0xc6, 0x07, 0xe0, 0xce, 0x97, 0xe0, 0x2b, 0xf0,
-	0x41, 0xd0, 0xce, 0xcc, 0x7a, 0xbd, 0xeb, 0xf5, 0x3a, 0x46, 0xea, 0xcd, 0x7e, 0x7f, 0x7e, 0xef,
-	0xf7, 0xde, 0x9b, 0xf7, 0x9e, 0x0d, 0x2b, 0x22, 0xf4, 0x7c, 0xdf, 0x23, 0x81, 0xe3, 0xb3, 0x96,
-	0x43, 0xda, 0xde, 0x4e, 0x3b, 0x64, 0x82, 0xa1, 0xe9, 0x58, 0x6e, 0x2d, 0xc4, 0x9f, 0x94, 0xc6,
-	0x5a, 0x6d, 0x31, 0xd6, 0xf2, 0xa9, 0x1d, 0xb6, 0x9b, 0x36, 0x17, 0x44, 0x74, 0xb8, 0x56, 0xac,
-	0x6b, 0x05, 0x69, 0x7b, 0x36, 0x09, 0x02, 0x26, 0x88, 0xf0, 0x58, 0xa0, 0xb5, 0xf8, 0x6f, 0x03,
-	0xde, 0xa9, 0xb3, 0x56, 0x9d, 0x92, 0x33, 0x54, 0x85, 0xf2, 0x05, 0x0d, 0xbf, 0xf7, 0xa9, 0xe3,
-	0x53, 0x72, 0xe6, 0x9c, 0x13, 0x7e, 0x5e, 0x31, 0x36, 0x8d, 0xea, 0x5c, 0x63, 0x41, 0xc9, 0x23,
-	0xab, 0x67, 0x84, 0x9f, 0xa3, 0x0d, 0x00, 0x69, 0x72, 0x49, 0xfc, 0x0e, 0xad, 0x94, 0xa4, 0xcd,
-	0x4c, 0x24, 0x79, 0x11, 0x09, 0x22, 0x35, 0xbd, 0x12, 0x21, 0x71, 0x5c, 0x22, 0x48, 0xc5, 0x54,
-	0x6a, 0x29, 0x39, 0x20, 0x82, 0xf4, 0xbc, 0xbd, 0xc0, 0xa5, 0x57, 0x95, 0x89, 0x4d, 0xa3, 0x6a,
-	0x2a, 0xef, 0xa3, 0x48, 0x80, 0x1e, 0x00, 0x52, 0x6a, 0x97, 0x06, 0xc2, 0x13, 0x5d, 0x45, 0x64,
-	0x52, 0xa2, 0x94, 0xa5, 0x99, 0x56, 0x44, 0x54, 0xf0, 0x01, 0x4c, 0x1e, 0x87, 0x8c, 0x9d, 0x65,
-	0x50, 0x8d, 0x2c, 0xea, 0x0a, 0x4c, 0x45, 0x38, 0x94, 0x57, 0xcc, 0x4d, 0xb3, 0x3a, 0xd7, 0xd0,
-	0xdf, 0xbe, 0x9c, 0x98, 0x2e, 0x95, 0x4d, 0x7c, 0x0a, 0xf3, 0xdf, 0x74, 0x68, 0x87, 0xba, 0x71,
-	0x2d, 0xb6, 0x60, 0x22, 0xf2, 0x95, 0x38, 0xb3, 0x7b, 0x4b, 0x3b, 0xbd, 0x6a, 0x6b, 0x83, 0x86,
-	0x54, 0xa3, 0x6d, 0x98, 0x52, 0xc5, 0x96, 0x45, 0x98, 0xdd, 0x43, 0x3b, 0xaa, 0xda, 0x3b, 0x61,
-	0xbb, 0xb9, 0x73, 0x22, 0x35, 0x0d, 0x6d, 0x81, 0x5f, 0x00, 0x92, 0x31, 0xea, 0x94, 0x5c, 0x52,
-	0xde, 0xa0, 0x2f, 0x3b, 0x94, 0x0b, 0x74, 0x13, 0xa6, 0xa2, 0x16, 0x7b, 0xae, 0xa6, 0x3c, 0xe9,
-	0xb3, 0xd6, 0x91, 0x8b, 0xee, 0xc3, 0x94, 0x2f, 0xed, 0x2a, 0xa5, 0x4d, 0x33, 0x9f, 0x81, 0x36,
-	0xc0, 0xc7, 0x50, 0x8e, 0x71, 0xcf, 0x46, 0xa0, 0xc6, 0x59, 0x95, 0x0a, 0xb3, 0xc2, 0x5f, 0xc1,
-	0x52, 0x1f, 0x22, 0x6f, 0xb3, 0x80, 0x53, 0xf4, 0x19, 0xcc, 0xbe, 0x94, 0x25, 0x72, 0xfa, 0x20,
-	0x56, 0x13, 0x88, 0x54, 0xfd, 0x1a, 0xa0, 0x6c, 0xa3, 0xcf, 0xf8, 0x04, 0x96, 0x53, 0x89, 0x6b,
-	0xc0, 0x47, 0x30, 0x9f, 0x00, 0x26, 0x99, 0x0e, 0x85, 0x9c, 0xeb, 0x41, 0x46, 0x59, 0x5f, 0x40,
-	0xe5, 0x90, 0x8a, 0xa3, 0xa0, 0xe9, 0x77, 0xb8, 0xc7, 0x02, 0xf9, 0x06, 0x46, 0x64, 0x9f, 0x7e,
-	0x21, 0xa5, 0xec, 0x0b, 0x59, 0x83, 0x19, 0x11, 0x52, 0xea, 0x70, 0xef, 0x15, 0x95, 0x8f, 0xd6,
-	0x6c, 0x4c, 0x47, 0x82, 0x13, 0xef, 0x15, 0xc5, 0x35, 0xb8, 0x95, 0x13, 0x4e, 0x67, 0xb2, 0x05,
-	0x93, 0xed, 0x48, 0xa0, 0x8b, 0xb2, 0x98, 0x64, 0xa0, 0xec, 0x94, 0x16, 0xff, 0x66, 0xc0, 0xbb,
-	0x03, 0x20, 0x35, 0xf9, 0x8c, 0x47, 0x30, 0x5f, 0x83, 0x99, 0x64, 0x24, 0xd5, 0xb8, 0x4d, 0xfb,
-	0xf1, 0x30, 0x16, 0xf1, 0x46, 0xdb, 0xb0, 0xc4, 0x42, 0x97, 0x86, 0xce, 0x69, 0xd7, 0xe1, 0x51,
-	0x90, 0xa0, 0x49, 0xe5, 0xc8, 0x4d, 0x37, 0x16, 0xa5, 0xa2, 0xd6, 0x3d, 0xd1, 0x62, 0xfc, 0x0c,
-	0xde, 0x1b, 0x4a, 0x6f, 0x30, 0x53, 0xb3, 0x20, 0xd3, 0x1f, 0x0d, 0xb0, 0x0e, 0xa9, 0x78, 0xc2,
-	0x02, 0xee, 0x71, 0x41, 0x83, 0x66, 0xf7, 0x3a, 0xfd, 0xb9, 0x0b, 0x8b, 0x67, 0x5e, 0xc8, 0x85,
-	0x93, 0xa4, 0xa3, 0x9a, 0x34, 0x2f, 0xc5, 0xcf, 0xe3, 0x9c, 0xaa, 0x50, 0xe6, 0xb4, 0xc9, 0x02,
-	0xd7, 0xc9, 0xe6, 0xbd, 0xa0, 0xe4, 0xb1, 0x25, 0x3e, 0x80, 0xb5, 0x5c, 0x1a, 0xe3, 0xf5, 0xed,
-	0x0a, 0x56, 0x0e, 0xa9, 0x50, 0xef, 0xee, 0xff, 0xb4, 0xcb, 0x4c, 0xb5, 0x2b, 0xb7, 0x23, 0x66,
-	0x7e, 0x47, 0x0e, 0x60, 0x75, 0x20, 0xb2, 0xe6, 0x3e, 0xc6, 0x82, 0xf8, 0x3a, 0x85, 0x22, 0x1f,
-	0xfb, 0x98, 0x93, 0x62, 0xa6, 0x26, 0x05, 0x3f, 0x95, 0xb3, 0x97, 0x01, 0x1c, 0x9f, 0xd7, 0x43,
-	0x58, 0x3f, 0xa4, 0x22, 0x4e, 0x56, 0xee, 0x8a, 0x27, 0xac, 0x13, 0x88, 0x62, 0x72, 0xf8, 0x73,
-	0xd8, 0x18, 0xe2, 0xa6, 0x29, 0xc4, 0xec, 0x9b, 0x91, 0xb4, 0x7f, 0xce, 0xa5, 0x19, 0xfe, 0x44,
-	0xfa, 0xd7, 0x89, 0xa0, 0x5c, 0x9c, 0x78, 0xad, 0x40, 0x6e, 0x98, 0x06, 0x63, 0xa3, 0xe2, 0x12,
-	0x39, 0xbd, 0xb9, 0x7e, 0x3a, 0xf0, 0x17, 0xb0, 0xc8, 0xa5, 0x42, 0x5e, 0xed, 0x90, 0x31, 0x31,
-	0xb8, 0x26, 0xd3, 0x9e, 0xf3, 0xbc, 0xff, 0x2b, 0xf6, 0x65, 0xa7, 0x9e, 0x06, 0x22, 0xec, 0x3e,
-	0x0e, 0xdc, 0xb7, 0xbd, 0xd3, 0xce, 0x65, 0x1b, 0x33, 0xd1, 0xc6, 0x1a, 0x8d, 0xde, 0x41, 0x31,
-	0x0b, 0x0f, 0xca, 0xde, 0x5f, 0x00, 0xb3, 0xcf, 0xb5, 0xaa, 0xce, 0x5a, 0x28, 0x80, 0x99, 0xde,
-	0x81, 0x41, 0x56, 0x66, 0xe1, 0xf7, 0xdd, 0x31, 0x6b, 0x2d, 0x57, 0xa7, 0x38, 0xe2, 0xea, 0x0f,
-	0xff, 0xfc, 0xfb, 0x73, 0x09, 0xe3, 0x0d, 0xfb, 0x72, 0xf7, 0x94, 0x0a, 0xb2, 0x6b, 0xfb, 0xac,
-	0xc5, 0xed, 0xd7, 0xaa, 0x4e, 0x6f, 0x6c, 0xf5, 0xcc, 0xf6, 0x8d, 0x6d, 0xf4, 0x87, 0x01, 0x4b,
-	0x03, 0xab, 0x0d, 0xe1, 0x04, 0x7c, 0xd8, 0x29, 0xb1, 0xee, 0x14, 0xda, 0x68, 0x22, 0x35, 0x49,
-	0xe4, 0x11, 0xda, 0x2f, 0x24, 0x62, 0xbf, 0x4e, 0x3a, 0xf5, 0x66, 0xdf, 0x8b, 0xa1, 0x1c, 0x55,
-	0xc9, 0x3f, 0x0d, 0xd9, 0xfb, 0xbc, 0xed, 0x8b, 0xaa, 0x05, 0x24, 0x52, 0x0b, 0xc9, 0xba, 0x7f,
-	0x0d, 0x4b, 0x4d, 0xfa, 0x53, 0x49, 0x7a, 0x17, 0xd9, 0xc5, 0xd5, 0x4b, 0x78, 0x9e, 0xaa, 0x5f,
-	0x62, 0xe8, 0x17, 0x03, 0x96, 0x73, 0xb6, 0x2a, 0x7a, 0x3f, 0x15, 0x7b, 0xc8, 0xee, 0xb7, 0xb6,
-	0x46, 0x58, 0x69, 0x76, 0x1f, 0x49, 0x76, 0xdb, 0xa8, 0x9a, 0xcf, 0x6e, 0xbf, 0x99, 0x38, 0xea,
-	0x02, 0xfe, 0x6a, 0xa8, 0x35, 0x3d, 0x38, 0x9f, 0xe8, 0x5e, 0x2a, 0xe6, 0xf0, 0xc9, 0xb7, 0xaa,
-	0xa3, 0x0d, 0x35, 0xbf, 0x0f, 0x24, 0xbf, 0x2d, 0x74, 0x67, 0x48, 0xf5, 0xa2, 0xe1, 0xe7, 0xfb,
-	0xbe, 0x44, 0x40, 0xbf, 0x1b, 0x70, 0x33, 0x77, 0x65, 0xa1, 0xbb, 0xa9, 0x80, 0x43, 0x57, 0xa1,
-	0x75, 0x6f, 0xa4, 0x9d, 0xe6, 0xf5, 0x50, 0xf2, 0xb2, 0xd1, 0x87, 0xc5, 0x5d, 0x8d, 0x0f, 0x8f,
-	0xab, 0x96, 0x24, 0xfa, 0xc9, 0x80, 0x72, 0x76, 0x17, 0xa0, 0xdb, 0xa9, 0xa0, 0x79, 0x5b, 0xc9,
-	0xc2, 0x45, 0x26, 0x9a, 0xd2, 0x9e, 0xa4, 0xf4, 0x00, 0x6d, 0x5f, 0x7f, 0x3a, 0x50, 0x1d, 0x66,
-	0xfb, 0x7e, 0x32, 0xa2, 0xf5, 0xc1, 0x35, 0x90, 0xfc, 0x84, 0xb6, 0x36, 0x86, 0x68, 0x75, 0xfc,
-	0x1b, 0xe8, 0x3b, 0x99, 0x5c, 0xea, 0x5e, 0x65, 0x92, 0xcb, 0x3b, 0x8e, 0x99, 0xe4, 0x72, 0xcf,
-	0x1d, 0xbe, 0x81, 0xbe, 0x85, 0xc5, 0xcc, 0x8d, 0x46, 0x9b, 0xb9, 0x8e, 0xfd, 0x73, 0x7a, 0xbb,
-	0xc0, 0x22, 0x46, 0xae, 0xed, 0xc1, 0xad, 0x26, 0xbb, 0x88, 0xff, 0x51, 0xa4, 0xff, 0xef, 0xd5,
-	0x96, 0xfb, 0xf6, 0xe9, 0xe3, 0xb6, 0x77, 0x1c, 0x09, 0x8f, 0x8d, 0xd3, 0x29, 0xa9, 0xfd, 0xf8,
-	0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x78, 0x40, 0xfb, 0xd5, 0x41, 0x0e, 0x00, 0x00,
+	// 2341 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x5a, 0xdf, 0x53, 0x1c, 0xc7,
+	0xf1, 0xd7, 0xde, 0x01, 0x86, 0x46, 0x1c, 0xc7, 0x20, 0xe0, 0x58, 0x84, 0x40, 0xab, 0x2f, 0xd2,
+	0x09, 0xdb, 0x9c, 0x85, 0xbe, 0x8e, 0x64, 0x4a, 0x15, 0x17, 0x1c, 0x48, 0x60, 0x9d, 0x00, 0xdf,
+	0x1d, 0xb6, 0x52, 0xa9, 0xca, 0xd6, 0x72, 0x3b, 0x1c, 0x1b, 0x2d, 0xbb, 0xa7, 0xdd, 0x39, 0x02,
+	0x76, 0x9c, 0x4a, 0xe2, 0xa4, 0x2a, 0xa9, 0x54, 0xf2, 0x92, 0x54, 0x2a, 0x2f, 0x4e, 0xfc, 0x92,
+	0x4a, 0xde, 0xf3, 0x1f, 0xe4, 0x5f, 0xc8, 0x63, 0x5e, 0xf3, 0x87, 0xa4, 0x76, 0x66, 0xf6, 0xf7,
+	0xaf, 0x23, 0x8a, 0x2b, 0x6f, 0x6c, 0x77, 0x4f, 0xff, 0xf8, 0x4c, 0x4f, 0x4f, 0x4f, 0x1f, 0x30,
+	0x4b, 0x2c, 0x4d, 0xd7, 0x35, 0xc5, 0x90, 0x75, 0xb3, 0x2b, 0x2b, 0x3d, 0x6d, 0xad, 0x67, 0x99,
+	0xc4, 0x44, 0xa3, 0x2e, 0x5d, 0x2c, 0xb9, 0x7f, 0x31, 0x8e, 0x38, 0xd7, 0x35, 0xcd, 0xae, 0x8e,
+	0x6b, 0x56, 0xaf, 0x53, 0xb3, 0x89, 0x42, 0xfa, 0x36, 0x67, 0xdc, 0xe4, 0x0c, 0xa5, 0xa7, 0xd5,
+	0x14, 0xc3, 0x30, 0x89, 0x42, 0x34, 0xd3, 0x70, 0xb9, 0x4b, 0x9c, 0x4b, 0xbf, 0x8e, 0xfb, 0x27,
+	0x35, 0xa2, 0x9d, 0x61, 0x9b, 0x28, 0x67, 0x3d, 0x26, 0x20, 0x7d, 0x59, 0x80, 0xb7, 0x1a, 0x66,
+	0xb7, 0x81, 0x95, 0x13, 0x54, 0x85, 0xf2, 0x19, 0xb6, 0x5e, 0xe9, 0x58, 0xd6, 0xb1, 0x72, 0x22,
+	0x9f, 0x2a, 0xf6, 0x69, 0x45, 0x58, 0x16, 0xaa, 0xd7, 0x9b, 0x25, 0x46, 0x77, 0xa4, 0x76, 0x15,
+	0xfb, 0x14, 0x2d, 0x02, 0x50, 0x91, 0x73, 0x45, 0xef, 0xe3, 0x4a, 0x81, 0xca, 0x8c, 0x39, 0x94,
+	0x4f, 0x1c, 0x82, 0xc3, 0xc6, 0x17, 0xc4, 0x52, 0x64, 0x55, 0x21, 0x4a, 0xa5, 0xc8, 0xd8, 0x94,
+	0xb2, 0xad, 0x10, 0xc5, 0x5b, 0xad, 0x19, 0x2a, 0xbe, 0xa8, 0x0c, 0x2d, 0x0b, 0xd5, 0x22, 0x5b,
+	0xbd, 0xe7, 0x10, 0xd0, 0x3b, 0x80, 0x18, 0x5b, 0xc5, 0x06, 0xd1, 0xc8, 0x25, 0x73, 0x64, 0x98,
+	0x6a, 0x29, 0x53, 0x31, 0xce, 0xa0, 0xae, 0xd4, 0x61, 0xf2, 0x75, 0x1f, 0xf7, 0xb1, 0xec, 0x45,
+	0x56, 0x19, 0x59, 0x16, 0xaa, 0xe3, 0xeb, 0xe2, 0x1a, 0x8b, 0x7d, 0xcd, 0x8d, 0x7d, 0xad, 0xed,
+	0x4a, 0x34, 0x4b, 0x74, 0x89, 0xf7, 0x2d, 0xfd, 0x4d, 0x80, 0xf2, 0x36, 0x56, 0xd4, 0x06, 0x26,
+	0x04, 0x5b, 0x58, 0xa5, 0x70, 0xac, 0xc0, 0x90, 0x63, 0x8d, 0x42, 0x30, 0xbe, 0x3e, 0xb5, 0xe6,
+	0xed, 0x08, 0xc7, 0xab, 0x49, 0xd9, 0x68, 0x16, 0x46, 0x2c, 0xac, 0xd8, 0xa6, 0x41, 0x71, 0x18,
+	0x6b, 0xf2, 0x2f, 0x24, 0xc2, 0xa8, 0x42, 0x08, 0x3e, 0xeb, 0x11, 0x9b, 0x42, 0x30, 0xdc, 0xf4,
+	0xbe, 0xd1, 0x36, 0x94, 0x55, 0xac, 0xa8, 0xb2, 0x4e, 0xed, 0x51, 0xd7, 0x2b, 0x43, 0xf9, 0x5e,
+	0xab, 0x9e, 0x8b, 0x0e, 0x51, 0xda, 0x86, 0xe1, 0x43, 0xcb, 0x34, 0x4f, 0x22, 0x80, 0x0a, 0x51,
+	0x40, 0x67, 0x61, 0xc4, 0x81, 0x10, 0x3b, 0x7e, 0x14, 0xab, 0xd7, 0x9b, 0xfc, 0xeb, 0xa3, 0xa1,
+	0xd1, 0x42, 0xb9, 0x28, 0x7d, 0x29, 0xc0, 0xc4, 0xc7, 0x0e, 0x1c, 0xaa, 0x9b, 0x07, 0x03, 0x06,
+	0xbe, 0x0a, 0x23, 0x2c, 0x13, 0x69, 0xe0, 0xe3, 0xeb, 0xc8, 0x75, 0xdd, 0xea, 0x75, 0xd6, 0x5a,
+	0x94, 0xd3, 0xe4, 0x12, 0x68, 0x09, 0xc6, 0xd9, 0x2e, 0xa9, 0xb8, 0x47, 0x4e, 0x29, 0x1e, 0xc5,
+	0x26, 0x50, 0xd2, 0xb6, 0x43, 0x91, 0x7e, 0x26, 0x00, 0xa2, 0x5e, 0x34, 0xb0, 0x72, 0x8e, 0xed,
+	0x26, 0x7e, 0xdd, 0xc7, 0x36, 0x41, 0x33, 0x30, 0xe2, 0x9c, 0x10, 0x4d, 0xe5, 0x51, 0x0d, 0xeb,
+	0x66, 0x77, 0x4f, 0x45, 0xf7, 0x61, 0x44, 0xa7, 0x72, 0x95, 0xc2, 0x72, 0x31, 0xd9, 0x47, 0x2e,
+	0xe0, 0x64, 0x93, 0x85, 0x49, 0xdf, 0x32, 0xe4, 0xa8, 0x03, 0xa3, 0xcd, 0x32, 0xe3, 0x7c, 0xec,
+	0xbb, 0x71, 0x08, 0x65, 0xd7, 0x8b, 0x93, 0x1c, 0x1f, 0x5c, 0x94, 0x0a, 0x99, 0x28, 0x49, 0x2f,
+	0x60, 0x2a, 0xa0, 0xd1, 0xee, 0x99, 0x86, 0x8d, 0xd1, 0x63, 0x0e, 0x87, 0x2a, 0x07, 0x54, 0xcc,
+	0xf9, 0x2a, 0x42, 0xfb, 0xc1, 0x71, 0xa2, 0x49, 0x29, 0xb5, 0x60, 0x3a, 0x04, 0x13, 0x57, 0xf8,
+	0x04, 0x26, 0x7c, 0x85, 0x3e, 0x2e, 0xa9, 0x2a, 0xaf, 0x7b, 0x2a, 0xcf, 0xb1, 0x2d, 0x9d, 0x41,
+	0xe5, 0x19, 0x26, 0x7b, 0x46, 0x47, 0xef, 0xdb, 0x9a, 0x69, 0xd0, 0xa4, 0xca, 0x89, 0x3e, 0x9c,
+	0x72, 0x85, 0x68, 0xca, 0x2d, 0xc0, 0x18, 0xb1, 0x30, 0x96, 0x6d, 0xed, 0x33, 0xcc, 0x77, 0x7b,
+	0xd4, 0x21, 0xb4, 0xb4, 0xcf, 0xb0, 0xf4, 0x12, 0xe6, 0x13, 0xcc, 0xf1, 0x48, 0x56, 0x60, 0xb8,
+	0xe7, 0x10, 0x38, 0x28, 0x93, 0x7e, 0x04, 0x4c, 0x8e, 0x71, 0xd1, 0x0d, 0x18, 0xb6, 0x89, 0xa2,
+	0x63, 0xbe, 0x93, 0xec, 0x43, 0xea, 0xc3, 0x72, 0x4c, 0xf3, 0xa7, 0x1a, 0x39, 0x6d, 0x9a, 0x26,
+	0xf9, 0x06, 0x03, 0xfa, 0x95, 0x00, 0xb7, 0x33, 0xec, 0x46, 0x23, 0x13, 0x32, 0x23, 0xfb, 0x10,
+	0x26, 0x6d, 0xad, 0x6b, 0x38, 0x5b, 0x69, 0x76, 0x65, 0xcb, 0x34, 0x49, 0x3c, 0x3f, 0x5a, 0x54,
+	0xa0, 0x61, 0x76, 0xa9, 0x81, 0x09, 0x3b, 0xf8, 0x29, 0x7d, 0x25, 0xc0, 0xad, 0x98, 0x37, 0x5b,
+	0xb4, 0x5a, 0xe6, 0x60, 0xb0, 0x00, 0x63, 0x7e, 0xe5, 0x67, 0x55, 0x7d, 0x54, 0x77, 0x6b, 0x7e,
+	0x16, 0x02, 0x68, 0x15, 0xa6, 0x4c, 0x4b, 0xc5, 0x96, 0x7c, 0x7c, 0x29, 0xdb, 0x8e, 0x11, 0xa3,
+	0xc3, 0x2a, 0xda, 0x68, 0x73, 0x92, 0x32, 0xb6, 0x2e, 0x5b, 0x9c, 0x2c, 0xed, 0xc2, 0x52, 0xaa,
+	0x7b, 0xf1, 0x24, 0x28, 0xa6, 0x43, 0x25, 0xfd, 0x5c, 0x00, 0xf1, 0x19, 0x26, 0x75, 0xd3, 0xb0,
+	0x35, 0x9b, 0x60, 0xa3, 0x73, 0x39, 0x48, 0xea, 0xde, 0x85, 0xc9, 0x13, 0xcd, 0xb2, 0x89, 0xec,
+	0x87, 0xc3, 0xb6, 0x7b, 0x82, 0x92, 0xdb, 0x6e, 0x4c, 0x55, 0x28, 0xdb, 0xb8, 0x63, 0x1a, 0xaa,
+	0x1c, 0x8d, 0xbb, 0xc4, 0xe8, 0xae, 0xa4, 0xb4, 0x0d, 0x0b, 0x89, 0x6e, 0x5c, 0x29, 0xa5, 0xa5,
+	0x1f, 0x17, 0xe0, 0x66, 0xfd, 0x14, 0x77, 0x5e, 0xfd, 0xaf, 0xe3, 0xf1, 0x35, 0x3a, 0xd9, 0xc7,
+	0xb2, 0x61, 0x88, 0x66, 0x03, 0xd3, 0xe8, 0x64, 0x19, 0x4d, 0x09, 0x5f, 0xa3, 0x2f, 0xc8, 0xee,
+	0x69, 0xae, 0xd1, 0x93, 0xf4, 0x20, 0x18, 0xc9, 0x84, 0xa0, 0x07, 0x8b, 0x29, 0x08, 0x70, 0x28,
+	0x1f, 0x39, 0x97, 0xad, 0xdd, 0xd7, 0x09, 0x85, 0xa0, 0xb4, 0xbe, 0xe4, 0x2b, 0x8a, 0xae, 0xa1,
+	0x8a, 0x9a, 0x5c, 0xdc, 0xb9, 0x03, 0x55, 0x4c, 0x14, 0x4d, 0x77, 0x6f, 0x69, 0xf6, 0x25, 0xfd,
+	0x5d, 0xa0, 0x7b, 0xc7, 0x93, 0xf0, 0x05, 0x6d, 0x73, 0xde, 0xf4, 0xa4, 0x24, 0x6c, 0x48, 0x31,
+	0x69, 0x43, 0x42, 0x27, 0x6a, 0x68, 0x90, 0x13, 0x35, 0x9c, 0x7c, 0xa2, 0x7e, 0x2f, 0xc0, 0xcd,
+	0xe4, 0x20, 0xbc, 0xfb, 0x66, 0x52, 0x73, 0xcf, 0x9b, 0x9c, 0x59, 0x84, 0x4a, 0x5a, 0xe8, 0x5c,
+	0xa2, 0x27, 0x30, 0xd5, 0xf1, 0x81, 0x95, 0x33, 0xf3, 0xb8, 0xdc, 0x89, 0x6c, 0x81, 0x74, 0x01,
+	0xb3, 0xcf, 0x30, 0x61, 0xb7, 0xcc, 0x7f, 0x52, 0x81, 0x8a, 0x21, 0x5c, 0x13, 0x21, 0x29, 0x26,
+	0x43, 0xb2, 0x0d, 0x73, 0x31, 0xcb, 0x1c, 0x8c, 0xc1, 0x9b, 0x07, 0xe9, 0x17, 0x02, 0x94, 0x77,
+	0x15, 0x7b, 0xa0, 0x9e, 0x24, 0xb9, 0x6d, 0x65, 0x31, 0xc4, 0xdb, 0xd6, 0x1a, 0x4c, 0x53, 0xa4,
+	0x55, 0x2c, 0xf7, 0x0d, 0x37, 0x18, 0x95, 0x47, 0x83, 0x38, 0xeb, 0xc8, 0xe7, 0x48, 0xef, 0xc2,
+	0x54, 0xc0, 0x13, 0x1e, 0x4a, 0x05, 0xde, 0xea, 0x59, 0xd8, 0xc6, 0x86, 0x73, 0x1e, 0x8a, 0xd5,
+	0xd1, 0xa6, 0xfb, 0x29, 0xfd, 0xb9, 0x00, 0xa8, 0x6e, 0xf6, 0x0d, 0x32, 0x90, 0xef, 0x1f, 0xc1,
+	0xf4, 0x99, 0xe6, 0x76, 0x48, 0x7e, 0x23, 0x5d, 0xc8, 0x6d, 0x49, 0xa7, 0xce, 0x34, 0xd6, 0x3e,
+	0x79, 0x24, 0xaa, 0x4b, 0xb9, 0x88, 0xe9, 0x2a, 0x0e, 0xa0, 0x4b, 0xb9, 0x88, 0xe8, 0xfa, 0x00,
+	0xe6, 0xe3, 0x98, 0xca, 0x3d, 0x0b, 0x9f, 0x68, 0x17, 0xbc, 0x24, 0xcd, 0x46, 0xa1, 0x3d, 0xa4,
+	0x5c, 0xb4, 0x02, 0x25, 0x0f, 0x3c, 0xd9, 0x34, 0xf4, 0x4b, 0x7e, 0x78, 0x26, 0x3c, 0xea, 0x81,
+	0xa1, 0x5f, 0x4a, 0xff, 0x0f, 0xd3, 0x21, 0x98, 0x38, 0xb0, 0x6e, 0x37, 0xd0, 0x71, 0x78, 0xc1,
+	0x8e, 0x9a, 0x0a, 0x4b, 0x24, 0x94, 0x5d, 0xb4, 0x43, 0xb8, 0x62, 0x7b, 0x51, 0x0c, 0xb7, 0x17,
+	0x77, 0x60, 0x42, 0xd1, 0x75, 0xf3, 0x07, 0x72, 0x4f, 0xb1, 0x88, 0xa6, 0xe8, 0x3c, 0x11, 0xae,
+	0x53, 0xe2, 0x21, 0xa3, 0x49, 0x3f, 0x11, 0xa0, 0x12, 0x37, 0x7b, 0xe5, 0xac, 0x46, 0x1b, 0x30,
+	0x4e, 0x7d, 0xe1, 0xdd, 0xbb, 0xf3, 0x28, 0x28, 0xad, 0xcf, 0x07, 0xe4, 0x5d, 0xb7, 0x78, 0x13,
+	0x4f, 0x3d, 0x67, 0x7f, 0x4b, 0xa7, 0x30, 0xf5, 0x0c, 0x93, 0x1d, 0x83, 0x58, 0x5a, 0x6e, 0x56,
+	0x2d, 0xc1, 0xb8, 0x4d, 0x14, 0x8b, 0x84, 0x7a, 0x2a, 0xa0, 0x24, 0xaf, 0xa9, 0xc2, 0x86, 0xca,
+	0xd9, 0xbc, 0xa5, 0xc0, 0x86, 0x4a, 0x99, 0xd2, 0x87, 0x80, 0x82, 0x96, 0x62, 0x61, 0x0a, 0x79,
+	0x87, 0xf7, 0x7b, 0xb4, 0x0d, 0x0a, 0xb5, 0x4a, 0xbb, 0x9a, 0x4d, 0x4c, 0xeb, 0x32, 0xb7, 0xb3,
+	0x2f, 0x31, 0xbf, 0x2d, 0x7c, 0xae, 0x39, 0xa5, 0xd0, 0xbd, 0x4f, 0x29, 0xb5, 0xc9, 0x89, 0x92,
+	0x0a, 0x4b, 0xa9, 0xfa, 0xb9, 0xb7, 0x9b, 0x50, 0x8e, 0xf4, 0x72, 0xae, 0xdf, 0xa9, 0xcd, 0x5c,
+	0x29, 0xd4, 0xcc, 0xd9, 0xd2, 0xfb, 0xb4, 0xb4, 0xbb, 0x75, 0x4d, 0x6d, 0xb8, 0x39, 0x98, 0x1d,
+	0x83, 0xf4, 0x6d, 0x58, 0x4c, 0x59, 0x96, 0x98, 0xe1, 0x85, 0x68, 0x86, 0x1b, 0x74, 0x7d, 0x43,
+	0x21, 0xd8, 0x0e, 0x87, 0x98, 0x83, 0xdd, 0xb7, 0x60, 0xce, 0xad, 0x6b, 0xde, 0xd9, 0x97, 0x89,
+	0xf9, 0x0a, 0x33, 0x10, 0x47, 0x9b, 0x33, 0x9c, 0xed, 0x1d, 0xf2, 0xb6, 0xc3, 0x94, 0xbe, 0x66,
+	0x4d, 0x6b, 0xa2, 0x41, 0xee, 0xf1, 0x9b, 0x36, 0xc6, 0xe8, 0x1e, 0x4c, 0x46, 0x7d, 0x62, 0xb3,
+	0x89, 0x12, 0x09, 0x39, 0xe3, 0x3f, 0x2e, 0x86, 0x82, 0x8f, 0x8b, 0x87, 0x20, 0x7a, 0x1e, 0xd2,
+	0xe6, 0xa2, 0x67, 0x6a, 0xb9, 0xfb, 0xf0, 0x23, 0x58, 0x48, 0x5c, 0xc4, 0x63, 0xba, 0x05, 0xd0,
+	0xf1, 0xa8, 0x7c, 0xd8, 0x12, 0xa0, 0xbc, 0xf9, 0x63, 0xa0, 0xc7, 0xf2, 0x20, 0x48, 0xdb, 0x24,
+	0x0e, 0xf6, 0x39, 0xfb, 0xf8, 0x18, 0xc6, 0xae, 0x72, 0x0f, 0xf8, 0xc2, 0x92, 0x02, 0xb7, 0xd2,
+	0x2c, 0xa6, 0x6f, 0xa4, 0x70, 0xa5, 0xa0, 0x74, 0x98, 0xe3, 0xa5, 0xe1, 0x72, 0xd3, 0x50, 0xbf,
+	0xe9, 0xe7, 0xea, 0x29, 0x54, 0xe2, 0xd6, 0xae, 0xf6, 0x5a, 0x75, 0x67, 0x05, 0xc5, 0xec, 0x59,
+	0xc1, 0x0f, 0xa1, 0xea, 0x25, 0x8b, 0x43, 0xde, 0xba, 0x8c, 0x5f, 0x6c, 0x39, 0x81, 0x66, 0xde,
+	0x98, 0x85, 0xac, 0x1b, 0x53, 0xfa, 0xa7, 0x00, 0xf7, 0x07, 0x30, 0xef, 0x45, 0x3e, 0xd0, 0x90,
+	0x68, 0x40, 0x80, 0x12, 0x52, 0xa2, 0x78, 0xa5, 0xb3, 0xbd, 0x04, 0xe3, 0x67, 0x0a, 0xe9, 0x9c,
+	0xf2, 0x7a, 0xc6, 0xba, 0x69, 0xa0, 0x24, 0x56, 0xd0, 0xfe, 0x2a, 0xc0, 0xcc, 0xa6, 0xaa, 0xd6,
+	0x4d, 0x67, 0x9d, 0x42, 0xfa, 0x56, 0xde, 0x09, 0x78, 0xe3, 0x72, 0xf3, 0x08, 0xc6, 0x3b, 0xbe,
+	0x35, 0x1e, 0xcf, 0x4c, 0xf0, 0xc1, 0xe2, 0xbb, 0x12, 0x94, 0x94, 0x2a, 0x30, 0x1b, 0xf5, 0x94,
+	0x81, 0x2e, 0x3d, 0x86, 0x25, 0x6f, 0x87, 0xea, 0x66, 0xc8, 0x5c, 0x4e, 0x1d, 0xfa, 0xa3, 0x00,
+	0xcb, 0xe9, 0x4b, 0xff, 0x4b, 0x07, 0x13, 0x7d, 0x00, 0xd7, 0x03, 0x81, 0xb8, 0xad, 0x48, 0x4a,
+	0xcc, 0x21, 0xd1, 0xd5, 0xaf, 0x04, 0x98, 0x49, 0x7c, 0xc2, 0xa1, 0x3b, 0xb0, 0x74, 0xb4, 0xff,
+	0x7c, 0xff, 0xe0, 0xd3, 0x7d, 0xb9, 0x7e, 0xb0, 0xdf, 0xda, 0x6b, 0xb5, 0x77, 0xf6, 0xeb, 0xdf,
+	0x91, 0x0f, 0x9b, 0x07, 0x07, 0x4f, 0xe5, 0xfa, 0xee, 0x4e, 0xfd, 0x79, 0xf9, 0x1a, 0x5a, 0x80,
+	0xb9, 0x38, 0xf3, 0x93, 0xcd, 0xc6, 0xde, 0x76, 0x59, 0x40, 0x8b, 0x30, 0x1f, 0x67, 0xee, 0xed,
+	0x33, 0x76, 0xc1, 0x31, 0x10, 0x67, 0x37, 0x0f, 0x0e, 0xda, 0xf2, 0x8b, 0xbd, 0xd6, 0x8b, 0xcd,
+	0x76, 0x7d, 0xb7, 0x5c, 0x5c, 0x7d, 0x0d, 0x93, 0x91, 0xbe, 0xc8, 0x51, 0xeb, 0x3a, 0xd6, 0xd8,
+	0xd9, 0x74, 0x34, 0x6e, 0xef, 0xbc, 0x94, 0x5b, 0xed, 0xcd, 0xf6, 0x51, 0xab, 0x7c, 0x0d, 0x95,
+	0x00, 0x28, 0xf9, 0xe9, 0xc1, 0xd1, 0xbe, 0xe3, 0xc5, 0x02, 0xcc, 0x05, 0xc4, 0x0e, 0x8e, 0xda,
+	0xb2, 0x63, 0x66, 0x73, 0xff, 0xd9, 0x4e, 0xb9, 0x80, 0x10, 0x94, 0x28, 0x73, 0xff, 0xa0, 0xcd,
+	0x17, 0x14, 0xd7, 0x7f, 0x79, 0x03, 0xc6, 0xdb, 0x1c, 0xb9, 0x86, 0xd9, 0x45, 0x06, 0x8c, 0x79,
+	0xa3, 0x44, 0x24, 0x46, 0x46, 0x7b, 0x81, 0x89, 0xa5, 0xb8, 0x90, 0xc8, 0xe3, 0x39, 0x54, 0xfd,
+	0xe9, 0x3f, 0xfe, 0xf5, 0xdb, 0x82, 0x24, 0x2d, 0xd6, 0xce, 0x1f, 0x1c, 0x63, 0xa2, 0x3c, 0xa8,
+	0xe9, 0x66, 0xd7, 0xae, 0x7d, 0xce, 0xb2, 0xe6, 0x8b, 0x1a, 0xeb, 0x9e, 0x36, 0x84, 0x55, 0xf4,
+	0xb5, 0x00, 0x53, 0xb1, 0x49, 0x0d, 0x92, 0x7c, 0xe5, 0x69, 0x43, 0x43, 0xf1, 0x4e, 0xa6, 0x0c,
+	0x77, 0x64, 0x8b, 0x3a, 0xf2, 0x04, 0x6d, 0x64, 0x3a, 0x52, 0xfb, 0xdc, 0x2f, 0xdc, 0x5f, 0x6c,
+	0x44, 0x5e, 0xb1, 0xe8, 0x1c, 0xe6, 0x53, 0x07, 0x6f, 0x68, 0x35, 0xc3, 0x8b, 0xc8, 0x54, 0x50,
+	0x7c, 0x7b, 0x20, 0x59, 0xee, 0xf9, 0x35, 0xf4, 0x17, 0x01, 0xe6, 0x62, 0x72, 0xec, 0x9d, 0x89,
+	0xaa, 0x19, 0xaa, 0x42, 0x8f, 0x60, 0xf1, 0xfe, 0x00, 0x92, 0xdc, 0xe4, 0x23, 0x0a, 0xd6, 0x03,
+	0x54, 0xcb, 0xde, 0x35, 0x1f, 0x9f, 0x63, 0x56, 0xfa, 0xd1, 0xef, 0x04, 0x98, 0x4e, 0x18, 0x4e,
+	0xa1, 0xff, 0x0b, 0xd9, 0x4e, 0x19, 0x39, 0x89, 0x2b, 0x39, 0x52, 0xdc, 0xbb, 0xf7, 0xa8, 0x77,
+	0xab, 0xa8, 0x9a, 0xec, 0xdd, 0x46, 0x6c, 0x84, 0x80, 0xbe, 0x0f, 0x33, 0x89, 0x93, 0x1e, 0x74,
+	0x37, 0x50, 0x2c, 0x32, 0x86, 0x61, 0xe2, 0xbd, 0x5c, 0x39, 0x6f, 0xb3, 0xba, 0x70, 0x23, 0x69,
+	0x3a, 0x82, 0xc2, 0xc1, 0xa5, 0x8d, 0x80, 0xc4, 0xbb, 0x79, 0x62, 0x9e, 0xa1, 0x3f, 0x08, 0x30,
+	0xeb, 0x15, 0xd9, 0x56, 0xb8, 0xf7, 0x0c, 0x29, 0x49, 0xef, 0xab, 0xc5, 0x6a, 0xbe, 0x20, 0xb7,
+	0xf7, 0x36, 0x05, 0x7d, 0x05, 0xdd, 0x49, 0x49, 0x09, 0xfa, 0xdc, 0xd8, 0xd0, 0xa9, 0x06, 0xa4,
+	0xc2, 0xb4, 0xa7, 0xce, 0xef, 0x43, 0x23, 0x59, 0x90, 0xd2, 0xdb, 0x8a, 0x2b, 0x39, 0x52, 0x1e,
+	0x00, 0x67, 0x34, 0xfe, 0x84, 0xde, 0x2f, 0x12, 0x7f, 0x7a, 0x3f, 0x2a, 0x56, 0xf3, 0x05, 0x3d,
+	0x73, 0x47, 0x50, 0x0a, 0x5f, 0x94, 0x28, 0x30, 0x0f, 0x4c, 0xbc, 0xec, 0xc5, 0xe5, 0x74, 0x01,
+	0x4f, 0xad, 0x0d, 0x15, 0x3f, 0xcc, 0xf0, 0x55, 0x89, 0xee, 0x27, 0x41, 0x91, 0x78, 0x13, 0x8b,
+	0xab, 0x83, 0x88, 0x7a, 0x46, 0xff, 0x24, 0xc0, 0x4c, 0xe2, 0x8b, 0x0d, 0x85, 0xf3, 0x2f, 0xf5,
+	0x25, 0x28, 0xde, 0xcb, 0x95, 0xe3, 0xc6, 0xde, 0xa7, 0x89, 0x53, 0x43, 0xef, 0x66, 0xd7, 0x12,
+	0x7f, 0x7c, 0x42, 0x7b, 0x2a, 0xf4, 0x6b, 0x01, 0xca, 0xd1, 0x46, 0x18, 0xdd, 0x0e, 0x19, 0x4d,
+	0x6a, 0xc9, 0x45, 0x29, 0x4b, 0x84, 0xbb, 0xb4, 0x4e, 0x5d, 0x7a, 0x07, 0xad, 0x0e, 0x7e, 0x17,
+	0xa0, 0xdf, 0xb0, 0x5f, 0x5d, 0xb2, 0xfb, 0x55, 0xb4, 0x9e, 0xb0, 0x0b, 0x39, 0xbd, 0xb5, 0xf8,
+	0xf0, 0x4a, 0x6b, 0xbc, 0x2d, 0x6c, 0xc0, 0x78, 0xe0, 0xb7, 0x39, 0x74, 0x33, 0x7e, 0x0b, 0xfb,
+	0x93, 0x38, 0x71, 0x31, 0x85, 0xeb, 0x69, 0xfb, 0x2e, 0x45, 0x3b, 0x34, 0xec, 0x89, 0xa0, 0x9d,
+	0x34, 0x7f, 0x12, 0xa5, 0x2c, 0x11, 0x4f, 0xf9, 0x4b, 0x98, 0x8c, 0x8c, 0x47, 0xd1, 0x72, 0xe2,
+	0xc2, 0x60, 0x21, 0xbc, 0x9d, 0x21, 0xe1, 0x69, 0x7e, 0x0e, 0xe0, 0x8f, 0x6d, 0xd0, 0x42, 0x6c,
+	0xef, 0xfd, 0xb1, 0x91, 0x78, 0x33, 0x99, 0xe9, 0xaa, 0x7a, 0x4f, 0x40, 0x16, 0xcc, 0x45, 0x8b,
+	0x00, 0x1f, 0xb1, 0xa0, 0x8c, 0x3a, 0x11, 0x9e, 0xf2, 0x88, 0xf7, 0x07, 0x90, 0x0c, 0xd8, 0x7c,
+	0x0a, 0x63, 0xde, 0xa0, 0x35, 0xd8, 0x65, 0x45, 0xe7, 0xc0, 0xe2, 0x42, 0x22, 0x2f, 0x98, 0x0d,
+	0x81, 0xc9, 0x62, 0x30, 0x1b, 0xe2, 0x73, 0x59, 0x71, 0x31, 0x85, 0xeb, 0x6a, 0xdb, 0x5a, 0x87,
+	0xf9, 0x8e, 0x79, 0xe6, 0xbe, 0xc0, 0xc3, 0xff, 0x1c, 0xb2, 0x35, 0x1d, 0xe8, 0x12, 0x37, 0x7b,
+	0xda, 0xa1, 0x43, 0x3c, 0x14, 0x8e, 0x47, 0x28, 0xf7, 0xe1, 0xbf, 0x07, 0x00, 0xae, 0x6b, 0xcb,
+	0x34, 0x6e, 0x22, 0x00, 0x00,
 }
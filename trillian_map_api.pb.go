@@ -7,6 +7,7 @@ import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
 import _ "google.golang.org/genproto/googleapis/api/annotations"
+import google_rpc "google.golang.org/genproto/googleapis/rpc/status"
 
 import (
 	context "golang.org/x/net/context"
@@ -24,8 +25,11 @@ type MapLeaf struct {
 	// All indexes for a given Map must contain a constant number of bits.
 	Index []byte `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
 	// leaf_hash is the tree hash of leaf_value.
+	// For maps with CLIENT_HASHED_LEAVES, leaf_hash is provided by the client and
+	// stored verbatim. Absent leaves are returned with the tree's empty leaf hash.
 	LeafHash []byte `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
 	// leaf_value is the data the tree commits to.
+	// Optional for maps with CLIENT_HASHED_LEAVES.
 	LeafValue []byte `protobuf:"bytes,3,opt,name=leaf_value,json=leafValue,proto3" json:"leaf_value,omitempty"`
 	// extra_data holds related contextual data, but is not covered by any hash.
 	ExtraData []byte `protobuf:"bytes,4,opt,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`
@@ -144,6 +148,154 @@ func (m *GetMapLeavesResponse) GetMapRoot() *SignedMapRoot {
 	return nil
 }
 
+// MapLeafAtRevision identifies a map leaf as of a given map revision.
+type MapLeafAtRevision struct {
+	Index    []byte `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	Revision int64  `protobuf:"varint,2,opt,name=revision" json:"revision,omitempty"`
+}
+
+func (m *MapLeafAtRevision) Reset()                    { *m = MapLeafAtRevision{} }
+func (m *MapLeafAtRevision) String() string            { return proto.CompactTextString(m) }
+func (*MapLeafAtRevision) ProtoMessage()               {}
+func (*MapLeafAtRevision) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+
+func (m *MapLeafAtRevision) GetIndex() []byte {
+	if m != nil {
+		return m.Index
+	}
+	return nil
+}
+
+func (m *MapLeafAtRevision) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+type GetMapLeavesByRevisionsRequest struct {
+	MapId  int64                `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
+	Leaves []*MapLeafAtRevision `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+	// index requests each of its indices at every revision in
+	// [start_revision, end_revision], after the leaves above. This lets a
+	// client audit the history of a key in one call. An end_revision that is
+	// negative or past the latest revision means the latest revision.
+	Index         [][]byte `protobuf:"bytes,3,rep,name=index,proto3" json:"index,omitempty"`
+	StartRevision int64    `protobuf:"varint,4,opt,name=start_revision,json=startRevision" json:"start_revision,omitempty"`
+	EndRevision   int64    `protobuf:"varint,5,opt,name=end_revision,json=endRevision" json:"end_revision,omitempty"`
+}
+
+func (m *GetMapLeavesByRevisionsRequest) Reset()                    { *m = GetMapLeavesByRevisionsRequest{} }
+func (m *GetMapLeavesByRevisionsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetMapLeavesByRevisionsRequest) ProtoMessage()               {}
+func (*GetMapLeavesByRevisionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+
+func (m *GetMapLeavesByRevisionsRequest) GetMapId() int64 {
+	if m != nil {
+		return m.MapId
+	}
+	return 0
+}
+
+func (m *GetMapLeavesByRevisionsRequest) GetLeaves() []*MapLeafAtRevision {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+func (m *GetMapLeavesByRevisionsRequest) GetIndex() [][]byte {
+	if m != nil {
+		return m.Index
+	}
+	return nil
+}
+
+func (m *GetMapLeavesByRevisionsRequest) GetStartRevision() int64 {
+	if m != nil {
+		return m.StartRevision
+	}
+	return 0
+}
+
+func (m *GetMapLeavesByRevisionsRequest) GetEndRevision() int64 {
+	if m != nil {
+		return m.EndRevision
+	}
+	return 0
+}
+
+// MapLeafRevisionInclusion is the result for a single MapLeafAtRevision.
+type MapLeafRevisionInclusion struct {
+	// status is OK if the leaf could be served, or explains why not. Revisions
+	// outside the range of published map roots are reported as OUT_OF_RANGE.
+	Status *google_rpc.Status `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+	// index and revision echo the request.
+	Index    []byte `protobuf:"bytes,2,opt,name=index,proto3" json:"index,omitempty"`
+	Revision int64  `protobuf:"varint,3,opt,name=revision" json:"revision,omitempty"`
+	// map_leaf_inclusion proves the leaf against map_root. Only set if status is OK.
+	MapLeafInclusion *MapLeafInclusion `protobuf:"bytes,4,opt,name=map_leaf_inclusion,json=mapLeafInclusion" json:"map_leaf_inclusion,omitempty"`
+	// map_root is the signed map root at revision. Only set if status is OK.
+	MapRoot *SignedMapRoot `protobuf:"bytes,5,opt,name=map_root,json=mapRoot" json:"map_root,omitempty"`
+}
+
+func (m *MapLeafRevisionInclusion) Reset()                    { *m = MapLeafRevisionInclusion{} }
+func (m *MapLeafRevisionInclusion) String() string            { return proto.CompactTextString(m) }
+func (*MapLeafRevisionInclusion) ProtoMessage()               {}
+func (*MapLeafRevisionInclusion) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *MapLeafRevisionInclusion) GetStatus() *google_rpc.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func (m *MapLeafRevisionInclusion) GetIndex() []byte {
+	if m != nil {
+		return m.Index
+	}
+	return nil
+}
+
+func (m *MapLeafRevisionInclusion) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *MapLeafRevisionInclusion) GetMapLeafInclusion() *MapLeafInclusion {
+	if m != nil {
+		return m.MapLeafInclusion
+	}
+	return nil
+}
+
+func (m *MapLeafRevisionInclusion) GetMapRoot() *SignedMapRoot {
+	if m != nil {
+		return m.MapRoot
+	}
+	return nil
+}
+
+type GetMapLeavesByRevisionsResponse struct {
+	// results holds one entry per requested leaf, in request order.
+	Results []*MapLeafRevisionInclusion `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *GetMapLeavesByRevisionsResponse) Reset()                    { *m = GetMapLeavesByRevisionsResponse{} }
+func (m *GetMapLeavesByRevisionsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetMapLeavesByRevisionsResponse) ProtoMessage()               {}
+func (*GetMapLeavesByRevisionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
+
+func (m *GetMapLeavesByRevisionsResponse) GetResults() []*MapLeafRevisionInclusion {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
 type SetMapLeavesRequest struct {
 	MapId      int64           `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
 	Leaves     []*MapLeaf      `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
@@ -153,7 +305,7 @@ type SetMapLeavesRequest struct {
 func (m *SetMapLeavesRequest) Reset()                    { *m = SetMapLeavesRequest{} }
 func (m *SetMapLeavesRequest) String() string            { return proto.CompactTextString(m) }
 func (*SetMapLeavesRequest) ProtoMessage()               {}
-func (*SetMapLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+func (*SetMapLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
 
 func (m *SetMapLeavesRequest) GetMapId() int64 {
 	if m != nil {
@@ -183,7 +335,7 @@ type SetMapLeavesResponse struct {
 func (m *SetMapLeavesResponse) Reset()                    { *m = SetMapLeavesResponse{} }
 func (m *SetMapLeavesResponse) String() string            { return proto.CompactTextString(m) }
 func (*SetMapLeavesResponse) ProtoMessage()               {}
-func (*SetMapLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+func (*SetMapLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
 
 func (m *SetMapLeavesResponse) GetMapRoot() *SignedMapRoot {
 	if m != nil {
@@ -192,6 +344,38 @@ func (m *SetMapLeavesResponse) GetMapRoot() *SignedMapRoot {
 	return nil
 }
 
+type QueueMapLeavesRequest struct {
+	MapId  int64      `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
+	Leaves []*MapLeaf `protobuf:"bytes,2,rep,name=leaves" json:"leaves,omitempty"`
+}
+
+func (m *QueueMapLeavesRequest) Reset()                    { *m = QueueMapLeavesRequest{} }
+func (m *QueueMapLeavesRequest) String() string            { return proto.CompactTextString(m) }
+func (*QueueMapLeavesRequest) ProtoMessage()               {}
+func (*QueueMapLeavesRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
+
+func (m *QueueMapLeavesRequest) GetMapId() int64 {
+	if m != nil {
+		return m.MapId
+	}
+	return 0
+}
+
+func (m *QueueMapLeavesRequest) GetLeaves() []*MapLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+type QueueMapLeavesResponse struct {
+}
+
+func (m *QueueMapLeavesResponse) Reset()                    { *m = QueueMapLeavesResponse{} }
+func (m *QueueMapLeavesResponse) String() string            { return proto.CompactTextString(m) }
+func (*QueueMapLeavesResponse) ProtoMessage()               {}
+func (*QueueMapLeavesResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{11} }
+
 type GetSignedMapRootRequest struct {
 	MapId int64 `protobuf:"varint,1,opt,name=map_id,json=mapId" json:"map_id,omitempty"`
 }
@@ -199,7 +383,7 @@ type GetSignedMapRootRequest struct {
 func (m *GetSignedMapRootRequest) Reset()                    { *m = GetSignedMapRootRequest{} }
 func (m *GetSignedMapRootRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetSignedMapRootRequest) ProtoMessage()               {}
-func (*GetSignedMapRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+func (*GetSignedMapRootRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{12} }
 
 func (m *GetSignedMapRootRequest) GetMapId() int64 {
 	if m != nil {
@@ -217,7 +401,7 @@ func (m *GetSignedMapRootByRevisionRequest) Reset()         { *m = GetSignedMapR
 func (m *GetSignedMapRootByRevisionRequest) String() string { return proto.CompactTextString(m) }
 func (*GetSignedMapRootByRevisionRequest) ProtoMessage()    {}
 func (*GetSignedMapRootByRevisionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor1, []int{7}
+	return fileDescriptor1, []int{13}
 }
 
 func (m *GetSignedMapRootByRevisionRequest) GetMapId() int64 {
@@ -241,7 +425,7 @@ type GetSignedMapRootResponse struct {
 func (m *GetSignedMapRootResponse) Reset()                    { *m = GetSignedMapRootResponse{} }
 func (m *GetSignedMapRootResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetSignedMapRootResponse) ProtoMessage()               {}
-func (*GetSignedMapRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
+func (*GetSignedMapRootResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
 
 func (m *GetSignedMapRootResponse) GetMapRoot() *SignedMapRoot {
 	if m != nil {
@@ -255,8 +439,14 @@ func init() {
 	proto.RegisterType((*MapLeafInclusion)(nil), "trillian.MapLeafInclusion")
 	proto.RegisterType((*GetMapLeavesRequest)(nil), "trillian.GetMapLeavesRequest")
 	proto.RegisterType((*GetMapLeavesResponse)(nil), "trillian.GetMapLeavesResponse")
+	proto.RegisterType((*MapLeafAtRevision)(nil), "trillian.MapLeafAtRevision")
+	proto.RegisterType((*GetMapLeavesByRevisionsRequest)(nil), "trillian.GetMapLeavesByRevisionsRequest")
+	proto.RegisterType((*MapLeafRevisionInclusion)(nil), "trillian.MapLeafRevisionInclusion")
+	proto.RegisterType((*GetMapLeavesByRevisionsResponse)(nil), "trillian.GetMapLeavesByRevisionsResponse")
 	proto.RegisterType((*SetMapLeavesRequest)(nil), "trillian.SetMapLeavesRequest")
 	proto.RegisterType((*SetMapLeavesResponse)(nil), "trillian.SetMapLeavesResponse")
+	proto.RegisterType((*QueueMapLeavesRequest)(nil), "trillian.QueueMapLeavesRequest")
+	proto.RegisterType((*QueueMapLeavesResponse)(nil), "trillian.QueueMapLeavesResponse")
 	proto.RegisterType((*GetSignedMapRootRequest)(nil), "trillian.GetSignedMapRootRequest")
 	proto.RegisterType((*GetSignedMapRootByRevisionRequest)(nil), "trillian.GetSignedMapRootByRevisionRequest")
 	proto.RegisterType((*GetSignedMapRootResponse)(nil), "trillian.GetSignedMapRootResponse")
@@ -276,7 +466,25 @@ type TrillianMapClient interface {
 	// GetLeaves returns an inclusion proof for each index requested.
 	// For indexes that do not exist, the inclusion proof will use nil for the empty leaf value.
 	GetLeaves(ctx context.Context, in *GetMapLeavesRequest, opts ...grpc.CallOption) (*GetMapLeavesResponse, error)
+	// GetLeavesByRevisions returns an inclusion proof for each (index, revision)
+	// pair requested, against the signed map root at that revision. Pairs may be
+	// listed individually or as indices over a range of revisions. Pairs whose
+	// revision is out of range fail individually rather than failing the call.
+	GetLeavesByRevisions(ctx context.Context, in *GetMapLeavesByRevisionsRequest, opts ...grpc.CallOption) (*GetMapLeavesByRevisionsResponse, error)
 	SetLeaves(ctx context.Context, in *SetMapLeavesRequest, opts ...grpc.CallOption) (*SetMapLeavesResponse, error)
+	// SetLeavesStream is like SetLeaves, but takes the leaves over a stream of
+	// requests, so that more leaves can be set in one new map revision than fit
+	// in memory. The server applies the leaves in bounded batches as they
+	// arrive, and writes a single signed map root when the client closes the
+	// stream. map_id and mapper_data are taken from the first request; later
+	// requests must have the same map_id, or leave it unset.
+	SetLeavesStream(ctx context.Context, opts ...grpc.CallOption) (TrillianMap_SetLeavesStreamClient, error)
+	// QueueLeaves queues leaves to be set in a later revision of the map, rather
+	// than writing a new revision itself. A map sequencer periodically sets the
+	// queued leaves of each map in batches, in queue order, so a leaf queued
+	// after another with the same index takes precedence. Leaves are validated
+	// when they're queued, so once queued they're only delayed, never rejected.
+	QueueLeaves(ctx context.Context, in *QueueMapLeavesRequest, opts ...grpc.CallOption) (*QueueMapLeavesResponse, error)
 	GetSignedMapRoot(ctx context.Context, in *GetSignedMapRootRequest, opts ...grpc.CallOption) (*GetSignedMapRootResponse, error)
 	GetSignedMapRootByRevision(ctx context.Context, in *GetSignedMapRootByRevisionRequest, opts ...grpc.CallOption) (*GetSignedMapRootResponse, error)
 }
@@ -298,6 +506,15 @@ func (c *trillianMapClient) GetLeaves(ctx context.Context, in *GetMapLeavesReque
 	return out, nil
 }
 
+func (c *trillianMapClient) GetLeavesByRevisions(ctx context.Context, in *GetMapLeavesByRevisionsRequest, opts ...grpc.CallOption) (*GetMapLeavesByRevisionsResponse, error) {
+	out := new(GetMapLeavesByRevisionsResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianMap/GetLeavesByRevisions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianMapClient) SetLeaves(ctx context.Context, in *SetMapLeavesRequest, opts ...grpc.CallOption) (*SetMapLeavesResponse, error) {
 	out := new(SetMapLeavesResponse)
 	err := grpc.Invoke(ctx, "/trillian.TrillianMap/SetLeaves", in, out, c.cc, opts...)
@@ -307,6 +524,49 @@ func (c *trillianMapClient) SetLeaves(ctx context.Context, in *SetMapLeavesReque
 	return out, nil
 }
 
+func (c *trillianMapClient) SetLeavesStream(ctx context.Context, opts ...grpc.CallOption) (TrillianMap_SetLeavesStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_TrillianMap_serviceDesc.Streams[0], c.cc, "/trillian.TrillianMap/SetLeavesStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trillianMapSetLeavesStreamClient{stream}
+	return x, nil
+}
+
+type TrillianMap_SetLeavesStreamClient interface {
+	Send(*SetMapLeavesRequest) error
+	CloseAndRecv() (*SetMapLeavesResponse, error)
+	grpc.ClientStream
+}
+
+type trillianMapSetLeavesStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *trillianMapSetLeavesStreamClient) Send(m *SetMapLeavesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *trillianMapSetLeavesStreamClient) CloseAndRecv() (*SetMapLeavesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SetMapLeavesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *trillianMapClient) QueueLeaves(ctx context.Context, in *QueueMapLeavesRequest, opts ...grpc.CallOption) (*QueueMapLeavesResponse, error) {
+	out := new(QueueMapLeavesResponse)
+	err := grpc.Invoke(ctx, "/trillian.TrillianMap/QueueLeaves", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianMapClient) GetSignedMapRoot(ctx context.Context, in *GetSignedMapRootRequest, opts ...grpc.CallOption) (*GetSignedMapRootResponse, error) {
 	out := new(GetSignedMapRootResponse)
 	err := grpc.Invoke(ctx, "/trillian.TrillianMap/GetSignedMapRoot", in, out, c.cc, opts...)
@@ -331,7 +591,25 @@ type TrillianMapServer interface {
 	// GetLeaves returns an inclusion proof for each index requested.
 	// For indexes that do not exist, the inclusion proof will use nil for the empty leaf value.
 	GetLeaves(context.Context, *GetMapLeavesRequest) (*GetMapLeavesResponse, error)
+	// GetLeavesByRevisions returns an inclusion proof for each (index, revision)
+	// pair requested, against the signed map root at that revision. Pairs may be
+	// listed individually or as indices over a range of revisions. Pairs whose
+	// revision is out of range fail individually rather than failing the call.
+	GetLeavesByRevisions(context.Context, *GetMapLeavesByRevisionsRequest) (*GetMapLeavesByRevisionsResponse, error)
 	SetLeaves(context.Context, *SetMapLeavesRequest) (*SetMapLeavesResponse, error)
+	// SetLeavesStream is like SetLeaves, but takes the leaves over a stream of
+	// requests, so that more leaves can be set in one new map revision than fit
+	// in memory. The server applies the leaves in bounded batches as they
+	// arrive, and writes a single signed map root when the client closes the
+	// stream. map_id and mapper_data are taken from the first request; later
+	// requests must have the same map_id, or leave it unset.
+	SetLeavesStream(TrillianMap_SetLeavesStreamServer) error
+	// QueueLeaves queues leaves to be set in a later revision of the map, rather
+	// than writing a new revision itself. A map sequencer periodically sets the
+	// queued leaves of each map in batches, in queue order, so a leaf queued
+	// after another with the same index takes precedence. Leaves are validated
+	// when they're queued, so once queued they're only delayed, never rejected.
+	QueueLeaves(context.Context, *QueueMapLeavesRequest) (*QueueMapLeavesResponse, error)
 	GetSignedMapRoot(context.Context, *GetSignedMapRootRequest) (*GetSignedMapRootResponse, error)
 	GetSignedMapRootByRevision(context.Context, *GetSignedMapRootByRevisionRequest) (*GetSignedMapRootResponse, error)
 }
@@ -358,6 +636,24 @@ func _TrillianMap_GetLeaves_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianMap_GetLeavesByRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMapLeavesByRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianMapServer).GetLeavesByRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianMap/GetLeavesByRevisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianMapServer).GetLeavesByRevisions(ctx, req.(*GetMapLeavesByRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianMap_SetLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SetMapLeavesRequest)
 	if err := dec(in); err != nil {
@@ -376,6 +672,50 @@ func _TrillianMap_SetLeaves_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianMap_SetLeavesStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TrillianMapServer).SetLeavesStream(&trillianMapSetLeavesStreamServer{stream})
+}
+
+type TrillianMap_SetLeavesStreamServer interface {
+	SendAndClose(*SetMapLeavesResponse) error
+	Recv() (*SetMapLeavesRequest, error)
+	grpc.ServerStream
+}
+
+type trillianMapSetLeavesStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *trillianMapSetLeavesStreamServer) SendAndClose(m *SetMapLeavesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *trillianMapSetLeavesStreamServer) Recv() (*SetMapLeavesRequest, error) {
+	m := new(SetMapLeavesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _TrillianMap_QueueLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueMapLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianMapServer).QueueLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianMap/QueueLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianMapServer).QueueLeaves(ctx, req.(*QueueMapLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianMap_GetSignedMapRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetSignedMapRootRequest)
 	if err := dec(in); err != nil {
@@ -420,10 +760,18 @@ var _TrillianMap_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetLeaves",
 			Handler:    _TrillianMap_GetLeaves_Handler,
 		},
+		{
+			MethodName: "GetLeavesByRevisions",
+			Handler:    _TrillianMap_GetLeavesByRevisions_Handler,
+		},
 		{
 			MethodName: "SetLeaves",
 			Handler:    _TrillianMap_SetLeaves_Handler,
 		},
+		{
+			MethodName: "QueueLeaves",
+			Handler:    _TrillianMap_QueueLeaves_Handler,
+		},
 		{
 			MethodName: "GetSignedMapRoot",
 			Handler:    _TrillianMap_GetSignedMapRoot_Handler,
@@ -433,49 +781,70 @@ var _TrillianMap_serviceDesc = grpc.ServiceDesc{
 			Handler:    _TrillianMap_GetSignedMapRootByRevision_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SetLeavesStream",
+			Handler:       _TrillianMap_SetLeavesStream_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "trillian_map_api.proto",
 }
 
 func init() { proto.RegisterFile("trillian_map_api.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 591 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xdd, 0x4e, 0xd4, 0x40,
-	0x14, 0xb6, 0xbb, 0xfc, 0x2c, 0x67, 0x8d, 0xc1, 0x01, 0xa5, 0x56, 0x30, 0x50, 0x43, 0x94, 0x90,
-	0x6c, 0xa5, 0x5e, 0xe9, 0x9d, 0xc4, 0x04, 0x30, 0xac, 0x21, 0xad, 0xc1, 0x3b, 0x37, 0x07, 0x76,
-	0x80, 0x49, 0xda, 0xce, 0xd8, 0x0e, 0x1b, 0x94, 0x70, 0xe3, 0x85, 0x0f, 0xa0, 0x5e, 0xfb, 0x52,
-	0xbc, 0x82, 0x0f, 0x62, 0x66, 0xa6, 0xfb, 0xd3, 0xdd, 0xee, 0xba, 0xf1, 0xae, 0x3d, 0xdf, 0x39,
-	0xdf, 0xf9, 0xbe, 0x73, 0x4e, 0x06, 0x1e, 0xca, 0x94, 0x45, 0x11, 0xc3, 0xa4, 0x15, 0xa3, 0x68,
-	0xa1, 0x60, 0x0d, 0x91, 0x72, 0xc9, 0x49, 0xad, 0x1b, 0x77, 0xee, 0x75, 0xbf, 0x0c, 0xe2, 0xac,
-	0x9e, 0x73, 0x7e, 0x1e, 0x51, 0x0f, 0x05, 0xf3, 0x30, 0x49, 0xb8, 0x44, 0xc9, 0x78, 0x92, 0x19,
-	0xd4, 0xfd, 0x0a, 0xf3, 0x4d, 0x14, 0x87, 0x14, 0xcf, 0xc8, 0x32, 0xcc, 0xb2, 0xa4, 0x4d, 0xaf,
-	0x6c, 0x6b, 0xdd, 0x7a, 0x7e, 0x37, 0x30, 0x3f, 0xe4, 0x31, 0x2c, 0x44, 0x14, 0xcf, 0x5a, 0x17,
-	0x98, 0x5d, 0xd8, 0x15, 0x8d, 0xd4, 0x54, 0x60, 0x1f, 0xb3, 0x0b, 0xb2, 0x06, 0xa0, 0xc1, 0x0e,
-	0x46, 0x97, 0xd4, 0xae, 0x6a, 0x54, 0xa7, 0x1f, 0xab, 0x80, 0x82, 0xe9, 0x95, 0x4c, 0xb1, 0xd5,
-	0x46, 0x89, 0xf6, 0x8c, 0x81, 0x75, 0xe4, 0x2d, 0x4a, 0x74, 0x3f, 0xc2, 0x62, 0xde, 0xfb, 0x20,
-	0x39, 0x8d, 0x2e, 0x33, 0xc6, 0x13, 0xb2, 0x09, 0x33, 0xaa, 0x5e, 0x6b, 0xa8, 0xfb, 0xf7, 0x1b,
-	0x3d, 0x33, 0x79, 0x66, 0xa0, 0x61, 0xb2, 0x0a, 0x0b, 0xac, 0x5b, 0x63, 0x57, 0xd6, 0xab, 0x8a,
-	0xb8, 0x17, 0x70, 0x3f, 0xc1, 0xd2, 0x1e, 0x95, 0xa6, 0xa2, 0x43, 0xb3, 0x80, 0x7e, 0xbe, 0xa4,
-	0x99, 0x24, 0x0f, 0x60, 0x4e, 0x0d, 0x8d, 0xb5, 0x35, 0x7b, 0x35, 0x98, 0x8d, 0x51, 0x1c, 0xb4,
-	0xfb, 0xbe, 0x0d, 0x4f, 0xee, 0xdb, 0x81, 0x5a, 0x4a, 0x3b, 0x4c, 0x37, 0xa8, 0xea, 0xf4, 0xde,
-	0xbf, 0xfb, 0xcb, 0x82, 0xe5, 0x62, 0x83, 0x4c, 0xf0, 0x24, 0xa3, 0x64, 0x1f, 0x88, 0xea, 0xa0,
-	0x67, 0x52, 0xd4, 0x57, 0xf7, 0x9d, 0x11, 0x2f, 0x3d, 0xd7, 0xc1, 0x62, 0x3c, 0x3c, 0x07, 0x1f,
-	0x6a, 0x8a, 0x29, 0xe5, 0x5c, 0xea, 0xf6, 0x75, 0x7f, 0xa5, 0x5f, 0x1f, 0xb2, 0xf3, 0x84, 0xb6,
-	0x9b, 0x28, 0x02, 0xce, 0x65, 0x30, 0x1f, 0x9b, 0x0f, 0xf7, 0x87, 0x05, 0x4b, 0xe1, 0xf4, 0xbe,
-	0xb7, 0x60, 0x2e, 0xd2, 0x79, 0xb9, 0xc0, 0x92, 0x61, 0xe7, 0x09, 0xe4, 0x15, 0xd4, 0x63, 0x14,
-	0x82, 0xa6, 0x66, 0x93, 0x46, 0x90, 0x5d, 0xc8, 0x17, 0x34, 0x6d, 0x52, 0x89, 0x0a, 0x0f, 0xc0,
-	0x24, 0xeb, 0x25, 0xbf, 0x83, 0xe5, 0xb0, 0x6c, 0x54, 0x83, 0x06, 0x2b, 0x53, 0x1a, 0x7c, 0x01,
-	0x2b, 0x7b, 0x54, 0x16, 0xc1, 0x89, 0x1e, 0xdd, 0x63, 0xd8, 0x18, 0xae, 0xd8, 0xfd, 0x12, 0xe4,
-	0x7b, 0xfc, 0xc7, 0x7c, 0x06, 0x2f, 0xa0, 0x32, 0x74, 0x01, 0xef, 0xc1, 0x1e, 0x55, 0xf2, 0xff,
-	0xce, 0xfc, 0xdb, 0x2a, 0xd4, 0x3f, 0xe4, 0x39, 0x4d, 0x14, 0xe4, 0x10, 0x16, 0xf6, 0xa8, 0x34,
-	0x23, 0x23, 0x6b, 0xfd, 0xf2, 0x92, 0xb3, 0x76, 0x9e, 0x8c, 0x83, 0x8d, 0x1e, 0xf7, 0x8e, 0x62,
-	0x0b, 0xcb, 0xd8, 0xc2, 0xc9, 0x6c, 0x61, 0x39, 0xdb, 0x77, 0x0b, 0x16, 0x87, 0xcd, 0x93, 0x8d,
-	0x82, 0x88, 0xb2, 0x15, 0x39, 0xee, 0xa4, 0x94, 0x9c, 0x7d, 0xfb, 0xdb, 0xed, 0x9f, 0x9f, 0x95,
-	0x4d, 0xf2, 0xd4, 0xeb, 0xec, 0x9c, 0x50, 0x89, 0x3b, 0x5e, 0x8c, 0x22, 0xf3, 0xae, 0xcd, 0x7e,
-	0x6e, 0x3c, 0x35, 0xd4, 0xec, 0x75, 0x84, 0x52, 0xed, 0xed, 0xb7, 0x05, 0xce, 0xf8, 0xed, 0x92,
-	0xed, 0xf1, 0xfd, 0x46, 0x6e, 0x60, 0x2a, 0x71, 0x9e, 0x16, 0xb7, 0x45, 0x9e, 0x4d, 0x12, 0xe7,
-	0x5d, 0x77, 0x8f, 0xe4, 0x66, 0xd7, 0x87, 0x47, 0xa7, 0x3c, 0x6e, 0x98, 0x07, 0xb8, 0x51, 0x7c,
-	0x97, 0x77, 0x97, 0x06, 0xf6, 0xfd, 0x46, 0xb0, 0x23, 0x15, 0x3c, 0xb2, 0x4e, 0xe6, 0x34, 0xfa,
-	0xf2, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x2a, 0x75, 0x1f, 0xfa, 0xe9, 0x05, 0x00, 0x00,
+	// 822 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4b, 0x4f, 0xdb, 0x4a,
+	0x14, 0xbe, 0xce, 0x8b, 0xe4, 0x84, 0xcb, 0x85, 0xe1, 0xe5, 0x6b, 0x5e, 0xc1, 0x57, 0xe8, 0xc2,
+	0x45, 0x8a, 0x2f, 0x61, 0xd5, 0xaa, 0x1b, 0x50, 0x2b, 0xa0, 0x22, 0x15, 0xb5, 0x11, 0x55, 0x37,
+	0x8d, 0x86, 0x64, 0x00, 0x4b, 0x7e, 0x4c, 0xed, 0x49, 0x44, 0x8b, 0xd8, 0x74, 0xd1, 0x1f, 0xd0,
+	0x76, 0xdd, 0x5f, 0xd4, 0x5d, 0xff, 0x42, 0x97, 0x5d, 0x77, 0x5d, 0x79, 0x3c, 0xb6, 0xf3, 0x70,
+	0x42, 0x54, 0xba, 0x8b, 0xe7, 0x7c, 0xe7, 0x9c, 0xef, 0x3b, 0xf3, 0x9d, 0x01, 0x58, 0x60, 0x9e,
+	0x69, 0x59, 0x26, 0x76, 0x1a, 0x36, 0xa6, 0x0d, 0x4c, 0xcd, 0x2a, 0xf5, 0x5c, 0xe6, 0xa2, 0x62,
+	0x74, 0xae, 0x4c, 0x45, 0xbf, 0xc2, 0x88, 0xb2, 0x7c, 0xe9, 0xba, 0x97, 0x16, 0xd1, 0x30, 0x35,
+	0x35, 0xec, 0x38, 0x2e, 0xc3, 0xcc, 0x74, 0x1d, 0x5f, 0x44, 0x17, 0x45, 0xd4, 0xa3, 0x4d, 0xcd,
+	0x67, 0x98, 0xb5, 0x45, 0x40, 0x7d, 0x0b, 0x13, 0x75, 0x4c, 0x8f, 0x09, 0xbe, 0x40, 0x73, 0x90,
+	0x37, 0x9d, 0x16, 0xb9, 0x96, 0xa5, 0x8a, 0xb4, 0x39, 0xa9, 0x87, 0x1f, 0x68, 0x09, 0x4a, 0x16,
+	0xc1, 0x17, 0x8d, 0x2b, 0xec, 0x5f, 0xc9, 0x19, 0x1e, 0x29, 0x06, 0x07, 0x87, 0xd8, 0xbf, 0x42,
+	0x2b, 0x00, 0x3c, 0xd8, 0xc1, 0x56, 0x9b, 0xc8, 0x59, 0x1e, 0xe5, 0xf0, 0xb3, 0xe0, 0x20, 0x08,
+	0x93, 0x6b, 0xe6, 0xe1, 0x46, 0x0b, 0x33, 0x2c, 0xe7, 0xc2, 0x30, 0x3f, 0x79, 0x8c, 0x19, 0x56,
+	0x5f, 0xc0, 0xb4, 0xe8, 0x7d, 0xe4, 0x34, 0xad, 0xb6, 0x6f, 0xba, 0x0e, 0xda, 0x80, 0x5c, 0x90,
+	0xcf, 0x39, 0x94, 0x6b, 0x33, 0xd5, 0x58, 0xa5, 0x40, 0xea, 0x3c, 0x8c, 0x96, 0xa1, 0x64, 0x46,
+	0x39, 0x72, 0xa6, 0x92, 0x0d, 0x0a, 0xc7, 0x07, 0xea, 0x2b, 0x98, 0x3d, 0x20, 0x2c, 0xcc, 0xe8,
+	0x10, 0x5f, 0x27, 0xaf, 0xdb, 0xc4, 0x67, 0x68, 0x1e, 0x0a, 0xc1, 0x34, 0xcd, 0x16, 0xaf, 0x9e,
+	0xd5, 0xf3, 0x36, 0xa6, 0x47, 0xad, 0x44, 0x77, 0x58, 0x47, 0xe8, 0x56, 0xa0, 0xe8, 0x91, 0x8e,
+	0xc9, 0x1b, 0x64, 0x39, 0x3c, 0xfe, 0x56, 0x3f, 0x49, 0x30, 0xd7, 0xdb, 0xc0, 0xa7, 0xae, 0xe3,
+	0x13, 0x74, 0x08, 0x28, 0xe8, 0xc0, 0x67, 0xd2, 0xcb, 0xaf, 0x5c, 0x53, 0x06, 0xb4, 0xc4, 0xaa,
+	0xf5, 0x69, 0xbb, 0x7f, 0x0e, 0x35, 0x28, 0x06, 0x95, 0x3c, 0xd7, 0x65, 0xbc, 0x7d, 0xb9, 0xb6,
+	0x98, 0xe4, 0x1b, 0xe6, 0xa5, 0x43, 0x5a, 0x75, 0x4c, 0x75, 0xd7, 0x65, 0xfa, 0x84, 0x1d, 0xfe,
+	0x50, 0x9f, 0xc0, 0x8c, 0xa8, 0xbc, 0xc7, 0x74, 0xc1, 0x75, 0xc8, 0xad, 0x76, 0xab, 0xcb, 0xf4,
+	0xa9, 0xfb, 0x22, 0xc1, 0x6a, 0xb7, 0xba, 0xfd, 0x37, 0x51, 0xb1, 0xbb, 0x26, 0xb9, 0x0b, 0x05,
+	0x8b, 0xa7, 0x08, 0xc9, 0x4b, 0x03, 0x92, 0x13, 0x62, 0xba, 0x80, 0x26, 0x04, 0xb3, 0xdd, 0xe3,
+	0xdf, 0x80, 0x29, 0x9f, 0x61, 0x8f, 0x35, 0x62, 0x9a, 0x39, 0xde, 0xe9, 0x4f, 0x7e, 0x1a, 0xab,
+	0x5b, 0x87, 0x49, 0xe2, 0xb4, 0x12, 0x50, 0x9e, 0x83, 0xca, 0xc4, 0x69, 0x45, 0x10, 0xf5, 0x87,
+	0x04, 0x72, 0x64, 0x1e, 0x71, 0x96, 0x8c, 0xf9, 0x3f, 0x28, 0x84, 0xeb, 0x20, 0x0c, 0x87, 0xaa,
+	0xe1, 0xa2, 0x54, 0x3d, 0xda, 0xac, 0x1a, 0x3c, 0xa2, 0x0b, 0x44, 0xb7, 0x4f, 0xa4, 0xb1, 0x7c,
+	0x32, 0xc4, 0x0e, 0xb9, 0x8a, 0x74, 0x2f, 0x3b, 0xe4, 0xc7, 0xb4, 0x43, 0x03, 0xd6, 0x86, 0x5e,
+	0xa3, 0xf0, 0xeb, 0x23, 0x98, 0xf0, 0x88, 0xdf, 0xb6, 0x58, 0xa0, 0x3f, 0xb8, 0x31, 0x75, 0x70,
+	0xe1, 0xfa, 0x67, 0xa6, 0x47, 0x29, 0xea, 0x07, 0x09, 0x66, 0x8d, 0xf1, 0xf7, 0x6c, 0xab, 0xcf,
+	0x1d, 0x29, 0xcb, 0x1d, 0x79, 0xe2, 0x01, 0x94, 0x6d, 0x4c, 0x29, 0xf1, 0xc2, 0x97, 0x23, 0x5c,
+	0x00, 0xb9, 0x07, 0x4f, 0x89, 0x57, 0x27, 0x0c, 0x07, 0x71, 0x1d, 0x42, 0x30, 0x7f, 0x54, 0x9e,
+	0xc2, 0x9c, 0x91, 0xb6, 0x9a, 0xdd, 0x13, 0xcc, 0x8c, 0x39, 0xc1, 0x97, 0x30, 0xff, 0xbc, 0x4d,
+	0xda, 0xe4, 0xf7, 0x2b, 0x54, 0x65, 0x58, 0xe8, 0x2f, 0x1d, 0x12, 0x55, 0xff, 0x87, 0xc5, 0x03,
+	0xc2, 0x7a, 0x19, 0x8d, 0x6c, 0xab, 0x9e, 0xc1, 0x7a, 0x7f, 0x46, 0x72, 0xd9, 0x77, 0x50, 0x1e,
+	0xf5, 0x10, 0x3c, 0x03, 0x79, 0x90, 0xc9, 0xaf, 0x8f, 0xb3, 0xf6, 0x3d, 0x0f, 0xe5, 0x53, 0x81,
+	0xa9, 0x63, 0x8a, 0x8e, 0xa1, 0x74, 0x40, 0x58, 0x28, 0x1f, 0xad, 0x24, 0xe9, 0x29, 0x6f, 0xb7,
+	0xb2, 0x3a, 0x2c, 0x2c, 0xa6, 0xf6, 0x07, 0xb2, 0xf9, 0x9b, 0x3c, 0xe0, 0x75, 0xb4, 0x99, 0x9e,
+	0x39, 0xf8, 0xaa, 0x29, 0x5b, 0x63, 0x20, 0xe3, 0x76, 0xc7, 0x50, 0x32, 0xd2, 0xc8, 0x1b, 0xa3,
+	0xc9, 0x1b, 0xe9, 0xe4, 0x4f, 0xe1, 0xaf, 0xb8, 0x9a, 0xc1, 0x3c, 0x82, 0xed, 0x7b, 0xd7, 0xdc,
+	0x94, 0x90, 0x0e, 0x65, 0x6e, 0x32, 0xc1, 0x72, 0x2d, 0x49, 0x49, 0xb5, 0xb5, 0x52, 0x19, 0x0e,
+	0x88, 0x99, 0xbe, 0x97, 0x60, 0xba, 0xdf, 0x15, 0x68, 0xbd, 0x67, 0x72, 0x69, 0xde, 0x55, 0xd4,
+	0x51, 0x10, 0x51, 0x7d, 0xfb, 0xdd, 0xd7, 0x6f, 0x1f, 0x33, 0x1b, 0xe8, 0x1f, 0xad, 0xb3, 0x73,
+	0x4e, 0x18, 0xde, 0xd1, 0x6c, 0x4c, 0x7d, 0xed, 0x26, 0x34, 0xee, 0xad, 0x16, 0xb8, 0xcd, 0x7f,
+	0x68, 0x61, 0x16, 0x18, 0xfa, 0xb3, 0x04, 0xca, 0x70, 0xdb, 0xa3, 0xed, 0xe1, 0xfd, 0x06, 0x96,
+	0x63, 0x2c, 0x72, 0x1a, 0x27, 0xb7, 0x85, 0xfe, 0x1d, 0x45, 0x4e, 0xbb, 0x89, 0xb6, 0xe7, 0x76,
+	0xbf, 0x06, 0x7f, 0x37, 0x5d, 0x3b, 0xfa, 0x83, 0xd2, 0xfb, 0xef, 0xda, 0xfe, 0x6c, 0xd7, 0x22,
+	0xec, 0x51, 0xf3, 0x24, 0x38, 0x3c, 0x91, 0xce, 0x0b, 0x3c, 0xba, 0xfb, 0x73, 0x00, 0x12, 0x5a,
+	0xbf, 0xbe, 0x00, 0x0a, 0x00, 0x00,
 }
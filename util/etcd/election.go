@@ -19,15 +19,38 @@ package etcd
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
 	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/util"
 )
 
+const treeIDLabel = "treeid"
+
+var (
+	once          sync.Once
+	campaigns     monitoring.Counter
+	isLeader      monitoring.Gauge
+	sessionsLost  monitoring.Counter
+	electionFails monitoring.Counter
+)
+
+func createMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	campaigns = mf.NewCounter("etcd_election_campaigns", "Number of campaigns started for mastership of the tree", treeIDLabel)
+	isLeader = mf.NewGauge("etcd_election_leader", "Whether this instance holds the tree's etcd election lock (0/1)", treeIDLabel)
+	sessionsLost = mf.NewCounter("etcd_election_sessions_lost", "Number of times the etcd session backing the tree's election expired or was lost", treeIDLabel)
+	electionFails = mf.NewCounter("etcd_election_errors", "Number of failed etcd election operations for the tree", treeIDLabel, "operation")
+}
+
 // MasterElection is an implementation of util.MasterElection based on etcd.
 type MasterElection struct {
 	instanceID string
@@ -36,6 +59,38 @@ type MasterElection struct {
 	client     *clientv3.Client
 	session    *concurrency.Session
 	election   *concurrency.Election
+	// closed is closed by Close, so that the end of the session isn't
+	// reported as a lost session.
+	closed chan struct{}
+}
+
+func (eme *MasterElection) label() string {
+	return strconv.FormatInt(eme.treeID, 10)
+}
+
+// failed meters err, if any, as a failure of the given election operation.
+func (eme *MasterElection) failed(op string, err error) error {
+	if err != nil {
+		electionFails.Inc(eme.label(), op)
+	}
+	return err
+}
+
+// watchSession waits for the session to end, and reports the lock as lost if
+// that happened before Close.
+func (eme *MasterElection) watchSession() {
+	select {
+	case <-eme.session.Done():
+	case <-eme.closed:
+		return
+	}
+	select {
+	case <-eme.closed:
+	default:
+		glog.Warningf("%d: etcd session for %s lost", eme.treeID, eme.lockFile)
+		sessionsLost.Inc(eme.label())
+		isLeader.Set(0, eme.label())
+	}
 }
 
 // Start commences election operation.
@@ -45,26 +100,40 @@ func (eme *MasterElection) Start(ctx context.Context) error {
 
 // WaitForMastership blocks until the current instance is master.
 func (eme *MasterElection) WaitForMastership(ctx context.Context) error {
-	return eme.election.Campaign(ctx, eme.instanceID)
+	campaigns.Inc(eme.label())
+	if err := eme.election.Campaign(ctx, eme.instanceID); err != nil {
+		return eme.failed("campaign", err)
+	}
+	isLeader.Set(1, eme.label())
+	return nil
 }
 
 // IsMaster returns whether the current instance is the master.
 func (eme *MasterElection) IsMaster(ctx context.Context) (bool, error) {
 	leader, err := eme.election.Leader(ctx)
 	if err != nil {
-		return false, err
+		return false, eme.failed("leader", err)
+	}
+	if leader != eme.instanceID {
+		isLeader.Set(0, eme.label())
+		return false, nil
 	}
-	return leader == eme.instanceID, nil
+	return true, nil
 }
 
 // ResignAndRestart releases mastership, and re-joins the election.
 func (eme *MasterElection) ResignAndRestart(ctx context.Context) error {
-	return eme.election.Resign(ctx)
+	if err := eme.election.Resign(ctx); err != nil {
+		return eme.failed("resign", err)
+	}
+	isLeader.Set(0, eme.label())
+	return nil
 }
 
 // Close terminates election operation.
 func (eme *MasterElection) Close(ctx context.Context) error {
 	_ = eme.ResignAndRestart(ctx)
+	close(eme.closed)
 	if err := eme.session.Close(); err != nil {
 		glog.Errorf("error closing session: %v", err)
 	}
@@ -76,15 +145,23 @@ type ElectionFactory struct {
 	instanceID string
 	servers    []string
 	lockDir    string
+	lockTTL    time.Duration
 }
 
 // NewElectionFactory builds an election factory that uses the given parameters.
 // The servers parameter should be a comma-separated list of etcd server URIs.
-func NewElectionFactory(instanceID string, servers, lockDir string) *ElectionFactory {
+// Election locks are held through etcd leases of the given TTL, rounded up to
+// a whole number of seconds, so that the lock of an instance that stops
+// renewing its lease (e.g. because it crashed or was partitioned from etcd)
+// passes on to another after at most lockTTL; a zero lockTTL uses etcd's
+// default.
+func NewElectionFactory(instanceID string, servers, lockDir string, lockTTL time.Duration, mf monitoring.MetricFactory) *ElectionFactory {
+	once.Do(func() { createMetrics(mf) })
 	return &ElectionFactory{
 		instanceID: instanceID,
 		servers:    strings.Split(servers, ","),
 		lockDir:    lockDir,
+		lockTTL:    lockTTL,
 	}
 }
 
@@ -98,7 +175,12 @@ func (ef ElectionFactory) NewElection(ctx context.Context, treeID int64) (util.M
 	if err != nil {
 		return nil, fmt.Errorf("failed to create etcd client: %v", err)
 	}
-	session, err := concurrency.NewSession(client)
+	var opts []concurrency.SessionOption
+	if ef.lockTTL > 0 {
+		ttl := (ef.lockTTL + time.Second - 1) / time.Second
+		opts = append(opts, concurrency.WithTTL(int(ttl)))
+	}
+	session, err := concurrency.NewSession(client, opts...)
 	if err != nil {
 		client.Close()
 		return nil, fmt.Errorf("failed to create etcd session: %v", err)
@@ -113,7 +195,9 @@ func (ef ElectionFactory) NewElection(ctx context.Context, treeID int64) (util.M
 		client:     client,
 		session:    session,
 		election:   election,
+		closed:     make(chan struct{}),
 	}
 	glog.Infof("MasterElection created: %+v", eme)
+	go eme.watchSession()
 	return &eme, nil
 }
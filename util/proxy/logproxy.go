@@ -16,6 +16,8 @@
 package proxy
 
 import (
+	"io"
+
 	"github.com/google/trillian"
 	"golang.org/x/net/context"
 )
@@ -48,6 +50,11 @@ func (p *Log) GetInclusionProof(ctx context.Context, in *trillian.GetInclusionPr
 	return p.c.GetInclusionProof(ctx, in)
 }
 
+// GetInclusionProofWithRoot forwards the RPC.
+func (p *Log) GetInclusionProofWithRoot(ctx context.Context, in *trillian.GetInclusionProofWithRootRequest) (*trillian.GetInclusionProofWithRootResponse, error) {
+	return p.c.GetInclusionProofWithRoot(ctx, in)
+}
+
 // GetInclusionProofByHash forwards the RPC.
 func (p *Log) GetInclusionProofByHash(ctx context.Context, in *trillian.GetInclusionProofByHashRequest) (*trillian.GetInclusionProofByHashResponse, error) {
 	return p.c.GetInclusionProofByHash(ctx, in)
@@ -58,11 +65,96 @@ func (p *Log) GetConsistencyProof(ctx context.Context, in *trillian.GetConsisten
 	return p.c.GetConsistencyProof(ctx, in)
 }
 
+// GetProofByMerkleHash forwards the RPC.
+func (p *Log) GetProofByMerkleHash(ctx context.Context, in *trillian.GetProofByMerkleHashRequest) (*trillian.GetProofByMerkleHashResponse, error) {
+	return p.c.GetProofByMerkleHash(ctx, in)
+}
+
 // GetLatestSignedLogRoot forwards the RPC.
 func (p *Log) GetLatestSignedLogRoot(ctx context.Context, in *trillian.GetLatestSignedLogRootRequest) (*trillian.GetLatestSignedLogRootResponse, error) {
 	return p.c.GetLatestSignedLogRoot(ctx, in)
 }
 
+// GetLatestCheckpoint forwards the RPC.
+func (p *Log) GetLatestCheckpoint(ctx context.Context, in *trillian.GetLatestCheckpointRequest) (*trillian.GetLatestCheckpointResponse, error) {
+	return p.c.GetLatestCheckpoint(ctx, in)
+}
+
+// HasLeaves forwards the RPC.
+func (p *Log) HasLeaves(ctx context.Context, in *trillian.HasLeavesRequest) (*trillian.HasLeavesResponse, error) {
+	return p.c.HasLeaves(ctx, in)
+}
+
+// GetLatestLeafByIdentityHashPrefix forwards the RPC.
+func (p *Log) GetLatestLeafByIdentityHashPrefix(ctx context.Context, in *trillian.GetLatestLeafByIdentityHashPrefixRequest) (*trillian.GetLatestLeafByIdentityHashPrefixResponse, error) {
+	return p.c.GetLatestLeafByIdentityHashPrefix(ctx, in)
+}
+
+// CheckConsistencyProof forwards the RPC.
+func (p *Log) CheckConsistencyProof(ctx context.Context, in *trillian.CheckConsistencyProofRequest) (*trillian.CheckConsistencyProofResponse, error) {
+	return p.c.CheckConsistencyProof(ctx, in)
+}
+
+// CountLeaves forwards the RPC.
+func (p *Log) CountLeaves(ctx context.Context, in *trillian.CountLeavesRequest) (*trillian.CountLeavesResponse, error) {
+	return p.c.CountLeaves(ctx, in)
+}
+
+// AddCosignature forwards the RPC.
+func (p *Log) AddCosignature(ctx context.Context, in *trillian.AddCosignatureRequest) (*trillian.AddCosignatureResponse, error) {
+	return p.c.AddCosignature(ctx, in)
+}
+
+// GetLatestCosignedLogRoot forwards the RPC.
+func (p *Log) GetLatestCosignedLogRoot(ctx context.Context, in *trillian.GetLatestCosignedLogRootRequest) (*trillian.GetLatestCosignedLogRootResponse, error) {
+	return p.c.GetLatestCosignedLogRoot(ctx, in)
+}
+
+// GetEntries forwards the RPC, relaying each streamed response.
+func (p *Log) GetEntries(in *trillian.GetEntriesRequest, stream trillian.TrillianLog_GetEntriesServer) error {
+	c, err := p.c.GetEntries(stream.Context(), in)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := c.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// GetSignedLogRootHistory forwards the RPC, relaying each streamed response.
+func (p *Log) GetSignedLogRootHistory(in *trillian.GetSignedLogRootHistoryRequest, stream trillian.TrillianLog_GetSignedLogRootHistoryServer) error {
+	c, err := p.c.GetSignedLogRootHistory(stream.Context(), in)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := c.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// GetSignedLogRootAtTime forwards the RPC.
+func (p *Log) GetSignedLogRootAtTime(ctx context.Context, in *trillian.GetSignedLogRootAtTimeRequest) (*trillian.GetSignedLogRootAtTimeResponse, error) {
+	return p.c.GetSignedLogRootAtTime(ctx, in)
+}
+
 // GetSequencedLeafCount forwards the RPC.
 func (p *Log) GetSequencedLeafCount(ctx context.Context, in *trillian.GetSequencedLeafCountRequest) (*trillian.GetSequencedLeafCountResponse, error) {
 	return p.c.GetSequencedLeafCount(ctx, in)